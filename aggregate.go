@@ -0,0 +1,148 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultAggregationWindow is the window size used by an Aggregator if no window is given
+const DefaultAggregationWindow = time.Minute
+
+// Aggregator counts log messages by hostname, app name and severity within fixed time
+// windows and emits a Summary for each of these combinations once a window is complete.
+// This allows for cheap metrics extraction from a stream of log messages without the
+// need to retain the messages themselves.
+//
+// An Aggregator is safe for concurrent use.
+type Aggregator struct {
+	counts map[AggregationKey]uint64
+	emit   func([]Summary)
+	mu     sync.Mutex
+	now    func() time.Time
+	start  time.Time
+	window time.Duration
+}
+
+// AggregationKey represents the combination of fields that log messages are grouped by
+type AggregationKey struct {
+	AppName  string
+	Hostname string
+	Severity Severity
+}
+
+// Summary represents the periodic summary record of an Aggregator for a single
+// AggregationKey
+type Summary struct {
+	AggregationKey
+	Count  uint64
+	End    time.Time
+	Start  time.Time
+	Window time.Duration
+}
+
+// NewAggregator returns a new Aggregator using the given window size. Whenever a window
+// is complete, the emit function is called with the summaries of that window. If the
+// window is 0 or negative, DefaultAggregationWindow is used.
+//
+// Windows are based on the time a message is added to the Aggregator, not on the
+// timestamp of the message itself, so that messages with skewed or missing timestamps
+// are still accounted for.
+func NewAggregator(window time.Duration, emit func([]Summary)) *Aggregator {
+	if window <= 0 {
+		window = DefaultAggregationWindow
+	}
+	return &Aggregator{
+		counts: make(map[AggregationKey]uint64),
+		emit:   emit,
+		now:    time.Now,
+		window: window,
+	}
+}
+
+// Add accounts the given LogMsg in the current window. If the current window is
+// complete, the summaries of it are emitted before the LogMsg is accounted.
+func (a *Aggregator) Add(lm LogMsg) {
+	a.mu.Lock()
+	sums := a.rotate(a.now())
+	a.counts[AggregationKey{AppName: lm.AppName, Hostname: lm.Hostname, Severity: lm.Severity}]++
+	a.mu.Unlock()
+	a.send(sums)
+}
+
+// Tick checks if the current window is complete and emits its summaries if so. It
+// should be called periodically (i. e. by a time.Ticker) so that summaries are emitted
+// even if no further messages are added
+func (a *Aggregator) Tick() {
+	a.mu.Lock()
+	sums := a.rotate(a.now())
+	a.mu.Unlock()
+	a.send(sums)
+}
+
+// Flush emits the summaries of the current window regardless if it is complete or
+// not. The remainder of the window is started as new window, so that the windows of the
+// summaries never overlap.
+func (a *Aggregator) Flush() {
+	a.mu.Lock()
+	sums := a.summaries(a.now())
+	a.mu.Unlock()
+	a.send(sums)
+}
+
+// rotate returns the summaries of the current window and starts a new one, if the
+// current window is complete at time t. It needs to be called with a.mu held.
+func (a *Aggregator) rotate(t time.Time) []Summary {
+	ws := t.Truncate(a.window)
+	if a.start.IsZero() {
+		a.start = ws
+		return nil
+	}
+	if t.Before(a.end()) {
+		return nil
+	}
+	return a.summaries(ws)
+}
+
+// end returns the end of the current window. After a Flush, the current window starts
+// in the middle of a window, but still ends at the end of it.
+func (a *Aggregator) end() time.Time {
+	return a.start.Truncate(a.window).Add(a.window)
+}
+
+// summaries returns the summaries of the current window, ending at t, resets the
+// counters and sets t as start of the next window. It needs to be called with a.mu held.
+func (a *Aggregator) summaries(t time.Time) []Summary {
+	var sums []Summary
+	end := a.end()
+	if t.Before(end) {
+		end = t
+	}
+	for k, c := range a.counts {
+		sums = append(sums, Summary{AggregationKey: k, Count: c, End: end, Start: a.start, Window: a.window})
+		delete(a.counts, k)
+	}
+	sort.Slice(sums, func(i, j int) bool {
+		if sums[i].Hostname != sums[j].Hostname {
+			return sums[i].Hostname < sums[j].Hostname
+		}
+		if sums[i].AppName != sums[j].AppName {
+			return sums[i].AppName < sums[j].AppName
+		}
+		return sums[i].Severity < sums[j].Severity
+	})
+	a.start = t
+	return sums
+}
+
+// send hands the summaries to the emit function, if there is anything to emit
+func (a *Aggregator) send(sums []Summary) {
+	if len(sums) == 0 || a.emit == nil {
+		return
+	}
+	a.emit(sums)
+}
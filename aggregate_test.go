@@ -0,0 +1,113 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"testing"
+	"time"
+)
+
+// TestAggregator_Add tests the Add method of the Aggregator
+func TestAggregator_Add(t *testing.T) {
+	var sums []Summary
+	a := NewAggregator(time.Minute, func(s []Summary) { sums = append(sums, s...) })
+	ts := time.Date(2023, 10, 11, 22, 14, 0, 0, time.UTC)
+	a.now = func() time.Time { return ts }
+
+	msgs := []LogMsg{
+		{Hostname: "host1", AppName: "su", Severity: 5},
+		{Hostname: "host1", AppName: "su", Severity: 5},
+		{Hostname: "host1", AppName: "sshd", Severity: 6},
+		{Hostname: "host2", AppName: "su", Severity: 5},
+	}
+	for _, lm := range msgs {
+		a.Add(lm)
+	}
+	if len(sums) != 0 {
+		t.Errorf("Aggregator emitted summaries before the window was complete")
+	}
+
+	ts = ts.Add(time.Minute + time.Second)
+	a.Add(LogMsg{Hostname: "host3"})
+	if len(sums) != 3 {
+		t.Fatalf("Aggregator summary count => expected: %d, got: %d", 3, len(sums))
+	}
+	want := []struct {
+		host  string
+		app   string
+		count uint64
+	}{{"host1", "sshd", 1}, {"host1", "su", 2}, {"host2", "su", 1}}
+	for i, w := range want {
+		if sums[i].Hostname != w.host || sums[i].AppName != w.app || sums[i].Count != w.count {
+			t.Errorf("Aggregator summary %d => expected: %s/%s/%d, got: %s/%s/%d", i, w.host, w.app,
+				w.count, sums[i].Hostname, sums[i].AppName, sums[i].Count)
+		}
+		if !sums[i].Start.Equal(time.Date(2023, 10, 11, 22, 14, 0, 0, time.UTC)) {
+			t.Errorf("Aggregator summary %d wrong start time: %s", i, sums[i].Start)
+		}
+	}
+
+	sums = nil
+	a.Flush()
+	if len(sums) != 1 || sums[0].Hostname != "host3" {
+		t.Errorf("Aggregator.Flush() => expected summary for host3, got: %+v", sums)
+	}
+}
+
+// TestAggregator_Flush tests that a Flush in the middle of a window does not result in
+// overlapping windows
+func TestAggregator_Flush(t *testing.T) {
+	var sums []Summary
+	a := NewAggregator(time.Minute, func(s []Summary) { sums = append(sums, s...) })
+	ts := time.Date(2023, 10, 11, 22, 14, 0, 0, time.UTC)
+	a.now = func() time.Time { return ts }
+
+	a.Add(LogMsg{Hostname: "host1"})
+	ts = ts.Add(time.Second * 20)
+	a.Flush()
+	ts = ts.Add(time.Second * 20)
+	a.Add(LogMsg{Hostname: "host1"})
+	a.Tick()
+	ts = ts.Add(time.Second * 25)
+	a.Add(LogMsg{Hostname: "host2"})
+	if len(sums) != 2 {
+		t.Fatalf("Aggregator summary count => expected: %d, got: %d", 2, len(sums))
+	}
+	want := []struct {
+		start time.Time
+		end   time.Time
+	}{
+		{time.Date(2023, 10, 11, 22, 14, 0, 0, time.UTC), time.Date(2023, 10, 11, 22, 14, 20, 0, time.UTC)},
+		{time.Date(2023, 10, 11, 22, 14, 20, 0, time.UTC), time.Date(2023, 10, 11, 22, 15, 0, 0, time.UTC)},
+	}
+	for i, w := range want {
+		if !sums[i].Start.Equal(w.start) || !sums[i].End.Equal(w.end) || sums[i].Count != 1 {
+			t.Errorf("Aggregator summary %d => expected: %s - %s (1), got: %s - %s (%d)", i, w.start, w.end,
+				sums[i].Start, sums[i].End, sums[i].Count)
+		}
+	}
+}
+
+// TestAggregator_Tick tests the Tick method of the Aggregator
+func TestAggregator_Tick(t *testing.T) {
+	var sums []Summary
+	a := NewAggregator(0, func(s []Summary) { sums = append(sums, s...) })
+	if a.window != DefaultAggregationWindow {
+		t.Errorf("NewAggregator() default window => expected: %s, got: %s", DefaultAggregationWindow,
+			a.window)
+	}
+	ts := time.Date(2023, 10, 11, 22, 14, 30, 0, time.UTC)
+	a.now = func() time.Time { return ts }
+	a.Add(LogMsg{Hostname: "host1"})
+	a.Tick()
+	if len(sums) != 0 {
+		t.Errorf("Aggregator.Tick() emitted summaries before the window was complete")
+	}
+	ts = ts.Add(time.Minute)
+	a.Tick()
+	if len(sums) != 1 {
+		t.Errorf("Aggregator.Tick() summary count => expected: %d, got: %d", 1, len(sums))
+	}
+}
@@ -0,0 +1,147 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Allowlist is a pre-parse filter for collectors that only care about a subset of the
+// senders on a shared feed. It locates the HOSTNAME and the APP-NAME (RFC5424) or the TAG
+// (RFC3164) in the raw bytes of a log message and matches them against compiled lists of
+// allowed values, so that unwanted log messages can be dropped without parsing them.
+//
+// An Allowlist is safe for concurrent use.
+type Allowlist struct {
+	apps  allowMatcher
+	hosts allowMatcher
+}
+
+// allowMatcher matches a raw value against exact values and prefixes. A matcher without
+// values and prefixes matches everything.
+type allowMatcher struct {
+	exact    map[string]struct{}
+	prefixes [][]byte
+}
+
+// NewAllowlist returns a new Allowlist for the given hostnames and app names. A value
+// that ends with "*" matches all values with the given prefix (i. e. "fw*"). An empty
+// list allows all hostnames or app names respectively.
+func NewAllowlist(hosts, apps []string) *Allowlist {
+	return &Allowlist{apps: newAllowMatcher(apps), hosts: newAllowMatcher(hosts)}
+}
+
+// newAllowMatcher compiles the given values to an allowMatcher
+func newAllowMatcher(vs []string) allowMatcher {
+	var m allowMatcher
+	for _, v := range vs {
+		if strings.HasSuffix(v, "*") {
+			m.prefixes = append(m.prefixes, []byte(strings.TrimSuffix(v, "*")))
+			continue
+		}
+		if m.exact == nil {
+			m.exact = make(map[string]struct{})
+		}
+		m.exact[v] = struct{}{}
+	}
+	return m
+}
+
+// match returns true if the given raw value is allowed
+func (m allowMatcher) match(b []byte) bool {
+	if m.exact == nil && m.prefixes == nil {
+		return true
+	}
+	if _, ok := m.exact[string(b)]; ok {
+		return true
+	}
+	for _, p := range m.prefixes {
+		if bytes.HasPrefix(b, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match returns true if the given raw log message (RFC5424 or RFC3164, optionally
+// prefixed with its octet count) comes from an allowed host and app. Log messages whose
+// HOSTNAME or APP-NAME/TAG can not be located are allowed, so that the parser can report
+// them.
+func (a *Allowlist) Match(b []byte) bool {
+	host, app, ok := rawHostApp(b)
+	if !ok {
+		return true
+	}
+	return a.hosts.match(host) && a.apps.match(app)
+}
+
+// rawHostApp returns the raw HOSTNAME and APP-NAME/TAG of the given log message. The
+// returned bool is false, if they could not be located.
+func rawHostApp(b []byte) ([]byte, []byte, bool) {
+	if len(b) > 0 && b[0] >= '0' && b[0] <= '9' {
+		i := bytes.IndexByte(b, ' ')
+		if i < 0 {
+			return nil, nil, false
+		}
+		b = b[i+1:]
+	}
+	if len(b) == 0 || b[0] != '<' {
+		return nil, nil, false
+	}
+	i := bytes.IndexByte(b, '>')
+	if i < 0 {
+		return nil, nil, false
+	}
+	b = b[i+1:]
+
+	// RFC5424: VERSION SP TIMESTAMP SP HOSTNAME SP APP-NAME SP
+	v := 0
+	for v < len(b) && v < 3 && b[v] >= '0' && b[v] <= '9' {
+		v++
+	}
+	if v > 0 && b[0] != '0' && v < len(b) && b[v] == ' ' {
+		var f [4][]byte
+		for n := range f {
+			i := bytes.IndexByte(b, ' ')
+			if i < 0 {
+				return nil, nil, false
+			}
+			f[n], b = b[:i], b[i+1:]
+		}
+		return f[2], f[3], true
+	}
+
+	// RFC3164: TIMESTAMP SP HOSTNAME SP TAG, where the TAG ends with '[', ':' or SP. The
+	// TIMESTAMP is either a BSD timestamp, optionally with a year, or an RFC3339 timestamp.
+	switch {
+	case v == 3 && len(b) > 4 && b[3] >= '0' && b[3] <= '9' && b[4] == '-':
+		i = bytes.IndexByte(b, ' ')
+		if i < 0 {
+			return nil, nil, false
+		}
+		b = b[i+1:]
+	case len(b) >= 16 && b[3] == ' ' && b[6] == ' ' && b[9] == ':' && b[12] == ':' && b[15] == ' ':
+		b = b[16:]
+	case len(b) >= 21 && b[3] == ' ' && b[6] == ' ' && b[11] == ' ' && b[14] == ':' && b[17] == ':' && b[20] == ' ':
+		b = b[21:]
+	default:
+		return nil, nil, false
+	}
+	i = bytes.IndexByte(b, ' ')
+	if i < 0 {
+		return nil, nil, false
+	}
+	host, b := b[:i], b[i+1:]
+	if i > 1 && host[i-1] == ':' && host[i-2] != ':' {
+		// Local log messages (i. e. written to /dev/log) lack the HOSTNAME
+		host, b = nil, host
+	}
+	i = bytes.IndexAny(b, "[: ")
+	if i < 0 {
+		return nil, nil, false
+	}
+	return host, b[:i], true
+}
@@ -0,0 +1,48 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"testing"
+)
+
+// TestAllowlist_Match tests matching raw log messages against an Allowlist
+func TestAllowlist_Match(t *testing.T) {
+	a := NewAllowlist([]string{"mymachine", "fw*"}, []string{"su", "sshd"})
+	tests := []struct {
+		name string
+		msg  string
+		want bool
+	}{
+		{"RFC5424 allowed", "<165>1 2003-10-11T22:14:15.003Z mymachine su - ID47 - test", true},
+		{"RFC5424 with octet count", "57 <165>1 2003-10-11T22:14:15.003Z fw01 sshd - ID47 - test", true},
+		{"RFC5424 wrong host", "<165>1 2003-10-11T22:14:15.003Z web01 su - ID47 - test", false},
+		{"RFC5424 wrong app", "<165>1 2003-10-11T22:14:15.003Z mymachine cron - ID47 - test", false},
+		{"RFC5424 host prefix only", "<165>1 2003-10-11T22:14:15.003Z fw sshd - - - test", true},
+		{"RFC3164 allowed", "<34>Oct 11 22:14:15 mymachine su: 'su root' failed", true},
+		{"RFC3164 with PID", "<34>Oct  1 22:14:15 fw02 sshd[123]: Accepted", true},
+		{"RFC3164 wrong host", "<34>Oct 11 22:14:15 web01 su: 'su root' failed", false},
+		{"RFC3164 wrong tag", "<34>Oct 11 22:14:15 mymachine cron[1]: job", false},
+		{"no PRI", "garbage", true},
+		{"truncated header", "<165>1 2003-10-11T22:14:15.003Z mymachine", true},
+		{"RFC3164 without hostname", "<13>Oct 11 22:14:15 su[1]: test", false},
+		{"RFC3164 with year", "<34>Oct 11 2023 22:14:15 web01 su: test", false},
+		{"RFC3164 with RFC3339 timestamp", "<34>2003-10-11T22:14:15.003+02:00 fw01 sshd[1]: test", true},
+		{"RFC3164 with RFC3339 timestamp wrong host", "<34>2003-10-11T22:14:15Z web01 su: test", false},
+		{"unknown timestamp", "<34>11.10.2003 web01 su: test", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := a.Match([]byte(tt.msg)); got != tt.want {
+				t.Errorf("Match() => expected: %t, got: %t", tt.want, got)
+			}
+		})
+	}
+
+	all := NewAllowlist(nil, nil)
+	if !all.Match([]byte("<165>1 2003-10-11T22:14:15.003Z web01 cron - - - test")) {
+		t.Error("Match() of empty Allowlist => expected all messages to be allowed")
+	}
+}
@@ -0,0 +1,204 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+// Package auto implements a go-parsesyslog parser that detects the format of each
+// log message and hands it to the corresponding parser
+package auto
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/wneessen/go-parsesyslog"
+	"github.com/wneessen/go-parsesyslog/rfc3164"
+	"github.com/wneessen/go-parsesyslog/rfc5424"
+)
+
+// sniffLength is the amount of bytes that are inspected to detect the log format
+const sniffLength = 32
+
+// msg represents a parser that detects the format of a log message
+type msg struct {
+	lr      io.LimitedReader
+	lbr     *bufio.Reader
+	opts    parsesyslog.Options
+	parsers map[parsesyslog.ParserType]parsesyslog.Parser
+}
+
+// Type represents the ParserType for this Parser
+const Type parsesyslog.ParserType = "auto"
+
+// init registers the Parser
+func init() {
+	fn := func(o parsesyslog.Options) (parsesyslog.Parser, error) {
+		return &msg{opts: o, parsers: make(map[parsesyslog.ParserType]parsesyslog.Parser)}, nil
+	}
+	parsesyslog.RegisterWithOptions(Type, fn)
+}
+
+// Sniff inspects the beginning of a log message and returns the ParserType of the
+// format it detected. An optional octet-count prefix is skipped. If a PRI is followed
+// by a VERSION, the message is considered RFC5424, otherwise (i. e. if the PRI is
+// followed by a BSD timestamp) RFC3164. The returned bool is false if the format could
+// not be detected.
+func Sniff(b []byte) (parsesyslog.ParserType, bool) {
+	b = skipLength(b)
+	if len(b) == 0 || b[0] != '<' {
+		return "", false
+	}
+	i := 1
+	for i < len(b) && i <= 4 && isDigit(b[i]) {
+		i++
+	}
+	if i == 1 || i >= len(b) || b[i] != '>' {
+		return "", false
+	}
+	b = b[i+1:]
+
+	// VERSION = NONZERO-DIGIT 0*2DIGIT, followed by SP
+	// See: https://datatracker.ietf.org/doc/html/rfc5424#section-6
+	v := 0
+	for v < len(b) && v < 3 && isDigit(b[v]) {
+		v++
+	}
+	if v > 0 && b[0] != '0' && v < len(b) && b[v] == ' ' {
+		return rfc5424.Type, true
+	}
+	return rfc3164.Type, true
+}
+
+// ParseString returns the parsed log message read from a string (as buffered i/o)
+func (m *msg) ParseString(s string) (parsesyslog.LogMsg, error) {
+	return m.ParseReader(bufio.NewReader(strings.NewReader(s)))
+}
+
+// ParseBytes returns the parsed log message read from a byte slice. Messages without an
+// octet-count prefix are handed to the ParseBytes method of the corresponding parser (see
+// parsesyslog.ParseBytes). It satisfies the parsesyslog.BytesParser interface
+func (m *msg) ParseBytes(b []byte) (parsesyslog.LogMsg, error) {
+	if len(b) == 0 || b[0] != '<' {
+		return m.ParseReader(bytes.NewReader(b))
+	}
+	t, ok := Sniff(b)
+	if !ok {
+		return parsesyslog.LogMsg{}, parsesyslog.ErrWrongFormat
+	}
+	p, err := m.parser(t)
+	if err != nil {
+		return parsesyslog.LogMsg{}, err
+	}
+	return parsesyslog.ParseBytes(p, b)
+}
+
+// ParseReader detects the format of the log message and hands it to the
+// corresponding parser. It satisfies the Parser interface
+func (m *msg) ParseReader(r io.Reader) (parsesyslog.LogMsg, error) {
+	var l parsesyslog.LogMsg
+	err := m.ParseReaderInto(r, &l)
+	return l, err
+}
+
+// ParseReaderInto works like ParseReader, but parses the log message into the given
+// LogMsg. It satisfies the parsesyslog.ReusingParser interface
+func (m *msg) ParseReaderInto(r io.Reader, l *parsesyslog.LogMsg) error {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	b, err := br.Peek(sniffLength)
+	if err != nil && (!errors.Is(err, io.EOF) || len(b) == 0) {
+		return err
+	}
+	t, ok := m.sniff(b)
+	if !ok {
+		l.Reset()
+		return parsesyslog.ErrWrongFormat
+	}
+	p, err := m.parser(t)
+	if err != nil {
+		return err
+	}
+
+	// The octet-count prefix is consumed here, so that parsers without support for
+	// it (like RFC3164) are handed the message only
+	if hasLength(b) {
+		ml, err := parsesyslog.ReadMsgLength(br)
+		if err != nil {
+			return err
+		}
+		m.lr = io.LimitedReader{R: br, N: int64(ml)}
+		if m.lbr == nil {
+			m.lbr = bufio.NewReader(&m.lr)
+		}
+		m.lbr.Reset(&m.lr)
+		br = m.lbr
+		defer func() {
+			_, _ = io.Copy(io.Discard, m.lbr)
+		}()
+	}
+
+	if rp, ok := p.(parsesyslog.ReusingParser); ok {
+		return rp.ParseReaderInto(br, l)
+	}
+	lm, err := p.ParseReader(br)
+	*l = lm
+	return err
+}
+
+// parser returns the parser of the given ParserType, which is created on first use
+func (m *msg) parser(t parsesyslog.ParserType) (parsesyslog.Parser, error) {
+	if p, ok := m.parsers[t]; ok {
+		return p, nil
+	}
+	p, err := parsesyslog.NewWithOptions(t, m.opts)
+	if err != nil {
+		return nil, err
+	}
+	m.parsers[t] = p
+	return p, nil
+}
+
+// sniff works like Sniff, but considers log messages without PRI as RFC3164, if a
+// default priority has been set with WithDefaultPriority, since only the RFC3164 parser
+// accepts them
+func (m *msg) sniff(b []byte) (parsesyslog.ParserType, bool) {
+	if t, ok := Sniff(b); ok {
+		return t, true
+	}
+	if m.opts.UseDefaultPriority && len(b) > 0 && b[0] != '<' && !hasLength(b) {
+		return rfc3164.Type, true
+	}
+	return "", false
+}
+
+// skipLength skips the octet-count prefix (MSG-LEN SP) of a message, if present
+func skipLength(b []byte) []byte {
+	i := 0
+	for i < len(b) && isDigit(b[i]) {
+		i++
+	}
+	if i > 0 && i < len(b) && b[i] == ' ' {
+		return b[i+1:]
+	}
+	return b
+}
+
+// hasLength returns true if the message starts with an octet-count prefix (MSG-LEN SP)
+// that is followed by a PRI. Other leading digits (i. e. of a timestamp or a sequence
+// number of a message without PRI) are part of the message.
+func hasLength(b []byte) bool {
+	i := 0
+	for i < len(b) && isDigit(b[i]) {
+		i++
+	}
+	return i > 0 && i+1 < len(b) && b[i] == ' ' && b[i+1] == '<'
+}
+
+// isDigit returns true if the given byte is an ASCII digit
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
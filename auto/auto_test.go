@@ -0,0 +1,178 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package auto
+
+import (
+	"bufio"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/wneessen/go-parsesyslog"
+	"github.com/wneessen/go-parsesyslog/corpus"
+	"github.com/wneessen/go-parsesyslog/rfc3164"
+	"github.com/wneessen/go-parsesyslog/rfc5424"
+)
+
+// TestSniff tests the Sniff method
+func TestSniff(t *testing.T) {
+	tests := []struct {
+		name   string
+		msg    string
+		want   parsesyslog.ParserType
+		wantOK bool
+	}{
+		{"RFC5424", "<165>1 2003-10-11T22:14:15.003Z host app - - - msg", rfc5424.Type, true},
+		{"RFC5424 with length", "52 <165>1 2003-10-11T22:14:15.003Z host app - - - msg", rfc5424.Type, true},
+		{"RFC5424 two digit version", "<165>12 2003-10-11T22:14:15.003Z", rfc5424.Type, true},
+		{"RFC3164", "<13>Nov 27 16:00:35 arch-vm wneessen[1130275]: test", rfc3164.Type, true},
+		{"RFC3164 with length", "45 <13>Nov 27 16:00:35 arch-vm wneessen: test", rfc3164.Type, true},
+		{"RFC3164 Cisco prefix", "<189>42: Nov 27 16:00:35 host: %LINK-3-UPDOWN", rfc3164.Type, true},
+		{"zero version", "<13>0 foo", rfc3164.Type, true},
+		{"no PRI", "Nov 27 16:00:35 arch-vm test", "", false},
+		{"unterminated PRI", "<13", "", false},
+		{"empty PRI", "<>1 foo", "", false},
+		{"empty", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pt, ok := Sniff([]byte(tt.msg))
+			if ok != tt.wantOK {
+				t.Errorf("Sniff() ok => expected: %t, got: %t", tt.wantOK, ok)
+			}
+			if pt != tt.want {
+				t.Errorf("Sniff() => expected: %s, got: %s", tt.want, pt)
+			}
+		})
+	}
+}
+
+// TestSniff_corpus tests that Sniff detects the format of all samples of the corpus
+func TestSniff_corpus(t *testing.T) {
+	for _, s := range corpus.All() {
+		if pt, ok := Sniff(s.Data); !ok || pt != s.Format {
+			t.Errorf("%s:%d => Sniff() expected: %s, got: %s (%t)", s.Category, s.Line, s.Format, pt, ok)
+		}
+	}
+}
+
+// TestParseReader tests the auto parser with a stream of mixed log messages
+func TestParseReader(t *testing.T) {
+	p, err := parsesyslog.New(Type)
+	if err != nil {
+		t.Fatalf("failed to create new auto parser: %s", err)
+	}
+	in := "<13>Nov 27 16:00:35 arch-vm wneessen[1130275]: test\n" +
+		"54 <165>1 2003-10-11T22:14:15.003Z host1 app1 - - - first" +
+		"38 <13>Nov 27 16:00:35 arch-vm su: second"
+	br := bufio.NewReader(strings.NewReader(in))
+	want := []struct {
+		host string
+		app  string
+		msg  string
+	}{
+		{"arch-vm", "wneessen", "test\n"},
+		{"host1", "app1", "first"},
+		{"arch-vm", "su", "second"},
+	}
+	for i, w := range want {
+		l, err := p.ParseReader(br)
+		if err != nil {
+			t.Fatalf("ParseReader() message %d failed: %s", i, err)
+		}
+		if l.Hostname != w.host || l.AppName != w.app || l.Message.String() != w.msg {
+			t.Errorf("ParseReader() message %d => expected: %s/%s/%q, got: %s/%s/%q", i, w.host, w.app,
+				w.msg, l.Hostname, l.AppName, l.Message.String())
+		}
+	}
+}
+
+// TestParseString_unknown tests the auto parser with a message of unknown format
+func TestParseString_unknown(t *testing.T) {
+	p, err := parsesyslog.New(Type)
+	if err != nil {
+		t.Fatalf("failed to create new auto parser: %s", err)
+	}
+	if _, err := p.ParseString("this is not syslog"); !errors.Is(err, parsesyslog.ErrWrongFormat) {
+		t.Errorf("ParseString() => expected error: %s, got: %s", parsesyslog.ErrWrongFormat, err)
+	}
+}
+
+// TestParseString_withDefaultPriority tests that the auto parser hands log messages
+// without PRI to the RFC3164 parser, if a default priority has been set
+func TestParseString_withDefaultPriority(t *testing.T) {
+	p, err := parsesyslog.New(Type, parsesyslog.WithDefaultPriority(13))
+	if err != nil {
+		t.Fatalf("failed to create new auto parser: %s", err)
+	}
+	tests := []struct {
+		name string
+		msg  string
+		prio parsesyslog.Priority
+		host string
+	}{
+		{"without PRI", "Nov 27 16:00:35 arch-vm kernel: test", 13, "arch-vm"},
+		{"with timestamp", "2023-10-11T22:14:15.003+02:00 arch-vm kernel: test", 13, "arch-vm"},
+		{"with octet-count", "40 <34>Nov 27 16:00:35 arch-vm kernel: test", 34, "arch-vm"},
+		{"with PRI", "<34>Nov 27 16:00:35 arch-vm kernel: test", 34, "arch-vm"},
+		{"RFC5424", "<165>1 2003-10-11T22:14:15.003Z host1 app1 - - - test", 165, "host1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := p.ParseString(tt.msg)
+			if err != nil {
+				t.Fatalf("ParseString() failed: %s", err)
+			}
+			if l.Priority != tt.prio || l.Hostname != tt.host {
+				t.Errorf("ParseString() => expected priority %d and host %s, got: %d and %s", tt.prio,
+					tt.host, l.Priority, l.Hostname)
+			}
+		})
+	}
+
+	p, err = parsesyslog.New(Type)
+	if err != nil {
+		t.Fatalf("failed to create new auto parser: %s", err)
+	}
+	if _, err := p.ParseString(tests[0].msg); !errors.Is(err, parsesyslog.ErrWrongFormat) {
+		t.Errorf("ParseString() => expected error: %s, got: %s", parsesyslog.ErrWrongFormat, err)
+	}
+}
+
+// TestParseBytes tests the auto parser with log messages in byte slices
+func TestParseBytes(t *testing.T) {
+	p, err := parsesyslog.New(Type)
+	if err != nil {
+		t.Fatalf("failed to create new auto parser: %s", err)
+	}
+	for _, msg := range []string{
+		"<13>Nov 27 16:00:35 arch-vm wneessen[1130275]: test\n",
+		"<165>1 2003-10-11T22:14:15.003Z host1 app1 - - - first",
+		"54 <165>1 2003-10-11T22:14:15.003Z host1 app1 - - - first",
+		"38 <13>Nov 27 16:00:35 arch-vm su: second",
+		"<",
+		"this is not syslog",
+	} {
+		want, wantErr := p.ParseString(msg)
+		got, err := parsesyslog.ParseBytes(p, []byte(msg))
+		if !errors.Is(err, wantErr) {
+			t.Errorf("ParseBytes(%q) => expected error: %v, got: %v", msg, wantErr, err)
+		}
+		for _, d := range parsesyslog.Diff(want, got) {
+			t.Errorf("ParseBytes(%q) => %s", msg, d)
+		}
+	}
+}
+
+// TestSelfTest tests that the auto parser passes the self-test for both formats
+func TestSelfTest(t *testing.T) {
+	p, err := parsesyslog.New(Type)
+	if err != nil {
+		t.Fatalf("failed to create new auto parser: %s", err)
+	}
+	if f := parsesyslog.SelfTest(p); len(f) != 0 {
+		t.Errorf("SelfTest() => expected no failures, got: %v", f)
+	}
+}
@@ -0,0 +1,90 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BodyDecoder decodes a vendor specific message body of a parsed LogMsg (i. e. the
+// key=value pairs of a firewall log) and stores the decoded fields as structured data.
+// It returns true if it has recognized the message body. If it returns false, the
+// LogMsg has to be left unchanged, so that the next BodyDecoder can be tried.
+// See: WithBodyDecoders
+type BodyDecoder func(lm *LogMsg) bool
+
+// ParseKeyValues parses the given string of whitespace separated key=value pairs, as
+// used by many network devices (i. e. `date=2023-01-10 devname="fw 1" level=notice`).
+// Values may be enclosed in double quotes, which allows whitespace inside of them and
+// backslash escapes of double quotes and backslashes. Keys without a value (i. e.
+// `debug`) are returned with an empty value. The pairs are returned in the order of
+// the string.
+//
+// If the string is not a sequence of key=value pairs (i. e. because of an unterminated
+// quoted value), ErrWrongFormat is returned.
+func ParseKeyValues(s string) ([]StructuredDataParam, error) {
+	var ps []StructuredDataParam
+	var sb strings.Builder
+	i := 0
+	for {
+		for i < len(s) && isKVSpace(s[i]) {
+			i++
+		}
+		if i >= len(s) {
+			return ps, nil
+		}
+		ks := i
+		for i < len(s) && s[i] != '=' && !isKVSpace(s[i]) {
+			if s[i] == '"' {
+				return nil, fmt.Errorf("%w: quote in key at position %d", ErrWrongFormat, i)
+			}
+			i++
+		}
+		p := StructuredDataParam{Name: s[ks:i]}
+		if i >= len(s) || s[i] != '=' {
+			ps = append(ps, p)
+			continue
+		}
+		if i == ks {
+			return nil, fmt.Errorf("%w: empty key at position %d", ErrWrongFormat, i)
+		}
+		i++
+		if i < len(s) && s[i] == '"' {
+			i++
+			sb.Reset()
+			closed := false
+			for i < len(s) && !closed {
+				switch c := s[i]; {
+				case c == '\\' && i+1 < len(s) && (s[i+1] == '"' || s[i+1] == '\\'):
+					sb.WriteByte(s[i+1])
+					i += 2
+				case c == '"':
+					closed = true
+					i++
+				default:
+					sb.WriteByte(c)
+					i++
+				}
+			}
+			if !closed {
+				return nil, fmt.Errorf("%w: unterminated value of key %q", ErrWrongFormat, p.Name)
+			}
+			p.Value = sb.String()
+		} else {
+			vs := i
+			for i < len(s) && !isKVSpace(s[i]) {
+				i++
+			}
+			p.Value = s[vs:i]
+		}
+		ps = append(ps, p)
+	}
+}
+
+// isKVSpace returns true if the given byte separates key=value pairs
+func isKVSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
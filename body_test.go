@@ -0,0 +1,48 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// TestParseKeyValues tests the ParseKeyValues function
+func TestParseKeyValues(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want []StructuredDataParam
+		err  error
+	}{
+		{"empty", "", nil, nil},
+		{"single pair", "level=notice", []StructuredDataParam{{"level", "notice"}}, nil},
+		{
+			"multiple pairs", " date=2023-01-10\ttime=11:37:47  level=notice\n",
+			[]StructuredDataParam{{"date", "2023-01-10"}, {"time", "11:37:47"}, {"level", "notice"}}, nil,
+		},
+		{
+			"quoted value", `devname="fw 01" msg="said \"hi\" \\o/"`,
+			[]StructuredDataParam{{"devname", "fw 01"}, {"msg", `said "hi" \o/`}}, nil,
+		},
+		{"empty values", `a= b="" c`, []StructuredDataParam{{"a", ""}, {"b", ""}, {"c", ""}}, nil},
+		{"value with equal sign", "url=/?a=b", []StructuredDataParam{{"url", "/?a=b"}}, nil},
+		{"unterminated quote", `msg="open`, nil, ErrWrongFormat},
+		{"quote in key", `"msg"=x`, nil, ErrWrongFormat},
+		{"empty key", "=x", nil, ErrWrongFormat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ps, err := ParseKeyValues(tt.s)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ParseKeyValues() error => expected: %v, got: %v", tt.err, err)
+			}
+			if !reflect.DeepEqual(ps, tt.want) {
+				t.Errorf("ParseKeyValues() => expected: %v, got: %v", tt.want, ps)
+			}
+		})
+	}
+}
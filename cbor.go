@@ -0,0 +1,253 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+)
+
+// CBOR major types
+// See: https://datatracker.ietf.org/doc/html/rfc8949#section-3.1
+const (
+	cborUint   = 0
+	cborNegInt = 1
+	cborBytes  = 2
+	cborText   = 3
+	cborArray  = 4
+	cborMap    = 5
+	cborTag    = 6
+	cborSimple = 7
+)
+
+// CBOR simple values
+const (
+	cborFalse = 0xf4
+	cborTrue  = 0xf5
+	cborNull  = 0xf6
+)
+
+// maxSerialDepth is the maximum nesting depth the binary decoders accept
+const maxSerialDepth = 16
+
+// MarshalCBOR returns the CBOR representation of the LogMsg. It uses the same field
+// names and SchemaVersion as the JSON representation and is meant for bandwidth
+// sensitive forwarding between collectors.
+// See: https://datatracker.ietf.org/doc/html/rfc8949
+func (l LogMsg) MarshalCBOR() ([]byte, error) {
+	var buf bytes.Buffer
+	f := l.serialFields()
+	cborHeader(&buf, cborMap, uint64(len(f)))
+	for _, kv := range f {
+		cborValue(&buf, kv.key)
+		cborValue(&buf, kv.val)
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalCBOR sets the LogMsg to the given CBOR representation. Data of a newer
+// SchemaVersion is rejected with ErrUnsupportedSchemaVersion.
+func (l *LogMsg) UnmarshalCBOR(b []byte) error {
+	d := &cborDecoder{b: b}
+	v, err := d.value(0)
+	if err != nil {
+		return err
+	}
+	if d.p != len(b) {
+		return fmt.Errorf("%w: trailing data", ErrInvalidEncoding)
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("%w: not a map", ErrInvalidEncoding)
+	}
+	return l.fromSerialMap(m)
+}
+
+// cborHeader writes the head of a CBOR data item with the given major type and argument
+func cborHeader(buf *bytes.Buffer, major byte, n uint64) {
+	mt := major << 5
+	switch {
+	case n < 24:
+		buf.WriteByte(mt | byte(n))
+	case n <= 0xff:
+		buf.WriteByte(mt | 24)
+		buf.WriteByte(byte(n))
+	case n <= 0xffff:
+		buf.WriteByte(mt | 25)
+		_ = binary.Write(buf, binary.BigEndian, uint16(n))
+	case n <= 0xffffffff:
+		buf.WriteByte(mt | 26)
+		_ = binary.Write(buf, binary.BigEndian, uint32(n))
+	default:
+		buf.WriteByte(mt | 27)
+		_ = binary.Write(buf, binary.BigEndian, n)
+	}
+}
+
+// cborValue writes the given value of the generic LogMsg representation
+func cborValue(buf *bytes.Buffer, v interface{}) {
+	switch v := v.(type) {
+	case string:
+		cborHeader(buf, cborText, uint64(len(v)))
+		buf.WriteString(v)
+	case []byte:
+		cborHeader(buf, cborBytes, uint64(len(v)))
+		buf.Write(v)
+	case int64:
+		if v < 0 {
+			cborHeader(buf, cborNegInt, uint64(-1-v))
+			return
+		}
+		cborHeader(buf, cborUint, uint64(v))
+	case bool:
+		if v {
+			buf.WriteByte(cborTrue)
+			return
+		}
+		buf.WriteByte(cborFalse)
+	case []interface{}:
+		cborHeader(buf, cborArray, uint64(len(v)))
+		for _, e := range v {
+			cborValue(buf, e)
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		cborHeader(buf, cborMap, uint64(len(keys)))
+		for _, k := range keys {
+			cborValue(buf, k)
+			cborValue(buf, v[k])
+		}
+	default:
+		buf.WriteByte(cborNull)
+	}
+}
+
+// cborDecoder decodes the subset of CBOR that is used by MarshalCBOR. Tags are
+// skipped, floats and indefinite-length items are not supported.
+type cborDecoder struct {
+	b []byte
+	p int
+}
+
+// value decodes the next data item
+func (d *cborDecoder) value(depth int) (interface{}, error) {
+	if depth > maxSerialDepth {
+		return nil, fmt.Errorf("%w: nesting too deep", ErrInvalidEncoding)
+	}
+	if d.p >= len(d.b) {
+		return nil, fmt.Errorf("%w: unexpected end of data", ErrInvalidEncoding)
+	}
+	ib := d.b[d.p]
+	major, ai := ib>>5, ib&0x1f
+	if major == cborSimple {
+		d.p++
+		switch ib {
+		case cborFalse:
+			return false, nil
+		case cborTrue:
+			return true, nil
+		case cborNull:
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%w: simple value 0x%x", ErrInvalidEncoding, ib)
+	}
+	n, err := d.argument(ai)
+	if err != nil {
+		return nil, err
+	}
+	switch major {
+	case cborUint:
+		return n, nil
+	case cborNegInt:
+		return -1 - int64(n), nil
+	case cborBytes:
+		b, err := d.read(n)
+		return b, err
+	case cborText:
+		b, err := d.read(n)
+		return string(b), err
+	case cborArray:
+		if n > uint64(len(d.b)-d.p) {
+			return nil, fmt.Errorf("%w: array length exceeds data", ErrInvalidEncoding)
+		}
+		a := make([]interface{}, 0, n)
+		for i := uint64(0); i < n; i++ {
+			v, err := d.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, v)
+		}
+		return a, nil
+	case cborMap:
+		if n > uint64(len(d.b)-d.p) {
+			return nil, fmt.Errorf("%w: map length exceeds data", ErrInvalidEncoding)
+		}
+		m := make(map[string]interface{}, n)
+		for i := uint64(0); i < n; i++ {
+			k, err := d.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			ks, ok := k.(string)
+			if !ok {
+				return nil, fmt.Errorf("%w: non-string map key", ErrInvalidEncoding)
+			}
+			if m[ks], err = d.value(depth + 1); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	default:
+		// cborTag: the tagged item is used as is
+		return d.value(depth + 1)
+	}
+}
+
+// argument decodes the argument of a data item with the given additional information
+func (d *cborDecoder) argument(ai byte) (uint64, error) {
+	d.p++
+	if ai < 24 {
+		return uint64(ai), nil
+	}
+	var l int
+	switch ai {
+	case 24:
+		l = 1
+	case 25:
+		l = 2
+	case 26:
+		l = 4
+	case 27:
+		l = 8
+	default:
+		return 0, fmt.Errorf("%w: additional information %d", ErrInvalidEncoding, ai)
+	}
+	b, err := d.read(uint64(l))
+	if err != nil {
+		return 0, err
+	}
+	var n uint64
+	for _, c := range b {
+		n = n<<8 | uint64(c)
+	}
+	return n, nil
+}
+
+// read returns the next n bytes of the data
+func (d *cborDecoder) read(n uint64) ([]byte, error) {
+	if n > uint64(len(d.b)-d.p) {
+		return nil, fmt.Errorf("%w: unexpected end of data", ErrInvalidEncoding)
+	}
+	b := d.b[d.p : d.p+int(n)]
+	d.p += int(n)
+	return b, nil
+}
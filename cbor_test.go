@@ -0,0 +1,153 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testSerialLogMsg returns a LogMsg with all fields set, for the serialization tests
+func testSerialLogMsg(msg string) LogMsg {
+	orig := Severity(6)
+	lm := LogMsg{
+		AppName: "su", Facility: 4, HasBOM: true, Hostname: "host1", MsgID: "ID47", Priority: 34,
+		ProcID: "123", ProtoVersion: 1, ResolvedHost: "host1.example.com", Severity: 2,
+		SpanID: "00f067aa0ba902b7", TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", TraceState: "congo=t61rcWkgMzE",
+		OriginalSeverity: &orig, Type: RFC5424,
+		Timestamp: time.Date(2003, 10, 11, 22, 14, 15, 3000000, time.FixedZone("", -7*3600)),
+		StructuredData: []StructuredDataElement{
+			{ID: "exampleSDID@32473", Param: []StructuredDataParam{{"iut", "3"}, {"eventSource", "App"}}},
+			{ID: "empty@32473"},
+		},
+	}
+	lm.Message.WriteString(msg)
+	lm.MsgLength = lm.Message.Len()
+	return lm
+}
+
+// checkSerialRoundTrip compares the LogMsg after a serialization round trip with the original
+func checkSerialRoundTrip(t *testing.T, want, got LogMsg) {
+	t.Helper()
+	if got.Message.String() != want.Message.String() || got.MsgLength != want.MsgLength {
+		t.Errorf("round trip wrong message => expected: %q, got: %q", want.Message.String(), got.Message.String())
+	}
+	if got.AppName != want.AppName || got.Facility != want.Facility || got.HasBOM != want.HasBOM ||
+		got.Hostname != want.Hostname || got.MsgID != want.MsgID || got.Priority != want.Priority ||
+		got.ProcID != want.ProcID || got.ProtoVersion != want.ProtoVersion ||
+		got.ResolvedHost != want.ResolvedHost || got.Severity != want.Severity || got.SpanID != want.SpanID ||
+		got.TraceID != want.TraceID || got.TraceState != want.TraceState || got.Type != want.Type {
+		t.Errorf("round trip wrong header => expected: %+v, got: %+v", want, got)
+	}
+	if diffSeverity(got.OriginalSeverity) != diffSeverity(want.OriginalSeverity) {
+		t.Errorf("round trip wrong original severity => expected: %s, got: %s", diffSeverity(want.OriginalSeverity),
+			diffSeverity(got.OriginalSeverity))
+	}
+	if !got.Timestamp.Equal(want.Timestamp) || got.Timestamp.Format(time.RFC3339Nano) !=
+		want.Timestamp.Format(time.RFC3339Nano) {
+		t.Errorf("round trip wrong timestamp => expected: %s, got: %s", want.Timestamp, got.Timestamp)
+	}
+	if len(got.StructuredData) != len(want.StructuredData) {
+		t.Fatalf("round trip wrong structured data => expected: %+v, got: %+v", want.StructuredData,
+			got.StructuredData)
+	}
+	for i, e := range want.StructuredData {
+		g := got.StructuredData[i]
+		if g.ID != e.ID || len(g.Param) != len(e.Param) {
+			t.Errorf("round trip wrong SD element %d => expected: %+v, got: %+v", i, e, g)
+			continue
+		}
+		for j, p := range e.Param {
+			if g.Param[j] != p {
+				t.Errorf("round trip wrong SD param %d/%d => expected: %+v, got: %+v", i, j, p, g.Param[j])
+			}
+		}
+	}
+}
+
+// TestLogMsg_MarshalCBOR tests the MarshalCBOR and UnmarshalCBOR methods of the LogMsg
+func TestLogMsg_MarshalCBOR(t *testing.T) {
+	for _, msg := range []string{"Hello, World!", "binary\xff\xfe", "", strings.Repeat("x", 70000)} {
+		lm := testSerialLogMsg(msg)
+		b, err := lm.MarshalCBOR()
+		if err != nil {
+			t.Fatalf("MarshalCBOR() failed: %s", err)
+		}
+		var got LogMsg
+		if err := got.UnmarshalCBOR(b); err != nil {
+			t.Fatalf("UnmarshalCBOR() failed: %s", err)
+		}
+		checkSerialRoundTrip(t, lm, got)
+	}
+}
+
+// TestLogMsg_MarshalCBOR_encoding tests the encoding of MarshalCBOR against a known
+// CBOR representation
+func TestLogMsg_MarshalCBOR_encoding(t *testing.T) {
+	lm := LogMsg{Priority: 34, Facility: 4, Severity: 2}
+	lm.Message.WriteString("a")
+	b, err := lm.MarshalCBOR()
+	if err != nil {
+		t.Fatalf("MarshalCBOR() failed: %s", err)
+	}
+	// {"schema": 1, "facility": 4, "message": "a", "priority": 34, "severity": 2}
+	want := "a5" + "66736368656d61" + "01" + "68666163696c697479" + "04" + "676d657373616765" + "6161" +
+		"687072696f72697479" + "1822" + "68736576657269747902"
+	if hex.EncodeToString(b) != want {
+		t.Errorf("MarshalCBOR() => expected: %s, got: %s", want, hex.EncodeToString(b))
+	}
+}
+
+// TestLogMsg_UnmarshalCBOR_invalid tests the UnmarshalCBOR method with invalid data
+func TestLogMsg_UnmarshalCBOR_invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr error
+	}{
+		{"empty", "", ErrInvalidEncoding},
+		{"not a map", "01", ErrInvalidEncoding},
+		{"truncated", "a266736368656d", ErrInvalidEncoding},
+		{"trailing data", "a0a0", ErrInvalidEncoding},
+		{"huge map", "bb00000000ffffffff", ErrInvalidEncoding},
+		{"float", "a166736368656d61f93c00", ErrInvalidEncoding},
+		{"non-string key", "a10101", ErrInvalidEncoding},
+		{"newer schema", "a166736368656d6102", ErrUnsupportedSchemaVersion},
+		{"wrong field type", "a168686f73746e616d6501", ErrInvalidEncoding},
+		{"invalid timestamp", "a16974696d657374616d706178", ErrInvalidEncoding},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := hex.DecodeString(tt.data)
+			if err != nil {
+				t.Fatalf("invalid test data: %s", err)
+			}
+			var lm LogMsg
+			if err := lm.UnmarshalCBOR(b); !errors.Is(err, tt.wantErr) {
+				t.Errorf("UnmarshalCBOR() => expected: %s, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestLogMsg_UnmarshalCBOR_tag tests that UnmarshalCBOR accepts tagged values, as they
+// are produced by other CBOR encoders (i. e. a standard date/time string with tag 0)
+func TestLogMsg_UnmarshalCBOR_tag(t *testing.T) {
+	b, err := hex.DecodeString("a16974696d657374616d70c074323030332d31302d31315432323a31343a31355a")
+	if err != nil {
+		t.Fatalf("invalid test data: %s", err)
+	}
+	var lm LogMsg
+	if err := lm.UnmarshalCBOR(b); err != nil {
+		t.Fatalf("UnmarshalCBOR() failed: %s", err)
+	}
+	want := time.Date(2003, 10, 11, 22, 14, 15, 0, time.UTC)
+	if !lm.Timestamp.Equal(want) {
+		t.Errorf("UnmarshalCBOR() wrong timestamp => expected: %s, got: %s", want, lm.Timestamp)
+	}
+}
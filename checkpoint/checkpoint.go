@@ -0,0 +1,252 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+// Package checkpoint implements the decoding of the semicolon-delimited key/value log
+// format of Check Point firewalls, as sent by the Log Exporter in its syslog format (i. e.
+// `[action:"Accept"; origin:"10.1.1.1"; src:"10.1.1.2"; ...]`). The Log Exporter puts the
+// fields where RFC5424 expects the structured data, but they do not conform to its format,
+// so such messages are parsed with the Parser of this package. Decode is a
+// parsesyslog.BodyDecoder for messages that carry the fields in their message body (i. e.
+// after being relayed in the RFC3164 format).
+package checkpoint
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wneessen/go-parsesyslog"
+)
+
+// MsgType is the LogMsgType of the LogMsg returned by the Parser
+const MsgType parsesyslog.LogMsgType = "CheckPoint"
+
+// SDID is the SD-ID of the structured data element that holds the fields of a Check
+// Point log message, using the private enterprise number of Check Point
+const SDID = "checkpoint@2620"
+
+// Type represents the ParserType for this Parser
+const Type parsesyslog.ParserType = "checkpoint"
+
+// nilValue represents the NILVALUE of a RFC5424 header field
+const nilValue = "-"
+
+// msg represents a Check Point log message parser
+type msg struct {
+	buf  bytes.Buffer
+	opts parsesyslog.Options
+}
+
+// init registers the Parser
+func init() {
+	fn := func(o parsesyslog.Options) (parsesyslog.Parser, error) {
+		return &msg{opts: o}, nil
+	}
+	parsesyslog.RegisterWithOptions(Type, fn)
+}
+
+// ParseString returns the parsed log message read from a string (as buffered i/o)
+func (m *msg) ParseString(s string) (parsesyslog.LogMsg, error) {
+	return m.ParseReader(strings.NewReader(s))
+}
+
+// ParseReader parses a Check Point log message until the end of the io.Reader. The
+// message consists of the PRI, an optional RFC5424 header (as sent by the Log Exporter)
+// and the fields (see ParseFields). The fields are stored as structured data element with
+// the SD-ID SDID and as Message. If the header has no Hostname or Timestamp, they are
+// taken from the "origin" and "time" fields. It satisfies the Parser interface
+func (m *msg) ParseReader(r io.Reader) (parsesyslog.LogMsg, error) {
+	var lm parsesyslog.LogMsg
+	lm.Type = MsgType
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	if err := parsesyslog.ParsePriority(br, &m.buf, &lm); err != nil {
+		if errors.Is(err, io.EOF) {
+			return lm, parsesyslog.ErrPrematureEOF
+		}
+		return lm, err
+	}
+	b, err := io.ReadAll(br)
+	if err != nil {
+		return lm, err
+	}
+	s := strings.TrimRight(string(b), "\r\n\x00")
+	if strings.HasPrefix(s, "1 ") {
+		if s, err = parseHeader(s, &lm); err != nil {
+			return lm, err
+		}
+	}
+	if m.opts.Wants(parsesyslog.FieldMessage) {
+		lm.Message.WriteString(s)
+		lm.MsgLength = lm.Message.Len()
+	}
+
+	ps, err := ParseFields(s)
+	if err != nil {
+		return lm, err
+	}
+	if !isCheckPoint(ps) {
+		return lm, parsesyslog.ErrWrongFormat
+	}
+	setHeader(&lm, ps)
+	if m.opts.Wants(parsesyslog.FieldStructuredData) {
+		lm.StructuredData = append(lm.StructuredData, parsesyslog.StructuredDataElement{ID: SDID, Param: ps})
+	}
+	return lm, nil
+}
+
+// ParseFields parses the given string of semicolon separated fields of a Check Point log
+// message. The fields may be enclosed in square brackets, as sent by the Log Exporter,
+// and are given as key:"value" or key="value" (i. e. `[action:"Accept"; ifdir:"inbound"]`).
+// Quoted values may contain semicolons and backslash escapes of double quotes and
+// backslashes. Unquoted values last until the next semicolon. The fields are returned in
+// the order of the string.
+//
+// If the string is not a sequence of fields (i. e. because of an unterminated quoted
+// value), ErrWrongFormat is returned.
+func ParseFields(s string) ([]parsesyslog.StructuredDataParam, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]") {
+		s = s[1 : len(s)-1]
+	}
+	var ps []parsesyslog.StructuredDataParam
+	var sb strings.Builder
+	i := 0
+	for {
+		for i < len(s) && (s[i] == ';' || s[i] == ' ' || s[i] == '\t') {
+			i++
+		}
+		if i >= len(s) {
+			return ps, nil
+		}
+		ks := i
+		for i < len(s) && s[i] != ':' && s[i] != '=' {
+			if s[i] == '"' || s[i] == ';' || s[i] == ' ' {
+				return nil, fmt.Errorf("%w: unexpected %q in key at position %d", parsesyslog.ErrWrongFormat,
+					s[i], i)
+			}
+			i++
+		}
+		if i >= len(s) {
+			return nil, fmt.Errorf("%w: key %q without value", parsesyslog.ErrWrongFormat, s[ks:])
+		}
+		if i == ks {
+			return nil, fmt.Errorf("%w: empty key at position %d", parsesyslog.ErrWrongFormat, i)
+		}
+		p := parsesyslog.StructuredDataParam{Name: s[ks:i]}
+		i++
+		if i < len(s) && s[i] == '"' {
+			i++
+			sb.Reset()
+			closed := false
+			for i < len(s) && !closed {
+				switch c := s[i]; {
+				case c == '\\' && i+1 < len(s) && (s[i+1] == '"' || s[i+1] == '\\'):
+					sb.WriteByte(s[i+1])
+					i += 2
+				case c == '"':
+					closed = true
+					i++
+				default:
+					sb.WriteByte(c)
+					i++
+				}
+			}
+			if !closed {
+				return nil, fmt.Errorf("%w: unterminated value of key %q", parsesyslog.ErrWrongFormat, p.Name)
+			}
+			p.Value = sb.String()
+		} else {
+			vs := i
+			for i < len(s) && s[i] != ';' {
+				i++
+			}
+			p.Value = strings.TrimSpace(s[vs:i])
+		}
+		ps = append(ps, p)
+	}
+}
+
+// Decode decodes the fields of a Check Point log message body (see ParseFields) and
+// stores them as structured data element with the SD-ID SDID. If the LogMsg has no
+// Hostname or Timestamp, they are taken from the "origin" and "time" fields. Message
+// bodies that do not consist of fields with at least the "origin" and the "loguid" or
+// "product" fields are not recognized. Decode satisfies the parsesyslog.BodyDecoder type.
+func Decode(lm *parsesyslog.LogMsg) bool {
+	ps, err := ParseFields(lm.Message.String())
+	if err != nil || !isCheckPoint(ps) {
+		return false
+	}
+	setHeader(lm, ps)
+	lm.StructuredData = append(lm.StructuredData, parsesyslog.StructuredDataElement{ID: SDID, Param: ps})
+	return true
+}
+
+// parseHeader parses the RFC5424 header fields that the Log Exporter puts in front of
+// the fields into the LogMsg and returns the rest of the given string
+func parseHeader(s string, lm *parsesyslog.LogMsg) (string, error) {
+	hf := strings.SplitN(s, " ", 7)
+	if len(hf) < 6 {
+		return s, fmt.Errorf("%w: incomplete header", parsesyslog.ErrWrongFormat)
+	}
+	lm.ProtoVersion = 1
+	if hf[1] != nilValue {
+		ts, err := time.Parse(time.RFC3339, hf[1])
+		if err != nil {
+			return s, parsesyslog.ErrInvalidTimestamp
+		}
+		lm.Timestamp = ts
+	}
+	for i, f := range []*string{&lm.Hostname, &lm.AppName, &lm.ProcID, &lm.MsgID} {
+		if hf[i+2] != nilValue {
+			*f = hf[i+2]
+		}
+	}
+	if len(hf) < 7 {
+		return "", nil
+	}
+	return hf[6], nil
+}
+
+// isCheckPoint returns true if the given fields contain the fields that every Check
+// Point log message has
+func isCheckPoint(ps []parsesyslog.StructuredDataParam) bool {
+	var origin, id bool
+	for _, p := range ps {
+		switch p.Name {
+		case "origin":
+			origin = true
+		case "loguid", "product":
+			id = true
+		}
+	}
+	return origin && id
+}
+
+// setHeader sets the Hostname and Timestamp of the LogMsg from the "origin" and the
+// "time" (seconds since the epoch) fields, if they are not set yet
+func setHeader(lm *parsesyslog.LogMsg, ps []parsesyslog.StructuredDataParam) {
+	for _, p := range ps {
+		switch p.Name {
+		case "origin":
+			if lm.Hostname == "" {
+				lm.Hostname = p.Value
+			}
+		case "time":
+			if !lm.Timestamp.IsZero() {
+				continue
+			}
+			if sec, err := strconv.ParseInt(p.Value, 10, 64); err == nil {
+				lm.Timestamp = time.Unix(sec, 0).UTC()
+			}
+		}
+	}
+}
@@ -0,0 +1,162 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package checkpoint
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/wneessen/go-parsesyslog"
+	"github.com/wneessen/go-parsesyslog/rfc3164"
+)
+
+const testFields = `[action:"Accept"; flags:"411908"; ifdir:"inbound"; ifname:"eth1"; loguid:"{0x63bd4d1b,0x0,0x1,0x2}"; ` +
+	`origin:"10.1.1.1"; originsicname:"CN=gw01,O=mgmt..abcdef"; product:"VPN-1 & FireWall-1"; ` +
+	`time:"1673350667"; src:"10.1.1.2"; dst:"192.0.2.1"; proto:"6"; s_port:"50123"; service:"443"]`
+
+// TestMsg_ParseString tests the Parser for the Log Exporter syslog format
+func TestMsg_ParseString(t *testing.T) {
+	p, err := parsesyslog.New(Type)
+	if err != nil {
+		t.Fatalf("failed to create new parser: %s", err)
+	}
+	tests := []struct {
+		name     string
+		msg      string
+		hostname string
+		ts       time.Time
+		err      error
+	}{
+		{
+			"log exporter", "<134>1 2023-01-10T11:37:47Z gw01 CheckPoint 4711 - " + testFields + "\n", "gw01",
+			time.Date(2023, 1, 10, 11, 37, 47, 0, time.UTC), nil,
+		},
+		{
+			"nil header", "<134>1 - - CheckPoint - - " + testFields, "10.1.1.1",
+			time.Unix(1673350667, 0), nil,
+		},
+		{"no header", "<134>" + testFields, "10.1.1.1", time.Unix(1673350667, 0), nil},
+		{"invalid timestamp", "<134>1 yesterday gw01 CheckPoint - - " + testFields, "", time.Time{},
+			parsesyslog.ErrInvalidTimestamp},
+		{"incomplete header", "<134>1 - gw01", "", time.Time{}, parsesyslog.ErrWrongFormat},
+		{"no check point fields", `<134>[action:"Accept"; src:"10.1.1.2"]`, "", time.Time{},
+			parsesyslog.ErrWrongFormat},
+		{"unterminated quote", `<134>[origin:"10.1.1.1; product:"VPN-1"]`, "", time.Time{},
+			parsesyslog.ErrWrongFormat},
+		{"no priority", testFields, "", time.Time{}, parsesyslog.ErrWrongFormat},
+		{"empty", "", "", time.Time{}, parsesyslog.ErrPrematureEOF},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lm, err := p.ParseString(tt.msg)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Errorf("ParseString() => expected error: %s, got: %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseString() failed: %s", err)
+			}
+			if lm.Type != MsgType {
+				t.Errorf("ParseString() type => expected: %s, got: %s", MsgType, lm.Type)
+			}
+			if lm.Facility != 16 || lm.Severity != 6 {
+				t.Errorf("ParseString() priority => unexpected facility/severity: %d/%d", lm.Facility, lm.Severity)
+			}
+			if lm.ProtoVersion == 1 && lm.AppName != "CheckPoint" {
+				t.Errorf("ParseString() app name => expected: %s, got: %s", "CheckPoint", lm.AppName)
+			}
+			if lm.Hostname != tt.hostname {
+				t.Errorf("ParseString() hostname => expected: %s, got: %s", tt.hostname, lm.Hostname)
+			}
+			if !lm.Timestamp.Equal(tt.ts) {
+				t.Errorf("ParseString() timestamp => expected: %s, got: %s", tt.ts, lm.Timestamp)
+			}
+			if lm.Message.String() != testFields {
+				t.Errorf("ParseString() message => expected: %s, got: %s", testFields, lm.Message.String())
+			}
+			if len(lm.StructuredData) != 1 || lm.StructuredData[0].ID != SDID {
+				t.Fatalf("ParseString() => unexpected structured data: %v", lm.StructuredData)
+			}
+			if ps := lm.StructuredData[0].Param; len(ps) != 14 || ps[7].Value != "VPN-1 & FireWall-1" {
+				t.Errorf("ParseString() => unexpected params: %v", ps)
+			}
+		})
+	}
+}
+
+// TestParseFields tests the ParseFields function
+func TestParseFields(t *testing.T) {
+	tests := []struct {
+		name  string
+		s     string
+		names []string
+		vals  []string
+		err   bool
+	}{
+		{"log exporter", `[action:"Accept"; src:"10.1.1.2"]`, []string{"action", "src"}, []string{"Accept", "10.1.1.2"}, false},
+		{"equal sign", `action="Drop"; rule=4; `, []string{"action", "rule"}, []string{"Drop", "4"}, false},
+		{
+			"quoted semicolon", `[msg:"a; b \"c\" \\d"; x:""]`, []string{"msg", "x"},
+			[]string{`a; b "c" \d`, ""}, false,
+		},
+		{"unquoted with spaces", `product: VPN-1 & FireWall-1 ;x:1`, []string{"product", "x"}, []string{"VPN-1 & FireWall-1", "1"}, false},
+		{"empty", "", nil, nil, false},
+		{"empty brackets", "[]", nil, nil, false},
+		{"no value", `[action:"Accept"; src]`, nil, nil, true},
+		{"empty key", `[:"Accept"]`, nil, nil, true},
+		{"space in key", `[my action:"Accept"]`, nil, nil, true},
+		{"unterminated", `[action:"Accept]`, nil, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ps, err := ParseFields(tt.s)
+			if tt.err {
+				if !errors.Is(err, parsesyslog.ErrWrongFormat) {
+					t.Errorf("ParseFields() => expected ErrWrongFormat, got: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseFields() failed: %s", err)
+			}
+			if len(ps) != len(tt.names) {
+				t.Fatalf("ParseFields() => expected %d fields, got: %v", len(tt.names), ps)
+			}
+			for i, p := range ps {
+				if p.Name != tt.names[i] || p.Value != tt.vals[i] {
+					t.Errorf("ParseFields() field %d => expected: %s=%q, got: %s=%q", i, tt.names[i], tt.vals[i],
+						p.Name, p.Value)
+				}
+			}
+		})
+	}
+}
+
+// TestDecode_rfc3164 tests Decode as BodyDecoder of the RFC3164 parser
+func TestDecode_rfc3164(t *testing.T) {
+	p, err := parsesyslog.New(rfc3164.Type, parsesyslog.WithBodyDecoders(Decode))
+	if err != nil {
+		t.Fatalf("failed to create new parser: %s", err)
+	}
+	lm, err := p.ParseString("<134>Jan 10 11:37:47 relay01 " + testFields + "\n")
+	if err != nil {
+		t.Fatalf("ParseString() failed: %s", err)
+	}
+	if len(lm.StructuredData) != 1 || lm.StructuredData[0].ID != SDID {
+		t.Fatalf("ParseString() => unexpected structured data: %+v", lm.StructuredData)
+	}
+	if lm.Hostname != "relay01" {
+		t.Errorf("ParseString() => hostname of the header expected to be kept, got: %s", lm.Hostname)
+	}
+
+	var nm parsesyslog.LogMsg
+	nm.Message.WriteString("User admin logged in")
+	if Decode(&nm) || len(nm.StructuredData) != 0 {
+		t.Errorf("Decode() => expected message body not to be recognized")
+	}
+}
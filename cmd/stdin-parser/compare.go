@@ -0,0 +1,131 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/wneessen/go-parsesyslog"
+)
+
+// maxLineLen is the maximum length of an input line of the compare subcommand
+const maxLineLen = 1024 * 1024
+
+// parserOptions maps the option names of a parser spec to the parsesyslog.Option
+var parserOptions = map[string]parsesyslog.Option{
+	"cisco":        parsesyslog.WithStripCiscoPrefix(),
+	"lenient":      parsesyslog.WithLenient(),
+	"skip-emptysd": parsesyslog.WithSkipEmptySD(),
+	"strict":       parsesyslog.WithStrict(),
+}
+
+// newSpecParser returns a new Parser for the given parser spec, which consists of the
+// ParserType and optional option names, separated by "+" (i. e. "rfc5424+strict")
+func newSpecParser(spec string) (parsesyslog.Parser, error) {
+	parts := strings.Split(spec, "+")
+	var opts []parsesyslog.Option
+	for _, o := range parts[1:] {
+		opt, ok := parserOptions[o]
+		if !ok {
+			return nil, fmt.Errorf("unknown parser option %q", o)
+		}
+		opts = append(opts, opt)
+	}
+	return parsesyslog.New(parsesyslog.ParserType(parts[0]), opts...)
+}
+
+// compareStats represents the summary of a comparison. lines is the number of lines
+// read, including empty lines, and messages the number of non-empty lines compared.
+type compareStats struct {
+	lines, messages, equal, differ, errA, errB int
+}
+
+// runCompare runs the compare subcommand: it parses every line read from stdin with two
+// parsers and prints the fields that differ, so that configurations can be compared on
+// the logs of a fleet
+func runCompare(args []string) error {
+	fs := flag.NewFlagSet("compare", flag.ContinueOnError)
+	specA := fs.String("a", "rfc3164", "parser spec of the first parser (i. e. rfc5424+lenient)")
+	specB := fs.String("b", "rfc5424", "parser spec of the second parser (i. e. rfc5424+strict)")
+	quiet := fs.Bool("q", false, "only print the summary")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	pa, err := newSpecParser(*specA)
+	if err != nil {
+		return fmt.Errorf("failed to create parser %q: %w", *specA, err)
+	}
+	pb, err := newSpecParser(*specB)
+	if err != nil {
+		return fmt.Errorf("failed to create parser %q: %w", *specB, err)
+	}
+	r, err := parsesyslog.Decompress(os.Stdin)
+	if err != nil {
+		return fmt.Errorf("failed to read from stdin: %w", err)
+	}
+	out := io.Writer(os.Stdout)
+	if *quiet {
+		out = io.Discard
+	}
+	st, err := compare(r, out, pa, pb, *specA, *specB)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d lines, %d messages: %d equal, %d differ, %d failed with %s, %d failed with %s\n", st.lines,
+		st.messages, st.equal, st.differ, st.errA, *specA, st.errB, *specB)
+	return nil
+}
+
+// compare parses every non-empty line of the given io.Reader with both parsers and writes
+// the differences to the given io.Writer, along with the number of the line in the input
+func compare(r io.Reader, w io.Writer, pa, pb parsesyslog.Parser, nameA, nameB string) (compareStats, error) {
+	var st compareStats
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), maxLineLen)
+	for sc.Scan() {
+		st.lines++
+		line := sc.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		st.messages++
+		la, errA := pa.ParseString(string(line))
+		lb, errB := pb.ParseString(string(line))
+		if errA != nil {
+			st.errA++
+		}
+		if errB != nil {
+			st.errB++
+		}
+		if errA != nil || errB != nil {
+			_, _ = fmt.Fprintf(w, "line %d:\n", st.lines)
+			if errA != nil {
+				_, _ = fmt.Fprintf(w, "  %s: %s\n", nameA, errA)
+			}
+			if errB != nil {
+				_, _ = fmt.Fprintf(w, "  %s: %s\n", nameB, errB)
+			}
+			continue
+		}
+		// The type of the LogMsg only reflects the parser type
+		la.Type = lb.Type
+		d := parsesyslog.Diff(la, lb)
+		if len(d) == 0 {
+			st.equal++
+			continue
+		}
+		st.differ++
+		_, _ = fmt.Fprintf(w, "line %d:\n", st.lines)
+		for _, fd := range d {
+			_, _ = fmt.Fprintf(w, "  %s: %s => %s\n", fd.Field, fd.A, fd.B)
+		}
+	}
+	return st, sc.Err()
+}
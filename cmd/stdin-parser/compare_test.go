@@ -0,0 +1,86 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/wneessen/go-parsesyslog"
+	"github.com/wneessen/go-parsesyslog/rfc3164"
+	"github.com/wneessen/go-parsesyslog/rfc5424"
+)
+
+// TestCompare tests the compare function with equal and differing log messages, log
+// messages that only one parser fails on and empty lines
+func TestCompare(t *testing.T) {
+	bsd := "<34>Oct 11 22:14:15 mymachine su: 'su root' failed"
+	ietf := "<34>1 2003-10-11T22:14:15.003Z mymachine su - ID47 - 'su root' failed"
+	p3164, err := parsesyslog.New(rfc3164.Type, parsesyslog.WithYear(2003))
+	if err != nil {
+		t.Fatalf("failed to create RFC3164 parser: %s", err)
+	}
+	p3164y, err := parsesyslog.New(rfc3164.Type, parsesyslog.WithYear(2000))
+	if err != nil {
+		t.Fatalf("failed to create RFC3164 parser: %s", err)
+	}
+	p5424, err := parsesyslog.New(rfc5424.Type)
+	if err != nil {
+		t.Fatalf("failed to create RFC5424 parser: %s", err)
+	}
+	tests := []struct {
+		name    string
+		input   string
+		pa, pb  parsesyslog.Parser
+		want    compareStats
+		wantOut []string
+	}{
+		{
+			"equal", bsd + "\n" + bsd + "\n", p3164, p3164,
+			compareStats{lines: 2, messages: 2, equal: 2}, nil,
+		},
+		{
+			"differ", bsd + "\n", p3164, p3164y,
+			compareStats{lines: 1, messages: 1, differ: 1}, []string{"line 1:", "  Timestamp: "},
+		},
+		{
+			"one side failing", bsd + "\n" + ietf + "\n", p3164, p5424,
+			compareStats{lines: 2, messages: 2, errA: 1, errB: 1},
+			[]string{"line 1:", "  b: ", "line 2:", "  a: "},
+		},
+		{
+			"empty lines", "\n" + bsd + "\n\n\n" + bsd, p3164, p3164y,
+			compareStats{lines: 5, messages: 2, differ: 2},
+			[]string{"line 2:", "  Timestamp: ", "line 5:", "  Timestamp: "},
+		},
+		{"empty", "", p3164, p3164, compareStats{}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			st, err := compare(strings.NewReader(tt.input), &out, tt.pa, tt.pb, "a", "b")
+			if err != nil {
+				t.Fatalf("compare() failed: %s", err)
+			}
+			if st != tt.want {
+				t.Errorf("compare() => expected stats: %+v, got: %+v", tt.want, st)
+			}
+			var lines []string
+			if out.Len() > 0 {
+				lines = strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+			}
+			if len(lines) != len(tt.wantOut) {
+				t.Fatalf("compare() => expected %d output lines, got: %q", len(tt.wantOut), lines)
+			}
+			for i, l := range lines {
+				if !strings.HasPrefix(l, tt.wantOut[i]) {
+					t.Errorf("compare() => expected output line %d to start with %q, got: %q", i+1,
+						tt.wantOut[i], l)
+				}
+			}
+		})
+	}
+}
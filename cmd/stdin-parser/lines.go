@@ -0,0 +1,50 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/wneessen/go-parsesyslog"
+)
+
+// runLines runs the lines subcommand: it parses every line read from stdin as independent
+// log message, as it is needed for log files, and prints the parsed messages or errors
+func runLines(args []string) error {
+	fs := flag.NewFlagSet("lines", flag.ContinueOnError)
+	spec := fs.String("p", "auto", "parser spec of the parser (i. e. rfc3164+lenient)")
+	quiet := fs.Bool("q", false, "only print the errors and the summary")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	p, err := newSpecParser(*spec)
+	if err != nil {
+		return fmt.Errorf("failed to create parser %q: %w", *spec, err)
+	}
+	r, err := parsesyslog.Decompress(os.Stdin)
+	if err != nil {
+		return fmt.Errorf("failed to read from stdin: %w", err)
+	}
+	lines, failed := 0, 0
+	err = parsesyslog.ParseLines(p, r, func(n int, lm *parsesyslog.LogMsg, err error) error {
+		lines++
+		if err != nil {
+			failed++
+			fmt.Printf("line %d: %s\n", n, err)
+			return nil
+		}
+		if !*quiet {
+			fmt.Printf("line %d: %s\n", n, lm)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read from stdin: %w", err)
+	}
+	fmt.Printf("%d lines: %d parsed, %d failed\n", lines, lines-failed, failed)
+	return nil
+}
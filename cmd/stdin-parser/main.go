@@ -11,11 +11,34 @@ import (
 	"time"
 
 	"github.com/wneessen/go-parsesyslog"
+	_ "github.com/wneessen/go-parsesyslog/auto"
+	_ "github.com/wneessen/go-parsesyslog/rfc3164"
 	"github.com/wneessen/go-parsesyslog/rfc5424"
+	_ "github.com/wneessen/go-parsesyslog/zstd"
 )
 
 func main() {
-	br := bufio.NewReader(os.Stdin)
+	if len(os.Args) > 1 && os.Args[1] == "compare" {
+		if err := runCompare(os.Args[2:]); err != nil {
+			fmt.Printf("compare failed: %s\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "lines" {
+		if err := runLines(os.Args[2:]); err != nil {
+			fmt.Printf("lines failed: %s\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	r, err := parsesyslog.Decompress(os.Stdin)
+	if err != nil {
+		fmt.Printf("failed to read from stdin: %s", err)
+		os.Exit(1)
+	}
+	br := bufio.NewReader(r)
 	p, err := parsesyslog.New(rfc5424.Type)
 	if err != nil {
 		fmt.Printf("failed to create RFC5424 parser: %s", err)
@@ -27,32 +50,11 @@ func main() {
 		panic(err)
 	}
 	et := time.Since(st)
-	fmt.Println("Log message details:")
-	fmt.Printf("+ Log format:         %s\n", lm.Type)
-	fmt.Println("+ Header:")
-	fmt.Printf("  - Priority:         %d (Facility: %s / Severity: %s)\n", lm.Priority,
-		parsesyslog.FacilityStringFromPrio(lm.Priority), parsesyslog.SeverityStringFromPrio(lm.Priority))
-	fmt.Printf("  - Protocol Version: %d\n", lm.ProtoVersion)
-	fmt.Printf("  - Hostname:         %s\n", lm.Hostname)
-	fmt.Printf("  - AppName:          %s\n", lm.AppName)
-	fmt.Printf("  - ProcID:           %s\n", lm.ProcID)
-	fmt.Printf("  - MsgID:            %s\n", lm.MsgID)
-	fmt.Printf("  - Timestamp (UTC):  %s\n", lm.Timestamp.UTC().String())
-	if len(lm.StructuredData) > 0 {
-		fmt.Println("+ Structured Data:")
-		for _, se := range lm.StructuredData {
-			fmt.Printf("  - ID:               %s\n", se.ID)
-			pc := 0
-			for _, sp := range se.Param {
-				fmt.Printf("    + Param %d:               \n", pc)
-				fmt.Printf("      - Name:         %s\n", sp.Name)
-				fmt.Printf("      - Value:        %s\n", sp.Value)
-				pc++
-			}
+	fmt.Printf("%s\n\n", lm)
+	for _, se := range lm.StructuredData {
+		if v, ok := parsesyslog.SDIDVendor(se.ID); ok {
+			fmt.Printf("SD-ID %s: %s\n", se.ID, v)
 		}
 	}
-	fmt.Printf("+ Message has BOM:    %t\n", lm.HasBOM)
-	fmt.Printf("+ Message Length:     %d\n", lm.MsgLength)
-	fmt.Printf("+ Message:            %s\n\n", lm.Message.String())
 	fmt.Printf("Log parsed in %s\n", et.String())
 }
@@ -88,6 +88,16 @@ func ParsePriority(r *bufio.Reader, buf *bytes.Buffer, lm *LogMsg) error {
 	return nil
 }
 
+// ReuseString returns s if it is equal to the given byte slice, otherwise a new string
+// of the byte slice is returned. This avoids allocations for repeated values, like the
+// hostname of consecutive log messages of the same sender.
+func ReuseString(s string, b []byte) string {
+	if s == string(b) {
+		return s
+	}
+	return string(b)
+}
+
 // Atoi performs allocation free ASCII number to integer conversion
 func Atoi(b []byte) (int, error) {
 	z := 0
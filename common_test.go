@@ -159,3 +159,17 @@ func Benchmark_readMsgLength(b *testing.B) {
 	}
 	_ = ml
 }
+
+// TestReuseString tests the ReuseString helper method
+func TestReuseString(t *testing.T) {
+	s := "myhostname"
+	if got := ReuseString(s, []byte("myhostname")); got != s {
+		t.Errorf("ReuseString() => expected: %s, got: %s", s, got)
+	}
+	if got := ReuseString(s, []byte("otherhost")); got != "otherhost" {
+		t.Errorf("ReuseString() => expected: %s, got: %s", "otherhost", got)
+	}
+	if a := testing.AllocsPerRun(10, func() { _ = ReuseString(s, []byte("myhostname")) }); a != 0 {
+		t.Errorf("ReuseString() with equal value => expected no allocations, got: %f", a)
+	}
+}
@@ -0,0 +1,110 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"time"
+)
+
+// Maximum lengths of the header fields of the respective log formats
+const (
+	maxAppName3164  = 32
+	maxAppName5424  = 48
+	maxHostname5424 = 255
+	maxProcID5424   = 128
+)
+
+// utf8BOM is the UTF-8 byte order mark that RFC5424 uses to mark UTF-8 encoded messages
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// ConvertTo5424 returns a copy of the given LogMsg that is converted to RFC5424. The
+// TAG of an RFC3164 message has already been split into AppName and ProcID by the
+// parser, so the conversion sets the ProtoVersion to 1, removes the trailing line
+// break of the message and truncates header fields that exceed the lengths allowed by
+// RFC5424. Fields that RFC3164 does not provide (MsgID and StructuredData) are left
+// empty and therefore serialized as NILVALUE.
+// See: https://datatracker.ietf.org/doc/html/rfc5424#appendix-A.1
+func ConvertTo5424(lm LogMsg) LogMsg {
+	c := lm.Clone()
+	c.Type = RFC5424
+	c.ProtoVersion = 1
+	c.Hostname = truncate(c.Hostname, maxHostname5424)
+	c.AppName = truncate(c.AppName, maxAppName5424)
+	c.ProcID = truncate(c.ProcID, maxProcID5424)
+	if lm.Type == RFC3164 {
+		b := bytes.TrimRight(c.Message.Bytes(), "\r\n")
+		c.Message.Truncate(len(b))
+		c.MsgLength = c.Message.Len()
+	}
+	return c
+}
+
+// ConvertTo3164 returns a copy of the given LogMsg that is converted to RFC3164. Since
+// RFC3164 has no structured data, the structured data elements are folded into the
+// message in RFC5424 notation (i. e. `[exampleSDID@32473 iut="3"] message`). The MsgID
+// is dropped, as well as the BOM, since RFC3164 has no way to express either. AppName
+// and ProcID make up the TAG and the AppName is truncated to the maximum TAG length.
+//
+// RFC3164 requires a TIMESTAMP and a HOSTNAME, so, like a relay that receives a message
+// without them, ConvertTo3164 fills a missing Timestamp with the current time and a
+// missing Hostname with the hostname of the local system.
+// See: https://tools.ietf.org/search/rfc3164#section-4.3.2
+func ConvertTo3164(lm LogMsg) LogMsg {
+	c := lm.Clone()
+	c.Type = RFC3164
+	c.ProtoVersion = 0
+	c.MsgID = ""
+	c.AppName = truncate(c.AppName, maxAppName3164)
+	if c.Timestamp.IsZero() {
+		c.Timestamp = time.Now()
+	}
+	if c.Hostname == "" {
+		c.Hostname, _ = os.Hostname()
+	}
+
+	msg := bytes.TrimPrefix(lm.Message.Bytes(), utf8BOM)
+	c.Message.Reset()
+	if len(c.StructuredData) > 0 {
+		writeStructuredData(&c.Message, c.StructuredData)
+		if len(msg) > 0 {
+			c.Message.WriteByte(' ')
+		}
+	}
+	c.Message.Write(msg)
+	c.MsgLength = c.Message.Len()
+	c.HasBOM = false
+	c.StructuredData = nil
+	return c
+}
+
+// writeStructuredData writes the given structured data elements in RFC5424 notation
+// to the buffer
+// See: https://datatracker.ietf.org/doc/html/rfc5424#section-6.3
+func writeStructuredData(buf *bytes.Buffer, sds []StructuredDataElement) {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+	for _, e := range sds {
+		buf.WriteByte('[')
+		buf.WriteString(e.ID)
+		for _, p := range e.Param {
+			buf.WriteByte(' ')
+			buf.WriteString(p.Name)
+			buf.WriteString(`="`)
+			_, _ = r.WriteString(buf, p.Value)
+			buf.WriteByte('"')
+		}
+		buf.WriteByte(']')
+	}
+}
+
+// truncate returns the given string truncated to at most n bytes
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
@@ -0,0 +1,116 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestConvertTo5424 tests the ConvertTo5424 function
+func TestConvertTo5424(t *testing.T) {
+	ts := time.Date(2021, 11, 27, 16, 0, 35, 0, time.UTC)
+	lm := LogMsg{
+		Type: RFC3164, Priority: 13, Facility: 1, Severity: 5, Timestamp: ts, Hostname: "arch-vm",
+		AppName: "wneessen", ProcID: "1130275",
+	}
+	lm.Message.WriteString("test\n")
+	lm.MsgLength = lm.Message.Len()
+
+	c := ConvertTo5424(lm)
+	want := []string{
+		`Message: "test\n" != "test"`, `MsgLength: 5 != 4`, `ProtoVersion: 0 != 1`, `Type: "RFC3164" != "RFC5424"`,
+	}
+	d := Diff(lm, c)
+	if len(d) != len(want) {
+		t.Fatalf("ConvertTo5424() => expected %d diffs, got: %v", len(want), d)
+	}
+	for i, w := range want {
+		if d[i].String() != w {
+			t.Errorf("ConvertTo5424() => expected: %s, got: %s", w, d[i].String())
+		}
+	}
+	if lm.Message.String() != "test\n" {
+		t.Errorf("ConvertTo5424() modified the original message: %q", lm.Message.String())
+	}
+
+	lm.AppName = strings.Repeat("a", 60)
+	if c = ConvertTo5424(lm); len(c.AppName) != maxAppName5424 {
+		t.Errorf("ConvertTo5424() wrong app name length => expected: %d, got: %d", maxAppName5424,
+			len(c.AppName))
+	}
+}
+
+// TestConvertTo3164 tests the ConvertTo3164 function
+func TestConvertTo3164(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  string
+		sd   []StructuredDataElement
+		bom  bool
+		want string
+	}{
+		{"plain", "Hello, World!", nil, false, "Hello, World!"},
+		{"BOM", "\xef\xbb\xbfHello, World!", nil, true, "Hello, World!"},
+		{
+			"structured data", "Hello, World!", []StructuredDataElement{
+				{ID: "exampleSDID@32473", Param: []StructuredDataParam{{"iut", "3"}, {"v", `a"b]`}}},
+				{ID: "empty@32473"},
+			}, false, `[exampleSDID@32473 iut="3" v="a\"b\]"][empty@32473] Hello, World!`,
+		},
+		{
+			"structured data only", "", []StructuredDataElement{{ID: "empty@32473"}}, false,
+			`[empty@32473]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lm := LogMsg{
+				Type: RFC5424, ProtoVersion: 1, Priority: 34, Timestamp: time.Now(), Hostname: "host",
+				AppName: "su", ProcID: "123", MsgID: "ID47", HasBOM: tt.bom, StructuredData: tt.sd,
+			}
+			lm.Message.WriteString(tt.msg)
+			c := ConvertTo3164(lm)
+			if c.Message.String() != tt.want {
+				t.Errorf("ConvertTo3164() wrong message => expected: %q, got: %q", tt.want, c.Message.String())
+			}
+			if c.MsgLength != len(tt.want) {
+				t.Errorf("ConvertTo3164() wrong msg length => expected: %d, got: %d", len(tt.want), c.MsgLength)
+			}
+			if c.Type != RFC3164 || c.ProtoVersion != 0 || c.MsgID != "" || c.HasBOM || c.StructuredData != nil {
+				t.Errorf("ConvertTo3164() wrong header: %+v", c)
+			}
+			if c.AppName != "su" || c.ProcID != "123" || c.Hostname != "host" || c.Priority != 34 {
+				t.Errorf("ConvertTo3164() expected unchanged header fields: %+v", c)
+			}
+			if lm.Message.String() != tt.msg || len(lm.StructuredData) != len(tt.sd) {
+				t.Errorf("ConvertTo3164() modified the original LogMsg")
+			}
+		})
+	}
+}
+
+// TestConvertTo3164_fillMissing tests that ConvertTo3164 fills the fields that RFC3164
+// requires
+func TestConvertTo3164_fillMissing(t *testing.T) {
+	host, err := os.Hostname()
+	if err != nil {
+		t.Skipf("failed to look up local hostname: %s", err)
+	}
+	st := time.Now()
+	c := ConvertTo3164(LogMsg{Type: RFC5424, AppName: strings.Repeat("a", 40)})
+	if c.Hostname != host {
+		t.Errorf("ConvertTo3164() wrong hostname => expected: %s, got: %s", host, c.Hostname)
+	}
+	if c.Timestamp.Before(st) || c.Timestamp.After(time.Now()) {
+		t.Errorf("ConvertTo3164() expected current time, got: %s", c.Timestamp)
+	}
+	if len(c.AppName) != maxAppName3164 {
+		t.Errorf("ConvertTo3164() wrong app name length => expected: %d, got: %d", maxAppName3164,
+			len(c.AppName))
+	}
+}
@@ -0,0 +1,118 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+// Package corpus provides an embedded corpus of anonymized real-world syslog messages,
+// categorized by log format and vendor or dialect (i. e. "rfc3164/cisco"). It is used by
+// the tests and benchmarks of go-parsesyslog and can be used to test the configuration
+// of a collector or custom parsers against the same samples.
+//
+// Hostnames, addresses and user names have been replaced by documentation values (i. e.
+// example.com and the address ranges of RFC5737).
+package corpus
+
+import (
+	"bufio"
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/wneessen/go-parsesyslog"
+)
+
+// samplesDir is the directory of the embedded sample files. It holds a directory for
+// every log format, named after the ParserType, with a file per vendor or dialect.
+// Lines that start with "#" are comments, every other line is a sample.
+const samplesDir = "samples"
+
+// samplesFS holds the embedded sample files
+//
+//go:embed samples
+var samplesFS embed.FS
+
+// ParserOptions are the parsesyslog.Option functions that all samples of the corpus can be
+// parsed with, using the parser of their Format. As real-world messages deviate from the
+// log formats, the samples need the parser to be lenient.
+var ParserOptions = []parsesyslog.Option{parsesyslog.WithLenient(), parsesyslog.WithStripCiscoPrefix()}
+
+// Sample represents a single log message of the corpus
+type Sample struct {
+	// Category is the category the sample belongs to, i. e. "rfc3164/cisco"
+	Category string
+	// Data is the raw log message
+	Data []byte
+	// Format is the ParserType of the log format of the sample
+	Format parsesyslog.ParserType
+	// Line is the line number of the sample in the file of its category
+	Line int
+}
+
+// samples holds the samples of all categories, which are loaded once
+var samples = mustLoad()
+
+// Categories returns the names of all categories of the corpus in lexical order
+func Categories() []string {
+	cs := make([]string, 0, len(samples))
+	for c := range samples {
+		cs = append(cs, c)
+	}
+	sort.Strings(cs)
+	return cs
+}
+
+// Load returns the samples of the given category. The returned bool is false if there
+// is no such category. The Data of the samples must not be modified.
+func Load(category string) ([]Sample, bool) {
+	s, ok := samples[category]
+	if !ok {
+		return nil, false
+	}
+	return append([]Sample(nil), s...), true
+}
+
+// All returns the samples of all categories, ordered by category. The Data of the
+// samples must not be modified.
+func All() []Sample {
+	var all []Sample
+	for _, c := range Categories() {
+		all = append(all, samples[c]...)
+	}
+	return all
+}
+
+// mustLoad reads the embedded sample files and panics if they can not be read, as this
+// means that the embedded corpus is broken
+func mustLoad() map[string][]Sample {
+	m := make(map[string][]Sample)
+	err := fs.WalkDir(samplesFS, samplesDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || path.Ext(p) != ".log" {
+			return err
+		}
+		b, err := samplesFS.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		rel := strings.TrimSuffix(strings.TrimPrefix(p, samplesDir+"/"), ".log")
+		format := parsesyslog.ParserType(path.Dir(rel))
+		sc := bufio.NewScanner(bytes.NewReader(b))
+		for n := 1; sc.Scan(); n++ {
+			l := sc.Bytes()
+			if len(l) == 0 || l[0] == '#' {
+				continue
+			}
+			m[rel] = append(m[rel], Sample{
+				Category: rel, Data: append([]byte(nil), l...), Format: format,
+				Line: n,
+			})
+		}
+		return sc.Err()
+	})
+	if err != nil {
+		panic(fmt.Sprintf("failed to load the embedded corpus: %s", err))
+	}
+	return m
+}
@@ -0,0 +1,80 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package corpus
+
+import (
+	"testing"
+
+	"github.com/wneessen/go-parsesyslog"
+	"github.com/wneessen/go-parsesyslog/rfc3164"
+	"github.com/wneessen/go-parsesyslog/rfc5424"
+)
+
+// TestCategories tests that the corpus holds samples of both log formats
+func TestCategories(t *testing.T) {
+	formats := make(map[parsesyslog.ParserType]int)
+	for _, c := range Categories() {
+		s, ok := Load(c)
+		if !ok || len(s) == 0 {
+			t.Errorf("Load(%q) => expected samples, got: %d", c, len(s))
+		}
+		for _, sm := range s {
+			if sm.Category != c {
+				t.Errorf("Load(%q) => unexpected category of sample: %s", c, sm.Category)
+			}
+			formats[sm.Format]++
+		}
+	}
+	for _, f := range []parsesyslog.ParserType{rfc3164.Type, rfc5424.Type} {
+		if formats[f] == 0 {
+			t.Errorf("Categories() => expected samples of format %s", f)
+		}
+	}
+	if n := len(All()); n != formats[rfc3164.Type]+formats[rfc5424.Type] {
+		t.Errorf("All() => expected %d samples, got: %d", formats[rfc3164.Type]+formats[rfc5424.Type], n)
+	}
+	if _, ok := Load("rfc5424/unknown"); ok {
+		t.Error("Load() of unknown category => expected false")
+	}
+}
+
+// TestParse tests that all samples can be parsed with the parser of their format
+func TestParse(t *testing.T) {
+	for _, s := range All() {
+		p, err := parsesyslog.New(s.Format, ParserOptions...)
+		if err != nil {
+			t.Fatalf("failed to create parser %s: %s", s.Format, err)
+		}
+		lm, err := p.ParseString(string(s.Data))
+		if err != nil {
+			t.Errorf("%s:%d => ParseString() failed: %s", s.Category, s.Line, err)
+			continue
+		}
+		if lm.Hostname == "" || lm.Timestamp.IsZero() {
+			t.Errorf("%s:%d => ParseString() => expected hostname and timestamp, got: %s", s.Category,
+				s.Line, lm)
+		}
+	}
+}
+
+// BenchmarkParse benchmarks parsing the samples of every category
+func BenchmarkParse(b *testing.B) {
+	for _, c := range Categories() {
+		s, _ := Load(c)
+		p, err := parsesyslog.New(s[0].Format, ParserOptions...)
+		if err != nil {
+			b.Fatalf("failed to create parser %s: %s", s[0].Format, err)
+		}
+		bp := p.(parsesyslog.BytesParser)
+		b.Run(c, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				for _, sm := range s {
+					_, _ = bp.ParseBytes(sm.Data)
+				}
+			}
+		})
+	}
+}
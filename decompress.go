@@ -0,0 +1,99 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+var (
+	// gzipMagic are the magic bytes that a gzip stream starts with
+	// See: https://datatracker.ietf.org/doc/html/rfc1952#section-2.3.1
+	gzipMagic = []byte{0x1f, 0x8b}
+	// zstdMagic are the magic bytes that a zstd frame starts with
+	// See: https://datatracker.ietf.org/doc/html/rfc8878#section-3.1.1
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+	// decompressorLock protects the decompressors during RegisterDecompressor()
+	decompressorLock sync.RWMutex
+	// decompressors are the registered decompressors of Decompress
+	decompressors []decompressor
+)
+
+// decompressor is a decompressor for streams that start with its magic bytes
+type decompressor struct {
+	magic []byte
+	fn    func(io.Reader) (io.Reader, error)
+}
+
+// RegisterDecompressor registers a decompression function for streams that start with
+// the given magic bytes, which is then used by Decompress. Packages that implement a
+// compression method register it in their init function, so that importing them is
+// sufficient, i. e. the zstd package for zstd compressed streams. If a decompression
+// function for the magic bytes has already been registered, it is left as is.
+func RegisterDecompressor(magic []byte, fn func(io.Reader) (io.Reader, error)) {
+	decompressorLock.Lock()
+	defer decompressorLock.Unlock()
+	for _, d := range decompressors {
+		if bytes.Equal(d.magic, magic) {
+			return
+		}
+	}
+	decompressors = append(decompressors, decompressor{magic: append([]byte(nil), magic...), fn: fn})
+}
+
+// Decompress inspects the first bytes of the given io.Reader and returns an io.Reader
+// that transparently decompresses the stream, if it is compressed. This way rotated
+// log archives (i. e. "messages.1.gz") can be handed to a Parser or a StreamParser
+// just like uncompressed log files. Uncompressed streams are returned buffered, but
+// otherwise unchanged.
+//
+// gzip streams (including concatenated gzip members) are supported, as well as the
+// compression methods that have been registered with RegisterDecompressor. zstd
+// compressed streams are supported if the zstd package is imported, since the Go
+// standard library does not provide a zstd decoder. Otherwise ErrUnsupportedCompression
+// is returned for them.
+func Decompress(r io.Reader) (io.Reader, error) {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	decompressorLock.RLock()
+	ds := decompressors
+	decompressorLock.RUnlock()
+	n := len(zstdMagic)
+	for _, d := range ds {
+		if len(d.magic) > n {
+			n = len(d.magic)
+		}
+	}
+	b, err := br.Peek(n)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	for _, d := range ds {
+		if bytes.HasPrefix(b, d.magic) {
+			return d.fn(br)
+		}
+	}
+	switch {
+	case bytes.HasPrefix(b, gzipMagic):
+		gr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read gzip header: %w", err)
+		}
+		return gr, nil
+	case bytes.HasPrefix(b, zstdMagic):
+		return nil, fmt.Errorf("zstd: %w (import the zstd package to support it)", ErrUnsupportedCompression)
+	default:
+		return br, nil
+	}
+}
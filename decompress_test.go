@@ -0,0 +1,112 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// TestDecompress tests the Decompress method
+func TestDecompress(t *testing.T) {
+	msgs := "first\nsecond\n"
+	var gz bytes.Buffer
+	for _, m := range []string{"first\n", "second\n"} {
+		gw := gzip.NewWriter(&gz)
+		if _, err := gw.Write([]byte(m)); err != nil {
+			t.Fatalf("failed to write gzip data: %s", err)
+		}
+		if err := gw.Close(); err != nil {
+			t.Fatalf("failed to close gzip writer: %s", err)
+		}
+	}
+	tests := []struct {
+		name    string
+		input   []byte
+		want    string
+		wantErr error
+	}{
+		{"uncompressed", []byte(msgs), msgs, nil},
+		{"gzip multi-member", gz.Bytes(), msgs, nil},
+		{"zstd", []byte{0x28, 0xb5, 0x2f, 0xfd, 0x00}, "", ErrUnsupportedCompression},
+		{"short", []byte("a"), "a", nil},
+		{"empty", nil, "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := Decompress(bytes.NewReader(tt.input))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Decompress() error => expected: %v, got: %v", tt.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+			b, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatalf("failed to read decompressed data: %s", err)
+			}
+			if string(b) != tt.want {
+				t.Errorf("Decompress() => expected: %q, got: %q", tt.want, string(b))
+			}
+		})
+	}
+}
+
+// TestDecompress_stream tests the Decompress method together with a StreamParser
+func TestDecompress_stream(t *testing.T) {
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	_, _ = gw.Write([]byte("first\nsecond\n"))
+	_ = gw.Close()
+	r, err := Decompress(&gz)
+	if err != nil {
+		t.Fatalf("Decompress() failed: %s", err)
+	}
+	var msgs []string
+	next := ParseStream(lineParser{}, r)
+	for {
+		lm, err := next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("ParseStream() failed: %s", err)
+		}
+		msgs = append(msgs, lm.Message.String())
+	}
+	if strings.Join(msgs, ",") != "first,second" {
+		t.Errorf("ParseStream() of decompressed data => expected: %s, got: %s", "first,second",
+			strings.Join(msgs, ","))
+	}
+}
+
+// TestRegisterDecompressor tests that Decompress uses the registered decompressors
+func TestRegisterDecompressor(t *testing.T) {
+	magic := []byte("\x00TESTCOMPRESSION")
+	RegisterDecompressor(magic, func(r io.Reader) (io.Reader, error) {
+		if _, err := io.CopyN(io.Discard, r, int64(len(magic))); err != nil {
+			return nil, err
+		}
+		return strings.NewReader("decompressed\n"), nil
+	})
+	RegisterDecompressor(magic, func(io.Reader) (io.Reader, error) {
+		return nil, errors.New("second registration must be ignored")
+	})
+	r, err := Decompress(bytes.NewReader(append(append([]byte(nil), magic...), "payload"...)))
+	if err != nil {
+		t.Fatalf("Decompress() failed: %s", err)
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("failed to read decompressed data: %s", err)
+	}
+	if string(b) != "decompressed\n" {
+		t.Errorf("Decompress() => expected: %q, got: %q", "decompressed\n", string(b))
+	}
+}
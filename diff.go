@@ -0,0 +1,142 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// FieldDiff represents a field that differs between two LogMsg
+type FieldDiff struct {
+	// Field is the name of the field, i. e. "Hostname" or "StructuredData[0].Param[1].Value"
+	Field string
+	// A is the value of the field in the first LogMsg
+	A string
+	// B is the value of the field in the second LogMsg
+	B string
+}
+
+// String returns a human-readable representation of the FieldDiff
+func (d FieldDiff) String() string {
+	return fmt.Sprintf("%s: %s != %s", d.Field, d.A, d.B)
+}
+
+// diffMissing is the value of a FieldDiff for structured data that is missing in one
+// of the LogMsg
+const diffMissing = "<missing>"
+
+// Diff compares the two given LogMsg field by field and returns the fields that differ,
+// in the order of the LogMsg struct. String values are quoted. Timestamps are equal if
+// they represent the same instant in the same time zone offset. Structured data is
+// compared in wire order, so that elements and params are reported individually. The
+// Body and Wire fields are not compared. An empty result means that both LogMsg are
+// equal.
+func Diff(a, b LogMsg) []FieldDiff {
+	var d []FieldDiff
+	str := func(f, x, y string) {
+		if x != y {
+			d = append(d, FieldDiff{Field: f, A: strconv.Quote(x), B: strconv.Quote(y)})
+		}
+	}
+	num := func(f string, x, y int) {
+		if x != y {
+			d = append(d, FieldDiff{Field: f, A: strconv.Itoa(x), B: strconv.Itoa(y)})
+		}
+	}
+
+	str("AppName", a.AppName, b.AppName)
+	num("ExtraBytes", a.ExtraBytes, b.ExtraBytes)
+	num("Facility", int(a.Facility), int(b.Facility))
+	if a.HasBOM != b.HasBOM {
+		d = append(d, FieldDiff{Field: "HasBOM", A: strconv.FormatBool(a.HasBOM), B: strconv.FormatBool(b.HasBOM)})
+	}
+	str("Hostname", a.Hostname, b.Hostname)
+	str("Message", a.Message.String(), b.Message.String())
+	num("MsgLength", a.MsgLength, b.MsgLength)
+	str("MsgID", a.MsgID, b.MsgID)
+	if oa, ob := diffSeverity(a.OriginalSeverity), diffSeverity(b.OriginalSeverity); oa != ob {
+		d = append(d, FieldDiff{Field: "OriginalSeverity", A: oa, B: ob})
+	}
+	num("Priority", int(a.Priority), int(b.Priority))
+	str("ProcID", a.ProcID, b.ProcID)
+	num("ProtoVersion", int(a.ProtoVersion), int(b.ProtoVersion))
+	str("Relaxed", a.Relaxed.String(), b.Relaxed.String())
+	str("ResolvedHost", a.ResolvedHost, b.ResolvedHost)
+	num("Severity", int(a.Severity), int(b.Severity))
+	str("SpanID", a.SpanID, b.SpanID)
+	d = append(d, diffStructuredData(a.StructuredData, b.StructuredData)...)
+	if ta, tb := diffTime(a.Timestamp), diffTime(b.Timestamp); ta != tb {
+		d = append(d, FieldDiff{Field: "Timestamp", A: ta, B: tb})
+	}
+	str("TraceID", a.TraceID, b.TraceID)
+	str("TraceState", a.TraceState, b.TraceState)
+	if a.Truncated != b.Truncated {
+		d = append(d, FieldDiff{Field: "Truncated", A: strconv.FormatBool(a.Truncated),
+			B: strconv.FormatBool(b.Truncated)})
+	}
+	str("Type", string(a.Type), string(b.Type))
+	return d
+}
+
+// diffStructuredData compares the given structured data elements in wire order
+func diffStructuredData(a, b []StructuredDataElement) []FieldDiff {
+	var d []FieldDiff
+	for i := 0; i < len(a) || i < len(b); i++ {
+		f := fmt.Sprintf("StructuredData[%d]", i)
+		if i >= len(a) {
+			d = append(d, FieldDiff{Field: f, A: diffMissing, B: strconv.Quote(b[i].ID)})
+			continue
+		}
+		if i >= len(b) {
+			d = append(d, FieldDiff{Field: f, A: strconv.Quote(a[i].ID), B: diffMissing})
+			continue
+		}
+		if a[i].ID != b[i].ID {
+			d = append(d, FieldDiff{Field: f + ".ID", A: strconv.Quote(a[i].ID), B: strconv.Quote(b[i].ID)})
+		}
+		pa, pb := a[i].Param, b[i].Param
+		for j := 0; j < len(pa) || j < len(pb); j++ {
+			pf := fmt.Sprintf("%s.Param[%d]", f, j)
+			switch {
+			case j >= len(pa):
+				d = append(d, FieldDiff{Field: pf, A: diffMissing, B: strconv.Quote(pb[j].Name)})
+			case j >= len(pb):
+				d = append(d, FieldDiff{Field: pf, A: strconv.Quote(pa[j].Name), B: diffMissing})
+			default:
+				if pa[j].Name != pb[j].Name {
+					d = append(d, FieldDiff{
+						Field: pf + ".Name", A: strconv.Quote(pa[j].Name),
+						B: strconv.Quote(pb[j].Name),
+					})
+				}
+				if pa[j].Value != pb[j].Value {
+					d = append(d, FieldDiff{
+						Field: pf + ".Value", A: strconv.Quote(pa[j].Value),
+						B: strconv.Quote(pb[j].Value),
+					})
+				}
+			}
+		}
+	}
+	return d
+}
+
+// diffTime returns the representation of a timestamp in a FieldDiff
+func diffTime(t time.Time) string {
+	if t.IsZero() {
+		return "<zero>"
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+// diffSeverity returns the representation of an optional Severity in a FieldDiff
+func diffSeverity(s *Severity) string {
+	if s == nil {
+		return "<nil>"
+	}
+	return strconv.Itoa(int(*s))
+}
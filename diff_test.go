@@ -0,0 +1,80 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"testing"
+	"time"
+)
+
+// TestDiff tests the Diff function
+func TestDiff(t *testing.T) {
+	a := testSerialLogMsg("Hello, World!")
+	tests := []struct {
+		name   string
+		modify func(*LogMsg)
+		want   []string
+	}{
+		{"equal", func(*LogMsg) {}, nil},
+		{
+			"header fields", func(l *LogMsg) {
+				l.Hostname = "host2"
+				l.Priority = 35
+				l.HasBOM = false
+			},
+			[]string{`HasBOM: true != false`, `Hostname: "host1" != "host2"`, `Priority: 34 != 35`},
+		},
+		{
+			"message", func(l *LogMsg) {
+				l.Message.Reset()
+				l.Message.WriteString("Hello\n")
+				l.MsgLength = l.Message.Len()
+			},
+			[]string{`Message: "Hello, World!" != "Hello\n"`, `MsgLength: 13 != 6`},
+		},
+		{
+			"same instant, other zone", func(l *LogMsg) {
+				l.Timestamp = l.Timestamp.UTC()
+			},
+			[]string{`Timestamp: 2003-10-11T22:14:15.003-07:00 != 2003-10-12T05:14:15.003Z`},
+		},
+		{
+			"zero timestamp", func(l *LogMsg) {
+				l.Timestamp = time.Time{}
+			},
+			[]string{`Timestamp: 2003-10-11T22:14:15.003-07:00 != <zero>`},
+		},
+		{
+			"structured data", func(l *LogMsg) {
+				l.StructuredData = []StructuredDataElement{
+					{ID: "exampleSDID@32473", Param: []StructuredDataParam{{"iut", "4"}}},
+					{ID: "other@32473"},
+					{ID: "new@32473"},
+				}
+			},
+			[]string{
+				`StructuredData[0].Param[0].Value: "3" != "4"`,
+				`StructuredData[0].Param[1]: "eventSource" != <missing>`,
+				`StructuredData[1].ID: "empty@32473" != "other@32473"`,
+				`StructuredData[2]: <missing> != "new@32473"`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := a.Clone()
+			tt.modify(&b)
+			d := Diff(a, b)
+			if len(d) != len(tt.want) {
+				t.Fatalf("Diff() => expected %d diffs, got: %v", len(tt.want), d)
+			}
+			for i, w := range tt.want {
+				if d[i].String() != w {
+					t.Errorf("Diff() => expected: %s, got: %s", w, d[i].String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// enterpriseNumbersFile is the embedded table of the private enterprise numbers of
+// common syslog senders
+//
+//go:embed enterprise-numbers.txt
+var enterpriseNumbersFile string
+
+// DefaultEnterpriseNumbers is the table of the private enterprise numbers of common
+// syslog senders (i. e. network equipment vendors) that is shipped with this package.
+// It is used by EnterpriseName and SDIDVendor.
+var DefaultEnterpriseNumbers = mustLoadEnterpriseNumbers(enterpriseNumbersFile)
+
+// EnterpriseNumbers maps IANA private enterprise numbers to the names of the
+// organizations they are assigned to. The private enterprise number of an SD-ID (i. e.
+// 32473 in "exampleSDID@32473") identifies the organization that defined it.
+// See: https://datatracker.ietf.org/doc/html/rfc5424#section-7.2.2
+type EnterpriseNumbers map[int]string
+
+// LoadEnterpriseNumbers reads a table of private enterprise numbers in the format of the
+// IANA registry (https://www.iana.org/assignments/enterprise-numbers.txt) from the given
+// io.Reader, so that the full registry can be used instead of DefaultEnterpriseNumbers.
+// Every entry starts with the number on a line of its own, followed by the indented name
+// of the organization. Further indented lines (i. e. the contact) and all other lines
+// (i. e. the header of the registry) are ignored.
+func LoadEnterpriseNumbers(r io.Reader) (EnterpriseNumbers, error) {
+	en := make(EnterpriseNumbers)
+	sc := bufio.NewScanner(r)
+	pen, want := 0, false
+	for sc.Scan() {
+		l := strings.TrimRight(sc.Text(), " \t\r")
+		if l == "" {
+			continue
+		}
+		if l[0] != ' ' && l[0] != '\t' {
+			n, err := strconv.Atoi(l)
+			pen, want = n, err == nil
+			continue
+		}
+		if want {
+			en[pen] = strings.TrimSpace(l)
+			want = false
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return en, nil
+}
+
+// Lookup returns the name of the organization that the given private enterprise number
+// is assigned to. The returned bool is false if the number is unknown.
+func (en EnterpriseNumbers) Lookup(pen int) (string, bool) {
+	n, ok := en[pen]
+	return n, ok
+}
+
+// SDIDVendor returns the name of the organization that defined the given SD-ID, based on
+// its private enterprise number (i. e. "Example Enterprise Number for Documentation
+// Use" for "exampleSDID@32473"). Sub-identifiers after the enterprise number (i. e.
+// "@32473.1.2") are ignored. The returned bool is false for IANA registered SD-IDs
+// without an enterprise number (i. e. "timeQuality") and for unknown numbers.
+func (en EnterpriseNumbers) SDIDVendor(id string) (string, bool) {
+	i := strings.LastIndexByte(id, '@')
+	if i < 0 {
+		return "", false
+	}
+	s := id[i+1:]
+	if j := strings.IndexByte(s, '.'); j >= 0 {
+		s = s[:j]
+	}
+	pen, err := strconv.Atoi(s)
+	if err != nil {
+		return "", false
+	}
+	return en.Lookup(pen)
+}
+
+// EnterpriseName returns the name of the organization that the given private enterprise
+// number is assigned to, according to DefaultEnterpriseNumbers
+func EnterpriseName(pen int) (string, bool) {
+	return DefaultEnterpriseNumbers.Lookup(pen)
+}
+
+// SDIDVendor returns the name of the organization that defined the given SD-ID,
+// according to DefaultEnterpriseNumbers (see EnterpriseNumbers.SDIDVendor)
+func SDIDVendor(id string) (string, bool) {
+	return DefaultEnterpriseNumbers.SDIDVendor(id)
+}
+
+// mustLoadEnterpriseNumbers returns the EnterpriseNumbers of the given embedded table
+func mustLoadEnterpriseNumbers(s string) EnterpriseNumbers {
+	en, err := LoadEnterpriseNumbers(strings.NewReader(s))
+	if err != nil {
+		panic(fmt.Sprintf("failed to load embedded enterprise numbers: %s", err))
+	}
+	return en
+}
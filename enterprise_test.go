@@ -0,0 +1,87 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"strings"
+	"testing"
+)
+
+// testRegistry is an excerpt of the IANA registry of private enterprise numbers
+const testRegistry = `PRIVATE ENTERPRISE NUMBERS
+
+SMI Network Management Private Enterprise Codes:
+
+Decimal
+| Organization
+| | Contact
+| | | Email
+| | | |
+0
+  Reserved
+    Internet Assigned Numbers Authority
+      iana&iana.org
+1
+  NxNetworks
+    Michael Kellen
+      OID.Admin&NxNetworks.com
+99999
+  Does Not Exist (just testing)
+    Nobody
+      nobody&example.com
+End of Document
+`
+
+// TestSDIDVendor tests the SDIDVendor function with the DefaultEnterpriseNumbers
+func TestSDIDVendor(t *testing.T) {
+	tests := []struct {
+		id     string
+		vendor string
+		ok     bool
+	}{
+		{"exampleSDID@32473", "Example Enterprise Number for Documentation Use", true},
+		{"fortigate@12356", "Fortinet, Inc.", true},
+		{"panos@25461", "Palo Alto Networks", true},
+		{"winevent@311", "Microsoft", true},
+		{"foo@32473.1.2", "Example Enterprise Number for Documentation Use", true},
+		{"foo@99999999", "", false},
+		{"timeQuality", "", false},
+		{"foo@bar", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			v, ok := SDIDVendor(tt.id)
+			if ok != tt.ok || v != tt.vendor {
+				t.Errorf("SDIDVendor(%q) => expected: %q/%t, got: %q/%t", tt.id, tt.vendor, tt.ok, v, ok)
+			}
+		})
+	}
+	if n, ok := EnterpriseName(9); !ok || n != "ciscoSystems" {
+		t.Errorf("EnterpriseName(9) => expected: %q, got: %q", "ciscoSystems", n)
+	}
+}
+
+// TestLoadEnterpriseNumbers tests loading a table in the format of the IANA registry
+func TestLoadEnterpriseNumbers(t *testing.T) {
+	en, err := LoadEnterpriseNumbers(strings.NewReader(testRegistry))
+	if err != nil {
+		t.Fatalf("LoadEnterpriseNumbers() failed: %s", err)
+	}
+	if len(en) != 3 {
+		t.Errorf("LoadEnterpriseNumbers() => expected 3 entries, got: %v", en)
+	}
+	for pen, want := range map[int]string{0: "Reserved", 1: "NxNetworks", 99999: "Does Not Exist (just testing)"} {
+		if n, ok := en.Lookup(pen); !ok || n != want {
+			t.Errorf("Lookup(%d) => expected: %q, got: %q", pen, want, n)
+		}
+	}
+	if v, ok := en.SDIDVendor("test@1"); !ok || v != "NxNetworks" {
+		t.Errorf("SDIDVendor() => expected: %q, got: %q", "NxNetworks", v)
+	}
+	if _, ok := en.Lookup(32473); ok {
+		t.Errorf("Lookup() => expected unknown number not to be found")
+	}
+}
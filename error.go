@@ -7,16 +7,74 @@ package parsesyslog
 import "errors"
 
 var (
+	// ErrABNFViolation is returned in strict mode if a log message violates the ABNF of its format
+	ErrABNFViolation = errors.New("log message violates the ABNF of the logging format")
+	// ErrCorruptSpool is returned if a record of a disk spool can not be read because it is corrupted
+	ErrCorruptSpool = errors.New("spool record is corrupted")
+	// ErrFrameTimeout should be used if a frame was not completed within the configured frame timeout
+	ErrFrameTimeout = errors.New("frame was not completed within the frame timeout")
+	// ErrFrameTooLarge should be used if a frame exceeds the maximum allowed frame length
+	ErrFrameTooLarge = errors.New("frame exceeds the maximum frame length")
+	// ErrFramingMismatch should be used if a frame does not use the expected framing method
+	ErrFramingMismatch = errors.New("frame does not match the expected framing method")
+	// ErrHandoffFailed is reported by a listener if a sink failed to accept log messages, so they were not acknowledged
+	ErrHandoffFailed = errors.New("sink failed to accept the log messages")
+	// ErrHeaderTooLarge is returned if the header of a log message exceeds the maximum header size
+	ErrHeaderTooLarge = errors.New("log message header exceeds the maximum header size")
+	// ErrInvalidBaudRate is returned if a serial port is opened with a baud rate that is not supported
+	ErrInvalidBaudRate = errors.New("unsupported baud rate")
+	// ErrInvalidEncoding should be used if binary encoded data (i. e. CBOR, MessagePack or Protocol Buffers) can not be decoded
+	ErrInvalidEncoding = errors.New("invalid or unsupported binary encoding")
+	// ErrInvalidFrameLength should be used if the MSG-LEN part of an octet-counted frame is invalid
+	ErrInvalidFrameLength = errors.New("invalid octet-count frame length")
+	// ErrInvalidPattern should be used if a message pattern (i. e. of a syslog-ng patterndb) can not be compiled
+	ErrInvalidPattern = errors.New("invalid message pattern")
 	// ErrInvalidPrio should be used if the PRI part of the message is not following the log format
 	ErrInvalidPrio = errors.New("PRI header not a valid priority string")
 	// ErrInvalidProtoVersion should be used if the protocol version part of the header is not following the log format
 	ErrInvalidProtoVersion = errors.New("protocol version string invalid")
+	// ErrInvalidProxyHeader should be used if a connection does not start with a valid PROXY protocol header
+	ErrInvalidProxyHeader = errors.New("invalid PROXY protocol header")
+	// ErrInvalidRELPFrame should be used if a RELP frame does not conform the RELP frame format
+	ErrInvalidRELPFrame = errors.New("invalid RELP frame")
+	// ErrInvalidSignature is returned if the signature of a log message does not match its content
+	ErrInvalidSignature = errors.New("signature of the log message is invalid")
+	// ErrInvalidTemplate should be used if an output template (i. e. an rsyslog template) can not be compiled
+	ErrInvalidTemplate = errors.New("invalid output template")
 	// ErrInvalidTimestamp should be used if it was not possible to parse the timestamp of the log message
 	ErrInvalidTimestamp = errors.New("timestamp does not conform the logging format")
+	// ErrMessageTooLarge is returned if a log message exceeds the maximum message size set with WithMaxMessageSize
+	ErrMessageTooLarge = errors.New("log message exceeds the maximum message size")
+	// ErrMessageTooLong is returned if a log message exceeds the maximum message length and the LengthPolicy is LengthReject
+	ErrMessageTooLong = errors.New("log message exceeds the maximum message length")
+	// ErrMissingSignature is returned if a log message that is supposed to be signed carries no signature
+	ErrMissingSignature = errors.New("log message is not signed")
+	// ErrNoCertificate is returned if a TLS listener is started with a TLS config that provides no certificate
+	ErrNoCertificate = errors.New("TLS config does not provide a certificate")
+	// ErrParserPanic is returned if a Parser panicked while parsing a log message (see PanicError)
+	ErrParserPanic = errors.New("parser panicked")
 	// ErrParserTypeUnknown is returned if a Parser is requested via New() which is not registered
 	ErrParserTypeUnknown = errors.New("unknown parser type")
 	// ErrPrematureEOF should be used in case a log message ends before the provided length
 	ErrPrematureEOF = errors.New("log message is shorter than the provided length")
+	// ErrSelfTestUnsupported is returned by SelfTest if the parser supports none of the corpus formats
+	ErrSelfTestUnsupported = errors.New("parser supports none of the self-test formats")
+	// ErrSenderRejected is reported by a listener if a sender has been rejected by its authentication function
+	ErrSenderRejected = errors.New("sender has been rejected")
+	// ErrServerClosed is returned by the Serve methods of a listener after it has been closed
+	ErrServerClosed = errors.New("listener has been closed")
+	// ErrSpoolClosed is returned by the methods of a disk spool after it has been closed
+	ErrSpoolClosed = errors.New("spool has been closed")
+	// ErrUnclassified is used by ClassifyError for errors that do not match any of the errors of this package
+	ErrUnclassified = errors.New("unclassified parse error")
+	// ErrUnknownTenant is reported by a listener if the tenant of a sender can not be determined
+	ErrUnknownTenant = errors.New("sender does not belong to a known tenant")
+	// ErrUnsupportedCompression should be used if a stream is compressed with a method that can not be decompressed
+	ErrUnsupportedCompression = errors.New("unsupported compression method")
+	// ErrUnsupportedPlatform is returned if a feature is not available on the operating system of the build
+	ErrUnsupportedPlatform = errors.New("not supported on this platform")
+	// ErrUnsupportedSchemaVersion should be used if serialized data uses a schema version that is not supported
+	ErrUnsupportedSchemaVersion = errors.New("unsupported schema version")
 	// ErrWrongFormat should be used if a log messages does not comply with the logging format definitions
 	ErrWrongFormat = errors.New("log message does not conform the logging format")
 	// ErrWrongSDFormat should be used in case the structured data is not parsable
@@ -0,0 +1,109 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+// errorClasses is the list of sentinel errors that parse failures are classified into
+var errorClasses = []error{
+	ErrABNFViolation, ErrFrameTimeout, ErrFrameTooLarge, ErrFramingMismatch, ErrHeaderTooLarge, ErrInvalidFrameLength,
+	ErrInvalidPrio, ErrInvalidProtoVersion, ErrInvalidProxyHeader, ErrInvalidRELPFrame, ErrInvalidTimestamp,
+	ErrMessageTooLarge, ErrMessageTooLong, ErrParserPanic, ErrParserTypeUnknown, ErrPrematureEOF,
+	ErrUnsupportedCompression, ErrWrongFormat, ErrWrongSDFormat,
+}
+
+// ErrorStats counts parse failures per source, classified by the sentinel errors of
+// this package. This allows operators to see which sources emit which kind of
+// non-conforming messages.
+//
+// An ErrorStats is safe for concurrent use.
+type ErrorStats struct {
+	counts map[string]map[error]uint64
+	mu     sync.Mutex
+}
+
+// ErrorCount represents the amount of parse failures of a given class for a source
+type ErrorCount struct {
+	Class  error
+	Count  uint64
+	Source string
+}
+
+// ClassifyError returns the sentinel error of this package that the given error
+// wraps or is. If the error does not match any of the sentinel errors, ErrUnclassified
+// is returned. For a nil error, nil is returned.
+func ClassifyError(err error) error {
+	if err == nil {
+		return nil
+	}
+	for _, c := range errorClasses {
+		if errors.Is(err, c) {
+			return c
+		}
+	}
+	return ErrUnclassified
+}
+
+// NewErrorStats returns a new, empty ErrorStats
+func NewErrorStats() *ErrorStats {
+	return &ErrorStats{counts: make(map[string]map[error]uint64)}
+}
+
+// Add classifies the given error using ClassifyError and increments the counter of
+// that class for the given source. The source can be any string that identifies the
+// sender, i. e. the remote address or the hostname. It returns the class of the error.
+// A nil error is not counted.
+func (s *ErrorStats) Add(source string, err error) error {
+	c := ClassifyError(err)
+	if c == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sc, ok := s.counts[source]
+	if !ok {
+		sc = make(map[error]uint64)
+		s.counts[source] = sc
+	}
+	sc[c]++
+	return c
+}
+
+// Count returns the amount of parse failures of the given class for the given source
+func (s *ErrorStats) Count(source string, class error) uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.counts[source][class]
+}
+
+// Snapshot returns the current counters, sorted by source and class
+func (s *ErrorStats) Snapshot() []ErrorCount {
+	s.mu.Lock()
+	var ecs []ErrorCount
+	for src, sc := range s.counts {
+		for c, n := range sc {
+			ecs = append(ecs, ErrorCount{Class: c, Count: n, Source: src})
+		}
+	}
+	s.mu.Unlock()
+	sort.Slice(ecs, func(i, j int) bool {
+		if ecs[i].Source != ecs[j].Source {
+			return ecs[i].Source < ecs[j].Source
+		}
+		return ecs[i].Class.Error() < ecs[j].Class.Error()
+	})
+	return ecs
+}
+
+// Reset resets all counters
+func (s *ErrorStats) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.counts = make(map[string]map[error]uint64)
+}
@@ -0,0 +1,63 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// TestClassifyError tests the ClassifyError method
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"nil", nil, nil},
+		{"sentinel", ErrInvalidPrio, ErrInvalidPrio},
+		{"wrapped sentinel", fmt.Errorf("parse failed: %w", ErrWrongSDFormat), ErrWrongSDFormat},
+		{"unknown", errors.New("foo"), ErrUnclassified},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyError(tt.err); !errors.Is(got, tt.want) || (got == nil) != (tt.want == nil) {
+				t.Errorf("ClassifyError() => expected: %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestErrorStats tests the ErrorStats
+func TestErrorStats(t *testing.T) {
+	s := NewErrorStats()
+	s.Add("10.0.0.1", ErrInvalidPrio)
+	s.Add("10.0.0.1", fmt.Errorf("wrapped: %w", ErrInvalidPrio))
+	s.Add("10.0.0.1", ErrWrongSDFormat)
+	s.Add("10.0.0.2", errors.New("foo"))
+	s.Add("10.0.0.2", nil)
+
+	if c := s.Count("10.0.0.1", ErrInvalidPrio); c != 2 {
+		t.Errorf("Count() => expected: %d, got: %d", 2, c)
+	}
+	if c := s.Count("10.0.0.2", ErrUnclassified); c != 1 {
+		t.Errorf("Count() => expected: %d, got: %d", 1, c)
+	}
+	if c := s.Count("10.0.0.3", ErrInvalidPrio); c != 0 {
+		t.Errorf("Count() for unknown source => expected: %d, got: %d", 0, c)
+	}
+	snap := s.Snapshot()
+	if len(snap) != 3 {
+		t.Fatalf("Snapshot() => expected %d entries, got: %d", 3, len(snap))
+	}
+	if snap[0].Source != "10.0.0.1" || snap[2].Source != "10.0.0.2" {
+		t.Errorf("Snapshot() not sorted by source: %+v", snap)
+	}
+	s.Reset()
+	if len(s.Snapshot()) != 0 {
+		t.Errorf("Reset() did not reset the counters")
+	}
+}
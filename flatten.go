@@ -0,0 +1,75 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+// SDFlattening represents a scheme to flatten the structured data of a LogMsg into
+// single key/value pairs, as needed by output formats without nested structures (i. e.
+// CSV columns or ECS fields). Exporters use the same SDFlattening, so that the field
+// names are consistent across all output formats.
+type SDFlattening int
+
+const (
+	// SDFlattenDotted joins SD-ID and param name with a dot (i. e.
+	// "exampleSDID@32473.iut")
+	SDFlattenDotted SDFlattening = iota
+	// SDFlattenBracketed appends the param name in brackets to the SD-ID (i. e.
+	// "exampleSDID@32473[iut]")
+	SDFlattenBracketed
+	// SDFlattenStripped uses the param name without the SD-ID (i. e. "iut"). If the
+	// name of a param has been used by a previous param already, the dotted key is used
+	// instead, so that no param is lost.
+	SDFlattenStripped
+)
+
+// String satisfies the fmt.Stringer interface for the SDFlattening type
+func (f SDFlattening) String() string {
+	switch f {
+	case SDFlattenDotted:
+		return "dotted"
+	case SDFlattenBracketed:
+		return "bracketed"
+	case SDFlattenStripped:
+		return "stripped"
+	default:
+		return "unknown"
+	}
+}
+
+// Key returns the flattened key of the param with the given name of the structured data
+// element with the given SD-ID. For SDFlattenStripped, this is the param name.
+func (f SDFlattening) Key(id, name string) string {
+	switch f {
+	case SDFlattenBracketed:
+		return id + "[" + name + "]"
+	case SDFlattenStripped:
+		return name
+	default:
+		return id + "." + name
+	}
+}
+
+// Flatten returns the params of all the given structured data elements as single
+// key/value pairs, using the keys of the SDFlattening. The pairs are in wire order.
+// Elements without params are left out.
+func (f SDFlattening) Flatten(sd []StructuredDataElement) []StructuredDataParam {
+	var ps []StructuredDataParam
+	var seen map[string]struct{}
+	if f == SDFlattenStripped {
+		seen = make(map[string]struct{})
+	}
+	for _, e := range sd {
+		for _, p := range e.Param {
+			k := f.Key(e.ID, p.Name)
+			if seen != nil {
+				if _, ok := seen[k]; ok {
+					k = SDFlattenDotted.Key(e.ID, p.Name)
+				}
+				seen[k] = struct{}{}
+			}
+			ps = append(ps, StructuredDataParam{Name: k, Value: p.Value})
+		}
+	}
+	return ps
+}
@@ -0,0 +1,51 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestSDFlattening_Flatten tests the Flatten method of the SDFlattening
+func TestSDFlattening_Flatten(t *testing.T) {
+	sd := []StructuredDataElement{
+		{ID: "exampleSDID@32473", Param: []StructuredDataParam{{"iut", "3"}, {"eventID", "1011"}}},
+		{ID: "empty@32473"},
+		{ID: "origin", Param: []StructuredDataParam{{"ip", "192.0.2.1"}, {"ip", "192.0.2.2"}}},
+		{ID: "foo@1234", Param: []StructuredDataParam{{"iut", "4"}}},
+	}
+	tests := []struct {
+		f    SDFlattening
+		name string
+		want []StructuredDataParam
+	}{
+		{SDFlattenDotted, "dotted", []StructuredDataParam{
+			{"exampleSDID@32473.iut", "3"}, {"exampleSDID@32473.eventID", "1011"}, {"origin.ip", "192.0.2.1"},
+			{"origin.ip", "192.0.2.2"}, {"foo@1234.iut", "4"},
+		}},
+		{SDFlattenBracketed, "bracketed", []StructuredDataParam{
+			{"exampleSDID@32473[iut]", "3"}, {"exampleSDID@32473[eventID]", "1011"}, {"origin[ip]", "192.0.2.1"},
+			{"origin[ip]", "192.0.2.2"}, {"foo@1234[iut]", "4"},
+		}},
+		{SDFlattenStripped, "stripped", []StructuredDataParam{
+			{"iut", "3"}, {"eventID", "1011"}, {"ip", "192.0.2.1"}, {"origin.ip", "192.0.2.2"},
+			{"foo@1234.iut", "4"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.f.String() != tt.name {
+				t.Errorf("String() => expected: %s, got: %s", tt.name, tt.f.String())
+			}
+			if got := tt.f.Flatten(sd); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Flatten() => expected: %v, got: %v", tt.want, got)
+			}
+			if got := tt.f.Flatten(nil); got != nil {
+				t.Errorf("Flatten() without structured data => expected nil, got: %v", got)
+			}
+		})
+	}
+}
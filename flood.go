@@ -0,0 +1,174 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultFloodBurst is the amount of messages a source may send at once before a
+	// FloodDetector considers it flooding, if no burst is given
+	DefaultFloodBurst = 1000
+	// DefaultFloodRate is the amount of messages per second a source may send before a
+	// FloodDetector considers it flooding, if no rate is given
+	DefaultFloodRate = 100
+	// FloodSDID is the SD-ID of the structured data element that marks a message that
+	// has been received during a flood of its source
+	FloodSDID = "flood@32473"
+)
+
+// FloodDetector detects sources that exceed a configurable message rate and marks their
+// messages with a structured data element with the SD-ID FloodSDID, instead of dropping
+// them. Downstream systems can then handle message storms, i. e. by sampling or by
+// routing them to cheaper storage. Once a flood is over, a FloodSummary is emitted.
+//
+// Every source has a token bucket that holds up to burst tokens and is refilled with
+// rate tokens per second. Every message takes a token. A source is flooding once its
+// bucket is empty and until it has been refilled completely.
+//
+// A FloodDetector is safe for concurrent use.
+type FloodDetector struct {
+	burst   float64
+	emit    func(FloodSummary)
+	mu      sync.Mutex
+	now     func() time.Time
+	rate    float64
+	sources map[string]*floodSource
+}
+
+// floodSource represents the token bucket and flood state of a single source
+type floodSource struct {
+	count  uint64
+	last   time.Time
+	since  time.Time
+	tokens float64
+}
+
+// FloodSummary represents a flood of a single source that is over
+type FloodSummary struct {
+	// Count is the amount of messages that have been marked during the flood
+	Count  uint64
+	End    time.Time
+	Source string
+	Start  time.Time
+}
+
+// NewFloodDetector returns a new FloodDetector that considers sources flooding once
+// they exceed the given rate (in messages per second) after a burst of the given amount
+// of messages. Whenever a flood is over, the emit function is called with its summary,
+// if it is not nil. If the rate or burst is 0 or negative, DefaultFloodRate or
+// DefaultFloodBurst is used.
+func NewFloodDetector(rate float64, burst int, emit func(FloodSummary)) *FloodDetector {
+	if rate <= 0 {
+		rate = DefaultFloodRate
+	}
+	if burst <= 0 {
+		burst = DefaultFloodBurst
+	}
+	return &FloodDetector{
+		burst:   float64(burst),
+		emit:    emit,
+		now:     time.Now,
+		rate:    rate,
+		sources: make(map[string]*floodSource),
+	}
+}
+
+// Check accounts the given LogMsg for the given source and marks it with a structured
+// data element with the SD-ID FloodSDID if the source is flooding. The element holds
+// the start of the flood ("since") and the amount of messages marked so far ("count").
+// The source can be any string that identifies the sender, i. e. the remote address or
+// the hostname. It returns true if the LogMsg has been marked.
+func (d *FloodDetector) Check(source string, lm *LogMsg) bool {
+	t := d.now()
+	d.mu.Lock()
+	s, ok := d.sources[source]
+	if !ok {
+		s = &floodSource{last: t, tokens: d.burst}
+		d.sources[source] = s
+	}
+	sum, ended := d.refill(source, s, t)
+	flooding := s.tokens < 1 || s.count > 0
+	if s.tokens >= 1 {
+		s.tokens--
+	}
+	if flooding {
+		if s.count == 0 {
+			s.since = t
+		}
+		s.count++
+		lm.StructuredData = append(lm.StructuredData, StructuredDataElement{ID: FloodSDID, Param: []StructuredDataParam{
+			{Name: "since", Value: s.since.Format(time.RFC3339Nano)},
+			{Name: "count", Value: strconv.FormatUint(s.count, 10)},
+		}})
+	}
+	d.mu.Unlock()
+	if ended {
+		d.send([]FloodSummary{sum})
+	}
+	return flooding
+}
+
+// Flooding returns true if the given source is currently flooding
+func (d *FloodDetector) Flooding(source string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	s, ok := d.sources[source]
+	return ok && s.count > 0
+}
+
+// Tick ends the floods of all sources that have been quiet long enough for their bucket
+// to be refilled and emits their summaries. It also forgets those sources, so it should
+// be called periodically (i. e. by a time.Ticker) to keep the memory usage bounded.
+func (d *FloodDetector) Tick() {
+	t := d.now()
+	var sums []FloodSummary
+	d.mu.Lock()
+	for src, s := range d.sources {
+		sum, ended := d.refill(src, s, t)
+		if ended {
+			sums = append(sums, sum)
+		}
+		if s.tokens >= d.burst {
+			delete(d.sources, src)
+		}
+	}
+	d.mu.Unlock()
+	sort.Slice(sums, func(i, j int) bool { return sums[i].Source < sums[j].Source })
+	d.send(sums)
+}
+
+// refill refills the bucket of the given source up to time t. If the source has been
+// flooding and its bucket is full again, the flood is ended and its summary returned.
+// It needs to be called with d.mu held.
+func (d *FloodDetector) refill(src string, s *floodSource, t time.Time) (FloodSummary, bool) {
+	if el := t.Sub(s.last); el > 0 {
+		s.tokens += el.Seconds() * d.rate
+		if s.tokens > d.burst {
+			s.tokens = d.burst
+		}
+		s.last = t
+	}
+	if s.count == 0 || s.tokens < d.burst {
+		return FloodSummary{}, false
+	}
+	sum := FloodSummary{Count: s.count, End: t, Source: src, Start: s.since}
+	s.count = 0
+	return sum, true
+}
+
+// send hands the summaries to the emit function, if there is anything to emit
+func (d *FloodDetector) send(sums []FloodSummary) {
+	if len(sums) == 0 || d.emit == nil {
+		return
+	}
+	for _, s := range sums {
+		d.emit(s)
+	}
+}
@@ -0,0 +1,86 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"testing"
+	"time"
+)
+
+// TestFloodDetector tests the FloodDetector
+func TestFloodDetector(t *testing.T) {
+	now := time.Date(2023, 1, 10, 12, 0, 0, 0, time.UTC)
+	var sums []FloodSummary
+	d := NewFloodDetector(10, 3, func(s FloodSummary) { sums = append(sums, s) })
+	d.now = func() time.Time { return now }
+
+	tests := []struct {
+		name    string
+		advance time.Duration
+		src     string
+		marked  bool
+		count   string
+		sums    int
+	}{
+		{"burst 1", 0, "a", false, "", 0},
+		{"burst 2", 0, "a", false, "", 0},
+		{"burst 3", 0, "a", false, "", 0},
+		{"flood start", 0, "a", true, "1", 0},
+		{"other source", 0, "b", false, "", 0},
+		{"flood", 0, "a", true, "2", 0},
+		{"flood with refilled token", time.Millisecond * 100, "a", true, "3", 0},
+		{"flood end", time.Second, "a", false, "", 1},
+		{"after flood", 0, "a", false, "", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = now.Add(tt.advance)
+			var lm LogMsg
+			if marked := d.Check(tt.src, &lm); marked != tt.marked {
+				t.Fatalf("Check() => expected: %t, got: %t", tt.marked, marked)
+			}
+			if d.Flooding(tt.src) != tt.marked {
+				t.Errorf("Flooding() => expected: %t", tt.marked)
+			}
+			if tt.marked {
+				if len(lm.StructuredData) != 1 || lm.StructuredData[0].ID != FloodSDID ||
+					lm.StructuredData[0].Param[1].Value != tt.count {
+					t.Errorf("Check() => unexpected structured data: %+v", lm.StructuredData)
+				}
+			} else if len(lm.StructuredData) != 0 {
+				t.Errorf("Check() => unexpected structured data: %+v", lm.StructuredData)
+			}
+			if len(sums) != tt.sums {
+				t.Errorf("Check() => expected %d summaries, got: %d", tt.sums, len(sums))
+			}
+		})
+	}
+	if sums[0].Source != "a" || sums[0].Count != 3 || sums[0].End.Sub(sums[0].Start) != time.Second+time.Millisecond*100 {
+		t.Errorf("Check() => unexpected summary: %+v", sums[0])
+	}
+}
+
+// TestFloodDetector_Tick tests that Tick ends the floods of quiet sources
+func TestFloodDetector_Tick(t *testing.T) {
+	now := time.Now()
+	var sums []FloodSummary
+	d := NewFloodDetector(1, 1, func(s FloodSummary) { sums = append(sums, s) })
+	d.now = func() time.Time { return now }
+	for _, src := range []string{"b", "a", "a", "b", "c"} {
+		d.Check(src, &LogMsg{})
+	}
+	d.Tick()
+	if len(sums) != 0 || !d.Flooding("a") {
+		t.Fatalf("Tick() => expected floods to continue, got: %+v", sums)
+	}
+	now = now.Add(time.Second)
+	d.Tick()
+	if len(sums) != 2 || sums[0].Source != "a" || sums[1].Source != "b" {
+		t.Errorf("Tick() => unexpected summaries: %+v", sums)
+	}
+	if d.Flooding("a") || len(d.sources) != 0 {
+		t.Errorf("Tick() => expected all sources to be forgotten, got: %d", len(d.sources))
+	}
+}
@@ -0,0 +1,158 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+// Package fortigate implements the decoding of the key=value log format of Fortinet
+// FortiGate firewalls (i. e. `date=2023-01-10 time=11:37:47 devname="fw01" logid=...`).
+// Decode is a parsesyslog.BodyDecoder for messages that have been sent with a syslog
+// header (FortiOS "set format rfc5424" or via a relay). Messages in the default FortiOS
+// format consist of the PRI and the key=value pairs only and are parsed with the Parser
+// of this package.
+// See: https://docs.fortinet.com/document/fortigate/7.4.0/fortios-log-message-reference
+package fortigate
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/wneessen/go-parsesyslog"
+)
+
+// MsgType is the LogMsgType of the LogMsg returned by the Parser
+const MsgType parsesyslog.LogMsgType = "FortiGate"
+
+// SDID is the SD-ID of the structured data element that holds the key=value pairs of
+// a FortiGate log message, using the private enterprise number of Fortinet
+const SDID = "fortigate@12356"
+
+// Type represents the ParserType for this Parser
+const Type parsesyslog.ParserType = "fortigate"
+
+// msg represents a FortiGate log message parser
+type msg struct {
+	buf  bytes.Buffer
+	opts parsesyslog.Options
+}
+
+// init registers the Parser
+func init() {
+	fn := func(o parsesyslog.Options) (parsesyslog.Parser, error) {
+		return &msg{opts: o}, nil
+	}
+	parsesyslog.RegisterWithOptions(Type, fn)
+}
+
+// ParseString returns the parsed log message read from a string (as buffered i/o)
+func (m *msg) ParseString(s string) (parsesyslog.LogMsg, error) {
+	return m.ParseReader(strings.NewReader(s))
+}
+
+// ParseReader parses a FortiGate log message in the default FortiOS format, which
+// consists of the PRI and the key=value pairs, until the end of the io.Reader. The
+// pairs are stored as structured data element with the SD-ID SDID and the whole body
+// as Message. The Hostname is taken from the "devname" field, the Timestamp from the
+// "date", "time" and "tz" fields (or the local time zone, if there is no "tz" field).
+// It satisfies the Parser interface
+func (m *msg) ParseReader(r io.Reader) (parsesyslog.LogMsg, error) {
+	var lm parsesyslog.LogMsg
+	lm.Type = MsgType
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	if err := parsesyslog.ParsePriority(br, &m.buf, &lm); err != nil {
+		if errors.Is(err, io.EOF) {
+			return lm, parsesyslog.ErrPrematureEOF
+		}
+		return lm, err
+	}
+	b, err := io.ReadAll(br)
+	if err != nil {
+		return lm, err
+	}
+	b = bytes.TrimRight(b, "\r\n\x00")
+	if m.opts.Wants(parsesyslog.FieldMessage) {
+		lm.Message.Write(b)
+		lm.MsgLength = lm.Message.Len()
+	}
+
+	ps, err := parsesyslog.ParseKeyValues(string(b))
+	if err != nil {
+		return lm, err
+	}
+	if !isFortiGate(ps) {
+		return lm, parsesyslog.ErrWrongFormat
+	}
+	setHeader(&lm, ps)
+	if m.opts.Wants(parsesyslog.FieldStructuredData) {
+		lm.StructuredData = append(lm.StructuredData, parsesyslog.StructuredDataElement{ID: SDID, Param: ps})
+	}
+	return lm, nil
+}
+
+// Decode decodes the key=value pairs of a FortiGate log message body and stores them
+// as structured data element with the SD-ID SDID. If the LogMsg has no Hostname or
+// Timestamp, they are taken from the "devname" and the "date", "time" and "tz" fields.
+// Message bodies that do not consist of key=value pairs with at least the "logid" and
+// the "devid" or "devname" fields are not recognized. Decode satisfies the
+// parsesyslog.BodyDecoder type.
+func Decode(lm *parsesyslog.LogMsg) bool {
+	ps, err := parsesyslog.ParseKeyValues(lm.Message.String())
+	if err != nil || !isFortiGate(ps) {
+		return false
+	}
+	setHeader(lm, ps)
+	lm.StructuredData = append(lm.StructuredData, parsesyslog.StructuredDataElement{ID: SDID, Param: ps})
+	return true
+}
+
+// isFortiGate returns true if the given key=value pairs contain the fields that every
+// FortiGate log message has
+func isFortiGate(ps []parsesyslog.StructuredDataParam) bool {
+	var logid, dev bool
+	for _, p := range ps {
+		switch p.Name {
+		case "logid":
+			logid = true
+		case "devid", "devname":
+			dev = true
+		}
+	}
+	return logid && dev
+}
+
+// setHeader sets the Hostname and Timestamp of the LogMsg from the given key=value
+// pairs, if they are not set yet
+func setHeader(lm *parsesyslog.LogMsg, ps []parsesyslog.StructuredDataParam) {
+	var date, clock, tz string
+	for _, p := range ps {
+		switch p.Name {
+		case "devname":
+			if lm.Hostname == "" {
+				lm.Hostname = p.Value
+			}
+		case "date":
+			date = p.Value
+		case "time":
+			clock = p.Value
+		case "tz":
+			tz = p.Value
+		}
+	}
+	if !lm.Timestamp.IsZero() || date == "" || clock == "" {
+		return
+	}
+	if tz != "" {
+		if ts, err := time.Parse("2006-01-02 15:04:05 -0700", date+" "+clock+" "+tz); err == nil {
+			lm.Timestamp = ts
+			return
+		}
+	}
+	if ts, err := time.ParseInLocation("2006-01-02 15:04:05", date+" "+clock, time.Local); err == nil {
+		lm.Timestamp = ts
+	}
+}
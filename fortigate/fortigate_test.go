@@ -0,0 +1,125 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package fortigate
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/wneessen/go-parsesyslog"
+	"github.com/wneessen/go-parsesyslog/rfc5424"
+)
+
+const testBody = `date=2023-01-10 time=11:37:47 devname="fw 01" devid="FGT60E" logid="0000000013" ` +
+	`type="traffic" subtype="forward" level="notice" srcip=10.1.1.2 dstip=192.0.2.1 action="close" tz="+0100"`
+
+// TestMsg_ParseString tests the Parser for the default FortiOS format
+func TestMsg_ParseString(t *testing.T) {
+	p, err := parsesyslog.New(Type)
+	if err != nil {
+		t.Fatalf("failed to create new parser: %s", err)
+	}
+	tests := []struct {
+		name string
+		msg  string
+		err  error
+	}{
+		{"valid", "<189>" + testBody + "\n", nil},
+		{"no fortigate fields", "<189>date=2023-01-10 time=11:37:47 level=notice", parsesyslog.ErrWrongFormat},
+		{"unterminated quote", `<189>logid="0000000013 devname=fw`, parsesyslog.ErrWrongFormat},
+		{"no priority", testBody, parsesyslog.ErrWrongFormat},
+		{"empty", "", parsesyslog.ErrPrematureEOF},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lm, err := p.ParseString(tt.msg)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Errorf("ParseString() => expected error: %s, got: %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseString() failed: %s", err)
+			}
+			if lm.Type != MsgType {
+				t.Errorf("ParseString() type => expected: %s, got: %s", MsgType, lm.Type)
+			}
+			if lm.Facility != 23 || lm.Severity != 5 {
+				t.Errorf("ParseString() priority => unexpected facility/severity: %d/%d", lm.Facility, lm.Severity)
+			}
+			if lm.Hostname != "fw 01" {
+				t.Errorf("ParseString() hostname => expected: %s, got: %s", "fw 01", lm.Hostname)
+			}
+			want := time.Date(2023, 1, 10, 10, 37, 47, 0, time.UTC)
+			if !lm.Timestamp.Equal(want) {
+				t.Errorf("ParseString() timestamp => expected: %s, got: %s", want, lm.Timestamp)
+			}
+			if lm.Message.String() != testBody {
+				t.Errorf("ParseString() message => expected: %s, got: %s", testBody, lm.Message.String())
+			}
+			if len(lm.StructuredData) != 1 || lm.StructuredData[0].ID != SDID {
+				t.Fatalf("ParseString() => unexpected structured data: %v", lm.StructuredData)
+			}
+			if ps := lm.StructuredData[0].Param; len(ps) != 12 || ps[11].Name != "tz" || ps[7].Value != "notice" {
+				t.Errorf("ParseString() => unexpected params: %v", ps)
+			}
+		})
+	}
+}
+
+// TestMsg_ParseString_fields tests that the Parser respects WithFields
+func TestMsg_ParseString_fields(t *testing.T) {
+	p, err := parsesyslog.New(Type, parsesyslog.WithFields(parsesyslog.FieldHostname))
+	if err != nil {
+		t.Fatalf("failed to create new parser: %s", err)
+	}
+	lm, err := p.ParseString("<189>" + testBody)
+	if err != nil {
+		t.Fatalf("ParseString() failed: %s", err)
+	}
+	if lm.Hostname != "fw 01" {
+		t.Errorf("ParseString() hostname => expected: %s, got: %s", "fw 01", lm.Hostname)
+	}
+	if lm.Message.Len() != 0 || len(lm.StructuredData) != 0 {
+		t.Errorf("ParseString() => expected no message and structured data")
+	}
+}
+
+// TestDecode tests Decode as BodyDecoder of the RFC5424 parser
+func TestDecode(t *testing.T) {
+	p, err := parsesyslog.New(rfc5424.Type, parsesyslog.WithBodyDecoders(Decode))
+	if err != nil {
+		t.Fatalf("failed to create new parser: %s", err)
+	}
+	tests := []struct {
+		name    string
+		msg     string
+		decoded bool
+		host    string
+	}{
+		{"fortigate body", "<189>1 2023-01-10T11:37:47Z relay - - - - " + testBody, true, "relay"},
+		{"fortigate body without hostname", "<189>1 2023-01-10T11:37:47Z - - - - - " + testBody, true, "fw 01"},
+		{"other body", "<189>1 2023-01-10T11:37:47Z relay - - - - user=root logged in", false, "relay"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lm, err := p.ParseString(tt.msg)
+			if err != nil {
+				t.Fatalf("ParseString() failed: %s", err)
+			}
+			if decoded := len(lm.StructuredData) == 1 && lm.StructuredData[0].ID == SDID; decoded != tt.decoded {
+				t.Errorf("Decode() => expected decoded: %t, got: %v", tt.decoded, lm.StructuredData)
+			}
+			if lm.Hostname != tt.host {
+				t.Errorf("Decode() hostname => expected: %s, got: %s", tt.host, lm.Hostname)
+			}
+			if want := time.Date(2023, 1, 10, 11, 37, 47, 0, time.UTC); !lm.Timestamp.Equal(want) {
+				t.Errorf("Decode() timestamp was overwritten: %s", lm.Timestamp)
+			}
+		})
+	}
+}
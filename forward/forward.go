@@ -0,0 +1,186 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+// Package forward implements a client that forwards log messages to a syslog receiver,
+// i. e. to build parse-modify-forward pipelines or relays
+package forward
+
+import (
+	"compress/zlib"
+	"crypto/tls"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/wneessen/go-parsesyslog"
+	"github.com/wneessen/go-parsesyslog/rfc5424"
+)
+
+// DefaultDialTimeout is the maximum duration Dial and DialTLS wait for a connection to
+// be established
+const DefaultDialTimeout = time.Second * 30
+
+// Client forwards log messages in RFC5424 format over a single connection. On stream
+// connections (i. e. TCP or TLS), every message is framed with octet-counting as
+// described in RFC6587 and RFC5425. On datagram connections (i. e. UDP), every message
+// is sent as a single datagram.
+//
+// A Client is safe for concurrent use.
+type Client struct {
+	compress Compressor
+	conn     net.Conn
+	cw       CompressWriter
+	mu       sync.Mutex
+	packet   bool
+	timeout  time.Duration
+}
+
+// CompressWriter is a writer that compresses the data written to it, i. e. a
+// *zlib.Writer. Flush writes the pending data to the underlying writer, so that the
+// receiver is able to decompress every message as soon as it has been sent. Close
+// flushes the pending data and ends the compressed stream.
+type CompressWriter interface {
+	io.WriteCloser
+	Flush() error
+}
+
+// Compressor returns a CompressWriter that writes the compressed data to the given
+// io.Writer
+type Compressor func(w io.Writer) CompressWriter
+
+// Option is a function that configures a Client
+type Option func(*Client)
+
+// WithCompression makes the Client compress the stream of messages on stream
+// connections with the given Compressor (i. e. Zlib), to reduce the bandwidth of high
+// volume relays. The compressed stream is flushed after every message. The receiver has
+// to be configured to decompress the stream, as there is no negotiation of compression
+// in the syslog protocols. Datagram connections are not compressed.
+func WithCompression(c Compressor) Option {
+	return func(cl *Client) {
+		cl.compress = c
+	}
+}
+
+// WithWriteTimeout sets the maximum duration a Client waits for a message to be written.
+// By default, there is no write timeout.
+func WithWriteTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = d
+	}
+}
+
+// Dial connects to the syslog receiver at the given address on the given network (i. e.
+// "tcp", "udp" or "unix") and returns a Client for the connection
+func Dial(network, addr string, opts ...Option) (*Client, error) {
+	conn, err := net.DialTimeout(network, addr, DefaultDialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(conn, opts...), nil
+}
+
+// DialTLS connects to the syslog receiver at the given TCP address via TLS as described
+// in RFC5425 and returns a Client for the connection. All fields of the given tls.Config
+// that are not set are filled with the syslog TLS defaults of parsesyslog.DefaultTLSConfig,
+// which includes a session cache, so that a Client that reconnects with the same
+// tls.Config resumes the previous TLS session. To make use of it, pass the tls.Config
+// returned by parsesyslog.DefaultTLSConfig to every call. Please note that TLS 1.3 servers
+// send their session tickets after the handshake and a Client, which never reads from
+// the connection, does not receive them. Session resumption is therefore limited to
+// TLS 1.2.
+// See: https://datatracker.ietf.org/doc/html/rfc5425
+func DialTLS(addr string, cfg *tls.Config, opts ...Option) (*Client, error) {
+	d := &net.Dialer{Timeout: DefaultDialTimeout}
+	conn, err := tls.DialWithDialer(d, "tcp", addr, parsesyslog.DefaultTLSConfig(cfg))
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(conn, opts...), nil
+}
+
+// NewClient returns a Client that forwards the log messages over the given connection
+func NewClient(conn net.Conn, opts ...Option) *Client {
+	c := &Client{conn: conn}
+	_, c.packet = conn.(net.PacketConn)
+	for _, o := range opts {
+		if o == nil {
+			continue
+		}
+		o(c)
+	}
+	if c.compress != nil && !c.packet {
+		c.cw = c.compress(conn)
+	}
+	return c
+}
+
+// Zlib returns a Compressor that compresses the stream in the zlib format (RFC1950) with
+// the given compression level (i. e. zlib.BestSpeed), as used by some syslog-ng
+// deployments. Invalid levels are replaced by zlib.DefaultCompression.
+// See: https://datatracker.ietf.org/doc/html/rfc1950
+func Zlib(level int) Compressor {
+	if level < zlib.HuffmanOnly || level > zlib.BestCompression {
+		level = zlib.DefaultCompression
+	}
+	return func(w io.Writer) CompressWriter {
+		zw, _ := zlib.NewWriterLevel(w, level)
+		return zw
+	}
+}
+
+// Send forwards the given LogMsg. It returns an error if the LogMsg can not be
+// represented in RFC5424 (see rfc5424.Marshal) or could not be written.
+func (c *Client) Send(lm parsesyslog.LogMsg) error {
+	var opts []rfc5424.MarshalOption
+	if !c.packet {
+		opts = append(opts, rfc5424.WithOctetCounting())
+	}
+	b, err := rfc5424.Marshal(lm, opts...)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.timeout > 0 {
+		if err := c.conn.SetWriteDeadline(time.Now().Add(c.timeout)); err != nil {
+			return err
+		}
+	}
+	if c.cw == nil {
+		_, err = c.conn.Write(b)
+		return err
+	}
+	if _, err = c.cw.Write(b); err != nil {
+		return err
+	}
+	return c.cw.Flush()
+}
+
+// ConnectionState returns the state of the TLS connection of the Client. The returned
+// bool is false if the Client does not use TLS.
+func (c *Client) ConnectionState() (tls.ConnectionState, bool) {
+	tc, ok := c.conn.(*tls.Conn)
+	if !ok {
+		return tls.ConnectionState{}, false
+	}
+	return tc.ConnectionState(), true
+}
+
+// Close closes the connection of the Client. If the Client compresses the stream, the
+// compressed stream is ended first.
+func (c *Client) Close() error {
+	if c.cw != nil {
+		c.mu.Lock()
+		err := c.cw.Close()
+		c.mu.Unlock()
+		if err != nil {
+			_ = c.conn.Close()
+			return err
+		}
+	}
+	return c.conn.Close()
+}
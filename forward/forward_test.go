@@ -0,0 +1,295 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package forward
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/wneessen/go-parsesyslog"
+	"github.com/wneessen/go-parsesyslog/listener"
+	"github.com/wneessen/go-parsesyslog/rfc5424"
+)
+
+// received is a message received by the test listener
+type received struct {
+	lm parsesyslog.LogMsg
+	si listener.SourceInfo
+}
+
+// testServer starts a listener.Server on a random local port and returns its address
+// and the channel of the received messages. If cfg is not nil, the Server uses TLS.
+func testServer(t *testing.T, cfg *tls.Config) (string, <-chan received) {
+	t.Helper()
+	ch := make(chan received, 10)
+	s := listener.New(rfc5424.Type, listener.HandlerFunc(func(lm parsesyslog.LogMsg, si listener.SourceInfo) {
+		ch <- received{lm: lm.Clone(), si: si}
+	}))
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		if cfg != nil {
+			done <- s.ServeTLS(ln, cfg)
+			return
+		}
+		done <- s.Serve(ln)
+	}()
+	t.Cleanup(func() {
+		_ = s.Close()
+		if err := <-done; !errors.Is(err, parsesyslog.ErrServerClosed) {
+			t.Errorf("Serve() after Close() => expected: %s, got: %s", parsesyslog.ErrServerClosed, err)
+		}
+	})
+	return ln.Addr().String(), ch
+}
+
+// receive waits for the next received message
+func receive(t *testing.T, ch <-chan received) received {
+	t.Helper()
+	select {
+	case r := <-ch:
+		return r
+	case <-time.After(time.Second * 5):
+		t.Fatal("timeout waiting for message")
+	}
+	return received{}
+}
+
+// testCert returns a self-signed certificate for the given common name
+func testCert(t *testing.T, cn string) (tls.Certificate, *x509.Certificate) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %s", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: cn},
+		DNSNames:              []string{cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %s", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %s", err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: cert}, cert
+}
+
+// testLogMsg returns a LogMsg with the given message
+func testLogMsg(msg string) parsesyslog.LogMsg {
+	lm := parsesyslog.LogMsg{
+		Priority: 165, Facility: 20, Severity: 5, ProtoVersion: 1, Hostname: "mymachine",
+		Timestamp: time.Date(2003, 10, 11, 22, 14, 15, 3000000, time.UTC), Type: parsesyslog.RFC5424,
+	}
+	lm.Message.WriteString(msg)
+	lm.MsgLength = lm.Message.Len()
+	return lm
+}
+
+// TestClient_Send tests forwarding messages via TCP
+func TestClient_Send(t *testing.T) {
+	addr, ch := testServer(t, nil)
+	c, err := Dial("tcp", addr, WithWriteTimeout(time.Second))
+	if err != nil {
+		t.Fatalf("Dial() failed: %s", err)
+	}
+	defer func() { _ = c.Close() }()
+	if _, ok := c.ConnectionState(); ok {
+		t.Errorf("ConnectionState() expected no TLS state for a TCP connection")
+	}
+	for _, m := range []string{"first", "second\nline"} {
+		lm := testLogMsg(m)
+		if err := c.Send(lm); err != nil {
+			t.Fatalf("Send() failed: %s", err)
+		}
+		r := receive(t, ch)
+		for _, d := range parsesyslog.Diff(lm, r.lm) {
+			t.Errorf("Send() wrong message => %s", d)
+		}
+	}
+	if err := c.Send(parsesyslog.LogMsg{Priority: 200}); !errors.Is(err, parsesyslog.ErrInvalidPrio) {
+		t.Errorf("Send() => expected: %s, got: %v", parsesyslog.ErrInvalidPrio, err)
+	}
+}
+
+// TestClient_Send_compressed tests forwarding messages via TCP with zlib compression
+func TestClient_Send_compressed(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+	defer func() { _ = ln.Close() }()
+	ch := make(chan received, 10)
+	done := make(chan error, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			done <- err
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		zr, err := zlib.NewReader(conn)
+		if err != nil {
+			done <- err
+			return
+		}
+		p, err := parsesyslog.New(rfc5424.Type)
+		if err != nil {
+			done <- err
+			return
+		}
+		br := bufio.NewReader(zr)
+		for i := 0; i < 2; i++ {
+			lm, err := p.ParseReader(br)
+			if err != nil {
+				done <- err
+				return
+			}
+			ch <- received{lm: lm}
+		}
+		// The end of the compressed stream has to be valid after Close
+		_, err = io.ReadAll(br)
+		done <- err
+	}()
+
+	c, err := Dial("tcp", ln.Addr().String(), WithCompression(Zlib(zlib.BestSpeed)))
+	if err != nil {
+		t.Fatalf("Dial() failed: %s", err)
+	}
+	msg := bytes.Repeat([]byte("compressible "), 100)
+	for _, m := range []string{"first", string(msg)} {
+		lm := testLogMsg(m)
+		if err := c.Send(lm); err != nil {
+			t.Fatalf("Send() failed: %s", err)
+		}
+		r := receive(t, ch)
+		for _, d := range parsesyslog.Diff(lm, r.lm) {
+			t.Errorf("Send() wrong message => %s", d)
+		}
+	}
+	if err := c.Close(); err != nil {
+		t.Errorf("Close() failed: %s", err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("failed to read compressed stream: %s", err)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("timeout waiting for the end of the compressed stream")
+	}
+}
+
+// TestZlib tests that Zlib replaces invalid compression levels
+func TestZlib(t *testing.T) {
+	for _, l := range []int{zlib.DefaultCompression, zlib.BestCompression, 42, -5} {
+		var buf bytes.Buffer
+		cw := Zlib(l)(&buf)
+		if _, err := cw.Write([]byte("test")); err != nil {
+			t.Fatalf("Write() with level %d failed: %s", l, err)
+		}
+		if err := cw.Close(); err != nil {
+			t.Fatalf("Close() with level %d failed: %s", l, err)
+		}
+		zr, err := zlib.NewReader(&buf)
+		if err != nil {
+			t.Fatalf("failed to read stream of level %d: %s", l, err)
+		}
+		if b, err := io.ReadAll(zr); err != nil || string(b) != "test" {
+			t.Errorf("Zlib(%d) => expected: %q, got: %q (%v)", l, "test", b, err)
+		}
+	}
+}
+
+// TestClient_Send_udp tests forwarding messages via UDP
+func TestClient_Send_udp(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+	defer func() { _ = pc.Close() }()
+	c, err := Dial("udp", pc.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("Dial() failed: %s", err)
+	}
+	defer func() { _ = c.Close() }()
+	if err := c.Send(testLogMsg("datagram")); err != nil {
+		t.Fatalf("Send() failed: %s", err)
+	}
+	buf := make([]byte, 1024)
+	_ = pc.SetReadDeadline(time.Now().Add(time.Second * 5))
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("failed to read datagram: %s", err)
+	}
+	want := "<165>1 2003-10-11T22:14:15.003Z mymachine - - - - datagram"
+	if string(buf[:n]) != want {
+		t.Errorf("Send() wrong datagram => expected: %q, got: %q", want, buf[:n])
+	}
+}
+
+// TestDialTLS tests forwarding messages via TLS with the syslog TLS defaults
+func TestDialTLS(t *testing.T) {
+	srvCert, srvX509 := testCert(t, "server")
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(srvX509)
+	addr, ch := testServer(t, &tls.Config{Certificates: []tls.Certificate{srvCert}})
+
+	// Session resumption of a client that does not read is limited to TLS 1.2
+	cfg := parsesyslog.DefaultTLSConfig(&tls.Config{
+		RootCAs: rootCAs, ServerName: "server", MaxVersion: tls.VersionTLS12,
+	})
+	for i, wantResume := range []bool{false, true} {
+		c, err := DialTLS(addr, cfg)
+		if err != nil {
+			t.Fatalf("DialTLS() failed: %s", err)
+		}
+		if err := c.Send(testLogMsg("via TLS")); err != nil {
+			t.Fatalf("Send() failed: %s", err)
+		}
+		r := receive(t, ch)
+		_ = c.Close()
+		if r.lm.Message.String() != "via TLS" {
+			t.Errorf("Send() wrong message => expected: %s, got: %s", "via TLS", r.lm.Message.String())
+		}
+		cs, ok := c.ConnectionState()
+		if !ok {
+			t.Fatalf("ConnectionState() expected TLS state")
+		}
+		if cs.NegotiatedProtocol != parsesyslog.ALPNProtocol || r.si.TLS.NegotiatedProtocol != parsesyslog.ALPNProtocol {
+			t.Errorf("DialTLS() wrong ALPN protocol => expected: %s, got: %s/%s", parsesyslog.ALPNProtocol,
+				cs.NegotiatedProtocol, r.si.TLS.NegotiatedProtocol)
+		}
+		if cs.DidResume != wantResume {
+			t.Errorf("DialTLS() connection %d session resumption => expected: %t, got: %t", i, wantResume,
+				cs.DidResume)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package gelf
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/wneessen/go-parsesyslog"
+)
+
+// GELF chunking constants
+// See: https://go2docs.graylog.org/current/getting_in_log_data/gelf.html#GELFviaUDP
+const (
+	// chunkHeaderLen is the length of the header of a chunk
+	chunkHeaderLen = 12
+	// maxChunks is the maximum number of chunks of a message
+	maxChunks = 128
+)
+
+// DefaultChunkSize is the default maximum size of a chunk (including its header) that
+// is used by Chunk. It fits into the MTU of most networks.
+const DefaultChunkSize = 1420
+
+// DefaultChunkTimeout is the default time after which an incomplete chunked message is
+// discarded by an Assembler, as recommended by the GELF specification
+const DefaultChunkTimeout = 5 * time.Second
+
+// DefaultMaxPending is the default maximum number of incomplete chunked messages an
+// Assembler keeps
+const DefaultMaxPending = 1024
+
+// chunkMagic are the magic bytes that start a chunk
+var chunkMagic = []byte{0x1e, 0x0f}
+
+// Assembler reassembles chunked GELF messages, as sent via UDP. It is safe for
+// concurrent use.
+type Assembler struct {
+	maxPending int
+	mu         sync.Mutex
+	now        func() time.Time
+	pending    map[[8]byte]*chunkedMsg
+	timeout    time.Duration
+}
+
+// chunkedMsg is an incomplete chunked message
+type chunkedMsg struct {
+	chunks  [][]byte
+	first   time.Time
+	missing int
+}
+
+// NewAssembler returns a new Assembler that discards incomplete chunked messages after
+// the given timeout. A timeout <= 0 results in DefaultChunkTimeout.
+func NewAssembler(timeout time.Duration) *Assembler {
+	if timeout <= 0 {
+		timeout = DefaultChunkTimeout
+	}
+	return &Assembler{
+		maxPending: DefaultMaxPending,
+		now:        time.Now,
+		pending:    make(map[[8]byte]*chunkedMsg),
+		timeout:    timeout,
+	}
+}
+
+// Add adds the given datagram to the Assembler. If the datagram completes a chunked
+// message, the reassembled message is returned, which can then be passed to Decode. A
+// datagram that is not a chunk is returned as is. As long as a chunked message is
+// incomplete, Add returns nil. Duplicate chunks are ignored.
+//
+// Malformed chunks result in ErrWrongFormat. If the maximum number of incomplete
+// messages is reached, the oldest one is discarded.
+func (a *Assembler) Add(d []byte) ([]byte, error) {
+	if !bytes.HasPrefix(d, chunkMagic) {
+		return d, nil
+	}
+	if len(d) < chunkHeaderLen {
+		return nil, fmt.Errorf("%w: chunk header too short", parsesyslog.ErrWrongFormat)
+	}
+	var id [8]byte
+	copy(id[:], d[2:10])
+	seq, count := int(d[10]), int(d[11])
+	if count == 0 || count > maxChunks || seq >= count {
+		return nil, fmt.Errorf("%w: invalid chunk %d of %d", parsesyslog.ErrWrongFormat, seq, count)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	now := a.now()
+	a.expire(now)
+	m, ok := a.pending[id]
+	if !ok {
+		if len(a.pending) >= a.maxPending {
+			a.evictOldest()
+		}
+		m = &chunkedMsg{chunks: make([][]byte, count), first: now, missing: count}
+		a.pending[id] = m
+	}
+	if len(m.chunks) != count {
+		delete(a.pending, id)
+		return nil, fmt.Errorf("%w: inconsistent chunk count %d", parsesyslog.ErrWrongFormat, count)
+	}
+	if m.chunks[seq] != nil {
+		return nil, nil
+	}
+	m.chunks[seq] = append([]byte(nil), d[chunkHeaderLen:]...)
+	m.missing--
+	if m.missing > 0 {
+		return nil, nil
+	}
+	delete(a.pending, id)
+	return bytes.Join(m.chunks, nil), nil
+}
+
+// Pending returns the number of incomplete chunked messages
+func (a *Assembler) Pending() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.pending)
+}
+
+// expire discards all incomplete messages whose first chunk is older than the timeout
+func (a *Assembler) expire(now time.Time) {
+	for id, m := range a.pending {
+		if now.Sub(m.first) > a.timeout {
+			delete(a.pending, id)
+		}
+	}
+}
+
+// evictOldest discards the incomplete message whose first chunk is the oldest
+func (a *Assembler) evictOldest() {
+	var oid [8]byte
+	var ot time.Time
+	for id, m := range a.pending {
+		if ot.IsZero() || m.first.Before(ot) {
+			oid, ot = id, m.first
+		}
+	}
+	delete(a.pending, oid)
+}
+
+// Chunk splits the given GELF message into chunks of at most the given size (including
+// the chunk header), using a random message ID. A message that fits into a single
+// datagram is returned as the only element without chunk header. A size <= 0 results
+// in DefaultChunkSize. Messages that require more than 128 chunks result in
+// ErrWrongFormat.
+func Chunk(b []byte, size int) ([][]byte, error) {
+	if size <= 0 {
+		size = DefaultChunkSize
+	}
+	if len(b) <= size {
+		return [][]byte{b}, nil
+	}
+	if size <= chunkHeaderLen {
+		return nil, fmt.Errorf("%w: chunk size %d too small", parsesyslog.ErrWrongFormat, size)
+	}
+	pl := size - chunkHeaderLen
+	count := (len(b) + pl - 1) / pl
+	if count > maxChunks {
+		return nil, fmt.Errorf("%w: message requires %d chunks", parsesyslog.ErrWrongFormat, count)
+	}
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	chunks := make([][]byte, 0, count)
+	for i := 0; i < count; i++ {
+		p := b[i*pl:]
+		if len(p) > pl {
+			p = p[:pl]
+		}
+		c := make([]byte, 0, chunkHeaderLen+len(p))
+		c = append(c, chunkMagic...)
+		c = append(c, id...)
+		c = append(c, byte(i), byte(count))
+		chunks = append(chunks, append(c, p...))
+	}
+	return chunks, nil
+}
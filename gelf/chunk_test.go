@@ -0,0 +1,153 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package gelf
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/wneessen/go-parsesyslog"
+)
+
+// TestChunk_roundtrip tests that chunks created by Chunk are reassembled by an Assembler
+func TestChunk_roundtrip(t *testing.T) {
+	msg := bytes.Repeat([]byte("0123456789"), 100)
+	tests := []struct {
+		name  string
+		size  int
+		count int
+	}{
+		{"single datagram", 0, 1},
+		{"exact fit", 1000, 1},
+		{"two chunks", 512 + chunkHeaderLen, 2},
+		{"many chunks", 20, 125},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks, err := Chunk(msg, tt.size)
+			if err != nil {
+				t.Fatalf("Chunk() failed: %s", err)
+			}
+			if len(chunks) != tt.count {
+				t.Fatalf("Chunk() => expected %d chunks, got: %d", tt.count, len(chunks))
+			}
+			a := NewAssembler(0)
+			var got []byte
+			// Chunks may arrive in any order
+			for i := len(chunks) - 1; i >= 0; i-- {
+				if tt.size > 0 && len(chunks[i]) > tt.size {
+					t.Errorf("Chunk() => chunk %d exceeds size: %d", i, len(chunks[i]))
+				}
+				b, err := a.Add(chunks[i])
+				if err != nil {
+					t.Fatalf("Add() failed: %s", err)
+				}
+				if b != nil && i != 0 {
+					t.Fatalf("Add() => returned message before the last chunk")
+				}
+				got = b
+			}
+			if !bytes.Equal(got, msg) {
+				t.Errorf("Add() => reassembled message differs: %q", got)
+			}
+			if a.Pending() != 0 {
+				t.Errorf("Add() => expected no pending messages, got: %d", a.Pending())
+			}
+		})
+	}
+}
+
+// TestChunk_fails tests the Chunk function with messages that can not be chunked
+func TestChunk_fails(t *testing.T) {
+	msg := make([]byte, 200)
+	if _, err := Chunk(msg, chunkHeaderLen); !errors.Is(err, parsesyslog.ErrWrongFormat) {
+		t.Errorf("Chunk() => expected error: %s, got: %v", parsesyslog.ErrWrongFormat, err)
+	}
+	if _, err := Chunk(msg, chunkHeaderLen+1); !errors.Is(err, parsesyslog.ErrWrongFormat) {
+		t.Errorf("Chunk() => expected error: %s, got: %v", parsesyslog.ErrWrongFormat, err)
+	}
+}
+
+// TestAssembler_Add tests the Add method with duplicate and invalid chunks
+func TestAssembler_Add(t *testing.T) {
+	chunk := func(id byte, seq, count byte, p string) []byte {
+		return append([]byte{0x1e, 0x0f, id, 0, 0, 0, 0, 0, 0, 0, seq, count}, p...)
+	}
+	a := NewAssembler(0)
+	if b, err := a.Add([]byte(`{"host":"h"}`)); err != nil || string(b) != `{"host":"h"}` {
+		t.Errorf("Add() => expected unchunked datagram to pass through, got: %q, %v", b, err)
+	}
+	if b, err := a.Add(chunk(1, 0, 2, "ab")); err != nil || b != nil {
+		t.Errorf("Add() => expected incomplete message, got: %q, %v", b, err)
+	}
+	if b, err := a.Add(chunk(1, 0, 2, "xx")); err != nil || b != nil {
+		t.Errorf("Add() => expected duplicate chunk to be ignored, got: %q, %v", b, err)
+	}
+	if b, err := a.Add(chunk(1, 1, 2, "cd")); err != nil || string(b) != "abcd" {
+		t.Errorf("Add() => expected reassembled message, got: %q, %v", b, err)
+	}
+
+	tests := []struct {
+		name string
+		d    []byte
+	}{
+		{"short header", []byte{0x1e, 0x0f, 1, 2, 3}},
+		{"zero count", chunk(2, 0, 0, "x")},
+		{"too many chunks", chunk(2, 0, 129, "x")},
+		{"seq out of range", chunk(2, 2, 2, "x")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := a.Add(tt.d); !errors.Is(err, parsesyslog.ErrWrongFormat) {
+				t.Errorf("Add() => expected error: %s, got: %v", parsesyslog.ErrWrongFormat, err)
+			}
+		})
+	}
+
+	if _, err := a.Add(chunk(3, 0, 2, "x")); err != nil {
+		t.Fatalf("Add() failed: %s", err)
+	}
+	if _, err := a.Add(chunk(3, 1, 3, "x")); !errors.Is(err, parsesyslog.ErrWrongFormat) {
+		t.Errorf("Add() => expected error on inconsistent count, got: %v", err)
+	}
+	if a.Pending() != 0 {
+		t.Errorf("Add() => expected inconsistent message to be discarded, got: %d pending", a.Pending())
+	}
+}
+
+// TestAssembler_expire tests that incomplete messages are discarded after the timeout
+// and when the maximum number of pending messages is reached
+func TestAssembler_expire(t *testing.T) {
+	now := time.Date(2021, 11, 7, 16, 0, 0, 0, time.UTC)
+	a := NewAssembler(time.Second)
+	a.now = func() time.Time { return now }
+	a.maxPending = 2
+	chunk := func(id byte, seq byte) []byte {
+		return []byte{0x1e, 0x0f, id, 0, 0, 0, 0, 0, 0, 0, seq, 2, 'x'}
+	}
+
+	for id := byte(1); id <= 3; id++ {
+		if _, err := a.Add(chunk(id, 0)); err != nil {
+			t.Fatalf("Add() failed: %s", err)
+		}
+		now = now.Add(time.Millisecond)
+	}
+	if a.Pending() != 2 {
+		t.Errorf("Add() => expected 2 pending messages, got: %d", a.Pending())
+	}
+	if b, _ := a.Add(chunk(1, 1)); b != nil {
+		t.Errorf("Add() => expected oldest message to be evicted, got: %q", b)
+	}
+
+	now = now.Add(2 * time.Second)
+	if b, _ := a.Add(chunk(3, 1)); b != nil {
+		t.Errorf("Add() => expected expired message to be discarded, got: %q", b)
+	}
+	if a.Pending() != 1 {
+		t.Errorf("Add() => expected 1 pending message, got: %d", a.Pending())
+	}
+}
@@ -0,0 +1,340 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+// Package gelf implements the Graylog Extended Log Format (GELF). It decodes GELF
+// messages (uncompressed, gzip or zlib compressed, chunked via UDP) into a LogMsg and
+// encodes a LogMsg as GELF message, so that syslog and Graylog ecosystems can be
+// bridged.
+// See: https://go2docs.graylog.org/current/getting_in_log_data/gelf.html
+package gelf
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wneessen/go-parsesyslog"
+)
+
+// Version is the GELF version that is written by Encode
+const Version = "1.1"
+
+// MsgType is the LogMsgType of the LogMsg returned by Decode
+const MsgType parsesyslog.LogMsgType = "GELF"
+
+// SDID is the SD-ID of the structured data element that holds the additional fields of
+// a decoded GELF message (without the leading underscore), as well as its file and line
+// fields
+const SDID = "gelf@32473"
+
+// Type represents the ParserType for this Parser
+const Type parsesyslog.ParserType = "gelf"
+
+// Additional fields that are mapped to the header fields of a LogMsg
+const (
+	fieldAppName  = "_application_name"
+	fieldFacility = "_facility"
+	fieldMsgID    = "_message_id"
+	fieldProcID   = "_process_id"
+)
+
+// DefaultMaxSize is the default maximum size of a decoded GELF message in bytes, after
+// decompression. It is well above the size of a message of the maximum number of chunks.
+const DefaultMaxSize = 8 << 20
+
+// defaultLevel is the level of GELF messages that do not provide one
+const defaultLevel = 1
+
+// maxTimestamp is the latest timestamp (9999-12-31T23:59:59Z) that is accepted
+const maxTimestamp = 253402300799
+
+// fieldNameRe matches the characters that are not allowed in GELF field names
+var fieldNameRe = regexp.MustCompile(`[^\w.\-]`)
+
+// msg represents a GELF parser
+type msg struct {
+	maxSize int
+}
+
+// init registers the Parser. The maximum size of a message can be set with
+// WithMaxMessageSize.
+func init() {
+	fn := func(o parsesyslog.Options) (parsesyslog.Parser, error) {
+		return &msg{maxSize: o.MaxMessageSize}, nil
+	}
+	parsesyslog.RegisterWithOptions(Type, fn)
+}
+
+// ParseString returns the decoded GELF message of the given string
+func (m *msg) ParseString(s string) (parsesyslog.LogMsg, error) {
+	return DecodeLimit([]byte(s), m.maxSize)
+}
+
+// ParseReader returns the decoded GELF message read from the io.Reader until EOF. With
+// a framing parser (see the rfc6587 package), it parses GELF messages received via TCP,
+// which are terminated by a NUL byte. Messages that exceed the maximum size are not read
+// further and result in ErrMessageTooLarge. It satisfies the Parser interface
+func (m *msg) ParseReader(r io.Reader) (parsesyslog.LogMsg, error) {
+	size := m.maxSize
+	if size <= 0 {
+		size = DefaultMaxSize
+	}
+	b, err := io.ReadAll(io.LimitReader(r, int64(size)+1))
+	if err != nil {
+		return parsesyslog.LogMsg{}, err
+	}
+	if len(b) > size {
+		return parsesyslog.LogMsg{}, fmt.Errorf("%w: more than %d bytes", parsesyslog.ErrMessageTooLarge, size)
+	}
+	return DecodeLimit(b, size)
+}
+
+// Decode decodes the given GELF message, which may be gzip or zlib compressed. Chunked
+// messages need to be reassembled with an Assembler first.
+//
+// The host is stored as Hostname and the level as Severity (which defaults to ALERT, as
+// defined by the GELF specification). The Message is the full_message, or if there is
+// none, the short_message. The additional fields "_application_name", "_process_id",
+// "_message_id" and "_facility" are mapped to the corresponding LogMsg fields. All
+// other additional fields, as well as file and line, are stored as params of a
+// structured data element with the SD-ID SDID, sorted by name.
+//
+// Messages without host or short_message result in ErrWrongFormat. Messages that exceed
+// DefaultMaxSize after decompression result in ErrMessageTooLarge (see DecodeLimit).
+func Decode(b []byte) (parsesyslog.LogMsg, error) {
+	return DecodeLimit(b, DefaultMaxSize)
+}
+
+// DecodeLimit works like Decode, but with the given maximum size of the message in bytes,
+// after decompression. As a compressed message can expand to a multiple of its size, the
+// decompression stops once the limit is exceeded and ErrMessageTooLarge is returned. A
+// maximum size <= 0 results in DefaultMaxSize.
+func DecodeLimit(b []byte, size int) (parsesyslog.LogMsg, error) {
+	var lm parsesyslog.LogMsg
+	if size <= 0 {
+		size = DefaultMaxSize
+	}
+	b, err := decompress(b, size)
+	if err != nil {
+		return lm, err
+	}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var f map[string]interface{}
+	if err := d.Decode(&f); err != nil {
+		return lm, fmt.Errorf("%w: %s", parsesyslog.ErrWrongFormat, err)
+	}
+
+	lm.Type = MsgType
+	host, ok := f["host"].(string)
+	if !ok || host == "" {
+		return lm, fmt.Errorf("%w: missing host", parsesyslog.ErrWrongFormat)
+	}
+	lm.Hostname = host
+	short, ok := f["short_message"].(string)
+	if !ok || short == "" {
+		return lm, fmt.Errorf("%w: missing short_message", parsesyslog.ErrWrongFormat)
+	}
+	if full, ok := f["full_message"].(string); ok && full != "" {
+		lm.Message.WriteString(full)
+	} else {
+		lm.Message.WriteString(short)
+	}
+	lm.MsgLength = lm.Message.Len()
+
+	if ts, ok := f["timestamp"].(json.Number); ok {
+		sec, err := ts.Float64()
+		if err != nil || math.IsNaN(sec) || sec < 0 || sec > maxTimestamp {
+			return lm, fmt.Errorf("%w: %s", parsesyslog.ErrInvalidTimestamp, ts)
+		}
+		s, frac := math.Modf(sec)
+		// GELF timestamps have millisecond precision, which the float64 can represent
+		// exactly when rounded to microseconds
+		lm.Timestamp = time.Unix(int64(s), int64(math.Round(frac*1e6))*1e3)
+	}
+	level, err := intField(f, "level", defaultLevel)
+	if err != nil {
+		return lm, err
+	}
+	facility, err := intField(f, fieldFacility, 0)
+	if err != nil {
+		return lm, err
+	}
+	prio := parsesyslog.PriorityFrom(parsesyslog.Facility(facility), parsesyslog.Severity(level))
+	if !prio.Valid() {
+		return lm, parsesyslog.ErrInvalidPrio
+	}
+	lm.Priority = prio
+	lm.Facility = parsesyslog.FacilityFromPrio(lm.Priority)
+	lm.Severity = parsesyslog.SeverityFromPrio(lm.Priority)
+	lm.AppName, _ = f[fieldAppName].(string)
+	lm.MsgID, _ = f[fieldMsgID].(string)
+	lm.ProcID = fieldString(f[fieldProcID])
+
+	var params []parsesyslog.StructuredDataParam
+	for k, v := range f {
+		switch k {
+		case fieldAppName, fieldFacility, fieldMsgID, fieldProcID, "_id":
+			continue
+		case "file", "line":
+		default:
+			if !strings.HasPrefix(k, "_") {
+				continue
+			}
+			k = k[1:]
+		}
+		if v == nil {
+			continue
+		}
+		params = append(params, parsesyslog.StructuredDataParam{Name: k, Value: fieldString(v)})
+	}
+	if len(params) > 0 {
+		sort.Slice(params, func(i, j int) bool { return params[i].Name < params[j].Name })
+		lm.StructuredData = []parsesyslog.StructuredDataElement{{ID: SDID, Param: params}}
+	}
+	return lm, nil
+}
+
+// Encode returns the GELF representation of the given LogMsg. The first line of the
+// Message is used as short_message and, if the Message consists of more than one
+// line, the whole Message as full_message. An empty Message results in a short_message
+// of "-", since GELF requires it. The params of the structured data element with the
+// SD-ID SDID are written as additional fields of the same name (except for file and
+// line, which are written as the respective GELF fields), the params of all other
+// elements as additional fields named "<SD-ID>_<PARAM-NAME>", with all characters that
+// are not allowed in GELF field names replaced by an underscore.
+//
+// A LogMsg without Hostname results in ErrWrongFormat, since GELF requires a host.
+func Encode(lm parsesyslog.LogMsg) ([]byte, error) {
+	if lm.Hostname == "" {
+		return nil, fmt.Errorf("%w: missing host", parsesyslog.ErrWrongFormat)
+	}
+	f := map[string]interface{}{
+		"version":     Version,
+		"host":        lm.Hostname,
+		"level":       int(lm.Severity),
+		fieldFacility: int(lm.Facility),
+	}
+	m := lm.Message.String()
+	short := m
+	if i := strings.IndexAny(m, "\r\n"); i >= 0 {
+		short = m[:i]
+		f["full_message"] = m
+	}
+	if short == "" {
+		short = "-"
+	}
+	f["short_message"] = short
+	if !lm.Timestamp.IsZero() {
+		us := lm.Timestamp.UnixNano() / 1e3
+		f["timestamp"] = json.Number(fmt.Sprintf("%d.%06d", us/1e6, us%1e6))
+	}
+	for k, v := range map[string]string{fieldAppName: lm.AppName, fieldMsgID: lm.MsgID, fieldProcID: lm.ProcID} {
+		if v != "" {
+			f[k] = v
+		}
+	}
+	for _, e := range lm.StructuredData {
+		for _, p := range e.Param {
+			k := p.Name
+			switch {
+			case e.ID != SDID:
+				k = e.ID + "_" + p.Name
+			case k == "file":
+				f[k] = p.Value
+				continue
+			case k == "line":
+				if _, err := strconv.Atoi(p.Value); err == nil {
+					f[k] = json.Number(p.Value)
+				}
+				continue
+			}
+			k = fieldNameRe.ReplaceAllString(k, "_")
+			if _, ok := f["_"+k]; ok || k == "id" {
+				continue
+			}
+			f["_"+k] = p.Value
+		}
+	}
+	return json.Marshal(f)
+}
+
+// decompress returns the decompressed GELF message, if it is gzip or zlib compressed. If
+// the message exceeds the given maximum size, ErrMessageTooLarge is returned.
+func decompress(b []byte, size int) ([]byte, error) {
+	tooLarge := fmt.Errorf("%w: more than %d bytes", parsesyslog.ErrMessageTooLarge, size)
+	var r io.Reader
+	var err error
+	switch {
+	case len(b) > 2 && b[0] == 0x1f && b[1] == 0x8b:
+		r, err = gzip.NewReader(bytes.NewReader(b))
+	case len(b) > 2 && b[0]&0x0f == 0x08 && (uint16(b[0])<<8|uint16(b[1]))%31 == 0:
+		r, err = zlib.NewReader(bytes.NewReader(b))
+	default:
+		if len(b) > size {
+			return nil, tooLarge
+		}
+		return b, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", parsesyslog.ErrWrongFormat, err)
+	}
+	d, err := io.ReadAll(io.LimitReader(r, int64(size)+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", parsesyslog.ErrWrongFormat, err)
+	}
+	if len(d) > size {
+		return nil, tooLarge
+	}
+	return d, nil
+}
+
+// intField returns the integer value of the given field. A missing field results in
+// the given default value.
+func intField(f map[string]interface{}, k string, def int) (int, error) {
+	switch v := f[k].(type) {
+	case nil:
+		return def, nil
+	case json.Number:
+		n, err := strconv.Atoi(v.String())
+		if err != nil {
+			return 0, fmt.Errorf("%w: invalid %s %q", parsesyslog.ErrWrongFormat, k, v)
+		}
+		return n, nil
+	case string:
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, fmt.Errorf("%w: invalid %s %q", parsesyslog.ErrWrongFormat, k, v)
+		}
+		return n, nil
+	default:
+		return 0, fmt.Errorf("%w: invalid %s", parsesyslog.ErrWrongFormat, k)
+	}
+}
+
+// fieldString returns the string representation of a field value
+func fieldString(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+}
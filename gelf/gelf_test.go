@@ -0,0 +1,322 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package gelf
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/wneessen/go-parsesyslog"
+)
+
+// testMsg is a GELF message with all standard fields and some additional fields
+const testMsg = `{"version":"1.1","host":"example.org","short_message":"A short message",` +
+	`"full_message":"Backtrace here\n\nmore stuff","timestamp":1385053862.3072,"level":3,` +
+	`"_facility":4,"_application_name":"app","_process_id":1234,"_message_id":"ID47",` +
+	`"_user_id":9001,"_some_info":"foo","_flag":true,"_obj":{"a":1},"_null":null,` +
+	`"_id":"ignored","file":"main.go","line":42,"unknown":"ignored"}`
+
+// TestDecode tests the Decode function
+func TestDecode(t *testing.T) {
+	lm, err := Decode([]byte(testMsg))
+	if err != nil {
+		t.Fatalf("Decode() failed: %s", err)
+	}
+	if lm.Type != MsgType {
+		t.Errorf("Decode() => expected type: %s, got: %s", MsgType, lm.Type)
+	}
+	if lm.Hostname != "example.org" {
+		t.Errorf("Decode() => expected hostname: %s, got: %s", "example.org", lm.Hostname)
+	}
+	if lm.Message.String() != "Backtrace here\n\nmore stuff" {
+		t.Errorf("Decode() => unexpected message: %q", lm.Message.String())
+	}
+	if lm.MsgLength != lm.Message.Len() {
+		t.Errorf("Decode() => expected msg length: %d, got: %d", lm.Message.Len(), lm.MsgLength)
+	}
+	want := time.Date(2013, 11, 21, 17, 11, 2, 307200000, time.UTC)
+	if !lm.Timestamp.Equal(want) {
+		t.Errorf("Decode() => expected timestamp: %s, got: %s", want, lm.Timestamp.UTC())
+	}
+	if lm.Priority != 35 || lm.Facility != 4 || lm.Severity != 3 {
+		t.Errorf("Decode() => expected prio 35/fac 4/sev 3, got: %d/%d/%d", lm.Priority, lm.Facility,
+			lm.Severity)
+	}
+	if lm.AppName != "app" || lm.ProcID != "1234" || lm.MsgID != "ID47" {
+		t.Errorf("Decode() => unexpected header: %s/%s/%s", lm.AppName, lm.ProcID, lm.MsgID)
+	}
+	wp := []parsesyslog.StructuredDataParam{
+		{Name: "file", Value: "main.go"}, {Name: "flag", Value: "true"},
+		{Name: "line", Value: "42"},
+		{Name: "obj", Value: `{"a":1}`}, {Name: "some_info", Value: "foo"}, {Name: "user_id", Value: "9001"},
+	}
+	if len(lm.StructuredData) != 1 || lm.StructuredData[0].ID != SDID {
+		t.Fatalf("Decode() => expected a single %s SD element, got: %+v", SDID, lm.StructuredData)
+	}
+	if len(lm.StructuredData[0].Param) != len(wp) {
+		t.Fatalf("Decode() => expected %d params, got: %+v", len(wp), lm.StructuredData[0].Param)
+	}
+	for i, p := range lm.StructuredData[0].Param {
+		if p != wp[i] {
+			t.Errorf("Decode() => expected param %d: %+v, got: %+v", i, wp[i], p)
+		}
+	}
+}
+
+// TestDecode_defaults tests the Decode function with a minimal GELF message
+func TestDecode_defaults(t *testing.T) {
+	lm, err := Decode([]byte(`{"version":"1.1","host":"h","short_message":"short"}`))
+	if err != nil {
+		t.Fatalf("Decode() failed: %s", err)
+	}
+	if lm.Message.String() != "short" {
+		t.Errorf("Decode() => expected message: %q, got: %q", "short", lm.Message.String())
+	}
+	if lm.Priority != parsesyslog.Kern|parsesyslog.Alert || lm.Severity != 1 || lm.Facility != 0 {
+		t.Errorf("Decode() => expected ALERT/KERN, got: %s/%s", lm.Severity, lm.Facility)
+	}
+	if !lm.Timestamp.IsZero() {
+		t.Errorf("Decode() => expected zero timestamp, got: %s", lm.Timestamp)
+	}
+	if lm.StructuredData != nil {
+		t.Errorf("Decode() => expected no structured data, got: %+v", lm.StructuredData)
+	}
+}
+
+// TestDecode_compressed tests the Decode function with compressed GELF messages
+func TestDecode_compressed(t *testing.T) {
+	var gz, zl bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	zw := zlib.NewWriter(&zl)
+	for _, w := range []interface {
+		Write([]byte) (int, error)
+		Close() error
+	}{gw, zw} {
+		if _, err := w.Write([]byte(testMsg)); err != nil {
+			t.Fatalf("failed to compress message: %s", err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("failed to compress message: %s", err)
+		}
+	}
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"gzip", gz.Bytes()},
+		{"zlib", zl.Bytes()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lm, err := Decode(tt.data)
+			if err != nil {
+				t.Fatalf("Decode() failed: %s", err)
+			}
+			if lm.Hostname != "example.org" || lm.AppName != "app" {
+				t.Errorf("Decode() => unexpected result: %s/%s", lm.Hostname, lm.AppName)
+			}
+		})
+	}
+}
+
+// TestDecodeLimit tests that the decompression of a GELF message stops at the maximum size
+func TestDecodeLimit(t *testing.T) {
+	// A decompression bomb: 64 MiB of zeros compress to a few KiB
+	var bomb bytes.Buffer
+	gw := gzip.NewWriter(&bomb)
+	zero := make([]byte, 1<<20)
+	for i := 0; i < 64; i++ {
+		if _, err := gw.Write(zero); err != nil {
+			t.Fatalf("failed to compress message: %s", err)
+		}
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatalf("failed to compress message: %s", err)
+	}
+	var gz bytes.Buffer
+	gw = gzip.NewWriter(&gz)
+	if _, err := gw.Write([]byte(testMsg)); err != nil {
+		t.Fatalf("failed to compress message: %s", err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatalf("failed to compress message: %s", err)
+	}
+	tests := []struct {
+		name string
+		data []byte
+		size int
+		err  error
+	}{
+		{"bomb", bomb.Bytes(), 0, parsesyslog.ErrMessageTooLarge},
+		{"compressed within limit", gz.Bytes(), len(testMsg), nil},
+		{"compressed exceeds limit", gz.Bytes(), len(testMsg) - 1, parsesyslog.ErrMessageTooLarge},
+		{"uncompressed exceeds limit", []byte(testMsg), 10, parsesyslog.ErrMessageTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeLimit(tt.data, tt.size); !errors.Is(err, tt.err) {
+				t.Errorf("DecodeLimit() => expected error: %v, got: %v", tt.err, err)
+			}
+		})
+	}
+	if _, err := Decode(bomb.Bytes()); !errors.Is(err, parsesyslog.ErrMessageTooLarge) {
+		t.Errorf("Decode() => expected error: %s, got: %v", parsesyslog.ErrMessageTooLarge, err)
+	}
+
+	p, err := parsesyslog.New(Type, parsesyslog.WithMaxMessageSize(10))
+	if err != nil {
+		t.Fatalf("failed to create parser: %s", err)
+	}
+	if _, err = p.ParseReader(bytes.NewReader(gz.Bytes())); !errors.Is(err, parsesyslog.ErrMessageTooLarge) {
+		t.Errorf("ParseReader() => expected error: %s, got: %v", parsesyslog.ErrMessageTooLarge, err)
+	}
+
+	// Streams that exceed the limit must not be read completely
+	r := bytes.NewReader(bomb.Bytes())
+	if _, err = p.ParseReader(r); !errors.Is(err, parsesyslog.ErrMessageTooLarge) {
+		t.Errorf("ParseReader() => expected error: %s, got: %v", parsesyslog.ErrMessageTooLarge, err)
+	}
+	if n := bomb.Len() - r.Len(); n > 11 {
+		t.Errorf("ParseReader() => expected to read at most 11 bytes, got: %d", n)
+	}
+}
+
+// TestDecode_fails tests the Decode function with invalid GELF messages
+func TestDecode_fails(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  string
+		err  error
+	}{
+		{"not json", `short message`, parsesyslog.ErrWrongFormat},
+		{"no object", `["host"]`, parsesyslog.ErrWrongFormat},
+		{"missing host", `{"short_message":"m"}`, parsesyslog.ErrWrongFormat},
+		{"empty host", `{"host":"","short_message":"m"}`, parsesyslog.ErrWrongFormat},
+		{"missing short_message", `{"host":"h","full_message":"m"}`, parsesyslog.ErrWrongFormat},
+		{"invalid level", `{"host":"h","short_message":"m","level":"x"}`, parsesyslog.ErrWrongFormat},
+		{"float level", `{"host":"h","short_message":"m","level":1.5}`, parsesyslog.ErrWrongFormat},
+		{"level out of range", `{"host":"h","short_message":"m","level":8}`, parsesyslog.ErrInvalidPrio},
+		{"facility out of range", `{"host":"h","short_message":"m","_facility":24}`, parsesyslog.ErrInvalidPrio},
+		{"negative timestamp", `{"host":"h","short_message":"m","timestamp":-1}`, parsesyslog.ErrInvalidTimestamp},
+		{"huge timestamp", `{"host":"h","short_message":"m","timestamp":1e300}`, parsesyslog.ErrInvalidTimestamp},
+		{"broken gzip", "\x1f\x8b\x08\x00", parsesyslog.ErrWrongFormat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Decode([]byte(tt.msg)); !errors.Is(err, tt.err) {
+				t.Errorf("Decode() => expected error: %s, got: %v", tt.err, err)
+			}
+		})
+	}
+}
+
+// TestEncode tests the Encode function
+func TestEncode(t *testing.T) {
+	lm := parsesyslog.LogMsg{
+		Priority: 165, Facility: 20, Severity: 5, Hostname: "mymachine.example.com",
+		Timestamp: time.Date(2003, 10, 11, 22, 14, 15, 3000000, time.UTC), AppName: "evntslog",
+		ProcID: "-", MsgID: "ID47",
+		StructuredData: []parsesyslog.StructuredDataElement{
+			{ID: "exampleSDID@32473", Param: []parsesyslog.StructuredDataParam{
+				{Name: "iut", Value: "3"}, {Name: "event Source", Value: "Application"},
+			}},
+			{ID: SDID, Param: []parsesyslog.StructuredDataParam{
+				{Name: "user", Value: "x"}, {Name: "id", Value: "y"},
+				{Name: "file", Value: "main.go"}, {Name: "line", Value: "7"},
+			}},
+		},
+	}
+	lm.Message.WriteString("An application event\nwith details")
+	b, err := Encode(lm)
+	if err != nil {
+		t.Fatalf("Encode() failed: %s", err)
+	}
+	var f map[string]interface{}
+	if err := json.Unmarshal(b, &f); err != nil {
+		t.Fatalf("Encode() returned invalid JSON: %s", err)
+	}
+	want := map[string]interface{}{
+		"version": "1.1", "host": "mymachine.example.com", "short_message": "An application event",
+		"full_message": "An application event\nwith details", "timestamp": 1065910455.003, "level": 5.0,
+		"_facility": 20.0, "_application_name": "evntslog", "_process_id": "-", "_message_id": "ID47",
+		"_exampleSDID_32473_iut": "3", "_exampleSDID_32473_event_Source": "Application", "_user": "x",
+		"file": "main.go", "line": 7.0,
+	}
+	if len(f) != len(want) {
+		t.Errorf("Encode() => expected %d fields, got: %s", len(want), b)
+	}
+	for k, v := range want {
+		if f[k] != v {
+			t.Errorf("Encode() => expected %s: %v, got: %v", k, v, f[k])
+		}
+	}
+}
+
+// TestEncode_roundtrip tests that a decoded GELF message encodes to the same fields
+func TestEncode_roundtrip(t *testing.T) {
+	lm, err := Decode([]byte(testMsg))
+	if err != nil {
+		t.Fatalf("Decode() failed: %s", err)
+	}
+	b, err := Encode(lm)
+	if err != nil {
+		t.Fatalf("Encode() failed: %s", err)
+	}
+	rt, err := Decode(b)
+	if err != nil {
+		t.Fatalf("Decode() of encoded message failed: %s", err)
+	}
+	if d := parsesyslog.Diff(lm, rt); len(d) > 0 {
+		t.Errorf("round trip => unexpected differences: %v", d)
+	}
+}
+
+// TestEncode_fails tests the Encode function with a LogMsg without Hostname
+func TestEncode_fails(t *testing.T) {
+	if _, err := Encode(parsesyslog.LogMsg{}); !errors.Is(err, parsesyslog.ErrWrongFormat) {
+		t.Errorf("Encode() => expected error: %s, got: %v", parsesyslog.ErrWrongFormat, err)
+	}
+}
+
+// TestEncode_emptyMessage tests that Encode writes a placeholder short_message
+func TestEncode_emptyMessage(t *testing.T) {
+	b, err := Encode(parsesyslog.LogMsg{Hostname: "h"})
+	if err != nil {
+		t.Fatalf("Encode() failed: %s", err)
+	}
+	if !bytes.Contains(b, []byte(`"short_message":"-"`)) {
+		t.Errorf("Encode() => expected placeholder short_message, got: %s", b)
+	}
+	if bytes.Contains(b, []byte(`"timestamp"`)) {
+		t.Errorf("Encode() => expected no timestamp, got: %s", b)
+	}
+}
+
+// TestParser tests the registered GELF Parser
+func TestParser(t *testing.T) {
+	p, err := parsesyslog.New(Type)
+	if err != nil {
+		t.Fatalf("failed to create parser: %s", err)
+	}
+	lm, err := p.ParseString(testMsg)
+	if err != nil {
+		t.Fatalf("ParseString() failed: %s", err)
+	}
+	if lm.Hostname != "example.org" {
+		t.Errorf("ParseString() => expected hostname: %s, got: %s", "example.org", lm.Hostname)
+	}
+	lm, err = p.ParseReader(bytes.NewReader([]byte(testMsg)))
+	if err != nil {
+		t.Fatalf("ParseReader() failed: %s", err)
+	}
+	if lm.AppName != "app" {
+		t.Errorf("ParseReader() => expected app name: %s, got: %s", "app", lm.AppName)
+	}
+}
@@ -0,0 +1,287 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+// Package haproxy implements the extraction of the fields of the default HAProxy HTTP
+// and TCP log formats (i. e. "option httplog" and "option tcplog") from the message of
+// a syslog message, as it is sent by HAProxy in RFC3164 format.
+// See: https://docs.haproxy.org/2.8/configuration.html#8.2
+package haproxy
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wneessen/go-parsesyslog"
+)
+
+// SDID is the SD-ID of the structured data element that holds the fields of a HAProxy
+// log message
+const SDID = "haproxy@32473"
+
+// acceptDateLayout is the time layout of the accept date of a HAProxy log message
+const acceptDateLayout = "02/Jan/2006:15:04:05.000"
+
+// Mode represents the log format of a HAProxy log message
+type Mode string
+
+// Log formats of HAProxy log messages
+const (
+	ModeHTTP Mode = "http" // ModeHTTP: "option httplog"
+	ModeTCP  Mode = "tcp"  // ModeTCP: "option tcplog"
+)
+
+// Log represents the fields of a HAProxy log message. Timers are negative if the
+// corresponding phase has not been reached (HAProxy logs "-1" then). Fields that do not
+// exist in the Mode of the log message have their zero value.
+type Log struct {
+	// AcceptDate is the time the connection was accepted, in the local time zone
+	AcceptDate time.Time
+	// ActConn, FeConn, BeConn and SrvConn are the amount of concurrent connections on
+	// the process, the frontend, the backend and the server
+	ActConn, FeConn, BeConn, SrvConn int
+	Backend                          string
+	// BackendQueue and SrvQueue are the amount of requests that were queued before
+	// this one in the backend and the server queue
+	BackendQueue, SrvQueue int
+	BytesRead              int64
+	ClientIP               net.IP
+	ClientPort             int
+	Frontend               string
+	Mode                   Mode
+	// Request is the HTTP request line (i. e. "GET /index.html HTTP/1.1")
+	Request string
+	// RequestHeaders and ResponseHeaders are the captured HTTP headers, if captures
+	// are configured
+	RequestHeaders, ResponseHeaders []string
+	Retries                         int
+	Server                          string
+	StatusCode                      int
+	// TerminationState is the state of the session at termination (i. e. "----")
+	TerminationState string
+	// Timers of the session. In ModeTCP, only Tw, Tc and Tt are set.
+	// See: https://docs.haproxy.org/2.8/configuration.html#8.4
+	TR, Tw, Tc, Tr, Ta, Tt time.Duration
+}
+
+// Parse parses the given message of a HAProxy log message in the default HTTP or TCP
+// log format. The syslog header (i. e. "haproxy[1234]: ") must not be part of it. If the
+// message does not conform to one of the formats, parsesyslog.ErrWrongFormat is
+// returned.
+func Parse(s string) (Log, error) {
+	var l Log
+	f := fields(strings.TrimRight(s, "\r\n\x00"))
+
+	c := f.next()
+	i := strings.LastIndexByte(c, ':')
+	if i < 0 {
+		return l, fmt.Errorf("%w: invalid client address %q", parsesyslog.ErrWrongFormat, c)
+	}
+	if l.ClientIP = net.ParseIP(c[:i]); l.ClientIP == nil {
+		return l, fmt.Errorf("%w: invalid client address %q", parsesyslog.ErrWrongFormat, c)
+	}
+	var err error
+	if l.ClientPort, err = strconv.Atoi(c[i+1:]); err != nil {
+		return l, fmt.Errorf("%w: invalid client port %q", parsesyslog.ErrWrongFormat, c)
+	}
+	d := f.next()
+	if len(d) < 2 || d[0] != '[' || d[len(d)-1] != ']' {
+		return l, fmt.Errorf("%w: invalid accept date %q", parsesyslog.ErrWrongFormat, d)
+	}
+	if l.AcceptDate, err = time.ParseInLocation(acceptDateLayout, d[1:len(d)-1], time.Local); err != nil {
+		return l, fmt.Errorf("%w: invalid accept date %q", parsesyslog.ErrWrongFormat, d)
+	}
+	l.Frontend = f.next()
+	bs := f.next()
+	if i = strings.IndexByte(bs, '/'); i < 0 {
+		return l, fmt.Errorf("%w: invalid backend/server %q", parsesyslog.ErrWrongFormat, bs)
+	}
+	l.Backend, l.Server = bs[:i], bs[i+1:]
+
+	ts, err := ints(f.next(), 3, 5)
+	if err != nil {
+		return l, err
+	}
+	ms := func(n int) time.Duration { return time.Duration(n) * time.Millisecond }
+	if len(ts) == 5 {
+		l.Mode = ModeHTTP
+		l.TR, l.Tw, l.Tc, l.Tr, l.Ta = ms(ts[0]), ms(ts[1]), ms(ts[2]), ms(ts[3]), ms(ts[4])
+		if l.StatusCode, err = strconv.Atoi(f.next()); err != nil {
+			return l, fmt.Errorf("%w: invalid status code", parsesyslog.ErrWrongFormat)
+		}
+	} else {
+		l.Mode = ModeTCP
+		l.Tw, l.Tc, l.Tt = ms(ts[0]), ms(ts[1]), ms(ts[2])
+	}
+	if l.BytesRead, err = strconv.ParseInt(strings.TrimPrefix(f.next(), "+"), 10, 64); err != nil {
+		return l, fmt.Errorf("%w: invalid bytes read", parsesyslog.ErrWrongFormat)
+	}
+	if l.Mode == ModeHTTP {
+		// Captured request and response cookies
+		f.next()
+		f.next()
+	}
+	l.TerminationState = f.next()
+	if (l.Mode == ModeHTTP && len(l.TerminationState) != 4) || (l.Mode == ModeTCP && len(l.TerminationState) != 2) {
+		return l, fmt.Errorf("%w: invalid termination state %q", parsesyslog.ErrWrongFormat, l.TerminationState)
+	}
+	cs, err := ints(f.next(), 5)
+	if err != nil {
+		return l, err
+	}
+	l.ActConn, l.FeConn, l.BeConn, l.SrvConn, l.Retries = cs[0], cs[1], cs[2], cs[3], cs[4]
+	qs, err := ints(f.next(), 2)
+	if err != nil {
+		return l, err
+	}
+	l.SrvQueue, l.BackendQueue = qs[0], qs[1]
+
+	if l.Mode == ModeTCP {
+		if !f.done() {
+			return l, fmt.Errorf("%w: unexpected data after TCP log", parsesyslog.ErrWrongFormat)
+		}
+		return l, nil
+	}
+	for _, h := range []*[]string{&l.RequestHeaders, &l.ResponseHeaders} {
+		if v, ok := f.captures(); ok {
+			*h = v
+		}
+	}
+	if l.Request, err = f.quoted(); err != nil {
+		return l, err
+	}
+	return l, nil
+}
+
+// Decode extracts the fields of a HAProxy log message in the default HTTP or TCP log
+// format and stores them as structured data element with the SD-ID SDID. Messages that
+// do not conform to one of the formats are not recognized. Decode satisfies the
+// parsesyslog.BodyDecoder type.
+func Decode(lm *parsesyslog.LogMsg) bool {
+	l, err := Parse(lm.Message.String())
+	if err != nil {
+		return false
+	}
+	lm.StructuredData = append(lm.StructuredData, parsesyslog.StructuredDataElement{ID: SDID, Param: l.Params()})
+	return true
+}
+
+// Params returns the fields of the Log as structured data params. The timers are
+// given in milliseconds.
+func (l Log) Params() []parsesyslog.StructuredDataParam {
+	var ps []parsesyslog.StructuredDataParam
+	add := func(n, v string) {
+		ps = append(ps, parsesyslog.StructuredDataParam{Name: n, Value: v})
+	}
+	ms := func(d time.Duration) string { return strconv.FormatInt(d.Milliseconds(), 10) }
+	add("mode", string(l.Mode))
+	add("client_ip", l.ClientIP.String())
+	add("client_port", strconv.Itoa(l.ClientPort))
+	add("accept_date", l.AcceptDate.Format(time.RFC3339Nano))
+	add("frontend", l.Frontend)
+	add("backend", l.Backend)
+	add("server", l.Server)
+	if l.Mode == ModeHTTP {
+		add("TR", ms(l.TR))
+		add("Tw", ms(l.Tw))
+		add("Tc", ms(l.Tc))
+		add("Tr", ms(l.Tr))
+		add("Ta", ms(l.Ta))
+		add("status_code", strconv.Itoa(l.StatusCode))
+	} else {
+		add("Tw", ms(l.Tw))
+		add("Tc", ms(l.Tc))
+		add("Tt", ms(l.Tt))
+	}
+	add("bytes_read", strconv.FormatInt(l.BytesRead, 10))
+	add("termination_state", l.TerminationState)
+	add("actconn", strconv.Itoa(l.ActConn))
+	add("feconn", strconv.Itoa(l.FeConn))
+	add("beconn", strconv.Itoa(l.BeConn))
+	add("srv_conn", strconv.Itoa(l.SrvConn))
+	add("retries", strconv.Itoa(l.Retries))
+	add("srv_queue", strconv.Itoa(l.SrvQueue))
+	add("backend_queue", strconv.Itoa(l.BackendQueue))
+	if l.Mode == ModeHTTP {
+		add("request", l.Request)
+	}
+	return ps
+}
+
+// fieldReader reads the space separated fields of a HAProxy log message
+type fieldReader string
+
+// fields returns a fieldReader for the given string
+func fields(s string) *fieldReader {
+	f := fieldReader(s)
+	return &f
+}
+
+// next returns the next space separated field
+func (f *fieldReader) next() string {
+	s := strings.TrimLeft(string(*f), " ")
+	i := strings.IndexByte(s, ' ')
+	if i < 0 {
+		*f = ""
+		return s
+	}
+	*f = fieldReader(s[i+1:])
+	return s[:i]
+}
+
+// done returns true if there are no more fields
+func (f *fieldReader) done() bool {
+	return strings.TrimSpace(string(*f)) == ""
+}
+
+// captures returns the "|" separated values of the next field, if it is a captured
+// headers field enclosed in curly braces
+func (f *fieldReader) captures() ([]string, bool) {
+	s := strings.TrimLeft(string(*f), " ")
+	if !strings.HasPrefix(s, "{") {
+		return nil, false
+	}
+	i := strings.IndexByte(s, '}')
+	if i < 0 {
+		return nil, false
+	}
+	*f = fieldReader(s[i+1:])
+	return strings.Split(s[1:i], "|"), true
+}
+
+// quoted returns the remainder of the fieldReader, which needs to be enclosed in double
+// quotes. HAProxy truncates long requests, in which case the closing quote is missing.
+func (f *fieldReader) quoted() (string, error) {
+	s := strings.TrimSpace(string(*f))
+	*f = ""
+	if !strings.HasPrefix(s, `"`) {
+		return "", fmt.Errorf("%w: missing HTTP request", parsesyslog.ErrWrongFormat)
+	}
+	return strings.TrimSuffix(s[1:], `"`), nil
+}
+
+// ints parses the given "/" separated list of integers, which needs to consist of one
+// of the given amounts of integers. A "+" prefix of an integer (as logged by HAProxy
+// for "option logasap" or redispatched connections) is ignored.
+func ints(s string, n ...int) ([]int, error) {
+	ps := strings.Split(s, "/")
+	ok := false
+	for _, c := range n {
+		ok = ok || len(ps) == c
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: invalid field %q", parsesyslog.ErrWrongFormat, s)
+	}
+	is := make([]int, len(ps))
+	for i, p := range ps {
+		v, err := strconv.Atoi(strings.TrimPrefix(p, "+"))
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid field %q", parsesyslog.ErrWrongFormat, s)
+		}
+		is[i] = v
+	}
+	return is, nil
+}
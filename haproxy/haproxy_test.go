@@ -0,0 +1,144 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package haproxy
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/wneessen/go-parsesyslog"
+	"github.com/wneessen/go-parsesyslog/rfc3164"
+)
+
+const (
+	testHTTP = `10.0.1.2:33317 [06/Feb/2009:12:14:14.655] http-in static/srv1 10/0/30/69/109 200 2750 - - ---- ` +
+		`1/1/1/1/0 0/0 {1wt.eu} {} "GET /index.html HTTP/1.1"`
+	testTCP = `10.0.1.2:33313 [06/Feb/2009:12:12:51.443] fnt bck/srv1 0/0/5007 212 -- 0/0/0/0/3 0/0`
+)
+
+// TestParse tests the Parse function
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  string
+		want Log
+		err  error
+	}{
+		{
+			"http", testHTTP + "\n", Log{
+				ActConn: 1, FeConn: 1, BeConn: 1, SrvConn: 1, Backend: "static", BytesRead: 2750,
+				ClientPort: 33317, Frontend: "http-in", Mode: ModeHTTP, Request: "GET /index.html HTTP/1.1",
+				RequestHeaders: []string{"1wt.eu"}, ResponseHeaders: []string{""}, Server: "srv1",
+				StatusCode: 200, TerminationState: "----", TR: 10 * time.Millisecond, Tc: 30 * time.Millisecond,
+				Tr: 69 * time.Millisecond, Ta: 109 * time.Millisecond,
+			}, nil,
+		},
+		{
+			"http without captures", `::1:4711 [06/Feb/2009:12:14:14.655] fe~ be/<NOSRV> -1/-1/-1/-1/+3 503 +212 - - ` +
+				`SC-- 2/2/0/0/+1 0/0 "GET /very/long/truncated`, Log{
+				ActConn: 2, FeConn: 2, Backend: "be", BytesRead: 212, ClientPort: 4711, Frontend: "fe~",
+				Mode: ModeHTTP, Request: "GET /very/long/truncated", Retries: 1, Server: "<NOSRV>",
+				StatusCode: 503, TerminationState: "SC--", TR: -time.Millisecond, Tw: -time.Millisecond,
+				Tc: -time.Millisecond, Tr: -time.Millisecond, Ta: 3 * time.Millisecond,
+			}, nil,
+		},
+		{
+			"tcp", testTCP, Log{
+				Backend: "bck", BytesRead: 212, ClientPort: 33313, Frontend: "fnt", Mode: ModeTCP, Retries: 3,
+				Server: "srv1", TerminationState: "--", Tt: 5007 * time.Millisecond,
+			}, nil,
+		},
+		{"tcp with trailing data", testTCP + " extra", Log{}, parsesyslog.ErrWrongFormat},
+		{"http without request", testHTTP[:len(testHTTP)-27], Log{}, parsesyslog.ErrWrongFormat},
+		{"invalid client", "localhost [06/Feb/2009:12:14:14.655]", Log{}, parsesyslog.ErrWrongFormat},
+		{"invalid date", "10.0.1.2:33317 06/Feb/2009:12:14:14.655", Log{}, parsesyslog.ErrWrongFormat},
+		{"invalid timers", "10.0.1.2:1 [06/Feb/2009:12:14:14.655] fe be/srv 1/2 3", Log{}, parsesyslog.ErrWrongFormat},
+		{"other message", "Proxy http-in started.", Log{}, parsesyslog.ErrWrongFormat},
+		{"empty", "", Log{}, parsesyslog.ErrWrongFormat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := Parse(tt.msg)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Errorf("Parse() => expected error: %s, got: %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() failed: %s", err)
+			}
+			if l.ClientIP == nil || l.AcceptDate.IsZero() {
+				t.Errorf("Parse() => expected client IP and accept date, got: %s/%s", l.ClientIP, l.AcceptDate)
+			}
+			l.ClientIP, l.AcceptDate = nil, time.Time{}
+			if l.Mode != tt.want.Mode || l.Frontend != tt.want.Frontend || l.Backend != tt.want.Backend ||
+				l.Server != tt.want.Server || l.ClientPort != tt.want.ClientPort || l.StatusCode != tt.want.StatusCode ||
+				l.BytesRead != tt.want.BytesRead || l.TerminationState != tt.want.TerminationState ||
+				l.Request != tt.want.Request || l.Retries != tt.want.Retries || l.ActConn != tt.want.ActConn ||
+				l.FeConn != tt.want.FeConn || l.BeConn != tt.want.BeConn || l.SrvConn != tt.want.SrvConn {
+				t.Errorf("Parse() => expected: %+v, got: %+v", tt.want, l)
+			}
+			if l.TR != tt.want.TR || l.Tw != tt.want.Tw || l.Tc != tt.want.Tc || l.Tr != tt.want.Tr ||
+				l.Ta != tt.want.Ta || l.Tt != tt.want.Tt {
+				t.Errorf("Parse() timers => expected: %+v, got: %+v", tt.want, l)
+			}
+			if len(l.RequestHeaders) != len(tt.want.RequestHeaders) ||
+				len(l.ResponseHeaders) != len(tt.want.ResponseHeaders) {
+				t.Errorf("Parse() captures => expected: %q/%q, got: %q/%q", tt.want.RequestHeaders,
+					tt.want.ResponseHeaders, l.RequestHeaders, l.ResponseHeaders)
+			}
+		})
+	}
+}
+
+// TestDecode_rfc3164 tests Decode as BodyDecoder of the RFC3164 parser
+func TestDecode_rfc3164(t *testing.T) {
+	p, err := parsesyslog.New(rfc3164.Type, parsesyslog.WithBodyDecoders(Decode))
+	if err != nil {
+		t.Fatalf("failed to create new parser: %s", err)
+	}
+	tests := []struct {
+		name   string
+		msg    string
+		params map[string]string
+	}{
+		{"http", "<134>Feb  6 12:14:14 lb01 haproxy[14389]: " + testHTTP + "\n", map[string]string{
+			"mode": "http", "client_ip": "10.0.1.2", "frontend": "http-in", "server": "srv1", "Ta": "109",
+			"status_code": "200", "request": "GET /index.html HTTP/1.1",
+		}},
+		{"tcp", "<134>Feb  6 12:12:51 lb01 haproxy[14387]: " + testTCP + "\n", map[string]string{
+			"mode": "tcp", "client_port": "33313", "backend": "bck", "Tt": "5007", "retries": "3",
+		}},
+		{"other", "<134>Feb  6 12:12:51 lb01 haproxy[14387]: Proxy http-in started.\n", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lm, err := p.ParseString(tt.msg)
+			if err != nil {
+				t.Fatalf("ParseString() failed: %s", err)
+			}
+			if tt.params == nil {
+				if len(lm.StructuredData) != 0 {
+					t.Errorf("ParseString() => unexpected structured data: %+v", lm.StructuredData)
+				}
+				return
+			}
+			if len(lm.StructuredData) != 1 || lm.StructuredData[0].ID != SDID {
+				t.Fatalf("ParseString() => unexpected structured data: %+v", lm.StructuredData)
+			}
+			ps := make(map[string]string)
+			for _, p := range lm.StructuredData[0].Param {
+				ps[p.Name] = p.Value
+			}
+			for k, v := range tt.params {
+				if ps[k] != v {
+					t.Errorf("ParseString() param %s => expected: %q, got: %q", k, v, ps[k])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultHistogramWindow is the amount of minutes a SeverityHistogram covers by default
+const DefaultHistogramWindow = 60
+
+// SeverityHistogram counts log messages per minute, per severity and per facility, over
+// a rolling window of minutes. It is a lightweight alternative to a metrics pipeline
+// for feeding dashboards with the severity distribution of a stream. Messages are
+// accounted for in the minute they are added, not in the minute of their Timestamp,
+// since the clocks of senders can not be trusted.
+//
+// A SeverityHistogram is safe for concurrent use.
+type SeverityHistogram struct {
+	buckets []HistogramBucket
+	mu      sync.Mutex
+	now     func() time.Time
+	window  int
+}
+
+// HistogramBucket represents the counters of a single minute of a SeverityHistogram. The
+// counters are indexed by the numeric Facility and Severity.
+type HistogramBucket struct {
+	Facility [24]uint64 `json:"facility"`
+	Severity [8]uint64  `json:"severity"`
+	Start    time.Time  `json:"start"`
+}
+
+// NewSeverityHistogram returns a new, empty SeverityHistogram that covers the given
+// amount of minutes. If window is 0 or negative, DefaultHistogramWindow is used.
+func NewSeverityHistogram(window int) *SeverityHistogram {
+	if window <= 0 {
+		window = DefaultHistogramWindow
+	}
+	return &SeverityHistogram{now: time.Now, window: window}
+}
+
+// Add accounts a log message with the given Facility and Severity in the bucket of the
+// current minute
+func (h *SeverityHistogram) Add(f Facility, s Severity) {
+	if h == nil {
+		return
+	}
+	m := h.now().Truncate(time.Minute)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.buckets == nil {
+		h.buckets = make([]HistogramBucket, h.window)
+	}
+	b := &h.buckets[int(m.Unix()/60)%h.window]
+	if !b.Start.Equal(m) {
+		*b = HistogramBucket{Start: m}
+	}
+	if f >= 0 && int(f) < len(b.Facility) {
+		b.Facility[f]++
+	}
+	if s >= 0 && int(s) < len(b.Severity) {
+		b.Severity[s]++
+	}
+}
+
+// Snapshot returns the buckets of the minutes within the window that have messages,
+// ordered from the oldest to the current minute
+func (h *SeverityHistogram) Snapshot() []HistogramBucket {
+	if h == nil {
+		return nil
+	}
+	oldest := h.now().Truncate(time.Minute).Add(-time.Duration(h.window-1) * time.Minute)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var bs []HistogramBucket
+	for _, b := range h.buckets {
+		if !b.Start.IsZero() && !b.Start.Before(oldest) {
+			bs = append(bs, b)
+		}
+	}
+	sort.Slice(bs, func(i, j int) bool {
+		return bs[i].Start.Before(bs[j].Start)
+	})
+	return bs
+}
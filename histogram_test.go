@@ -0,0 +1,51 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"testing"
+	"time"
+)
+
+// TestSeverityHistogram tests that the SeverityHistogram counts per minute and only
+// covers its window
+func TestSeverityHistogram(t *testing.T) {
+	now := time.Date(2023, 1, 10, 12, 0, 30, 0, time.UTC)
+	h := NewSeverityHistogram(3)
+	h.now = func() time.Time { return now }
+
+	h.Add(4, 2)
+	h.Add(4, 3)
+	now = now.Add(time.Minute)
+	h.Add(1, 3)
+	h.Add(99, -1)
+	bs := h.Snapshot()
+	if len(bs) != 2 {
+		t.Fatalf("Snapshot() => expected 2 buckets, got: %d", len(bs))
+	}
+	if !bs[0].Start.Equal(time.Date(2023, 1, 10, 12, 0, 0, 0, time.UTC)) || bs[1].Start.Sub(bs[0].Start) != time.Minute {
+		t.Errorf("Snapshot() => unexpected buckets: %s, %s", bs[0].Start, bs[1].Start)
+	}
+	if bs[0].Facility[4] != 2 || bs[0].Severity[2] != 1 || bs[0].Severity[3] != 1 {
+		t.Errorf("Snapshot() => unexpected first bucket: %+v", bs[0])
+	}
+	if bs[1].Facility[1] != 1 || bs[1].Severity[3] != 1 {
+		t.Errorf("Snapshot() => unexpected second bucket: %+v", bs[1])
+	}
+
+	// The first minute leaves the window, its slot is reused by the fourth minute
+	now = now.Add(2 * time.Minute)
+	h.Add(0, 0)
+	bs = h.Snapshot()
+	if len(bs) != 2 || bs[0].Facility[1] != 1 || bs[1].Facility[0] != 1 || bs[1].Facility[4] != 0 {
+		t.Errorf("Snapshot() => unexpected buckets after rollover: %+v", bs)
+	}
+
+	var nh *SeverityHistogram
+	nh.Add(0, 0)
+	if nh.Snapshot() != nil {
+		t.Errorf("Snapshot() on nil SeverityHistogram => expected nil")
+	}
+}
@@ -0,0 +1,87 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"container/list"
+	"sync"
+)
+
+// DefaultInternerSize is the amount of strings an Interner keeps by default
+const DefaultInternerSize = 4096
+
+// maxInternLen is the maximum length of a string that is interned. It matches the
+// maximum length of a hostname, so longer values are not expected to recur.
+const maxInternLen = 255
+
+// Interner deduplicates recurring strings, like the hostnames and app names of log
+// messages. High-volume streams repeat the same few values millions of times, so log
+// messages that are retained (i. e. in a Ring) would otherwise each hold their
+// own copy of them. The Interner keeps the most recently used strings in a LRU cache of
+// bounded size and returns the cached string for equal byte slices, so that they share
+// the same backing memory. Strings longer than 255 bytes are not interned.
+//
+// An Interner is safe for concurrent use and can be shared between parsers.
+type Interner struct {
+	lru  *list.List
+	mu   sync.Mutex
+	size int
+	strs map[string]*list.Element
+}
+
+// NewInterner returns a new Interner that keeps up to size strings. If size is 0 or
+// negative, DefaultInternerSize is used.
+func NewInterner(size int) *Interner {
+	if size <= 0 {
+		size = DefaultInternerSize
+	}
+	return &Interner{
+		lru:  list.New(),
+		size: size,
+		strs: make(map[string]*list.Element),
+	}
+}
+
+// Intern returns the string of the given byte slice. If an equal string is cached, the
+// cached string is returned without allocation, otherwise the new string is cached and
+// the least recently used string is evicted, if the Interner is full. A nil Interner
+// returns a new string.
+func (i *Interner) Intern(b []byte) string {
+	if i == nil || len(b) == 0 || len(b) > maxInternLen {
+		return string(b)
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if el, ok := i.strs[string(b)]; ok {
+		i.lru.MoveToFront(el)
+		return el.Value.(string)
+	}
+	s := string(b)
+	i.strs[s] = i.lru.PushFront(s)
+	if i.lru.Len() > i.size {
+		el := i.lru.Back()
+		i.lru.Remove(el)
+		delete(i.strs, el.Value.(string))
+	}
+	return s
+}
+
+// Reuse works like ReuseString, but interns the byte slice if it is not equal to s
+func (i *Interner) Reuse(s string, b []byte) string {
+	if s == string(b) {
+		return s
+	}
+	return i.Intern(b)
+}
+
+// Len returns the amount of strings the Interner currently keeps
+func (i *Interner) Len() int {
+	if i == nil {
+		return 0
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.lru.Len()
+}
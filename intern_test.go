@@ -0,0 +1,65 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+// stringData returns the pointer to the backing memory of the given string
+func stringData(s string) uintptr {
+	return (*reflect.StringHeader)(unsafe.Pointer(&s)).Data
+}
+
+// TestInterner_Intern tests that equal byte slices share the same string and that the
+// Interner is bounded
+func TestInterner_Intern(t *testing.T) {
+	i := NewInterner(2)
+	a := i.Intern([]byte("host1"))
+	if b := i.Intern([]byte("host1")); stringData(a) != stringData(b) {
+		t.Errorf("Intern() => expected equal byte slices to share the same string")
+	}
+	_ = i.Intern([]byte("host2"))
+	_ = i.Intern([]byte("host1"))
+	_ = i.Intern([]byte("host3"))
+	if i.Len() != 2 {
+		t.Errorf("Len() => expected: %d, got: %d", 2, i.Len())
+	}
+	if b := i.Intern([]byte("host1")); stringData(a) != stringData(b) {
+		t.Errorf("Intern() => expected recently used string to be kept")
+	}
+	if _, ok := i.strs["host2"]; ok {
+		t.Errorf("Intern() => expected least recently used string to be evicted")
+	}
+
+	long := strings.Repeat("x", maxInternLen+1)
+	if s := i.Intern([]byte(long)); s != long {
+		t.Errorf("Intern() => expected: %q, got: %q", long, s)
+	}
+	if _, ok := i.strs[long]; ok {
+		t.Errorf("Intern() => expected long string not to be interned")
+	}
+
+	var ni *Interner
+	if s := ni.Intern([]byte("host1")); s != "host1" || ni.Len() != 0 {
+		t.Errorf("Intern() on nil Interner => expected: %q, got: %q", "host1", s)
+	}
+	if s := ni.Reuse("host1", []byte("host2")); s != "host2" {
+		t.Errorf("Reuse() on nil Interner => expected: %q, got: %q", "host2", s)
+	}
+}
+
+// TestInterner_Intern_allocs tests that interning a cached string does not allocate
+func TestInterner_Intern_allocs(t *testing.T) {
+	i := NewInterner(0)
+	b := []byte("mymachine.example.com")
+	_ = i.Intern(b)
+	if n := testing.AllocsPerRun(100, func() { _ = i.Intern(b) }); n != 0 {
+		t.Errorf("Intern() => expected no allocations, got: %f", n)
+	}
+}
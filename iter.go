@@ -0,0 +1,78 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+//go:build go1.23
+// +build go1.23
+
+package parsesyslog
+
+import (
+	"errors"
+	"io"
+	"iter"
+)
+
+// Messages returns an iterator over the log messages that the given Parser parses from
+// the given io.Reader, so that a stream can be consumed with a range loop:
+//
+//	for lm, err := range parsesyslog.Messages(p, conn) {
+//		...
+//	}
+//
+// It is a shortcut for the All method of a StreamParser
+func Messages(p Parser, r io.Reader) iter.Seq2[LogMsg, error] {
+	return NewStreamParser(p, r).All()
+}
+
+// recoverableErrors are the error classes (see ClassifyError) of errors that concern a
+// single log message, after which the stream continues with the next log message
+var recoverableErrors = []error{
+	ErrABNFViolation, ErrFrameTooLarge, ErrHeaderTooLarge, ErrInvalidPrio, ErrInvalidProtoVersion,
+	ErrInvalidTimestamp, ErrMessageTooLarge, ErrMessageTooLong, ErrParserPanic, ErrWrongFormat, ErrWrongSDFormat,
+}
+
+// All returns an iterator over the remaining log messages of the stream. Every log
+// message is yielded with the error of the Parser (see Next). The iteration ends when the
+// stream ends between two messages, which is not yielded as error, or after the first
+// error that does not concern a single log message, i. e. a read error, a stream that
+// ends within a message or a framing error after which the start of the next message is
+// unknown, as the stream can not be continued then.
+func (s *StreamParser) All() iter.Seq2[LogMsg, error] {
+	return func(yield func(LogMsg, error) bool) {
+		for {
+			lm, err := s.Next()
+			if errors.Is(err, io.EOF) && !isMessageError(err) {
+				return
+			}
+			if !yield(lm, err) {
+				return
+			}
+			if err != nil && (!isMessageError(err) || isEOF(err)) {
+				return
+			}
+		}
+	}
+}
+
+// isMessageError returns true if the given error concerns a single log message, i. e. a
+// ParseError or a PanicError of the Parser or an error of a recoverable class
+func isMessageError(err error) bool {
+	var pe *ParseError
+	var pae *PanicError
+	if errors.As(err, &pe) || errors.As(err, &pae) {
+		return true
+	}
+	c := ClassifyError(err)
+	for _, r := range recoverableErrors {
+		if c == r {
+			return true
+		}
+	}
+	return false
+}
+
+// isEOF returns true if the given error means that the stream has ended
+func isEOF(err error) bool {
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, ErrPrematureEOF)
+}
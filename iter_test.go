@@ -0,0 +1,127 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+//go:build go1.23
+// +build go1.23
+
+package parsesyslog
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// TestMessages tests the iterator over the log messages of a stream
+func TestMessages(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		wantErr error
+	}{
+		{"multiple messages", "first\nsecond\nthird\n", []string{"first", "second", "third"}, nil},
+		{"separators", "\n\x00first\n\nsecond\n", []string{"first", "second"}, nil},
+		{"truncated", "first\nsec", []string{"first", ""}, ErrPrematureEOF},
+		{"empty", "", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var msgs []string
+			var err error
+			for lm, lerr := range Messages(lineParser{}, strings.NewReader(tt.input)) {
+				msgs = append(msgs, lm.Message.String())
+				if lerr != nil {
+					err = lerr
+				}
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Messages() error => expected: %v, got: %v", tt.wantErr, err)
+			}
+			if strings.Join(msgs, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("Messages() => expected: %q, got: %q", tt.want, msgs)
+			}
+		})
+	}
+}
+
+// limitParser is a singleLineParser that fails with ErrMessageTooLarge on lines that
+// contain "large" and with an unclassified error on lines that contain "broken"
+type limitParser struct {
+	singleLineParser
+}
+
+// ParseReader satisfies the Parser interface for the limitParser type
+func (p limitParser) ParseReader(r io.Reader) (LogMsg, error) {
+	l, err := p.panicParser.ParseReader(r)
+	switch {
+	case strings.Contains(l.Message.String(), "large"):
+		return l, fmt.Errorf("%w: more than 5 bytes", ErrMessageTooLarge)
+	case strings.Contains(l.Message.String(), "broken"):
+		return l, errors.New("connection reset")
+	case errors.Is(err, io.EOF):
+		return l, nil
+	}
+	return l, err
+}
+
+// TestStreamParser_All_errors tests that the iteration continues after errors of single
+// log messages and stops after other errors
+func TestStreamParser_All_errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+		errs  int
+	}{
+		{"oversize message", "first\nlarge message\nthird\n", []string{"first", "large message", "third"}, 1},
+		{"read error", "first\nbroken\nthird\n", []string{"first", "broken"}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var msgs []string
+			errs := 0
+			for lm, err := range Messages(limitParser{}, strings.NewReader(tt.input)) {
+				msgs = append(msgs, lm.Message.String())
+				if err != nil {
+					errs++
+				}
+			}
+			if errs != tt.errs || strings.Join(msgs, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("Messages() => expected %q with %d errors, got: %q with %d errors", tt.want, tt.errs,
+					msgs, errs)
+			}
+		})
+	}
+}
+
+// TestStreamParser_All tests that the iteration continues after errors of single log
+// messages and stops when the loop is left
+func TestStreamParser_All(t *testing.T) {
+	sp := NewStreamParser(panicParser{}, strings.NewReader("first\nboom\nthird\nfourth\n"))
+	var msgs []string
+	panics := 0
+	for lm, err := range sp.All() {
+		var pe *PanicError
+		if errors.As(err, &pe) {
+			panics++
+			continue
+		}
+		if err != nil {
+			t.Fatalf("All() => unexpected error: %s", err)
+		}
+		msgs = append(msgs, lm.Message.String())
+		if len(msgs) == 2 {
+			break
+		}
+	}
+	if panics != 1 || strings.Join(msgs, "|") != "first|third" {
+		t.Errorf("All() => expected 1 panic and messages first and third, got: %d and %q", panics, msgs)
+	}
+	if lm, err := sp.Next(); err != nil || lm.Message.String() != "fourth" {
+		t.Errorf("Next() after break => expected: %q, got: %q (%v)", "fourth", lm.Message.String(), err)
+	}
+}
@@ -0,0 +1,181 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+	"unicode/utf8"
+)
+
+// SchemaVersion is the version of the schema of the serialized representations of a
+// LogMsg (i. e. JSON). It is written with every serialized LogMsg.
+//
+// Compatibility policy: fields are only ever added to the schema within a version.
+// Consumers ignore fields they do not know, so data written by a newer release with
+// the same SchemaVersion can be read by older releases. Renaming, removing or changing
+// the meaning of a field increases the SchemaVersion. Data of an older SchemaVersion is
+// always accepted and converted to the current one, data of a newer SchemaVersion is
+// rejected with ErrUnsupportedSchemaVersion. Data without schema version is treated as
+// SchemaVersion 1.
+const SchemaVersion = 1
+
+// jsonLogMsg represents the JSON schema of a LogMsg
+type jsonLogMsg struct {
+	Schema         int             `json:"schema"`
+	AppName        string          `json:"app_name,omitempty"`
+	ExtraBytes     int             `json:"extra_bytes,omitempty"`
+	Facility       Facility        `json:"facility"`
+	HasBOM         bool            `json:"has_bom,omitempty"`
+	Hostname       string          `json:"hostname,omitempty"`
+	Message        *string         `json:"message,omitempty"`
+	MessageRaw     []byte          `json:"message_raw,omitempty"`
+	MsgID          string          `json:"msg_id,omitempty"`
+	OrigSeverity   *Severity       `json:"original_severity,omitempty"`
+	Priority       Priority        `json:"priority"`
+	ProcID         string          `json:"proc_id,omitempty"`
+	ProtoVersion   ProtoVersion    `json:"proto_version,omitempty"`
+	Relaxed        Relaxation      `json:"relaxed,omitempty"`
+	ResolvedHost   string          `json:"resolved_host,omitempty"`
+	Severity       Severity        `json:"severity"`
+	SpanID         string          `json:"span_id,omitempty"`
+	StructuredData []jsonSDElement `json:"structured_data,omitempty"`
+	Timestamp      *time.Time      `json:"timestamp,omitempty"`
+	TraceID        string          `json:"trace_id,omitempty"`
+	TraceState     string          `json:"trace_state,omitempty"`
+	Truncated      bool            `json:"truncated,omitempty"`
+	Type           LogMsgType      `json:"type,omitempty"`
+}
+
+// jsonSDElement represents the JSON schema of a StructuredDataElement
+type jsonSDElement struct {
+	ID     string        `json:"id"`
+	Params []jsonSDParam `json:"params,omitempty"`
+}
+
+// jsonSDParam represents the JSON schema of a StructuredDataParam
+type jsonSDParam struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// MarshalJSON returns the JSON representation of the LogMsg, including the
+// SchemaVersion. Messages that are valid UTF-8 are stored as "message" string, all
+// other messages as base64 encoded "message_raw", so that they survive the round
+// trip unchanged. It satisfies the json.Marshaler interface.
+func (l LogMsg) MarshalJSON() ([]byte, error) {
+	j := jsonLogMsg{
+		Schema:       SchemaVersion,
+		AppName:      l.AppName,
+		ExtraBytes:   l.ExtraBytes,
+		Facility:     l.Facility,
+		HasBOM:       l.HasBOM,
+		Hostname:     l.Hostname,
+		MsgID:        l.MsgID,
+		OrigSeverity: l.OriginalSeverity,
+		Priority:     l.Priority,
+		ProcID:       l.ProcID,
+		ProtoVersion: l.ProtoVersion,
+		Relaxed:      l.Relaxed,
+		ResolvedHost: l.ResolvedHost,
+		Severity:     l.Severity,
+		SpanID:       l.SpanID,
+		TraceID:      l.TraceID,
+		TraceState:   l.TraceState,
+		Truncated:    l.Truncated,
+		Type:         l.Type,
+	}
+	if mb := l.Message.Bytes(); utf8.Valid(mb) {
+		m := string(mb)
+		j.Message = &m
+	} else {
+		j.MessageRaw = mb
+	}
+	if !l.Timestamp.IsZero() {
+		ts := l.Timestamp
+		j.Timestamp = &ts
+	}
+	for _, e := range l.StructuredData {
+		je := jsonSDElement{ID: e.ID}
+		for _, p := range e.Param {
+			je.Params = append(je.Params, jsonSDParam{Name: p.Name, Value: p.Value})
+		}
+		j.StructuredData = append(j.StructuredData, je)
+	}
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON sets the LogMsg to the given JSON representation. Data of a newer
+// SchemaVersion is rejected with ErrUnsupportedSchemaVersion. It satisfies the
+// json.Unmarshaler interface.
+func (l *LogMsg) UnmarshalJSON(b []byte) error {
+	var j jsonLogMsg
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+	if err := migrateJSON(&j); err != nil {
+		return err
+	}
+	l.Reset()
+	l.AppName = j.AppName
+	l.ExtraBytes = j.ExtraBytes
+	l.Facility = j.Facility
+	l.HasBOM = j.HasBOM
+	l.Hostname = j.Hostname
+	l.MsgID = j.MsgID
+	l.OriginalSeverity = j.OrigSeverity
+	l.Priority = j.Priority
+	l.ProcID = j.ProcID
+	l.ProtoVersion = j.ProtoVersion
+	l.Relaxed = j.Relaxed
+	l.ResolvedHost = j.ResolvedHost
+	l.Severity = j.Severity
+	l.SpanID = j.SpanID
+	l.TraceID = j.TraceID
+	l.TraceState = j.TraceState
+	l.Truncated = j.Truncated
+	l.Type = j.Type
+	if j.Timestamp != nil {
+		l.Timestamp = *j.Timestamp
+	}
+	if j.Message != nil {
+		l.Message.WriteString(*j.Message)
+	} else {
+		l.Message.Write(j.MessageRaw)
+	}
+	l.MsgLength = l.Message.Len()
+	for _, je := range j.StructuredData {
+		e := StructuredDataElement{ID: je.ID}
+		for _, jp := range je.Params {
+			e.Param = append(e.Param, StructuredDataParam{Name: jp.Name, Value: jp.Value})
+		}
+		l.StructuredData = append(l.StructuredData, e)
+	}
+	return nil
+}
+
+// MigrateJSON converts the given JSON representation of a LogMsg of any supported
+// SchemaVersion to the current SchemaVersion. This allows to upgrade archives in place.
+func MigrateJSON(b []byte) ([]byte, error) {
+	var l LogMsg
+	if err := l.UnmarshalJSON(b); err != nil {
+		return nil, err
+	}
+	return l.MarshalJSON()
+}
+
+// migrateJSON converts the decoded JSON representation to the current SchemaVersion.
+// Conversions of older schema versions are added here, once the SchemaVersion is
+// increased.
+func migrateJSON(j *jsonLogMsg) error {
+	if j.Schema == 0 {
+		j.Schema = 1
+	}
+	if j.Schema > SchemaVersion {
+		return fmt.Errorf("%w: %d (supported: %d)", ErrUnsupportedSchemaVersion, j.Schema, SchemaVersion)
+	}
+	return nil
+}
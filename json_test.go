@@ -0,0 +1,102 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestLogMsg_MarshalJSON tests the MarshalJSON and UnmarshalJSON methods of the LogMsg
+func TestLogMsg_MarshalJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  string
+	}{
+		{"UTF-8 message", "Hello, World! ✓"},
+		{"binary message", "Hello\xff\xfeWorld"},
+		{"empty message", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lm := LogMsg{
+				AppName: "su", Facility: 4, Hostname: "host1", MsgID: "ID47", Priority: 34, ProcID: "123",
+				ProtoVersion: 1, Severity: 2, Type: RFC5424,
+				Timestamp: time.Date(2003, 10, 11, 22, 14, 15, 3000000, time.FixedZone("", -7*3600)),
+				StructuredData: []StructuredDataElement{
+					{ID: "exampleSDID@32473", Param: []StructuredDataParam{{"iut", "3"}, {"eventSource", "App"}}},
+					{ID: "empty@32473"},
+				},
+			}
+			lm.Message.WriteString(tt.msg)
+			lm.MsgLength = lm.Message.Len()
+			b, err := json.Marshal(lm)
+			if err != nil {
+				t.Fatalf("json.Marshal() failed: %s", err)
+			}
+			if !strings.HasPrefix(string(b), `{"schema":1,`) {
+				t.Errorf("json.Marshal() schema version missing: %s", b)
+			}
+			var got LogMsg
+			if err := json.Unmarshal(b, &got); err != nil {
+				t.Fatalf("json.Unmarshal() failed: %s", err)
+			}
+			if got.Message.String() != tt.msg {
+				t.Errorf("json round trip wrong message => expected: %q, got: %q", tt.msg, got.Message.String())
+			}
+			if got.AppName != lm.AppName || got.Hostname != lm.Hostname || got.Priority != lm.Priority ||
+				got.MsgLength != lm.MsgLength || !got.Timestamp.Equal(lm.Timestamp) || got.Type != lm.Type {
+				t.Errorf("json round trip wrong header => expected: %+v, got: %+v", lm, got)
+			}
+			if len(got.StructuredData) != 2 || got.StructuredData[0].Param[1].Value != "App" ||
+				got.StructuredData[1].ID != "empty@32473" {
+				t.Errorf("json round trip wrong structured data: %+v", got.StructuredData)
+			}
+		})
+	}
+}
+
+// TestLogMsg_UnmarshalJSON_schema tests the schema version handling of UnmarshalJSON
+func TestLogMsg_UnmarshalJSON_schema(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr error
+	}{
+		{"current version", `{"schema":1,"hostname":"host1"}`, nil},
+		{"no version", `{"hostname":"host1"}`, nil},
+		{"unknown fields", `{"schema":1,"hostname":"host1","future_field":true}`, nil},
+		{"newer version", `{"schema":2,"hostname":"host1"}`, ErrUnsupportedSchemaVersion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var lm LogMsg
+			err := json.Unmarshal([]byte(tt.data), &lm)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("json.Unmarshal() error => expected: %v, got: %v", tt.wantErr, err)
+			}
+			if err == nil && lm.Hostname != "host1" {
+				t.Errorf("json.Unmarshal() wrong hostname => expected: %s, got: %s", "host1", lm.Hostname)
+			}
+		})
+	}
+}
+
+// TestMigrateJSON tests the MigrateJSON method
+func TestMigrateJSON(t *testing.T) {
+	b, err := MigrateJSON([]byte(`{"hostname":"host1","message":"test"}`))
+	if err != nil {
+		t.Fatalf("MigrateJSON() failed: %s", err)
+	}
+	if !strings.Contains(string(b), `"schema":1`) || !strings.Contains(string(b), `"message":"test"`) {
+		t.Errorf("MigrateJSON() => unexpected result: %s", b)
+	}
+	if _, err = MigrateJSON([]byte(`{"schema":99}`)); !errors.Is(err, ErrUnsupportedSchemaVersion) {
+		t.Errorf("MigrateJSON() => expected: %s, got: %v", ErrUnsupportedSchemaVersion, err)
+	}
+}
@@ -0,0 +1,86 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// JSONBodySDID is the SD-ID of the structured data element that holds the fields of
+	// a JSON message body
+	JSONBodySDID = "json@32473"
+	// ceeCookie is the cookie that marks a CEE (Common Event Expression) JSON message
+	// body
+	ceeCookie = "@cee:"
+)
+
+// ParseJSONBody decodes the given message body, if it is a JSON object. The object may
+// be preceded by a BOM and by the "@cee:" cookie of the Common Event Expression format,
+// as used by rsyslog (mmjsonparse) and syslog-ng. Numbers are decoded as json.Number, so
+// that they do not lose precision. If the message body is not a JSON object,
+// ErrWrongFormat is returned.
+// See: https://www.rsyslog.com/doc/configuration/modules/mmjsonparse.html
+func ParseJSONBody(s string) (map[string]interface{}, error) {
+	s = strings.TrimPrefix(s, "\ufeff")
+	s = strings.TrimLeft(strings.TrimPrefix(strings.TrimLeft(s, " "), ceeCookie), " ")
+	if !strings.HasPrefix(s, "{") {
+		return nil, fmt.Errorf("%w: message body is not a JSON object", ErrWrongFormat)
+	}
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	var m map[string]interface{}
+	if err := dec.Decode(&m); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrWrongFormat, err)
+	}
+	if strings.TrimSpace(s[dec.InputOffset():]) != "" {
+		return nil, fmt.Errorf("%w: unexpected data after JSON object", ErrWrongFormat)
+	}
+	return m, nil
+}
+
+// DecodeJSONBody decodes a JSON object message body (see ParseJSONBody) and stores its
+// fields as structured data element with the SD-ID JSONBodySDID. Nested objects are
+// flattened into dotted param names (i. e. "http.status"), arrays are stored as their
+// JSON encoding and null values as empty values. The params are sorted by name. Message
+// bodies that are not a JSON object are not recognized. DecodeJSONBody satisfies the
+// BodyDecoder type.
+func DecodeJSONBody(lm *LogMsg) bool {
+	m, err := ParseJSONBody(lm.Message.String())
+	if err != nil {
+		return false
+	}
+	var ps []StructuredDataParam
+	flattenJSON("", m, &ps)
+	sort.SliceStable(ps, func(i, j int) bool { return ps[i].Name < ps[j].Name })
+	lm.StructuredData = append(lm.StructuredData, StructuredDataElement{ID: JSONBodySDID, Param: ps})
+	return true
+}
+
+// WithJSONBody makes the parser decode JSON object message bodies (optionally preceded
+// by the "@cee:" cookie) into a structured data element with the SD-ID JSONBodySDID. It
+// adds DecodeJSONBody to the BodyDecoders, so that BodyDecoders given before take
+// precedence.
+func WithJSONBody() Option {
+	return WithBodyDecoders(DecodeJSONBody)
+}
+
+// flattenJSON appends the fields of the given JSON object to the given params, with
+// the given prefix in front of their names
+func flattenJSON(prefix string, m map[string]interface{}, ps *[]StructuredDataParam) {
+	for k, v := range m {
+		n := prefix + k
+		if o, ok := v.(map[string]interface{}); ok {
+			flattenJSON(n+".", o, ps)
+			continue
+		}
+		if s, ok := jsonValue(v); ok {
+			*ps = append(*ps, StructuredDataParam{Name: n, Value: s})
+		}
+	}
+}
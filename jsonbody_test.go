@@ -0,0 +1,66 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// TestParseJSONBody tests the ParseJSONBody function
+func TestParseJSONBody(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want map[string]interface{}
+		err  error
+	}{
+		{"object", `{"msg":"hi","n":1}` + "\n", map[string]interface{}{"msg": "hi", "n": json.Number("1")}, nil},
+		{"cee cookie", `@cee: {"msg":"hi"}`, map[string]interface{}{"msg": "hi"}, nil},
+		{"cee cookie without space", `@cee:{"msg":"hi"}`, map[string]interface{}{"msg": "hi"}, nil},
+		{"bom", "\xef\xbb\xbf{\"msg\":\"hi\"}", map[string]interface{}{"msg": "hi"}, nil},
+		{"array", `["msg"]`, nil, ErrWrongFormat},
+		{"text", "user logged in", nil, ErrWrongFormat},
+		{"invalid json", `{"msg":}`, nil, ErrWrongFormat},
+		{"trailing data", `{"msg":"hi"} and more`, nil, ErrWrongFormat},
+		{"empty", "", nil, ErrWrongFormat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := ParseJSONBody(tt.s)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ParseJSONBody() error => expected: %v, got: %v", tt.err, err)
+			}
+			if !reflect.DeepEqual(m, tt.want) {
+				t.Errorf("ParseJSONBody() => expected: %v, got: %v", tt.want, m)
+			}
+		})
+	}
+}
+
+// TestDecodeJSONBody tests the DecodeJSONBody function
+func TestDecodeJSONBody(t *testing.T) {
+	var lm LogMsg
+	lm.Message.WriteString(`@cee: {"msg":"request done","http":{"status":200,"ok":true,"tags":["a","b"]},` +
+		`"user":null,"latency":0.25}`)
+	if !DecodeJSONBody(&lm) {
+		t.Fatalf("DecodeJSONBody() => expected JSON body to be recognized")
+	}
+	want := []StructuredDataElement{{ID: JSONBodySDID, Param: []StructuredDataParam{
+		{"http.ok", "true"}, {"http.status", "200"}, {"http.tags", `["a","b"]`}, {"latency", "0.25"},
+		{"msg", "request done"}, {"user", ""},
+	}}}
+	if !reflect.DeepEqual(lm.StructuredData, want) {
+		t.Errorf("DecodeJSONBody() => expected: %v, got: %v", want, lm.StructuredData)
+	}
+
+	lm = LogMsg{}
+	lm.Message.WriteString("{not json")
+	if NewOptions(WithJSONBody()).PostProcess(&lm); len(lm.StructuredData) != 0 {
+		t.Errorf("PostProcess() => expected no structured data, got: %v", lm.StructuredData)
+	}
+}
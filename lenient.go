@@ -0,0 +1,56 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"strings"
+)
+
+// Relaxation represents the deviations from the log format that a parser in lenient
+// mode (see WithLenient) accepted while parsing a log message
+type Relaxation uint8
+
+// Relaxations
+const (
+	// RelaxedPriority means that the PRI had leading zeros (i. e. "<034>")
+	RelaxedPriority Relaxation = 1 << iota
+	// RelaxedTimestamp means that the timestamp deviated from the format (i. e. a BSD
+	// timestamp with fractional seconds or a single space before a single-digit day, or an
+	// RFC5424 timestamp without time zone)
+	RelaxedTimestamp
+	// RelaxedTag means that the colon of the RFC3164 tag was not followed by a space
+	RelaxedTag
+	// RelaxedMsgID means that the MSGID of the RFC5424 header was missing
+	RelaxedMsgID
+	// RelaxedSDName means that an SD-ID or a PARAM-NAME of the RFC5424 structured data
+	// was not a valid SD-NAME (i. e. longer than 32 characters)
+	RelaxedSDName
+	// RelaxedDuplicateSDID means that multiple elements of the RFC5424 structured data
+	// had the same SD-ID
+	RelaxedDuplicateSDID
+	// RelaxedMissingPriority means that the log message lacked the PRI and got the
+	// default priority (see WithDefaultPriority)
+	RelaxedMissingPriority
+)
+
+// relaxationNames are the names of the Relaxation flags, in the order of their values
+var relaxationNames = []string{"priority", "timestamp", "tag", "msgid", "sdname", "duplicate-sdid", "missing-pri"}
+
+// Has returns true if all of the given Relaxation flags are set
+func (r Relaxation) Has(f Relaxation) bool {
+	return r&f == f
+}
+
+// String satisfies the fmt.Stringer interface for the Relaxation type. It returns the
+// names of the set flags, separated by commas (i. e. "priority,tag").
+func (r Relaxation) String() string {
+	var n []string
+	for i, name := range relaxationNames {
+		if r&(1<<i) != 0 {
+			n = append(n, name)
+		}
+	}
+	return strings.Join(n, ",")
+}
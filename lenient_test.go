@@ -0,0 +1,38 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import "testing"
+
+// TestRelaxation_String tests the String and Has methods of the Relaxation type
+func TestRelaxation_String(t *testing.T) {
+	tests := []struct {
+		name string
+		r    Relaxation
+		want string
+	}{
+		{"none", 0, ""},
+		{"priority", RelaxedPriority, "priority"},
+		{"timestamp", RelaxedTimestamp, "timestamp"},
+		{"tag", RelaxedTag, "tag"},
+		{"msgid", RelaxedMsgID, "msgid"},
+		{"missing-pri", RelaxedMissingPriority, "missing-pri"},
+		{"priority and tag", RelaxedPriority | RelaxedTag, "priority,tag"},
+		{"all", RelaxedPriority | RelaxedTimestamp | RelaxedTag | RelaxedMsgID, "priority,timestamp,tag,msgid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.r.String() != tt.want {
+				t.Errorf("String() => expected: %q, got: %q", tt.want, tt.r.String())
+			}
+			if !tt.r.Has(tt.r) {
+				t.Errorf("Has() => expected %q to have itself", tt.r)
+			}
+			if tt.r != 0 && tt.r.Has(tt.r|RelaxedMissingPriority<<1) {
+				t.Errorf("Has() => expected %q not to have an unset flag", tt.r)
+			}
+		})
+	}
+}
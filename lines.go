@@ -0,0 +1,105 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+)
+
+// LineFunc is called by ParseLines for every non-empty line of the input with the line
+// number (starting at 1), the parsed LogMsg and the error of the Parser. If the Parser
+// references the input (see WithZeroCopy), the LogMsg is only valid until the function
+// returns, unless it is cloned (see Clone). If the function returns an error, ParseLines
+// stops and returns it.
+type LineFunc func(line int, lm *LogMsg, err error) error
+
+// LineResult represents the result of parsing a single line with ParseAllLines
+type LineResult struct {
+	// Line is the line number of the log message, starting at 1
+	Line int
+	// Msg is the parsed log message
+	Msg LogMsg
+	// Err is the error of the Parser, if parsing the line failed
+	Err error
+}
+
+// ParseLines reads the given io.Reader line by line and parses every line as an
+// independent log message with the given Parser, as it is needed for log files. Unlike
+// the StreamParser, no octet-count or framing is required and a broken line does not
+// affect the following ones. The line endings (LF or CRLF) are not part of the log
+// messages and empty lines are skipped. A panic of the Parser is recovered and handed to
+// the LineFunc as *PanicError.
+//
+// ParseLines returns nil once the io.Reader is exhausted, or the first error of the
+// io.Reader or the LineFunc.
+func ParseLines(p Parser, r io.Reader, fn LineFunc) error {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	var l LogMsg
+	var buf []byte
+	for n := 1; ; n++ {
+		line, err := readLine(br, &buf)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		if len(line) > 0 {
+			perr := parseLine(p, line, &l)
+			if ferr := fn(n, &l, perr); ferr != nil {
+				return ferr
+			}
+		}
+		if err != nil {
+			return nil
+		}
+	}
+}
+
+// ParseAllLines works like ParseLines, but returns the results of all lines as slice,
+// in the order of the lines. The LogMsgs are cloned (see Clone), so that they do not
+// reference the input, even if the Parser has been created with WithZeroCopy.
+func ParseAllLines(p Parser, r io.Reader) ([]LineResult, error) {
+	var res []LineResult
+	err := ParseLines(p, r, func(line int, lm *LogMsg, err error) error {
+		res = append(res, LineResult{Line: line, Msg: lm.Clone(), Err: err})
+		return nil
+	})
+	return res, err
+}
+
+// readLine returns the next line of the bufio.Reader without its line ending. Lines that
+// exceed the buffer of the bufio.Reader are assembled in the given buffer. The error is
+// io.EOF if the reader ended after the returned line.
+func readLine(br *bufio.Reader, buf *[]byte) ([]byte, error) {
+	line, err := br.ReadSlice('\n')
+	if errors.Is(err, bufio.ErrBufferFull) {
+		*buf = append((*buf)[:0], line...)
+		for errors.Is(err, bufio.ErrBufferFull) {
+			line, err = br.ReadSlice('\n')
+			*buf = append(*buf, line...)
+		}
+		line = *buf
+	}
+	line = bytes.TrimSuffix(line, []byte("\n"))
+	line = bytes.TrimSuffix(line, []byte("\r"))
+	return line, err
+}
+
+// parseLine parses a single line into the given LogMsg, recovering from a panic of the
+// Parser
+func parseLine(p Parser, line []byte, l *LogMsg) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			l.Reset()
+			err = NewPanicError(r, line)
+		}
+	}()
+	*l, err = ParseBytes(p, line)
+	return err
+}
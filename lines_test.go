@@ -0,0 +1,91 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// singleLineParser is a panicParser that expects the line without line ending
+type singleLineParser struct {
+	panicParser
+}
+
+// ParseReader satisfies the Parser interface for the singleLineParser type
+func (p singleLineParser) ParseReader(r io.Reader) (LogMsg, error) {
+	l, err := p.panicParser.ParseReader(r)
+	if errors.Is(err, io.EOF) {
+		err = nil
+	}
+	return l, err
+}
+
+// TestParseLines tests that ParseLines parses every line as independent log message
+func TestParseLines(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		lines []int
+		msgs  []string
+		errs  int
+	}{
+		{"single line", "first", []int{1}, []string{"first"}, 0},
+		{"multiple lines", "first\nsecond\r\nthird\n", []int{1, 2, 3}, []string{"first", "second", "third"}, 0},
+		{"empty lines", "\nfirst\n\n\r\nsecond\n", []int{2, 5}, []string{"first", "second"}, 0},
+		{"boom", "first\nboom\nthird", []int{1, 2, 3}, []string{"first", "", "third"}, 1},
+		{"long line", strings.Repeat("x", 10000) + "\nsecond", []int{1, 2}, []string{strings.Repeat("x", 10000), "second"}, 0},
+		{"empty", "", nil, nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := ParseAllLines(singleLineParser{}, strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("ParseAllLines() failed: %s", err)
+			}
+			var lines []int
+			var msgs []string
+			errs := 0
+			for _, r := range res {
+				lines = append(lines, r.Line)
+				msgs = append(msgs, r.Msg.Message.String())
+				var pe *PanicError
+				if errors.As(r.Err, &pe) {
+					errs++
+				}
+			}
+			if len(lines) != len(tt.lines) || strings.Join(msgs, "|") != strings.Join(tt.msgs, "|") {
+				t.Errorf("ParseAllLines() => expected lines %v with messages %q, got: %v with %q", tt.lines, tt.msgs,
+					lines, msgs)
+			}
+			for i := range lines {
+				if i < len(tt.lines) && lines[i] != tt.lines[i] {
+					t.Errorf("ParseAllLines() => expected line numbers: %v, got: %v", tt.lines, lines)
+				}
+			}
+			if errs != tt.errs {
+				t.Errorf("ParseAllLines() => expected %d panic errors, got: %d", tt.errs, errs)
+			}
+		})
+	}
+}
+
+// TestParseLines_stop tests that ParseLines stops at the first error of the LineFunc
+func TestParseLines_stop(t *testing.T) {
+	errStop := errors.New("stop")
+	n := 0
+	err := ParseLines(singleLineParser{}, strings.NewReader("first\nsecond\nthird\n"), func(int, *LogMsg, error) error {
+		n++
+		if n == 2 {
+			return errStop
+		}
+		return nil
+	})
+	if !errors.Is(err, errStop) || n != 2 {
+		t.Errorf("ParseLines() => expected to stop after 2 lines with %s, got: %d lines with %v", errStop, n, err)
+	}
+}
@@ -0,0 +1,45 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package listener
+
+import (
+	"fmt"
+
+	"github.com/wneessen/go-parsesyslog"
+)
+
+// AuthFunc authenticates the sender of a connection or a datagram before any message
+// of it is parsed. The SourceInfo provides the address of the sender and, for TLS
+// connections, the peer certificates. frame is the raw first frame of a stream
+// connection or the raw datagram, so that the function can check a shared token.
+//
+// A non-nil error rejects the sender: stream connections are closed and datagrams are
+// dropped. If the returned bool is true, the frame has been consumed by the function
+// (i. e. because it only contains the token) and is not parsed as log message.
+type AuthFunc func(si SourceInfo, frame []byte) (bool, error)
+
+// WithAuthFunc sets the AuthFunc that authenticates every stream connection (once,
+// with its first frame) and every datagram. For RELP sessions, the function is called
+// once before the session is served, with a nil frame, so only the SourceInfo can be
+// checked. Rejections are reported to the error handler as
+// parsesyslog.ErrSenderRejected.
+func WithAuthFunc(fn AuthFunc) Option {
+	return func(s *Server) {
+		s.auth = fn
+	}
+}
+
+// authenticate calls the AuthFunc, if one is configured. It returns true if the frame
+// has been consumed.
+func (s *Server) authenticate(si SourceInfo, frame []byte) (bool, error) {
+	if s.auth == nil {
+		return false, nil
+	}
+	consumed, err := s.auth(si, frame)
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", parsesyslog.ErrSenderRejected, err)
+	}
+	return consumed, nil
+}
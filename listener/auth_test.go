@@ -0,0 +1,142 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package listener
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/wneessen/go-parsesyslog"
+	"github.com/wneessen/go-parsesyslog/rfc5424"
+)
+
+// tokenAuth is an AuthFunc that expects the given token as first frame
+func tokenAuth(token string) AuthFunc {
+	return func(_ SourceInfo, frame []byte) (bool, error) {
+		if string(frame) != "token "+token {
+			return false, errors.New("invalid token")
+		}
+		return true, nil
+	}
+}
+
+// TestServer_WithAuthFunc tests a Server that authenticates connections with a token
+// in the first frame
+func TestServer_WithAuthFunc(t *testing.T) {
+	tests := []struct {
+		name    string
+		token   string
+		wantMsg bool
+	}{
+		{"valid token", "secret", true},
+		{"invalid token", "wrong", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newCollector()
+			errCh := make(chan error, 10)
+			s := New(rfc5424.Type, c, WithAuthFunc(tokenAuth("secret")),
+				WithErrorHandler(func(err error, _ SourceInfo) { errCh <- err }))
+			addr := serve(t, s)
+
+			conn, err := net.Dial("tcp", addr.String())
+			if err != nil {
+				t.Fatalf("failed to connect: %s", err)
+			}
+			defer func() { _ = conn.Close() }()
+			_, err = conn.Write([]byte("token " + tt.token + "\n" +
+				"<165>1 2003-10-11T22:14:15.003Z mymachine - - - - message\n"))
+			if err != nil {
+				t.Fatalf("failed to write: %s", err)
+			}
+			if tt.wantMsg {
+				c.wait(t, 1)
+				c.mu.Lock()
+				if len(c.msgs) != 1 || c.msgs[0] != "message" {
+					t.Errorf("WithAuthFunc() wrong messages => expected: %s, got: %v", "message", c.msgs)
+				}
+				c.mu.Unlock()
+				return
+			}
+
+			select {
+			case err := <-errCh:
+				if !errors.Is(err, parsesyslog.ErrSenderRejected) {
+					t.Errorf("WithAuthFunc() => expected: %s, got: %s", parsesyslog.ErrSenderRejected, err)
+				}
+			case <-time.After(time.Second * 5):
+				t.Fatal("WithAuthFunc() rejection was not reported")
+			}
+			_ = conn.SetReadDeadline(time.Now().Add(time.Second * 5))
+			if _, err := conn.Read(make([]byte, 1)); err == nil {
+				t.Errorf("WithAuthFunc() expected connection to be closed")
+			}
+			c.mu.Lock()
+			if len(c.msgs) != 0 {
+				t.Errorf("WithAuthFunc() rejected connection delivered messages: %v", c.msgs)
+			}
+			c.mu.Unlock()
+		})
+	}
+}
+
+// TestServer_WithAuthFunc_packet tests a Server that authenticates datagrams by the
+// address and the content of the datagram
+func TestServer_WithAuthFunc_packet(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+	var mu sync.Mutex
+	var errs []error
+	c := newCollector()
+	s := New(rfc5424.Type, c, WithAuthFunc(func(si SourceInfo, frame []byte) (bool, error) {
+		ua, ok := si.RemoteAddr.(*net.UDPAddr)
+		if !ok || !ua.IP.IsLoopback() {
+			return false, errors.New("not a local sender")
+		}
+		if string(frame) == "reject" {
+			return false, errors.New("rejected datagram")
+		}
+		return false, nil
+	}), WithErrorHandler(func(err error, _ SourceInfo) {
+		mu.Lock()
+		errs = append(errs, err)
+		mu.Unlock()
+	}))
+	done := make(chan error, 1)
+	go func() { done <- s.ServePacket(pc) }()
+	defer func() {
+		_ = s.Close()
+		if err := <-done; !errors.Is(err, parsesyslog.ErrServerClosed) {
+			t.Errorf("ServePacket() after Close() => expected: %s, got: %s", parsesyslog.ErrServerClosed, err)
+		}
+	}()
+
+	conn, err := net.Dial("udp", pc.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("failed to connect: %s", err)
+	}
+	defer func() { _ = conn.Close() }()
+	for _, d := range []string{"reject", "<165>1 2003-10-11T22:14:15.003Z mymachine - - - - accepted"} {
+		if _, err := conn.Write([]byte(d)); err != nil {
+			t.Fatalf("failed to write: %s", err)
+		}
+	}
+	c.wait(t, 1)
+	c.mu.Lock()
+	if c.msgs[0] != "accepted" {
+		t.Errorf("WithAuthFunc() wrong message => expected: %s, got: %s", "accepted", c.msgs[0])
+	}
+	c.mu.Unlock()
+	mu.Lock()
+	if len(errs) != 1 || !errors.Is(errs[0], parsesyslog.ErrSenderRejected) {
+		t.Errorf("WithAuthFunc() => expected one rejection, got: %v", errs)
+	}
+	mu.Unlock()
+}
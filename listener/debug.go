@@ -0,0 +1,115 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package listener
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wneessen/go-parsesyslog"
+)
+
+// debugResponse represents the JSON document served by the DebugHandler
+type debugResponse struct {
+	Messages []parsesyslog.LogMsg `json:"messages,omitempty"`
+	Stats    *StatsSnapshot       `json:"stats,omitempty"`
+}
+
+// DebugHandler returns a http.Handler that serves the current state of the given Stats
+// (i. e. counters, per-source counters and recent errors) and the messages of the given
+// Ring as JSON document, so that a running collector can be inspected without extra
+// tooling. Either of them can be nil, in which case it is left out of the document.
+//
+// The messages of the Ring can be filtered with the query parameters "from" and "to"
+// (RFC3339 timestamps), "host", "severity" (comma-separated list of severity numbers or
+// names, i. e. "0,1,err") and "limit". Invalid query parameters are answered with status
+// 400.
+//
+// Since the document contains hostnames, addresses and messages of the senders, the
+// handler should only be exposed on an internal or protected address.
+func DebugHandler(st *Stats, r *parsesyslog.Ring) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		var dr debugResponse
+		if r != nil {
+			q, err := ringQuery(req)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			dr.Messages = r.Query(q)
+		}
+		if st != nil {
+			ss := st.Snapshot()
+			dr.Stats = &ss
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(dr)
+	})
+}
+
+// ringQuery returns the parsesyslog.RingQuery of the query parameters of the given
+// http.Request
+func ringQuery(req *http.Request) (parsesyslog.RingQuery, error) {
+	var q parsesyslog.RingQuery
+	var err error
+	v := req.URL.Query()
+	if s := v.Get("from"); s != "" {
+		if q.From, err = time.Parse(time.RFC3339, s); err != nil {
+			return q, fmt.Errorf("invalid from parameter: %w", err)
+		}
+	}
+	if s := v.Get("to"); s != "" {
+		if q.To, err = time.Parse(time.RFC3339, s); err != nil {
+			return q, fmt.Errorf("invalid to parameter: %w", err)
+		}
+	}
+	if s := v.Get("limit"); s != "" {
+		if q.Limit, err = strconv.Atoi(s); err != nil || q.Limit < 0 {
+			return q, fmt.Errorf("invalid limit parameter: %q", s)
+		}
+	}
+	q.Host = v.Get("host")
+	if s := v.Get("severity"); s != "" {
+		for _, f := range strings.Split(s, ",") {
+			sev, ok := parseSeverity(strings.TrimSpace(f))
+			if !ok {
+				return q, fmt.Errorf("invalid severity parameter: %q", f)
+			}
+			q.Severities = append(q.Severities, sev)
+		}
+	}
+	return q, nil
+}
+
+// parseSeverity returns the Severity of the given number or name (i. e. "3", "ERROR" or
+// "err"). Names are matched case-insensitive, also by their prefix of at least three
+// characters.
+func parseSeverity(s string) (parsesyslog.Severity, bool) {
+	if n, err := strconv.Atoi(s); err == nil {
+		return parsesyslog.Severity(n), n >= 0 && n <= 7
+	}
+	if len(s) < 3 {
+		return 0, false
+	}
+	for i := 0; i <= 7; i++ {
+		sev := parsesyslog.Severity(i)
+		if strings.HasPrefix(sev.String(), strings.ToUpper(s)) {
+			return sev, true
+		}
+	}
+	return 0, false
+}
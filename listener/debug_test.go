@@ -0,0 +1,137 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package listener
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/wneessen/go-parsesyslog"
+	"github.com/wneessen/go-parsesyslog/rfc5424"
+)
+
+// TestServer_withStats tests that the Server accounts connections, messages and errors
+// in the Stats
+func TestServer_withStats(t *testing.T) {
+	st := NewStats()
+	c := newCollector()
+	errCh := make(chan error, 10)
+	s := New(rfc5424.Type, c, WithStats(st), WithErrorHandler(func(err error, _ SourceInfo) { errCh <- err }))
+	addr := serve(t, s)
+
+	conn, err := net.Dial("tcp", addr.String())
+	if err != nil {
+		t.Fatalf("failed to connect: %s", err)
+	}
+	defer func() { _ = conn.Close() }()
+	_, err = conn.Write([]byte("<165>1 2003-10-11T22:14:15.003Z host - - - - first message\n" +
+		"<165>1 yesterday host - - - - invalid timestamp\n"))
+	if err != nil {
+		t.Fatalf("failed to write: %s", err)
+	}
+	c.wait(t, 1)
+	select {
+	case <-errCh:
+	case <-time.After(time.Second * 5):
+		t.Fatal("Serve() parse error was not reported")
+	}
+
+	ss := st.Snapshot()
+	if ss.ActiveConns != 1 || ss.Messages != 1 || ss.Errors != 1 {
+		t.Errorf("Snapshot() => expected 1 connection, message and error, got: %d/%d/%d", ss.ActiveConns,
+			ss.Messages, ss.Errors)
+	}
+	src, ok := ss.Sources["127.0.0.1"]
+	if !ok || src.Messages != 1 || src.Errors != 1 || len(src.ErrorClasses) != 1 {
+		t.Errorf("Snapshot() => unexpected sources: %+v", ss.Sources)
+	}
+	if len(src.Histogram) != 1 || src.Histogram[0].Facility[20] != 1 || src.Histogram[0].Severity[5] != 1 {
+		t.Errorf("Snapshot() => unexpected histogram: %+v", src.Histogram)
+	}
+	if len(ss.RecentErrors) != 1 || ss.RecentErrors[0].Source != "127.0.0.1" {
+		t.Errorf("Snapshot() => unexpected recent errors: %+v", ss.RecentErrors)
+	}
+}
+
+// TestStats_recentErrors tests that the Stats retains the most recent errors only
+func TestStats_recentErrors(t *testing.T) {
+	st := NewStats()
+	for i := 0; i < DefaultRecentErrors+10; i++ {
+		st.addError(parsesyslog.ErrWrongFormat, SourceInfo{Network: "unixgram", Tenant: string(rune('a' + i%26))})
+	}
+	ss := st.Snapshot()
+	if len(ss.RecentErrors) != DefaultRecentErrors || ss.Errors != DefaultRecentErrors+10 {
+		t.Fatalf("Snapshot() => expected %d recent errors, got: %d", DefaultRecentErrors, len(ss.RecentErrors))
+	}
+	// The oldest retained error is the 11th one
+	if ss.RecentErrors[0].Tenant != "k" || ss.RecentErrors[len(ss.RecentErrors)-1].Tenant != "f" {
+		t.Errorf("Snapshot() => unexpected order of recent errors: %s ... %s", ss.RecentErrors[0].Tenant,
+			ss.RecentErrors[len(ss.RecentErrors)-1].Tenant)
+	}
+	if ss.Sources["unixgram"].ErrorClasses[parsesyslog.ErrWrongFormat.Error()] != DefaultRecentErrors+10 {
+		t.Errorf("Snapshot() => unexpected sources: %+v", ss.Sources)
+	}
+}
+
+// TestDebugHandler tests the DebugHandler
+func TestDebugHandler(t *testing.T) {
+	st, r := NewStats(), parsesyslog.NewRing(10)
+	st.addMessages(make([]parsesyslog.LogMsg, 2), SourceInfo{RemoteAddr: &net.UDPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 514}})
+	for i, sev := range []parsesyslog.Severity{3, 6} {
+		var lm parsesyslog.LogMsg
+		lm.Hostname = "host"
+		lm.Severity = sev
+		lm.Timestamp = time.Date(2023, 1, 10, 12, i, 0, 0, time.UTC)
+		r.Add(lm)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		query  string
+		status int
+		msgs   int
+	}{
+		{"all", http.MethodGet, "", http.StatusOK, 2},
+		{"severity number", http.MethodGet, "?severity=3", http.StatusOK, 1},
+		{"severity names", http.MethodGet, "?severity=err,info", http.StatusOK, 2},
+		{"time range", http.MethodGet, "?from=2023-01-10T12:01:00Z&to=2023-01-10T13:00:00Z", http.StatusOK, 1},
+		{"host and limit", http.MethodGet, "?host=HOST&limit=1", http.StatusOK, 1},
+		{"invalid severity", http.MethodGet, "?severity=8", http.StatusBadRequest, 0},
+		{"invalid from", http.MethodGet, "?from=yesterday", http.StatusBadRequest, 0},
+		{"invalid limit", http.MethodGet, "?limit=-1", http.StatusBadRequest, 0},
+		{"invalid method", http.MethodPost, "", http.StatusMethodNotAllowed, 0},
+	}
+	h := DebugHandler(st, r)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(tt.method, "/debug/syslog"+tt.query, nil))
+			if rec.Code != tt.status {
+				t.Fatalf("DebugHandler() status => expected: %d, got: %d", tt.status, rec.Code)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			var dr struct {
+				Messages []parsesyslog.LogMsg `json:"messages"`
+				Stats    StatsSnapshot        `json:"stats"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &dr); err != nil {
+				t.Fatalf("DebugHandler() returned invalid JSON: %s", err)
+			}
+			if len(dr.Messages) != tt.msgs {
+				t.Errorf("DebugHandler() => expected %d messages, got: %d", tt.msgs, len(dr.Messages))
+			}
+			if dr.Stats.Messages != 2 || dr.Stats.Sources["192.0.2.1"].Messages != 2 {
+				t.Errorf("DebugHandler() => unexpected stats: %+v", dr.Stats)
+			}
+		})
+	}
+}
@@ -0,0 +1,513 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+// Package listener implements network receivers that accept syslog messages, parse
+// them with one of the registered go-parsesyslog parsers and dispatch the resulting
+// LogMsg to a Handler
+package listener
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/wneessen/go-parsesyslog"
+	"github.com/wneessen/go-parsesyslog/rfc6587"
+)
+
+// Handler handles the log messages received by a listener
+type Handler interface {
+	Handle(parsesyslog.LogMsg, SourceInfo)
+}
+
+// HandlerFunc is an adapter to use an ordinary function as Handler
+type HandlerFunc func(parsesyslog.LogMsg, SourceInfo)
+
+// Handle calls f(lm, si). It satisfies the Handler interface
+func (f HandlerFunc) Handle(lm parsesyslog.LogMsg, si SourceInfo) {
+	f(lm, si)
+}
+
+// MultiHandler returns a Handler that hands every log message to all of the given
+// Handlers, in the given order
+func MultiHandler(hs ...Handler) Handler {
+	return HandlerFunc(func(lm parsesyslog.LogMsg, si SourceInfo) {
+		for _, h := range hs {
+			h.Handle(lm, si)
+		}
+	})
+}
+
+// SourceInfo describes the origin of a received log message
+type SourceInfo struct {
+	// LocalAddr is the address the message was received on. For proxied connections,
+	// it is the destination address the proxy received the connection on.
+	LocalAddr net.Addr
+	// Network is the network the message was received from (i. e. "tcp")
+	Network string
+	// Proxy is the address of the proxy that forwarded the connection via the PROXY
+	// protocol. It is nil for connections that have not been proxied.
+	Proxy net.Addr
+	// RemoteAddr is the address of the sender. For proxied connections, it is the
+	// address of the original client as announced by the proxy.
+	RemoteAddr net.Addr
+	// Tenant is the tenant the sender belongs to, as determined by the TenantFunc. It
+	// is empty if no TenantFunc is configured.
+	Tenant string
+	// TLS holds the state of the TLS connection the message was received on. It is nil
+	// for messages that were not received via TLS.
+	TLS *tls.ConnectionState
+}
+
+// DefaultTLSHandshakeTimeout is the maximum duration of a TLS handshake, if no read
+// timeout is configured
+const DefaultTLSHandshakeTimeout = time.Second * 10
+
+// DefaultProxyHeaderTimeout is the maximum duration the Server waits for the PROXY
+// protocol header of a connection, if no read timeout is configured
+const DefaultProxyHeaderTimeout = time.Second * 10
+
+// Server is a listener for syslog messages. Every connection accepted by a stream
+// listener (i. e. TCP) is served in its own goroutine, split into frames as described
+// in RFC6587 and every frame is parsed with a Parser of the configured ParserType.
+// Datagram listeners (i. e. unix datagram sockets) parse every datagram as a single
+// message. A Server can serve multiple listeners at once.
+type Server struct {
+	addr    net.Addr
+	allow   *parsesyslog.Allowlist
+	auth    AuthFunc
+	closed  bool
+	conns   map[io.Closer]struct{}
+	errFn   func(error, SourceInfo)
+	fopts   []rfc6587.FramerOption
+	handler Handler
+	lns     map[io.Closer]struct{}
+	mu      sync.Mutex
+	policy  *parsesyslog.PriorityPolicy
+	popts   []parsesyslog.Option
+	proxy   bool
+	pt      parsesyslog.ParserType
+	stats   *Stats
+	tenant  TenantFunc
+	timeout time.Duration
+	wg      sync.WaitGroup
+}
+
+// Option is a function that configures a Server
+type Option func(*Server)
+
+// WithAllowlist makes the Server drop the frames and datagrams of senders that are not
+// allowed by the given Allowlist before they are parsed, so that collectors which only
+// care about a subset of the senders on a shared feed do not spend time on the others.
+// Dropped frames are neither handed to the Handler nor reported as errors. The Allowlist
+// does not apply to RELP sessions, as their messages are parsed by the RELP session.
+func WithAllowlist(a *parsesyslog.Allowlist) Option {
+	return func(s *Server) {
+		s.allow = a
+	}
+}
+
+// WithErrorHandler sets a function that is called for every frame that could not be
+// framed or parsed and for every error that causes a connection to be closed. Errors
+// that are caused by the remote side closing the connection are not reported. Panics of
+// the Parser are reported as *parsesyslog.PanicError, which holds the frame that caused
+// the panic, so that it can be quarantined.
+func WithErrorHandler(fn func(error, SourceInfo)) Option {
+	return func(s *Server) {
+		s.errFn = fn
+	}
+}
+
+// WithFramerOptions sets the rfc6587.FramerOption functions that are used to
+// configure the Framer of every connection
+func WithFramerOptions(opts ...rfc6587.FramerOption) Option {
+	return func(s *Server) {
+		s.fopts = append(s.fopts, opts...)
+	}
+}
+
+// WithParserOptions sets the parsesyslog.Option functions that are used to create
+// the Parser of every connection
+func WithParserOptions(opts ...parsesyslog.Option) Option {
+	return func(s *Server) {
+		s.popts = append(s.popts, opts...)
+	}
+}
+
+// WithPriorityPolicy makes the Server override the facility and/or severity of every
+// received log message according to the given PriorityPolicy, based on the source
+// address of the sender (for proxied connections, the address of the original client),
+// before the message is handed to the Handler.
+func WithPriorityPolicy(p *parsesyslog.PriorityPolicy) Option {
+	return func(s *Server) {
+		s.policy = p
+	}
+}
+
+// WithProxyProtocol makes the Server expect a PROXY protocol header (version 1 or 2) at
+// the start of every accepted stream connection, as it is sent by load balancers like
+// HAProxy or AWS NLB. The addresses announced in the header are handed to the Handler
+// as the RemoteAddr and LocalAddr of the SourceInfo, the address of the proxy as Proxy.
+// Connections that do not start with a valid header are closed.
+//
+// Since the header is trusted blindly, only enable this if all connections to the
+// listener are made by the proxy. For TLS connections, the header precedes the TLS
+// handshake, so ServeTLS needs to be used instead of wrapping the net.Listener.
+// See: https://www.haproxy.org/download/2.8/doc/proxy-protocol.txt
+func WithProxyProtocol() Option {
+	return func(s *Server) {
+		s.proxy = true
+	}
+}
+
+// WithReadTimeout sets the maximum duration the Server waits for the next frame on a
+// connection. Connections that stay idle for longer than that are closed. A frame
+// that has been started is subject to this timeout as well, unless a frame timeout
+// has been configured via WithFramerOptions. By default, there is no read timeout.
+func WithReadTimeout(d time.Duration) Option {
+	return func(s *Server) {
+		s.timeout = d
+	}
+}
+
+// New returns a new Server that parses the received messages with a Parser of the
+// given ParserType and hands them to the given Handler. The Handler is called from
+// the goroutine of the connection a message was received on, so it needs to be safe
+// for concurrent use if multiple connections are served.
+func New(t parsesyslog.ParserType, h Handler, opts ...Option) *Server {
+	s := &Server{
+		conns:   make(map[io.Closer]struct{}),
+		handler: h,
+		lns:     make(map[io.Closer]struct{}),
+		pt:      t,
+	}
+	for _, o := range opts {
+		if o == nil {
+			continue
+		}
+		o(s)
+	}
+	return s
+}
+
+// ListenAndServe listens on the given TCP address and serves the accepted connections.
+// It always returns a non-nil error. After Close, the returned error is
+// parsesyslog.ErrServerClosed.
+func (s *Server) ListenAndServe(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ln)
+}
+
+// ListenAndServeTLS listens on the given TCP address and serves the accepted
+// connections via TLS as described in RFC5425. See ServeTLS for details.
+func (s *Server) ListenAndServeTLS(addr string, cfg *tls.Config) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.ServeTLS(ln, cfg)
+}
+
+// ServeTLS works like Serve, but performs a TLS handshake on every accepted connection
+// as described in RFC5425 (after the PROXY protocol header, if enabled). The given
+// tls.Config needs to provide at least one certificate. All fields that are not set are
+// filled with the syslog TLS defaults of parsesyslog.DefaultTLSConfig (i. e. TLS 1.2 as
+// minimum version and ALPN "syslog"). For mutual TLS, set ClientAuth to
+// tls.RequireAndVerifyClientCert and ClientCAs to the pool of accepted client CAs. The
+// state of the TLS connection is available to the Handler via SourceInfo.TLS.
+//
+// RFC5425 mandates octet-counting framing, so ServeTLS uses rfc6587.FramingOctetCounting
+// unless a different framing is configured via WithFramerOptions.
+// See: https://datatracker.ietf.org/doc/html/rfc5425#section-4.3
+func (s *Server) ServeTLS(ln net.Listener, cfg *tls.Config) error {
+	if cfg == nil || (len(cfg.Certificates) == 0 && cfg.GetCertificate == nil &&
+		cfg.GetConfigForClient == nil) {
+		return parsesyslog.ErrNoCertificate
+	}
+	cfg = parsesyslog.DefaultTLSConfig(cfg)
+	return s.accept(ln, func(c net.Conn, si SourceInfo) {
+		tc := tls.Server(c, cfg)
+		defer func() { _ = tc.Close() }()
+		if err := s.handshake(tc, &si); err != nil {
+			s.reportError(err, si)
+			return
+		}
+		s.serveConn(tc, si)
+	})
+}
+
+// Serve accepts connections on the given net.Listener and serves each of them in its
+// own goroutine. It always returns a non-nil error. After Close, the returned error is
+// parsesyslog.ErrServerClosed.
+func (s *Server) Serve(ln net.Listener) error {
+	return s.accept(ln, func(c net.Conn, si SourceInfo) { s.serveConn(c, si) })
+}
+
+// accept accepts connections on the given net.Listener and serves each of them with
+// the given function in its own goroutine, once its SourceInfo has been determined
+func (s *Server) accept(ln net.Listener, serve func(net.Conn, SourceInfo)) error {
+	if err := s.start(ln, ln.Addr()); err != nil {
+		return err
+	}
+	defer s.stop(ln)
+
+	for {
+		c, err := ln.Accept()
+		if err != nil {
+			if s.isClosed() {
+				return parsesyslog.ErrServerClosed
+			}
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				continue
+			}
+			return err
+		}
+		if !s.track(c) {
+			_ = c.Close()
+			return parsesyslog.ErrServerClosed
+		}
+		go func() {
+			defer s.wg.Done()
+			defer s.untrack(c)
+			if s.stats != nil {
+				s.stats.addConn(1)
+				defer s.stats.addConn(-1)
+			}
+			si, err := s.sourceInfo(c)
+			if err != nil {
+				s.reportError(err, si)
+				return
+			}
+			serve(c, si)
+		}()
+	}
+}
+
+// Addr returns the address the Server has most recently started listening on, or nil
+// if it has not been started
+func (s *Server) Addr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addr
+}
+
+// Close stops accepting new connections and messages on all listeners of the Server,
+// closes all active connections and waits until all connection goroutines have
+// returned
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	var err error
+	for l := range s.lns {
+		if cerr := l.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	for c := range s.conns {
+		_ = c.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	return err
+}
+
+// serveConn reads, parses and dispatches the log messages of a single connection. The
+// read timeout is applied if the connection supports read deadlines.
+func (s *Server) serveConn(c io.Reader, si SourceInfo) {
+	if err := s.resolveTenant(&si); err != nil {
+		s.reportError(err, si)
+		return
+	}
+	fopts := s.fopts
+	switch {
+	case si.TLS != nil:
+		fopts = append([]rfc6587.FramerOption{rfc6587.WithFraming(rfc6587.FramingOctetCounting)}, fopts...)
+	case si.Network == NetworkSerial:
+		fopts = append([]rfc6587.FramerOption{rfc6587.WithFraming(rfc6587.FramingNonTransparent)}, fopts...)
+	}
+
+	p, err := parsesyslog.New(s.pt, s.popts...)
+	if err != nil {
+		s.reportError(err, si)
+		return
+	}
+	fr := rfc6587.NewFramer(c, fopts...)
+	defer func() { _ = fr.Close() }()
+	var pr bytes.Reader
+	br := bufio.NewReader(&pr)
+	authenticated := false
+	dl, _ := c.(readDeadliner)
+	for {
+		if s.timeout > 0 && dl != nil {
+			if err := dl.SetReadDeadline(time.Now().Add(s.timeout)); err != nil {
+				if !errors.Is(err, os.ErrNoDeadline) {
+					s.reportError(err, si)
+					return
+				}
+				dl = nil
+			}
+		}
+		f, err := fr.Next()
+		if err == nil && !authenticated {
+			authenticated = true
+			var consumed bool
+			if consumed, err = s.authenticate(si, f); err != nil {
+				s.reportError(err, si)
+				return
+			}
+			if consumed {
+				continue
+			}
+		}
+		if err == nil && s.allow != nil && !s.allow.Match(f) {
+			continue
+		}
+		var lm parsesyslog.LogMsg
+		if err == nil {
+			pr.Reset(f)
+			br.Reset(&pr)
+			if lm, err = parseFrame(p, br, f); errors.Is(err, parsesyslog.ErrParserPanic) {
+				// The state of a Parser that panicked is unknown
+				p, _ = parsesyslog.New(s.pt, s.popts...)
+			}
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) || s.isClosed() {
+				return
+			}
+			s.reportError(err, si)
+			// Framing and parse errors only affect a single frame, everything else
+			// (i. e. I/O errors or an exceeded read timeout) ends the connection
+			if errors.Is(parsesyslog.ClassifyError(err), parsesyslog.ErrUnclassified) {
+				return
+			}
+			continue
+		}
+		if err := s.deliver([]parsesyslog.LogMsg{lm}, si); err != nil {
+			s.reportError(err, si)
+			return
+		}
+	}
+}
+
+// parseFrame parses the given frame from the given bufio.Reader with the given Parser.
+// A panic of the Parser is recovered and returned as *parsesyslog.PanicError, so that a
+// Parser bug on exotic input only affects a single frame and not the whole Server.
+func parseFrame(p parsesyslog.Parser, br *bufio.Reader, f []byte) (lm parsesyslog.LogMsg, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = parsesyslog.NewPanicError(r, f)
+		}
+	}()
+	return p.ParseReader(br)
+}
+
+// sourceInfo returns the SourceInfo of the given connection. If the PROXY protocol is
+// enabled, the header is read first. For TLS connections (i. e. of a net.Listener that
+// has been wrapped by tls.NewListener), the TLS handshake is performed, so that the TLS
+// state is available.
+func (s *Server) sourceInfo(c net.Conn) (SourceInfo, error) {
+	si := SourceInfo{LocalAddr: c.LocalAddr(), Network: c.LocalAddr().Network(), RemoteAddr: c.RemoteAddr()}
+	if s.proxy {
+		if err := s.readProxyHeader(c, &si); err != nil {
+			return si, err
+		}
+	}
+	if tc, ok := c.(*tls.Conn); ok {
+		if err := s.handshake(tc, &si); err != nil {
+			return si, err
+		}
+	}
+	return si, nil
+}
+
+// handshake performs the TLS handshake on the given connection, limited by the read
+// timeout or DefaultTLSHandshakeTimeout, and stores the TLS state in the SourceInfo
+func (s *Server) handshake(c *tls.Conn, si *SourceInfo) error {
+	to := s.timeout
+	if to <= 0 {
+		to = DefaultTLSHandshakeTimeout
+	}
+	if err := c.SetDeadline(time.Now().Add(to)); err != nil {
+		return err
+	}
+	if err := c.Handshake(); err != nil {
+		return err
+	}
+	cs := c.ConnectionState()
+	si.TLS = &cs
+	return c.SetDeadline(time.Time{})
+}
+
+// reportError accounts the given error in the Stats and hands it to the error handler,
+// if they are configured
+func (s *Server) reportError(err error, si SourceInfo) {
+	if s.stats != nil {
+		s.stats.addError(err, si)
+	}
+	if s.errFn != nil {
+		s.errFn(err, si)
+	}
+}
+
+// start validates the ParserType and registers the given listener, so that it is closed
+// by Close. It returns parsesyslog.ErrServerClosed if the Server has been closed already
+func (s *Server) start(l io.Closer, addr net.Addr) error {
+	if _, err := parsesyslog.New(s.pt, s.popts...); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return parsesyslog.ErrServerClosed
+	}
+	s.lns[l] = struct{}{}
+	s.addr = addr
+	return nil
+}
+
+// stop removes the given listener from the registered listeners
+func (s *Server) stop(l io.Closer) {
+	s.mu.Lock()
+	delete(s.lns, l)
+	s.mu.Unlock()
+}
+
+// track adds the given connection to the active connections. It returns false if the
+// Server has been closed already
+func (s *Server) track(c io.Closer) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	s.conns[c] = struct{}{}
+	s.wg.Add(1)
+	return true
+}
+
+// untrack closes the given connection and removes it from the active connections
+func (s *Server) untrack(c io.Closer) {
+	_ = c.Close()
+	s.mu.Lock()
+	delete(s.conns, c)
+	s.mu.Unlock()
+}
+
+// isClosed returns true if the Server has been closed
+func (s *Server) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
@@ -0,0 +1,428 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package listener
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/wneessen/go-parsesyslog"
+	"github.com/wneessen/go-parsesyslog/rfc5424"
+)
+
+// collector is a Handler that collects the received messages
+type collector struct {
+	mu   sync.Mutex
+	msgs []string
+	srcs []SourceInfo
+	ch   chan struct{}
+}
+
+func newCollector() *collector {
+	return &collector{ch: make(chan struct{}, 100)}
+}
+
+func (c *collector) Handle(lm parsesyslog.LogMsg, si SourceInfo) {
+	c.mu.Lock()
+	c.msgs = append(c.msgs, lm.Message.String())
+	c.srcs = append(c.srcs, si)
+	c.mu.Unlock()
+	c.ch <- struct{}{}
+}
+
+// wait waits until n messages have been collected
+func (c *collector) wait(t *testing.T, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		select {
+		case <-c.ch:
+		case <-time.After(time.Second * 5):
+			t.Fatalf("timeout waiting for message %d", i+1)
+		}
+	}
+}
+
+// serve starts the given Server on a random local port
+func serve(t *testing.T, s *Server) net.Addr {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- s.Serve(ln) }()
+	t.Cleanup(func() {
+		if err := s.Close(); err != nil {
+			t.Errorf("Close() failed: %s", err)
+		}
+		if err := <-done; !errors.Is(err, parsesyslog.ErrServerClosed) {
+			t.Errorf("Serve() after Close() => expected: %s, got: %s", parsesyslog.ErrServerClosed, err)
+		}
+	})
+	return ln.Addr()
+}
+
+// TestServer_Serve tests the Serve method of the Server
+func TestServer_Serve(t *testing.T) {
+	c := newCollector()
+	var errs []error
+	var emu sync.Mutex
+	s := New(rfc5424.Type, c, WithErrorHandler(func(err error, _ SourceInfo) {
+		emu.Lock()
+		errs = append(errs, err)
+		emu.Unlock()
+	}))
+	addr := serve(t, s)
+
+	conn, err := net.Dial("tcp", addr.String())
+	if err != nil {
+		t.Fatalf("failed to connect: %s", err)
+	}
+	defer func() { _ = conn.Close() }()
+	_, err = conn.Write([]byte("57 <165>1 2003-10-11T22:14:15.003Z mymachine - - - - message\n" +
+		"<165>1 2003-10-11T22:14:15.003Z mymachine - - - - second\n" +
+		"<165>foo\n" +
+		"<165>1 2003-10-11T22:14:15.003Z mymachine - - - - third\n"))
+	if err != nil {
+		t.Fatalf("failed to write: %s", err)
+	}
+	c.wait(t, 3)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	want := []string{"message", "second", "third"}
+	for i, w := range want {
+		if c.msgs[i] != w {
+			t.Errorf("Serve() message %d => expected: %s, got: %s", i, w, c.msgs[i])
+		}
+	}
+	if c.srcs[0].Network != "tcp" || c.srcs[0].RemoteAddr.String() != conn.LocalAddr().String() {
+		t.Errorf("Serve() wrong source info: %+v", c.srcs[0])
+	}
+	emu.Lock()
+	defer emu.Unlock()
+	if len(errs) != 1 {
+		t.Errorf("Serve() error count => expected: %d, got: %d (%v)", 1, len(errs), errs)
+	}
+	if s.Addr().String() != addr.String() {
+		t.Errorf("Addr() => expected: %s, got: %s", addr, s.Addr())
+	}
+}
+
+// TestServer_readTimeout tests that idle connections are closed after the read timeout
+func TestServer_readTimeout(t *testing.T) {
+	s := New(rfc5424.Type, newCollector(), WithReadTimeout(time.Millisecond*50))
+	addr := serve(t, s)
+	conn, err := net.Dial("tcp", addr.String())
+	if err != nil {
+		t.Fatalf("failed to connect: %s", err)
+	}
+	defer func() { _ = conn.Close() }()
+	if err := conn.SetReadDeadline(time.Now().Add(time.Second * 5)); err != nil {
+		t.Fatalf("failed to set read deadline: %s", err)
+	}
+	_, err = conn.Read(make([]byte, 1))
+	if err == nil {
+		t.Errorf("idle connection expected to be closed by the server")
+	}
+	var ne net.Error
+	if errors.As(err, &ne) && ne.Timeout() {
+		t.Errorf("idle connection was not closed within the read timeout")
+	}
+}
+
+// TestServer_unknownParser tests that Serve fails for an unknown ParserType
+func TestServer_unknownParser(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+	defer func() { _ = ln.Close() }()
+	s := New("invalid", newCollector())
+	if err := s.Serve(ln); !errors.Is(err, parsesyslog.ErrParserTypeUnknown) {
+		t.Errorf("Serve() => expected: %s, got: %s", parsesyslog.ErrParserTypeUnknown, err)
+	}
+}
+
+// TestMultiHandler tests the MultiHandler method
+func TestMultiHandler(t *testing.T) {
+	var calls []string
+	h := MultiHandler(
+		HandlerFunc(func(lm parsesyslog.LogMsg, _ SourceInfo) { calls = append(calls, "a:"+lm.Hostname) }),
+		HandlerFunc(func(lm parsesyslog.LogMsg, _ SourceInfo) { calls = append(calls, "b:"+lm.Hostname) }),
+	)
+	h.Handle(parsesyslog.LogMsg{Hostname: "host"}, SourceInfo{})
+	if len(calls) != 2 || calls[0] != "a:host" || calls[1] != "b:host" {
+		t.Errorf("MultiHandler() => unexpected calls: %v", calls)
+	}
+}
+
+// testCert returns a self-signed certificate for the given common name, which is
+// valid for 127.0.0.1 and usable as server and client certificate
+func testCert(t *testing.T, cn string) (tls.Certificate, *x509.Certificate) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %s", err)
+	}
+	tpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		DNSNames:              []string{cn},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %s", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %s", err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: cert}, cert
+}
+
+// serveTLS starts the given Server as TLS server on a random local port
+func serveTLS(t *testing.T, s *Server, cfg *tls.Config) net.Addr {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- s.ServeTLS(ln, cfg) }()
+	t.Cleanup(func() {
+		_ = s.Close()
+		if err := <-done; !errors.Is(err, parsesyslog.ErrServerClosed) {
+			t.Errorf("ServeTLS() after Close() => expected: %s, got: %s", parsesyslog.ErrServerClosed, err)
+		}
+	})
+	return ln.Addr()
+}
+
+// TestServer_ServeTLS tests the ServeTLS method of the Server with mutual TLS
+func TestServer_ServeTLS(t *testing.T) {
+	srvCert, srvX509 := testCert(t, "server")
+	cliCert, cliX509 := testCert(t, "client")
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(cliX509)
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(srvX509)
+
+	c := newCollector()
+	var errs []error
+	var emu sync.Mutex
+	s := New(rfc5424.Type, c, WithErrorHandler(func(err error, _ SourceInfo) {
+		emu.Lock()
+		errs = append(errs, err)
+		emu.Unlock()
+	}))
+	addr := serveTLS(t, s, &tls.Config{
+		Certificates: []tls.Certificate{srvCert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+		MinVersion:   tls.VersionTLS12,
+	})
+
+	conn, err := tls.Dial("tcp", addr.String(), &tls.Config{
+		Certificates: []tls.Certificate{cliCert},
+		RootCAs:      rootCAs,
+		ServerName:   "server",
+		MinVersion:   tls.VersionTLS12,
+	})
+	if err != nil {
+		t.Fatalf("failed to connect: %s", err)
+	}
+	if _, err = conn.Write([]byte("57 <165>1 2003-10-11T22:14:15.003Z mymachine - - - - message")); err != nil {
+		t.Fatalf("failed to write: %s", err)
+	}
+	c.wait(t, 1)
+	_ = conn.Close()
+	c.mu.Lock()
+	if c.msgs[0] != "message" {
+		t.Errorf("ServeTLS() wrong message => expected: %s, got: %s", "message", c.msgs[0])
+	}
+	if c.srcs[0].TLS == nil || len(c.srcs[0].TLS.PeerCertificates) != 1 ||
+		c.srcs[0].TLS.PeerCertificates[0].Subject.CommonName != "client" {
+		t.Errorf("ServeTLS() client certificate not available in the source info")
+	}
+	c.mu.Unlock()
+
+	// A client without certificate must be rejected
+	conn, err = tls.Dial("tcp", addr.String(), &tls.Config{
+		RootCAs:    rootCAs,
+		ServerName: "server",
+		MinVersion: tls.VersionTLS12,
+	})
+	if err == nil {
+		_, _ = conn.Write([]byte("57 <165>1 2003-10-11T22:14:15.003Z mymachine - - - - message"))
+		_ = conn.SetReadDeadline(time.Now().Add(time.Second * 5))
+		_, err = conn.Read(make([]byte, 1))
+		_ = conn.Close()
+	}
+	if err == nil {
+		t.Errorf("ServeTLS() accepted client without certificate")
+	}
+	deadline := time.Now().Add(time.Second * 5)
+	for time.Now().Before(deadline) {
+		emu.Lock()
+		n := len(errs)
+		emu.Unlock()
+		if n > 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	emu.Lock()
+	if len(errs) == 0 {
+		t.Errorf("ServeTLS() handshake failure was not reported")
+	}
+	emu.Unlock()
+}
+
+// TestServer_ServeTLS_noCertificate tests that ServeTLS fails without certificate
+func TestServer_ServeTLS_noCertificate(t *testing.T) {
+	s := New(rfc5424.Type, newCollector())
+	if err := s.ServeTLS(nil, &tls.Config{MinVersion: tls.VersionTLS12}); !errors.Is(err, parsesyslog.ErrNoCertificate) {
+		t.Errorf("ServeTLS() => expected: %s, got: %s", parsesyslog.ErrNoCertificate, err)
+	}
+}
+
+// TestServer_WithPriorityPolicy tests that the PriorityPolicy overrides the facility
+// claimed by the sender
+func TestServer_WithPriorityPolicy(t *testing.T) {
+	p, err := parsesyslog.NewPriorityPolicy(map[string]parsesyslog.PriorityOverride{
+		"127.0.0.0/8": parsesyslog.ForceFacility(parsesyslog.FacilityFromPrio(parsesyslog.Local5)),
+	})
+	if err != nil {
+		t.Fatalf("NewPriorityPolicy() failed: %s", err)
+	}
+	ch := make(chan parsesyslog.LogMsg, 1)
+	addr := serve(t, New(rfc5424.Type, HandlerFunc(func(lm parsesyslog.LogMsg, _ SourceInfo) {
+		ch <- lm
+	}), WithPriorityPolicy(p)))
+
+	conn, err := net.Dial("tcp", addr.String())
+	if err != nil {
+		t.Fatalf("failed to connect: %s", err)
+	}
+	defer func() { _ = conn.Close() }()
+	if _, err = conn.Write([]byte("54 <34>1 2003-10-11T22:14:15.003Z mymachine - - - - message")); err != nil {
+		t.Fatalf("failed to write: %s", err)
+	}
+	select {
+	case lm := <-ch:
+		if lm.Priority != parsesyslog.Local5|parsesyslog.Crit || lm.Facility != 21 || lm.Severity != 2 {
+			t.Errorf("WithPriorityPolicy() => expected priority: %d, got: %d", parsesyslog.Local5|parsesyslog.Crit,
+				lm.Priority)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("timeout waiting for message")
+	}
+}
+
+// panicParser is a Parser that panics on log messages containing "boom" and parses all
+// other log messages as RFC5424
+type panicParser struct {
+	parsesyslog.Parser
+}
+
+// ParseReader satisfies the Parser interface for the panicParser type
+func (p panicParser) ParseReader(r io.Reader) (parsesyslog.LogMsg, error) {
+	if b, _ := r.(*bufio.Reader).Peek(1024); bytes.Contains(b, []byte("boom")) {
+		panic("boom")
+	}
+	return p.Parser.ParseReader(r)
+}
+
+// TestServer_parserPanic tests that panics of the Parser only affect a single frame
+func TestServer_parserPanic(t *testing.T) {
+	pt := parsesyslog.ParserType("listener-panic-test")
+	parsesyslog.RegisterWithOptions(pt, func(o parsesyslog.Options) (parsesyslog.Parser, error) {
+		p, err := parsesyslog.New(rfc5424.Type)
+		return panicParser{p}, err
+	})
+	c := newCollector()
+	st := NewStats()
+	errs := make(chan error, 10)
+	s := New(pt, c, WithStats(st), WithErrorHandler(func(err error, _ SourceInfo) { errs <- err }))
+	addr := serve(t, s)
+
+	conn, err := net.Dial("tcp", addr.String())
+	if err != nil {
+		t.Fatalf("failed to connect: %s", err)
+	}
+	defer func() { _ = conn.Close() }()
+	_, err = conn.Write([]byte("<165>1 2003-10-11T22:14:15.003Z mymachine - - - - boom\n" +
+		"<165>1 2003-10-11T22:14:15.003Z mymachine - - - - message\n"))
+	if err != nil {
+		t.Fatalf("failed to write: %s", err)
+	}
+	c.wait(t, 1)
+
+	var pe *parsesyslog.PanicError
+	if err := <-errs; !errors.As(err, &pe) {
+		t.Fatalf("expected PanicError to be reported, got: %v", err)
+	}
+	if string(pe.Frame) != "<165>1 2003-10-11T22:14:15.003Z mymachine - - - - boom" {
+		t.Errorf("PanicError => unexpected frame: %q", pe.Frame)
+	}
+	c.mu.Lock()
+	if c.msgs[0] != "message" {
+		t.Errorf("expected message after the panic to be handled, got: %s", c.msgs[0])
+	}
+	c.mu.Unlock()
+	for _, sc := range st.Snapshot().Sources {
+		if sc.ErrorClasses[parsesyslog.ErrParserPanic.Error()] != 1 {
+			t.Errorf("Stats => expected 1 error of class %q, got: %v", parsesyslog.ErrParserPanic,
+				sc.ErrorClasses)
+		}
+	}
+}
+
+// TestServer_allowlist tests that the Server drops the frames of senders that are not
+// allowed by the Allowlist
+func TestServer_allowlist(t *testing.T) {
+	c := newCollector()
+	a := parsesyslog.NewAllowlist([]string{"fw*"}, nil)
+	s := New(rfc5424.Type, c, WithAllowlist(a))
+	addr := serve(t, s)
+
+	conn, err := net.Dial("tcp", addr.String())
+	if err != nil {
+		t.Fatalf("failed to connect: %s", err)
+	}
+	defer func() { _ = conn.Close() }()
+	_, err = conn.Write([]byte("<165>1 2003-10-11T22:14:15.003Z web01 app - - - dropped\n" +
+		"<165>1 2003-10-11T22:14:15.003Z fw01 app - - - allowed\n"))
+	if err != nil {
+		t.Fatalf("failed to write: %s", err)
+	}
+	c.wait(t, 1)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.msgs) != 1 || c.msgs[0] != "allowed" {
+		t.Errorf("expected only the allowed message to be handled, got: %v", c.msgs)
+	}
+}
@@ -0,0 +1,82 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package listener
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"net"
+
+	"github.com/wneessen/go-parsesyslog"
+)
+
+// maxDatagramSize is the maximum size of a datagram a Server reads
+const maxDatagramSize = 64 * 1024
+
+// ServePacket reads datagrams from the given net.PacketConn and parses every datagram
+// as a single log message. Trailing LF and NUL characters of a datagram are removed.
+// If a TenantFunc or an AuthFunc is configured, it is called for every datagram.
+// Datagrams that can not be parsed are reported to the error handler. It always
+// returns a non-nil error. After Close, the returned error is
+// parsesyslog.ErrServerClosed.
+func (s *Server) ServePacket(pc net.PacketConn) error {
+	if err := s.start(pc, pc.LocalAddr()); err != nil {
+		return err
+	}
+	defer s.stop(pc)
+	p, err := parsesyslog.New(s.pt, s.popts...)
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, maxDatagramSize)
+	var pr bytes.Reader
+	br := bufio.NewReader(&pr)
+	for {
+		n, addr, err := pc.ReadFrom(buf)
+		if err != nil {
+			if s.isClosed() {
+				return parsesyslog.ErrServerClosed
+			}
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				continue
+			}
+			return err
+		}
+		si := SourceInfo{LocalAddr: pc.LocalAddr(), Network: pc.LocalAddr().Network(), RemoteAddr: addr}
+		d := bytes.TrimRight(buf[:n], "\n\x00")
+		if len(d) == 0 {
+			continue
+		}
+		if err := s.resolveTenant(&si); err != nil {
+			s.reportError(err, si)
+			continue
+		}
+		consumed, err := s.authenticate(si, d)
+		if err != nil {
+			s.reportError(err, si)
+			continue
+		}
+		if consumed || s.allow != nil && !s.allow.Match(d) {
+			continue
+		}
+		pr.Reset(d)
+		br.Reset(&pr)
+		lm, err := parseFrame(p, br, d)
+		if err != nil {
+			if errors.Is(err, parsesyslog.ErrParserPanic) {
+				// The state of a Parser that panicked is unknown
+				p, _ = parsesyslog.New(s.pt, s.popts...)
+			}
+			s.reportError(err, si)
+			continue
+		}
+		if err := s.deliver([]parsesyslog.LogMsg{lm}, si); err != nil {
+			s.reportError(err, si)
+		}
+	}
+}
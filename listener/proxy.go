@@ -0,0 +1,184 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package listener
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wneessen/go-parsesyslog"
+)
+
+// PROXY protocol header constants
+// See: https://www.haproxy.org/download/2.8/doc/proxy-protocol.txt
+const (
+	// proxyV1MaxLen is the maximum length of a version 1 header, including the CRLF
+	proxyV1MaxLen = 107
+	// proxyV2HeaderLen is the length of the fixed part of a version 2 header
+	proxyV2HeaderLen = 16
+	// proxyV2CmdLocal is the command of a version 2 header of a connection that has
+	// been established by the proxy itself (i. e. for a health check)
+	proxyV2CmdLocal = 0x0
+	// proxyV2CmdProxy is the command of a version 2 header of a proxied connection
+	proxyV2CmdProxy = 0x1
+)
+
+var (
+	// proxyV1Sig is the signature of a version 1 header
+	proxyV1Sig = []byte("PROXY ")
+	// proxyV2Sig is the signature of a version 2 header
+	proxyV2Sig = []byte("\r\n\r\n\x00\r\nQUIT\n")
+)
+
+// readProxyHeader reads the PROXY protocol header of the given connection and stores
+// the announced addresses in the SourceInfo. The header is read without buffering, so
+// that no data that follows it is consumed.
+func (s *Server) readProxyHeader(c net.Conn, si *SourceInfo) error {
+	to := s.timeout
+	if to <= 0 {
+		to = DefaultProxyHeaderTimeout
+	}
+	if err := c.SetReadDeadline(time.Now().Add(to)); err != nil {
+		return err
+	}
+
+	// The first 6 bytes are sufficient to distinguish both versions
+	sig := make([]byte, len(proxyV1Sig))
+	if _, err := io.ReadFull(c, sig); err != nil {
+		return err
+	}
+	var src, dst net.Addr
+	var err error
+	switch {
+	case bytes.Equal(sig, proxyV1Sig):
+		src, dst, err = readProxyV1(c)
+	case bytes.Equal(sig, proxyV2Sig[:len(sig)]):
+		src, dst, err = readProxyV2(c)
+	default:
+		err = fmt.Errorf("%w: missing signature", parsesyslog.ErrInvalidProxyHeader)
+	}
+	if err != nil {
+		return err
+	}
+	if src != nil && dst != nil {
+		si.Proxy = si.RemoteAddr
+		si.RemoteAddr = src
+		si.LocalAddr = dst
+	}
+	return c.SetReadDeadline(time.Time{})
+}
+
+// readProxyV1 reads the remainder of a version 1 (text) header, after the signature.
+// A header with the protocol "UNKNOWN" results in nil addresses.
+func readProxyV1(r io.Reader) (net.Addr, net.Addr, error) {
+	var line []byte
+	b := make([]byte, 1)
+	for !bytes.HasSuffix(line, []byte("\r\n")) {
+		if len(line) >= proxyV1MaxLen-len(proxyV1Sig) {
+			return nil, nil, fmt.Errorf("%w: header too long", parsesyslog.ErrInvalidProxyHeader)
+		}
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, nil, err
+		}
+		line = append(line, b[0])
+	}
+
+	f := strings.Split(string(line[:len(line)-2]), " ")
+	if f[0] == "UNKNOWN" {
+		return nil, nil, nil
+	}
+	if len(f) != 5 || (f[0] != "TCP4" && f[0] != "TCP6") {
+		return nil, nil, fmt.Errorf("%w: malformed header %q", parsesyslog.ErrInvalidProxyHeader, line)
+	}
+	src, err := proxyV1Addr(f[1], f[3], f[0] == "TCP4")
+	if err != nil {
+		return nil, nil, err
+	}
+	dst, err := proxyV1Addr(f[2], f[4], f[0] == "TCP4")
+	if err != nil {
+		return nil, nil, err
+	}
+	return src, dst, nil
+}
+
+// proxyV1Addr returns the TCP address of the given IP address and port of a version 1
+// header
+func proxyV1Addr(ip, port string, v4 bool) (net.Addr, error) {
+	a := net.ParseIP(ip)
+	if a == nil || (a.To4() != nil) != v4 {
+		return nil, fmt.Errorf("%w: invalid address %q", parsesyslog.ErrInvalidProxyHeader, ip)
+	}
+	p, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid port %q", parsesyslog.ErrInvalidProxyHeader, port)
+	}
+	return &net.TCPAddr{IP: a, Port: int(p)}, nil
+}
+
+// readProxyV2 reads the remainder of a version 2 (binary) header, after the first 6
+// bytes of the signature. Headers of LOCAL connections and of unsupported address
+// families result in nil addresses. TLVs are skipped.
+func readProxyV2(r io.Reader) (net.Addr, net.Addr, error) {
+	h := make([]byte, proxyV2HeaderLen-len(proxyV1Sig))
+	if _, err := io.ReadFull(r, h); err != nil {
+		return nil, nil, err
+	}
+	if !bytes.Equal(h[:len(proxyV2Sig)-len(proxyV1Sig)], proxyV2Sig[len(proxyV1Sig):]) {
+		return nil, nil, fmt.Errorf("%w: missing signature", parsesyslog.ErrInvalidProxyHeader)
+	}
+	h = h[len(proxyV2Sig)-len(proxyV1Sig):]
+	if h[0]>>4 != 2 {
+		return nil, nil, fmt.Errorf("%w: unsupported version %d", parsesyslog.ErrInvalidProxyHeader, h[0]>>4)
+	}
+	cmd, fam := h[0]&0x0f, h[1]
+	data := make([]byte, binary.BigEndian.Uint16(h[2:4]))
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, nil, err
+	}
+
+	switch cmd {
+	case proxyV2CmdLocal:
+		return nil, nil, nil
+	case proxyV2CmdProxy:
+	default:
+		return nil, nil, fmt.Errorf("%w: unsupported command %d", parsesyslog.ErrInvalidProxyHeader, cmd)
+	}
+
+	// The upper nibble is the address family (1: IPv4, 2: IPv6), the lower one the
+	// transport protocol (1: stream, 2: datagram)
+	var l int
+	switch fam >> 4 {
+	case 0x1:
+		l = net.IPv4len
+	case 0x2:
+		l = net.IPv6len
+	default:
+		return nil, nil, nil
+	}
+	if len(data) < 2*l+4 {
+		return nil, nil, fmt.Errorf("%w: address block too short", parsesyslog.ErrInvalidProxyHeader)
+	}
+	src := proxyV2Addr(fam&0x0f, data[:l], data[2*l:2*l+2])
+	dst := proxyV2Addr(fam&0x0f, data[l:2*l], data[2*l+2:2*l+4])
+	return src, dst, nil
+}
+
+// proxyV2Addr returns the address of the given IP address and port of a version 2
+// header for the given transport protocol
+func proxyV2Addr(proto byte, ip, port []byte) net.Addr {
+	a := make(net.IP, len(ip))
+	copy(a, ip)
+	p := int(binary.BigEndian.Uint16(port))
+	if proto == 0x2 {
+		return &net.UDPAddr{IP: a, Port: p}
+	}
+	return &net.TCPAddr{IP: a, Port: p}
+}
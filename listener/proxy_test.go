@@ -0,0 +1,171 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package listener
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/wneessen/go-parsesyslog"
+	"github.com/wneessen/go-parsesyslog/rfc5424"
+)
+
+// proxyV2 returns a version 2 header with the given command, family and address block
+func proxyV2(t *testing.T, verCmd, fam byte, addrs string) []byte {
+	t.Helper()
+	a, err := hex.DecodeString(addrs)
+	if err != nil {
+		t.Fatalf("invalid test data: %s", err)
+	}
+	h := append([]byte(nil), proxyV2Sig...)
+	h = append(h, verCmd, fam, byte(len(a)>>8), byte(len(a)))
+	return append(h, a...)
+}
+
+// TestServer_readProxyHeader tests the readProxyHeader method of the Server
+func TestServer_readProxyHeader(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  []byte
+		src     string
+		dst     string
+		wantErr error
+	}{
+		{"v1 TCP4", []byte("PROXY TCP4 192.0.2.1 198.51.100.1 56324 514\r\n"), "192.0.2.1:56324", "198.51.100.1:514", nil},
+		{"v1 TCP6", []byte("PROXY TCP6 2001:db8::1 2001:db8::2 56324 514\r\n"), "[2001:db8::1]:56324", "[2001:db8::2]:514", nil},
+		{"v1 UNKNOWN", []byte("PROXY UNKNOWN\r\n"), "", "", nil},
+		{"v1 IPv6 in TCP4", []byte("PROXY TCP4 2001:db8::1 2001:db8::2 1 2\r\n"), "", "", parsesyslog.ErrInvalidProxyHeader},
+		{"v1 invalid port", []byte("PROXY TCP4 192.0.2.1 198.51.100.1 65536 514\r\n"), "", "", parsesyslog.ErrInvalidProxyHeader},
+		{"v1 missing fields", []byte("PROXY TCP4 192.0.2.1\r\n"), "", "", parsesyslog.ErrInvalidProxyHeader},
+		{"v1 too long", append([]byte("PROXY "), bytes.Repeat([]byte("1"), 120)...), "", "", parsesyslog.ErrInvalidProxyHeader},
+		{
+			"v2 TCP4", proxyV2(t, 0x21, 0x11, "c0000201c6336401dc0402020000"), "192.0.2.1:56324",
+			"198.51.100.1:514", nil,
+		},
+		{
+			"v2 TCP4 with TLV", proxyV2(t, 0x21, 0x11, "c0000201c6336401dc04020204000474657374"),
+			"192.0.2.1:56324", "198.51.100.1:514", nil,
+		},
+		{
+			"v2 TCP6", proxyV2(t, 0x21, 0x21,
+				"20010db800000000000000000000000120010db8000000000000000000000002dc040202"),
+			"[2001:db8::1]:56324", "[2001:db8::2]:514", nil,
+		},
+		{"v2 LOCAL", proxyV2(t, 0x20, 0x00, ""), "", "", nil},
+		{"v2 unix", proxyV2(t, 0x21, 0x31, "00"), "", "", nil},
+		{"v2 wrong version", proxyV2(t, 0x11, 0x11, "c0000201c6336401dc0402020000"), "", "", parsesyslog.ErrInvalidProxyHeader},
+		{"v2 unknown command", proxyV2(t, 0x22, 0x11, "c0000201c6336401dc0402020000"), "", "", parsesyslog.ErrInvalidProxyHeader},
+		{"v2 short addresses", proxyV2(t, 0x21, 0x11, "c0000201"), "", "", parsesyslog.ErrInvalidProxyHeader},
+		{"v2 broken signature", append([]byte("\r\n\r\n\x00\r\nQUIX\n"), 0x21, 0x11, 0, 0), "", "", parsesyslog.ErrInvalidProxyHeader},
+		{"no header", []byte("<13>1 - - - - - - test"), "", "", parsesyslog.ErrInvalidProxyHeader},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc, cc := net.Pipe()
+			defer func() { _ = sc.Close() }()
+			go func() {
+				_, _ = cc.Write(append(tt.header, "trailer"...))
+				_ = cc.Close()
+			}()
+
+			s := New(rfc5424.Type, newCollector())
+			si := SourceInfo{LocalAddr: sc.LocalAddr(), RemoteAddr: sc.RemoteAddr()}
+			err := s.readProxyHeader(sc, &si)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("readProxyHeader() => expected: %s, got: %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readProxyHeader() failed: %s", err)
+			}
+			if tt.src == "" {
+				if si.Proxy != nil || si.RemoteAddr != sc.RemoteAddr() {
+					t.Errorf("readProxyHeader() expected unchanged source info, got: %+v", si)
+				}
+			} else {
+				if si.RemoteAddr.String() != tt.src || si.LocalAddr.String() != tt.dst {
+					t.Errorf("readProxyHeader() => expected: %s -> %s, got: %s -> %s", tt.src, tt.dst,
+						si.RemoteAddr, si.LocalAddr)
+				}
+				if si.Proxy != sc.RemoteAddr() {
+					t.Errorf("readProxyHeader() wrong proxy address => expected: %s, got: %s", sc.RemoteAddr(),
+						si.Proxy)
+				}
+			}
+			rest := make([]byte, len("trailer"))
+			if _, err := sc.Read(rest); err != nil || string(rest) != "trailer" {
+				t.Errorf("readProxyHeader() consumed data after the header: %q (%v)", rest, err)
+			}
+		})
+	}
+}
+
+// TestServer_WithProxyProtocol tests a Server with PROXY protocol support, with and
+// without TLS
+func TestServer_WithProxyProtocol(t *testing.T) {
+	srvCert, srvX509 := testCert(t, "server")
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(srvX509)
+
+	for _, useTLS := range []bool{false, true} {
+		c := newCollector()
+		var errs []error
+		var emu sync.Mutex
+		s := New(rfc5424.Type, c, WithProxyProtocol(), WithErrorHandler(func(err error, _ SourceInfo) {
+			emu.Lock()
+			errs = append(errs, err)
+			emu.Unlock()
+		}))
+		var addr net.Addr
+		if useTLS {
+			addr = serveTLS(t, s, &tls.Config{Certificates: []tls.Certificate{srvCert}, MinVersion: tls.VersionTLS12})
+		} else {
+			addr = serve(t, s)
+		}
+
+		conn, err := net.Dial("tcp", addr.String())
+		if err != nil {
+			t.Fatalf("failed to connect: %s", err)
+		}
+		if _, err = conn.Write([]byte("PROXY TCP4 192.0.2.1 198.51.100.1 56324 514\r\n")); err != nil {
+			t.Fatalf("failed to write: %s", err)
+		}
+		var w net.Conn = conn
+		if useTLS {
+			w = tls.Client(conn, &tls.Config{RootCAs: rootCAs, ServerName: "server", MinVersion: tls.VersionTLS12})
+		}
+		if _, err = w.Write([]byte("57 <165>1 2003-10-11T22:14:15.003Z mymachine - - - - message")); err != nil {
+			t.Fatalf("failed to write: %s", err)
+		}
+		c.wait(t, 1)
+		_ = w.Close()
+
+		c.mu.Lock()
+		si := c.srcs[0]
+		c.mu.Unlock()
+		if si.RemoteAddr.String() != "192.0.2.1:56324" || si.LocalAddr.String() != "198.51.100.1:514" {
+			t.Errorf("WithProxyProtocol() wrong source info (TLS: %t): %+v", useTLS, si)
+		}
+		if si.Proxy == nil || si.Proxy.String() != conn.LocalAddr().String() {
+			t.Errorf("WithProxyProtocol() wrong proxy address (TLS: %t): %v", useTLS, si.Proxy)
+		}
+		if useTLS && si.TLS == nil {
+			t.Errorf("WithProxyProtocol() TLS state missing")
+		}
+		emu.Lock()
+		if len(errs) != 0 {
+			t.Errorf("WithProxyProtocol() unexpected errors (TLS: %t): %v", useTLS, errs)
+		}
+		emu.Unlock()
+	}
+}
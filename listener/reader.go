@@ -0,0 +1,76 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package listener
+
+import (
+	"io"
+	"time"
+
+	"github.com/wneessen/go-parsesyslog"
+)
+
+// NetworkReader is the network of the SourceInfo of messages served by ServeReader
+const NetworkReader = "reader"
+
+// readDeadliner is implemented by sources that support read deadlines (i. e. net.Conn
+// or os.File for pipes)
+type readDeadliner interface {
+	SetReadDeadline(time.Time) error
+}
+
+// readerAddr is the net.Addr of a source that is not a socket (i. e. of ServeReader)
+type readerAddr struct {
+	network string
+	name    string
+}
+
+// Network returns the network of the readerAddr. It satisfies the net.Addr interface
+func (a readerAddr) Network() string {
+	return a.network
+}
+
+// String returns the name of the readerAddr. It satisfies the net.Addr interface
+func (a readerAddr) String() string {
+	return a.name
+}
+
+// ServeReader reads, parses and dispatches the log messages of the given io.ReadCloser
+// (i. e. os.Stdin, the stdout of a child process, a serial port or an SSH channel) like
+// the messages of a stream connection: they are split into frames as described in
+// RFC6587 and handed to the Handler with a SourceInfo whose Network is NetworkReader and
+// whose RemoteAddr is the given name. Sources are accounted in the Stats by that name.
+//
+// ServeReader blocks until the reader is exhausted, the source fails or the Server is
+// closed. The reader is closed in any case, Close closes it right away, so that a
+// blocking read returns. The read timeout is applied if the reader supports read
+// deadlines. After Close, the returned error is parsesyslog.ErrServerClosed, otherwise
+// it is nil, as errors of the source are reported to the error handler.
+func (s *Server) ServeReader(rc io.ReadCloser, name string) error {
+	return s.serveReader(rc, SourceInfo{Network: NetworkReader, RemoteAddr: readerAddr{NetworkReader, name}})
+}
+
+// serveReader serves the given io.ReadCloser with the given SourceInfo as described in
+// ServeReader
+func (s *Server) serveReader(rc io.ReadCloser, si SourceInfo) error {
+	if _, err := parsesyslog.New(s.pt, s.popts...); err != nil {
+		_ = rc.Close()
+		return err
+	}
+	if !s.track(rc) {
+		_ = rc.Close()
+		return parsesyslog.ErrServerClosed
+	}
+	defer s.wg.Done()
+	defer s.untrack(rc)
+	if s.stats != nil {
+		s.stats.addConn(1)
+		defer s.stats.addConn(-1)
+	}
+	s.serveConn(rc, si)
+	if s.isClosed() {
+		return parsesyslog.ErrServerClosed
+	}
+	return nil
+}
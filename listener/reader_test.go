@@ -0,0 +1,85 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package listener
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wneessen/go-parsesyslog"
+	"github.com/wneessen/go-parsesyslog/rfc5424"
+)
+
+// TestServer_ServeReader tests the ServeReader method of the Server
+func TestServer_ServeReader(t *testing.T) {
+	c := newCollector()
+	st := NewStats()
+	s := New(rfc5424.Type, c, WithStats(st))
+	r := io.NopCloser(strings.NewReader("57 <165>1 2003-10-11T22:14:15.003Z mymachine - - - - message\n" +
+		"<165>1 2003-10-11T22:14:15.003Z mymachine - - - - second\n"))
+	if err := s.ServeReader(r, "stdin"); err != nil {
+		t.Fatalf("ServeReader() failed: %s", err)
+	}
+	c.wait(t, 2)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.msgs[0] != "message" || c.msgs[1] != "second" {
+		t.Errorf("ServeReader() => unexpected messages: %v", c.msgs)
+	}
+	if c.srcs[0].Network != NetworkReader || c.srcs[0].RemoteAddr.String() != "stdin" ||
+		c.srcs[0].RemoteAddr.Network() != NetworkReader {
+		t.Errorf("ServeReader() wrong source info: %+v", c.srcs[0])
+	}
+	ss := st.Snapshot()
+	if ss.Sources["stdin"].Messages != 2 || ss.ActiveConns != 0 {
+		t.Errorf("ServeReader() => unexpected stats: %+v", ss)
+	}
+}
+
+// TestServer_ServeReader_close tests that Close ends a blocking ServeReader
+func TestServer_ServeReader_close(t *testing.T) {
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("failed to create pipe: %s", err)
+	}
+	defer func() { _ = pw.Close() }()
+	c := newCollector()
+	s := New(rfc5424.Type, c, WithReadTimeout(time.Second*5))
+	done := make(chan error, 1)
+	go func() { done <- s.ServeReader(pr, "pipe") }()
+	if _, err := pw.Write([]byte("<165>1 2003-10-11T22:14:15.003Z mymachine - - - - message\n")); err != nil {
+		t.Fatalf("failed to write: %s", err)
+	}
+	c.wait(t, 1)
+
+	if err := s.Close(); err != nil {
+		t.Errorf("Close() failed: %s", err)
+	}
+	select {
+	case err := <-done:
+		if !errors.Is(err, parsesyslog.ErrServerClosed) {
+			t.Errorf("ServeReader() after Close() => expected: %s, got: %s", parsesyslog.ErrServerClosed, err)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("ServeReader() did not return after Close()")
+	}
+	if err := s.ServeReader(io.NopCloser(strings.NewReader("")), "closed"); !errors.Is(err,
+		parsesyslog.ErrServerClosed) {
+		t.Errorf("ServeReader() on closed Server => expected: %s, got: %s", parsesyslog.ErrServerClosed, err)
+	}
+}
+
+// TestServer_ServeReader_invalidType tests ServeReader with an unknown ParserType
+func TestServer_ServeReader_invalidType(t *testing.T) {
+	s := New("invalid", newCollector())
+	if err := s.ServeReader(io.NopCloser(strings.NewReader("")), "stdin"); err == nil {
+		t.Error("ServeReader() with invalid ParserType => expected error, got nil")
+	}
+}
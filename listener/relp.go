@@ -0,0 +1,124 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package listener
+
+import (
+	"errors"
+	"io"
+	"net"
+	"time"
+
+	"github.com/wneessen/go-parsesyslog"
+	"github.com/wneessen/go-parsesyslog/relp"
+)
+
+// ListenAndServeRELP listens on the given TCP address and serves the accepted
+// connections as RELP sessions. See ServeRELP for details.
+func (s *Server) ListenAndServeRELP(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.ServeRELP(ln)
+}
+
+// ServeRELP works like Serve, but serves every accepted connection as RELP session
+// (see the relp package). A message is acknowledged once the Handler has returned, so
+// that the sender only discards messages that have been handed off. If the Handler is
+// a Sink, pipelined messages are handed over in batches and rejected if the handoff
+// fails, so that the sender retransmits them. Messages that can not be parsed are
+// rejected and reported to the error handler. The read timeout applies to RELP
+// sessions as well.
+func (s *Server) ServeRELP(ln net.Listener) error {
+	return s.accept(ln, s.serveRELP)
+}
+
+// serveRELP serves a single RELP session
+func (s *Server) serveRELP(c net.Conn, si SourceInfo) {
+	if err := s.resolveTenant(&si); err != nil {
+		s.reportError(err, si)
+		return
+	}
+	if _, err := s.authenticate(si, nil); err != nil {
+		s.reportError(err, si)
+		return
+	}
+	p, err := parsesyslog.New(s.pt, s.popts...)
+	if err != nil {
+		s.reportError(err, si)
+		return
+	}
+	rs := relp.NewSession(c, p, 0)
+	var batch []parsesyslog.LogMsg
+	var txnrs []uint64
+	for {
+		if s.timeout > 0 {
+			if err := c.SetReadDeadline(time.Now().Add(s.timeout)); err != nil {
+				s.reportError(err, si)
+				return
+			}
+		}
+		lm, txnr, err := rs.Next()
+		if err != nil {
+			// Respond to the pending messages first, even if the session is ending
+			if ferr := s.flushRELP(rs, batch, txnrs, si); ferr != nil {
+				s.reportError(ferr, si)
+				return
+			}
+			batch, txnrs = batch[:0], txnrs[:0]
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if s.isClosed() {
+				_ = rs.Close()
+				return
+			}
+			s.reportError(err, si)
+			if txnr == 0 {
+				_ = rs.Close()
+				return
+			}
+			if err := rs.Nack(txnr, err.Error()); err != nil {
+				s.reportError(err, si)
+				return
+			}
+			continue
+		}
+		batch = append(batch, lm)
+		txnrs = append(txnrs, txnr)
+		if rs.Buffered() > 0 && len(batch) < DefaultMaxBatch {
+			continue
+		}
+		if err := s.flushRELP(rs, batch, txnrs, si); err != nil {
+			s.reportError(err, si)
+			return
+		}
+		batch, txnrs = batch[:0], txnrs[:0]
+	}
+}
+
+// flushRELP hands the given batch of messages to the Handler and acknowledges them
+// with the given transaction numbers or, if the handoff failed, rejects them
+func (s *Server) flushRELP(rs *relp.Session, batch []parsesyslog.LogMsg, txnrs []uint64, si SourceInfo) error {
+	if len(batch) == 0 {
+		return nil
+	}
+	herr := s.deliver(batch, si)
+	if herr != nil {
+		s.reportError(herr, si)
+	}
+	for _, txnr := range txnrs {
+		var err error
+		if herr != nil {
+			err = rs.Nack(txnr, herr.Error())
+		} else {
+			err = rs.Ack(txnr)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
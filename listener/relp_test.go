@@ -0,0 +1,75 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package listener
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/wneessen/go-parsesyslog"
+	"github.com/wneessen/go-parsesyslog/relp"
+	"github.com/wneessen/go-parsesyslog/rfc5424"
+)
+
+// TestServer_ServeRELP tests the ServeRELP method of the Server
+func TestServer_ServeRELP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+	c := newCollector()
+	s := New(rfc5424.Type, c)
+	done := make(chan error, 1)
+	go func() { done <- s.ServeRELP(ln) }()
+	defer func() {
+		_ = s.Close()
+		if err := <-done; !errors.Is(err, parsesyslog.ErrServerClosed) {
+			t.Errorf("ServeRELP() after Close() => expected: %s, got: %s", parsesyslog.ErrServerClosed, err)
+		}
+	}()
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to connect: %s", err)
+	}
+	defer func() { _ = conn.Close() }()
+	_ = conn.SetDeadline(time.Now().Add(time.Second * 5))
+	br := bufio.NewReader(conn)
+	frames := []relp.Frame{
+		{Command: relp.CommandOpen, Data: []byte("relp_version=0\ncommands=syslog"), Txnr: 1},
+		{Command: relp.CommandSyslog, Data: []byte("<165>1 2003-10-11T22:14:15.003Z host - - - - message"), Txnr: 2},
+		{Command: relp.CommandSyslog, Data: []byte("<165>foo"), Txnr: 3},
+		{Command: relp.CommandClose, Txnr: 4},
+	}
+	wantCodes := []string{"200", "200", "500", ""}
+	for i, f := range frames {
+		if err := relp.WriteFrame(conn, f); err != nil {
+			t.Fatalf("failed to write frame: %s", err)
+		}
+		rsp, err := relp.ReadFrame(br, relp.DefaultMaxFrameLength)
+		if err != nil {
+			t.Fatalf("failed to read response: %s", err)
+		}
+		if rsp.Command != relp.CommandRsp || rsp.Txnr != f.Txnr {
+			t.Errorf("ServeRELP() unexpected response: %+v", rsp)
+		}
+		code := ""
+		if len(rsp.Data) >= 3 {
+			code = string(rsp.Data[:3])
+		}
+		if code != wantCodes[i] {
+			t.Errorf("ServeRELP() response code for frame %d => expected: %q, got: %q", i, wantCodes[i], code)
+		}
+	}
+	c.wait(t, 1)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.msgs) != 1 || c.msgs[0] != "message" {
+		t.Errorf("ServeRELP() wrong messages: %q", c.msgs)
+	}
+}
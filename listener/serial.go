@@ -0,0 +1,27 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package listener
+
+// NetworkSerial is the network of the SourceInfo of messages served by ServeSerial
+const NetworkSerial = "serial"
+
+// ServeSerial opens the serial port or TTY device at the given path (i. e.
+// "/dev/ttyUSB0"), switches it to raw mode with 8 data bits, no parity and the given
+// baud rate and serves the log messages that are received on it like ServeReader
+// does. A baud rate of 0 keeps the current speed of the device. Since serial consoles
+// transmit one log message per line, the lines are always framed with the
+// non-transparent framing of RFC6587 and carriage returns are dropped. The SourceInfo
+// of the messages has NetworkSerial as Network and the path as RemoteAddr.
+//
+// Embedded devices commonly log in the RFC3164 format, so the Server is usually created
+// with the rfc3164.Type. Serial ports are only supported on Linux, on all other
+// platforms parsesyslog.ErrUnsupportedPlatform is returned.
+func (s *Server) ServeSerial(path string, baud int) error {
+	rc, err := openSerial(path, baud)
+	if err != nil {
+		return err
+	}
+	return s.serveReader(rc, SourceInfo{Network: NetworkSerial, RemoteAddr: readerAddr{NetworkSerial, path}})
+}
@@ -0,0 +1,94 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+//go:build linux
+// +build linux
+
+package listener
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+	"unsafe"
+
+	"github.com/wneessen/go-parsesyslog"
+)
+
+// cbaud is the mask of the baud rate bits of the c_cflag of a termios, which is not
+// exported by the syscall package
+const cbaud = 0x100f
+
+// baudRates maps the supported baud rates to their termios speed
+var baudRates = map[int]uint32{
+	1200:    syscall.B1200,
+	2400:    syscall.B2400,
+	4800:    syscall.B4800,
+	9600:    syscall.B9600,
+	19200:   syscall.B19200,
+	38400:   syscall.B38400,
+	57600:   syscall.B57600,
+	115200:  syscall.B115200,
+	230400:  syscall.B230400,
+	460800:  syscall.B460800,
+	921600:  syscall.B921600,
+	1500000: syscall.B1500000,
+	3000000: syscall.B3000000,
+}
+
+// openSerial opens the TTY device at the given path for reading and configures it as
+// described in ServeSerial
+func openSerial(path string, baud int) (io.ReadCloser, error) {
+	speed, ok := baudRates[baud]
+	if !ok && baud != 0 {
+		return nil, fmt.Errorf("%w: %d", parsesyslog.ErrInvalidBaudRate, baud)
+	}
+	f, err := os.OpenFile(path, os.O_RDONLY|syscall.O_NOCTTY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, err
+	}
+	rc, err := f.SyscallConn()
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	var terr error
+	err = rc.Control(func(fd uintptr) {
+		var t syscall.Termios
+		if terr = ioctl(fd, syscall.TCGETS, &t); terr != nil {
+			return
+		}
+		// Raw mode (see cfmakeraw(3)), but with carriage returns being dropped
+		t.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP | syscall.INLCR |
+			syscall.ICRNL | syscall.IXON
+		t.Iflag |= syscall.IGNCR
+		t.Oflag &^= syscall.OPOST
+		t.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+		t.Cflag &^= syscall.CSIZE | syscall.PARENB
+		t.Cflag |= syscall.CS8 | syscall.CREAD | syscall.CLOCAL
+		t.Cc[syscall.VMIN], t.Cc[syscall.VTIME] = 1, 0
+		if baud != 0 {
+			t.Cflag = t.Cflag&^cbaud | speed
+			t.Ispeed, t.Ospeed = speed, speed
+		}
+		terr = ioctl(fd, syscall.TCSETS, &t)
+	})
+	if err == nil {
+		err = terr
+	}
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("failed to configure serial port %s: %w", path, err)
+	}
+	return f, nil
+}
+
+// ioctl performs the given termios ioctl request on the given file descriptor
+func ioctl(fd, req uintptr, t *syscall.Termios) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, req, uintptr(unsafe.Pointer(t))); errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+//go:build linux
+// +build linux
+
+package listener
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/wneessen/go-parsesyslog"
+	"github.com/wneessen/go-parsesyslog/rfc3164"
+)
+
+// openPTY opens a new pseudo terminal and returns its master and the path of its slave
+func openPTY(t *testing.T) (*os.File, string) {
+	t.Helper()
+	m, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		t.Skipf("pseudo terminals are not available: %s", err)
+	}
+	t.Cleanup(func() { _ = m.Close() })
+	var unlock, n uint32
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, m.Fd(), syscall.TIOCSPTLCK,
+		uintptr(unsafe.Pointer(&unlock))); errno != 0 {
+		t.Skipf("failed to unlock pseudo terminal: %s", errno)
+	}
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, m.Fd(), syscall.TIOCGPTN,
+		uintptr(unsafe.Pointer(&n))); errno != 0 {
+		t.Skipf("failed to get pseudo terminal number: %s", errno)
+	}
+	return m, "/dev/pts/" + strconv.Itoa(int(n))
+}
+
+// TestServer_ServeSerial tests the ServeSerial method of the Server
+func TestServer_ServeSerial(t *testing.T) {
+	m, path := openPTY(t)
+	c := newCollector()
+	s := New(rfc3164.Type, c)
+	done := make(chan error, 1)
+	go func() { done <- s.ServeSerial(path, 115200) }()
+
+	// The line discipline of the slave needs to be configured before anything is written
+	deadline := time.Now().Add(time.Second * 5)
+	for {
+		var tio syscall.Termios
+		if err := ioctl(m.Fd(), syscall.TCGETS, &tio); err != nil {
+			t.Fatalf("failed to get terminal attributes: %s", err)
+		}
+		if tio.Iflag&syscall.IGNCR != 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("serial port has not been configured")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := m.Write([]byte("<34>Oct 11 22:14:15 mymachine su: first\r\n" +
+		"<34>Oct 11 22:14:16 mymachine su: second\r\n")); err != nil {
+		t.Fatalf("failed to write: %s", err)
+	}
+	c.wait(t, 2)
+
+	if err := s.Close(); err != nil {
+		t.Errorf("Close() failed: %s", err)
+	}
+	if err := <-done; !errors.Is(err, parsesyslog.ErrServerClosed) {
+		t.Errorf("ServeSerial() after Close() => expected: %s, got: %s", parsesyslog.ErrServerClosed, err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.msgs[0] != "first" || c.msgs[1] != "second" {
+		t.Errorf("ServeSerial() => unexpected messages: %q", c.msgs)
+	}
+	if c.srcs[0].Network != NetworkSerial || c.srcs[0].RemoteAddr.String() != path {
+		t.Errorf("ServeSerial() wrong source info: %+v", c.srcs[0])
+	}
+}
+
+// TestServer_ServeSerial_fails tests ServeSerial with invalid baud rates and devices
+func TestServer_ServeSerial_fails(t *testing.T) {
+	s := New(rfc3164.Type, newCollector())
+	if err := s.ServeSerial("/dev/null", 12345); !errors.Is(err, parsesyslog.ErrInvalidBaudRate) {
+		t.Errorf("ServeSerial() with invalid baud rate => expected: %s, got: %v", parsesyslog.ErrInvalidBaudRate,
+			err)
+	}
+	if err := s.ServeSerial("/dev/null", 9600); err == nil {
+		t.Error("ServeSerial() on a device that is not a TTY => expected error, got nil")
+	}
+	if err := s.ServeSerial("/nonexistent/tty", 9600); err == nil {
+		t.Error("ServeSerial() on non-existing device => expected error, got nil")
+	}
+}
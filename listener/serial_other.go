@@ -0,0 +1,19 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+//go:build !linux
+// +build !linux
+
+package listener
+
+import (
+	"io"
+
+	"github.com/wneessen/go-parsesyslog"
+)
+
+// openSerial is not supported on this platform
+func openSerial(string, int) (io.ReadCloser, error) {
+	return nil, parsesyslog.ErrUnsupportedPlatform
+}
@@ -0,0 +1,79 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package listener
+
+import (
+	"fmt"
+
+	"github.com/wneessen/go-parsesyslog"
+)
+
+// DefaultMaxBatch is the maximum number of log messages of a RELP session that are
+// handed to a Sink at once
+const DefaultMaxBatch = 128
+
+// Sink is a Handler that acknowledges the handoff of log messages. If the Handler of a
+// Server implements Sink, the Server hands the messages to WriteBatch instead of
+// Handle and only acknowledges them to the sender once WriteBatch has returned nil,
+// i. e. once the messages have been stored durably (see the spool package).
+//
+// RELP messages that the sender has pipelined are handed over in batches of up to
+// DefaultMaxBatch messages and are acknowledged or rejected together, so that the
+// sender retransmits rejected messages. Messages of stream connections are handed
+// over one at a time, since the syslog transport has no acknowledgements. If the
+// handoff fails, the connection is closed, so that the sender does not consider any
+// further message as delivered. Failed datagrams are dropped. Failures are reported to
+// the error handler as parsesyslog.ErrHandoffFailed.
+//
+// The batch is only valid until WriteBatch returns.
+type Sink interface {
+	Handler
+	WriteBatch(batch []parsesyslog.LogMsg, si SourceInfo) error
+}
+
+// SinkFunc is an adapter to use an ordinary function as Sink
+type SinkFunc func([]parsesyslog.LogMsg, SourceInfo) error
+
+// WriteBatch calls f(batch, si). It satisfies the Sink interface
+func (f SinkFunc) WriteBatch(batch []parsesyslog.LogMsg, si SourceInfo) error {
+	return f(batch, si)
+}
+
+// Handle calls f with a batch of the given log message and discards the error. It
+// satisfies the Handler interface
+func (f SinkFunc) Handle(lm parsesyslog.LogMsg, si SourceInfo) {
+	_ = f([]parsesyslog.LogMsg{lm}, si)
+}
+
+// deliver hands the given batch of log messages to the Handler. If the Handler is a
+// Sink, the error of the handoff is returned.
+func (s *Server) deliver(batch []parsesyslog.LogMsg, si SourceInfo) error {
+	if s.stats != nil {
+		s.stats.addMessages(batch, si)
+	}
+	if s.policy != nil {
+		if ip := addrIP(si.RemoteAddr); ip != nil {
+			for i := range batch {
+				s.policy.Apply(&batch[i], ip.String())
+			}
+		}
+	}
+	if err := writeBatch(s.handler, batch, si); err != nil {
+		return fmt.Errorf("%w: %v", parsesyslog.ErrHandoffFailed, err)
+	}
+	return nil
+}
+
+// writeBatch hands the given batch of log messages to the given Handler, via
+// WriteBatch if it is a Sink
+func writeBatch(h Handler, batch []parsesyslog.LogMsg, si SourceInfo) error {
+	if sk, ok := h.(Sink); ok {
+		return sk.WriteBatch(batch, si)
+	}
+	for _, lm := range batch {
+		h.Handle(lm, si)
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package listener
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/wneessen/go-parsesyslog"
+	"github.com/wneessen/go-parsesyslog/relp"
+	"github.com/wneessen/go-parsesyslog/rfc5424"
+)
+
+// batchSink is a Sink that collects the received messages and fails for batches that
+// contain the message "fail"
+type batchSink struct {
+	mu   sync.Mutex
+	msgs []string
+}
+
+func (b *batchSink) WriteBatch(batch []parsesyslog.LogMsg, _ SourceInfo) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, lm := range batch {
+		if lm.Message.String() == "fail" {
+			return errors.New("disk full")
+		}
+	}
+	for _, lm := range batch {
+		b.msgs = append(b.msgs, lm.Message.String())
+	}
+	return nil
+}
+
+func (b *batchSink) Handle(lm parsesyslog.LogMsg, si SourceInfo) {
+	_ = b.WriteBatch([]parsesyslog.LogMsg{lm}, si)
+}
+
+// TestServer_ServeRELP_sink tests that RELP messages are only acknowledged once the
+// Sink has accepted them
+func TestServer_ServeRELP_sink(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+	sk := &batchSink{}
+	errCh := make(chan error, 10)
+	s := New(rfc5424.Type, sk, WithErrorHandler(func(err error, _ SourceInfo) { errCh <- err }))
+	done := make(chan error, 1)
+	go func() { done <- s.ServeRELP(ln) }()
+	defer func() {
+		_ = s.Close()
+		<-done
+	}()
+
+	tests := []struct {
+		name  string
+		msgs  []string
+		codes []string
+	}{
+		{"accepted", []string{"first", "second", "third"}, []string{"200", "200", "200"}},
+		{"rejected", []string{"fail"}, []string{"500"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, err := net.Dial("tcp", ln.Addr().String())
+			if err != nil {
+				t.Fatalf("failed to connect: %s", err)
+			}
+			defer func() { _ = conn.Close() }()
+			_ = conn.SetDeadline(time.Now().Add(time.Second * 5))
+
+			// All frames are sent at once, like a pipelining sender does
+			var buf bytes.Buffer
+			_ = relp.WriteFrame(&buf, relp.Frame{Command: relp.CommandOpen, Data: []byte("relp_version=0"), Txnr: 1})
+			for i, m := range tt.msgs {
+				_ = relp.WriteFrame(&buf, relp.Frame{
+					Command: relp.CommandSyslog, Txnr: uint64(i + 2),
+					Data: []byte("<165>1 2003-10-11T22:14:15.003Z host - - - - " + m),
+				})
+			}
+			if _, err := conn.Write(buf.Bytes()); err != nil {
+				t.Fatalf("failed to write frames: %s", err)
+			}
+
+			br := bufio.NewReader(conn)
+			codes := make(map[uint64]string)
+			for i := 0; i <= len(tt.msgs); i++ {
+				rsp, err := relp.ReadFrame(br, relp.DefaultMaxFrameLength)
+				if err != nil {
+					t.Fatalf("failed to read response: %s", err)
+				}
+				codes[rsp.Txnr] = string(rsp.Data[:3])
+			}
+			for i, want := range tt.codes {
+				if got := codes[uint64(i+2)]; got != want {
+					t.Errorf("ServeRELP() response code for txnr %d => expected: %s, got: %s", i+2, want, got)
+				}
+			}
+			if tt.codes[0] == "500" {
+				select {
+				case err := <-errCh:
+					if !errors.Is(err, parsesyslog.ErrHandoffFailed) {
+						t.Errorf("ServeRELP() => expected: %s, got: %s", parsesyslog.ErrHandoffFailed, err)
+					}
+				case <-time.After(time.Second * 5):
+					t.Fatal("ServeRELP() handoff failure was not reported")
+				}
+			}
+		})
+	}
+
+	sk.mu.Lock()
+	defer sk.mu.Unlock()
+	if len(sk.msgs) != 3 || sk.msgs[0] != "first" || sk.msgs[1] != "second" || sk.msgs[2] != "third" {
+		t.Errorf("ServeRELP() => unexpected messages: %v", sk.msgs)
+	}
+}
+
+// TestServer_Serve_sink tests that a stream connection is closed if the Sink fails
+func TestServer_Serve_sink(t *testing.T) {
+	sk := &batchSink{}
+	errCh := make(chan error, 10)
+	s := New(rfc5424.Type, sk, WithErrorHandler(func(err error, _ SourceInfo) { errCh <- err }))
+	addr := serve(t, s)
+
+	conn, err := net.Dial("tcp", addr.String())
+	if err != nil {
+		t.Fatalf("failed to connect: %s", err)
+	}
+	defer func() { _ = conn.Close() }()
+	_, err = conn.Write([]byte("<165>1 2003-10-11T22:14:15.003Z host - - - - accepted\n" +
+		"<165>1 2003-10-11T22:14:15.003Z host - - - - fail\n"))
+	if err != nil {
+		t.Fatalf("failed to write: %s", err)
+	}
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, parsesyslog.ErrHandoffFailed) {
+			t.Errorf("Serve() => expected: %s, got: %s", parsesyslog.ErrHandoffFailed, err)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("Serve() handoff failure was not reported")
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second * 5))
+	if _, err := conn.Read(make([]byte, 1)); err == nil {
+		t.Errorf("Serve() expected connection to be closed")
+	}
+	sk.mu.Lock()
+	defer sk.mu.Unlock()
+	if len(sk.msgs) != 1 || sk.msgs[0] != "accepted" {
+		t.Errorf("Serve() => unexpected messages: %v", sk.msgs)
+	}
+}
+
+// TestTenantRouter_WriteBatch tests that the TenantRouter routes batches to Sinks and
+// Handlers
+func TestTenantRouter_WriteBatch(t *testing.T) {
+	sk, c := &batchSink{}, newCollector()
+	r := NewTenantRouter(nil)
+	r.Route("sink", sk)
+	r.Route("handler", c)
+	batch := make([]parsesyslog.LogMsg, 2)
+	batch[1].Message.WriteString("fail")
+	if err := r.WriteBatch(batch, SourceInfo{Tenant: "sink"}); err == nil {
+		t.Errorf("WriteBatch() => expected error of the Sink")
+	}
+	if err := r.WriteBatch(batch, SourceInfo{Tenant: "handler"}); err != nil {
+		t.Errorf("WriteBatch() failed: %s", err)
+	}
+	if err := r.WriteBatch(batch, SourceInfo{Tenant: "other"}); err != nil {
+		t.Errorf("WriteBatch() without route failed: %s", err)
+	}
+	if len(c.msgs) != 2 {
+		t.Errorf("WriteBatch() => expected 2 messages for the Handler, got: %d", len(c.msgs))
+	}
+}
@@ -0,0 +1,229 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+//go:build soak
+// +build soak
+
+package listener
+
+// The soak test runs a Server against a load generator with induced disconnects,
+// malformed frames and slowloris senders and asserts that no goroutines or file
+// descriptors leak over time. It is excluded from the regular test runs and needs to be
+// run explicitly, i. e.:
+//
+//	go test -tags soak -run TestServer_soak ./listener -args -soak.duration=10m
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"net"
+	"os"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/wneessen/go-parsesyslog"
+	"github.com/wneessen/go-parsesyslog/rfc5424"
+	"github.com/wneessen/go-parsesyslog/rfc6587"
+)
+
+var soakDuration = flag.Duration("soak.duration", time.Minute, "duration of the listener soak test")
+
+const (
+	// soakRound is the duration of a single round of the soak test, after which the
+	// senders are stopped and the Server is checked for leaks
+	soakRound = time.Second * 5
+	// soakSenders is the amount of concurrent senders per kind of sender
+	soakSenders = 4
+	// soakSlack is the amount of goroutines and file descriptors a round may exceed the
+	// first round by, to tolerate runtime internals
+	soakSlack = 5
+)
+
+// soakSender is a sender of the soak test. It sends to the Server at the given address
+// until it is done or the stop channel is closed.
+type soakSender func(addr string, rnd *rand.Rand, stop <-chan struct{})
+
+// soakMessage returns a valid RFC5424 message
+func soakMessage(rnd *rand.Rand) string {
+	return fmt.Sprintf("<%d>1 2003-10-11T22:14:15.003Z host%d app - - [soak@32473 n=\"%d\"] message %d",
+		rnd.Intn(192), rnd.Intn(10), rnd.Int(), rnd.Int())
+}
+
+// soakLoad sends a batch of valid messages, either octet-counted or newline-delimited
+func soakLoad(addr string, rnd *rand.Rand, _ <-chan struct{}) {
+	c, err := net.DialTimeout("tcp", addr, time.Second)
+	if err != nil {
+		return
+	}
+	defer func() { _ = c.Close() }()
+	_ = c.SetWriteDeadline(time.Now().Add(time.Second))
+	octets, n := rnd.Intn(2) == 0, 1+rnd.Intn(100)
+	for i := 0; i < n; i++ {
+		m := soakMessage(rnd)
+		if octets {
+			m = strconv.Itoa(len(m)) + " " + m
+		} else {
+			m += "\n"
+		}
+		if _, err := c.Write([]byte(m)); err != nil {
+			return
+		}
+	}
+}
+
+// soakDisconnect sends the start of a message and resets the connection
+func soakDisconnect(addr string, rnd *rand.Rand, _ <-chan struct{}) {
+	c, err := net.DialTimeout("tcp", addr, time.Second)
+	if err != nil {
+		return
+	}
+	m := soakMessage(rnd)
+	_ = c.SetWriteDeadline(time.Now().Add(time.Second))
+	_, _ = c.Write([]byte(m[:rnd.Intn(len(m))]))
+	if tc, ok := c.(*net.TCPConn); ok {
+		_ = tc.SetLinger(0)
+	}
+	_ = c.Close()
+}
+
+// soakMalformed sends frames that can not be framed or parsed
+func soakMalformed(addr string, rnd *rand.Rand, _ <-chan struct{}) {
+	c, err := net.DialTimeout("tcp", addr, time.Second)
+	if err != nil {
+		return
+	}
+	defer func() { _ = c.Close() }()
+	frames := []string{"<999>1 - - - - - -\n", "<34>x garbage\n", "\x00\x00\x00\n", "<34>1 yesterday - - - - -\n",
+		"<34>1 2003-10-11T22:14:15.003Z h a - - [broken\n", "999999999 short\n", "12 <34>1\n"}
+	_ = c.SetWriteDeadline(time.Now().Add(time.Second))
+	for i, n := 0, 1+rnd.Intn(20); i < n; i++ {
+		b := []byte(frames[rnd.Intn(len(frames))])
+		if rnd.Intn(4) == 0 {
+			b = make([]byte, 1+rnd.Intn(256))
+			_, _ = rnd.Read(b)
+		}
+		if _, err := c.Write(b); err != nil {
+			return
+		}
+	}
+}
+
+// soakSlowloris sends a message byte by byte, slower than the frame timeout, until the
+// Server closes the connection
+func soakSlowloris(addr string, rnd *rand.Rand, stop <-chan struct{}) {
+	c, err := net.DialTimeout("tcp", addr, time.Second)
+	if err != nil {
+		return
+	}
+	defer func() { _ = c.Close() }()
+	m := soakMessage(rnd)
+	for i := 0; ; i++ {
+		select {
+		case <-stop:
+			return
+		case <-time.After(time.Millisecond * 50):
+		}
+		_ = c.SetWriteDeadline(time.Now().Add(time.Second))
+		if _, err := c.Write([]byte{m[i%len(m)]}); err != nil {
+			return
+		}
+	}
+}
+
+// openFDs returns the amount of open file descriptors of the process, or -1 if it can
+// not be determined
+func openFDs() int {
+	fds, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		return -1
+	}
+	return len(fds)
+}
+
+// settle waits until the amount of goroutines stops decreasing and returns it
+func settle() int {
+	n := runtime.NumGoroutine()
+	for i := 0; i < 50; i++ {
+		time.Sleep(time.Millisecond * 20)
+		m := runtime.NumGoroutine()
+		if m == n {
+			return n
+		}
+		n = m
+	}
+	return n
+}
+
+// TestServer_soak runs the Server against the soak senders for the configured duration
+// and checks for leaked goroutines and file descriptors after every round
+func TestServer_soak(t *testing.T) {
+	var handled, failed uint64
+	st := NewStats()
+	s := New(rfc5424.Type, HandlerFunc(func(parsesyslog.LogMsg, SourceInfo) {
+		atomic.AddUint64(&handled, 1)
+	}), WithStats(st), WithReadTimeout(time.Millisecond*500),
+		WithFramerOptions(rfc6587.WithFrameTimeout(time.Millisecond*200)),
+		WithErrorHandler(func(error, SourceInfo) { atomic.AddUint64(&failed, 1) }))
+	addr := serve(t, s).String()
+
+	senders := []soakSender{soakLoad, soakDisconnect, soakMalformed, soakSlowloris}
+	rounds := int(*soakDuration / soakRound)
+	if rounds < 2 {
+		rounds = 2
+	}
+	refG, refFD := 0, 0
+	for r := 0; r < rounds; r++ {
+		stop := make(chan struct{})
+		var wg sync.WaitGroup
+		for i, send := range senders {
+			for j := 0; j < soakSenders; j++ {
+				wg.Add(1)
+				go func(send soakSender, seed int64) {
+					defer wg.Done()
+					rnd := rand.New(rand.NewSource(seed))
+					for {
+						select {
+						case <-stop:
+							return
+						default:
+						}
+						send(addr, rnd, stop)
+					}
+				}(send, int64(r*100+i*10+j))
+			}
+		}
+		time.Sleep(soakRound)
+		close(stop)
+		wg.Wait()
+
+		deadline := time.Now().Add(time.Second * 10)
+		for st.Snapshot().ActiveConns > 0 {
+			if time.Now().After(deadline) {
+				t.Fatalf("round %d: %d connections are still active", r, st.Snapshot().ActiveConns)
+			}
+			time.Sleep(time.Millisecond * 10)
+		}
+		g, fd := settle(), openFDs()
+		t.Logf("round %d: %d goroutines, %d file descriptors, %d messages, %d errors", r, g, fd,
+			atomic.LoadUint64(&handled), atomic.LoadUint64(&failed))
+		if r == 0 {
+			refG, refFD = g, fd
+			continue
+		}
+		if g > refG+soakSlack {
+			t.Errorf("round %d: goroutines leaked: %d, expected at most: %d", r, g, refG+soakSlack)
+		}
+		if fd > refFD+soakSlack {
+			t.Errorf("round %d: file descriptors leaked: %d, expected at most: %d", r, fd, refFD+soakSlack)
+		}
+	}
+	if atomic.LoadUint64(&handled) == 0 || atomic.LoadUint64(&failed) == 0 {
+		t.Errorf("expected messages and errors, got: %d messages and %d errors", handled, failed)
+	}
+}
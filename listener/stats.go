@@ -0,0 +1,189 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package listener
+
+import (
+	"sync"
+	"time"
+
+	"github.com/wneessen/go-parsesyslog"
+)
+
+// DefaultRecentErrors is the amount of recent errors a Stats retains
+const DefaultRecentErrors = 100
+
+// Stats collects live statistics of one or more Servers: the amount of active
+// connections, received messages and errors, both in total and per source, and the
+// most recent errors. Sources are identified by the IP address of the sender (without
+// the port), so that reconnecting senders are accounted for as one source.
+//
+// A Stats is safe for concurrent use.
+type Stats struct {
+	conns    int64
+	errors   uint64
+	hists    map[string]*parsesyslog.SeverityHistogram
+	messages uint64
+	mu       sync.Mutex
+	next     int
+	now      func() time.Time
+	recent   []ErrorRecord
+	sources  map[string]*SourceStats
+	started  time.Time
+}
+
+// ErrorRecord represents a single error reported by a Server
+type ErrorRecord struct {
+	Class  string    `json:"class"`
+	Error  string    `json:"error"`
+	Source string    `json:"source"`
+	Tenant string    `json:"tenant,omitempty"`
+	Time   time.Time `json:"time"`
+}
+
+// SourceStats represents the counters of a single source
+type SourceStats struct {
+	// ErrorClasses holds the amount of errors per class, as returned by
+	// parsesyslog.ClassifyError
+	ErrorClasses map[string]uint64 `json:"error_classes,omitempty"`
+	Errors       uint64            `json:"errors"`
+	// Histogram holds the amount of messages per minute, per severity and per facility
+	// of the last parsesyslog.DefaultHistogramWindow minutes
+	Histogram []parsesyslog.HistogramBucket `json:"histogram,omitempty"`
+	LastSeen  time.Time                     `json:"last_seen"`
+	Messages  uint64                        `json:"messages"`
+}
+
+// StatsSnapshot represents the state of a Stats at a given point in time
+type StatsSnapshot struct {
+	ActiveConns  int64                  `json:"active_connections"`
+	Errors       uint64                 `json:"errors"`
+	Messages     uint64                 `json:"messages"`
+	RecentErrors []ErrorRecord          `json:"recent_errors"`
+	Sources      map[string]SourceStats `json:"sources"`
+	Started      time.Time              `json:"started"`
+	Time         time.Time              `json:"time"`
+}
+
+// WithStats makes the Server account its connections, messages and errors in the given
+// Stats. A Stats can be shared by multiple Servers.
+func WithStats(st *Stats) Option {
+	return func(s *Server) {
+		s.stats = st
+	}
+}
+
+// NewStats returns a new, empty Stats
+func NewStats() *Stats {
+	return &Stats{
+		hists:   make(map[string]*parsesyslog.SeverityHistogram),
+		now:     time.Now,
+		recent:  make([]ErrorRecord, 0, DefaultRecentErrors),
+		sources: make(map[string]*SourceStats),
+		started: time.Now(),
+	}
+}
+
+// Snapshot returns a copy of the current state of the Stats. The recent errors are
+// ordered from the oldest to the most recent one.
+func (st *Stats) Snapshot() StatsSnapshot {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	ss := StatsSnapshot{
+		ActiveConns:  st.conns,
+		Errors:       st.errors,
+		Messages:     st.messages,
+		RecentErrors: make([]ErrorRecord, 0, len(st.recent)),
+		Sources:      make(map[string]SourceStats, len(st.sources)),
+		Started:      st.started,
+		Time:         st.now(),
+	}
+	ss.RecentErrors = append(ss.RecentErrors, st.recent[st.next:]...)
+	ss.RecentErrors = append(ss.RecentErrors, st.recent[:st.next]...)
+	for src, c := range st.sources {
+		sc := *c
+		if c.ErrorClasses != nil {
+			sc.ErrorClasses = make(map[string]uint64, len(c.ErrorClasses))
+			for k, v := range c.ErrorClasses {
+				sc.ErrorClasses[k] = v
+			}
+		}
+		sc.Histogram = st.hists[src].Snapshot()
+		ss.Sources[src] = sc
+	}
+	return ss
+}
+
+// addConn adds the given delta to the amount of active connections
+func (st *Stats) addConn(d int64) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.conns += d
+}
+
+// addError accounts the given error for the source of the given SourceInfo
+func (st *Stats) addError(err error, si SourceInfo) {
+	class := parsesyslog.ClassifyError(err).Error()
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.errors++
+	sc := st.source(si)
+	sc.Errors++
+	if sc.ErrorClasses == nil {
+		sc.ErrorClasses = make(map[string]uint64)
+	}
+	sc.ErrorClasses[class]++
+
+	er := ErrorRecord{Class: class, Error: err.Error(), Source: source(si), Tenant: si.Tenant, Time: sc.LastSeen}
+	if len(st.recent) < cap(st.recent) {
+		st.recent = append(st.recent, er)
+		return
+	}
+	st.recent[st.next] = er
+	st.next = (st.next + 1) % len(st.recent)
+}
+
+// addMessages accounts the given batch of messages for the source of the given
+// SourceInfo
+func (st *Stats) addMessages(batch []parsesyslog.LogMsg, si SourceInfo) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.messages += uint64(len(batch))
+	st.source(si).Messages += uint64(len(batch))
+	src := source(si)
+	h, ok := st.hists[src]
+	if !ok {
+		h = parsesyslog.NewSeverityHistogram(0)
+		st.hists[src] = h
+	}
+	for i := range batch {
+		h.Add(batch[i].Facility, batch[i].Severity)
+	}
+}
+
+// source returns the counters of the source of the given SourceInfo, after updating
+// its LastSeen time. It needs to be called with the lock held.
+func (st *Stats) source(si SourceInfo) *SourceStats {
+	src := source(si)
+	sc, ok := st.sources[src]
+	if !ok {
+		sc = &SourceStats{}
+		st.sources[src] = sc
+	}
+	sc.LastSeen = st.now()
+	return sc
+}
+
+// source returns the name of the source of the given SourceInfo, which is the IP
+// address of the sender or, for senders without an IP address (i. e. via unix
+// sockets), the remote address or the network
+func source(si SourceInfo) string {
+	if ip := addrIP(si.RemoteAddr); ip != nil {
+		return ip.String()
+	}
+	if si.RemoteAddr != nil && si.RemoteAddr.String() != "" {
+		return si.RemoteAddr.String()
+	}
+	return si.Network
+}
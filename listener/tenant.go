@@ -0,0 +1,198 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package listener
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+
+	"github.com/wneessen/go-parsesyslog"
+)
+
+// TenantFunc determines the tenant a sender belongs to, based on the SourceInfo of its
+// connection or datagram (i. e. the client certificate or the source address). A
+// non-nil error rejects the sender.
+type TenantFunc func(si SourceInfo) (string, error)
+
+// WithTenantFunc sets the TenantFunc that determines the tenant of every stream
+// connection (once, before the first frame is read), every RELP session and every
+// datagram. The tenant is handed to the AuthFunc and the Handler as
+// SourceInfo.Tenant. Rejections are reported to the error handler and close the
+// connection or drop the datagram.
+func WithTenantFunc(fn TenantFunc) Option {
+	return func(s *Server) {
+		s.tenant = fn
+	}
+}
+
+// resolveTenant calls the TenantFunc, if one is configured, and stores the tenant in
+// the SourceInfo
+func (s *Server) resolveTenant(si *SourceInfo) error {
+	if s.tenant == nil {
+		return nil
+	}
+	t, err := s.tenant(*si)
+	if err != nil {
+		return err
+	}
+	si.Tenant = t
+	return nil
+}
+
+// TenantMap maps senders to tenants by the subject alternative names (SAN) of their
+// TLS client certificate or by their source address. SANs take precedence over source
+// addresses and of multiple matching networks, the most specific one is used. A
+// TenantMap is safe for concurrent use. Its Tenant method satisfies TenantFunc.
+type TenantMap struct {
+	def  string
+	mu   sync.RWMutex
+	nets []tenantNet
+	sans map[string]string
+}
+
+// tenantNet is a network of a TenantMap
+type tenantNet struct {
+	net    *net.IPNet
+	tenant string
+}
+
+// NewTenantMap returns a new, empty TenantMap. Senders that match none of its entries
+// belong to the given default tenant. If the default tenant is empty, they are
+// rejected with parsesyslog.ErrUnknownTenant.
+func NewTenantMap(def string) *TenantMap {
+	return &TenantMap{def: def, sans: make(map[string]string)}
+}
+
+// AddCIDR maps all senders with a source address in the given network (in CIDR
+// notation, i. e. "192.0.2.0/24") to the given tenant. For proxied connections, the
+// address of the original client is used.
+func (m *TenantMap) AddCIDR(cidr, tenant string) error {
+	_, n, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nets = append(m.nets, tenantNet{net: n, tenant: tenant})
+	// Sort the most specific networks first, so the first match is the best one
+	sort.SliceStable(m.nets, func(i, j int) bool {
+		oi, _ := m.nets[i].net.Mask.Size()
+		oj, _ := m.nets[j].net.Mask.Size()
+		return oi > oj
+	})
+	return nil
+}
+
+// AddSAN maps all senders with a TLS client certificate that has the given DNS name,
+// email address or URI as subject alternative name to the given tenant
+func (m *TenantMap) AddSAN(san, tenant string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sans[san] = tenant
+}
+
+// Tenant returns the tenant of the sender described by the given SourceInfo. Only the
+// leaf certificate of a verified TLS client is considered. It satisfies the TenantFunc
+// type.
+func (m *TenantMap) Tenant(si SourceInfo) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if si.TLS != nil && len(si.TLS.VerifiedChains) > 0 && len(si.TLS.PeerCertificates) > 0 {
+		c := si.TLS.PeerCertificates[0]
+		sans := append(append([]string(nil), c.DNSNames...), c.EmailAddresses...)
+		for _, u := range c.URIs {
+			sans = append(sans, u.String())
+		}
+		for _, san := range sans {
+			if t, ok := m.sans[san]; ok {
+				return t, nil
+			}
+		}
+	}
+	if ip := addrIP(si.RemoteAddr); ip != nil {
+		for _, n := range m.nets {
+			if n.net.Contains(ip) {
+				return n.tenant, nil
+			}
+		}
+	}
+	if m.def == "" {
+		return "", fmt.Errorf("%w: %s", parsesyslog.ErrUnknownTenant, si.RemoteAddr)
+	}
+	return m.def, nil
+}
+
+// addrIP returns the IP address of the given net.Addr or nil if it has none
+func addrIP(a net.Addr) net.IP {
+	switch a := a.(type) {
+	case *net.TCPAddr:
+		return a.IP
+	case *net.UDPAddr:
+		return a.IP
+	case *net.IPAddr:
+		return a.IP
+	default:
+		return nil
+	}
+}
+
+// TenantRouter is a Sink that routes every log message to the Handler of its tenant,
+// so that the messages of different tenants are processed in isolation.
+// Messages of tenants without a route are handed to the default Handler or, if there
+// is none, dropped. A TenantRouter is safe for concurrent use.
+type TenantRouter struct {
+	def    Handler
+	mu     sync.RWMutex
+	routes map[string]Handler
+}
+
+// NewTenantRouter returns a new TenantRouter with the given default Handler, which
+// may be nil
+func NewTenantRouter(def Handler) *TenantRouter {
+	return &TenantRouter{def: def, routes: make(map[string]Handler)}
+}
+
+// Route sets the Handler for the log messages of the given tenant. A nil Handler
+// removes the route.
+func (r *TenantRouter) Route(tenant string, h Handler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if h == nil {
+		delete(r.routes, tenant)
+		return
+	}
+	r.routes[tenant] = h
+}
+
+// Handle hands the log message to the Handler of the tenant of the SourceInfo. It
+// satisfies the Handler interface
+func (r *TenantRouter) Handle(lm parsesyslog.LogMsg, si SourceInfo) {
+	if h := r.route(si.Tenant); h != nil {
+		h.Handle(lm, si)
+	}
+}
+
+// WriteBatch hands the batch of log messages to the Handler of the tenant of the
+// SourceInfo, via WriteBatch if it is a Sink. Batches of tenants without a Handler
+// are dropped and acknowledged. It satisfies the Sink interface
+func (r *TenantRouter) WriteBatch(batch []parsesyslog.LogMsg, si SourceInfo) error {
+	h := r.route(si.Tenant)
+	if h == nil {
+		return nil
+	}
+	return writeBatch(h, batch, si)
+}
+
+// route returns the Handler of the given tenant or the default Handler
+func (r *TenantRouter) route(tenant string) Handler {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if h, ok := r.routes[tenant]; ok {
+		return h
+	}
+	return r.def
+}
@@ -0,0 +1,184 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package listener
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/wneessen/go-parsesyslog"
+	"github.com/wneessen/go-parsesyslog/rfc5424"
+)
+
+// TestTenantMap_Tenant tests the Tenant method of the TenantMap
+func TestTenantMap_Tenant(t *testing.T) {
+	m := NewTenantMap("")
+	for cidr, tenant := range map[string]string{
+		"10.0.0.0/8": "wide", "10.1.0.0/16": "narrow", "2001:db8::/32": "v6",
+	} {
+		if err := m.AddCIDR(cidr, tenant); err != nil {
+			t.Fatalf("AddCIDR() failed: %s", err)
+		}
+	}
+	m.AddSAN("collector.example.com", "dns")
+	m.AddSAN("spiffe://example.com/acme", "uri")
+	cert := &x509.Certificate{
+		DNSNames: []string{"other.example.com", "collector.example.com"},
+	}
+	uri, _ := url.Parse("spiffe://example.com/acme")
+	verified := func(c *x509.Certificate) *tls.ConnectionState {
+		return &tls.ConnectionState{PeerCertificates: []*x509.Certificate{c}, VerifiedChains: [][]*x509.Certificate{{c}}}
+	}
+
+	tests := []struct {
+		name string
+		si   SourceInfo
+		want string
+	}{
+		{"most specific network", SourceInfo{RemoteAddr: &net.TCPAddr{IP: net.ParseIP("10.1.2.3")}}, "narrow"},
+		{"wider network", SourceInfo{RemoteAddr: &net.UDPAddr{IP: net.ParseIP("10.2.2.3")}}, "wide"},
+		{"ipv6 network", SourceInfo{RemoteAddr: &net.TCPAddr{IP: net.ParseIP("2001:db8::1")}}, "v6"},
+		{"dns san", SourceInfo{
+			RemoteAddr: &net.TCPAddr{IP: net.ParseIP("10.1.2.3")}, TLS: verified(cert),
+		}, "dns"},
+		{"uri san", SourceInfo{
+			RemoteAddr: &net.TCPAddr{IP: net.ParseIP("192.0.2.1")},
+			TLS:        verified(&x509.Certificate{URIs: []*url.URL{uri}}),
+		}, "uri"},
+		{"unverified certificate", SourceInfo{
+			RemoteAddr: &net.TCPAddr{IP: net.ParseIP("10.1.2.3")},
+			TLS:        &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}},
+		}, "narrow"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := m.Tenant(tt.si)
+			if err != nil {
+				t.Fatalf("Tenant() failed: %s", err)
+			}
+			if got != tt.want {
+				t.Errorf("Tenant() => expected: %s, got: %s", tt.want, got)
+			}
+		})
+	}
+
+	si := SourceInfo{RemoteAddr: &net.TCPAddr{IP: net.ParseIP("192.0.2.1")}}
+	if _, err := m.Tenant(si); !errors.Is(err, parsesyslog.ErrUnknownTenant) {
+		t.Errorf("Tenant() => expected: %s, got: %v", parsesyslog.ErrUnknownTenant, err)
+	}
+	if got, err := NewTenantMap("default").Tenant(si); err != nil || got != "default" {
+		t.Errorf("Tenant() => expected default tenant, got: %s, %v", got, err)
+	}
+	if err := m.AddCIDR("10.0.0.1", "invalid"); err == nil {
+		t.Errorf("AddCIDR() with invalid network => expected error")
+	}
+}
+
+// TestTenantRouter tests that the TenantRouter routes messages by tenant
+func TestTenantRouter(t *testing.T) {
+	acme, def := newCollector(), newCollector()
+	r := NewTenantRouter(def)
+	r.Route("acme", acme)
+	r.Route("removed", acme)
+	r.Route("removed", nil)
+	for _, tenant := range []string{"acme", "other", "removed", "acme"} {
+		lm := parsesyslog.LogMsg{}
+		lm.Message.WriteString(tenant)
+		r.Handle(lm, SourceInfo{Tenant: tenant})
+	}
+	if len(acme.msgs) != 2 || len(def.msgs) != 2 {
+		t.Errorf("Handle() => expected 2 routed and 2 default messages, got: %v and %v", acme.msgs, def.msgs)
+	}
+	// Without a default Handler, unrouted messages are dropped
+	NewTenantRouter(nil).Handle(parsesyslog.LogMsg{}, SourceInfo{Tenant: "other"})
+}
+
+// TestServer_WithTenantFunc tests a Server that assigns tenants by source address and
+// by client certificate
+func TestServer_WithTenantFunc(t *testing.T) {
+	srvCert, srvX509 := testCert(t, "server")
+	cliCert, cliX509 := testCert(t, "client.example.com")
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(cliX509)
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(srvX509)
+
+	m := NewTenantMap("")
+	m.AddSAN("client.example.com", "acme")
+	if err := m.AddCIDR("127.0.0.0/8", "local"); err != nil {
+		t.Fatalf("AddCIDR() failed: %s", err)
+	}
+	c := newCollector()
+	s := New(rfc5424.Type, c, WithTenantFunc(m.Tenant))
+	addr := serve(t, s)
+	tlsAddr := serveTLS(t, New(rfc5424.Type, c, WithTenantFunc(m.Tenant)), &tls.Config{
+		Certificates: []tls.Certificate{srvCert},
+		ClientAuth:   tls.VerifyClientCertIfGiven,
+		ClientCAs:    clientCAs,
+	})
+
+	msg := "57 <165>1 2003-10-11T22:14:15.003Z mymachine - - - - message"
+	conn, err := net.Dial("tcp", addr.String())
+	if err != nil {
+		t.Fatalf("failed to connect: %s", err)
+	}
+	defer func() { _ = conn.Close() }()
+	if _, err = conn.Write([]byte(msg)); err != nil {
+		t.Fatalf("failed to write: %s", err)
+	}
+	c.wait(t, 1)
+	tc, err := tls.Dial("tcp", tlsAddr.String(), &tls.Config{
+		Certificates: []tls.Certificate{cliCert},
+		RootCAs:      rootCAs,
+		ServerName:   "server",
+	})
+	if err != nil {
+		t.Fatalf("failed to connect: %s", err)
+	}
+	defer func() { _ = tc.Close() }()
+	if _, err = tc.Write([]byte(msg)); err != nil {
+		t.Fatalf("failed to write: %s", err)
+	}
+	c.wait(t, 1)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.srcs[0].Tenant != "local" || c.srcs[1].Tenant != "acme" {
+		t.Errorf("WithTenantFunc() => expected tenants local and acme, got: %s and %s", c.srcs[0].Tenant,
+			c.srcs[1].Tenant)
+	}
+}
+
+// TestServer_WithTenantFunc_unknown tests that senders of unknown tenants are rejected
+func TestServer_WithTenantFunc_unknown(t *testing.T) {
+	c := newCollector()
+	errCh := make(chan error, 10)
+	s := New(rfc5424.Type, c, WithTenantFunc(NewTenantMap("").Tenant),
+		WithErrorHandler(func(err error, _ SourceInfo) { errCh <- err }))
+	addr := serve(t, s)
+
+	conn, err := net.Dial("tcp", addr.String())
+	if err != nil {
+		t.Fatalf("failed to connect: %s", err)
+	}
+	defer func() { _ = conn.Close() }()
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, parsesyslog.ErrUnknownTenant) {
+			t.Errorf("WithTenantFunc() => expected: %s, got: %s", parsesyslog.ErrUnknownTenant, err)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("WithTenantFunc() rejection was not reported")
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second * 5))
+	if _, err := conn.Read(make([]byte, 1)); err == nil {
+		t.Errorf("WithTenantFunc() expected connection to be closed")
+	}
+}
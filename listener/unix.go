@@ -0,0 +1,75 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package listener
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+)
+
+// ListenAndServeUnix listens on a unix stream socket at the given path and serves the
+// accepted connections like Serve does. A stale socket at the path (i. e. of a
+// previous, crashed process) is removed. A socket that is still in use by another
+// process and any other file at the path are left untouched and an error is returned
+// instead.
+func (s *Server) ListenAndServeUnix(path string) error {
+	if err := removeStaleSocket("unix", path); err != nil {
+		return err
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ln)
+}
+
+// ListenAndServeUnixgram listens on a unix datagram socket at the given path (i. e.
+// "/dev/log" or "/run/systemd/journal/syslog") and parses every datagram like
+// ServePacket does. A stale socket at the path is removed before and after serving. A
+// socket that is still in use by another process (i. e. a running syslogd) and any
+// other file at the path are left untouched and an error is returned instead.
+//
+// Note that the local syslog(3) implementation of most C libraries sends messages in
+// the RFC3164 format, but without a hostname.
+func (s *Server) ListenAndServeUnixgram(path string) error {
+	if err := removeStaleSocket("unixgram", path); err != nil {
+		return err
+	}
+	pc, err := net.ListenPacket("unixgram", path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(path) }()
+	return s.ServePacket(pc)
+}
+
+// removeStaleSocket removes the unix socket of the given network at the given path, if
+// no process listens on it anymore, which is detected by the connection being refused.
+// If the path does not exist, nothing is done. If the path is not a socket or a process
+// still listens on it, an error is returned.
+func removeStaleSocket(network, path string) error {
+	fi, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if fi.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s exists and is not a unix socket", path)
+	}
+	c, err := net.Dial(network, path)
+	if err == nil {
+		_ = c.Close()
+		return fmt.Errorf("%s is in use by another process: %w", path, syscall.EADDRINUSE)
+	}
+	if !errors.Is(err, syscall.ECONNREFUSED) {
+		return fmt.Errorf("failed to check if %s is stale: %w", path, err)
+	}
+	return os.Remove(path)
+}
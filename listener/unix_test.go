@@ -0,0 +1,189 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+//go:build !windows
+// +build !windows
+
+package listener
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/wneessen/go-parsesyslog"
+	"github.com/wneessen/go-parsesyslog/rfc3164"
+)
+
+// socketPath returns the path for a unix socket in a new temporary directory. Since
+// the length of socket paths is limited, t.TempDir() is not used.
+func socketPath(t *testing.T) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "psl")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %s", err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	return filepath.Join(dir, "log")
+}
+
+// waitForSocket waits until a unix socket exists at the given path
+func waitForSocket(t *testing.T, path string) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second * 5)
+	for time.Now().Before(deadline) {
+		if fi, err := os.Lstat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("socket %s was not created", path)
+}
+
+// TestServer_ListenAndServeUnixgram tests the ListenAndServeUnixgram method of the Server
+func TestServer_ListenAndServeUnixgram(t *testing.T) {
+	path := socketPath(t)
+	c := newCollector()
+	s := New(rfc3164.Type, c)
+	done := make(chan error, 1)
+	go func() { done <- s.ListenAndServeUnixgram(path) }()
+	waitForSocket(t, path)
+
+	conn, err := net.Dial("unixgram", path)
+	if err != nil {
+		t.Fatalf("failed to connect: %s", err)
+	}
+	defer func() { _ = conn.Close() }()
+	for _, m := range []string{"<34>Oct 11 22:14:15 mymachine su: first\n", "<34>Oct 11 22:14:15 mymachine su: second"} {
+		if _, err := conn.Write([]byte(m)); err != nil {
+			t.Fatalf("failed to write: %s", err)
+		}
+	}
+	c.wait(t, 2)
+	if err := s.Close(); err != nil {
+		t.Errorf("Close() failed: %s", err)
+	}
+	if err := <-done; !errors.Is(err, parsesyslog.ErrServerClosed) {
+		t.Errorf("ListenAndServeUnixgram() after Close() => expected: %s, got: %s",
+			parsesyslog.ErrServerClosed, err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.msgs[0] != "first" || c.msgs[1] != "second" {
+		t.Errorf("ListenAndServeUnixgram() wrong messages: %q", c.msgs)
+	}
+	if c.srcs[0].Network != "unixgram" {
+		t.Errorf("ListenAndServeUnixgram() wrong network => expected: %s, got: %s", "unixgram",
+			c.srcs[0].Network)
+	}
+	if _, err := os.Lstat(path); !os.IsNotExist(err) {
+		t.Errorf("ListenAndServeUnixgram() did not remove the socket")
+	}
+}
+
+// TestServer_ListenAndServeUnix tests the ListenAndServeUnix method of the Server
+func TestServer_ListenAndServeUnix(t *testing.T) {
+	path := socketPath(t)
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("failed to create stale socket: %s", err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	_ = stale.Close()
+
+	c := newCollector()
+	s := New(rfc3164.Type, c)
+	done := make(chan error, 1)
+	go func() { done <- s.ListenAndServeUnix(path) }()
+	defer func() {
+		_ = s.Close()
+		<-done
+	}()
+
+	var conn net.Conn
+	deadline := time.Now().Add(time.Second * 5)
+	for time.Now().Before(deadline) {
+		if conn, err = net.Dial("unix", path); err == nil {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("failed to connect: %s", err)
+	}
+	defer func() { _ = conn.Close() }()
+	if _, err := conn.Write([]byte("<34>Oct 11 22:14:15 mymachine su: first\n")); err != nil {
+		t.Fatalf("failed to write: %s", err)
+	}
+	c.wait(t, 1)
+}
+
+// TestServer_ListenAndServeUnix_inUse tests that a socket that is still in use by another
+// process is not removed
+func TestServer_ListenAndServeUnix_inUse(t *testing.T) {
+	tests := []struct {
+		network string
+		listen  func(path string) (func() error, error)
+		serve   func(s *Server, path string) error
+	}{
+		{
+			"unix",
+			func(path string) (func() error, error) {
+				ln, err := net.Listen("unix", path)
+				if err != nil {
+					return nil, err
+				}
+				return ln.Close, nil
+			},
+			(*Server).ListenAndServeUnix,
+		},
+		{
+			"unixgram",
+			func(path string) (func() error, error) {
+				pc, err := net.ListenPacket("unixgram", path)
+				if err != nil {
+					return nil, err
+				}
+				return pc.Close, nil
+			},
+			(*Server).ListenAndServeUnixgram,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.network, func(t *testing.T) {
+			path := socketPath(t)
+			closeFn, err := tt.listen(path)
+			if err != nil {
+				t.Fatalf("failed to create live socket: %s", err)
+			}
+			defer func() { _ = closeFn() }()
+			s := New(rfc3164.Type, newCollector())
+			if err := tt.serve(s, path); !errors.Is(err, syscall.EADDRINUSE) {
+				t.Errorf("serving on a live socket => expected: %s, got: %v", syscall.EADDRINUSE, err)
+			}
+			if fi, err := os.Lstat(path); err != nil || fi.Mode()&os.ModeSocket == 0 {
+				t.Errorf("serving on a live socket removed the socket")
+			}
+		})
+	}
+}
+
+// TestServer_ListenAndServeUnix_noSocket tests that an existing file is not removed
+func TestServer_ListenAndServeUnix_noSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log")
+	if err := os.WriteFile(path, []byte("data"), 0o600); err != nil {
+		t.Fatalf("failed to create file: %s", err)
+	}
+	s := New(rfc3164.Type, newCollector())
+	if err := s.ListenAndServeUnix(path); err == nil {
+		t.Errorf("ListenAndServeUnix() on regular file expected to fail")
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("ListenAndServeUnix() removed a regular file")
+	}
+}
@@ -0,0 +1,95 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LocationMap maps sending hosts to the time.Location their timestamps are in. Log
+// formats like RFC3164 carry timestamps without any time zone information, so devices
+// in different time zones can only be stamped correctly if their zone is known.
+//
+// A LocationMap is immutable and therefore safe for concurrent use.
+type LocationMap struct {
+	hosts map[string]*time.Location
+	nets  []locationNet
+}
+
+// locationNet represents a network entry of a LocationMap
+type locationNet struct {
+	loc *time.Location
+	net *net.IPNet
+}
+
+// NewLocationMap returns a new LocationMap for the given entries. The keys of the
+// entries are either hostnames, IP addresses or networks in CIDR notation (i. e.
+// "10.1.0.0/16"). Hostnames are matched case-insensitive. An error is returned if a
+// key looks like a network, but is not a valid CIDR or if a time.Location is nil.
+func NewLocationMap(entries map[string]*time.Location) (*LocationMap, error) {
+	m := &LocationMap{hosts: make(map[string]*time.Location)}
+	for k, loc := range entries {
+		if loc == nil {
+			return nil, fmt.Errorf("no location given for %q", k)
+		}
+		if strings.Contains(k, "/") {
+			_, n, err := net.ParseCIDR(k)
+			if err != nil {
+				return nil, fmt.Errorf("invalid network %q: %w", k, err)
+			}
+			m.nets = append(m.nets, locationNet{loc: loc, net: n})
+			continue
+		}
+		if ip := net.ParseIP(k); ip != nil {
+			k = ip.String()
+		}
+		m.hosts[strings.ToLower(k)] = loc
+	}
+
+	// The most specific network takes precedence
+	sort.Slice(m.nets, func(i, j int) bool {
+		oi, _ := m.nets[i].net.Mask.Size()
+		oj, _ := m.nets[j].net.Mask.Size()
+		return oi > oj
+	})
+	return m, nil
+}
+
+// Lookup returns the time.Location of the given host. Exact matches of a hostname or
+// IP address take precedence over networks, of which the most specific one matches.
+// The returned bool is false if the host is not covered by the LocationMap.
+func (m *LocationMap) Lookup(host string) (*time.Location, bool) {
+	if m == nil || host == "" {
+		return nil, false
+	}
+	if loc, ok := m.hosts[host]; ok {
+		return loc, true
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		loc, ok := m.hosts[strings.ToLower(host)]
+		return loc, ok
+	}
+	if loc, ok := m.hosts[ip.String()]; ok {
+		return loc, true
+	}
+	for _, n := range m.nets {
+		if n.net.Contains(ip) {
+			return n.loc, true
+		}
+	}
+	return nil, false
+}
+
+// InLocation returns a time.Time with the same wall clock as t, but in the given
+// time.Location. It is used to re-interpret a timestamp that has been parsed without
+// time zone information.
+func InLocation(t time.Time, loc *time.Location) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+}
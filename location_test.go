@@ -0,0 +1,80 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"testing"
+	"time"
+)
+
+// TestLocationMap_Lookup tests the Lookup method of the LocationMap
+func TestLocationMap_Lookup(t *testing.T) {
+	berlin := time.FixedZone("CET", 3600)
+	tokyo := time.FixedZone("JST", 9*3600)
+	ny := time.FixedZone("EST", -5*3600)
+	m, err := NewLocationMap(map[string]*time.Location{
+		"Router1":       berlin,
+		"10.0.0.0/8":    tokyo,
+		"10.1.0.0/16":   ny,
+		"10.1.2.3":      berlin,
+		"2001:db8::/32": ny,
+	})
+	if err != nil {
+		t.Fatalf("NewLocationMap() failed: %s", err)
+	}
+	tests := []struct {
+		name   string
+		host   string
+		want   *time.Location
+		wantOK bool
+	}{
+		{"hostname", "Router1", berlin, true},
+		{"hostname case-insensitive", "router1", berlin, true},
+		{"exact IP", "10.1.2.3", berlin, true},
+		{"most specific network", "10.1.2.4", ny, true},
+		{"network", "10.2.0.1", tokyo, true},
+		{"IPv6 network", "2001:db8::1", ny, true},
+		{"unknown host", "router2", nil, false},
+		{"unknown IP", "192.168.0.1", nil, false},
+		{"empty", "", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loc, ok := m.Lookup(tt.host)
+			if ok != tt.wantOK {
+				t.Errorf("Lookup() ok => expected: %t, got: %t", tt.wantOK, ok)
+			}
+			if loc != tt.want {
+				t.Errorf("Lookup() => expected: %s, got: %s", tt.want, loc)
+			}
+		})
+	}
+	var nm *LocationMap
+	if _, ok := nm.Lookup("router1"); ok {
+		t.Errorf("Lookup() on nil LocationMap expected to fail")
+	}
+}
+
+// TestNewLocationMap_invalid tests the NewLocationMap method with invalid entries
+func TestNewLocationMap_invalid(t *testing.T) {
+	if _, err := NewLocationMap(map[string]*time.Location{"10.0.0.0/33": time.UTC}); err == nil {
+		t.Errorf("NewLocationMap() with invalid CIDR expected to fail")
+	}
+	if _, err := NewLocationMap(map[string]*time.Location{"router1": nil}); err == nil {
+		t.Errorf("NewLocationMap() with nil location expected to fail")
+	}
+}
+
+// TestInLocation tests the InLocation method
+func TestInLocation(t *testing.T) {
+	loc := time.FixedZone("CET", 3600)
+	ts := InLocation(time.Date(2023, 10, 11, 22, 14, 15, 0, time.UTC), loc)
+	if ts.Hour() != 22 || ts.Location() != loc {
+		t.Errorf("InLocation() => unexpected time: %s", ts)
+	}
+	if !ts.Equal(time.Date(2023, 10, 11, 21, 14, 15, 0, time.UTC)) {
+		t.Errorf("InLocation() => wrong instant: %s", ts.UTC())
+	}
+}
@@ -0,0 +1,93 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// LogfmtSDID is the SD-ID of the structured data element that holds the pairs of a
+// logfmt message body
+const LogfmtSDID = "logfmt@32473"
+
+// ParseLogfmt parses the given logfmt line (i. e. `level=info msg="user logged in"
+// user=root`) and returns its key/value pairs in the order of the line. Quoted values
+// are unescaped like Go string literals, as written by the common logfmt encoders.
+// Unlike ParseKeyValues, every key needs to be followed by a "=" and a value, so that
+// ordinary text is not mistaken for a logfmt line. If the line is not a logfmt line,
+// ErrWrongFormat is returned.
+// See: https://brandur.org/logfmt
+func ParseLogfmt(s string) ([]StructuredDataParam, error) {
+	var ps []StructuredDataParam
+	i := 0
+	for {
+		for i < len(s) && isKVSpace(s[i]) {
+			i++
+		}
+		if i >= len(s) {
+			if len(ps) == 0 {
+				return nil, fmt.Errorf("%w: empty logfmt line", ErrWrongFormat)
+			}
+			return ps, nil
+		}
+		ks := i
+		for i < len(s) && s[i] > ' ' && s[i] != '=' && s[i] != '"' {
+			i++
+		}
+		if i == ks || i >= len(s) || s[i] != '=' {
+			return nil, fmt.Errorf("%w: invalid logfmt key at position %d", ErrWrongFormat, ks)
+		}
+		p := StructuredDataParam{Name: s[ks:i]}
+		i++
+		if i < len(s) && s[i] == '"' {
+			vs := i
+			for i++; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' {
+					i++
+				}
+			}
+			if i >= len(s) {
+				return nil, fmt.Errorf("%w: unterminated value of key %q", ErrWrongFormat, p.Name)
+			}
+			i++
+			v, err := strconv.Unquote(s[vs:i])
+			if err != nil {
+				return nil, fmt.Errorf("%w: invalid value of key %q: %s", ErrWrongFormat, p.Name, err)
+			}
+			p.Value = v
+		} else {
+			vs := i
+			for i < len(s) && !isKVSpace(s[i]) {
+				if s[i] == '"' {
+					return nil, fmt.Errorf("%w: quote in value of key %q", ErrWrongFormat, p.Name)
+				}
+				i++
+			}
+			p.Value = s[vs:i]
+		}
+		ps = append(ps, p)
+	}
+}
+
+// DecodeLogfmt decodes a logfmt message body and stores its pairs as structured data
+// element with the SD-ID LogfmtSDID. A leading BOM is ignored. Message bodies that are not a logfmt line are not
+// recognized. DecodeLogfmt satisfies the BodyDecoder type.
+func DecodeLogfmt(lm *LogMsg) bool {
+	ps, err := ParseLogfmt(strings.TrimPrefix(lm.Message.String(), "\ufeff"))
+	if err != nil {
+		return false
+	}
+	lm.StructuredData = append(lm.StructuredData, StructuredDataElement{ID: LogfmtSDID, Param: ps})
+	return true
+}
+
+// WithLogfmt makes the parser decode logfmt message bodies into a structured data
+// element with the SD-ID LogfmtSDID. It adds DecodeLogfmt to the BodyDecoders, so that
+// vendor specific BodyDecoders given before take precedence.
+func WithLogfmt() Option {
+	return WithBodyDecoders(DecodeLogfmt)
+}
@@ -0,0 +1,74 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// TestParseLogfmt tests the ParseLogfmt function
+func TestParseLogfmt(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want []StructuredDataParam
+		err  error
+	}{
+		{
+			"simple", "level=info msg=started port=8080\n",
+			[]StructuredDataParam{{"level", "info"}, {"msg", "started"}, {"port", "8080"}}, nil,
+		},
+		{
+			"quoted", `msg="user \"root\" logged in\n" path="C:\\tmp" unicode="\u00e4"`,
+			[]StructuredDataParam{{"msg", "user \"root\" logged in\n"}, {"path", `C:\tmp`}, {"unicode", "ä"}}, nil,
+		},
+		{"empty values", `a= b=""`, []StructuredDataParam{{"a", ""}, {"b", ""}}, nil},
+		{"dotted keys", "http.status=200", []StructuredDataParam{{"http.status", "200"}}, nil},
+		{"plain text", "user root logged in", nil, ErrWrongFormat},
+		{"text with pair", "user logged in level=info", nil, ErrWrongFormat},
+		{"unterminated quote", `msg="open`, nil, ErrWrongFormat},
+		{"quote in value", `msg=a"b`, nil, ErrWrongFormat},
+		{"invalid escape", `msg="\q"`, nil, ErrWrongFormat},
+		{"empty key", "=value", nil, ErrWrongFormat},
+		{"empty", " \n", nil, ErrWrongFormat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ps, err := ParseLogfmt(tt.s)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ParseLogfmt() error => expected: %v, got: %v", tt.err, err)
+			}
+			if !reflect.DeepEqual(ps, tt.want) {
+				t.Errorf("ParseLogfmt() => expected: %v, got: %v", tt.want, ps)
+			}
+		})
+	}
+}
+
+// TestWithLogfmt tests that WithLogfmt decodes logfmt bodies
+func TestWithLogfmt(t *testing.T) {
+	o := NewOptions(WithLogfmt())
+	var lm LogMsg
+	lm.Message.WriteString(`level=warn msg="disk almost full"`)
+	o.PostProcess(&lm)
+	if len(lm.StructuredData) != 1 || lm.StructuredData[0].ID != LogfmtSDID ||
+		len(lm.StructuredData[0].Param) != 2 {
+		t.Fatalf("PostProcess() => unexpected structured data: %+v", lm.StructuredData)
+	}
+	lm = LogMsg{}
+	lm.Message.WriteString("\xef\xbb\xbflevel=warn")
+	o.PostProcess(&lm)
+	if len(lm.StructuredData) != 1 || lm.StructuredData[0].Param[0].Name != "level" {
+		t.Errorf("PostProcess() => expected BOM to be ignored, got: %+v", lm.StructuredData)
+	}
+	lm = LogMsg{}
+	lm.Message.WriteString("disk almost full")
+	o.PostProcess(&lm)
+	if len(lm.StructuredData) != 0 {
+		t.Errorf("PostProcess() => expected no structured data for plain text, got: %+v", lm.StructuredData)
+	}
+}
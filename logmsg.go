@@ -6,6 +6,9 @@ package parsesyslog
 
 import (
 	"bytes"
+	"io"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -16,22 +19,66 @@ const (
 )
 
 // LogMsg represents a parsed syslog message
+//
+// The elements of StructuredData, as well as the params of each element, are guaranteed
+// to be in the same order as they appeared in the original message (wire order).
 type LogMsg struct {
-	AppName  string
-	Facility Facility
-	HasBOM   bool
-	Hostname string
+	AppName string
+	// Body is the MSG part of the message as io.Reader, if the parser streams the
+	// message body instead of buffering it in Message (see WithStreamingBody). It reads
+	// from the underlying io.Reader of the parser and is only valid until the next
+	// message is parsed. It is nil otherwise.
+	Body io.Reader
+	// ExtraBytes is the number of bytes of the Message that exceeded the octet count of
+	// the log message (see WithOctetCountPolicy)
+	ExtraBytes int
+	Facility   Facility
+	HasBOM     bool
+	Hostname   string
 	// Message        []byte
-	Message        bytes.Buffer
-	MsgLength      int
-	MsgID          string
-	Priority       Priority
-	ProcID         string
-	ProtoVersion   ProtoVersion
+	Message   bytes.Buffer
+	MsgLength int
+	MsgID     string
+	// OriginalSeverity is the Severity of the PRI of the message, if the Severity has been
+	// overridden by a SeverityMapper or a PriorityPolicy. It is nil otherwise.
+	OriginalSeverity *Severity
+	Priority         Priority
+	ProcID           string
+	ProtoVersion     ProtoVersion
+	// Relaxed holds the deviations from the log format that the parser accepted in
+	// lenient mode (see WithLenient) or with a default priority (see WithDefaultPriority)
+	Relaxed        Relaxation
+	ResolvedHost   string
 	Severity       Severity
+	SpanID         string
 	StructuredData []StructuredDataElement
 	Timestamp      time.Time
-	Type           LogMsgType
+	TraceID        string
+	TraceState     string
+	// Truncated is true if the message has been truncated, because it exceeded the
+	// maximum message length of the parser (see WithMaxLength)
+	Truncated bool
+	Type      LogMsgType
+	// Wire holds the original text of the parts of the message that are normalized by
+	// the parser. It is only populated by parsers in round-trip mode (see WithRoundTrip).
+	Wire *WireFormat
+
+	// aliased is true if the strings or the Message reference the input of the parser
+	// (see MarkZeroCopy and SetMessageRef), so that Clone has to copy them and the
+	// storage of the Message must not be reused
+	aliased bool
+}
+
+// WireFormat holds the original text of the parts of a log message that are normalized
+// by the parser, so that marshalers can reproduce the message byte-exact. Marshalers
+// only use a part if it still matches the corresponding field of the LogMsg, so that
+// changes to the LogMsg take precedence.
+type WireFormat struct {
+	// StructuredData is the STRUCTURED-DATA part of the header, as it was received
+	StructuredData string
+	// Timestamp is the TIMESTAMP part of the header, as it was received. It is empty if
+	// the message had no timestamp.
+	Timestamp string
 }
 
 // LogMsgType represents the type of message
@@ -41,7 +88,7 @@ type LogMsgType string
 type ProtoVersion int
 
 // StructuredDataElement represents a structured data elements as defined in
-// RFC5424
+// RFC5424. The params are kept in wire order.
 // See: https://datatracker.ietf.org/doc/html/rfc5424#section-6.3.1
 type StructuredDataElement struct {
 	ID    string
@@ -55,3 +102,230 @@ type StructuredDataParam struct {
 	Name  string
 	Value string
 }
+
+// SDAt returns the structured data element at position i (in wire order) of the LogMsg.
+// The returned bool is false if there is no element at the given position.
+func (l *LogMsg) SDAt(i int) (StructuredDataElement, bool) {
+	if i < 0 || i >= len(l.StructuredData) {
+		return StructuredDataElement{}, false
+	}
+	return l.StructuredData[i], true
+}
+
+// SD returns the first structured data element of the LogMsg with the given SD-ID. The
+// returned bool is false if the LogMsg has no such element.
+func (l *LogMsg) SD(id string) (StructuredDataElement, bool) {
+	for _, e := range l.StructuredData {
+		if e.ID == id {
+			return e, true
+		}
+	}
+	return StructuredDataElement{}, false
+}
+
+// SDParam returns the value of the param with the given name of the structured data
+// element with the given SD-ID. If the SD-ID occurs multiple times, the first matching
+// param is returned. The returned bool is false if there is no such param.
+func (l *LogMsg) SDParam(id, name string) (string, bool) {
+	for _, e := range l.StructuredData {
+		if e.ID != id {
+			continue
+		}
+		if v, ok := e.Value(name); ok {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// StructuredDataMap returns the structured data of the LogMsg as map of SD-IDs to maps of
+// param names to values. Elements without params are included with an empty map. As with
+// SDParam, the first value wins if an SD-ID or a param name occurs multiple times, so the
+// map is lossy for such log messages. It returns nil if the LogMsg has no structured data.
+func (l *LogMsg) StructuredDataMap() map[string]map[string]string {
+	if len(l.StructuredData) == 0 {
+		return nil
+	}
+	m := make(map[string]map[string]string, len(l.StructuredData))
+	for _, e := range l.StructuredData {
+		ps, ok := m[e.ID]
+		if !ok {
+			ps = make(map[string]string, len(e.Param))
+			m[e.ID] = ps
+		}
+		for _, p := range e.Param {
+			if _, ok := ps[p.Name]; !ok {
+				ps[p.Name] = p.Value
+			}
+		}
+	}
+	return m
+}
+
+// Value returns the value of the first param with the given name of the
+// StructuredDataElement. The returned bool is false if there is no such param.
+func (e StructuredDataElement) Value(name string) (string, bool) {
+	for _, p := range e.Param {
+		if p.Name == name {
+			return p.Value, true
+		}
+	}
+	return "", false
+}
+
+// ParamAt returns the structured data param at position i (in wire order) of the
+// StructuredDataElement. The returned bool is false if there is no param at the given
+// position.
+func (e StructuredDataElement) ParamAt(i int) (StructuredDataParam, bool) {
+	if i < 0 || i >= len(e.Param) {
+		return StructuredDataParam{}, false
+	}
+	return e.Param[i], true
+}
+
+// String returns a deterministic, human-readable one-line representation of the LogMsg
+// for debugging and logging, i. e.:
+//
+//	<165> LOCAL4.NOTICE 2003-10-11T22:14:15.003Z mymachine evntslog[42] ID47 [id@32473 a="1"] message
+//
+// The fields are the PRI, the names of the facility and severity, the timestamp (in
+// RFC3339 format with the original time zone offset), the hostname, the app name with
+// the process ID, the message ID, the structured data in wire order (with the param
+// values escaped as in RFC5424) and the message. Empty fields are rendered as "-". A BOM
+// at the start of the message is left out, line breaks in the message are escaped as
+// "\n" and "\r". It satisfies the fmt.Stringer interface.
+func (l LogMsg) String() string {
+	var sb strings.Builder
+	nilOr := func(s string) string {
+		if s == "" {
+			return "-"
+		}
+		return s
+	}
+	sb.WriteString("<" + strconv.Itoa(int(l.Priority)) + "> " + l.Facility.String() + "." + l.Severity.String() + " ")
+	if l.Timestamp.IsZero() {
+		sb.WriteString("- ")
+	} else {
+		sb.WriteString(l.Timestamp.Format(time.RFC3339Nano) + " ")
+	}
+	sb.WriteString(nilOr(l.Hostname) + " " + nilOr(l.AppName))
+	if l.ProcID != "" {
+		sb.WriteString("[" + l.ProcID + "]")
+	}
+	sb.WriteString(" " + nilOr(l.MsgID) + " ")
+	if len(l.StructuredData) == 0 {
+		sb.WriteString("-")
+	}
+	for _, e := range l.StructuredData {
+		writeSDElement(&sb, e)
+	}
+	msg := bytes.TrimPrefix(l.Message.Bytes(), []byte{0xEF, 0xBB, 0xBF})
+	if len(msg) > 0 {
+		sb.WriteString(" " + lineBreakEscaper.Replace(string(msg)))
+	}
+	return sb.String()
+}
+
+// sdValueEscaper escapes the characters of a structured data param value that need to
+// be escaped as described in RFC5424
+// See: https://datatracker.ietf.org/doc/html/rfc5424#section-6.3.3
+var sdValueEscaper = strings.NewReplacer(`"`, `\"`, `\`, `\\`, `]`, `\]`)
+
+// writeSDElement writes the given structured data element to the strings.Builder, with
+// the param values escaped as in RFC5424
+func writeSDElement(sb *strings.Builder, e StructuredDataElement) {
+	sb.WriteString("[" + e.ID)
+	for _, p := range e.Param {
+		sb.WriteString(" " + p.Name + `="` + sdValueEscaper.Replace(p.Value) + `"`)
+	}
+	sb.WriteString("]")
+}
+
+// lineBreakEscaper escapes the line breaks of a message for String
+var lineBreakEscaper = strings.NewReplacer("\n", `\n`, "\r", `\r`)
+
+// Reset resets the LogMsg to its zero value, but keeps the underlying storage of the
+// Message buffer and the StructuredData slices for reuse by a ReusingParser. The Message
+// of a LogMsg that references the input of the parser (see MarkZeroCopy) is replaced by
+// a new buffer instead, so that the reuse never writes into the input.
+func (l *LogMsg) Reset() {
+	if l.aliased {
+		l.Message = bytes.Buffer{}
+	}
+	l.Message.Reset()
+	*l = LogMsg{Message: l.Message, StructuredData: l.StructuredData[:0]}
+}
+
+// SetMessageRef sets the Message of the LogMsg to the given byte slice without copying
+// it, as parsers do in zero-copy mode (see WithZeroCopy). The capacity of the byte slice
+// is limited to its length, so that writes to the Message do not overwrite the bytes
+// that follow it, and Reset and Clone never reuse its storage.
+func (l *LogMsg) SetMessageRef(b []byte) {
+	l.Message = *bytes.NewBuffer(b[:len(b):len(b)])
+	l.aliased = true
+}
+
+// MarkZeroCopy marks the LogMsg as parsed in zero-copy mode (see WithZeroCopy), in which
+// its strings reference the input of the parser. Clone copies the strings of such a
+// LogMsg, so that the clone does not reference the input anymore.
+func (l *LogMsg) MarkZeroCopy() {
+	l.aliased = true
+}
+
+// Clone returns a deep copy of the LogMsg that does not share any storage with the
+// original. LogMsgs that have been parsed by a ReusingParser need to be cloned if they
+// are retained beyond the next parse call, LogMsgs that have been parsed in zero-copy
+// mode if they are retained beyond the lifetime of the input.
+func (l *LogMsg) Clone() LogMsg {
+	c := *l
+	c.Message = bytes.Buffer{}
+	c.Message.Write(l.Message.Bytes())
+	c.aliased = false
+	if l.aliased {
+		c.AppName = cloneString(l.AppName)
+		c.Hostname = cloneString(l.Hostname)
+		c.MsgID = cloneString(l.MsgID)
+		c.ProcID = cloneString(l.ProcID)
+		c.ResolvedHost = cloneString(l.ResolvedHost)
+		c.SpanID = cloneString(l.SpanID)
+		c.TraceID = cloneString(l.TraceID)
+		c.TraceState = cloneString(l.TraceState)
+	}
+	if l.OriginalSeverity != nil {
+		s := *l.OriginalSeverity
+		c.OriginalSeverity = &s
+	}
+	if l.Wire != nil {
+		w := *l.Wire
+		c.Wire = &w
+	}
+	c.StructuredData = nil
+	if l.StructuredData != nil {
+		c.StructuredData = make([]StructuredDataElement, len(l.StructuredData))
+		for i, e := range l.StructuredData {
+			c.StructuredData[i] = StructuredDataElement{ID: e.ID}
+			if e.Param != nil {
+				c.StructuredData[i].Param = append([]StructuredDataParam(nil), e.Param...)
+			}
+			if !l.aliased {
+				continue
+			}
+			c.StructuredData[i].ID = cloneString(e.ID)
+			for j, p := range e.Param {
+				c.StructuredData[i].Param[j] = StructuredDataParam{Name: cloneString(p.Name),
+					Value: cloneString(p.Value)}
+			}
+		}
+	}
+	return c
+}
+
+// cloneString returns a copy of the given string that does not share its memory
+func cloneString(s string) string {
+	if s == "" {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString(s)
+	return sb.String()
+}
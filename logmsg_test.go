@@ -0,0 +1,219 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestLogMsg_SDAt tests the SDAt method of the LogMsg and the ParamAt method of the
+// StructuredDataElement
+func TestLogMsg_SDAt(t *testing.T) {
+	lm := LogMsg{StructuredData: []StructuredDataElement{
+		{ID: "foo@1234", Param: []StructuredDataParam{{Name: "b", Value: "1"}, {Name: "a", Value: "2"}}},
+		{ID: "bar@1234"},
+	}}
+	tests := []struct {
+		name      string
+		elem      int
+		param     int
+		wantID    string
+		wantParam string
+		wantOK    bool
+		wantPOK   bool
+	}{
+		{"first element, first param", 0, 0, "foo@1234", "b", true, true},
+		{"first element, second param", 0, 1, "foo@1234", "a", true, true},
+		{"first element, missing param", 0, 2, "foo@1234", "", true, false},
+		{"second element without params", 1, 0, "bar@1234", "", true, false},
+		{"negative element", -1, 0, "", "", false, false},
+		{"missing element", 2, 0, "", "", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, ok := lm.SDAt(tt.elem)
+			if ok != tt.wantOK {
+				t.Errorf("SDAt() ok => expected: %t, got: %t", tt.wantOK, ok)
+			}
+			if e.ID != tt.wantID {
+				t.Errorf("SDAt() ID => expected: %s, got: %s", tt.wantID, e.ID)
+			}
+			p, ok := e.ParamAt(tt.param)
+			if ok != tt.wantPOK {
+				t.Errorf("ParamAt() ok => expected: %t, got: %t", tt.wantPOK, ok)
+			}
+			if p.Name != tt.wantParam {
+				t.Errorf("ParamAt() name => expected: %s, got: %s", tt.wantParam, p.Name)
+			}
+		})
+	}
+}
+
+// TestLogMsg_SDParam tests the SD and SDParam methods of the LogMsg
+func TestLogMsg_SDParam(t *testing.T) {
+	lm := LogMsg{StructuredData: []StructuredDataElement{
+		{ID: "foo@1234", Param: []StructuredDataParam{{Name: "a", Value: "1"}, {Name: "a", Value: "2"}}},
+		{ID: "bar@1234"},
+		{ID: "foo@1234", Param: []StructuredDataParam{{Name: "b", Value: "3"}}},
+	}}
+	tests := []struct {
+		name      string
+		id        string
+		param     string
+		wantValue string
+		wantOK    bool
+		wantPOK   bool
+	}{
+		{"first param", "foo@1234", "a", "1", true, true},
+		{"param of repeated SD-ID", "foo@1234", "b", "3", true, true},
+		{"missing param", "foo@1234", "c", "", true, false},
+		{"element without params", "bar@1234", "a", "", true, false},
+		{"missing element", "baz@1234", "a", "", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, ok := lm.SD(tt.id)
+			if ok != tt.wantOK {
+				t.Errorf("SD() ok => expected: %t, got: %t", tt.wantOK, ok)
+			}
+			if ok && e.ID != tt.id {
+				t.Errorf("SD() ID => expected: %s, got: %s", tt.id, e.ID)
+			}
+			v, ok := lm.SDParam(tt.id, tt.param)
+			if ok != tt.wantPOK {
+				t.Errorf("SDParam() ok => expected: %t, got: %t", tt.wantPOK, ok)
+			}
+			if v != tt.wantValue {
+				t.Errorf("SDParam() => expected: %q, got: %q", tt.wantValue, v)
+			}
+		})
+	}
+}
+
+// TestLogMsg_StructuredDataMap tests the StructuredDataMap method of the LogMsg
+func TestLogMsg_StructuredDataMap(t *testing.T) {
+	tests := []struct {
+		name string
+		sd   []StructuredDataElement
+		want map[string]map[string]string
+	}{
+		{"no structured data", nil, nil},
+		{
+			"single element", []StructuredDataElement{
+				{ID: "foo@1234", Param: []StructuredDataParam{{Name: "a", Value: "1"}, {Name: "b", Value: "2"}}},
+			},
+			map[string]map[string]string{"foo@1234": {"a": "1", "b": "2"}},
+		},
+		{
+			"element without params", []StructuredDataElement{{ID: "bar@1234"}},
+			map[string]map[string]string{"bar@1234": {}},
+		},
+		{
+			"repeated SD-ID and param", []StructuredDataElement{
+				{ID: "foo@1234", Param: []StructuredDataParam{{Name: "a", Value: "1"}, {Name: "a", Value: "2"}}},
+				{ID: "foo@1234", Param: []StructuredDataParam{{Name: "a", Value: "3"}, {Name: "b", Value: "4"}}},
+			},
+			map[string]map[string]string{"foo@1234": {"a": "1", "b": "4"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lm := LogMsg{StructuredData: tt.sd}
+			got := lm.StructuredDataMap()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("StructuredDataMap() => expected: %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestLogMsg_ResetClone tests the Reset and Clone methods of the LogMsg
+func TestLogMsg_ResetClone(t *testing.T) {
+	lm := LogMsg{Hostname: "host", StructuredData: []StructuredDataElement{
+		{ID: "foo@1234", Param: []StructuredDataParam{{Name: "a", Value: "b"}}},
+	}}
+	lm.Message.WriteString("Hello, World!")
+
+	c := lm.Clone()
+	lm.StructuredData[0].Param[0].Value = "changed"
+	lm.Message.Reset()
+	lm.Message.WriteString("changed")
+	if c.StructuredData[0].Param[0].Value != "b" || c.Message.String() != "Hello, World!" {
+		t.Errorf("Clone() shares storage with the original: %+v", c)
+	}
+
+	sdcap := cap(lm.StructuredData)
+	lm.Reset()
+	if lm.Hostname != "" || lm.Message.Len() != 0 || len(lm.StructuredData) != 0 {
+		t.Errorf("Reset() did not reset the LogMsg: %+v", lm)
+	}
+	if cap(lm.StructuredData) != sdcap || lm.Message.Cap() == 0 {
+		t.Errorf("Reset() did not keep the underlying storage")
+	}
+}
+
+// TestLogMsg_SetMessageRef tests that the Reset and Clone methods of a LogMsg whose
+// Message references a byte slice never write into the byte slice
+func TestLogMsg_SetMessageRef(t *testing.T) {
+	b := []byte("Hello, World!")
+	var lm LogMsg
+	lm.SetMessageRef(b[:5])
+	if lm.Message.String() != "Hello" {
+		t.Errorf("SetMessageRef() => expected message: %q, got: %q", "Hello", lm.Message.String())
+	}
+	lm.Message.WriteString("!!!")
+	c := lm.Clone()
+	c.Message.Reset()
+	c.Message.WriteString("clone")
+	lm.Reset()
+	lm.Message.WriteString("reset")
+	if string(b) != "Hello, World!" {
+		t.Errorf("SetMessageRef() => the byte slice has been overwritten: %q", b)
+	}
+	if lm.Message.String() != "reset" {
+		t.Errorf("Reset() => expected message: %q, got: %q", "reset", lm.Message.String())
+	}
+}
+
+// TestLogMsg_String tests the String method of the LogMsg
+func TestLogMsg_String(t *testing.T) {
+	full := LogMsg{
+		Priority: 165, Facility: 20, Severity: 5, Hostname: "mymachine", AppName: "evntslog", ProcID: "42",
+		MsgID: "ID47", Timestamp: time.Date(2003, 10, 11, 22, 14, 15, 3000000, time.FixedZone("", -7*3600)),
+		StructuredData: []StructuredDataElement{
+			{ID: "exampleSDID@32473", Param: []StructuredDataParam{{"iut", "3"}, {"quote", `a "b" [c\]`}}},
+			{ID: "empty@1"},
+		},
+	}
+	full.Message.WriteString("\xEF\xBB\xBFAn application\nevent log entry\r")
+	var empty LogMsg
+	empty.Message.WriteString("just a message")
+
+	tests := []struct {
+		name string
+		lm   *LogMsg
+		want string
+	}{
+		{
+			"full", &full, `<165> LOCAL4.NOTICE 2003-10-11T22:14:15.003-07:00 mymachine evntslog[42] ID47 ` +
+				`[exampleSDID@32473 iut="3" quote="a \"b\" [c\\\]"][empty@1] An application\nevent log entry\r`,
+		},
+		{"empty", &empty, "<0> KERN.EMERGENCY - - - - - just a message"},
+		{"zero", &LogMsg{}, "<0> KERN.EMERGENCY - - - - -"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.lm.String(); got != tt.want {
+				t.Errorf("String() => expected: %s, got: %s", tt.want, got)
+			}
+			if got := fmt.Sprint(*tt.lm); got != tt.want {
+				t.Errorf("fmt.Sprint() => expected: %s, got: %s", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Merger reads log messages from several sources concurrently and returns them in
+// global timestamp order. This is useful to reconstruct a timeline from the log files
+// of multiple collectors.
+//
+// Each source is expected to yield its messages in timestamp order (as log files
+// usually do); the Merger then performs a k-way merge of the sources. Since the
+// oldest message can only be determined once every source has a message pending,
+// a slow source holds back the messages of all other sources. Messages with equal
+// timestamps are returned in the order of their sources.
+//
+// A Merger is not safe for concurrent use.
+type Merger struct {
+	done  chan struct{}
+	heads []*LogMsg
+	once  sync.Once
+	srcs  []chan mergeResult
+}
+
+// mergeResult is the result of a single read from a Merger source
+type mergeResult struct {
+	err error
+	lm  LogMsg
+}
+
+// NewMerger returns a new Merger for the given sources. A source is a function that
+// yields the next log message on every call and returns io.EOF once it is exhausted,
+// like the function returned by ParseStream or the Next method of a StreamParser.
+// Every source is read in its own goroutine, which is stopped once the source returns
+// io.EOF, an error that is not a parse error (see ClassifyError) or Close is called.
+func NewMerger(sources ...func() (LogMsg, error)) *Merger {
+	m := &Merger{
+		done:  make(chan struct{}),
+		heads: make([]*LogMsg, len(sources)),
+		srcs:  make([]chan mergeResult, len(sources)),
+	}
+	for i, s := range sources {
+		m.srcs[i] = make(chan mergeResult, 1)
+		go m.read(s, m.srcs[i])
+	}
+	return m
+}
+
+// Next returns the oldest log message of all sources. Parse errors of a source are
+// returned (wrapped with the index of the source) while reading from the other
+// sources continues on the next call. Once all sources are exhausted, io.EOF is
+// returned.
+func (m *Merger) Next() (LogMsg, error) {
+	for i, ch := range m.srcs {
+		if ch == nil || m.heads[i] != nil {
+			continue
+		}
+		select {
+		case r, ok := <-ch:
+			if !ok {
+				m.srcs[i] = nil
+				continue
+			}
+			if r.err != nil {
+				return LogMsg{}, fmt.Errorf("merge source %d: %w", i, r.err)
+			}
+			lm := r.lm
+			m.heads[i] = &lm
+		case <-m.done:
+			return LogMsg{}, io.EOF
+		}
+	}
+
+	oldest := -1
+	for i, h := range m.heads {
+		if h == nil {
+			continue
+		}
+		if oldest < 0 || h.Timestamp.Before(m.heads[oldest].Timestamp) {
+			oldest = i
+		}
+	}
+	if oldest < 0 {
+		return LogMsg{}, io.EOF
+	}
+	lm := *m.heads[oldest]
+	m.heads[oldest] = nil
+	return lm, nil
+}
+
+// Close stops reading from all sources. Sources that are blocked in a read are
+// only stopped once that read returns
+func (m *Merger) Close() {
+	m.once.Do(func() {
+		close(m.done)
+	})
+}
+
+// read reads the log messages from the given source and hands them to the channel
+// until the source is exhausted or the Merger is closed
+func (m *Merger) read(next func() (LogMsg, error), ch chan<- mergeResult) {
+	defer close(ch)
+	for {
+		lm, err := next()
+		if errors.Is(err, io.EOF) {
+			return
+		}
+		select {
+		case ch <- mergeResult{err: err, lm: lm}:
+		case <-m.done:
+			return
+		}
+		if err != nil && errors.Is(ClassifyError(err), ErrUnclassified) {
+			return
+		}
+	}
+}
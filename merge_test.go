@@ -0,0 +1,84 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// timeParser is a simple Parser that reads lines in the format "<RFC3339 timestamp> <message>"
+type timeParser struct {
+	lineParser
+}
+
+func (p timeParser) ParseReader(r io.Reader) (LogMsg, error) {
+	l, err := p.lineParser.ParseReader(r)
+	if err != nil {
+		return l, err
+	}
+	f := strings.SplitN(l.Message.String(), " ", 2)
+	ts, err := time.Parse(time.RFC3339, f[0])
+	if err != nil || len(f) != 2 {
+		return LogMsg{}, ErrInvalidTimestamp
+	}
+	l.Timestamp = ts
+	l.Message.Reset()
+	l.Message.WriteString(f[1])
+	return l, nil
+}
+
+// TestMerger_Next tests the Next method of the Merger
+func TestMerger_Next(t *testing.T) {
+	srcs := []string{
+		"2023-10-11T22:14:01Z a1\n2023-10-11T22:14:04Z a2\n2023-10-11T22:14:04Z a3\n",
+		"2023-10-11T22:14:02Z b1\ninvalid\n2023-10-11T22:14:05Z b2\n",
+		"",
+		"2023-10-11T22:14:00Z c1\n2023-10-11T22:14:04Z c2\n",
+	}
+	var nexts []func() (LogMsg, error)
+	for _, s := range srcs {
+		nexts = append(nexts, ParseStream(timeParser{}, strings.NewReader(s)))
+	}
+	m := NewMerger(nexts...)
+	defer m.Close()
+
+	var msgs []string
+	errs := 0
+	for {
+		lm, err := m.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			if !errors.Is(err, ErrInvalidTimestamp) {
+				t.Errorf("Next() unexpected error: %s", err)
+			}
+			errs++
+			continue
+		}
+		msgs = append(msgs, lm.Message.String())
+	}
+	want := "c1,a1,b1,a2,a3,c2,b2"
+	if strings.Join(msgs, ",") != want {
+		t.Errorf("Next() order => expected: %s, got: %s", want, strings.Join(msgs, ","))
+	}
+	if errs != 1 {
+		t.Errorf("Next() error count => expected: %d, got: %d", 1, errs)
+	}
+}
+
+// TestMerger_Close tests the Close method of the Merger
+func TestMerger_Close(t *testing.T) {
+	m := NewMerger(ParseStream(timeParser{}, strings.NewReader("2023-10-11T22:14:01Z a1\n")))
+	m.Close()
+	m.Close()
+	if _, err := m.Next(); err != nil && !errors.Is(err, io.EOF) {
+		t.Errorf("Next() after Close() => expected: %s, got: %s", io.EOF, err)
+	}
+}
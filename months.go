@@ -0,0 +1,59 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"strings"
+	"time"
+)
+
+// MaxMonthNameLen is the maximum length in bytes of a month name that is looked up in
+// the month names set with WithMonthNames
+const MaxMonthNameLen = 16
+
+// LocalizedMonthNames holds the abbreviated month names of common European locales
+// (German, French, Spanish, Italian, Portuguese and Dutch) that embedded devices use
+// in their RFC3164 timestamps, i. e. "Dez" or "Mär". It can be used with WithMonthNames.
+var LocalizedMonthNames = map[string]time.Month{
+	// German (including the Austrian "Jän")
+	"jän": time.January, "mär": time.March, "mrz": time.March, "mai": time.May, "okt": time.October,
+	"dez": time.December,
+	// French
+	"janv": time.January, "févr": time.February, "fév": time.February, "mars": time.March, "avr": time.April,
+	"juin": time.June, "juil": time.July, "août": time.August, "aoû": time.August, "sept": time.September,
+	"déc": time.December,
+	// Spanish
+	"ene": time.January, "abr": time.April, "ago": time.August, "dic": time.December,
+	// Italian
+	"gen": time.January, "mag": time.May, "giu": time.June, "lug": time.July, "set": time.September,
+	"ott": time.October,
+	// Portuguese
+	"fev": time.February, "out": time.October,
+	// Dutch
+	"mrt": time.March, "mei": time.May,
+}
+
+// MonthNames maps month names to their month. The names are matched case-insensitively
+// and without a trailing dot (i. e. "déc." matches "déc").
+type MonthNames map[string]time.Month
+
+// NewMonthNames returns the MonthNames for the given map of month names
+func NewMonthNames(m map[string]time.Month) MonthNames {
+	mn := make(MonthNames, len(m))
+	for k, v := range m {
+		mn[strings.ToLower(strings.TrimSuffix(k, "."))] = v
+	}
+	return mn
+}
+
+// Lookup returns the month of the given month name. The returned bool is false if the
+// name is unknown.
+func (mn MonthNames) Lookup(name string) (time.Month, bool) {
+	if len(mn) == 0 || len(name) > MaxMonthNameLen {
+		return 0, false
+	}
+	m, ok := mn[strings.ToLower(strings.TrimSuffix(name, "."))]
+	return m, ok
+}
@@ -0,0 +1,44 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMonthNames_Lookup tests the Lookup method of the MonthNames
+func TestMonthNames_Lookup(t *testing.T) {
+	mn := NewMonthNames(LocalizedMonthNames)
+	tests := []struct {
+		name  string
+		month time.Month
+		ok    bool
+	}{
+		{"Dez", time.December, true},
+		{"MÄR", time.March, true},
+		{"déc.", time.December, true},
+		{"juil", time.July, true},
+		{"Jan", 0, false},
+		{"", 0, false},
+		{strings.Repeat("a", MaxMonthNameLen+1), 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, ok := mn.Lookup(tt.name)
+			if m != tt.month || ok != tt.ok {
+				t.Errorf("Lookup() => expected: %s/%t, got: %s/%t", tt.month, tt.ok, m, ok)
+			}
+		})
+	}
+	if _, ok := NewMonthNames(map[string]time.Month{"XII.": time.December}).Lookup("xii"); !ok {
+		t.Error("Lookup() => expected custom month name to be matched case-insensitively")
+	}
+	var empty MonthNames
+	if _, ok := empty.Lookup("Dez"); ok {
+		t.Error("Lookup() on empty MonthNames => expected false")
+	}
+}
@@ -0,0 +1,309 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+)
+
+// MessagePack format bytes
+// See: https://github.com/msgpack/msgpack/blob/master/spec.md#formats
+const (
+	mpNil      = 0xc0
+	mpFalse    = 0xc2
+	mpTrue     = 0xc3
+	mpBin8     = 0xc4
+	mpBin16    = 0xc5
+	mpBin32    = 0xc6
+	mpUint8    = 0xcc
+	mpUint16   = 0xcd
+	mpUint32   = 0xce
+	mpUint64   = 0xcf
+	mpInt8     = 0xd0
+	mpInt16    = 0xd1
+	mpInt32    = 0xd2
+	mpInt64    = 0xd3
+	mpStr8     = 0xd9
+	mpStr16    = 0xda
+	mpStr32    = 0xdb
+	mpArray16  = 0xdc
+	mpArray32  = 0xdd
+	mpMap16    = 0xde
+	mpMap32    = 0xdf
+	mpFixMap   = 0x80
+	mpFixArray = 0x90
+	mpFixStr   = 0xa0
+)
+
+// MarshalMsgpack returns the MessagePack representation of the LogMsg. It uses the
+// same field names and SchemaVersion as the JSON representation and is meant for
+// bandwidth sensitive forwarding between collectors.
+// See: https://github.com/msgpack/msgpack/blob/master/spec.md
+func (l LogMsg) MarshalMsgpack() ([]byte, error) {
+	var buf bytes.Buffer
+	f := l.serialFields()
+	mpHeader(&buf, mpFixMap, mpMap16, mpMap32, 16, uint32(len(f)))
+	for _, kv := range f {
+		mpValue(&buf, kv.key)
+		mpValue(&buf, kv.val)
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalMsgpack sets the LogMsg to the given MessagePack representation. Data of a
+// newer SchemaVersion is rejected with ErrUnsupportedSchemaVersion.
+func (l *LogMsg) UnmarshalMsgpack(b []byte) error {
+	d := &mpDecoder{b: b}
+	v, err := d.value(0)
+	if err != nil {
+		return err
+	}
+	if d.p != len(b) {
+		return fmt.Errorf("%w: trailing data", ErrInvalidEncoding)
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("%w: not a map", ErrInvalidEncoding)
+	}
+	return l.fromSerialMap(m)
+}
+
+// mpHeader writes the header of a map or array with the given fix, 16 bit and 32 bit
+// format bytes
+func mpHeader(buf *bytes.Buffer, fix, f16, f32 byte, fixMax, n uint32) {
+	switch {
+	case n < fixMax:
+		buf.WriteByte(fix | byte(n))
+	case n <= 0xffff:
+		buf.WriteByte(f16)
+		_ = binary.Write(buf, binary.BigEndian, uint16(n))
+	default:
+		buf.WriteByte(f32)
+		_ = binary.Write(buf, binary.BigEndian, n)
+	}
+}
+
+// mpValue writes the given value of the generic LogMsg representation
+func mpValue(buf *bytes.Buffer, v interface{}) {
+	switch v := v.(type) {
+	case string:
+		n := len(v)
+		switch {
+		case n < 32:
+			buf.WriteByte(mpFixStr | byte(n))
+		case n <= 0xff:
+			buf.WriteByte(mpStr8)
+			buf.WriteByte(byte(n))
+		case n <= 0xffff:
+			buf.WriteByte(mpStr16)
+			_ = binary.Write(buf, binary.BigEndian, uint16(n))
+		default:
+			buf.WriteByte(mpStr32)
+			_ = binary.Write(buf, binary.BigEndian, uint32(n))
+		}
+		buf.WriteString(v)
+	case []byte:
+		n := len(v)
+		switch {
+		case n <= 0xff:
+			buf.WriteByte(mpBin8)
+			buf.WriteByte(byte(n))
+		case n <= 0xffff:
+			buf.WriteByte(mpBin16)
+			_ = binary.Write(buf, binary.BigEndian, uint16(n))
+		default:
+			buf.WriteByte(mpBin32)
+			_ = binary.Write(buf, binary.BigEndian, uint32(n))
+		}
+		buf.Write(v)
+	case int64:
+		switch {
+		case v >= 0 && v < 128:
+			buf.WriteByte(byte(v))
+		case v >= -32 && v < 0:
+			buf.WriteByte(byte(int8(v)))
+		case v > 0 && v <= 0xff:
+			buf.WriteByte(mpUint8)
+			buf.WriteByte(byte(v))
+		case v > 0 && v <= 0xffff:
+			buf.WriteByte(mpUint16)
+			_ = binary.Write(buf, binary.BigEndian, uint16(v))
+		case v > 0 && v <= 0xffffffff:
+			buf.WriteByte(mpUint32)
+			_ = binary.Write(buf, binary.BigEndian, uint32(v))
+		case v > 0:
+			buf.WriteByte(mpUint64)
+			_ = binary.Write(buf, binary.BigEndian, uint64(v))
+		default:
+			buf.WriteByte(mpInt64)
+			_ = binary.Write(buf, binary.BigEndian, v)
+		}
+	case bool:
+		if v {
+			buf.WriteByte(mpTrue)
+			return
+		}
+		buf.WriteByte(mpFalse)
+	case []interface{}:
+		mpHeader(buf, mpFixArray, mpArray16, mpArray32, 16, uint32(len(v)))
+		for _, e := range v {
+			mpValue(buf, e)
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		mpHeader(buf, mpFixMap, mpMap16, mpMap32, 16, uint32(len(keys)))
+		for _, k := range keys {
+			mpValue(buf, k)
+			mpValue(buf, v[k])
+		}
+	default:
+		buf.WriteByte(mpNil)
+	}
+}
+
+// mpDecoder decodes the subset of MessagePack that is used by MarshalMsgpack. Floats
+// and extension types are not supported.
+type mpDecoder struct {
+	b []byte
+	p int
+}
+
+// value decodes the next object
+func (d *mpDecoder) value(depth int) (interface{}, error) {
+	if depth > maxSerialDepth {
+		return nil, fmt.Errorf("%w: nesting too deep", ErrInvalidEncoding)
+	}
+	fb, err := d.read(1)
+	if err != nil {
+		return nil, err
+	}
+	f := fb[0]
+	switch {
+	case f < 0x80:
+		return int64(f), nil
+	case f >= 0xe0:
+		return int64(int8(f)), nil
+	case f&0xf0 == mpFixMap:
+		return d.mapOf(uint64(f&0x0f), depth)
+	case f&0xf0 == mpFixArray:
+		return d.arrayOf(uint64(f&0x0f), depth)
+	case f&0xe0 == mpFixStr:
+		b, err := d.read(uint64(f & 0x1f))
+		return string(b), err
+	}
+	switch f {
+	case mpNil:
+		return nil, nil
+	case mpFalse:
+		return false, nil
+	case mpTrue:
+		return true, nil
+	case mpUint8, mpUint16, mpUint32, mpUint64:
+		return d.uint(1 << (f - mpUint8))
+	case mpInt8, mpInt16, mpInt32, mpInt64:
+		l := 1 << (f - mpInt8)
+		n, err := d.uint(l)
+		if err != nil {
+			return nil, err
+		}
+		// Sign-extend the value
+		shift := 64 - 8*uint(l)
+		return int64(n<<shift) >> shift, nil
+	case mpStr8, mpStr16, mpStr32:
+		n, err := d.uint(1 << (f - mpStr8))
+		if err != nil {
+			return nil, err
+		}
+		b, err := d.read(n)
+		return string(b), err
+	case mpBin8, mpBin16, mpBin32:
+		n, err := d.uint(1 << (f - mpBin8))
+		if err != nil {
+			return nil, err
+		}
+		return d.read(n)
+	case mpArray16, mpArray32:
+		n, err := d.uint(2 << (f - mpArray16))
+		if err != nil {
+			return nil, err
+		}
+		return d.arrayOf(n, depth)
+	case mpMap16, mpMap32:
+		n, err := d.uint(2 << (f - mpMap16))
+		if err != nil {
+			return nil, err
+		}
+		return d.mapOf(n, depth)
+	}
+	return nil, fmt.Errorf("%w: format 0x%x", ErrInvalidEncoding, f)
+}
+
+// arrayOf decodes an array with n elements
+func (d *mpDecoder) arrayOf(n uint64, depth int) (interface{}, error) {
+	if n > uint64(len(d.b)-d.p) {
+		return nil, fmt.Errorf("%w: array length exceeds data", ErrInvalidEncoding)
+	}
+	a := make([]interface{}, 0, n)
+	for i := uint64(0); i < n; i++ {
+		v, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		a = append(a, v)
+	}
+	return a, nil
+}
+
+// mapOf decodes a map with n key/value pairs
+func (d *mpDecoder) mapOf(n uint64, depth int) (interface{}, error) {
+	if n > uint64(len(d.b)-d.p) {
+		return nil, fmt.Errorf("%w: map length exceeds data", ErrInvalidEncoding)
+	}
+	m := make(map[string]interface{}, n)
+	for i := uint64(0); i < n; i++ {
+		k, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		ks, ok := k.(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: non-string map key", ErrInvalidEncoding)
+		}
+		if m[ks], err = d.value(depth + 1); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// uint decodes a big endian unsigned integer of l bytes
+func (d *mpDecoder) uint(l int) (uint64, error) {
+	b, err := d.read(uint64(l))
+	if err != nil {
+		return 0, err
+	}
+	var n uint64
+	for _, c := range b {
+		n = n<<8 | uint64(c)
+	}
+	return n, nil
+}
+
+// read returns the next n bytes of the data
+func (d *mpDecoder) read(n uint64) ([]byte, error) {
+	if n > uint64(len(d.b)-d.p) {
+		return nil, fmt.Errorf("%w: unexpected end of data", ErrInvalidEncoding)
+	}
+	b := d.b[d.p : d.p+int(n)]
+	d.p += int(n)
+	return b, nil
+}
@@ -0,0 +1,106 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestLogMsg_MarshalMsgpack tests the MarshalMsgpack and UnmarshalMsgpack methods of the LogMsg
+func TestLogMsg_MarshalMsgpack(t *testing.T) {
+	for _, msg := range []string{"Hello, World!", "binary\xff\xfe", "", strings.Repeat("x", 70000)} {
+		lm := testSerialLogMsg(msg)
+		b, err := lm.MarshalMsgpack()
+		if err != nil {
+			t.Fatalf("MarshalMsgpack() failed: %s", err)
+		}
+		var got LogMsg
+		if err := got.UnmarshalMsgpack(b); err != nil {
+			t.Fatalf("UnmarshalMsgpack() failed: %s", err)
+		}
+		checkSerialRoundTrip(t, lm, got)
+	}
+}
+
+// TestLogMsg_MarshalMsgpack_encoding tests the encoding of MarshalMsgpack against a known
+// MessagePack representation
+func TestLogMsg_MarshalMsgpack_encoding(t *testing.T) {
+	lm := LogMsg{Priority: 134, Facility: 16, Severity: 6}
+	lm.Message.WriteString("a")
+	b, err := lm.MarshalMsgpack()
+	if err != nil {
+		t.Fatalf("MarshalMsgpack() failed: %s", err)
+	}
+	// {"schema": 1, "facility": 16, "message": "a", "priority": 134, "severity": 6}
+	want := "85" + "a6736368656d61" + "01" + "a8666163696c697479" + "10" + "a76d657373616765" + "a161" +
+		"a87072696f72697479" + "cc86" + "a8736576657269747906"
+	if hex.EncodeToString(b) != want {
+		t.Errorf("MarshalMsgpack() => expected: %s, got: %s", want, hex.EncodeToString(b))
+	}
+}
+
+// TestLogMsg_UnmarshalMsgpack tests UnmarshalMsgpack with encodings that are valid but
+// not produced by MarshalMsgpack
+func TestLogMsg_UnmarshalMsgpack(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want Priority
+	}{
+		{"uint16", "81a87072696f72697479cd0086", 134},
+		{"int8", "81a87072696f72697479d022", 34},
+		{"negative fixint", "81a87072696f72697479ff", -1},
+		{"map16", "de0001a87072696f72697479cc86", 134},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := hex.DecodeString(tt.data)
+			if err != nil {
+				t.Fatalf("invalid test data: %s", err)
+			}
+			var lm LogMsg
+			if err := lm.UnmarshalMsgpack(b); err != nil {
+				t.Fatalf("UnmarshalMsgpack() failed: %s", err)
+			}
+			if lm.Priority != tt.want {
+				t.Errorf("UnmarshalMsgpack() wrong priority => expected: %d, got: %d", tt.want, lm.Priority)
+			}
+		})
+	}
+}
+
+// TestLogMsg_UnmarshalMsgpack_invalid tests the UnmarshalMsgpack method with invalid data
+func TestLogMsg_UnmarshalMsgpack_invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr error
+	}{
+		{"empty", "", ErrInvalidEncoding},
+		{"not a map", "01", ErrInvalidEncoding},
+		{"truncated", "82a6736368656d", ErrInvalidEncoding},
+		{"trailing data", "8080", ErrInvalidEncoding},
+		{"huge map", "dfffffffff", ErrInvalidEncoding},
+		{"float", "81a6736368656d61ca3f800000", ErrInvalidEncoding},
+		{"non-string key", "810101", ErrInvalidEncoding},
+		{"wrong field type", "81a8686f73746e616d6501", ErrInvalidEncoding},
+		{"newer schema", "81a6736368656d6102", ErrUnsupportedSchemaVersion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := hex.DecodeString(tt.data)
+			if err != nil {
+				t.Fatalf("invalid test data: %s", err)
+			}
+			var lm LogMsg
+			if err := lm.UnmarshalMsgpack(b); !errors.Is(err, tt.wantErr) {
+				t.Errorf("UnmarshalMsgpack() => expected: %s, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
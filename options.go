@@ -0,0 +1,484 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import "time"
+
+// Field represents a field (or a group of fields) of a LogMsg
+type Field uint16
+
+// Fields of a LogMsg that can be selected with WithFields
+const (
+	// FieldPriority represents the Priority, Facility and Severity fields
+	FieldPriority Field = 1 << iota
+	// FieldTimestamp represents the Timestamp field
+	FieldTimestamp
+	// FieldHostname represents the Hostname field
+	FieldHostname
+	// FieldAppName represents the AppName field
+	FieldAppName
+	// FieldProcID represents the ProcID field
+	FieldProcID
+	// FieldMsgID represents the MsgID field
+	FieldMsgID
+	// FieldStructuredData represents the StructuredData field
+	FieldStructuredData
+	// FieldMessage represents the Message, MsgLength and HasBOM fields
+	FieldMessage
+
+	// FieldAll represents all fields of a LogMsg
+	FieldAll = FieldPriority | FieldTimestamp | FieldHostname | FieldAppName | FieldProcID |
+		FieldMsgID | FieldStructuredData | FieldMessage
+)
+
+// LengthPolicy represents the way a parser treats log messages that exceed the maximum
+// message length set with WithMaxLength
+type LengthPolicy int
+
+// LengthPolicies
+const (
+	// LengthAccept accepts log messages of any length
+	LengthAccept LengthPolicy = iota
+	// LengthTruncate truncates log messages to the maximum length and sets the Truncated
+	// field of the LogMsg
+	LengthTruncate
+	// LengthReject rejects log messages that exceed the maximum length with
+	// ErrMessageTooLong
+	LengthReject
+)
+
+// OctetCountPolicy represents the way a parser treats the bytes that follow an
+// octet-counted log message in its input, i. e. if the MSG is longer than the octet
+// count of the message announces
+type OctetCountPolicy int
+
+// OctetCountPolicies
+const (
+	// OctetCountNextFrame leaves the bytes that follow the log message in the reader, so
+	// that they are parsed as the next log message of a stream
+	OctetCountNextFrame OctetCountPolicy = iota
+	// OctetCountAccept appends the bytes that follow the log message, up to the end of
+	// the input, to the MSG and sets the ExtraBytes field of the LogMsg
+	OctetCountAccept
+	// OctetCountReject rejects log messages that are followed by further bytes with
+	// ErrInvalidFrameLength
+	OctetCountReject
+)
+
+// Option is a function that adjusts the Options of a Parser
+type Option func(*Options)
+
+// Options represents the settings that a Parser obeys while parsing log messages.
+// Parsers ignore settings that do not apply to the log format they implement.
+type Options struct {
+	// BodyDecoders are the BodyDecoder functions that are tried on the message body of
+	// every parsed log message, in the given order, until one of them succeeds
+	BodyDecoders []BodyDecoder
+	// DefaultPriority is the priority of log messages without PRI, if UseDefaultPriority
+	// is set
+	DefaultPriority Priority
+	// Fields is the set of LogMsg fields the parser populates. A zero value means
+	// that all fields are populated.
+	Fields Field
+	// Interner deduplicates the hostnames and app names of parsed log messages
+	Interner *Interner
+	// Location is the time zone of timestamps that lack time zone information. A nil
+	// value means that such timestamps are in UTC.
+	Location *time.Location
+	// Locations maps sending hosts to the time zone of their timestamps. It is used
+	// for log formats with timestamps that lack time zone information.
+	Locations *LocationMap
+	// Lenient makes the parser accept common deviations from the log format and record
+	// them in the Relaxed field of the LogMsg
+	Lenient bool
+	// LengthPolicy defines how log messages that exceed MaxLength are treated
+	LengthPolicy LengthPolicy
+	// MaxHeaderSize is the maximum size of the header and the structured data of a log
+	// message in bytes. A zero value means that there is no maximum size.
+	MaxHeaderSize int
+	// MaxLength is the maximum length of a log message in bytes. A zero value means
+	// that there is no maximum length.
+	MaxLength int
+	// MaxMessageSize is the maximum size of a log message in bytes. A zero value means
+	// that there is no maximum size.
+	MaxMessageSize int
+	// MonthNames are the additional month names that the parser accepts in timestamps
+	// (i. e. localized month abbreviations)
+	MonthNames MonthNames
+	// OctetCountPolicy defines how the bytes that follow an octet-counted log message
+	// are treated
+	OctetCountPolicy OctetCountPolicy
+	// ReferenceTime is the time the year of timestamps that lack it is inferred from. A
+	// zero value means that the current time is used.
+	ReferenceTime time.Time
+	// Resolver resolves the hostname of a message to a name via reverse DNS, if the
+	// hostname is an IP address. The result is stored in the ResolvedHost field.
+	Resolver *HostResolver
+	// RoundTrip makes the parser record the original text of the parts of the message
+	// that it normalizes in the Wire field of the LogMsg
+	RoundTrip bool
+	// SeverityMapper overrides the Severity of every parsed log message with the level
+	// found in its structured data or message
+	SeverityMapper *SeverityMapper
+	// SkipEmptySD makes the parser silently skip empty structured data elements ("[]")
+	// instead of failing with ErrWrongSDFormat
+	SkipEmptySD bool
+	// Strict makes the parser enforce the ABNF of the log format and fail with
+	// ErrABNFViolation on any deviation
+	Strict bool
+	// StreamingBody makes the parser expose the message body as io.Reader in the Body
+	// field of the LogMsg instead of buffering it in the Message field
+	StreamingBody bool
+	// StripCiscoPrefix makes the parser strip a leading Cisco sequence number ("NNN: ")
+	// and clock-status markers ("*" or ".") before the timestamp
+	StripCiscoPrefix bool
+	// Trace is called with the ParseTrace of every parsed log message
+	Trace TraceFunc
+	// UnescapeSD makes the parser unescape the values of structured data params
+	UnescapeSD bool
+	// UseDefaultPriority makes the parser accept log messages without PRI and assign
+	// them the DefaultPriority
+	UseDefaultPriority bool
+	// Year is the year of timestamps that lack it. A zero value means that the year is
+	// inferred from the ReferenceTime according to the YearPolicy.
+	Year int
+	// YearPolicy defines how the year of timestamps that lack it is inferred
+	YearPolicy YearPolicy
+	// ZeroCopy makes ParseBytes return log messages that reference the given byte slice
+	// instead of copies of it
+	ZeroCopy bool
+}
+
+// NewOptions returns the Options with all the given Option functions applied
+func NewOptions(opts ...Option) Options {
+	o := Options{}
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		opt(&o)
+	}
+	return o
+}
+
+// Wants returns true if the parser is supposed to populate the given Field
+func (o Options) Wants(f Field) bool {
+	return o.Fields == 0 || o.Fields&f != 0
+}
+
+// PostProcess applies the post-processing steps of the Options to the given LogMsg.
+// Parsers call it once the log message has been parsed. The first BodyDecoder that
+// recognizes the message body is applied, unless the structured data or the message
+// are not populated (see WithFields). Then the SeverityMapper is applied, so that it
+// sees the structured data of the BodyDecoder.
+func (o Options) PostProcess(lm *LogMsg) {
+	if len(o.BodyDecoders) > 0 && o.Wants(FieldStructuredData) && o.Wants(FieldMessage) {
+		for _, d := range o.BodyDecoders {
+			if d(lm) {
+				break
+			}
+		}
+	}
+	if o.SeverityMapper != nil && o.Wants(FieldPriority) {
+		o.SeverityMapper.Apply(lm)
+	}
+}
+
+// WithBodyDecoders makes the parser decode vendor specific message bodies (i. e. the
+// key=value pairs of FortiGate logs) with the given BodyDecoder functions, so that no
+// second parsing pass is needed. The decoders are tried in the given order until one
+// of them recognizes the message body.
+func WithBodyDecoders(d ...BodyDecoder) Option {
+	return func(o *Options) {
+		o.BodyDecoders = append(o.BodyDecoders, d...)
+	}
+}
+
+// WithDefaultPriority makes the RFC3164 parser accept log messages that lack the PRI
+// (i. e. kernel messages of some appliances) and assign them the given Priority, instead
+// of failing with ErrWrongFormat. rsyslog uses 13 (user.notice) for such messages. The
+// missing PRI is recorded as RelaxedMissingPriority in the Relaxed field of the LogMsg.
+// The auto parser hands log messages without PRI to the RFC3164 parser if the option is
+// set.
+func WithDefaultPriority(p Priority) Option {
+	return func(o *Options) {
+		o.DefaultPriority, o.UseDefaultPriority = p, true
+	}
+}
+
+// WithFields tells the parser which fields of the LogMsg the caller is interested in,
+// i. e. WithFields(FieldPriority|FieldTimestamp|FieldMessage). The parser still has to
+// read past all the other fields, but it will skip the work of decoding, validating
+// and copying them, which reduces the CPU and memory footprint for consumers that only
+// need a subset of the fields (i. e. for metrics). Unselected fields are left empty.
+func WithFields(f Field) Option {
+	return func(o *Options) {
+		o.Fields = f
+	}
+}
+
+// WithLocationMap makes the parser interpret timestamps without time zone information
+// (i. e. RFC3164 timestamps) in the time.Location that the given LocationMap returns for
+// the hostname of the message. Timestamps of hosts that are not covered by the
+// LocationMap are left unchanged.
+func WithLocationMap(m *LocationMap) Option {
+	return func(o *Options) {
+		o.Locations = m
+	}
+}
+
+// WithMaxLength sets the maximum length of a log message in bytes (including the PRI and
+// the header, excluding the trailing newline) and the LengthPolicy for messages that
+// exceed it. RFC3164 limits messages to 1024 bytes, but many senders exceed this limit,
+// so by default, the RFC3164 parser accepts messages of any length. The remainder of
+// a truncated or rejected message is consumed, so that the next message of a stream
+// can be parsed.
+// See: https://datatracker.ietf.org/doc/html/rfc3164#section-4.1
+func WithMaxLength(n int, p LengthPolicy) Option {
+	return func(o *Options) {
+		o.MaxLength = n
+		o.LengthPolicy = p
+	}
+}
+
+// WithMaxMessageSize sets the maximum size of a RFC5424 log message in bytes, from the
+// PRI to the end of the MSG. This protects against senders that announce huge messages
+// in their octet-count prefix (or send them without), which the parser would otherwise
+// buffer in full. The MSG of larger log messages is truncated to the maximum size and the
+// remainder of the message is discarded without being buffered. The parser returns
+// the truncated LogMsg, with its Truncated field set, together with ErrMessageTooLarge,
+// so that callers can decide whether to use it. The header and the structured data are
+// limited separately with WithMaxHeaderSize. Messages with a streaming body (see
+// WithStreamingBody) are not limited. The GELF parser rejects messages that exceed the
+// maximum size after decompression with ErrMessageTooLarge.
+func WithMaxMessageSize(n int) Option {
+	return func(o *Options) {
+		o.MaxMessageSize = n
+	}
+}
+
+// WithMonthNames makes the parser accept the given month names in addition to the
+// English month abbreviations in RFC3164 timestamps, i. e. the localized abbreviations
+// of LocalizedMonthNames ("Dez 24 18:00:00") that some embedded devices emit. The names
+// are matched case-insensitively and may end with a dot. Without this option, such
+// messages fail with ErrInvalidTimestamp.
+func WithMonthNames(m map[string]time.Month) Option {
+	return func(o *Options) {
+		o.MonthNames = NewMonthNames(m)
+	}
+}
+
+// WithOctetCountPolicy sets the OctetCountPolicy, which defines how the RFC5424 parser
+// treats the bytes that follow an octet-counted log message in its input. By default,
+// they are left in the reader and parsed as the next log message (OctetCountNextFrame),
+// as it is required for streams of octet-counted messages. Single log messages, i. e.
+// parsed with ParseString or ParseBytes, whose MSG exceeds the announced octet count
+// can be accepted as a whole (OctetCountAccept) or rejected (OctetCountReject). Both
+// read the input up to its end, so they must not be used with streams. Messages with
+// a maximum size (see WithMaxMessageSize) or a streaming body (see WithStreamingBody)
+// are not affected.
+func WithOctetCountPolicy(p OctetCountPolicy) Option {
+	return func(o *Options) {
+		o.OctetCountPolicy = p
+	}
+}
+
+// WithTrace makes the parser measure the time it spends on the header, the structured
+// data and the MSG of every log message and hand the ParseTrace to the given function,
+// including for log messages that fail to parse. This helps to find the inputs that slow
+// down a collector. The function is called synchronously, so it should be fast.
+func WithTrace(fn TraceFunc) Option {
+	return func(o *Options) {
+		o.Trace = fn
+	}
+}
+
+// WithUnescapeSD makes the RFC5424 parser unescape the values of structured data params,
+// so that the StructuredDataParam holds the logical value (i. e. `C:\temp` for the
+// escaped value `C:\\temp`). RFC5424 requires '"', '\' and ']' to be escaped by a
+// backslash in a PARAM-VALUE, other backslashes are kept. By default, the values are
+// kept as escaped on the wire. The escaped form remains available in the Wire field of
+// the LogMsg in round-trip mode (see WithRoundTrip).
+// See: https://datatracker.ietf.org/doc/html/rfc5424#section-6.3.3
+func WithUnescapeSD() Option {
+	return func(o *Options) {
+		o.UnescapeSD = true
+	}
+}
+
+// WithLocation makes the parser interpret timestamps that lack time zone information
+// (i. e. the BSD timestamps of RFC3164) in the given time.Location instead of UTC, so
+// that the log messages of devices in other time zones get correct absolute times. The
+// time zones of a LocationMap (see WithLocationMap) take precedence. A nil Location
+// means UTC, which is the default.
+func WithLocation(loc *time.Location) Option {
+	return func(o *Options) {
+		o.Location = loc
+	}
+}
+
+// WithReferenceTime makes the parser infer the year of timestamps that lack it (i. e.
+// the BSD timestamps of RFC3164) from the given time instead of the current time, so
+// that replayed historical logs are placed in the year they have been recorded. The
+// year is inferred according to the YearPolicy (see WithYearPolicy).
+func WithReferenceTime(t time.Time) Option {
+	return func(o *Options) {
+		o.ReferenceTime = t
+	}
+}
+
+// WithYear makes the parser place timestamps that lack a year (i. e. the BSD timestamps
+// of RFC3164) in the given year. It takes precedence over the reference time and the
+// YearPolicy.
+func WithYear(y int) Option {
+	return func(o *Options) {
+		o.Year = y
+	}
+}
+
+// WithYearPolicy defines how the parser infers the year of timestamps that lack it (i. e.
+// the BSD timestamps of RFC3164) from the reference time. By default, the year of the
+// reference time is used (YearCurrent). With YearNearest, the year before or after is
+// used if it places the timestamp closer to the reference time, which handles log
+// messages from around the turn of the year.
+func WithYearPolicy(p YearPolicy) Option {
+	return func(o *Options) {
+		o.YearPolicy = p
+	}
+}
+
+// WithInterner makes the parsers share the hostnames and app names of the parsed log
+// messages via the given Interner, so that log messages that are retained do not hold
+// their own copies of recurring values. The same Interner can be used by multiple
+// parsers. It has no effect in zero-copy mode, which does not copy the values anyway.
+func WithInterner(i *Interner) Option {
+	return func(o *Options) {
+		o.Interner = i
+	}
+}
+
+// WithLenient makes the parsers accept common deviations from the log format that real
+// world senders produce, instead of rejecting the message. The accepted deviations are
+// recorded in the Relaxed field of the LogMsg:
+//
+//   - RelaxedPriority: a PRI with leading zeros (i. e. "<034>")
+//   - RelaxedTimestamp: RFC3164 timestamps with a single space before a single-digit
+//     day or with fractional seconds and RFC5424
+//     timestamps without time zone (which are interpreted as UTC)
+//   - RelaxedTag: an RFC3164 tag whose colon is not followed by a space (i. e.
+//     "sshd:message")
+//   - RelaxedMsgID: an RFC5424 header without MSGID, in which the structured data
+//     directly follows the PROCID
+//   - RelaxedSDName: an RFC5424 SD-ID or PARAM-NAME that is not a valid SD-NAME (i. e.
+//     longer than 32 characters or with non-printable characters)
+//   - RelaxedDuplicateSDID: RFC5424 structured data with multiple elements of the same
+//     SD-ID, which are kept in wire order
+func WithLenient() Option {
+	return func(o *Options) {
+		o.Lenient = true
+	}
+}
+
+// WithStrict makes the RFC5424 parser enforce the full ABNF of RFC5424 instead of
+// accepting what it is able to parse: header fields of printable US-ASCII characters
+// within their maximum lengths, a VERSION without leading zeros, timestamps with at most
+// 6 digits of fractional seconds, valid SD-NAMEs, properly escaped PARAM-VALUEs, unique
+// SD-IDs and a MSG in valid UTF-8 if it starts with a BOM. Violations are reported with
+// a ErrABNFViolation error that details the violation. This is useful for conformance
+// testing of emitters. Strict mode takes precedence over WithLenient.
+// See: https://datatracker.ietf.org/doc/html/rfc5424#section-6
+func WithStrict() Option {
+	return func(o *Options) {
+		o.Strict = true
+	}
+}
+
+// WithMaxHeaderSize limits the size of the header and the structured data of a log
+// message to the given amount of bytes. The RFC5424 parser buffers these parts while
+// parsing them and rejects messages that exceed the limit with ErrHeaderTooLarge, so
+// that senders can not exhaust the memory of the receiver with a single message. By
+// default, there is no limit, so messages with large structured data sections are
+// accepted.
+func WithMaxHeaderSize(n int) Option {
+	return func(o *Options) {
+		o.MaxHeaderSize = n
+	}
+}
+
+// WithHostResolver makes the parser populate the ResolvedHost field of the LogMsg with
+// the result of HostResolver.Resolve for the hostname of the message. Since the
+// HostResolver performs its lookups asynchronously, the name of a new address is only
+// available for the messages that are parsed after the lookup has completed.
+func WithHostResolver(r *HostResolver) Option {
+	return func(o *Options) {
+		o.Resolver = r
+	}
+}
+
+// WithSkipEmptySD makes the parser skip empty structured data elements ("[]"), as they
+// are emitted by some senders, instead of failing with ErrWrongSDFormat. Structured
+// data elements that have an SD-ID but no params (i. e. "[exampleSDID@32473]") are
+// valid and always accepted.
+func WithSkipEmptySD() Option {
+	return func(o *Options) {
+		o.SkipEmptySD = true
+	}
+}
+
+// WithStripCiscoPrefix makes the RFC3164 parser tolerate and strip the sequence number
+// ("NNN: ") and the clock-status markers ("*" for an unsynchronized and "." for a formerly
+// synchronized clock) that Cisco devices put in front of the timestamp
+func WithStripCiscoPrefix() Option {
+	return func(o *Options) {
+		o.StripCiscoPrefix = true
+	}
+}
+
+// WithSeverityMapper makes the parser override the Severity of every parsed log message
+// with the level the application encoded in its structured data or message (i. e.
+// "level=error"), using the given SeverityMapper. The original Severity is preserved in
+// the OriginalSeverity field of the LogMsg.
+func WithSeverityMapper(m *SeverityMapper) Option {
+	return func(o *Options) {
+		o.SeverityMapper = m
+	}
+}
+
+// WithStreamingBody makes parsers that support it (i. e. the RFC5424 parser) expose the
+// message body as io.Reader in the Body field of the LogMsg, instead of buffering it in
+// the Message field. This avoids large buffers for messages with very large payloads.
+// Since the Body reads from the io.Reader the message is parsed from, it has to be
+// consumed before the next message is parsed. BodyDecoders are not applied to streamed
+// message bodies.
+func WithStreamingBody() Option {
+	return func(o *Options) {
+		o.StreamingBody = true
+	}
+}
+
+// WithRoundTrip makes parsers that support it (i. e. the RFC5424 parser) record the
+// original text of the parts of the message that they normalize (i. e. the timestamp
+// and the escaping of the structured data) in the Wire field of the LogMsg. This allows
+// marshalers to reproduce conforming messages byte-exact, as required for relays that
+// must not alter the messages they forward.
+func WithRoundTrip() Option {
+	return func(o *Options) {
+		o.RoundTrip = true
+	}
+}
+
+// WithZeroCopy makes the ParseBytes method of parsers that support it (i. e. the RFC5424
+// parser) return log messages whose strings (hostname, app name, proc ID, message ID and
+// structured data) and Message are sub-slices of the given byte slice instead of copies.
+// This avoids all copying of the message, but the caller has to make sure that the byte
+// slice is not modified as long as the LogMsg or any of its strings are in use. A LogMsg
+// that needs to outlive the byte slice can be copied with LogMsg.Clone, which copies all
+// of its strings and the Message in zero-copy mode. Reusing such a LogMsg (i. e. with
+// ParseReaderInto) does not write into the byte slice.
+func WithZeroCopy() Option {
+	return func(o *Options) {
+		o.ZeroCopy = true
+	}
+}
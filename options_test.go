@@ -0,0 +1,134 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+)
+
+// optParser is a Parser that only exposes its Options for testing
+type optParser struct {
+	opts Options
+}
+
+func (p *optParser) ParseReader(io.Reader) (LogMsg, error) { return LogMsg{}, nil }
+func (p *optParser) ParseString(string) (LogMsg, error)    { return LogMsg{}, nil }
+
+// TestNewOptions tests the NewOptions method
+func TestNewOptions(t *testing.T) {
+	o := NewOptions()
+	if o.SkipEmptySD {
+		t.Errorf("NewOptions() without options => SkipEmptySD expected to be false")
+	}
+	o = NewOptions(nil, WithSkipEmptySD())
+	if !o.SkipEmptySD {
+		t.Errorf("NewOptions() with WithSkipEmptySD() => SkipEmptySD expected to be true")
+	}
+}
+
+// TestNew_withOptions tests that New hands the Options to the registered Parser function
+func TestNew_withOptions(t *testing.T) {
+	pt := ParserType("options-test")
+	RegisterWithOptions(pt, func(o Options) (Parser, error) {
+		return &optParser{opts: o}, nil
+	})
+	p, err := New(pt, WithSkipEmptySD())
+	if err != nil {
+		t.Fatalf("New() failed: %s", err)
+	}
+	op, ok := p.(*optParser)
+	if !ok {
+		t.Fatalf("New() returned unexpected Parser type: %T", p)
+	}
+	if !op.opts.SkipEmptySD {
+		t.Errorf("New() Options were not handed to the Parser function")
+	}
+	if _, err := New("unknown-test"); !errors.Is(err, ErrParserTypeUnknown) {
+		t.Errorf("New() with unknown type => expected: %s, got: %s", ErrParserTypeUnknown, err)
+	}
+}
+
+// TestOptions_Wants tests the Wants method of the Options
+func TestOptions_Wants(t *testing.T) {
+	o := NewOptions()
+	if !o.Wants(FieldHostname) || !o.Wants(FieldMessage) {
+		t.Errorf("Wants() without WithFields => all fields expected to be wanted")
+	}
+	o = NewOptions(WithFields(FieldPriority | FieldMessage))
+	if !o.Wants(FieldPriority) || !o.Wants(FieldMessage) {
+		t.Errorf("Wants() selected fields expected to be wanted")
+	}
+	if o.Wants(FieldHostname) || o.Wants(FieldStructuredData) {
+		t.Errorf("Wants() unselected fields not expected to be wanted")
+	}
+}
+
+// TestOptions_PostProcess tests that PostProcess applies the first matching BodyDecoder
+func TestOptions_PostProcess(t *testing.T) {
+	var called []string
+	dec := func(name string, ok bool) BodyDecoder {
+		return func(lm *LogMsg) bool {
+			called = append(called, name)
+			if ok {
+				lm.StructuredData = append(lm.StructuredData, StructuredDataElement{ID: name})
+			}
+			return ok
+		}
+	}
+	tests := []struct {
+		name   string
+		opts   []Option
+		called []string
+		sd     int
+	}{
+		{"no decoders", nil, nil, 0},
+		{"first matches", []Option{WithBodyDecoders(dec("a", true), dec("b", true))}, []string{"a"}, 1},
+		{"second matches", []Option{WithBodyDecoders(dec("a", false), dec("b", true))}, []string{"a", "b"}, 1},
+		{"none matches", []Option{WithBodyDecoders(dec("a", false), dec("b", false))}, []string{"a", "b"}, 0},
+		{
+			"without structured data", []Option{WithBodyDecoders(dec("a", true)), WithFields(FieldMessage)},
+			nil, 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called = nil
+			var lm LogMsg
+			NewOptions(tt.opts...).PostProcess(&lm)
+			if !reflect.DeepEqual(called, tt.called) {
+				t.Errorf("PostProcess() called decoders => expected: %v, got: %v", tt.called, called)
+			}
+			if len(lm.StructuredData) != tt.sd {
+				t.Errorf("PostProcess() => expected %d SD elements, got: %d", tt.sd, len(lm.StructuredData))
+			}
+		})
+	}
+}
+
+// TestOptions_PostProcess_severityMapper tests that PostProcess applies the
+// SeverityMapper after the BodyDecoders
+func TestOptions_PostProcess_severityMapper(t *testing.T) {
+	dec := func(lm *LogMsg) bool {
+		lm.StructuredData = append(lm.StructuredData, StructuredDataElement{
+			ID: "dec@32473", Param: []StructuredDataParam{{"level", "warning"}},
+		})
+		return true
+	}
+	o := NewOptions(WithBodyDecoders(dec), WithSeverityMapper(NewSeverityMapper()))
+	lm := LogMsg{Severity: 6}
+	o.PostProcess(&lm)
+	if lm.Severity != 4 {
+		t.Errorf("PostProcess() => expected severity of the decoded body, got: %d", lm.Severity)
+	}
+	lm = LogMsg{Severity: 6}
+	lm.Message.WriteString("level=error")
+	NewOptions(WithSeverityMapper(NewSeverityMapper()), WithFields(FieldMessage)).PostProcess(&lm)
+	if lm.Severity != 6 {
+		t.Errorf("PostProcess() without FieldPriority => expected unchanged severity, got: %d", lm.Severity)
+	}
+}
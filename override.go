@@ -0,0 +1,156 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+// PriorityOverride describes the parts of the PRI of a log message that are overridden by
+// a PriorityPolicy. It is created with ForceFacility, ForceSeverity or ForcePriority.
+type PriorityOverride struct {
+	facility    Facility
+	severity    Severity
+	setFacility bool
+	setSeverity bool
+}
+
+// ForceFacility returns a PriorityOverride that replaces the Facility of a log message
+// with the given Facility (i. e. FacilityFromPrio(Local5)) and keeps its Severity
+func ForceFacility(f Facility) PriorityOverride {
+	return PriorityOverride{facility: f, setFacility: true}
+}
+
+// ForceSeverity returns a PriorityOverride that replaces the Severity of a log message
+// with the given Severity and keeps its Facility
+func ForceSeverity(s Severity) PriorityOverride {
+	return PriorityOverride{severity: s, setSeverity: true}
+}
+
+// ForcePriority returns a PriorityOverride that replaces the Facility and the Severity of
+// a log message with those of the given Priority (i. e. Local5|Warning)
+func ForcePriority(p Priority) PriorityOverride {
+	return PriorityOverride{
+		facility: FacilityFromPrio(p), severity: SeverityFromPrio(p),
+		setFacility: true, setSeverity: true,
+	}
+}
+
+// PriorityPolicy overrides the facility and/or severity that senders claim in the PRI of
+// their log messages, based on the host the messages have been received from. This
+// protects against senders that (accidentally or deliberately) log with a facility they
+// are not supposed to use, i. e. an application server that logs to "auth" to have its
+// messages end up in the security log.
+//
+// A PriorityPolicy is immutable and therefore safe for concurrent use.
+type PriorityPolicy struct {
+	hosts map[string]PriorityOverride
+	nets  []overrideNet
+}
+
+// overrideNet represents a network entry of a PriorityPolicy
+type overrideNet struct {
+	net *net.IPNet
+	po  PriorityOverride
+}
+
+// NewPriorityPolicy returns a new PriorityPolicy for the given entries. As with a
+// LocationMap, the keys of the entries are either hostnames, IP addresses or networks
+// in CIDR notation (i. e. "10.1.0.0/16"). An error is returned if a key looks like a
+// network, but is not a valid CIDR or if a PriorityOverride overrides nothing or is
+// out of range.
+func NewPriorityPolicy(entries map[string]PriorityOverride) (*PriorityPolicy, error) {
+	p := &PriorityPolicy{hosts: make(map[string]PriorityOverride)}
+	for k, po := range entries {
+		if !po.setFacility && !po.setSeverity {
+			return nil, fmt.Errorf("no override given for %q", k)
+		}
+		if (po.setFacility && (po.facility < 0 || po.facility > 23)) ||
+			(po.setSeverity && (po.severity < 0 || po.severity > 7)) {
+			return nil, fmt.Errorf("invalid override for %q", k)
+		}
+		if strings.Contains(k, "/") {
+			_, n, err := net.ParseCIDR(k)
+			if err != nil {
+				return nil, fmt.Errorf("invalid network %q: %w", k, err)
+			}
+			p.nets = append(p.nets, overrideNet{net: n, po: po})
+			continue
+		}
+		if ip := net.ParseIP(k); ip != nil {
+			k = ip.String()
+		}
+		p.hosts[strings.ToLower(k)] = po
+	}
+
+	// The most specific network takes precedence
+	sort.Slice(p.nets, func(i, j int) bool {
+		oi, _ := p.nets[i].net.Mask.Size()
+		oj, _ := p.nets[j].net.Mask.Size()
+		return oi > oj
+	})
+	return p, nil
+}
+
+// Lookup returns the PriorityOverride of the given host. Exact matches of a hostname or
+// IP address take precedence over networks, of which the most specific one matches.
+// The returned bool is false if the host is not covered by the PriorityPolicy.
+func (p *PriorityPolicy) Lookup(host string) (PriorityOverride, bool) {
+	if p == nil || host == "" {
+		return PriorityOverride{}, false
+	}
+	if po, ok := p.hosts[host]; ok {
+		return po, true
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		po, ok := p.hosts[strings.ToLower(host)]
+		return po, ok
+	}
+	if po, ok := p.hosts[ip.String()]; ok {
+		return po, true
+	}
+	for _, n := range p.nets {
+		if n.net.Contains(ip) {
+			return n.po, true
+		}
+	}
+	return PriorityOverride{}, false
+}
+
+// Apply overrides the Facility and/or Severity of the given LogMsg as configured for the
+// given host and updates its Priority accordingly. The host should be the address the
+// message has been received from, not the hostname claimed in the message, as the
+// latter can be spoofed just as easily as the PRI. If the Severity is overridden, the
+// Severity of the PRI is preserved in the OriginalSeverity field. It returns true if
+// the LogMsg has been changed.
+func (p *PriorityPolicy) Apply(lm *LogMsg, host string) bool {
+	po, ok := p.Lookup(host)
+	if !ok {
+		return false
+	}
+	f, s := lm.Facility, lm.Severity
+	if po.setFacility {
+		f = po.facility
+	}
+	if po.setSeverity {
+		s = po.severity
+	}
+	if f == lm.Facility && s == lm.Severity {
+		return false
+	}
+	if s != lm.Severity && lm.OriginalSeverity == nil {
+		os := lm.Severity
+		lm.OriginalSeverity = &os
+	}
+	lm.Facility, lm.Severity = f, s
+	// The Facility of a log message with a PRI greater than 191 is out of range, so the
+	// Priority is encoded without PriorityFrom to keep it in line with the PRI
+	lm.Priority = Priority(f)<<3 | Priority(s)
+	return true
+}
@@ -0,0 +1,86 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"testing"
+)
+
+// TestPriorityPolicy_Apply tests the Apply method of the PriorityPolicy
+func TestPriorityPolicy_Apply(t *testing.T) {
+	p, err := NewPriorityPolicy(map[string]PriorityOverride{
+		"10.0.0.0/8":    ForceFacility(FacilityFromPrio(Local5)),
+		"10.1.0.0/16":   ForcePriority(Local6 | Warning),
+		"10.1.2.3":      ForceSeverity(SeverityFromPrio(Debug)),
+		"AppServer":     ForceFacility(FacilityFromPrio(Local0)),
+		"2001:db8::/32": ForceFacility(FacilityFromPrio(Local7)),
+	})
+	if err != nil {
+		t.Fatalf("NewPriorityPolicy() failed: %s", err)
+	}
+	tests := []struct {
+		name    string
+		host    string
+		prio    Priority
+		want    Priority
+		changed bool
+		origSev bool
+	}{
+		{"facility of network", "10.2.0.1", Auth | Crit, Local5 | Crit, true, false},
+		{"most specific network", "10.1.2.4", Auth | Crit, Local6 | Warning, true, true},
+		{"severity of IP", "10.1.2.3", Auth | Crit, Auth | Debug, true, true},
+		{"severity of PRI > 191", "10.1.2.3", 203, 207, true, true},
+		{"hostname case-insensitive", "appserver", Auth | Info, Local0 | Info, true, false},
+		{"IPv6 network", "2001:db8::1", User | Notice, Local7 | Notice, true, false},
+		{"already matching", "10.2.0.1", Local5 | Info, Local5 | Info, false, false},
+		{"unknown host", "192.168.0.1", Auth | Crit, Auth | Crit, false, false},
+		{"empty host", "", Auth | Crit, Auth | Crit, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lm := LogMsg{Priority: tt.prio, Facility: FacilityFromPrio(tt.prio), Severity: SeverityFromPrio(tt.prio)}
+			if changed := p.Apply(&lm, tt.host); changed != tt.changed {
+				t.Errorf("Apply() => expected: %t, got: %t", tt.changed, changed)
+			}
+			if lm.Priority != tt.want || lm.Facility != FacilityFromPrio(tt.want) ||
+				lm.Severity != SeverityFromPrio(tt.want) {
+				t.Errorf("Apply() => expected priority: %d, got: %d (facility: %d, severity: %d)", tt.want,
+					lm.Priority, lm.Facility, lm.Severity)
+			}
+			if (lm.OriginalSeverity != nil) != tt.origSev {
+				t.Errorf("Apply() => expected original severity: %t, got: %v", tt.origSev, lm.OriginalSeverity)
+			}
+			if tt.origSev && *lm.OriginalSeverity != SeverityFromPrio(tt.prio) {
+				t.Errorf("Apply() => expected original severity: %d, got: %d", SeverityFromPrio(tt.prio),
+					*lm.OriginalSeverity)
+			}
+		})
+	}
+	var np *PriorityPolicy
+	lm := LogMsg{Priority: Auth | Crit}
+	if np.Apply(&lm, "10.1.2.3") {
+		t.Errorf("Apply() on nil PriorityPolicy expected to change nothing")
+	}
+}
+
+// TestNewPriorityPolicy_invalid tests the NewPriorityPolicy method with invalid entries
+func TestNewPriorityPolicy_invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries map[string]PriorityOverride
+	}{
+		{"invalid network", map[string]PriorityOverride{"10.0.0.0/33": ForceSeverity(1)}},
+		{"no override", map[string]PriorityOverride{"router1": {}}},
+		{"invalid facility", map[string]PriorityOverride{"router1": ForceFacility(24)}},
+		{"invalid severity", map[string]PriorityOverride{"router1": ForceSeverity(8)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewPriorityPolicy(tt.entries); err == nil {
+				t.Errorf("NewPriorityPolicy() expected to fail")
+			}
+		})
+	}
+}
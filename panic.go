@@ -0,0 +1,46 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError represents a panic of a Parser that has been recovered while parsing a log
+// message. It wraps ErrParserPanic, so that ClassifyError accounts it as parse failure
+// of its own class. The frame that caused the panic is retained, so that it can be
+// quarantined (i. e. written to a file by an error handler) and used to reproduce the
+// bug of the Parser.
+type PanicError struct {
+	// Frame is a copy of the log message that caused the panic. It is nil if the
+	// message is not known, i. e. for log messages parsed from a stream.
+	Frame []byte
+	// Stack is the stack trace of the goroutine at the time of the panic
+	Stack []byte
+	// Value is the value the Parser panicked with
+	Value interface{}
+}
+
+// NewPanicError returns a PanicError for the given recovered value and frame. It needs
+// to be called from the deferred function that recovered the panic, so that the stack
+// trace leads to the panic.
+func NewPanicError(v interface{}, frame []byte) *PanicError {
+	e := &PanicError{Stack: debug.Stack(), Value: v}
+	if frame != nil {
+		e.Frame = append([]byte(nil), frame...)
+	}
+	return e
+}
+
+// Error satisfies the error interface for the PanicError type
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("%s: %v", ErrParserPanic, e.Value)
+}
+
+// Unwrap returns ErrParserPanic
+func (e *PanicError) Unwrap() error {
+	return ErrParserPanic
+}
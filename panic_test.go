@@ -0,0 +1,77 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// panicParser is a Parser that panics on log messages containing "boom" and otherwise
+// returns the line as message
+type panicParser struct{}
+
+// ParseReader satisfies the Parser interface for the panicParser type
+func (p panicParser) ParseReader(r io.Reader) (LogMsg, error) {
+	var lm LogMsg
+	l, err := r.(*bufio.Reader).ReadString('\n')
+	if strings.Contains(l, "boom") {
+		panic("boom")
+	}
+	lm.Message.WriteString(strings.TrimSuffix(l, "\n"))
+	return lm, err
+}
+
+// ParseString satisfies the Parser interface for the panicParser type
+func (p panicParser) ParseString(s string) (LogMsg, error) {
+	return p.ParseReader(bufio.NewReader(strings.NewReader(s)))
+}
+
+// TestNewPanicError tests the NewPanicError function and the methods of the PanicError
+func TestNewPanicError(t *testing.T) {
+	frame := []byte("<34>boom")
+	err := NewPanicError("index out of range", frame)
+	frame[0] = 'x'
+	if string(err.Frame) != "<34>boom" {
+		t.Errorf("NewPanicError() => expected copy of the frame, got: %q", err.Frame)
+	}
+	if err.Error() != "parser panicked: index out of range" {
+		t.Errorf("Error() => unexpected message: %s", err)
+	}
+	if !errors.Is(err, ErrParserPanic) || ClassifyError(err) != ErrParserPanic {
+		t.Errorf("NewPanicError() => expected to wrap %s", ErrParserPanic)
+	}
+	if !strings.Contains(string(err.Stack), "TestNewPanicError") {
+		t.Errorf("NewPanicError() => expected stack trace, got: %s", err.Stack)
+	}
+	if NewPanicError(nil, nil).Frame != nil {
+		t.Errorf("NewPanicError() without frame => expected nil frame")
+	}
+}
+
+// TestStreamParser_panic tests that the StreamParser recovers panics of the Parser
+func TestStreamParser_panic(t *testing.T) {
+	sp := NewStreamParser(panicParser{}, strings.NewReader("first\nboom\nsecond\n"))
+	want := []string{"first", "", "second"}
+	for i, w := range want {
+		lm, err := sp.Next()
+		var pe *PanicError
+		if w == "" {
+			if !errors.As(err, &pe) || pe.Value != "boom" {
+				t.Errorf("Next() %d => expected PanicError, got: %v", i, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Next() %d failed: %s", i, err)
+		}
+		if lm.Message.String() != w {
+			t.Errorf("Next() %d => expected: %s, got: %s", i, w, lm.Message.String())
+		}
+	}
+}
@@ -0,0 +1,124 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+// Package panos implements the decoding of the CSV log format of Palo Alto Networks
+// firewalls (PAN-OS) for the TRAFFIC, THREAT and SYSTEM log types. Decode is a
+// parsesyslog.BodyDecoder that maps the named columns of the CSV record to the params
+// of a structured data element.
+// See: https://docs.paloaltonetworks.com/pan-os/11-0/pan-os-admin/monitoring/use-syslog-for-monitoring/syslog-field-descriptions
+package panos
+
+import (
+	"encoding/csv"
+	"strings"
+	"time"
+
+	"github.com/wneessen/go-parsesyslog"
+)
+
+// SDID is the SD-ID of the structured data element that holds the columns of a PAN-OS
+// log message, using the private enterprise number of Palo Alto Networks
+const SDID = "panos@25461"
+
+// Log types of PAN-OS log messages
+const (
+	TypeSystem  = "SYSTEM"
+	TypeThreat  = "THREAT"
+	TypeTraffic = "TRAFFIC"
+)
+
+// futureUse represents a column that is reserved by PAN-OS. These columns are not
+// mapped to a param.
+const futureUse = ""
+
+// typeColumn is the index of the column that holds the log type
+const typeColumn = 3
+
+// commonColumns are the first columns of the TRAFFIC and THREAT log types
+var commonColumns = []string{
+	futureUse, "receive_time", "serial", "type", "subtype", futureUse, "time_generated", "src", "dst",
+	"natsrc", "natdst", "rule", "srcuser", "dstuser", "app", "vsys", "from", "to", "inbound_if",
+	"outbound_if", "logset", futureUse, "sessionid", "repeatcnt", "sport", "dport", "natsport",
+	"natdport", "flags", "proto", "action",
+}
+
+// columns are the names of the columns per log type, in the order of the CSV record.
+// Columns that are appended by newer PAN-OS versions are ignored.
+var columns = map[string][]string{
+	TypeTraffic: append(append([]string(nil), commonColumns...),
+		"bytes", "bytes_sent", "bytes_received", "packets", "start", "elapsed", "category", futureUse,
+		"seqno", "actionflags", "srcloc", "dstloc", futureUse, "pkts_sent", "pkts_received",
+		"session_end_reason", "dg_hier_level_1", "dg_hier_level_2", "dg_hier_level_3", "dg_hier_level_4",
+		"vsys_name", "device_name", "action_source",
+	),
+	TypeThreat: append(append([]string(nil), commonColumns...),
+		"misc", "threatid", "category", "severity", "direction", "seqno", "actionflags", "srcloc", "dstloc",
+		futureUse, "contenttype", "pcap_id", "filedigest", "cloud", "url_idx", "user_agent", "filetype", "xff",
+		"referer", "sender", "subject", "recipient", "reportid", "dg_hier_level_1", "dg_hier_level_2",
+		"dg_hier_level_3", "dg_hier_level_4", "vsys_name", "device_name",
+	),
+	TypeSystem: {
+		futureUse, "receive_time", "serial", "type", "subtype", futureUse, "time_generated", "vsys", "eventid",
+		"object", futureUse, futureUse, "module", "severity", "opaque", "seqno", "actionflags",
+		"dg_hier_level_1", "dg_hier_level_2", "dg_hier_level_3", "dg_hier_level_4", "vsys_name",
+		"device_name",
+	},
+}
+
+// minColumns is the minimum amount of columns of a CSV record of any log type (i. e.
+// up to the "action" column of TRAFFIC and THREAT logs and up to the "opaque" column of
+// SYSTEM logs)
+var minColumns = map[string]int{
+	TypeSystem:  15,
+	TypeThreat:  len(commonColumns),
+	TypeTraffic: len(commonColumns),
+}
+
+// Decode decodes the CSV record of a PAN-OS TRAFFIC, THREAT or SYSTEM log message body
+// and stores its named columns as structured data element with the SD-ID SDID. Empty
+// and reserved columns are left out. If the LogMsg has no Hostname or Timestamp, they
+// are taken from the "device_name" column and the "time_generated" column (in the local
+// time zone, as PAN-OS does not include the time zone). Message bodies of other log
+// types or with less than the mandatory columns are not recognized. Decode satisfies
+// the parsesyslog.BodyDecoder type.
+func Decode(lm *parsesyslog.LogMsg) bool {
+	cr := csv.NewReader(strings.NewReader(lm.Message.String()))
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	rec, err := cr.Read()
+	if err != nil || len(rec) <= typeColumn {
+		return false
+	}
+	lt := rec[typeColumn]
+	cols, ok := columns[lt]
+	if !ok || len(rec) < minColumns[lt] {
+		return false
+	}
+
+	sde := parsesyslog.StructuredDataElement{ID: SDID}
+	for i, v := range rec {
+		if i >= len(cols) {
+			break
+		}
+		if cols[i] == futureUse || v == "" {
+			continue
+		}
+		sde.Param = append(sde.Param, parsesyslog.StructuredDataParam{Name: cols[i], Value: v})
+		switch cols[i] {
+		case "device_name":
+			if lm.Hostname == "" {
+				lm.Hostname = v
+			}
+		case "time_generated":
+			if !lm.Timestamp.IsZero() {
+				continue
+			}
+			if ts, err := time.ParseInLocation("2006/01/02 15:04:05", v, time.Local); err == nil {
+				lm.Timestamp = ts
+			}
+		}
+	}
+	lm.StructuredData = append(lm.StructuredData, sde)
+	return true
+}
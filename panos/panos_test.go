@@ -0,0 +1,111 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package panos
+
+import (
+	"testing"
+	"time"
+
+	"github.com/wneessen/go-parsesyslog"
+	"github.com/wneessen/go-parsesyslog/rfc3164"
+)
+
+const (
+	testTraffic = `1,2023/01/10 11:37:47,012801096514,TRAFFIC,end,2561,2023/01/10 11:37:46,10.1.1.2,192.0.2.1,` +
+		`198.51.100.7,192.0.2.1,allow-web,,,ssl,vsys1,trust,untrust,ethernet1/2,ethernet1/1,default,,4711,1,` +
+		`50123,443,31337,443,0x40001c,tcp,allow,5234,1176,4058,20,2023/01/10 11:37:30,16,"computer-and-internet-info,low-risk",` +
+		`0,7045,0x0,10.0.0.0-10.255.255.255,United States,0,11,9,tcp-fin,0,0,0,0,,fw01,from-policy`
+	testThreat = `1,2023/01/10 11:37:47,012801096514,THREAT,url,2561,2023/01/10 11:37:46,10.1.1.2,192.0.2.1,` +
+		`198.51.100.7,192.0.2.1,allow-web,,,web-browsing,vsys1,trust,untrust,ethernet1/2,ethernet1/1,default,,4712,1,` +
+		`50124,80,31338,80,0x40b000,tcp,alert,"example.com/index.html",(9999),news,informational,client-to-server`
+	testSystem = `1,2023/01/10 11:37:47,012801096514,SYSTEM,general,2561,2023/01/10 11:37:46,,general,,0,0,general,` +
+		`informational,"User admin logged in via Web from 10.1.1.5",4713,0x0,0,0,0,0,,fw01`
+)
+
+// TestDecode tests the Decode function
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		decoded bool
+		params  map[string]string
+	}{
+		{
+			"traffic", testTraffic, true, map[string]string{
+				"type": "TRAFFIC", "src": "10.1.1.2", "dport": "443", "action": "allow", "bytes": "5234",
+				"category": "computer-and-internet-info,low-risk", "session_end_reason": "tcp-fin",
+				"device_name": "fw01", "action_source": "from-policy",
+			},
+		},
+		{
+			"threat", testThreat, true, map[string]string{
+				"type": "THREAT", "subtype": "url", "misc": "example.com/index.html", "threatid": "(9999)",
+				"severity": "informational", "direction": "client-to-server",
+			},
+		},
+		{
+			"system", testSystem + "\n", true, map[string]string{
+				"type": "SYSTEM", "eventid": "general", "module": "general",
+				"opaque": "User admin logged in via Web from 10.1.1.5", "device_name": "fw01",
+			},
+		},
+		{"unknown type", "1,2023/01/10 11:37:47,012801096514,CONFIG,0,2561", false, nil},
+		{"too few columns", "1,2023/01/10 11:37:47,012801096514,TRAFFIC,end,2561", false, nil},
+		{"no csv", "User admin logged in", false, nil},
+		{"empty", "", false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var lm parsesyslog.LogMsg
+			lm.Message.WriteString(tt.body)
+			if got := Decode(&lm); got != tt.decoded {
+				t.Fatalf("Decode() => expected: %t, got: %t", tt.decoded, got)
+			}
+			if !tt.decoded {
+				if len(lm.StructuredData) != 0 || lm.Hostname != "" || !lm.Timestamp.IsZero() {
+					t.Errorf("Decode() => expected unchanged LogMsg, got: %+v", lm)
+				}
+				return
+			}
+			if len(lm.StructuredData) != 1 || lm.StructuredData[0].ID != SDID {
+				t.Fatalf("Decode() => unexpected structured data: %+v", lm.StructuredData)
+			}
+			ps := make(map[string]string)
+			for _, p := range lm.StructuredData[0].Param {
+				if p.Name == "" || p.Value == "" {
+					t.Errorf("Decode() => unexpected empty param: %+v", p)
+				}
+				ps[p.Name] = p.Value
+			}
+			for k, v := range tt.params {
+				if ps[k] != v {
+					t.Errorf("Decode() param %s => expected: %q, got: %q", k, v, ps[k])
+				}
+			}
+			want := time.Date(2023, 1, 10, 11, 37, 46, 0, time.Local)
+			if !lm.Timestamp.Equal(want) {
+				t.Errorf("Decode() timestamp => expected: %s, got: %s", want, lm.Timestamp)
+			}
+		})
+	}
+}
+
+// TestDecode_rfc3164 tests Decode as BodyDecoder of the RFC3164 parser
+func TestDecode_rfc3164(t *testing.T) {
+	p, err := parsesyslog.New(rfc3164.Type, parsesyslog.WithBodyDecoders(Decode))
+	if err != nil {
+		t.Fatalf("failed to create new parser: %s", err)
+	}
+	lm, err := p.ParseString("<14>Jan 10 11:37:47 fw01.example.com " + testSystem + "\n")
+	if err != nil {
+		t.Fatalf("ParseString() failed: %s", err)
+	}
+	if len(lm.StructuredData) != 1 || lm.StructuredData[0].ID != SDID {
+		t.Fatalf("ParseString() => unexpected structured data: %+v", lm.StructuredData)
+	}
+	if lm.Hostname != "fw01.example.com" {
+		t.Errorf("ParseString() => hostname of the header expected to be kept, got: %s", lm.Hostname)
+	}
+}
@@ -0,0 +1,58 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// maxSnippetLen is the maximum length of the Snippet of a ParseError
+const maxSnippetLen = 32
+
+// ParseError represents an error that occurred while parsing a field of a log message.
+// It wraps the error of the parser, which is (or wraps) one of the sentinel errors of
+// this package, so that errors.Is and ClassifyError work as with the plain error.
+type ParseError struct {
+	// Err is the error of the parser
+	Err error
+	// Field is the name of the field in which parsing failed, as named by the RFC of the
+	// log format (i. e. "TIMESTAMP" or "STRUCTURED-DATA")
+	Field string
+	// Offset is the byte offset of the field in the log message, starting at the PRI
+	Offset int
+	// Snippet holds the input of the field that has been read until the error occurred,
+	// up to its last 32 bytes. For structured data, this is the current SD-NAME or
+	// PARAM-VALUE.
+	Snippet string
+}
+
+// NewParseError returns a ParseError for the given error, field, offset and input of
+// the field. An io.EOF error is replaced with ErrPrematureEOF, since the field has not
+// been completed. If the error is nil or already a ParseError, it is returned unchanged.
+func NewParseError(err error, field string, offset int, input []byte) error {
+	var pe *ParseError
+	if err == nil || errors.As(err, &pe) {
+		return err
+	}
+	if errors.Is(err, io.EOF) {
+		err = ErrPrematureEOF
+	}
+	if len(input) > maxSnippetLen {
+		input = input[len(input)-maxSnippetLen:]
+	}
+	return &ParseError{Err: err, Field: field, Offset: offset, Snippet: string(input)}
+}
+
+// Error satisfies the error interface for the ParseError type
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s at offset %d in %s (near %q)", e.Err, e.Offset, e.Field, e.Snippet)
+}
+
+// Unwrap returns the error of the parser
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,44 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// TestNewParseError tests the NewParseError function and the methods of the ParseError
+func TestNewParseError(t *testing.T) {
+	if err := NewParseError(nil, "TIMESTAMP", 6, nil); err != nil {
+		t.Errorf("NewParseError() => expected nil, got: %s", err)
+	}
+
+	err := NewParseError(ErrInvalidTimestamp, "TIMESTAMP", 6, []byte("yesterday"))
+	want := `timestamp does not conform the logging format at offset 6 in TIMESTAMP (near "yesterday")`
+	if err.Error() != want {
+		t.Errorf("Error() => expected: %q, got: %q", want, err.Error())
+	}
+	if !errors.Is(err, ErrInvalidTimestamp) || ClassifyError(err) != ErrInvalidTimestamp {
+		t.Errorf("NewParseError() => expected to wrap %s", ErrInvalidTimestamp)
+	}
+	var pe *ParseError
+	if !errors.As(fmt.Errorf("wrapped: %w", err), &pe) || pe.Field != "TIMESTAMP" || pe.Offset != 6 {
+		t.Errorf("NewParseError() => unexpected ParseError: %+v", pe)
+	}
+	if NewParseError(err, "HOSTNAME", 20, nil) != err {
+		t.Errorf("NewParseError() => expected ParseError to be returned unchanged")
+	}
+
+	err = NewParseError(io.EOF, "STRUCTURED-DATA", 40, []byte(strings.Repeat("a", 30)+"0123456789"))
+	if !errors.Is(err, ErrPrematureEOF) || errors.Is(err, io.EOF) {
+		t.Errorf("NewParseError() => expected %s instead of io.EOF, got: %s", ErrPrematureEOF, err)
+	}
+	if errors.As(err, &pe); pe.Snippet != strings.Repeat("a", 22)+"0123456789" {
+		t.Errorf("NewParseError() => expected snippet of the last %d bytes, got: %q", maxSnippetLen, pe.Snippet)
+	}
+}
@@ -7,6 +7,8 @@
 package parsesyslog
 
 import (
+	"bufio"
+	"bytes"
 	"io"
 	"sync"
 )
@@ -17,21 +19,58 @@ var (
 
 	// types is a map of installed message parser types, supplying a function that
 	// creates a new instance of that Parser.
-	types = map[ParserType]func() (Parser, error){}
+	types = map[ParserType]func(Options) (Parser, error){}
 )
 
 // Parser is an interface for parsing log messages.
+//
+// If a log message can not be parsed completely, the Parsers of this module return the
+// LogMsg together with the error, so that broken messages can still be inspected or
+// routed. The Type of such a LogMsg is set, all fields that precede the field the error
+// occurred in (as reported by ParseError.Field) hold their parsed values and the field
+// itself and all following fields are left at their zero value. The post-processing
+// steps of the Options (i. e. WithBodyDecoders or WithSeverityMapper) are not applied to
+// it. The only exception is ErrMessageTooLarge, which is returned with the complete,
+// truncated LogMsg.
 type Parser interface {
 	ParseReader(io.Reader) (LogMsg, error)
 	ParseString(s string) (LogMsg, error)
 }
 
+// ReusingParser is implemented by Parsers that are able to parse a log message into an
+// existing LogMsg. The Parser reuses the Message buffer and the StructuredData slices of
+// the LogMsg, so that parsing a stream of messages into the same LogMsg does not allocate
+// new storage for every message. Since the storage is reused, a LogMsg that is supposed
+// to be retained beyond the next call must be copied with LogMsg.Clone.
+type ReusingParser interface {
+	ParseReaderInto(io.Reader, *LogMsg) error
+}
+
+// BytesParser is implemented by Parsers that are able to parse a log message directly
+// from a byte slice, without wrapping it into new readers for every message. This is
+// the cheapest way to parse log messages that are already in memory (i. e. received
+// datagrams). Unless the Parser has been created with WithZeroCopy, the LogMsg does not
+// reference the byte slice, so it can be reused after the call. See ParseBytes for a
+// function that works with every Parser.
+type BytesParser interface {
+	ParseBytes([]byte) (LogMsg, error)
+}
+
 // ParserType is a type of parser for logs messages
 type ParserType string
 
 // Register registers a new ParserType with its corresponding
 // Parser function.
 func Register(t ParserType, fn func() (Parser, error)) {
+	RegisterWithOptions(t, func(Options) (Parser, error) {
+		return fn()
+	})
+}
+
+// RegisterWithOptions registers a new ParserType with its corresponding
+// Parser function. Other than with Register, the Parser function is
+// handed the Options that have been passed to New.
+func RegisterWithOptions(t ParserType, fn func(Options) (Parser, error)) {
 	lock.Lock()
 	defer lock.Unlock()
 	// if already registered, leave
@@ -44,14 +83,33 @@ func Register(t ParserType, fn func() (Parser, error)) {
 // New returns a Parser of the specified ParserType and an error.
 // It looks up the ParserType in the types map and if found,
 // calls the corresponding Parser function to create a new Parser
-// instance.
+// instance, configured with the given Option functions.
 //
 // If the ParserType is not found in the map, it returns nil
 // and ErrParserTypeUnknown.
-func New(t ParserType) (Parser, error) {
+func New(t ParserType, opts ...Option) (Parser, error) {
+	return NewWithOptions(t, NewOptions(opts...))
+}
+
+// ParseBytes parses the log message in the given byte slice with the given Parser. If the
+// Parser is a BytesParser, its ParseBytes method is used, otherwise the byte slice is
+// handed to its ParseReader method as buffered i/o.
+func ParseBytes(p Parser, b []byte) (LogMsg, error) {
+	if bp, ok := p.(BytesParser); ok {
+		return bp.ParseBytes(b)
+	}
+	return p.ParseReader(bufio.NewReader(bytes.NewReader(b)))
+}
+
+// NewWithOptions works like New, but takes already assembled Options instead of
+// Option functions. This is useful for Parsers that create other Parsers with
+// their own Options.
+func NewWithOptions(t ParserType, o Options) (Parser, error) {
+	lock.RLock()
 	p, ok := types[t]
+	lock.RUnlock()
 	if !ok {
 		return nil, ErrParserTypeUnknown
 	}
-	return p()
+	return p(o)
 }
@@ -0,0 +1,24 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestParseBytes tests ParseBytes with a Parser that is not a BytesParser
+func TestParseBytes(t *testing.T) {
+	lm, err := ParseBytes(lineParser{}, []byte("first line\nsecond line\n"))
+	if err != nil {
+		t.Fatalf("ParseBytes() failed: %s", err)
+	}
+	if lm.Message.String() != "first line" {
+		t.Errorf("ParseBytes() => expected: %q, got: %q", "first line", lm.Message.String())
+	}
+	if _, err := ParseBytes(lineParser{}, []byte("incomplete")); !errors.Is(err, ErrPrematureEOF) {
+		t.Errorf("ParseBytes() => expected: %s, got: %v", ErrPrematureEOF, err)
+	}
+}
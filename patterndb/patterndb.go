@@ -0,0 +1,473 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+// Package patterndb implements the classification of log messages with the rules of a
+// syslog-ng pattern database (patterndb), so that existing classification rules can be
+// reused. The rules are grouped in rulesets, which apply to the messages of the programs
+// given by their program patterns. Every rule has one or more patterns that have to match
+// the whole message body. Patterns consist of literal text and parsers (i. e.
+// "Accepted @ESTRING:usracct.authmethod: @for @ESTRING:usracct.username: @from ..."),
+// which extract the values of the message into named fields.
+package patterndb
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/wneessen/go-parsesyslog"
+)
+
+// SDID is the SD-ID of the structured data element that holds the classification and
+// the extracted fields of a log message
+const SDID = "patterndb@32473"
+
+// Parsers of the patterns
+const (
+	parserAnyString = "ANYSTRING"
+	parserEmail     = "EMAIL"
+	parserEString   = "ESTRING"
+	parserFloat     = "FLOAT"
+	parserHostname  = "HOSTNAME"
+	parserIPv4      = "IPv4"
+	parserIPv6      = "IPv6"
+	parserIPvAny    = "IPvANY"
+	parserMACAddr   = "MACADDR"
+	parserNumber    = "NUMBER"
+	parserQString   = "QSTRING"
+	parserSet       = "SET"
+	parserString    = "STRING"
+)
+
+var (
+	// emailRe matches an email address at the start of a string
+	emailRe = regexp.MustCompile(`^[A-Za-z0-9._%+\-]+@[A-Za-z0-9\-]+(\.[A-Za-z0-9\-]+)*`)
+	// floatRe matches a floating point number at the start of a string
+	floatRe = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?([eE][\-+]?[0-9]+)?`)
+	// macAddrRe matches a MAC address at the start of a string
+	macAddrRe = regexp.MustCompile(`^[0-9A-Fa-f]{2}([:\-][0-9A-Fa-f]{2}){5}`)
+	// numberRe matches a decimal or hexadecimal integer at the start of a string
+	numberRe = regexp.MustCompile(`^(-?0x[0-9A-Fa-f]+|-?[0-9]+)`)
+	// valueRefRe matches the references to extracted fields in the values of a rule
+	valueRefRe = regexp.MustCompile(`\$\{([^}]+)\}`)
+)
+
+// DB is a compiled syslog-ng pattern database. It is safe for concurrent use.
+type DB struct {
+	rulesets []ruleset
+}
+
+// Match is the result of the classification of a log message
+type Match struct {
+	// Class is the class of the matching rule (i. e. "system" or "violation")
+	Class string
+	// Params are the fields that have been extracted by the parsers of the pattern,
+	// followed by the values of the rule
+	Params []parsesyslog.StructuredDataParam
+	// Provider is the provider of the matching rule
+	Provider string
+	// RuleID is the ID of the matching rule
+	RuleID string
+	// Ruleset is the name of the ruleset of the matching rule
+	Ruleset string
+	// Tags are the tags of the matching rule
+	Tags []string
+}
+
+// ruleset is a compiled ruleset of the pattern database
+type ruleset struct {
+	name     string
+	programs []pattern
+	rules    []rule
+}
+
+// rule is a compiled rule of the pattern database
+type rule struct {
+	class    string
+	id       string
+	patterns []pattern
+	provider string
+	tags     []string
+	values   []parsesyslog.StructuredDataParam
+}
+
+// pattern is a compiled pattern, consisting of literal text and parsers
+type pattern struct {
+	literals int
+	tokens   []token
+}
+
+// token is either a literal text or a parser of a pattern
+type token struct {
+	arg    string
+	lit    string
+	name   string
+	parser string
+}
+
+// xmlPatternDB represents the XML document of a pattern database
+type xmlPatternDB struct {
+	XMLName  xml.Name     `xml:"patterndb"`
+	Rulesets []xmlRuleset `xml:"ruleset"`
+}
+
+// xmlRuleset represents a ruleset of a pattern database
+type xmlRuleset struct {
+	Name     string    `xml:"name,attr"`
+	Patterns []string  `xml:"patterns>pattern"`
+	Pattern  []string  `xml:"pattern"`
+	Rules    []xmlRule `xml:"rules>rule"`
+}
+
+// xmlRule represents a rule of a pattern database
+type xmlRule struct {
+	Class    string   `xml:"class,attr"`
+	ID       string   `xml:"id,attr"`
+	Patterns []string `xml:"patterns>pattern"`
+	Provider string   `xml:"provider,attr"`
+	Tags     []string `xml:"tags>tag"`
+	Values   []struct {
+		Name  string `xml:"name,attr"`
+		Value string `xml:",chardata"`
+	} `xml:"values>value"`
+}
+
+// Load reads and compiles the syslog-ng pattern database XML document from the given
+// io.Reader. The parsers ANYSTRING, EMAIL, ESTRING, FLOAT, HOSTNAME, IPv4, IPv6, IPvANY,
+// MACADDR, NUMBER, QSTRING, SET and STRING are supported. If a pattern uses another
+// parser or is malformed, parsesyslog.ErrInvalidPattern is returned.
+func Load(r io.Reader) (*DB, error) {
+	var x xmlPatternDB
+	if err := xml.NewDecoder(r).Decode(&x); err != nil {
+		return nil, fmt.Errorf("failed to decode pattern database: %w", err)
+	}
+	db := &DB{}
+	for _, xrs := range x.Rulesets {
+		rs := ruleset{name: xrs.Name}
+		for _, s := range append(xrs.Patterns, xrs.Pattern...) {
+			p, err := compile(s)
+			if err != nil {
+				return nil, fmt.Errorf("ruleset %q: %w", xrs.Name, err)
+			}
+			rs.programs = append(rs.programs, p)
+		}
+		for _, xr := range xrs.Rules {
+			ru := rule{class: xr.Class, id: xr.ID, provider: xr.Provider, tags: xr.Tags}
+			for _, s := range xr.Patterns {
+				p, err := compile(s)
+				if err != nil {
+					return nil, fmt.Errorf("rule %q: %w", xr.ID, err)
+				}
+				ru.patterns = append(ru.patterns, p)
+			}
+			for _, v := range xr.Values {
+				ru.values = append(ru.values, parsesyslog.StructuredDataParam{Name: v.Name, Value: v.Value})
+			}
+			rs.rules = append(rs.rules, ru)
+		}
+		db.rulesets = append(db.rulesets, rs)
+	}
+	return db, nil
+}
+
+// Match classifies the given message body of the given program with the rules of the
+// rulesets that apply to the program. Rulesets without program patterns apply to all
+// programs. If several rules match, the rule with the most literal text in its pattern
+// wins, as it is the most specific one. The returned bool is false if no rule matches.
+func (db *DB) Match(program, msg string) (Match, bool) {
+	var m Match
+	best := -1
+	for _, rs := range db.rulesets {
+		if len(rs.programs) > 0 && !matchesAny(rs.programs, program) {
+			continue
+		}
+		for _, ru := range rs.rules {
+			for _, p := range ru.patterns {
+				if p.literals <= best {
+					continue
+				}
+				ps, ok := p.match(msg)
+				if !ok {
+					continue
+				}
+				best = p.literals
+				m = Match{
+					Class: ru.class, Params: append(ps, ru.expandValues(ps)...), Provider: ru.provider,
+					RuleID: ru.id, Ruleset: rs.name, Tags: ru.tags,
+				}
+			}
+		}
+	}
+	return m, best >= 0
+}
+
+// Decode classifies the message body of the LogMsg (see Match) by its AppName and
+// stores the "rule_id", "class" and "tags" (comma separated) of the matching rule,
+// followed by the extracted fields and the values of the rule, as structured data
+// element with the SD-ID SDID. A leading BOM and trailing line breaks of the message
+// body are ignored. Message bodies that no rule matches are not recognized. Decode
+// satisfies the parsesyslog.BodyDecoder type.
+func (db *DB) Decode(lm *parsesyslog.LogMsg) bool {
+	msg := strings.TrimRight(strings.TrimPrefix(lm.Message.String(), "\ufeff"), "\r\n")
+	m, ok := db.Match(lm.AppName, msg)
+	if !ok {
+		return false
+	}
+	sde := parsesyslog.StructuredDataElement{ID: SDID}
+	sde.Param = append(sde.Param, parsesyslog.StructuredDataParam{Name: "rule_id", Value: m.RuleID})
+	if m.Class != "" {
+		sde.Param = append(sde.Param, parsesyslog.StructuredDataParam{Name: "class", Value: m.Class})
+	}
+	if len(m.Tags) > 0 {
+		sde.Param = append(sde.Param, parsesyslog.StructuredDataParam{Name: "tags", Value: strings.Join(m.Tags, ",")})
+	}
+	sde.Param = append(sde.Param, m.Params...)
+	lm.StructuredData = append(lm.StructuredData, sde)
+	return true
+}
+
+// expandValues returns the values of the rule with the references to the given extracted
+// fields (i. e. "${usracct.username}") replaced by their values
+func (ru rule) expandValues(ps []parsesyslog.StructuredDataParam) []parsesyslog.StructuredDataParam {
+	if len(ru.values) == 0 {
+		return nil
+	}
+	vs := make([]parsesyslog.StructuredDataParam, len(ru.values))
+	for i, v := range ru.values {
+		vs[i] = parsesyslog.StructuredDataParam{Name: v.Name, Value: valueRefRe.ReplaceAllStringFunc(v.Value,
+			func(ref string) string {
+				n := ref[2 : len(ref)-1]
+				for _, p := range ps {
+					if p.Name == n {
+						return p.Value
+					}
+				}
+				return ref
+			}),
+		}
+	}
+	return vs
+}
+
+// compile compiles the given pattern. Parsers are given as @PARSER:name:arguments@, a
+// literal "@" as "@@".
+func compile(s string) (pattern, error) {
+	var p pattern
+	var lit strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '@' {
+			lit.WriteByte(s[i])
+			continue
+		}
+		e := strings.IndexByte(s[i+1:], '@')
+		if e < 0 {
+			return p, fmt.Errorf("%w: unterminated parser in %q", parsesyslog.ErrInvalidPattern, s)
+		}
+		if e == 0 {
+			lit.WriteByte('@')
+			i++
+			continue
+		}
+		t, err := compileParser(s[i+1 : i+1+e])
+		if err != nil {
+			return p, err
+		}
+		if lit.Len() > 0 {
+			p.tokens = append(p.tokens, token{lit: lit.String()})
+			p.literals += lit.Len()
+			lit.Reset()
+		}
+		p.tokens = append(p.tokens, t)
+		i += e + 1
+	}
+	if lit.Len() > 0 {
+		p.tokens = append(p.tokens, token{lit: lit.String()})
+		p.literals += lit.Len()
+	}
+	return p, nil
+}
+
+// compileParser compiles the given parser of a pattern, without the enclosing "@"
+func compileParser(s string) (token, error) {
+	f := strings.SplitN(s, ":", 3)
+	t := token{parser: f[0]}
+	if len(f) > 1 {
+		t.name = f[1]
+	}
+	if len(f) > 2 {
+		t.arg = f[2]
+	}
+	switch t.parser {
+	case parserAnyString, parserEmail, parserFloat, parserHostname, parserIPv4, parserIPv6, parserIPvAny,
+		parserMACAddr, parserNumber, parserString:
+	case parserEString, parserSet:
+		if t.arg == "" {
+			return t, fmt.Errorf("%w: parser %s requires an argument", parsesyslog.ErrInvalidPattern, t.parser)
+		}
+	case parserQString:
+		if len(t.arg) != 1 && len(t.arg) != 2 {
+			return t, fmt.Errorf("%w: parser %s requires one or two quote characters",
+				parsesyslog.ErrInvalidPattern, t.parser)
+		}
+	default:
+		return t, fmt.Errorf("%w: unsupported parser %q", parsesyslog.ErrInvalidPattern, t.parser)
+	}
+	return t, nil
+}
+
+// matchesAny returns true if one of the given patterns matches the given string
+func matchesAny(ps []pattern, s string) bool {
+	for _, p := range ps {
+		if _, ok := p.match(s); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// match matches the pattern against the whole given string and returns the fields that
+// have been extracted by its named parsers
+func (p pattern) match(s string) ([]parsesyslog.StructuredDataParam, bool) {
+	var ps []parsesyslog.StructuredDataParam
+	if !matchTokens(p.tokens, s, &ps) {
+		return nil, false
+	}
+	return ps, true
+}
+
+// matchTokens matches the given tokens against the whole given string. Parsers that are
+// able to match strings of different lengths are tried from the longest to the shortest
+// match, until the remaining tokens match as well.
+func matchTokens(ts []token, s string, ps *[]parsesyslog.StructuredDataParam) bool {
+	if len(ts) == 0 {
+		return s == ""
+	}
+	t := ts[0]
+	if t.parser == "" {
+		return strings.HasPrefix(s, t.lit) && matchTokens(ts[1:], s[len(t.lit):], ps)
+	}
+	for _, c := range t.candidates(s) {
+		n := len(*ps)
+		if t.name != "" {
+			*ps = append(*ps, parsesyslog.StructuredDataParam{Name: t.name, Value: c.value})
+		}
+		if matchTokens(ts[1:], s[c.length:], ps) {
+			return true
+		}
+		*ps = (*ps)[:n]
+	}
+	return false
+}
+
+// candidate is a possible match of a parser
+type candidate struct {
+	length int
+	value  string
+}
+
+// candidates returns the possible matches of the parser at the start of the given
+// string, from the longest to the shortest
+func (t token) candidates(s string) []candidate {
+	switch t.parser {
+	case parserAnyString:
+		return prefixes(s, len(s), 0)
+	case parserEmail:
+		return single(s, len(emailRe.FindString(s)))
+	case parserEString:
+		i := strings.Index(s, t.arg)
+		if i < 0 {
+			return nil
+		}
+		return []candidate{{length: i + len(t.arg), value: s[:i]}}
+	case parserFloat:
+		return single(s, len(floatRe.FindString(s)))
+	case parserHostname:
+		return prefixes(s, span(s, func(c byte) bool {
+			return isAlnum(c) || c == '-' || c == '.'
+		}), 1)
+	case parserIPv4:
+		return single(s, ipLength(s, true, false))
+	case parserIPv6:
+		return single(s, ipLength(s, false, true))
+	case parserIPvAny:
+		return single(s, ipLength(s, true, true))
+	case parserMACAddr:
+		return single(s, len(macAddrRe.FindString(s)))
+	case parserNumber:
+		return single(s, len(numberRe.FindString(s)))
+	case parserQString:
+		open, cls := t.arg[0], t.arg[len(t.arg)-1]
+		if len(s) == 0 || s[0] != open {
+			return nil
+		}
+		i := strings.IndexByte(s[1:], cls)
+		if i < 0 {
+			return nil
+		}
+		return []candidate{{length: i + 2, value: s[1 : i+1]}}
+	case parserSet:
+		return prefixes(s, span(s, func(c byte) bool { return strings.IndexByte(t.arg, c) >= 0 }), 1)
+	case parserString:
+		return prefixes(s, span(s, func(c byte) bool {
+			return isAlnum(c) || strings.IndexByte(t.arg, c) >= 0
+		}), 1)
+	}
+	return nil
+}
+
+// ipLength returns the length of the IPv4 and/or IPv6 address at the start of the given
+// string, or 0 if there is none
+func ipLength(s string, v4, v6 bool) int {
+	n := span(s, func(c byte) bool {
+		return c == '.' || c == ':' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+	})
+	for ; n > 0; n-- {
+		ip := net.ParseIP(s[:n])
+		if ip == nil {
+			continue
+		}
+		is4 := !strings.Contains(s[:n], ":")
+		if (is4 && v4) || (!is4 && v6) {
+			return n
+		}
+	}
+	return 0
+}
+
+// prefixes returns the candidates of the prefixes of the given string with lengths from
+// hi down to lo
+func prefixes(s string, hi, lo int) []candidate {
+	var cs []candidate
+	for n := hi; n >= lo; n-- {
+		cs = append(cs, candidate{length: n, value: s[:n]})
+	}
+	return cs
+}
+
+// single returns the candidate of the prefix of the given string with the given length,
+// or no candidate, if the length is 0
+func single(s string, n int) []candidate {
+	if n == 0 {
+		return nil
+	}
+	return []candidate{{length: n, value: s[:n]}}
+}
+
+// span returns the length of the prefix of the given string that consists of bytes for
+// which the given function returns true
+func span(s string, fn func(byte) bool) int {
+	n := 0
+	for n < len(s) && fn(s[n]) {
+		n++
+	}
+	return n
+}
+
+// isAlnum returns true if the given byte is an ASCII letter or digit
+func isAlnum(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
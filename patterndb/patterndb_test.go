@@ -0,0 +1,179 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package patterndb
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/wneessen/go-parsesyslog"
+	"github.com/wneessen/go-parsesyslog/rfc3164"
+)
+
+const testDB = `<?xml version='1.0' encoding='UTF-8'?>
+<patterndb version='4' pub_date='2023-01-10'>
+  <ruleset name='sshd' id='ssh-ruleset'>
+    <patterns>
+      <pattern>sshd</pattern>
+    </patterns>
+    <rules>
+      <rule provider='example' id='ssh-accepted' class='system'>
+        <patterns>
+          <pattern>Accepted @ESTRING:usracct.authmethod: @for @ESTRING:usracct.username: @from @IPvANY:usracct.device@ port @NUMBER:usracct.port@ @ANYSTRING:usracct.protocol@</pattern>
+        </patterns>
+        <tags>
+          <tag>usracct</tag>
+          <tag>secevt</tag>
+        </tags>
+        <values>
+          <value name='secevt.verdict'>ACCEPT</value>
+          <value name='usracct.summary'>${usracct.username} from ${usracct.device}</value>
+        </values>
+      </rule>
+      <rule provider='example' id='ssh-generic' class='system'>
+        <patterns>
+          <pattern>@ANYSTRING:message@</pattern>
+        </patterns>
+      </rule>
+    </rules>
+  </ruleset>
+  <ruleset name='any' id='any-ruleset'>
+    <rules>
+      <rule provider='example' id='link-down' class='violation'>
+        <patterns>
+          <pattern>Interface @STRING:ifname:/@ (@MACADDR:mac@) is down, "@@" @QSTRING:reason:""@ @FLOAT:uptime@s @HOSTNAME:peer@ @SET:flags:+-@ @EMAIL:contact@</pattern>
+        </patterns>
+      </rule>
+    </rules>
+  </ruleset>
+</patterndb>`
+
+// load loads the test pattern database
+func load(t *testing.T) *DB {
+	t.Helper()
+	db, err := Load(strings.NewReader(testDB))
+	if err != nil {
+		t.Fatalf("Load() failed: %s", err)
+	}
+	return db
+}
+
+// TestDB_Match tests the classification of messages
+func TestDB_Match(t *testing.T) {
+	db := load(t)
+	tests := []struct {
+		name    string
+		program string
+		msg     string
+		rule    string
+		params  map[string]string
+	}{
+		{
+			"specific rule", "sshd", "Accepted password for jdoe from 10.1.1.5 port 50123 ssh2", "ssh-accepted",
+			map[string]string{
+				"usracct.authmethod": "password", "usracct.username": "jdoe", "usracct.device": "10.1.1.5",
+				"usracct.port": "50123", "usracct.protocol": "ssh2", "secevt.verdict": "ACCEPT",
+				"usracct.summary": "jdoe from 10.1.1.5",
+			},
+		},
+		{
+			"ipv6", "sshd", "Accepted publickey for root from 2001:db8::1 port 22 ssh2", "ssh-accepted",
+			map[string]string{"usracct.device": "2001:db8::1", "usracct.authmethod": "publickey"},
+		},
+		{
+			"generic rule", "sshd", "Accepted password for jdoe from nowhere port 22 ssh2", "ssh-generic",
+			map[string]string{"message": "Accepted password for jdoe from nowhere port 22 ssh2"},
+		},
+		{
+			"ruleset without program", "kernel",
+			`Interface eth0/1 (00:1a:2b:3c:4d:5e) is down, "@" "carrier lost" 12.5s sw01.example.com +-+ ` +
+				`noc@example.com`, "link-down",
+			map[string]string{
+				"ifname": "eth0/1", "mac": "00:1a:2b:3c:4d:5e", "reason": "carrier lost", "uptime": "12.5",
+				"peer": "sw01.example.com", "flags": "+-+", "contact": "noc@example.com",
+			},
+		},
+		{"other program", "cron", "Accepted password for jdoe from 10.1.1.5 port 50123 ssh2", "", nil},
+		{"no match", "kernel", "Interface eth0 is up", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, ok := db.Match(tt.program, tt.msg)
+			if ok != (tt.rule != "") {
+				t.Fatalf("Match() => expected match: %t, got: %t (%+v)", tt.rule != "", ok, m)
+			}
+			if !ok {
+				return
+			}
+			if m.RuleID != tt.rule {
+				t.Errorf("Match() rule => expected: %s, got: %s", tt.rule, m.RuleID)
+			}
+			ps := make(map[string]string)
+			for _, p := range m.Params {
+				ps[p.Name] = p.Value
+			}
+			for k, v := range tt.params {
+				if ps[k] != v {
+					t.Errorf("Match() param %s => expected: %q, got: %q", k, v, ps[k])
+				}
+			}
+		})
+	}
+}
+
+// TestLoad_invalid tests that invalid pattern databases are rejected
+func TestLoad_invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+	}{
+		{"unsupported parser", "@PCRE:x:[a-z]+@"},
+		{"unterminated parser", "Accepted @STRING:x"},
+		{"estring without delimiter", "@ESTRING:x@"},
+		{"qstring without quotes", "@QSTRING:x@"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x := `<patterndb version='4'><ruleset name='r'><rules><rule id='1'><patterns><pattern>` +
+				tt.pattern + `</pattern></patterns></rule></rules></ruleset></patterndb>`
+			if _, err := Load(strings.NewReader(x)); !errors.Is(err, parsesyslog.ErrInvalidPattern) {
+				t.Errorf("Load() => expected: %s, got: %v", parsesyslog.ErrInvalidPattern, err)
+			}
+		})
+	}
+	if _, err := Load(strings.NewReader("<patterndb>")); err == nil {
+		t.Errorf("Load() with malformed XML expected to fail")
+	}
+}
+
+// TestDB_Decode tests Decode as BodyDecoder of the RFC3164 parser
+func TestDB_Decode(t *testing.T) {
+	db := load(t)
+	p, err := parsesyslog.New(rfc3164.Type, parsesyslog.WithBodyDecoders(db.Decode))
+	if err != nil {
+		t.Fatalf("failed to create new parser: %s", err)
+	}
+	lm, err := p.ParseString("<38>Jan 10 11:37:47 host01 sshd[4711]: Accepted password for jdoe from 10.1.1.5 " +
+		"port 50123 ssh2\n")
+	if err != nil {
+		t.Fatalf("ParseString() failed: %s", err)
+	}
+	if len(lm.StructuredData) != 1 || lm.StructuredData[0].ID != SDID {
+		t.Fatalf("ParseString() => unexpected structured data: %+v", lm.StructuredData)
+	}
+	ps := lm.StructuredData[0].Param
+	if len(ps) != 10 || ps[0].Value != "ssh-accepted" || ps[1].Value != "system" || ps[2].Value != "usracct,secevt" {
+		t.Errorf("ParseString() => unexpected params: %+v", ps)
+	}
+
+	lm, err = p.ParseString("<38>Jan 10 11:37:47 host01 cron[4711]: (root) CMD (run-parts /etc/cron.hourly)\n")
+	if err != nil {
+		t.Fatalf("ParseString() failed: %s", err)
+	}
+	if len(lm.StructuredData) != 0 {
+		t.Errorf("ParseString() => expected no structured data, got: %+v", lm.StructuredData)
+	}
+}
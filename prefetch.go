@@ -0,0 +1,110 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"io"
+)
+
+// prefetchChunkSize is the size of the chunks that are read ahead of time by the
+// prefetchReader
+const prefetchChunkSize = 32 << 10
+
+// prefetchReader is an io.Reader that reads chunks of the underlying io.Reader ahead of
+// time on a separate goroutine, so that reading the next messages of a stream overlaps
+// with the parsing of the current message
+type prefetchReader struct {
+	bufs   [][]byte
+	chunks chan prefetchedChunk
+	cur    []byte
+	done   chan struct{}
+	err    error
+}
+
+// prefetchedChunk represents a chunk that has been read ahead of time
+type prefetchedChunk struct {
+	data []byte
+	err  error
+}
+
+// WithStreamPrefetch makes the StreamParser read up to n chunks of the stream ahead of
+// time on a separate goroutine, while the current message is being parsed. This overlaps
+// the I/O of the stream with the CPU work of parsing, which increases the throughput for
+// network and disk sources. A value of 0 disables prefetching, which is the default.
+//
+// The prefetching goroutine terminates once the io.Reader returns an error (i. e. io.EOF).
+// To stop it earlier, the StreamParser must be closed with Close and the underlying
+// io.Reader should be closed as well, so that a pending read returns.
+func WithStreamPrefetch(n int) StreamOption {
+	return func(s *StreamParser) {
+		s.prefetch = n
+	}
+}
+
+// newPrefetchReader returns a new prefetchReader that reads up to n chunks of the given
+// io.Reader ahead of time
+func newPrefetchReader(r io.Reader, n int) *prefetchReader {
+	// The caller holds one chunk, the channel holds up to n chunks and the goroutine
+	// fills one, so n+2 buffers can be safely used in turns
+	p := &prefetchReader{
+		bufs:   make([][]byte, n+2),
+		chunks: make(chan prefetchedChunk, n),
+		done:   make(chan struct{}),
+	}
+	for i := range p.bufs {
+		p.bufs[i] = make([]byte, prefetchChunkSize)
+	}
+	go p.run(r, p.done)
+	return p
+}
+
+// Read satisfies the io.Reader interface for the prefetchReader
+func (p *prefetchReader) Read(b []byte) (int, error) {
+	for len(p.cur) == 0 {
+		if p.err != nil {
+			return 0, p.err
+		}
+		c, ok := <-p.chunks
+		if !ok {
+			p.err = io.EOF
+			continue
+		}
+		p.cur, p.err = c.data, c.err
+	}
+	n := copy(b, p.cur)
+	p.cur = p.cur[n:]
+	return n, nil
+}
+
+// close stops the prefetching goroutine. Any further read returns io.EOF
+func (p *prefetchReader) close() {
+	if p.done == nil {
+		return
+	}
+	close(p.done)
+	p.done = nil
+	p.cur, p.err = nil, io.EOF
+}
+
+// run reads chunks from the given io.Reader until it returns an error or the given
+// done channel is closed
+func (p *prefetchReader) run(r io.Reader, done <-chan struct{}) {
+	defer close(p.chunks)
+	for i := 0; ; i++ {
+		buf := p.bufs[i%len(p.bufs)]
+		n, err := r.Read(buf)
+		if n == 0 && err == nil {
+			continue
+		}
+		select {
+		case p.chunks <- prefetchedChunk{data: buf[:n], err: err}:
+		case <-done:
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
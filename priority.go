@@ -56,6 +56,31 @@ const (
 	Local7                           // Locally used facilities
 )
 
+// PriorityFrom returns the Priority, as encoded in the PRI header, of the given Facility
+// and Severity. If one of them is out of range (see Facility.Valid and Severity.Valid),
+// the returned Priority is -1, which is not valid either.
+func PriorityFrom(f Facility, s Severity) Priority {
+	if !f.Valid() || !s.Valid() {
+		return -1
+	}
+	return Priority(f)<<3 | Priority(s)
+}
+
+// Valid returns true if the Priority is in the range of 0 to 191
+func (p Priority) Valid() bool {
+	return p >= 0 && p <= Local7|Debug
+}
+
+// Valid returns true if the Facility is in the range of 0 (KERN) to 23 (LOCAL7)
+func (f Facility) Valid() bool {
+	return f >= 0 && f <= FacilityFromPrio(Local7)
+}
+
+// Valid returns true if the Severity is in the range of 0 (EMERGENCY) to 7 (DEBUG)
+func (s Severity) Valid() bool {
+	return s >= 0 && s <= SeverityFromPrio(Debug)
+}
+
 // FacilityFromPrio extracts the Facility from a given Priority
 func FacilityFromPrio(p Priority) Facility {
 	return Facility(p >> 3)
@@ -141,3 +141,39 @@ func TestSeverityStringFromPrio(t *testing.T) {
 		})
 	}
 }
+
+// TestPriorityFrom tests the PriorityFrom function and the Valid methods
+func TestPriorityFrom(t *testing.T) {
+	tests := []struct {
+		name     string
+		facility Facility
+		severity Severity
+		want     Priority
+		valid    bool
+	}{
+		{"Kern/Emergency", 0, 0, Kern | Emergency, true},
+		{"Local4/Notice", 20, 5, Local4 | Notice, true},
+		{"Local7/Debug", 23, 7, Local7 | Debug, true},
+		{"invalid facility", 24, 0, -1, false},
+		{"negative facility", -1, 0, -1, false},
+		{"invalid severity", 1, 8, -1, false},
+		{"negative severity", 1, -1, -1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := PriorityFrom(tt.facility, tt.severity)
+			if p != tt.want {
+				t.Errorf("PriorityFrom() => expected: %d, got: %d", tt.want, p)
+			}
+			if p.Valid() != tt.valid {
+				t.Errorf("Valid() => expected: %t, got: %t", tt.valid, p.Valid())
+			}
+			if tt.valid && (FacilityFromPrio(p) != tt.facility || SeverityFromPrio(p) != tt.severity) {
+				t.Errorf("PriorityFrom() => %d does not decode to %s/%s", p, tt.facility, tt.severity)
+			}
+		})
+	}
+	if Priority(192).Valid() {
+		t.Error("Valid() => expected priority 192 to be invalid")
+	}
+}
@@ -0,0 +1,483 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Protocol Buffers wire types
+// See: https://protobuf.dev/programming-guides/encoding/#structure
+const (
+	pbVarint = 0
+	pbI64    = 1
+	pbLen    = 2
+	pbI32    = 5
+)
+
+// Field numbers of the LogMsg message of logmsg.proto
+const (
+	pbSchema           = 1
+	pbAppName          = 2
+	pbExtraBytes       = 3
+	pbFacility         = 4
+	pbHasBOM           = 5
+	pbHostname         = 6
+	pbMessage          = 7
+	pbMessageRaw       = 8
+	pbMsgID            = 9
+	pbOrigSeverity     = 10
+	pbPriority         = 11
+	pbProcID           = 12
+	pbProtoVersion     = 13
+	pbRelaxed          = 14
+	pbResolvedHost     = 15
+	pbSeverity         = 16
+	pbSpanID           = 17
+	pbStructuredData   = 18
+	pbTimestamp        = 19
+	pbTimestampOffset  = 20
+	pbTraceID          = 21
+	pbTraceState       = 22
+	pbTruncated        = 23
+	pbType             = 24
+	pbSDID             = 1
+	pbSDParams         = 2
+	pbSDParamName      = 1
+	pbSDParamValue     = 2
+	pbTimestampSeconds = 1
+	pbTimestampNanos   = 2
+)
+
+// MarshalProtobuf returns the Protocol Buffers representation of the LogMsg, as defined
+// by the LogMsg message of logmsg.proto. It uses the same field names and SchemaVersion
+// as the JSON representation and is implemented without external dependencies, so that
+// consumers with generated code can read archives and forwarded messages. Like with
+// JSON, invalid UTF-8 in string fields is replaced by the Unicode replacement character.
+// See: https://protobuf.dev/programming-guides/encoding/
+func (l LogMsg) MarshalProtobuf() ([]byte, error) {
+	var buf bytes.Buffer
+	pbUint(&buf, pbSchema, SchemaVersion)
+	pbString(&buf, pbAppName, l.AppName)
+	pbUint(&buf, pbExtraBytes, uint64(l.ExtraBytes))
+	pbUint(&buf, pbFacility, uint64(l.Facility))
+	pbBool(&buf, pbHasBOM, l.HasBOM)
+	pbString(&buf, pbHostname, l.Hostname)
+	if mb := l.Message.Bytes(); utf8.Valid(mb) {
+		pbBytes(&buf, pbMessage, mb)
+	} else {
+		pbBytes(&buf, pbMessageRaw, mb)
+	}
+	pbString(&buf, pbMsgID, l.MsgID)
+	if l.OriginalSeverity != nil {
+		pbKey(&buf, pbOrigSeverity, pbVarint)
+		pbAppendVarint(&buf, uint64(*l.OriginalSeverity))
+	}
+	pbUint(&buf, pbPriority, uint64(l.Priority))
+	pbString(&buf, pbProcID, l.ProcID)
+	pbUint(&buf, pbProtoVersion, uint64(l.ProtoVersion))
+	pbUint(&buf, pbRelaxed, uint64(l.Relaxed))
+	pbString(&buf, pbResolvedHost, l.ResolvedHost)
+	pbUint(&buf, pbSeverity, uint64(l.Severity))
+	pbString(&buf, pbSpanID, l.SpanID)
+	var sub bytes.Buffer
+	for _, e := range l.StructuredData {
+		sub.Reset()
+		pbString(&sub, pbSDID, e.ID)
+		for _, p := range e.Param {
+			var pb bytes.Buffer
+			pbString(&pb, pbSDParamName, p.Name)
+			pbString(&pb, pbSDParamValue, p.Value)
+			pbBytes(&sub, pbSDParams, pb.Bytes())
+		}
+		pbBytes(&buf, pbStructuredData, sub.Bytes())
+	}
+	if !l.Timestamp.IsZero() {
+		sub.Reset()
+		pbUint(&sub, pbTimestampSeconds, uint64(l.Timestamp.Unix()))
+		pbUint(&sub, pbTimestampNanos, uint64(l.Timestamp.Nanosecond()))
+		pbBytes(&buf, pbTimestamp, sub.Bytes())
+		_, off := l.Timestamp.Zone()
+		pbUint(&buf, pbTimestampOffset, pbZigZag(int64(off)))
+	}
+	pbString(&buf, pbTraceID, l.TraceID)
+	pbString(&buf, pbTraceState, l.TraceState)
+	pbBool(&buf, pbTruncated, l.Truncated)
+	pbString(&buf, pbType, string(l.Type))
+	return buf.Bytes(), nil
+}
+
+// UnmarshalProtobuf sets the LogMsg to the given Protocol Buffers representation. Unknown
+// fields are skipped as defined by the compatibility policy of the SchemaVersion, data of
+// a newer SchemaVersion is rejected with ErrUnsupportedSchemaVersion.
+func (l *LogMsg) UnmarshalProtobuf(b []byte) error {
+	l.Reset()
+	if err := l.fromProtobuf(b); err != nil {
+		l.Reset()
+		return err
+	}
+	return nil
+}
+
+// MigrateProtobuf converts the given Protocol Buffers representation of a LogMsg of any
+// supported SchemaVersion to the current SchemaVersion. This allows to upgrade archives
+// in place.
+func MigrateProtobuf(b []byte) ([]byte, error) {
+	var l LogMsg
+	if err := l.UnmarshalProtobuf(b); err != nil {
+		return nil, err
+	}
+	return l.MarshalProtobuf()
+}
+
+// fromProtobuf decodes the fields of the given Protocol Buffers representation into
+// the LogMsg
+func (l *LogMsg) fromProtobuf(b []byte) error {
+	d := &pbDecoder{b: b}
+	var schema, off, v uint64
+	var ts time.Time
+	var raw []byte
+	for d.p < len(d.b) {
+		num, wt, err := d.key()
+		if err != nil {
+			return err
+		}
+		switch num {
+		case pbSchema:
+			schema, err = d.uint(num, wt)
+		case pbAppName:
+			l.AppName, err = d.string(num, wt)
+		case pbExtraBytes:
+			v, err = d.uint(num, wt)
+			l.ExtraBytes = int(v)
+		case pbFacility:
+			v, err = d.uint(num, wt)
+			l.Facility = Facility(v)
+		case pbHasBOM:
+			v, err = d.uint(num, wt)
+			l.HasBOM = v != 0
+		case pbHostname:
+			l.Hostname, err = d.string(num, wt)
+		case pbMessage, pbMessageRaw:
+			raw, err = d.bytes(num, wt)
+			l.Message.Reset()
+			l.Message.Write(raw)
+		case pbMsgID:
+			l.MsgID, err = d.string(num, wt)
+		case pbOrigSeverity:
+			v, err = d.uint(num, wt)
+			s := Severity(v)
+			l.OriginalSeverity = &s
+		case pbPriority:
+			v, err = d.uint(num, wt)
+			l.Priority = Priority(v)
+		case pbProcID:
+			l.ProcID, err = d.string(num, wt)
+		case pbProtoVersion:
+			v, err = d.uint(num, wt)
+			l.ProtoVersion = ProtoVersion(v)
+		case pbRelaxed:
+			v, err = d.uint(num, wt)
+			l.Relaxed = Relaxation(v)
+		case pbResolvedHost:
+			l.ResolvedHost, err = d.string(num, wt)
+		case pbSeverity:
+			v, err = d.uint(num, wt)
+			l.Severity = Severity(v)
+		case pbSpanID:
+			l.SpanID, err = d.string(num, wt)
+		case pbStructuredData:
+			if raw, err = d.bytes(num, wt); err == nil {
+				var e StructuredDataElement
+				e, err = pbStructuredDataElement(raw)
+				l.StructuredData = append(l.StructuredData, e)
+			}
+		case pbTimestamp:
+			if raw, err = d.bytes(num, wt); err == nil {
+				ts, err = pbTime(raw)
+			}
+		case pbTimestampOffset:
+			off, err = d.uint(num, wt)
+		case pbTraceID:
+			l.TraceID, err = d.string(num, wt)
+		case pbTraceState:
+			l.TraceState, err = d.string(num, wt)
+		case pbTruncated:
+			v, err = d.uint(num, wt)
+			l.Truncated = v != 0
+		case pbType:
+			var t string
+			t, err = d.string(num, wt)
+			l.Type = LogMsgType(t)
+		default:
+			err = d.skip(wt)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if schema == 0 {
+		schema = 1
+	}
+	if schema > SchemaVersion {
+		return fmt.Errorf("%w: %d (supported: %d)", ErrUnsupportedSchemaVersion, schema, SchemaVersion)
+	}
+	if !ts.IsZero() {
+		loc := time.UTC
+		if o := int64(off>>1) ^ -int64(off&1); o != 0 {
+			loc = time.FixedZone("", int(o))
+		}
+		l.Timestamp = ts.In(loc)
+	}
+	l.MsgLength = l.Message.Len()
+	return nil
+}
+
+// pbStructuredDataElement decodes a StructuredDataElement message
+func pbStructuredDataElement(b []byte) (StructuredDataElement, error) {
+	var e StructuredDataElement
+	d := &pbDecoder{b: b}
+	for d.p < len(d.b) {
+		num, wt, err := d.key()
+		if err != nil {
+			return e, err
+		}
+		switch num {
+		case pbSDID:
+			v, err := d.bytes(num, wt)
+			if err != nil {
+				return e, err
+			}
+			e.ID = string(v)
+		case pbSDParams:
+			v, err := d.bytes(num, wt)
+			if err != nil {
+				return e, err
+			}
+			p, err := pbStructuredDataParam(v)
+			if err != nil {
+				return e, err
+			}
+			e.Param = append(e.Param, p)
+		default:
+			if err := d.skip(wt); err != nil {
+				return e, err
+			}
+		}
+	}
+	return e, nil
+}
+
+// pbStructuredDataParam decodes a StructuredDataParam message
+func pbStructuredDataParam(b []byte) (StructuredDataParam, error) {
+	var p StructuredDataParam
+	d := &pbDecoder{b: b}
+	for d.p < len(d.b) {
+		num, wt, err := d.key()
+		if err != nil {
+			return p, err
+		}
+		switch num {
+		case pbSDParamName, pbSDParamValue:
+			v, err := d.bytes(num, wt)
+			if err != nil {
+				return p, err
+			}
+			if num == pbSDParamName {
+				p.Name = string(v)
+			} else {
+				p.Value = string(v)
+			}
+		default:
+			if err := d.skip(wt); err != nil {
+				return p, err
+			}
+		}
+	}
+	return p, nil
+}
+
+// pbTime decodes a google.protobuf.Timestamp message
+func pbTime(b []byte) (time.Time, error) {
+	var sec, nsec int64
+	d := &pbDecoder{b: b}
+	for d.p < len(d.b) {
+		num, wt, err := d.key()
+		if err != nil {
+			return time.Time{}, err
+		}
+		switch num {
+		case pbTimestampSeconds, pbTimestampNanos:
+			v, err := d.uint(num, wt)
+			if err != nil {
+				return time.Time{}, err
+			}
+			if num == pbTimestampSeconds {
+				sec = int64(v)
+			} else {
+				nsec = int64(int32(v))
+			}
+		default:
+			if err := d.skip(wt); err != nil {
+				return time.Time{}, err
+			}
+		}
+	}
+	if nsec < 0 || nsec >= int64(time.Second) {
+		return time.Time{}, fmt.Errorf("%w: invalid timestamp nanos: %d", ErrInvalidEncoding, nsec)
+	}
+	return time.Unix(sec, nsec), nil
+}
+
+// pbKey writes the key of a field with the given number and wire type
+func pbKey(buf *bytes.Buffer, num int, wt int) {
+	pbAppendVarint(buf, uint64(num)<<3|uint64(wt))
+}
+
+// pbAppendVarint writes the given value as base 128 varint
+func pbAppendVarint(buf *bytes.Buffer, v uint64) {
+	for v >= 0x80 {
+		buf.WriteByte(byte(v) | 0x80)
+		v >>= 7
+	}
+	buf.WriteByte(byte(v))
+}
+
+// pbUint writes a varint field, unless it has the default value 0
+func pbUint(buf *bytes.Buffer, num int, v uint64) {
+	if v == 0 {
+		return
+	}
+	pbKey(buf, num, pbVarint)
+	pbAppendVarint(buf, v)
+}
+
+// pbBool writes a bool field, unless it has the default value false
+func pbBool(buf *bytes.Buffer, num int, v bool) {
+	if v {
+		pbUint(buf, num, 1)
+	}
+}
+
+// pbString writes a string field, unless it is empty. Invalid UTF-8 is replaced by the
+// Unicode replacement character, since Protocol Buffers strings must be valid UTF-8.
+func pbString(buf *bytes.Buffer, num int, s string) {
+	if s == "" {
+		return
+	}
+	if !utf8.ValidString(s) {
+		s = strings.ToValidUTF8(s, "\uFFFD")
+	}
+	pbKey(buf, num, pbLen)
+	pbAppendVarint(buf, uint64(len(s)))
+	buf.WriteString(s)
+}
+
+// pbBytes writes a length-delimited field, even if it is empty
+func pbBytes(buf *bytes.Buffer, num int, b []byte) {
+	pbKey(buf, num, pbLen)
+	pbAppendVarint(buf, uint64(len(b)))
+	buf.Write(b)
+}
+
+// pbZigZag returns the ZigZag encoding of the given signed integer, as it is used for
+// sint32 and sint64 fields
+func pbZigZag(v int64) uint64 {
+	return uint64(v<<1) ^ uint64(v>>63)
+}
+
+// pbDecoder decodes the fields of a Protocol Buffers message
+type pbDecoder struct {
+	b []byte
+	p int
+}
+
+// varint reads a base 128 varint
+func (d *pbDecoder) varint() (uint64, error) {
+	var v uint64
+	for i := uint(0); i < 64; i += 7 {
+		if d.p >= len(d.b) {
+			return 0, fmt.Errorf("%w: truncated varint", ErrInvalidEncoding)
+		}
+		c := d.b[d.p]
+		d.p++
+		v |= uint64(c&0x7f) << i
+		if c < 0x80 {
+			return v, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: varint overflow", ErrInvalidEncoding)
+}
+
+// key reads the key of the next field and returns its number and wire type
+func (d *pbDecoder) key() (int, int, error) {
+	k, err := d.varint()
+	if err != nil {
+		return 0, 0, err
+	}
+	if k>>3 == 0 || k>>3 > 1<<29-1 {
+		return 0, 0, fmt.Errorf("%w: invalid field number %d", ErrInvalidEncoding, k>>3)
+	}
+	return int(k >> 3), int(k & 7), nil
+}
+
+// uint reads the value of the varint field with the given number
+func (d *pbDecoder) uint(num, wt int) (uint64, error) {
+	if wt != pbVarint {
+		return 0, fmt.Errorf("%w: invalid wire type %d of field %d", ErrInvalidEncoding, wt, num)
+	}
+	return d.varint()
+}
+
+// string reads the value of the string field with the given number
+func (d *pbDecoder) string(num, wt int) (string, error) {
+	b, err := d.bytes(num, wt)
+	return string(b), err
+}
+
+// bytes reads the value of the length-delimited field with the given number
+func (d *pbDecoder) bytes(num, wt int) ([]byte, error) {
+	if wt != pbLen {
+		return nil, fmt.Errorf("%w: invalid wire type %d of field %d", ErrInvalidEncoding, wt, num)
+	}
+	n, err := d.varint()
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(len(d.b)-d.p) {
+		return nil, fmt.Errorf("%w: truncated field %d", ErrInvalidEncoding, num)
+	}
+	b := d.b[d.p : d.p+int(n)]
+	d.p += int(n)
+	return b, nil
+}
+
+// skip skips the value of an unknown field with the given wire type
+func (d *pbDecoder) skip(wt int) error {
+	var n int
+	switch wt {
+	case pbVarint:
+		_, err := d.varint()
+		return err
+	case pbLen:
+		_, err := d.bytes(0, wt)
+		return err
+	case pbI64:
+		n = 8
+	case pbI32:
+		n = 4
+	default:
+		return fmt.Errorf("%w: unsupported wire type %d", ErrInvalidEncoding, wt)
+	}
+	if n > len(d.b)-d.p {
+		return fmt.Errorf("%w: truncated field", ErrInvalidEncoding)
+	}
+	d.p += n
+	return nil
+}
@@ -0,0 +1,151 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestLogMsg_MarshalProtobuf tests the MarshalProtobuf and UnmarshalProtobuf methods of
+// the LogMsg
+func TestLogMsg_MarshalProtobuf(t *testing.T) {
+	for _, msg := range []string{"Hello, World!", "binary\xff\xfe", "", strings.Repeat("x", 70000)} {
+		lm := testSerialLogMsg(msg)
+		b, err := lm.MarshalProtobuf()
+		if err != nil {
+			t.Fatalf("MarshalProtobuf() failed: %s", err)
+		}
+		var got LogMsg
+		if err := got.UnmarshalProtobuf(b); err != nil {
+			t.Fatalf("UnmarshalProtobuf() failed: %s", err)
+		}
+		checkSerialRoundTrip(t, lm, got)
+	}
+}
+
+// TestLogMsg_MarshalProtobuf_timestamp tests that the time zone offset of the timestamp
+// survives the round trip
+func TestLogMsg_MarshalProtobuf_timestamp(t *testing.T) {
+	for _, ts := range []time.Time{
+		time.Date(2003, 10, 11, 22, 14, 15, 3000000, time.UTC),
+		time.Date(2003, 10, 11, 22, 14, 15, 0, time.FixedZone("", 5*3600+30*60)),
+		time.Date(1969, 12, 31, 23, 59, 59, 999999999, time.FixedZone("", -3600)),
+	} {
+		lm := LogMsg{Timestamp: ts}
+		b, err := lm.MarshalProtobuf()
+		if err != nil {
+			t.Fatalf("MarshalProtobuf() failed: %s", err)
+		}
+		var got LogMsg
+		if err := got.UnmarshalProtobuf(b); err != nil {
+			t.Fatalf("UnmarshalProtobuf() failed: %s", err)
+		}
+		if got.Timestamp.Format(time.RFC3339Nano) != ts.Format(time.RFC3339Nano) {
+			t.Errorf("round trip wrong timestamp => expected: %s, got: %s", ts, got.Timestamp)
+		}
+	}
+}
+
+// TestLogMsg_MarshalProtobuf_encoding tests the encoding of MarshalProtobuf against a
+// known Protocol Buffers representation
+func TestLogMsg_MarshalProtobuf_encoding(t *testing.T) {
+	lm := LogMsg{Priority: 34, Facility: 4, Severity: 2}
+	lm.Message.WriteString("a")
+	b, err := lm.MarshalProtobuf()
+	if err != nil {
+		t.Fatalf("MarshalProtobuf() failed: %s", err)
+	}
+	// schema: 1, facility: 4, message: "a", priority: 34, severity: 2
+	want := "0801" + "2004" + "3a0161" + "5822" + "800102"
+	if hex.EncodeToString(b) != want {
+		t.Errorf("MarshalProtobuf() => expected: %s, got: %s", want, hex.EncodeToString(b))
+	}
+}
+
+// TestLogMsg_UnmarshalProtobuf tests the UnmarshalProtobuf method with valid data that
+// has not been written by MarshalProtobuf
+func TestLogMsg_UnmarshalProtobuf(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		wantHost string
+		wantSev  Severity
+	}{
+		{"empty", "", "", 0},
+		{"without schema", "32046873743180010a", "hst1", 10},
+		{"unknown fields", "f8070132046873743191030000000000000000fa0700fd07000000008001038a080178", "hst1", 3},
+		{"repeated field", "320161320268328001018001ff01", "h2", 255},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := hex.DecodeString(tt.data)
+			if err != nil {
+				t.Fatalf("invalid test data: %s", err)
+			}
+			var lm LogMsg
+			if err := lm.UnmarshalProtobuf(b); err != nil {
+				t.Fatalf("UnmarshalProtobuf() failed: %s", err)
+			}
+			if lm.Hostname != tt.wantHost || lm.Severity != tt.wantSev {
+				t.Errorf("UnmarshalProtobuf() => expected host %q and severity %d, got: %q and %d", tt.wantHost,
+					tt.wantSev, lm.Hostname, lm.Severity)
+			}
+		})
+	}
+}
+
+// TestLogMsg_UnmarshalProtobuf_invalid tests the UnmarshalProtobuf method with invalid data
+func TestLogMsg_UnmarshalProtobuf_invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr error
+	}{
+		{"truncated varint", "0880", ErrInvalidEncoding},
+		{"truncated string", "3205686f7374", ErrInvalidEncoding},
+		{"varint overflow", "08ffffffffffffffffffff01", ErrInvalidEncoding},
+		{"field number 0", "0001", ErrInvalidEncoding},
+		{"group", "0b", ErrInvalidEncoding},
+		{"wrong wire type", "3001", ErrInvalidEncoding},
+		{"truncated fixed64", "f9070000", ErrInvalidEncoding},
+		{"invalid SD element", "92010208ff", ErrInvalidEncoding},
+		{"invalid timestamp nanos", "9a0106108094ebdc03", ErrInvalidEncoding},
+		{"newer schema", "0802", ErrUnsupportedSchemaVersion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := hex.DecodeString(tt.data)
+			if err != nil {
+				t.Fatalf("invalid test data: %s", err)
+			}
+			lm := testSerialLogMsg("previous")
+			if err := lm.UnmarshalProtobuf(b); !errors.Is(err, tt.wantErr) {
+				t.Errorf("UnmarshalProtobuf() => expected: %s, got: %v", tt.wantErr, err)
+			}
+			if lm.Hostname != "" || lm.Message.Len() != 0 {
+				t.Errorf("UnmarshalProtobuf() => expected the LogMsg to be reset, got: %s", lm.String())
+			}
+		})
+	}
+}
+
+// TestMigrateProtobuf tests the MigrateProtobuf method
+func TestMigrateProtobuf(t *testing.T) {
+	b, err := MigrateProtobuf([]byte{0x32, 0x01, 'h'})
+	if err != nil {
+		t.Fatalf("MigrateProtobuf() failed: %s", err)
+	}
+	// schema: 1, hostname: "h", message: ""
+	if want := "08013201683a00"; hex.EncodeToString(b) != want {
+		t.Errorf("MigrateProtobuf() => expected: %s, got: %x", want, b)
+	}
+	if _, err = MigrateProtobuf([]byte{0x08, 0x02}); !errors.Is(err, ErrUnsupportedSchemaVersion) {
+		t.Errorf("MigrateProtobuf() => expected: %s, got: %v", ErrUnsupportedSchemaVersion, err)
+	}
+}
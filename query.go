@@ -0,0 +1,95 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// Query returns the value at the given path of the structured data of the LogMsg, so that
+// filters and templates can reference structured data and JSON message bodies uniformly.
+// A path consists of the SD-ID and the param name, separated by a slash (i. e.
+// "origin@32473/ip"). If an SD-ID occurs multiple times, the first matching param is
+// returned.
+//
+// For JSONBodySDID, the param name is the dotted path of a field of the JSON message body
+// (i. e. "json@32473/http.status"), in which elements of arrays are selected by their
+// index (i. e. "json@32473/tags.0"). If the message body has not been decoded with
+// DecodeJSONBody, it is decoded on the fly. Objects and arrays are returned as their
+// JSON encoding and null as empty value.
+//
+// The returned bool is false if there is no value at the given path.
+func (l *LogMsg) Query(path string) (string, bool) {
+	i := strings.IndexByte(path, '/')
+	if i <= 0 || i == len(path)-1 {
+		return "", false
+	}
+	id, name := path[:i], path[i+1:]
+	if v, ok := l.SDParam(id, name); ok {
+		return v, true
+	}
+	if id != JSONBodySDID {
+		return "", false
+	}
+	m, err := ParseJSONBody(l.Message.String())
+	if err != nil {
+		return "", false
+	}
+	return queryJSON(m, name)
+}
+
+// queryJSON returns the value at the given dotted path of the given decoded JSON value.
+// Since the keys of a JSON object may contain dots themselves (i. e. as result of a
+// flattened object), the longest matching key takes precedence.
+func queryJSON(v interface{}, path string) (string, bool) {
+	if path == "" {
+		return jsonValue(v)
+	}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if c, ok := v[path]; ok {
+			return jsonValue(c)
+		}
+		for i := strings.LastIndexByte(path, '.'); i > 0; i = strings.LastIndexByte(path[:i], '.') {
+			if c, ok := v[path[:i]]; ok {
+				if r, ok := queryJSON(c, path[i+1:]); ok {
+					return r, true
+				}
+			}
+		}
+	case []interface{}:
+		k, rest := path, ""
+		if i := strings.IndexByte(path, '.'); i >= 0 {
+			k, rest = path[:i], path[i+1:]
+		}
+		n, err := strconv.Atoi(k)
+		if err != nil || n < 0 || n >= len(v) {
+			return "", false
+		}
+		return queryJSON(v[n], rest)
+	}
+	return "", false
+}
+
+// jsonValue returns the given decoded JSON value as string, in the same representation
+// as DecodeJSONBody uses for param values
+func jsonValue(v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	case nil:
+		return "", true
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", false
+		}
+		return string(b), true
+	}
+}
@@ -0,0 +1,64 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"testing"
+)
+
+// TestLogMsg_Query tests the Query method of the LogMsg
+func TestLogMsg_Query(t *testing.T) {
+	lm := LogMsg{StructuredData: []StructuredDataElement{
+		{ID: "origin@32473", Param: []StructuredDataParam{{Name: "ip", Value: "192.0.2.1"}}},
+		{ID: "exampleSDID@32473", Param: []StructuredDataParam{{Name: "iut", Value: "3"}}},
+		{ID: "origin@32473", Param: []StructuredDataParam{{Name: "software", Value: "test"}}},
+	}}
+	lm.Message.WriteString(`@cee: {"http":{"status":404,"path":"/"},"tags":["a",{"b":true}],"user.name":"jdoe",` +
+		`"user":{"id":"1"},"none":null}`)
+	decoded := lm.Clone()
+	if !DecodeJSONBody(&decoded) {
+		t.Fatalf("DecodeJSONBody() failed")
+	}
+
+	tests := []struct {
+		name   string
+		path   string
+		want   string
+		wantOK bool
+	}{
+		{"param", "origin@32473/ip", "192.0.2.1", true},
+		{"param of repeated element", "origin@32473/software", "test", true},
+		{"unknown param", "origin@32473/hostname", "", false},
+		{"unknown element", "meta/sequenceId", "", false},
+		{"json field", "json@32473/http.status", "404", true},
+		{"json object", "json@32473/http", `{"path":"/","status":404}`, true},
+		{"json array element", "json@32473/tags.0", "a", true},
+		{"json nested array element", "json@32473/tags.1.b", "true", true},
+		{"json array out of range", "json@32473/tags.2", "", false},
+		{"json key with dot", "json@32473/user.name", "jdoe", true},
+		{"json nested key", "json@32473/user.id", "1", true},
+		{"json null", "json@32473/none", "", true},
+		{"json unknown field", "json@32473/http.method", "", false},
+		{"no slash", "origin@32473", "", false},
+		{"empty name", "origin@32473/", "", false},
+		{"empty", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, l := range []LogMsg{lm, decoded} {
+				got, ok := l.Query(tt.path)
+				if ok != tt.wantOK || got != tt.want {
+					t.Errorf("Query(%q) => expected: %q (%t), got: %q (%t)", tt.path, tt.want, tt.wantOK, got, ok)
+				}
+			}
+		})
+	}
+
+	var plain LogMsg
+	plain.Message.WriteString("not json")
+	if _, ok := plain.Query("json@32473/http.status"); ok {
+		t.Errorf("Query() on message without JSON body expected to fail")
+	}
+}
@@ -0,0 +1,206 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// RedactAction defines how a field is redacted by a RedactionPolicy
+type RedactAction int
+
+const (
+	// RedactKeep leaves the field unchanged
+	RedactKeep RedactAction = iota
+	// RedactOmit removes the field. Header fields are emptied (and therefore serialized as
+	// NILVALUE), structured data params and elements are removed.
+	RedactOmit
+	// RedactHash replaces the value with the first 16 bytes of its (HMAC-)SHA256 sum in
+	// hex notation. Equal values result in equal hashes, so redacted values can still be
+	// correlated.
+	RedactHash
+	// RedactMask replaces every character of the value with an asterisk, so that only its
+	// length is retained
+	RedactMask
+)
+
+// redactHashLen is the amount of bytes of the hash sum that is used by RedactHash. It
+// is chosen so that a hashed value fits into every RFC5424 header field.
+const redactHashLen = 16
+
+// RedactionPolicy defines which fields of a LogMsg are redacted and how. Apply returns
+// a redacted copy of a LogMsg, which then can be serialized with any of the available
+// encoders (i. e. MarshalJSON or rfc5424.Marshal). This way, the same LogMsg can be
+// emitted differently to different sinks (i. e. unchanged to an audit log, but with the
+// hostnames hashed to an analytics system).
+//
+// A RedactionPolicy is safe for concurrent use.
+type RedactionPolicy struct {
+	fields map[Field]RedactAction
+	key    []byte
+	params map[redactParam]RedactAction
+}
+
+// redactParam identifies a structured data param. An empty name identifies the whole
+// structured data element.
+type redactParam struct {
+	id   string
+	name string
+}
+
+// RedactionOption is a function that configures a RedactionPolicy
+type RedactionOption func(*RedactionPolicy)
+
+// WithRedactField sets the RedactAction for the given Field (or group of fields). The
+// Hostname action is applied to the ResolvedHost as well. FieldPriority and
+// FieldTimestamp can not be hashed or masked and are set to their zero value by every
+// action other than RedactKeep. For FieldStructuredData, the action is applied to all
+// param values that do not have a more specific action configured via WithRedactParam.
+func WithRedactField(f Field, a RedactAction) RedactionOption {
+	return func(p *RedactionPolicy) {
+		for _, ff := range redactFields {
+			if f&ff != 0 {
+				p.fields[ff] = a
+			}
+		}
+	}
+}
+
+// WithRedactParam sets the RedactAction for the structured data param with the given
+// name of all elements with the given SD-ID. If name is empty, the action is applied to
+// the element as a whole, i. e. RedactOmit removes the element.
+func WithRedactParam(id, name string, a RedactAction) RedactionOption {
+	return func(p *RedactionPolicy) {
+		p.params[redactParam{id: id, name: name}] = a
+	}
+}
+
+// WithRedactHashKey makes RedactHash use an HMAC-SHA256 with the given key instead of a
+// plain SHA256, so that the original values can not be found by hashing candidates
+func WithRedactHashKey(key []byte) RedactionOption {
+	return func(p *RedactionPolicy) {
+		p.key = append([]byte(nil), key...)
+	}
+}
+
+// redactFields are the individual Field values in the order they are applied
+var redactFields = []Field{
+	FieldPriority, FieldTimestamp, FieldHostname, FieldAppName, FieldProcID, FieldMsgID,
+	FieldStructuredData, FieldMessage,
+}
+
+// NewRedactionPolicy returns a new RedactionPolicy with the given RedactionOption
+// functions applied. Fields without a configured action are kept unchanged.
+func NewRedactionPolicy(opts ...RedactionOption) *RedactionPolicy {
+	p := &RedactionPolicy{
+		fields: make(map[Field]RedactAction),
+		params: make(map[redactParam]RedactAction),
+	}
+	for _, o := range opts {
+		if o == nil {
+			continue
+		}
+		o(p)
+	}
+	return p
+}
+
+// Apply returns a copy of the given LogMsg with the RedactionPolicy applied. The given
+// LogMsg is not modified.
+func (p *RedactionPolicy) Apply(lm *LogMsg) LogMsg {
+	r := lm.Clone()
+	if p.fields[FieldPriority] != RedactKeep {
+		r.Priority, r.Facility, r.Severity, r.OriginalSeverity = 0, 0, 0, nil
+	}
+	if p.fields[FieldTimestamp] != RedactKeep {
+		r.Timestamp = time.Time{}
+	}
+	if a := p.fields[FieldHostname]; a != RedactKeep {
+		r.Hostname = p.redact(a, r.Hostname)
+		r.ResolvedHost = p.redact(a, r.ResolvedHost)
+	}
+	r.AppName = p.redact(p.fields[FieldAppName], r.AppName)
+	r.ProcID = p.redact(p.fields[FieldProcID], r.ProcID)
+	r.MsgID = p.redact(p.fields[FieldMsgID], r.MsgID)
+	if a := p.fields[FieldMessage]; a != RedactKeep {
+		m := p.redact(a, r.Message.String())
+		r.Message.Reset()
+		r.Message.WriteString(m)
+		r.MsgLength = r.Message.Len()
+		if a == RedactOmit {
+			r.HasBOM = false
+		}
+	}
+	r.StructuredData = p.redactStructuredData(r.StructuredData)
+	return r
+}
+
+// redactStructuredData applies the RedactionPolicy to the given structured data elements
+// in place and returns the remaining elements
+func (p *RedactionPolicy) redactStructuredData(sds []StructuredDataElement) []StructuredDataElement {
+	all := p.fields[FieldStructuredData]
+	if all == RedactKeep && len(p.params) == 0 {
+		return sds
+	}
+	n := 0
+	for _, e := range sds {
+		ea, ok := p.params[redactParam{id: e.ID}]
+		if !ok {
+			ea = all
+		}
+		if ea == RedactOmit {
+			continue
+		}
+		ps := e.Param[:0]
+		for _, sp := range e.Param {
+			a, ok := p.params[redactParam{id: e.ID, name: sp.Name}]
+			if !ok {
+				a = ea
+			}
+			if a == RedactOmit {
+				continue
+			}
+			sp.Value = p.redact(a, sp.Value)
+			ps = append(ps, sp)
+		}
+		e.Param = ps
+		sds[n] = e
+		n++
+	}
+	if n == 0 {
+		return nil
+	}
+	return sds[:n]
+}
+
+// redact returns the given value with the given RedactAction applied
+func (p *RedactionPolicy) redact(a RedactAction, v string) string {
+	if v == "" {
+		return v
+	}
+	switch a {
+	case RedactOmit:
+		return ""
+	case RedactHash:
+		var h hash.Hash
+		if len(p.key) > 0 {
+			h = hmac.New(sha256.New, p.key)
+		} else {
+			h = sha256.New()
+		}
+		h.Write([]byte(v))
+		return hex.EncodeToString(h.Sum(nil)[:redactHashLen])
+	case RedactMask:
+		return strings.Repeat("*", utf8.RuneCountInString(v))
+	default:
+		return v
+	}
+}
@@ -0,0 +1,108 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"testing"
+)
+
+// TestRedactionPolicy_Apply tests the Apply method of the RedactionPolicy
+func TestRedactionPolicy_Apply(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []RedactionOption
+		want []string
+	}{
+		{"empty policy", nil, nil},
+		{
+			"omit hostname and message", []RedactionOption{
+				WithRedactField(FieldHostname|FieldMessage, RedactOmit),
+			},
+			[]string{
+				`HasBOM: true != false`, `Hostname: "host1" != ""`, `Message: "Hello, World!" != ""`,
+				`MsgLength: 13 != 0`, `ResolvedHost: "host1.example.com" != ""`,
+			},
+		},
+		{
+			"mask app name", []RedactionOption{WithRedactField(FieldAppName, RedactMask)},
+			[]string{`AppName: "su" != "**"`},
+		},
+		{
+			"hash proc ID", []RedactionOption{WithRedactField(FieldProcID, RedactHash)},
+			[]string{`ProcID: "123" != "a665a45920422f9d417e4867efdc4fb8"`},
+		},
+		{
+			"priority and timestamp", []RedactionOption{
+				WithRedactField(FieldPriority|FieldTimestamp, RedactMask),
+			},
+			[]string{
+				`Facility: 4 != 0`, `OriginalSeverity: 6 != <nil>`, `Priority: 34 != 0`, `Severity: 2 != 0`,
+				`Timestamp: 2003-10-11T22:14:15.003-07:00 != <zero>`,
+			},
+		},
+		{
+			"structured data", []RedactionOption{
+				WithRedactParam("exampleSDID@32473", "iut", RedactOmit),
+				WithRedactParam("exampleSDID@32473", "eventSource", RedactMask),
+				WithRedactParam("empty@32473", "", RedactOmit),
+			},
+			[]string{
+				`StructuredData[0].Param[0].Name: "iut" != "eventSource"`,
+				`StructuredData[0].Param[0].Value: "3" != "***"`,
+				`StructuredData[0].Param[1]: "eventSource" != <missing>`,
+				`StructuredData[1]: "empty@32473" != <missing>`,
+			},
+		},
+		{
+			"all structured data with exception", []RedactionOption{
+				WithRedactField(FieldStructuredData, RedactMask),
+				WithRedactParam("exampleSDID@32473", "iut", RedactKeep),
+			},
+			[]string{`StructuredData[0].Param[1].Value: "App" != "***"`},
+		},
+		{
+			"omit all structured data", []RedactionOption{WithRedactField(FieldStructuredData, RedactOmit)},
+			[]string{`StructuredData[0]: "exampleSDID@32473" != <missing>`, `StructuredData[1]: "empty@32473" != <missing>`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lm := testSerialLogMsg("Hello, World!")
+			orig := lm.Clone()
+			r := NewRedactionPolicy(tt.opts...).Apply(&lm)
+			if d := Diff(orig, lm); len(d) > 0 {
+				t.Errorf("Apply() modified the original LogMsg: %v", d)
+			}
+			d := Diff(lm, r)
+			if len(d) != len(tt.want) {
+				t.Fatalf("Apply() => expected %d diffs, got: %v", len(tt.want), d)
+			}
+			for i, w := range tt.want {
+				if d[i].String() != w {
+					t.Errorf("Apply() => expected: %s, got: %s", w, d[i].String())
+				}
+			}
+		})
+	}
+}
+
+// TestRedactionPolicy_hashKey tests that RedactHash uses an HMAC if a key is configured
+func TestRedactionPolicy_hashKey(t *testing.T) {
+	lm := testSerialLogMsg("")
+	plain := NewRedactionPolicy(WithRedactField(FieldHostname, RedactHash)).Apply(&lm)
+	k1 := NewRedactionPolicy(WithRedactField(FieldHostname, RedactHash), WithRedactHashKey([]byte("k1"))).Apply(&lm)
+	k2 := NewRedactionPolicy(WithRedactField(FieldHostname, RedactHash), WithRedactHashKey([]byte("k2"))).Apply(&lm)
+	if plain.Hostname == k1.Hostname || k1.Hostname == k2.Hostname {
+		t.Errorf("RedactHash with key expected different hashes, got: %s, %s, %s", plain.Hostname,
+			k1.Hostname, k2.Hostname)
+	}
+	if len(k1.Hostname) != 2*redactHashLen {
+		t.Errorf("RedactHash wrong hash length => expected: %d, got: %d", 2*redactHashLen, len(k1.Hostname))
+	}
+	again := NewRedactionPolicy(WithRedactField(FieldHostname, RedactHash), WithRedactHashKey([]byte("k1"))).Apply(&lm)
+	if again.Hostname != k1.Hostname {
+		t.Errorf("RedactHash expected stable hashes, got: %s, %s", k1.Hostname, again.Hostname)
+	}
+}
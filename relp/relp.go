@@ -0,0 +1,179 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+// Package relp implements the server side of the Reliable Event Logging Protocol
+// (RELP) as used by rsyslog. Other than plain TCP syslog, RELP acknowledges every
+// message, so that senders can retransmit messages that were not acknowledged.
+// See: https://www.rsyslog.com/doc/relp.html
+package relp
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/wneessen/go-parsesyslog"
+)
+
+// Commands of the RELP protocol
+const (
+	CommandOpen        = "open"
+	CommandClose       = "close"
+	CommandSyslog      = "syslog"
+	CommandRsp         = "rsp"
+	CommandServerClose = "serverclose"
+)
+
+// DefaultMaxFrameLength is the maximum length of the DATA part of a RELP frame that is
+// accepted by default
+const DefaultMaxFrameLength = 128 * 1024
+
+// Software is the name that is announced as "relp_software" in the response to the
+// "open" command
+const Software = "go-parsesyslog"
+
+const (
+	// maxTxnrDigits is the maximum amount of digits of a TXNR
+	maxTxnrDigits = 9
+	// maxCommandLength is the maximum length of a COMMAND
+	maxCommandLength = 32
+	// maxDataLenDigits is the maximum amount of digits of a DATALEN
+	maxDataLenDigits = 9
+)
+
+// Frame represents a single RELP frame
+type Frame struct {
+	Command string
+	Data    []byte
+	Txnr    uint64
+}
+
+// ReadFrame reads a single RELP frame ("TXNR SP COMMAND SP DATALEN [SP DATA] LF")
+// from the given bufio.Reader. Frames with a DATA part longer than maxLen are
+// rejected with parsesyslog.ErrFrameTooLarge, malformed frames with
+// parsesyslog.ErrInvalidRELPFrame.
+func ReadFrame(br *bufio.Reader, maxLen int) (Frame, error) {
+	var f Frame
+	txnr, err := readToken(br, maxTxnrDigits)
+	if err != nil {
+		return f, err
+	}
+	f.Txnr, err = strconv.ParseUint(txnr, 10, 64)
+	if err != nil {
+		return f, fmt.Errorf("%w: invalid TXNR %q", parsesyslog.ErrInvalidRELPFrame, txnr)
+	}
+	if f.Command, err = readToken(br, maxCommandLength); err != nil {
+		return f, eofErr(err)
+	}
+	dl, err := readDataLen(br)
+	if err != nil {
+		return f, eofErr(err)
+	}
+	if dl > maxLen {
+		return f, parsesyslog.ErrFrameTooLarge
+	}
+	if dl > 0 {
+		f.Data = make([]byte, dl)
+		if _, err = io.ReadFull(br, f.Data); err != nil {
+			return f, eofErr(err)
+		}
+	}
+	b, err := br.ReadByte()
+	if err != nil {
+		return f, eofErr(err)
+	}
+	if b != '\n' {
+		return f, fmt.Errorf("%w: missing trailer", parsesyslog.ErrInvalidRELPFrame)
+	}
+	return f, nil
+}
+
+// WriteFrame writes the given RELP frame to the io.Writer
+func WriteFrame(w io.Writer, f Frame) error {
+	var buf bytes.Buffer
+	buf.WriteString(strconv.FormatUint(f.Txnr, 10))
+	buf.WriteByte(' ')
+	buf.WriteString(f.Command)
+	buf.WriteByte(' ')
+	buf.WriteString(strconv.Itoa(len(f.Data)))
+	if len(f.Data) > 0 {
+		buf.WriteByte(' ')
+		buf.Write(f.Data)
+	}
+	buf.WriteByte('\n')
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// readToken reads a token terminated by a SP with a maximum length of n
+func readToken(br *bufio.Reader, n int) (string, error) {
+	var sb strings.Builder
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			if sb.Len() > 0 {
+				return "", eofErr(err)
+			}
+			return "", err
+		}
+		if b == ' ' {
+			break
+		}
+		if sb.Len() >= n || b == '\n' {
+			return "", parsesyslog.ErrInvalidRELPFrame
+		}
+		sb.WriteByte(b)
+	}
+	if sb.Len() == 0 {
+		return "", parsesyslog.ErrInvalidRELPFrame
+	}
+	return sb.String(), nil
+}
+
+// readDataLen reads the DATALEN part of a frame, including the following SP, if
+// DATALEN is not 0. For a DATALEN of 0, the SP is optional.
+func readDataLen(br *bufio.Reader) (int, error) {
+	l, digits := 0, 0
+	for {
+		p, err := br.Peek(1)
+		if err != nil {
+			return 0, err
+		}
+		if p[0] < '0' || p[0] > '9' {
+			break
+		}
+		if digits >= maxDataLenDigits {
+			return 0, parsesyslog.ErrInvalidRELPFrame
+		}
+		l = l*10 + int(p[0]-'0')
+		digits++
+		_, _ = br.Discard(1)
+	}
+	if digits == 0 {
+		return 0, parsesyslog.ErrInvalidRELPFrame
+	}
+	p, err := br.Peek(1)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case p[0] == ' ':
+		_, _ = br.Discard(1)
+	case p[0] != '\n' || l > 0:
+		return 0, parsesyslog.ErrInvalidRELPFrame
+	}
+	return l, nil
+}
+
+// eofErr maps an io.EOF within a frame to parsesyslog.ErrPrematureEOF
+func eofErr(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return parsesyslog.ErrPrematureEOF
+	}
+	return err
+}
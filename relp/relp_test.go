@@ -0,0 +1,68 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package relp
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/wneessen/go-parsesyslog"
+)
+
+// TestReadFrame tests the ReadFrame method
+func TestReadFrame(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    Frame
+		wantErr error
+	}{
+		{"syslog", "2 syslog 5 hello\n", Frame{Command: "syslog", Data: []byte("hello"), Txnr: 2}, nil},
+		{"data with LF", "3 syslog 5 he\nlo\n", Frame{Command: "syslog", Data: []byte("he\nlo"), Txnr: 3}, nil},
+		{"no data", "4 close 0\n", Frame{Command: "close", Txnr: 4}, nil},
+		{"no data with SP", "4 close 0 \n", Frame{Command: "close", Txnr: 4}, nil},
+		{"empty stream", "", Frame{}, io.EOF},
+		{"invalid TXNR", "a syslog 5 hello\n", Frame{}, parsesyslog.ErrInvalidRELPFrame},
+		{"TXNR too long", "1234567890 syslog 5 hello\n", Frame{}, parsesyslog.ErrInvalidRELPFrame},
+		{"missing DATALEN", "1 syslog hello\n", Frame{}, parsesyslog.ErrInvalidRELPFrame},
+		{"missing trailer", "1 syslog 5 hellox", Frame{}, parsesyslog.ErrInvalidRELPFrame},
+		{"too large", "1 syslog 11 hello world\n", Frame{}, parsesyslog.ErrFrameTooLarge},
+		{"truncated", "1 syslog 5 hel", Frame{}, parsesyslog.ErrPrematureEOF},
+		{"truncated header", "1 sys", Frame{}, parsesyslog.ErrPrematureEOF},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := ReadFrame(bufio.NewReader(strings.NewReader(tt.input)), 10)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ReadFrame() error => expected: %v, got: %v", tt.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if f.Txnr != tt.want.Txnr || f.Command != tt.want.Command || !bytes.Equal(f.Data, tt.want.Data) {
+				t.Errorf("ReadFrame() => expected: %+v, got: %+v", tt.want, f)
+			}
+		})
+	}
+}
+
+// TestWriteFrame tests the WriteFrame method
+func TestWriteFrame(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteFrame(&buf, Frame{Command: CommandRsp, Data: []byte("200 OK"), Txnr: 7}); err != nil {
+		t.Fatalf("WriteFrame() failed: %s", err)
+	}
+	if err := WriteFrame(&buf, Frame{Command: CommandServerClose}); err != nil {
+		t.Fatalf("WriteFrame() failed: %s", err)
+	}
+	want := "7 rsp 6 200 OK\n0 serverclose 0\n"
+	if buf.String() != want {
+		t.Errorf("WriteFrame() => expected: %q, got: %q", want, buf.String())
+	}
+}
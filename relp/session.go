@@ -0,0 +1,143 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package relp
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/wneessen/go-parsesyslog"
+)
+
+// Session represents the server side of a RELP session on a single connection. It
+// handles the "open" and "close" commands itself and hands the payload of every
+// "syslog" command to a parsesyslog.Parser. Every message returned by Next has to be
+// acknowledged with Ack (or rejected with Nack), so that the sender knows it can
+// discard the message.
+type Session struct {
+	br     *bufio.Reader
+	maxLen int
+	mu     sync.Mutex
+	open   bool
+	parser parsesyslog.Parser
+	pr     bytes.Reader
+	pbr    *bufio.Reader
+	w      io.Writer
+}
+
+// NewSession returns a new Session that reads RELP frames from and writes responses to
+// the given io.ReadWriter (i. e. a net.Conn). The payloads are parsed with the given
+// parsesyslog.Parser. If maxLen is 0 or negative, DefaultMaxFrameLength is used.
+func NewSession(rw io.ReadWriter, p parsesyslog.Parser, maxLen int) *Session {
+	if maxLen <= 0 {
+		maxLen = DefaultMaxFrameLength
+	}
+	s := &Session{
+		br:     bufio.NewReader(rw),
+		maxLen: maxLen,
+		parser: p,
+		w:      rw,
+	}
+	s.pbr = bufio.NewReader(&s.pr)
+	return s
+}
+
+// Next returns the parsed payload of the next "syslog" command together with its
+// transaction number, which has to be passed to Ack or Nack. If the payload could not
+// be parsed, the transaction number is returned together with the parse error, so that
+// the message can be rejected with Nack. This includes panics of the Parser, which are
+// returned as *parsesyslog.PanicError. Once the sender closes the session, io.EOF is
+// returned.
+//
+// Errors that are returned with a transaction number of 0 (i. e. a malformed frame or
+// an I/O error) leave the session in an undefined state, so the connection should be
+// closed.
+func (s *Session) Next() (parsesyslog.LogMsg, uint64, error) {
+	for {
+		f, err := ReadFrame(s.br, s.maxLen)
+		if err != nil {
+			return parsesyslog.LogMsg{}, 0, err
+		}
+		switch f.Command {
+		case CommandOpen:
+			if err = s.respond(f.Txnr, "200 OK\nrelp_version=0\nrelp_software="+Software+
+				"\ncommands="+CommandSyslog); err != nil {
+				return parsesyslog.LogMsg{}, 0, err
+			}
+			s.open = true
+		case CommandClose:
+			if err = s.respond(f.Txnr, ""); err != nil {
+				return parsesyslog.LogMsg{}, 0, err
+			}
+			s.open = false
+			return parsesyslog.LogMsg{}, 0, io.EOF
+		case CommandSyslog:
+			if !s.open {
+				if err = s.Nack(f.Txnr, "session not open"); err != nil {
+					return parsesyslog.LogMsg{}, 0, err
+				}
+				continue
+			}
+			lm, err := s.parse(f.Data)
+			return lm, f.Txnr, err
+		default:
+			if err = s.Nack(f.Txnr, "unsupported command "+f.Command); err != nil {
+				return parsesyslog.LogMsg{}, 0, err
+			}
+		}
+	}
+}
+
+// parse parses the given payload. A panic of the Parser is returned as
+// *parsesyslog.PanicError, so that the message can be rejected.
+func (s *Session) parse(d []byte) (lm parsesyslog.LogMsg, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = parsesyslog.NewPanicError(r, d)
+		}
+	}()
+	s.pr.Reset(d)
+	s.pbr.Reset(&s.pr)
+	return s.parser.ParseReader(s.pbr)
+}
+
+// Buffered returns the number of bytes that have been received, but not been read by
+// Next yet. A value greater than 0 means that the sender has pipelined further frames
+// (or a part of one).
+func (s *Session) Buffered() int {
+	return s.br.Buffered()
+}
+
+// Ack acknowledges the message with the given transaction number
+func (s *Session) Ack(txnr uint64) error {
+	return s.respond(txnr, "200 OK")
+}
+
+// Nack rejects the message with the given transaction number and the given reason
+func (s *Session) Nack(txnr uint64, reason string) error {
+	return s.respond(txnr, "500 "+strings.ReplaceAll(reason, "\n", " "))
+}
+
+// Close tells the sender that the server is closing the session by sending a
+// "serverclose" command
+func (s *Session) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return WriteFrame(s.w, Frame{Command: CommandServerClose})
+}
+
+// respond sends a "rsp" frame with the given data for the given transaction number
+func (s *Session) respond(txnr uint64, data string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := WriteFrame(s.w, Frame{Command: CommandRsp, Data: []byte(data), Txnr: txnr}); err != nil {
+		return fmt.Errorf("failed to send RELP response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package relp
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/wneessen/go-parsesyslog"
+	"github.com/wneessen/go-parsesyslog/rfc5424"
+)
+
+// readWriter combines an io.Reader and an io.Writer
+type readWriter struct {
+	io.Reader
+	io.Writer
+}
+
+// frame returns a RELP frame as string
+func frame(txnr int, cmd, data string) string {
+	f := strconv.Itoa(txnr) + " " + cmd + " " + strconv.Itoa(len(data))
+	if data != "" {
+		f += " " + data
+	}
+	return f + "\n"
+}
+
+// TestSession_Next tests the Next method of the Session
+func TestSession_Next(t *testing.T) {
+	p, err := parsesyslog.New(rfc5424.Type)
+	if err != nil {
+		t.Fatalf("failed to create RFC5424 parser: %s", err)
+	}
+	in := frame(1, "syslog", "<165>1 2003-10-11T22:14:15.003Z host - - - - early") +
+		frame(2, "open", "relp_version=0\nrelp_software=librelp\ncommands=syslog") +
+		frame(3, "syslog", "<165>1 2003-10-11T22:14:15.003Z host - - - - first") +
+		frame(4, "syslog", "<165>foo") +
+		frame(5, "foo", "") +
+		frame(6, "syslog", "<165>1 2003-10-11T22:14:15.003Z host - - - - second") +
+		frame(7, "close", "")
+	var out bytes.Buffer
+	s := NewSession(readWriter{strings.NewReader(in), &out}, p, 0)
+
+	lm, txnr, err := s.Next()
+	if err != nil || txnr != 3 || lm.Message.String() != "first" {
+		t.Fatalf("Next() => expected first message with txnr 3, got: %q/%d/%v", lm.Message.String(), txnr, err)
+	}
+	if err = s.Ack(txnr); err != nil {
+		t.Fatalf("Ack() failed: %s", err)
+	}
+	_, txnr, err = s.Next()
+	if err == nil || txnr != 4 {
+		t.Fatalf("Next() => expected parse error with txnr 4, got: %d/%v", txnr, err)
+	}
+	if err = s.Nack(txnr, "parse\nerror"); err != nil {
+		t.Fatalf("Nack() failed: %s", err)
+	}
+	lm, txnr, err = s.Next()
+	if err != nil || txnr != 6 || lm.Message.String() != "second" {
+		t.Fatalf("Next() => expected second message with txnr 6, got: %q/%d/%v", lm.Message.String(), txnr, err)
+	}
+	if err = s.Ack(txnr); err != nil {
+		t.Fatalf("Ack() failed: %s", err)
+	}
+	if _, _, err = s.Next(); !errors.Is(err, io.EOF) {
+		t.Fatalf("Next() after close => expected: %s, got: %v", io.EOF, err)
+	}
+
+	offers := "200 OK\nrelp_version=0\nrelp_software=" + Software + "\ncommands=syslog"
+	want := frame(1, "rsp", "500 session not open") + frame(2, "rsp", offers) + frame(3, "rsp", "200 OK") +
+		frame(4, "rsp", "500 parse error") + frame(5, "rsp", "500 unsupported command foo") +
+		frame(6, "rsp", "200 OK") + frame(7, "rsp", "")
+	if out.String() != want {
+		t.Errorf("Session responses => expected: %q, got: %q", want, out.String())
+	}
+}
@@ -0,0 +1,272 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"container/list"
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultResolverCacheSize is the amount of addresses a HostResolver caches by default
+	DefaultResolverCacheSize = 4096
+	// DefaultResolverTTL is the duration a HostResolver caches a resolved name by default
+	DefaultResolverTTL = time.Hour
+	// DefaultResolverNegativeTTL is the duration a HostResolver caches a failed lookup by default
+	DefaultResolverNegativeTTL = time.Minute * 5
+	// DefaultResolverTimeout is the timeout of a single reverse lookup by default
+	DefaultResolverTimeout = time.Second * 5
+	// DefaultResolverWorkers is the amount of concurrent lookups of a HostResolver by default
+	DefaultResolverWorkers = 4
+)
+
+// resolverQueueSize is the amount of pending lookups a HostResolver queues before new
+// lookups are dropped
+const resolverQueueSize = 1024
+
+// FailurePolicy defines what a HostResolver returns for an address that has no
+// (known) name
+type FailurePolicy int
+
+// FailurePolicies
+const (
+	// FailureUseAddress makes the HostResolver return the address itself
+	FailureUseAddress FailurePolicy = iota
+	// FailureLeaveEmpty makes the HostResolver return an empty string
+	FailureLeaveEmpty
+)
+
+// HostResolver resolves IP addresses to hostnames via reverse DNS (PTR records). To
+// not block the parse path, lookups are performed asynchronously: Resolve only answers
+// from the cache and queues a lookup for addresses that are not cached (yet). Resolved
+// names and failed lookups are cached in a LRU cache with separate TTLs.
+//
+// A HostResolver is safe for concurrent use.
+type HostResolver struct {
+	cache   map[string]*list.Element
+	done    chan struct{}
+	lookup  func(context.Context, string) ([]string, error)
+	lru     *list.List
+	mu      sync.Mutex
+	negTTL  time.Duration
+	now     func() time.Time
+	once    sync.Once
+	pending map[string]struct{}
+	policy  FailurePolicy
+	queue   chan string
+	size    int
+	timeout time.Duration
+	ttl     time.Duration
+	wg      sync.WaitGroup
+	workers int
+}
+
+// resolverEntry represents a cached lookup result
+type resolverEntry struct {
+	addr    string
+	expires time.Time
+	name    string
+}
+
+// ResolverOption is a function that configures a HostResolver
+type ResolverOption func(*HostResolver)
+
+// WithResolverCacheSize sets the maximum amount of cached addresses. If n is 0 or
+// negative, DefaultResolverCacheSize is used.
+func WithResolverCacheSize(n int) ResolverOption {
+	return func(r *HostResolver) {
+		r.size = n
+	}
+}
+
+// WithResolverFailurePolicy sets what Resolve returns for addresses that could not be
+// resolved or whose lookup is still pending. By default, FailureUseAddress is used.
+func WithResolverFailurePolicy(p FailurePolicy) ResolverOption {
+	return func(r *HostResolver) {
+		r.policy = p
+	}
+}
+
+// WithResolverNegativeTTL sets the duration a failed lookup is cached before the
+// address is looked up again. If d is 0 or negative, DefaultResolverNegativeTTL is used.
+func WithResolverNegativeTTL(d time.Duration) ResolverOption {
+	return func(r *HostResolver) {
+		r.negTTL = d
+	}
+}
+
+// WithResolverTimeout sets the timeout of a single reverse lookup. If d is 0 or
+// negative, DefaultResolverTimeout is used.
+func WithResolverTimeout(d time.Duration) ResolverOption {
+	return func(r *HostResolver) {
+		r.timeout = d
+	}
+}
+
+// WithResolverTTL sets the duration a resolved name is cached. If d is 0 or negative,
+// DefaultResolverTTL is used.
+func WithResolverTTL(d time.Duration) ResolverOption {
+	return func(r *HostResolver) {
+		r.ttl = d
+	}
+}
+
+// WithResolverWorkers sets the amount of concurrent lookups. If n is 0 or negative,
+// DefaultResolverWorkers is used.
+func WithResolverWorkers(n int) ResolverOption {
+	return func(r *HostResolver) {
+		r.workers = n
+	}
+}
+
+// NewHostResolver returns a new HostResolver that uses the default net.Resolver and
+// starts its lookup workers. Close should be called once the HostResolver is no
+// longer needed.
+func NewHostResolver(opts ...ResolverOption) *HostResolver {
+	r := &HostResolver{
+		cache:   make(map[string]*list.Element),
+		done:    make(chan struct{}),
+		lookup:  net.DefaultResolver.LookupAddr,
+		lru:     list.New(),
+		now:     time.Now,
+		pending: make(map[string]struct{}),
+		queue:   make(chan string, resolverQueueSize),
+	}
+	for _, o := range opts {
+		if o == nil {
+			continue
+		}
+		o(r)
+	}
+	if r.size <= 0 {
+		r.size = DefaultResolverCacheSize
+	}
+	if r.ttl <= 0 {
+		r.ttl = DefaultResolverTTL
+	}
+	if r.negTTL <= 0 {
+		r.negTTL = DefaultResolverNegativeTTL
+	}
+	if r.timeout <= 0 {
+		r.timeout = DefaultResolverTimeout
+	}
+	if r.workers <= 0 {
+		r.workers = DefaultResolverWorkers
+	}
+	r.wg.Add(r.workers)
+	for i := 0; i < r.workers; i++ {
+		go r.work()
+	}
+	return r
+}
+
+// Resolve returns the cached name of the given host, if the host is an IP address. For
+// addresses that are not cached (or whose cache entry has expired), a lookup is queued
+// and the result of the FailurePolicy is returned, so that Resolve never blocks on a
+// DNS lookup. Hosts that are not IP addresses are returned unchanged.
+func (r *HostResolver) Resolve(host string) string {
+	if r == nil || host == "" {
+		return ""
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return host
+	}
+	addr := ip.String()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if el, ok := r.cache[addr]; ok {
+		e := el.Value.(*resolverEntry)
+		if r.now().Before(e.expires) {
+			r.lru.MoveToFront(el)
+			if e.name != "" {
+				return e.name
+			}
+			return r.failed(host)
+		}
+	}
+	if _, ok := r.pending[addr]; !ok {
+		select {
+		case r.queue <- addr:
+			r.pending[addr] = struct{}{}
+		default:
+			// The queue is full, the address will be queued again on its next occurrence
+		}
+	}
+	return r.failed(host)
+}
+
+// Pending returns the amount of addresses that are queued for a lookup
+func (r *HostResolver) Pending() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.pending)
+}
+
+// Close stops the lookup workers. Resolve keeps answering from the cache, but no new
+// lookups are performed.
+func (r *HostResolver) Close() {
+	r.once.Do(func() {
+		close(r.done)
+	})
+	r.wg.Wait()
+}
+
+// failed returns the result of the FailurePolicy for the given host
+func (r *HostResolver) failed(host string) string {
+	if r.policy == FailureUseAddress {
+		return host
+	}
+	return ""
+}
+
+// work performs the queued lookups until the HostResolver is closed
+func (r *HostResolver) work() {
+	defer r.wg.Done()
+	for {
+		select {
+		case <-r.done:
+			return
+		case addr := <-r.queue:
+			ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+			names, err := r.lookup(ctx, addr)
+			cancel()
+			name := ""
+			if err == nil && len(names) > 0 {
+				name = strings.TrimSuffix(names[0], ".")
+			}
+			r.store(addr, name)
+		}
+	}
+}
+
+// store caches the lookup result for the given address and evicts the least recently
+// used entry, if the cache is full
+func (r *HostResolver) store(addr, name string) {
+	ttl := r.ttl
+	if name == "" {
+		ttl = r.negTTL
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.pending, addr)
+	e := &resolverEntry{addr: addr, expires: r.now().Add(ttl), name: name}
+	if el, ok := r.cache[addr]; ok {
+		el.Value = e
+		r.lru.MoveToFront(el)
+		return
+	}
+	r.cache[addr] = r.lru.PushFront(e)
+	for r.lru.Len() > r.size {
+		el := r.lru.Back()
+		r.lru.Remove(el)
+		delete(r.cache, el.Value.(*resolverEntry).addr)
+	}
+}
@@ -0,0 +1,129 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeLookup is a reverse lookup function that answers from a static map and counts
+// the lookups
+type fakeLookup struct {
+	calls int
+	mu    sync.Mutex
+	names map[string]string
+}
+
+func (f *fakeLookup) lookup(_ context.Context, addr string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if n, ok := f.names[addr]; ok {
+		return []string{n}, nil
+	}
+	return nil, errors.New("no such host")
+}
+
+// newTestResolver returns a HostResolver with a fake lookup function and clock
+func newTestResolver(f *fakeLookup, ts *time.Time, opts ...ResolverOption) *HostResolver {
+	r := NewHostResolver(append(opts, WithResolverWorkers(1))...)
+	r.mu.Lock()
+	r.lookup = f.lookup
+	r.now = func() time.Time { return *ts }
+	r.mu.Unlock()
+	return r
+}
+
+// resolveEventually calls Resolve until it returns the expected value
+func resolveEventually(t *testing.T, r *HostResolver, host, want string) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second * 5)
+	for time.Now().Before(deadline) {
+		if r.Resolve(host) == want {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("Resolve(%s) => expected: %s, got: %s", host, want, r.Resolve(host))
+}
+
+// TestHostResolver_Resolve tests the Resolve method of the HostResolver
+func TestHostResolver_Resolve(t *testing.T) {
+	ts := time.Date(2023, 10, 11, 22, 14, 15, 0, time.UTC)
+	f := &fakeLookup{names: map[string]string{"192.0.2.1": "host1.example.com."}}
+	r := newTestResolver(f, &ts, WithResolverTTL(time.Minute), WithResolverNegativeTTL(time.Second))
+	defer r.Close()
+
+	if h := r.Resolve("myhost"); h != "myhost" {
+		t.Errorf("Resolve() of hostname => expected: %s, got: %s", "myhost", h)
+	}
+	if h := r.Resolve("192.0.2.1"); h != "192.0.2.1" {
+		t.Errorf("Resolve() of pending address => expected: %s, got: %s", "192.0.2.1", h)
+	}
+	resolveEventually(t, r, "192.0.2.1", "host1.example.com")
+	resolveEventually(t, r, "192.0.2.2", "192.0.2.2")
+
+	f.mu.Lock()
+	calls := f.calls
+	f.mu.Unlock()
+	_ = r.Resolve("192.0.2.1")
+	ts = ts.Add(time.Second * 2)
+	if h := r.Resolve("192.0.2.1"); h != "host1.example.com" {
+		t.Errorf("Resolve() of cached address => expected: %s, got: %s", "host1.example.com", h)
+	}
+	f.mu.Lock()
+	if f.calls != calls {
+		t.Errorf("Resolve() of cached address performed a lookup")
+	}
+	f.mu.Unlock()
+
+	ts = ts.Add(time.Minute)
+	f.mu.Lock()
+	f.names["192.0.2.1"] = "host2.example.com"
+	f.mu.Unlock()
+	if h := r.Resolve("192.0.2.1"); h != "192.0.2.1" {
+		t.Errorf("Resolve() of expired address => expected: %s, got: %s", "192.0.2.1", h)
+	}
+	resolveEventually(t, r, "192.0.2.1", "host2.example.com")
+}
+
+// TestHostResolver_failurePolicy tests the FailureLeaveEmpty policy of the HostResolver
+func TestHostResolver_failurePolicy(t *testing.T) {
+	ts := time.Now()
+	f := &fakeLookup{names: map[string]string{}}
+	r := newTestResolver(f, &ts, WithResolverFailurePolicy(FailureLeaveEmpty))
+	defer r.Close()
+	if h := r.Resolve("2001:db8::1"); h != "" {
+		t.Errorf("Resolve() with FailureLeaveEmpty => expected empty result, got: %s", h)
+	}
+	if h := r.Resolve(""); h != "" {
+		t.Errorf("Resolve() of empty host => expected empty result, got: %s", h)
+	}
+}
+
+// TestHostResolver_cacheSize tests the LRU eviction of the HostResolver
+func TestHostResolver_cacheSize(t *testing.T) {
+	ts := time.Now()
+	f := &fakeLookup{names: map[string]string{"192.0.2.1": "a", "192.0.2.2": "b", "192.0.2.3": "c"}}
+	r := newTestResolver(f, &ts, WithResolverCacheSize(2))
+	defer r.Close()
+	resolveEventually(t, r, "192.0.2.1", "a")
+	resolveEventually(t, r, "192.0.2.2", "b")
+	_ = r.Resolve("192.0.2.1")
+	resolveEventually(t, r, "192.0.2.3", "c")
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.lru.Len() != 2 {
+		t.Errorf("HostResolver cache size => expected: %d, got: %d", 2, r.lru.Len())
+	}
+	if _, ok := r.cache["192.0.2.2"]; ok {
+		t.Errorf("HostResolver did not evict the least recently used entry")
+	}
+}
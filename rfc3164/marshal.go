@@ -0,0 +1,92 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package rfc3164
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/wneessen/go-parsesyslog"
+)
+
+// maxTagLen is the maximum length of the TAG
+// See: https://tools.ietf.org/search/rfc3164#section-4.1.3
+const maxTagLen = 32
+
+// timeFormat is the TIMESTAMP format of RFC3164
+// See: https://tools.ietf.org/search/rfc3164#section-4.1.2
+const timeFormat = "Jan _2 15:04:05"
+
+// timeFormatYear is the TIMESTAMP format of RFC3164 with the year that some devices
+// insert after the day
+const timeFormatYear = "Jan _2 2006 15:04:05"
+
+// Marshal returns the RFC3164 representation of the given LogMsg in the form of
+// "<PRI>Mmm dd hh:mm:ss HOSTNAME TAG[PID]: MSG". The AppName is used as TAG and the
+// ProcID is only written if there is an AppName. If there is no AppName, the MSG
+// follows the HOSTNAME directly.
+//
+// RFC3164 timestamps carry neither a year nor a time zone, so the Timestamp is written
+// in its own time.Location. Use Timestamp.In() to convert it to the time zone the
+// receiver expects first. The Message is written as is. Since receivers (including the
+// RFC3164 parser of this package) treat LF as the end of a message, messages with line
+// breaks are split by them.
+//
+// A missing Timestamp results in ErrInvalidTimestamp. A missing HOSTNAME or header fields
+// that contain characters that are not allowed result in ErrWrongFormat.
+// See: https://tools.ietf.org/search/rfc3164#section-4.1
+func Marshal(lm parsesyslog.LogMsg) ([]byte, error) {
+	var buf bytes.Buffer
+	if lm.Priority < 0 || lm.Priority > 191 {
+		return nil, parsesyslog.ErrInvalidPrio
+	}
+	buf.WriteByte('<')
+	buf.WriteString(strconv.Itoa(int(lm.Priority)))
+	buf.WriteByte('>')
+
+	if lm.Timestamp.IsZero() {
+		return nil, parsesyslog.ErrInvalidTimestamp
+	}
+	buf.WriteString(lm.Timestamp.Format(timeFormat))
+	buf.WriteByte(' ')
+
+	if lm.Hostname == "" || !isHeaderValue(lm.Hostname, "") {
+		return nil, fmt.Errorf("%w: invalid HOSTNAME %q", parsesyslog.ErrWrongFormat, lm.Hostname)
+	}
+	buf.WriteString(lm.Hostname)
+	buf.WriteByte(' ')
+
+	if lm.AppName != "" {
+		if len(lm.AppName) > maxTagLen || !isHeaderValue(lm.AppName, ":[]") {
+			return nil, fmt.Errorf("%w: invalid TAG %q", parsesyslog.ErrWrongFormat, lm.AppName)
+		}
+		buf.WriteString(lm.AppName)
+		if lm.ProcID != "" {
+			if !isHeaderValue(lm.ProcID, "]") {
+				return nil, fmt.Errorf("%w: invalid PID %q", parsesyslog.ErrWrongFormat, lm.ProcID)
+			}
+			buf.WriteByte('[')
+			buf.WriteString(lm.ProcID)
+			buf.WriteByte(']')
+		}
+		buf.WriteString(": ")
+	}
+	buf.Write(lm.Message.Bytes())
+
+	return buf.Bytes(), nil
+}
+
+// isHeaderValue returns true if the given string only consists of printable US-ASCII
+// characters, except for the given characters
+func isHeaderValue(s, except string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 33 || s[i] > 126 || strings.IndexByte(except, s[i]) >= 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,121 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package rfc3164
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wneessen/go-parsesyslog"
+)
+
+// TestMarshal tests the Marshal function
+func TestMarshal(t *testing.T) {
+	ts := time.Date(2021, 11, 7, 16, 0, 35, 500, time.UTC)
+	tests := []struct {
+		name string
+		lm   parsesyslog.LogMsg
+		msg  string
+		want string
+	}{
+		{
+			"tag with pid", parsesyslog.LogMsg{
+				Priority: 13, Timestamp: ts, Hostname: "arch-vm", AppName: "wneessen",
+				ProcID: "1130275",
+			}, "test\n", "<13>Nov  7 16:00:35 arch-vm wneessen[1130275]: test\n",
+		},
+		{
+			"tag without pid", parsesyslog.LogMsg{Priority: 34, Timestamp: ts, Hostname: "host", AppName: "su"},
+			"'su root' failed", "<34>Nov  7 16:00:35 host su: 'su root' failed",
+		},
+		{
+			"no tag", parsesyslog.LogMsg{Priority: 0, Timestamp: ts, Hostname: "10.0.0.1", ProcID: "123"},
+			"message", "<0>Nov  7 16:00:35 10.0.0.1 message",
+		},
+		{
+			"two-digit day", parsesyslog.LogMsg{
+				Priority: 191, Timestamp: time.Date(2021, 12, 24, 1, 2, 3, 0, time.UTC),
+				Hostname: "host",
+			}, "", "<191>Dec 24 01:02:03 host ",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.lm.Message.WriteString(tt.msg)
+			b, err := Marshal(tt.lm)
+			if err != nil {
+				t.Fatalf("Marshal() failed: %s", err)
+			}
+			if string(b) != tt.want {
+				t.Errorf("Marshal() => expected: %q, got: %q", tt.want, string(b))
+			}
+		})
+	}
+}
+
+// TestMarshal_fails tests the Marshal function with LogMsg that can not be represented
+// in RFC3164
+func TestMarshal_fails(t *testing.T) {
+	ts := time.Now()
+	tests := []struct {
+		name    string
+		lm      parsesyslog.LogMsg
+		wantErr error
+	}{
+		{"invalid priority", parsesyslog.LogMsg{Priority: 192, Timestamp: ts, Hostname: "h"}, parsesyslog.ErrInvalidPrio},
+		{"no timestamp", parsesyslog.LogMsg{Hostname: "h"}, parsesyslog.ErrInvalidTimestamp},
+		{"no hostname", parsesyslog.LogMsg{Timestamp: ts}, parsesyslog.ErrWrongFormat},
+		{"hostname with space", parsesyslog.LogMsg{Timestamp: ts, Hostname: "a b"}, parsesyslog.ErrWrongFormat},
+		{
+			"tag too long", parsesyslog.LogMsg{Timestamp: ts, Hostname: "h", AppName: strings.Repeat("a", 33)},
+			parsesyslog.ErrWrongFormat,
+		},
+		{"tag with colon", parsesyslog.LogMsg{Timestamp: ts, Hostname: "h", AppName: "a:b"}, parsesyslog.ErrWrongFormat},
+		{
+			"pid with bracket", parsesyslog.LogMsg{Timestamp: ts, Hostname: "h", AppName: "a", ProcID: "1]"},
+			parsesyslog.ErrWrongFormat,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Marshal(tt.lm); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Marshal() => expected: %s, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestMarshal_roundTrip tests that a marshaled LogMsg is parsed into the same LogMsg
+func TestMarshal_roundTrip(t *testing.T) {
+	p, err := parsesyslog.New(Type)
+	if err != nil {
+		t.Fatalf("failed to create new RFC3164 parser: %s", err)
+	}
+	for _, msg := range []string{
+		"<13>Nov 27 16:00:35 arch-vm wneessen[1130275]: test\n",
+		"<34>Oct 11 22:14:15 mymachine su: 'su root' failed for lonvick on /dev/pts/8",
+	} {
+		lm, err := p.ParseString(msg)
+		if err != nil {
+			t.Fatalf("failed to parse message: %s", err)
+		}
+		b, err := Marshal(lm)
+		if err != nil {
+			t.Fatalf("Marshal() failed: %s", err)
+		}
+		if string(b) != msg {
+			t.Errorf("Marshal() => expected: %q, got: %q", msg, string(b))
+		}
+		got, err := p.ParseString(string(b))
+		if err != nil {
+			t.Fatalf("failed to parse marshaled message: %s", err)
+		}
+		for _, d := range parsesyslog.Diff(lm, got) {
+			t.Errorf("round trip failed => %s", d)
+		}
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"bufio"
 	"bytes"
 	"errors"
+	"fmt"
 	"io"
 	"strings"
 	"time"
@@ -21,19 +22,44 @@ import (
 type msg struct {
 	buf  bytes.Buffer
 	app  bytes.Buffer
+	bbr  *bufio.Reader
+	br   bytes.Reader
+	hlen int
+	opts parsesyslog.Options
 	pid  bytes.Buffer
 	reol bool
+	tr   parsesyslog.Tracer
+	// zoneless is true if the timestamp of the message had no time zone, so that it is
+	// interpreted in the Location or the time zone of the LocationMap
+	zoneless bool
+
+	// The most recently parsed header strings, which are reused if the next
+	// message carries the same values
+	lastAppName  string
+	lastHostname string
+	lastProcID   string
 }
 
+// maxCiscoSeqLen is the maximum amount of digits of a Cisco sequence number
+const maxCiscoSeqLen = 10
+
+// maxLenientTSLen is the maximum length of a timestamp in lenient mode, including the
+// space that follows it
+const maxLenientTSLen = 48
+
+// maxRFC3339TSLen is the maximum length of an RFC3339 timestamp with nanoseconds and
+// time zone offset, including the space that follows it
+const maxRFC3339TSLen = 36
+
 // Type represents the ParserType for this Parser
 const Type parsesyslog.ParserType = "rfc3164"
 
 // init registers the Parser
 func init() {
-	fn := func() (parsesyslog.Parser, error) {
-		return &msg{}, nil
+	fn := func(o parsesyslog.Options) (parsesyslog.Parser, error) {
+		return &msg{opts: o}, nil
 	}
-	parsesyslog.Register(Type, fn)
+	parsesyslog.RegisterWithOptions(Type, fn)
 }
 
 // ParseString returns the parsed log message read from a string (as buffered i/o)
@@ -43,79 +69,211 @@ func (m *msg) ParseString(s string) (parsesyslog.LogMsg, error) {
 	return m.ParseReader(br)
 }
 
+// ParseBytes returns the parsed log message read from a byte slice. The readers that
+// are needed to parse it are reused, so that no new ones are allocated for every message.
+// It satisfies the parsesyslog.BytesParser interface
+func (m *msg) ParseBytes(b []byte) (parsesyslog.LogMsg, error) {
+	m.br.Reset(b)
+	if m.bbr == nil {
+		m.bbr = bufio.NewReaderSize(&m.br, 1024)
+	}
+	m.bbr.Reset(&m.br)
+	return m.ParseReader(m.bbr)
+}
+
 // ParseReader is the parser function that is able to interpret RFC3164 and
-// satisfies the Parser interface
+// satisfies the Parser interface. If parsing fails, the returned LogMsg holds the
+// fields that precede the one the error occurred in (see Parser).
 func (m *msg) ParseReader(r io.Reader) (parsesyslog.LogMsg, error) {
-	l := parsesyslog.LogMsg{
-		Type: parsesyslog.RFC3164,
-	}
-	m.reol = false
+	var l parsesyslog.LogMsg
+	err := m.ParseReaderInto(r, &l)
+	return l, err
+}
+
+// ParseReaderInto works like ParseReader, but parses the log message into the given
+// LogMsg, reusing its message buffer. It satisfies the parsesyslog.ReusingParser
+// interface
+func (m *msg) ParseReaderInto(r io.Reader, l *parsesyslog.LogMsg) error {
+	m.tr.Start(m.opts.Trace)
+	err := m.parseReaderInto(r, l)
+	m.tr.Done(l, err)
+	return err
+}
+
+// parseReaderInto parses the log message read from the given io.Reader into the given
+// LogMsg
+func (m *msg) parseReaderInto(r io.Reader, l *parsesyslog.LogMsg) error {
+	l.Reset()
+	l.Type = parsesyslog.RFC3164
+	m.hlen, m.reol, m.zoneless = 0, false, false
 
 	bufr := bufio.NewReaderSize(r, 1024)
-	if err := m.parseHeader(bufr, &l); err != nil {
-		switch {
-		case errors.Is(err, io.EOF):
-			return l, parsesyslog.ErrPrematureEOF
-		default:
-			return l, err
-		}
+	if err := m.parseHeader(bufr, l); err != nil {
+		return err
 	}
+	m.tr.Phase(parsesyslog.PhaseMessage)
 
-	if !m.reol {
+	// The part of the message body that has been read with the header
+	n := l.Message.Len()
+	if m.reol {
+		n--
+	}
+	wantmsg := m.opts.Wants(parsesyslog.FieldMessage)
+	if !wantmsg {
+		l.Message.Reset()
+	}
+	limit := -1
+	if m.opts.MaxLength > 0 && m.opts.LengthPolicy != parsesyslog.LengthAccept {
+		limit = m.opts.MaxLength - m.hlen
+		if limit < 0 {
+			limit = 0
+		}
+	}
+	for !m.reol {
 		rd, err := bufr.ReadSlice('\n')
+		if wantmsg && (limit < 0 || n <= limit) {
+			_, _ = l.Message.Write(rd)
+		}
+		n += len(rd)
+		if len(rd) > 0 && rd[len(rd)-1] == '\n' {
+			n--
+		}
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
 		if err != nil && !errors.Is(err, io.EOF) {
-			return l, err
+			return err
 		}
-		_, err = l.Message.Write(rd)
-		if err != nil {
-			return l, err
+		break
+	}
+	if limit >= 0 && m.hlen+n > m.opts.MaxLength {
+		if m.opts.LengthPolicy == parsesyslog.LengthReject {
+			l.Message.Reset()
+			return fmt.Errorf("%w: %d bytes (maximum: %d)", parsesyslog.ErrMessageTooLong, m.hlen+n,
+				m.opts.MaxLength)
 		}
+		if l.Message.Len() > limit {
+			l.Message.Truncate(limit)
+		}
+		l.Truncated = true
 	}
 	l.MsgLength = l.Message.Len()
+	m.opts.PostProcess(l)
 
-	return l, nil
+	return nil
+}
+
+// applyDefaultPriority assigns the DefaultPriority of the Options to the given LogMsg,
+// if the log message in the reader lacks the PRI and a default priority has been set
+// with WithDefaultPriority. It returns true if the default priority has been assigned,
+// in which case the header continues with the timestamp.
+func (m *msg) applyDefaultPriority(r *bufio.Reader, lm *parsesyslog.LogMsg) bool {
+	if !m.opts.UseDefaultPriority {
+		return false
+	}
+	p, err := r.Peek(1)
+	if err != nil || p[0] == '<' {
+		return false
+	}
+	lm.Priority = m.opts.DefaultPriority
+	lm.Facility = parsesyslog.FacilityFromPrio(lm.Priority)
+	lm.Severity = parsesyslog.SeverityFromPrio(lm.Priority)
+	lm.Relaxed |= parsesyslog.RelaxedMissingPriority
+	return true
 }
 
 // parseHeader will try to parse the header of a RFC3164 syslog message and store
 // it in the provided LogMsg pointer
 // See: https://tools.ietf.org/search/rfc3164#section-4.1.2
 func (m *msg) parseHeader(r *bufio.Reader, lm *parsesyslog.LogMsg) error {
-	if err := parsesyslog.ParsePriority(r, &m.buf, lm); err != nil {
-		return err
+	if !m.applyDefaultPriority(r, lm) {
+		if err := parsesyslog.ParsePriority(r, &m.buf, lm); err != nil {
+			return parsesyslog.NewParseError(err, "PRI", 0, m.buf.Bytes())
+		}
+		m.hlen += m.buf.Len() + 2
+		if m.opts.Lenient && m.buf.Len() > 1 && m.buf.Bytes()[0] == '0' {
+			lm.Relaxed |= parsesyslog.RelaxedPriority
+		}
 	}
-	if err := m.parseTimestamp(r, lm); err != nil {
-		return err
+	if m.opts.StripCiscoPrefix {
+		m.stripCiscoPrefix(r)
 	}
+	parseTS := m.parseTimestamp
+	if !m.opts.Wants(parsesyslog.FieldTimestamp) {
+		parseTS = m.skipTimestamp
+	}
+	if m.opts.Lenient {
+		parseTS = m.parseTimestampLenient
+	}
+	start := m.hlen
+	if err := parseTS(r, lm); err != nil {
+		return parsesyslog.NewParseError(err, "TIMESTAMP", start, m.buf.Bytes())
+	}
+	start = m.hlen
 	if err := m.parseHostname(r, lm); err != nil {
-		return err
+		return parsesyslog.NewParseError(err, "HOSTNAME", start, m.buf.Bytes())
 	}
+	start = m.hlen
 	if err := m.parseTag(r, lm); err != nil {
-		return err
+		lm.AppName, lm.ProcID = "", ""
+		lm.Relaxed &^= parsesyslog.RelaxedTag
+		lm.Message.Reset()
+		return parsesyslog.NewParseError(err, "TAG", start, m.buf.Bytes())
 	}
 
 	return nil
 }
 
+// stripCiscoPrefix discards the sequence number ("NNN: ") and the clock-status marker
+// ("*" or ".") that Cisco devices put in front of the timestamp, if present
+func (m *msg) stripCiscoPrefix(r *bufio.Reader) {
+	p, _ := r.Peek(maxCiscoSeqLen + 2)
+	i := 0
+	for i < len(p) && p[i] >= '0' && p[i] <= '9' {
+		i++
+	}
+	if i > 0 && i+1 < len(p) && p[i] == ':' && p[i+1] == ' ' {
+		n, _ := r.Discard(i + 2)
+		m.hlen += n
+	}
+	p, _ = r.Peek(1)
+	if len(p) == 1 && (p[0] == '*' || p[0] == '.') {
+		n, _ := r.Discard(1)
+		m.hlen += n
+	}
+}
+
 // parseTimestamp will try to parse the timestamp part of the RFC3164 header
 // See: https://tools.ietf.org/search/rfc3164#section-4.1.2
 func (m *msg) parseTimestamp(r *bufio.Reader, lm *parsesyslog.LogMsg) error {
+	if mon, n, ok := m.localizedMonth(r); ok {
+		return m.parseLocalizedTimestamp(r, lm, mon, n)
+	}
+	if n, ok := rfc3339Len(r); ok {
+		return m.parseRFC3339Timestamp(r, lm, n)
+	}
+	l, layout := 16, timeFormat
+	if hasYear(r) {
+		l, layout = 21, timeFormatYear
+	}
 	m.buf.Reset()
-	for m.buf.Len() < 16 {
+	for m.buf.Len() < l {
 		b, err := r.ReadByte()
 		if err != nil {
 			return err
 		}
 		m.buf.WriteByte(b)
 	}
-	ts, err := time.Parse(`Jan _2 15:04:05 `, m.buf.String())
+	ts, err := time.Parse(layout+" ", m.buf.String())
 	if err != nil {
 		return parsesyslog.ErrInvalidTimestamp
 	}
+	m.hlen += l
 
+	m.zoneless = true
+	ts = m.inLocation(ts)
 	if ts.Year() == 0 {
-		ts = time.Date(time.Now().Year(), ts.Month(), ts.Day(), ts.Hour(), ts.Minute(),
-			ts.Second(), ts.Nanosecond(), ts.Location())
-		lm.Timestamp = ts
+		lm.Timestamp = m.opts.InferYear(ts)
 		return nil
 	}
 
@@ -123,15 +281,274 @@ func (m *msg) parseTimestamp(r *bufio.Reader, lm *parsesyslog.LogMsg) error {
 	return nil
 }
 
+// inLocation returns the given timestamp, which has been parsed without time zone
+// information, in the time.Location set with WithLocation. Without a Location, the
+// timestamp is kept in UTC.
+func (m *msg) inLocation(ts time.Time) time.Time {
+	if m.opts.Location == nil {
+		return ts
+	}
+	return parsesyslog.InLocation(ts, m.opts.Location)
+}
+
+// localizedMonth returns the month of a timestamp that starts with one of the month
+// names set with WithMonthNames, together with the length of the month name. The
+// returned bool is false if the timestamp does not start with such a month name.
+func (m *msg) localizedMonth(r *bufio.Reader) (time.Month, int, bool) {
+	if len(m.opts.MonthNames) == 0 {
+		return 0, 0, false
+	}
+	p, _ := r.Peek(parsesyslog.MaxMonthNameLen + 1)
+	i := bytes.IndexByte(p, ' ')
+	if i <= 0 {
+		return 0, 0, false
+	}
+	mon, ok := m.opts.MonthNames.Lookup(string(p[:i]))
+	return mon, i, ok
+}
+
+// parseLocalizedTimestamp parses a timestamp that starts with the month name of the given
+// length, which represents the given month. The year of such timestamps is inferred
+// according to the Options (see InferYear).
+func (m *msg) parseLocalizedTimestamp(r *bufio.Reader, lm *parsesyslog.LogMsg, mon time.Month, n int) error {
+	// The month name is followed by " _2 15:04:05 "
+	p, err := r.Peek(n + 13)
+	m.buf.Reset()
+	m.buf.Write(p)
+	if err != nil {
+		return err
+	}
+	ts, err := time.Parse(timeFormat+" ", "Jan"+string(p[n:]))
+	if err != nil {
+		return parsesyslog.ErrInvalidTimestamp
+	}
+	lts := m.opts.InferYear(m.inLocation(time.Date(0, mon, ts.Day(), ts.Hour(), ts.Minute(), ts.Second(), 0,
+		time.UTC)))
+	if lts.Day() != ts.Day() {
+		return parsesyslog.ErrInvalidTimestamp
+	}
+	d, _ := r.Discard(n + 13)
+	m.hlen += d
+	m.zoneless = true
+	lm.Timestamp = lts
+	return nil
+}
+
+// hasYear returns true if the reader starts with a BSD timestamp that includes a 4-digit
+// year after the day (i. e. "Oct 11 2023 22:14:15")
+func hasYear(r *bufio.Reader) bool {
+	p, _ := r.Peek(21)
+	if len(p) < 21 || p[3] != ' ' || p[6] != ' ' || p[11] != ' ' || p[14] != ':' || p[20] != ' ' {
+		return false
+	}
+	for _, c := range p[7:11] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// rfc3339Len returns the length of the RFC3339 timestamp (i. e. as emitted by the default
+// template of rsyslog) that the reader starts with. The returned bool is false if the
+// reader does not start with a date in RFC3339 format, followed by a space.
+func rfc3339Len(r *bufio.Reader) (int, bool) {
+	p, _ := r.Peek(maxRFC3339TSLen)
+	if len(p) < 5 || p[0] < '0' || p[0] > '9' || p[4] != '-' {
+		return 0, false
+	}
+	i := bytes.IndexByte(p, ' ')
+	return i, i > 0
+}
+
+// parseRFC3339Timestamp parses an RFC3339 timestamp of the given length. Unlike the BSD
+// timestamps, it carries the year and the time zone offset.
+// See: https://datatracker.ietf.org/doc/html/rfc3339#section-5.6
+func (m *msg) parseRFC3339Timestamp(r *bufio.Reader, lm *parsesyslog.LogMsg, n int) error {
+	p, _ := r.Peek(n)
+	m.buf.Reset()
+	m.buf.Write(p)
+	ts, err := time.Parse(time.RFC3339Nano, m.buf.String())
+	if err != nil {
+		return parsesyslog.ErrInvalidTimestamp
+	}
+	d, _ := r.Discard(n + 1)
+	m.hlen += d
+	lm.Timestamp = ts
+	return nil
+}
+
+// skipTimestamp will read past the timestamp part of the RFC3164 header without
+// parsing it
+func (m *msg) skipTimestamp(r *bufio.Reader, _ *parsesyslog.LogMsg) error {
+	m.buf.Reset()
+	l := 16
+	if _, n, ok := m.localizedMonth(r); ok {
+		l = n + 13
+	}
+	if n, ok := rfc3339Len(r); ok {
+		l = n + 1
+	}
+	if hasYear(r) {
+		l = 21
+	}
+	n, err := r.Discard(l)
+	m.hlen += n
+	return err
+}
+
+// parseTimestampLenient works like parseTimestamp, but also accepts the timestamp
+// variations that are tolerated in lenient mode. Since the length of these timestamps
+// varies, they are peeked token by token, so that no more than the timestamp is read.
+func (m *msg) parseTimestampLenient(r *bufio.Reader, lm *parsesyslog.LogMsg) error {
+	wantts := m.opts.Wants(parsesyslog.FieldTimestamp)
+	if mon, n, ok := m.localizedMonth(r); ok {
+		var tm parsesyslog.LogMsg
+		if err := m.parseLocalizedTimestamp(r, &tm, mon, n); err == nil {
+			if wantts {
+				lm.Timestamp = tm.Timestamp
+			}
+			return nil
+		}
+	}
+	if n, ok := rfc3339Len(r); ok {
+		var tm parsesyslog.LogMsg
+		if err := m.parseRFC3339Timestamp(r, &tm, n); err == nil {
+			if wantts {
+				lm.Timestamp = tm.Timestamp
+			}
+			return nil
+		}
+	}
+	l, layout := 16, timeFormat
+	if hasYear(r) {
+		l, layout = 21, timeFormatYear
+	}
+	if p, err := r.Peek(l); err == nil {
+		if _, err = time.Parse(layout+" ", string(p)); err == nil {
+			if !wantts {
+				return m.skipTimestamp(r, lm)
+			}
+			return m.parseTimestamp(r, lm)
+		}
+	}
+
+	var toks []string
+	i := 0
+	// invalid keeps the peeked input for the ParseError
+	invalid := func() error {
+		p, _ := r.Peek(i)
+		m.buf.Reset()
+		m.buf.Write(p)
+		return parsesyslog.ErrInvalidTimestamp
+	}
+	for len(toks) < 4 {
+		s := -1
+		for ; i < maxLenientTSLen; i++ {
+			p, err := r.Peek(i + 1)
+			if err != nil {
+				return invalid()
+			}
+			if p[i] == ' ' && s >= 0 {
+				break
+			}
+			if p[i] != ' ' && s < 0 {
+				s = i
+			}
+		}
+		if i == maxLenientTSLen {
+			return invalid()
+		}
+		p, _ := r.Peek(i)
+		toks = append(toks, string(p[s:i]))
+		if strings.ContainsRune(toks[len(toks)-1], ':') {
+			break
+		}
+	}
+	if mon, ok := m.opts.MonthNames.Lookup(toks[0]); ok && len(toks) > 1 {
+		toks[0] = mon.String()[:3]
+	}
+	ts, ok := relaxedTimestamp(toks)
+	if !ok {
+		return invalid()
+	}
+	// Only RFC3339 timestamps, which consist of a single token, carry a time zone
+	if len(toks) > 1 {
+		m.zoneless = true
+		ts = m.inLocation(ts)
+	}
+	if ts.Year() == 0 {
+		ts = m.opts.InferYear(ts)
+	}
+	n, _ := r.Discard(i + 1)
+	m.hlen += n
+	if wantts {
+		lm.Timestamp = ts
+	}
+	lm.Relaxed |= parsesyslog.RelaxedTimestamp
+	return nil
+}
+
+// relaxedTimestamp returns the timestamp represented by the given tokens, which is
+// either a single RFC3339 timestamp without the following space or a BSD timestamp with a year or fractional seconds.
+// BSD timestamps without a year are returned in the year 0.
+func relaxedTimestamp(toks []string) (time.Time, bool) {
+	if len(toks) == 1 {
+		ts, err := time.Parse(time.RFC3339Nano, toks[0])
+		return ts, err == nil
+	}
+	year := "0000"
+	switch len(toks) {
+	case 3:
+	case 4:
+		year, toks = toks[2], []string{toks[0], toks[1], toks[3]}
+	default:
+		return time.Time{}, false
+	}
+	ts, err := time.Parse("Jan 2 2006 15:04:05.999999999", toks[0]+" "+toks[1]+" "+year+" "+
+		strings.TrimSuffix(toks[2], ":"))
+	return ts, err == nil
+}
+
+// missingHostname returns true if the timestamp is directly followed by the tag, as in
+// log messages that are written to /dev/log by the libc (i. e. "<13>Oct 11 22:14:15 su:
+// msg"). A hostname never ends with a single colon, while the tag always does.
+func missingHostname(r *bufio.Reader) bool {
+	p, _ := r.Peek(maxTagLen + 1)
+	i := bytes.IndexByte(p, ' ')
+	return i > 1 && p[i-1] == ':' && p[i-2] != ':'
+}
+
 // parseHostname will try to parse the hostname part of the RFC3164 header
 // See: https://tools.ietf.org/search/rfc3164#section-4.1.2
 func (m *msg) parseHostname(r *bufio.Reader, lm *parsesyslog.LogMsg) error {
 	m.buf.Reset()
-	h, _, err := parsesyslog.ReadBytesUntilSpace(r)
+	if missingHostname(r) {
+		return nil
+	}
+	h, n, err := parsesyslog.ReadBytesUntilSpace(r)
 	if err != nil {
+		m.buf.Write(h)
 		return err
 	}
-	lm.Hostname = string(h)
+	m.hlen += n
+	if m.opts.Wants(parsesyslog.FieldHostname) {
+		lm.Hostname = m.opts.Interner.Reuse(m.lastHostname, h)
+		m.lastHostname = lm.Hostname
+		if m.opts.Resolver != nil {
+			lm.ResolvedHost = m.opts.Resolver.Resolve(lm.Hostname)
+		}
+	}
+	// Timestamps with a time zone (i. e. RFC3339 timestamps) denote an instant already
+	if m.opts.Locations != nil && m.zoneless && !lm.Timestamp.IsZero() {
+		host := lm.Hostname
+		if host == "" {
+			host = string(h)
+		}
+		if loc, ok := m.opts.Locations.Lookup(host); ok {
+			lm.Timestamp = parsesyslog.InLocation(lm.Timestamp, loc)
+		}
+	}
 	return nil
 }
 
@@ -162,6 +579,12 @@ func (m *msg) parseTag(r *bufio.Reader, lm *parsesyslog.LogMsg) error {
 		if b == ':' {
 			hascolon = true
 			sb++
+			if m.opts.Lenient && !inpid {
+				if p, err := r.Peek(1); err == nil && p[0] != ' ' && p[0] != '\n' {
+					lm.Relaxed |= parsesyslog.RelaxedTag
+					break
+				}
+			}
 			continue
 		}
 		if b == '[' && !inpid {
@@ -182,12 +605,19 @@ func (m *msg) parseTag(r *bufio.Reader, lm *parsesyslog.LogMsg) error {
 		}
 	}
 	if hascolon && m.app.Len() > 0 {
+		m.hlen += m.buf.Len()
 		if m.app.Len() > 0 {
-			lm.AppName = m.app.String()
+			if m.opts.Wants(parsesyslog.FieldAppName) {
+				lm.AppName = m.opts.Interner.Reuse(m.lastAppName, m.app.Bytes())
+				m.lastAppName = lm.AppName
+			}
 			sb += m.app.Len()
 		}
 		if m.pid.Len() > 0 {
-			lm.ProcID = m.pid.String()
+			if m.opts.Wants(parsesyslog.FieldProcID) {
+				lm.ProcID = parsesyslog.ReuseString(m.lastProcID, m.pid.Bytes())
+				m.lastProcID = lm.ProcID
+			}
 			sb += m.pid.Len()
 		}
 		for x := sb; x < 32; x++ {
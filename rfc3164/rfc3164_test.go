@@ -7,9 +7,11 @@ package rfc3164
 import (
 	"bufio"
 	"errors"
+	"fmt"
 	"io"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/wneessen/go-parsesyslog"
 )
@@ -162,3 +164,992 @@ func BenchmarkParseStringRFC3164(b *testing.B) {
 	}
 	_ = lm
 }
+
+// TestRFC3164Msg_ParseStringCiscoPrefix tests the ParseString method of the msg type with
+// the WithStripCiscoPrefix option
+func TestRFC3164Msg_ParseStringCiscoPrefix(t *testing.T) {
+	strip := []parsesyslog.Option{parsesyslog.WithStripCiscoPrefix()}
+	tests := []struct {
+		name    string
+		msg     string
+		opts    []parsesyslog.Option
+		wantErr bool
+	}{
+		{"sequence number", "<189>123: Oct 11 22:14:15 router1 link: up\n", strip, false},
+		{"sequence number and unsynced", "<189>4711: *Oct 11 22:14:15 router1 link: up\n", strip, false},
+		{"formerly synced", "<189>.Oct 11 22:14:15 router1 link: up\n", strip, false},
+		{"no prefix", "<189>Oct 11 22:14:15 router1 link: up\n", strip, false},
+		{"sequence number without option", "<189>123: Oct 11 22:14:15 router1 link: up\n", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := parsesyslog.New(Type, tt.opts...)
+			if err != nil {
+				t.Fatalf("failed to create new RFC3164 parser: %s", err)
+			}
+			lm, err := p.ParseString(tt.msg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseString() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if lm.Timestamp.Format("Jan _2 15:04:05") != "Oct 11 22:14:15" {
+				t.Errorf("ParseString() wrong timestamp => got: %s", lm.Timestamp)
+			}
+			if lm.Hostname != "router1" {
+				t.Errorf("ParseString() wrong hostname => expected: %s, got: %s", "router1", lm.Hostname)
+			}
+			if lm.AppName != "link" {
+				t.Errorf("ParseString() wrong app name => expected: %s, got: %s", "link", lm.AppName)
+			}
+		})
+	}
+}
+
+// TestParseStringRFC3164_withFields tests the ParseString method with the WithFields option
+func TestParseStringRFC3164_withFields(t *testing.T) {
+	p, err := parsesyslog.New(Type, parsesyslog.WithFields(parsesyslog.FieldPriority|parsesyslog.FieldAppName))
+	if err != nil {
+		t.Fatalf("failed to create new RFC3164 parser: %s", err)
+	}
+	l, err := p.ParseString("<13>Nov 27 16:00:35 arch-vm wneessen[1130275]: test\n")
+	if err != nil {
+		t.Fatalf("failed to parse message: %s", err)
+	}
+	if l.Priority != 13 {
+		t.Errorf("ParseString() wrong priority => expected: %d, got: %d", 13, l.Priority)
+	}
+	if l.AppName != "wneessen" {
+		t.Errorf("ParseString() wrong app name => expected: %s, got: %s", "wneessen", l.AppName)
+	}
+	if l.Hostname != "" || l.ProcID != "" || !l.Timestamp.IsZero() || l.Message.Len() != 0 {
+		t.Errorf("ParseString() populated unselected fields: %+v", l)
+	}
+}
+
+// TestRFC3164Msg_ParseReaderInto tests the ParseReaderInto method of the msg type
+func TestRFC3164Msg_ParseReaderInto(t *testing.T) {
+	br := bufio.NewReader(strings.NewReader("<34>Oct 11 22:14:15 mymachine su[123]: first\n" +
+		"<34>Oct 11 22:14:16 mymachine sshd: second\n"))
+	m := &msg{}
+	var lm parsesyslog.LogMsg
+	if err := m.ParseReaderInto(br, &lm); err != nil {
+		t.Fatalf("ParseReaderInto() failed: %s", err)
+	}
+	if lm.ProcID != "123" || lm.Message.String() != "first\n" {
+		t.Errorf("ParseReaderInto() first message not correctly parsed: %+v", lm)
+	}
+	if err := m.ParseReaderInto(br, &lm); err != nil {
+		t.Fatalf("ParseReaderInto() failed: %s", err)
+	}
+	if lm.AppName != "sshd" || lm.ProcID != "" || lm.Message.String() != "second\n" {
+		t.Errorf("ParseReaderInto() second message not correctly parsed: %+v", lm)
+	}
+}
+
+// TestParseStreamRFC3164 tests the RFC3164 parser with a parsesyslog.StreamParser
+func TestParseStreamRFC3164(t *testing.T) {
+	p, err := parsesyslog.New(Type)
+	if err != nil {
+		t.Fatalf("failed to create new RFC3164 parser: %s", err)
+	}
+	next := parsesyslog.ParseStream(p, strings.NewReader("<34>Oct 11 22:14:15 mymachine su: first\n\n"+
+		"<34>Oct 11 22:14:16 mymachine su: second\n<34>Oct 11 22:14:17 mymachine su: third"))
+	for _, want := range []string{"first\n", "second\n", "third"} {
+		lm, err := next()
+		if err != nil {
+			t.Fatalf("ParseStream() failed: %s", err)
+		}
+		if lm.Message.String() != want {
+			t.Errorf("ParseStream() wrong message => expected: %q, got: %q", want, lm.Message.String())
+		}
+	}
+	if _, err := next(); !errors.Is(err, io.EOF) {
+		t.Errorf("ParseStream() at end of stream => expected: %s, got: %s", io.EOF, err)
+	}
+}
+
+// TestParseStringRFC3164_withLocationMap tests the RFC3164 parser with a LocationMap
+func TestParseStringRFC3164_withLocationMap(t *testing.T) {
+	loc := time.FixedZone("JST", 9*3600)
+	lmap, err := parsesyslog.NewLocationMap(map[string]*time.Location{"10.0.0.0/8": loc, "tokyo": loc})
+	if err != nil {
+		t.Fatalf("NewLocationMap() failed: %s", err)
+	}
+	tests := []struct {
+		name string
+		msg  string
+		opts []parsesyslog.Option
+		want *time.Location
+	}{
+		{"hostname", "<34>Oct 11 22:14:15 tokyo su: test", nil, loc},
+		{"network", "<34>Oct 11 22:14:15 10.1.2.3 su: test", nil, loc},
+		{"unknown host", "<34>Oct 11 22:14:15 berlin su: test", nil, time.UTC},
+		{
+			"hostname not selected", "<34>Oct 11 22:14:15 tokyo su: test",
+			[]parsesyslog.Option{parsesyslog.WithFields(parsesyslog.FieldTimestamp)}, loc,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := parsesyslog.New(Type, append(tt.opts, parsesyslog.WithLocationMap(lmap))...)
+			if err != nil {
+				t.Fatalf("failed to create new RFC3164 parser: %s", err)
+			}
+			l, err := p.ParseString(tt.msg)
+			if err != nil {
+				t.Fatalf("ParseString() failed: %s", err)
+			}
+			if l.Timestamp.Location() != tt.want {
+				t.Errorf("ParseString() wrong location => expected: %s, got: %s", tt.want, l.Timestamp.Location())
+			}
+			if l.Timestamp.Hour() != 22 || l.Timestamp.Minute() != 14 {
+				t.Errorf("ParseString() wrong wall clock: %s", l.Timestamp)
+			}
+		})
+	}
+}
+
+// TestParseStringRFC3164_withLocationMapRFC3339 tests that the time zone of a LocationMap
+// is only applied to timestamps without time zone
+func TestParseStringRFC3164_withLocationMapRFC3339(t *testing.T) {
+	nyc := time.FixedZone("EDT", -4*3600)
+	lmap, err := parsesyslog.NewLocationMap(map[string]*time.Location{"nyc": nyc})
+	if err != nil {
+		t.Fatalf("NewLocationMap() failed: %s", err)
+	}
+	tests := []struct {
+		name string
+		msg  string
+		opts []parsesyslog.Option
+		want time.Time
+	}{
+		{
+			"RFC3339", "<34>2023-10-11T22:14:15+02:00 nyc su: test", nil,
+			time.Date(2023, 10, 11, 20, 14, 15, 0, time.UTC),
+		},
+		{
+			"RFC3339 lenient", "<34>2023-10-11T22:14:15.5+02:00 nyc su: test",
+			[]parsesyslog.Option{parsesyslog.WithLenient()}, time.Date(2023, 10, 11, 20, 14, 15, 5e8, time.UTC),
+		},
+		{
+			"RFC3339 with location", "<34>2023-10-11T22:14:15Z nyc su: test",
+			[]parsesyslog.Option{parsesyslog.WithLocation(time.FixedZone("JST", 9*3600))},
+			time.Date(2023, 10, 11, 22, 14, 15, 0, time.UTC),
+		},
+		{
+			"BSD", "<34>Oct 11 22:14:15 nyc su: test", []parsesyslog.Option{parsesyslog.WithYear(2023)},
+			time.Date(2023, 10, 11, 22, 14, 15, 0, nyc),
+		},
+		{
+			"BSD lenient", "<34>Oct 11 22:14:15.5 nyc su: test",
+			[]parsesyslog.Option{parsesyslog.WithYear(2023), parsesyslog.WithLenient()},
+			time.Date(2023, 10, 11, 22, 14, 15, 5e8, nyc),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := parsesyslog.New(Type, append(tt.opts, parsesyslog.WithLocationMap(lmap))...)
+			if err != nil {
+				t.Fatalf("failed to create new RFC3164 parser: %s", err)
+			}
+			l, err := p.ParseString(tt.msg)
+			if err != nil {
+				t.Fatalf("ParseString() failed: %s", err)
+			}
+			if !l.Timestamp.Equal(tt.want) {
+				t.Errorf("ParseString() => expected timestamp: %s, got: %s", tt.want, l.Timestamp)
+			}
+		})
+	}
+}
+
+// TestParseStringRFC3164_withLocation tests that the RFC3164 parser interprets timestamps
+// without time zone in the given time.Location
+func TestParseStringRFC3164_withLocation(t *testing.T) {
+	nyc := time.FixedZone("EST", -5*3600)
+	jst := time.FixedZone("JST", 9*3600)
+	lmap, err := parsesyslog.NewLocationMap(map[string]*time.Location{"tokyo": jst})
+	if err != nil {
+		t.Fatalf("NewLocationMap() failed: %s", err)
+	}
+	tests := []struct {
+		name string
+		msg  string
+		opts []parsesyslog.Option
+		want time.Time
+	}{
+		{"default", "<34>Oct 11 22:14:15 host su: test", nil, time.Date(2003, 10, 11, 22, 14, 15, 0, time.UTC)},
+		{
+			"location", "<34>Oct 11 22:14:15 host su: test", []parsesyslog.Option{parsesyslog.WithLocation(nyc)},
+			time.Date(2003, 10, 11, 22, 14, 15, 0, nyc),
+		},
+		{
+			"location map", "<34>Oct 11 22:14:15 tokyo su: test",
+			[]parsesyslog.Option{parsesyslog.WithLocation(nyc), parsesyslog.WithLocationMap(lmap)},
+			time.Date(2003, 10, 11, 22, 14, 15, 0, jst),
+		},
+		{
+			"localized", "<34>Okt 11 22:14:15 host su: test",
+			[]parsesyslog.Option{
+				parsesyslog.WithLocation(nyc),
+				parsesyslog.WithMonthNames(parsesyslog.LocalizedMonthNames),
+			},
+			time.Date(2003, 10, 11, 22, 14, 15, 0, nyc),
+		},
+		{
+			"lenient", "<34>Oct 11 22:14:15.5 host su: test",
+			[]parsesyslog.Option{parsesyslog.WithLocation(nyc), parsesyslog.WithLenient()},
+			time.Date(2003, 10, 11, 22, 14, 15, 5e8, nyc),
+		},
+		{
+			"lenient RFC3339", "<34>2003-10-11T22:14:15+02:00 host su: test",
+			[]parsesyslog.Option{parsesyslog.WithLocation(nyc), parsesyslog.WithLenient()},
+			time.Date(2003, 10, 11, 20, 14, 15, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := parsesyslog.New(Type, append(tt.opts, parsesyslog.WithYear(2003))...)
+			if err != nil {
+				t.Fatalf("failed to create new RFC3164 parser: %s", err)
+			}
+			l, err := p.ParseString(tt.msg)
+			if err != nil {
+				t.Fatalf("ParseString() failed: %s", err)
+			}
+			if !l.Timestamp.Equal(tt.want) {
+				t.Errorf("ParseString() => expected timestamp: %s, got: %s", tt.want, l.Timestamp)
+			}
+		})
+	}
+}
+
+// TestParseStringRFC3164_withMaxLength tests the LengthPolicies of the RFC3164 parser.
+// The header of the test messages is 34 bytes long.
+func TestParseStringRFC3164_withMaxLength(t *testing.T) {
+	hdr := "<34>Oct 11 22:14:15 mymachine su: "
+	long := strings.Repeat("x", 2000)
+	tests := []struct {
+		name      string
+		msg       string
+		opts      []parsesyslog.Option
+		want      string
+		truncated bool
+		err       error
+	}{
+		{"accept by default", hdr + long + "\n", nil, long + "\n", false, nil},
+		{
+			"accept", hdr + long, []parsesyslog.Option{parsesyslog.WithMaxLength(1024, parsesyslog.LengthAccept)},
+			long, false, nil,
+		},
+		{
+			"truncate", hdr + long + "\n",
+			[]parsesyslog.Option{parsesyslog.WithMaxLength(50, parsesyslog.LengthTruncate)}, long[:16], true, nil,
+		},
+		{
+			"truncate within header", "<34>Oct 11 22:14:15 mymachine su: test\n",
+			[]parsesyslog.Option{parsesyslog.WithMaxLength(20, parsesyslog.LengthTruncate)}, "", true, nil,
+		},
+		{
+			"exactly at maximum length", hdr + long[:16] + "\n",
+			[]parsesyslog.Option{parsesyslog.WithMaxLength(50, parsesyslog.LengthTruncate)}, long[:16] + "\n",
+			false, nil,
+		},
+		{
+			"reject", hdr + long + "\n",
+			[]parsesyslog.Option{parsesyslog.WithMaxLength(1024, parsesyslog.LengthReject)}, "", false,
+			parsesyslog.ErrMessageTooLong,
+		},
+		{
+			"reject without tag", "<34>Oct 11 22:14:15 mymachine " + long,
+			[]parsesyslog.Option{parsesyslog.WithMaxLength(1024, parsesyslog.LengthReject)}, "", false,
+			parsesyslog.ErrMessageTooLong,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := parsesyslog.New(Type, tt.opts...)
+			if err != nil {
+				t.Fatalf("failed to create new RFC3164 parser: %s", err)
+			}
+			l, err := p.ParseString(tt.msg)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ParseString() => expected error: %v, got: %v", tt.err, err)
+			}
+			if err != nil {
+				return
+			}
+			if l.Message.String() != tt.want {
+				t.Errorf("ParseString() wrong message => expected: %q, got: %q", tt.want, l.Message.String())
+			}
+			if l.Truncated != tt.truncated {
+				t.Errorf("ParseString() truncated => expected: %t, got: %t", tt.truncated, l.Truncated)
+			}
+		})
+	}
+}
+
+// TestParseStringRFC3164_withLenient tests that the deviations of lenient mode are
+// accepted and recorded, while the strict parser rejects them
+func TestParseStringRFC3164_withLenient(t *testing.T) {
+	year := time.Now().Year()
+	tests := []struct {
+		name    string
+		msg     string
+		relaxed parsesyslog.Relaxation
+		ts      time.Time
+		app     string
+		want    string
+		strict  bool
+	}{
+		{
+			"strict message", "<34>Oct 11 22:14:15 mymachine su: test\n", 0,
+			time.Date(year, 10, 11, 22, 14, 15, 0, time.UTC), "su", "test\n", true,
+		},
+		{
+			"leading zeros in PRI", "<034>Oct 11 22:14:15 mymachine su: test\n", parsesyslog.RelaxedPriority,
+			time.Date(year, 10, 11, 22, 14, 15, 0, time.UTC), "su", "test\n", true,
+		},
+		{
+			"single space before day", "<34>Oct 1 22:14:15 mymachine su: test\n", parsesyslog.RelaxedTimestamp,
+			time.Date(year, 10, 1, 22, 14, 15, 0, time.UTC), "su", "test\n", false,
+		},
+		{
+			"fractional seconds", "<34>Oct 11 22:14:15.123 mymachine su: test\n", parsesyslog.RelaxedTimestamp,
+			time.Date(year, 10, 11, 22, 14, 15, 123000000, time.UTC), "su", "test\n", false,
+		},
+		{
+			"year", "<34>Oct 11 2003 22:14:15 mymachine su: test\n", 0,
+			time.Date(2003, 10, 11, 22, 14, 15, 0, time.UTC), "su", "test\n", true,
+		},
+		{
+			"RFC3339 timestamp", "<34>2003-10-11T22:14:15.003Z mymachine su: test\n", 0,
+			time.Date(2003, 10, 11, 22, 14, 15, 3000000, time.UTC), "su", "test\n", true,
+		},
+		{
+			"colon without space", "<34>Oct 11 22:14:15 mymachine su:test\n", parsesyslog.RelaxedTag,
+			time.Date(year, 10, 11, 22, 14, 15, 0, time.UTC), "su", "test\n", false,
+		},
+		{
+			"colon without space after PID", "<34>Oct 11 22:14:15 mymachine su[123]:test\n",
+			parsesyslog.RelaxedTag, time.Date(year, 10, 11, 22, 14, 15, 0, time.UTC), "su", "test\n", false,
+		},
+		{
+			"all deviations", "<034>Oct 1 2003 22:14:15.5 mymachine su:test\n",
+			parsesyslog.RelaxedPriority | parsesyslog.RelaxedTimestamp | parsesyslog.RelaxedTag,
+			time.Date(2003, 10, 1, 22, 14, 15, 500000000, time.UTC), "su", "test\n", false,
+		},
+	}
+	lp, err := parsesyslog.New(Type, parsesyslog.WithLenient())
+	if err != nil {
+		t.Fatalf("failed to create new RFC3164 parser: %s", err)
+	}
+	sp, err := parsesyslog.New(Type)
+	if err != nil {
+		t.Fatalf("failed to create new RFC3164 parser: %s", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := lp.ParseString(tt.msg)
+			if err != nil {
+				t.Fatalf("ParseString() failed: %s", err)
+			}
+			if l.Relaxed != tt.relaxed {
+				t.Errorf("ParseString() relaxed => expected: %q, got: %q", tt.relaxed, l.Relaxed)
+			}
+			if !l.Timestamp.Equal(tt.ts) {
+				t.Errorf("ParseString() timestamp => expected: %s, got: %s", tt.ts, l.Timestamp)
+			}
+			if l.Hostname != "mymachine" {
+				t.Errorf("ParseString() hostname => expected: %q, got: %q", "mymachine", l.Hostname)
+			}
+			if l.AppName != tt.app {
+				t.Errorf("ParseString() app name => expected: %q, got: %q", tt.app, l.AppName)
+			}
+			if l.Message.String() != tt.want {
+				t.Errorf("ParseString() message => expected: %q, got: %q", tt.want, l.Message.String())
+			}
+
+			sl, err := sp.ParseString(tt.msg)
+			if sl.Relaxed != 0 {
+				t.Errorf("ParseString() in strict mode => expected no relaxation, got: %q", sl.Relaxed)
+			}
+			ok := err == nil && sl.AppName == tt.app && sl.Timestamp.Equal(tt.ts)
+			if ok != tt.strict {
+				t.Errorf("ParseString() in strict mode => expected strict match: %t, got: %t", tt.strict, ok)
+			}
+		})
+	}
+}
+
+// TestParseStreamRFC3164_withMaxLength tests that the remainder of a rejected message is
+// consumed, so that the next message of the stream can be parsed
+func TestParseStreamRFC3164_withMaxLength(t *testing.T) {
+	p, err := parsesyslog.New(Type, parsesyslog.WithMaxLength(1024, parsesyslog.LengthReject))
+	if err != nil {
+		t.Fatalf("failed to create new RFC3164 parser: %s", err)
+	}
+	br := bufio.NewReader(strings.NewReader("<34>Oct 11 22:14:15 mymachine su: " + strings.Repeat("x", 5000) +
+		"\n<34>Oct 11 22:14:16 mymachine su: second\n"))
+	if _, err = p.ParseReader(br); !errors.Is(err, parsesyslog.ErrMessageTooLong) {
+		t.Fatalf("ParseReader() => expected: %s, got: %v", parsesyslog.ErrMessageTooLong, err)
+	}
+	l, err := p.ParseReader(br)
+	if err != nil {
+		t.Fatalf("ParseReader() failed: %s", err)
+	}
+	if l.Message.String() != "second\n" {
+		t.Errorf("ParseReader() wrong message => expected: %q, got: %q", "second\n", l.Message.String())
+	}
+}
+
+// TestParseBytesRFC3164 tests that the ParseBytes method of the msg type returns the
+// same LogMsg as ParseString and does not reference the byte slice
+func TestParseBytesRFC3164(t *testing.T) {
+	p, err := parsesyslog.New(Type)
+	if err != nil {
+		t.Fatalf("failed to create new RFC3164 parser: %s", err)
+	}
+	bp, ok := p.(parsesyslog.BytesParser)
+	if !ok {
+		t.Fatalf("RFC3164 parser is expected to satisfy the BytesParser interface")
+	}
+	for _, msg := range []string{
+		"<13>Nov 27 16:00:35 arch-vm wneessen[1130275]: test\n",
+		"<34>Oct 11 22:14:15 mymachine su: 'su root' failed for lonvick on /dev/pts/8",
+		"<34>Oct 11 22:14:15 mymachine",
+		"<34>",
+	} {
+		want, wantErr := p.ParseString(msg)
+		b := []byte(msg)
+		got, err := bp.ParseBytes(b)
+		if fmt.Sprint(err) != fmt.Sprint(wantErr) {
+			t.Errorf("ParseBytes(%q) => expected error: %v, got: %v", msg, wantErr, err)
+		}
+		for i := range b {
+			b[i] = 'x'
+		}
+		for _, d := range parsesyslog.Diff(want, got) {
+			t.Errorf("ParseBytes(%q) => %s", msg, d)
+		}
+	}
+}
+
+// BenchmarkParseBytesRFC3164 benchmarks the ParseBytes method of the msg type
+func BenchmarkParseBytesRFC3164(b *testing.B) {
+	b.ReportAllocs()
+	msg := []byte("<34>Oct 11 22:14:15 mymachine su: 'su root' failed for lonvick on /dev/pts/8\n")
+	var lm parsesyslog.LogMsg
+	var err error
+
+	p, err := parsesyslog.New(Type)
+	if err != nil {
+		b.Errorf("failed to create new RFC3164 parser")
+		return
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		lm, err = parsesyslog.ParseBytes(p, msg)
+		if err != nil {
+			b.Errorf("failed to read bytes: %s", err)
+			break
+		}
+	}
+	_ = lm
+}
+
+// TestSelfTest tests that the RFC3164 parser passes the self-test and that the self-test
+// detects options that reject valid messages
+func TestSelfTest(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []parsesyslog.Option
+		failures int
+	}{
+		{"default", nil, 0},
+		{"lenient", []parsesyslog.Option{parsesyslog.WithLenient()}, 0},
+		{"fields", []parsesyslog.Option{parsesyslog.WithFields(parsesyslog.FieldMessage)}, 0},
+		{"too short", []parsesyslog.Option{parsesyslog.WithMaxLength(60, parsesyslog.LengthReject)}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := parsesyslog.New(Type, tt.opts...)
+			if err != nil {
+				t.Fatalf("failed to create new RFC3164 parser: %s", err)
+			}
+			if f := parsesyslog.SelfTest(p); len(f) != tt.failures {
+				t.Errorf("SelfTest() => expected %d failures, got: %v", tt.failures, f)
+			}
+		})
+	}
+}
+
+// TestParseStringRFC3164_parseError tests that parse errors carry the field, offset and
+// input they occurred in
+func TestParseStringRFC3164_parseError(t *testing.T) {
+	tests := []struct {
+		name    string
+		msg     string
+		opts    []parsesyslog.Option
+		err     error
+		field   string
+		offset  int
+		snippet string
+	}{
+		{"invalid PRI", "<x4>Oct 11 22:14:15 host su: test", nil, parsesyslog.ErrInvalidPrio, "PRI", 0, "x4"},
+		{
+			"invalid timestamp", "<34>Octo 11 22:14:15 host su: test", nil, parsesyslog.ErrInvalidTimestamp,
+			"TIMESTAMP", 4, "Octo 11 22:14:15",
+		},
+		{
+			"invalid lenient timestamp", "<34>Octo 11 22:14:15 host su: test",
+			[]parsesyslog.Option{parsesyslog.WithLenient()}, parsesyslog.ErrInvalidTimestamp, "TIMESTAMP", 4,
+			"Octo 11 22:14:15",
+		},
+		{
+			"premature end", "<34>Oct 11 22:14:15 host", nil, parsesyslog.ErrPrematureEOF, "HOSTNAME", 20,
+			"host",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := parsesyslog.New(Type, tt.opts...)
+			if err != nil {
+				t.Fatalf("failed to create new RFC3164 parser: %s", err)
+			}
+			_, err = p.ParseString(tt.msg)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ParseString() => expected error: %v, got: %v", tt.err, err)
+			}
+			var pe *parsesyslog.ParseError
+			if !errors.As(err, &pe) {
+				t.Fatalf("ParseString() => expected ParseError, got: %T", err)
+			}
+			if pe.Field != tt.field || pe.Offset != tt.offset || pe.Snippet != tt.snippet {
+				t.Errorf("ParseString() => expected %s at %d near %q, got: %s at %d near %q", tt.field,
+					tt.offset, tt.snippet, pe.Field, pe.Offset, pe.Snippet)
+			}
+		})
+	}
+}
+
+// TestParseStringRFC3164_partial tests that the fields preceding a parse error are returned
+// together with the error
+func TestParseStringRFC3164_partial(t *testing.T) {
+	ts := time.Date(time.Now().Year(), 10, 11, 22, 14, 15, 0, time.UTC)
+	tests := []struct {
+		name string
+		msg  string
+		opts []parsesyslog.Option
+		want parsesyslog.LogMsg
+	}{
+		{
+			"invalid timestamp", "<34>Octo 11 22:14:15 host su: test", nil,
+			parsesyslog.LogMsg{Priority: 34, Facility: 4, Severity: 2},
+		},
+		{
+			"premature end", "<34>Oct 11 22:14:15 host", nil,
+			parsesyslog.LogMsg{Priority: 34, Facility: 4, Severity: 2, Timestamp: ts},
+		},
+		{
+			"message too long", "<34>Oct 11 22:14:15 host su: test message",
+			[]parsesyslog.Option{parsesyslog.WithMaxLength(30, parsesyslog.LengthReject)},
+			parsesyslog.LogMsg{Priority: 34, Facility: 4, Severity: 2, Timestamp: ts, Hostname: "host",
+				AppName: "su"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := parsesyslog.New(Type, tt.opts...)
+			if err != nil {
+				t.Fatalf("failed to create new RFC3164 parser: %s", err)
+			}
+			lm, err := p.ParseString(tt.msg)
+			if err == nil {
+				t.Fatal("ParseString() => expected error, got nil")
+			}
+			tt.want.Type = parsesyslog.RFC3164
+			if d := parsesyslog.Diff(lm, tt.want); len(d) > 0 {
+				t.Errorf("ParseString() => unexpected partial LogMsg: %v", d)
+			}
+		})
+	}
+}
+
+// TestParseStringRFC3164_withMonthNames tests the RFC3164 parser with localized month names
+func TestParseStringRFC3164_withMonthNames(t *testing.T) {
+	year := time.Now().Year()
+	mn := parsesyslog.WithMonthNames(parsesyslog.LocalizedMonthNames)
+	tests := []struct {
+		name string
+		msg  string
+		opts []parsesyslog.Option
+		ts   time.Time
+		err  error
+	}{
+		{
+			"German", "<34>Dez 24 18:00:00 host su: test", []parsesyslog.Option{mn},
+			time.Date(year, 12, 24, 18, 0, 0, 0, time.UTC), nil,
+		},
+		{
+			"German with umlaut", "<34>Mär  1 08:15:00 host su: test", []parsesyslog.Option{mn},
+			time.Date(year, 3, 1, 8, 15, 0, 0, time.UTC), nil,
+		},
+		{
+			"French with dot", "<34>déc.  3 10:00:00 host su: test", []parsesyslog.Option{mn},
+			time.Date(year, 12, 3, 10, 0, 0, 0, time.UTC), nil,
+		},
+		{
+			"English", "<34>Oct 11 22:14:15 host su: test", []parsesyslog.Option{mn},
+			time.Date(year, 10, 11, 22, 14, 15, 0, time.UTC), nil,
+		},
+		{
+			"custom", "<34>XII 24 18:00:00 host su: test",
+			[]parsesyslog.Option{parsesyslog.WithMonthNames(map[string]time.Month{"XII": time.December})},
+			time.Date(year, 12, 24, 18, 0, 0, 0, time.UTC), nil,
+		},
+		{
+			"lenient with year", "<34>Dez 24 2022 18:00:00 host su: test",
+			[]parsesyslog.Option{mn, parsesyslog.WithLenient()}, time.Date(2022, 12, 24, 18, 0, 0, 0, time.UTC), nil,
+		},
+		{
+			"lenient", "<34>Dez 24 18:00:00 host su: test", []parsesyslog.Option{mn, parsesyslog.WithLenient()},
+			time.Date(year, 12, 24, 18, 0, 0, 0, time.UTC), nil,
+		},
+		{
+			"skipped timestamp", "<34>Mär  1 08:15:00 host su: test",
+			[]parsesyslog.Option{mn, parsesyslog.WithFields(parsesyslog.FieldHostname | parsesyslog.FieldMessage)},
+			time.Time{}, nil,
+		},
+		{"invalid day", "<34>fév 30 10:00:00 host su: test", []parsesyslog.Option{mn}, time.Time{},
+			parsesyslog.ErrInvalidTimestamp},
+		{"without month names", "<34>Dez 24 18:00:00 host su: test", nil, time.Time{},
+			parsesyslog.ErrInvalidTimestamp},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := parsesyslog.New(Type, tt.opts...)
+			if err != nil {
+				t.Fatalf("failed to create new RFC3164 parser: %s", err)
+			}
+			lm, err := p.ParseString(tt.msg)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ParseString() => expected error: %v, got: %v", tt.err, err)
+			}
+			if err != nil {
+				return
+			}
+			if !lm.Timestamp.Equal(tt.ts) {
+				t.Errorf("ParseString() => expected timestamp: %s, got: %s", tt.ts, lm.Timestamp)
+			}
+			if lm.Hostname != "host" || lm.Message.String() != "test" {
+				t.Errorf("ParseString() => unexpected hostname %q or message %q", lm.Hostname, lm.Message.String())
+			}
+		})
+	}
+}
+
+// TestParseStringRFC3164_withTrace tests that the parser hands the ParseTrace of every log
+// message to the TraceFunc
+func TestParseStringRFC3164_withTrace(t *testing.T) {
+	var traces []parsesyslog.ParseTrace
+	fn := func(_ *parsesyslog.LogMsg, pt parsesyslog.ParseTrace, _ error) {
+		traces = append(traces, pt)
+	}
+	p, err := parsesyslog.New(Type, parsesyslog.WithTrace(fn))
+	if err != nil {
+		t.Fatalf("failed to create new RFC3164 parser: %s", err)
+	}
+	if _, err = p.ParseString("<34>Oct 11 22:14:15 mymachine su: 'su root' failed"); err != nil {
+		t.Fatalf("ParseString() failed: %s", err)
+	}
+	if len(traces) != 1 {
+		t.Fatalf("ParseString() => expected 1 trace, got: %d", len(traces))
+	}
+	if traces[0].Header <= 0 || traces[0].Message <= 0 || traces[0].StructuredData != 0 {
+		t.Errorf("ParseString() => unexpected trace: %+v", traces[0])
+	}
+}
+
+// TestParseStringRFC3164_withYear tests that the parser infers the year of the timestamps
+// according to the year options
+func TestParseStringRFC3164_withYear(t *testing.T) {
+	ref := parsesyslog.WithReferenceTime(time.Date(2023, 1, 2, 10, 0, 0, 0, time.UTC))
+	nearest := parsesyslog.WithYearPolicy(parsesyslog.YearNearest)
+	tests := []struct {
+		name string
+		msg  string
+		opts []parsesyslog.Option
+		ts   time.Time
+	}{
+		{
+			"fixed year", "<34>Oct 11 22:14:15 host su: test", []parsesyslog.Option{parsesyslog.WithYear(2003)},
+			time.Date(2003, 10, 11, 22, 14, 15, 0, time.UTC),
+		},
+		{
+			"reference time", "<34>Dec 31 23:59:00 host su: test", []parsesyslog.Option{ref},
+			time.Date(2023, 12, 31, 23, 59, 0, 0, time.UTC),
+		},
+		{
+			"nearest", "<34>Dec 31 23:59:00 host su: test", []parsesyslog.Option{ref, nearest},
+			time.Date(2022, 12, 31, 23, 59, 0, 0, time.UTC),
+		},
+		{
+			"localized", "<34>Dez 31 23:59:00 host su: test",
+			[]parsesyslog.Option{ref, nearest, parsesyslog.WithMonthNames(parsesyslog.LocalizedMonthNames)},
+			time.Date(2022, 12, 31, 23, 59, 0, 0, time.UTC),
+		},
+		{
+			"lenient", "<34>Dec 31 23:59:00.123 host su: test", []parsesyslog.Option{ref, nearest, parsesyslog.WithLenient()},
+			time.Date(2022, 12, 31, 23, 59, 0, 123000000, time.UTC),
+		},
+		{
+			"lenient with year", "<34>Dec 31 2020 23:59:00 host su: test",
+			[]parsesyslog.Option{ref, nearest, parsesyslog.WithLenient()},
+			time.Date(2020, 12, 31, 23, 59, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := parsesyslog.New(Type, tt.opts...)
+			if err != nil {
+				t.Fatalf("failed to create new RFC3164 parser: %s", err)
+			}
+			lm, err := p.ParseString(tt.msg)
+			if err != nil {
+				t.Fatalf("ParseString() failed: %s", err)
+			}
+			if !lm.Timestamp.Equal(tt.ts) {
+				t.Errorf("ParseString() => expected timestamp: %s, got: %s", tt.ts, lm.Timestamp)
+			}
+		})
+	}
+}
+
+// TestParseStringRFC3164_rfc3339 tests that the RFC3164 parser accepts the RFC3339
+// timestamps of the default template of rsyslog
+func TestParseStringRFC3164_rfc3339(t *testing.T) {
+	cest := time.FixedZone("", 2*3600)
+	tests := []struct {
+		name string
+		msg  string
+		opts []parsesyslog.Option
+		ts   time.Time
+		err  error
+	}{
+		{
+			"with offset", "<34>2023-10-11T22:14:15.003+02:00 host su: test", nil,
+			time.Date(2023, 10, 11, 22, 14, 15, 3000000, cest), nil,
+		},
+		{"UTC", "<34>2023-10-11T22:14:15Z host su: test", nil, time.Date(2023, 10, 11, 22, 14, 15, 0, time.UTC), nil},
+		{
+			"nanoseconds", "<34>2023-10-11T22:14:15.123456789+02:00 host su: test", nil,
+			time.Date(2023, 10, 11, 22, 14, 15, 123456789, cest), nil,
+		},
+		{
+			"location ignored", "<34>2023-10-11T22:14:15+02:00 host su: test",
+			[]parsesyslog.Option{parsesyslog.WithLocation(time.FixedZone("EST", -5*3600))},
+			time.Date(2023, 10, 11, 22, 14, 15, 0, cest), nil,
+		},
+		{
+			"lenient", "<34>2023-10-11T22:14:15.003+02:00 host su: test", []parsesyslog.Option{parsesyslog.WithLenient()},
+			time.Date(2023, 10, 11, 22, 14, 15, 3000000, cest), nil,
+		},
+		{
+			"skipped timestamp", "<34>2023-10-11T22:14:15.003+02:00 host su: test",
+			[]parsesyslog.Option{parsesyslog.WithFields(parsesyslog.FieldHostname | parsesyslog.FieldAppName |
+				parsesyslog.FieldMessage)},
+			time.Time{}, nil,
+		},
+		{"without time zone", "<34>2023-10-11T22:14:15 host su: test", nil, time.Time{}, parsesyslog.ErrInvalidTimestamp},
+		{"invalid date", "<34>2023-13-11T22:14:15Z host su: test", nil, time.Time{}, parsesyslog.ErrInvalidTimestamp},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := parsesyslog.New(Type, tt.opts...)
+			if err != nil {
+				t.Fatalf("failed to create new RFC3164 parser: %s", err)
+			}
+			lm, err := p.ParseString(tt.msg)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ParseString() => expected error: %v, got: %v", tt.err, err)
+			}
+			if err != nil {
+				return
+			}
+			if !lm.Timestamp.Equal(tt.ts) {
+				t.Errorf("ParseString() => expected timestamp: %s, got: %s", tt.ts, lm.Timestamp)
+			}
+			if lm.Relaxed != 0 {
+				t.Errorf("ParseString() => expected no relaxations, got: %s", lm.Relaxed)
+			}
+			if lm.Hostname != "host" || lm.AppName != "su" || lm.Message.String() != "test" {
+				t.Errorf("ParseString() => unexpected hostname %q, app name %q or message %q", lm.Hostname,
+					lm.AppName, lm.Message.String())
+			}
+		})
+	}
+}
+
+// TestParseStringRFC3164_timestampWithYear tests that the RFC3164 parser accepts BSD
+// timestamps that include a year
+func TestParseStringRFC3164_timestampWithYear(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  string
+		opts []parsesyslog.Option
+		ts   time.Time
+		err  error
+	}{
+		{"year", "<34>Oct 11 2023 22:14:15 host su: test", nil, time.Date(2023, 10, 11, 22, 14, 15, 0, time.UTC), nil},
+		{
+			"single-digit day", "<34>Oct  1 2023 22:14:15 host su: test", nil,
+			time.Date(2023, 10, 1, 22, 14, 15, 0, time.UTC), nil,
+		},
+		{
+			"year takes precedence", "<34>Oct 11 2023 22:14:15 host su: test",
+			[]parsesyslog.Option{parsesyslog.WithYear(2003)}, time.Date(2023, 10, 11, 22, 14, 15, 0, time.UTC), nil,
+		},
+		{
+			"location", "<34>Oct 11 2023 22:14:15 host su: test",
+			[]parsesyslog.Option{parsesyslog.WithLocation(time.FixedZone("JST", 9*3600))},
+			time.Date(2023, 10, 11, 22, 14, 15, 0, time.FixedZone("JST", 9*3600)), nil,
+		},
+		{
+			"lenient", "<34>Oct 11 2023 22:14:15 host su: test", []parsesyslog.Option{parsesyslog.WithLenient()},
+			time.Date(2023, 10, 11, 22, 14, 15, 0, time.UTC), nil,
+		},
+		{
+			"skipped timestamp", "<34>Oct 11 2023 22:14:15 host su: test",
+			[]parsesyslog.Option{parsesyslog.WithFields(parsesyslog.FieldHostname | parsesyslog.FieldAppName |
+				parsesyslog.FieldMessage)},
+			time.Time{}, nil,
+		},
+		{"invalid day", "<34>Feb 30 2023 22:14:15 host su: test", nil, time.Time{}, parsesyslog.ErrInvalidTimestamp},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := parsesyslog.New(Type, append(tt.opts, parsesyslog.WithYear(2003))...)
+			if err != nil {
+				t.Fatalf("failed to create new RFC3164 parser: %s", err)
+			}
+			lm, err := p.ParseString(tt.msg)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ParseString() => expected error: %v, got: %v", tt.err, err)
+			}
+			if err != nil {
+				return
+			}
+			if !lm.Timestamp.Equal(tt.ts) {
+				t.Errorf("ParseString() => expected timestamp: %s, got: %s", tt.ts, lm.Timestamp)
+			}
+			if lm.Relaxed != 0 {
+				t.Errorf("ParseString() => expected no relaxations, got: %s", lm.Relaxed)
+			}
+			if lm.Hostname != "host" || lm.AppName != "su" || lm.Message.String() != "test" {
+				t.Errorf("ParseString() => unexpected hostname %q, app name %q or message %q", lm.Hostname,
+					lm.AppName, lm.Message.String())
+			}
+		})
+	}
+}
+
+// TestParseStringRFC3164_withoutHostname tests that the RFC3164 parser detects log
+// messages that lack the hostname
+func TestParseStringRFC3164_withoutHostname(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  string
+		host string
+		app  string
+		pid  string
+		want string
+	}{
+		{"without hostname", "<13>Oct 11 22:14:15 su: 'su root' failed", "", "su", "", "'su root' failed"},
+		{"without hostname with PID", "<13>Oct 11 22:14:15 sshd[123]: Accepted", "", "sshd", "123", "Accepted"},
+		{"with hostname", "<13>Oct 11 22:14:15 mymachine su: test", "mymachine", "su", "", "test"},
+		{"IPv6 hostname", "<13>Oct 11 22:14:15 fe80:: su: test", "fe80::", "su", "", "test"},
+	}
+	p, err := parsesyslog.New(Type)
+	if err != nil {
+		t.Fatalf("failed to create new RFC3164 parser: %s", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lm, err := p.ParseString(tt.msg)
+			if err != nil {
+				t.Fatalf("ParseString() failed: %s", err)
+			}
+			if lm.Hostname != tt.host {
+				t.Errorf("ParseString() hostname => expected: %q, got: %q", tt.host, lm.Hostname)
+			}
+			if lm.AppName != tt.app || lm.ProcID != tt.pid {
+				t.Errorf("ParseString() tag => expected: %s[%s], got: %s[%s]", tt.app, tt.pid, lm.AppName, lm.ProcID)
+			}
+			if lm.Message.String() != tt.want {
+				t.Errorf("ParseString() message => expected: %q, got: %q", tt.want, lm.Message.String())
+			}
+		})
+	}
+}
+
+// TestParseStringRFC3164_withDefaultPriority tests that the RFC3164 parser assigns the
+// default priority to log messages without PRI
+func TestParseStringRFC3164_withDefaultPriority(t *testing.T) {
+	tests := []struct {
+		name    string
+		msg     string
+		opts    []parsesyslog.Option
+		prio    parsesyslog.Priority
+		relaxed parsesyslog.Relaxation
+		err     error
+	}{
+		{
+			"without PRI", "Oct 11 22:14:15 mymachine kernel: test",
+			[]parsesyslog.Option{parsesyslog.WithDefaultPriority(13)}, 13, parsesyslog.RelaxedMissingPriority, nil,
+		},
+		{
+			"kern.emerg", "Oct 11 22:14:15 mymachine kernel: test",
+			[]parsesyslog.Option{parsesyslog.WithDefaultPriority(0)}, 0, parsesyslog.RelaxedMissingPriority, nil,
+		},
+		{
+			"with PRI", "<34>Oct 11 22:14:15 mymachine kernel: test",
+			[]parsesyslog.Option{parsesyslog.WithDefaultPriority(13)}, 34, 0, nil,
+		},
+		{
+			"lenient", "<034>Oct 11 22:14:15 mymachine kernel: test",
+			[]parsesyslog.Option{parsesyslog.WithDefaultPriority(13), parsesyslog.WithLenient()}, 34,
+			parsesyslog.RelaxedPriority, nil,
+		},
+		{"without default priority", "Oct 11 22:14:15 mymachine kernel: test", nil, 0, 0, parsesyslog.ErrWrongFormat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := parsesyslog.New(Type, tt.opts...)
+			if err != nil {
+				t.Fatalf("failed to create new RFC3164 parser: %s", err)
+			}
+			lm, err := p.ParseString(tt.msg)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ParseString() => expected error: %v, got: %v", tt.err, err)
+			}
+			if err != nil {
+				return
+			}
+			if lm.Priority != tt.prio || lm.Facility != parsesyslog.FacilityFromPrio(tt.prio) ||
+				lm.Severity != parsesyslog.SeverityFromPrio(tt.prio) {
+				t.Errorf("ParseString() => expected priority: %d, got: %d (%d/%d)", tt.prio, lm.Priority,
+					lm.Facility, lm.Severity)
+			}
+			if lm.Relaxed != tt.relaxed {
+				t.Errorf("ParseString() => expected relaxations: %q, got: %q", tt.relaxed, lm.Relaxed)
+			}
+			if lm.Hostname != "mymachine" || lm.AppName != "kernel" || lm.Message.String() != "test" {
+				t.Errorf("ParseString() => unexpected hostname %q, app name %q or message %q", lm.Hostname,
+					lm.AppName, lm.Message.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package rfc5424
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/wneessen/go-parsesyslog"
+)
+
+const (
+	// HMACSDID is the SD-ID of the structured data element that holds the HMAC signature
+	// of a log message
+	HMACSDID = "hmac@32473"
+	// hmacAlg is the name of the HMAC algorithm, as given in the "alg" param
+	hmacAlg = "HMAC-SHA256"
+)
+
+// WithHMAC makes Marshal sign the message with an HMAC-SHA256 over its RFC5424
+// representation, using the given shared key. The signature is added as the last
+// structured data element with the SD-ID HMACSDID and the params "alg" and "sig" (base64
+// encoded). Existing HMACSDID elements are replaced. The receiver verifies the signature
+// with VerifyHMAC.
+//
+// This is a lightweight alternative to the signature blocks of RFC5848 for senders and
+// receivers that share a key, i. e. relays of the same operator. Since the signature
+// covers the whole message, it must not be modified in between.
+// See: https://datatracker.ietf.org/doc/html/rfc5848
+func WithHMAC(key []byte) MarshalOption {
+	return func(m *marshaler) {
+		m.hmacKey = key
+	}
+}
+
+// VerifyHMAC verifies the HMAC signature that has been added by Marshal with WithHMAC to
+// the given parsed LogMsg, using the given shared key. Since the parser returns the
+// values of the structured data params as they are escaped on the wire, they are
+// unescaped before the signature is computed. If the LogMsg carries no
+// signature, parsesyslog.ErrMissingSignature is returned, if the signature does not
+// match, parsesyslog.ErrInvalidSignature.
+func VerifyHMAC(lm parsesyslog.LogMsg, key []byte) error {
+	var alg, sig string
+	found := false
+	for _, e := range lm.StructuredData {
+		if e.ID != HMACSDID {
+			continue
+		}
+		found = true
+		for _, p := range e.Param {
+			switch p.Name {
+			case "alg":
+				alg = p.Value
+			case "sig":
+				sig = p.Value
+			}
+		}
+	}
+	if !found {
+		return parsesyslog.ErrMissingSignature
+	}
+	if alg != hmacAlg {
+		return fmt.Errorf("%w: unsupported algorithm %q", parsesyslog.ErrInvalidSignature, alg)
+	}
+	want, err := base64.StdEncoding.DecodeString(sig)
+	if err != nil {
+		return fmt.Errorf("%w: %s", parsesyslog.ErrInvalidSignature, err)
+	}
+	// The signature covers the message as marshaled by the sender, not as received
+	lm.StructuredData = unescapeSD(withoutHMAC(lm.StructuredData))
+	lm.Wire = nil
+	got, err := messageHMAC(lm, key)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal(got, want) {
+		return parsesyslog.ErrInvalidSignature
+	}
+	return nil
+}
+
+// signHMAC returns the structured data of the given LogMsg with the HMACSDID element
+// for the given key appended
+func signHMAC(lm parsesyslog.LogMsg, key []byte) ([]parsesyslog.StructuredDataElement, error) {
+	lm.StructuredData = withoutHMAC(lm.StructuredData)
+	sig, err := messageHMAC(lm, key)
+	if err != nil {
+		return nil, err
+	}
+	return append(lm.StructuredData, parsesyslog.StructuredDataElement{
+		ID: HMACSDID, Param: []parsesyslog.StructuredDataParam{
+			{Name: "alg", Value: hmacAlg},
+			{Name: "sig", Value: base64.StdEncoding.EncodeToString(sig)},
+		},
+	}), nil
+}
+
+// messageHMAC returns the HMAC-SHA256 over the RFC5424 representation of the given
+// LogMsg
+func messageHMAC(lm parsesyslog.LogMsg, key []byte) ([]byte, error) {
+	b, err := Marshal(lm)
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write(b)
+	return mac.Sum(nil), nil
+}
+
+// withoutHMAC returns a copy of the given structured data elements without the HMACSDID
+// elements
+func withoutHMAC(sd []parsesyslog.StructuredDataElement) []parsesyslog.StructuredDataElement {
+	var r []parsesyslog.StructuredDataElement
+	for _, e := range sd {
+		if e.ID != HMACSDID {
+			r = append(r, e)
+		}
+	}
+	return r
+}
+
+// unescapeSD returns a copy of the given structured data elements with the backslash
+// escapes of the param values removed, so that Marshal reproduces the original values
+func unescapeSD(sd []parsesyslog.StructuredDataElement) []parsesyslog.StructuredDataElement {
+	r := make([]parsesyslog.StructuredDataElement, len(sd))
+	for i, e := range sd {
+		r[i] = parsesyslog.StructuredDataElement{ID: e.ID, Param: make([]parsesyslog.StructuredDataParam, len(e.Param))}
+		for j, p := range e.Param {
+			r[i].Param[j] = parsesyslog.StructuredDataParam{Name: p.Name, Value: unescapeParamValue(p.Value)}
+		}
+	}
+	return r
+}
+
+// unescapeParamValue returns the given PARAM-VALUE with the backslash escapes of '"', '\'
+// and ']' removed
+func unescapeParamValue(v string) string {
+	if !strings.Contains(v, "\\") {
+		return v
+	}
+	var sb strings.Builder
+	for i := 0; i < len(v); i++ {
+		if v[i] == '\\' && i+1 < len(v) && (v[i+1] == '"' || v[i+1] == '\\' || v[i+1] == ']') {
+			i++
+		}
+		sb.WriteByte(v[i])
+	}
+	return sb.String()
+}
@@ -0,0 +1,91 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package rfc5424
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/wneessen/go-parsesyslog"
+)
+
+// TestVerifyHMAC tests the signing of messages with WithHMAC and their verification
+// with VerifyHMAC
+func TestVerifyHMAC(t *testing.T) {
+	p, err := parsesyslog.New(Type)
+	if err != nil {
+		t.Fatalf("failed to create new RFC5424 parser: %s", err)
+	}
+	key := []byte("s3cr3t")
+	orig, err := p.ParseString(`<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog 1234 ID47 ` +
+		`[exampleSDID@32473 iut="3" path="C:\\tmp"][hmac@32473 alg="HMAC-SHA256" sig="stale"] ` +
+		"\xef\xbb\xbfAn application event log entry...")
+	if err != nil {
+		t.Fatalf("failed to parse message: %s", err)
+	}
+
+	tests := []struct {
+		name   string
+		opts   []MarshalOption
+		modify func(string) string
+		key    []byte
+		err    error
+	}{
+		{"valid", []MarshalOption{WithHMAC(key)}, nil, key, nil},
+		{"valid with octet counting", []MarshalOption{WithOctetCounting(), WithHMAC(key)}, nil, key, nil},
+		{"wrong key", []MarshalOption{WithHMAC(key)}, nil, []byte("other"), parsesyslog.ErrInvalidSignature},
+		{
+			"modified message", []MarshalOption{WithHMAC(key)},
+			func(s string) string { return strings.Replace(s, "application", "Application", 1) }, key,
+			parsesyslog.ErrInvalidSignature,
+		},
+		{
+			"modified structured data", []MarshalOption{WithHMAC(key)},
+			func(s string) string { return strings.Replace(s, `iut="3"`, `iut="4"`, 1) }, key,
+			parsesyslog.ErrInvalidSignature,
+		},
+		{
+			"unsupported algorithm", []MarshalOption{WithHMAC(key)},
+			func(s string) string { return strings.Replace(s, "HMAC-SHA256", "HMAC-MD5", 1) }, key,
+			parsesyslog.ErrInvalidSignature,
+		},
+		{
+			"invalid signature encoding", []MarshalOption{WithHMAC(key)},
+			func(s string) string { return strings.Replace(s, `sig="`, `sig="!`, 1) }, key,
+			parsesyslog.ErrInvalidSignature,
+		},
+		{
+			"unsigned", nil,
+			func(s string) string { return strings.Replace(s, "[hmac@32473", "[other@32473", 1) }, key,
+			parsesyslog.ErrMissingSignature,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := Marshal(orig, tt.opts...)
+			if err != nil {
+				t.Fatalf("Marshal() failed: %s", err)
+			}
+			if n := strings.Count(string(b), "[hmac@32473"); n != 1 {
+				t.Fatalf("Marshal() => expected 1 HMAC element, got: %d", n)
+			}
+			s := string(b)
+			if tt.modify != nil {
+				s = tt.modify(s)
+			}
+			lm, err := p.ParseString(s)
+			if err != nil {
+				t.Fatalf("failed to parse signed message: %s", err)
+			}
+			if err := VerifyHMAC(lm, tt.key); !errors.Is(err, tt.err) {
+				t.Errorf("VerifyHMAC() => expected: %v, got: %v", tt.err, err)
+			}
+		})
+	}
+	if len(orig.StructuredData) != 2 || orig.StructuredData[1].Param[1].Value != "stale" {
+		t.Errorf("Marshal() modified the structured data of the LogMsg: %+v", orig.StructuredData)
+	}
+}
@@ -0,0 +1,273 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package rfc5424
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wneessen/go-parsesyslog"
+)
+
+// Maximum lengths of the header fields
+// See: https://datatracker.ietf.org/doc/html/rfc5424#section-6
+const (
+	maxHostnameLen = 255
+	maxAppNameLen  = 48
+	maxProcIDLen   = 128
+	maxMsgIDLen    = 32
+	maxSDNameLen   = 32
+)
+
+// timeFormat is the TIMESTAMP format of RFC5424, which allows up to 6 digits of
+// fractional seconds
+// See: https://datatracker.ietf.org/doc/html/rfc5424#section-6.2.3
+const timeFormat = "2006-01-02T15:04:05.999999Z07:00"
+
+// bom is the UTF-8 byte order mark that indicates a UTF-8 encoded MSG
+var bom = []byte{0xEF, 0xBB, 0xBF}
+
+// MarshalOption is a function that adjusts the output of Marshal
+type MarshalOption func(*marshaler)
+
+// marshaler holds the settings of Marshal
+type marshaler struct {
+	hmacKey       []byte
+	octetCounting bool
+}
+
+// WithOctetCounting makes Marshal prefix the message with its length, as required by
+// the octet-counting framing of RFC6587 and RFC5425
+// See: https://datatracker.ietf.org/doc/html/rfc6587#section-3.4.1
+func WithOctetCounting() MarshalOption {
+	return func(m *marshaler) {
+		m.octetCounting = true
+	}
+}
+
+// Marshal returns the RFC5424 representation of the given LogMsg. Empty header fields
+// are written as NILVALUE, a zero ProtoVersion as version 1 and the Timestamp with at
+// most microsecond precision. The values of the structured data params are escaped as
+// required by the RFC. If HasBOM is set, the MSG is prefixed with a BOM, unless it
+// already starts with one.
+//
+// If the LogMsg has been parsed in round-trip mode (see parsesyslog.WithRoundTrip), the
+// original text of the Timestamp and the structured data is written instead, as long as
+// it still matches the LogMsg, so that conforming messages are reproduced byte-exact.
+//
+// Header fields that exceed their maximum length or contain characters that are not
+// allowed result in ErrWrongFormat, invalid SD-IDs and param names in ErrWrongSDFormat.
+// See: https://datatracker.ietf.org/doc/html/rfc5424#section-6
+func Marshal(lm parsesyslog.LogMsg, opts ...MarshalOption) ([]byte, error) {
+	m := marshaler{}
+	for _, o := range opts {
+		if o == nil {
+			continue
+		}
+		o(&m)
+	}
+
+	if m.hmacKey != nil {
+		sd, err := signHMAC(lm, m.hmacKey)
+		if err != nil {
+			return nil, err
+		}
+		lm.StructuredData = sd
+	}
+
+	var buf bytes.Buffer
+	if lm.Priority < 0 || lm.Priority > 191 {
+		return nil, parsesyslog.ErrInvalidPrio
+	}
+	buf.WriteByte('<')
+	buf.WriteString(strconv.Itoa(int(lm.Priority)))
+	buf.WriteByte('>')
+
+	pv := lm.ProtoVersion
+	if pv == 0 {
+		pv = 1
+	}
+	if pv < 0 || pv > 999 {
+		return nil, parsesyslog.ErrInvalidProtoVersion
+	}
+	buf.WriteString(strconv.Itoa(int(pv)))
+	buf.WriteByte(' ')
+
+	switch {
+	case lm.Timestamp.IsZero():
+		buf.WriteByte('-')
+	case isWireTimestamp(lm):
+		buf.WriteString(lm.Wire.Timestamp)
+	case lm.Timestamp.Year() < 0 || lm.Timestamp.Year() > 9999:
+		return nil, parsesyslog.ErrInvalidTimestamp
+	default:
+		buf.WriteString(lm.Timestamp.Format(timeFormat))
+	}
+
+	fields := []struct {
+		name string
+		val  string
+		max  int
+	}{
+		{"HOSTNAME", lm.Hostname, maxHostnameLen},
+		{"APP-NAME", lm.AppName, maxAppNameLen},
+		{"PROCID", lm.ProcID, maxProcIDLen},
+		{"MSGID", lm.MsgID, maxMsgIDLen},
+	}
+	for _, f := range fields {
+		buf.WriteByte(' ')
+		if f.val == "" {
+			buf.WriteByte('-')
+			continue
+		}
+		if len(f.val) > f.max || !isPrintUSASCII(f.val) {
+			return nil, fmt.Errorf("%w: invalid %s %q", parsesyslog.ErrWrongFormat, f.name, f.val)
+		}
+		buf.WriteString(f.val)
+	}
+
+	buf.WriteByte(' ')
+	if isWireStructuredData(lm) {
+		buf.WriteString(lm.Wire.StructuredData)
+	} else if err := marshalStructuredData(&buf, lm.StructuredData); err != nil {
+		return nil, err
+	}
+
+	// The parser requires a SP after the header, even if the MSG is empty
+	if lm.Message.Len() > 0 || lm.HasBOM || lm.Wire != nil {
+		buf.WriteByte(' ')
+		if lm.HasBOM && !bytes.HasPrefix(lm.Message.Bytes(), bom) {
+			buf.Write(bom)
+		}
+		buf.Write(lm.Message.Bytes())
+	}
+
+	if !m.octetCounting {
+		return buf.Bytes(), nil
+	}
+	b := make([]byte, 0, buf.Len()+8)
+	b = strconv.AppendInt(b, int64(buf.Len()), 10)
+	b = append(b, ' ')
+	return append(b, buf.Bytes()...), nil
+}
+
+// marshalStructuredData writes the given structured data elements to the buffer, or the
+// NILVALUE if there are none
+// See: https://datatracker.ietf.org/doc/html/rfc5424#section-6.3
+func marshalStructuredData(buf *bytes.Buffer, sds []parsesyslog.StructuredDataElement) error {
+	if len(sds) == 0 {
+		buf.WriteByte('-')
+		return nil
+	}
+	for _, e := range sds {
+		if !isSDName(e.ID) {
+			return fmt.Errorf("%w: invalid SD-ID %q", parsesyslog.ErrWrongSDFormat, e.ID)
+		}
+		buf.WriteByte('[')
+		buf.WriteString(e.ID)
+		for _, p := range e.Param {
+			if !isSDName(p.Name) {
+				return fmt.Errorf("%w: invalid PARAM-NAME %q", parsesyslog.ErrWrongSDFormat, p.Name)
+			}
+			buf.WriteByte(' ')
+			buf.WriteString(p.Name)
+			buf.WriteString(`="`)
+			escapeParamValue(buf, p.Value)
+			buf.WriteByte('"')
+		}
+		buf.WriteByte(']')
+	}
+	return nil
+}
+
+// isWireTimestamp returns true if the LogMsg carries the original text of its Timestamp
+// and the text still represents the Timestamp
+func isWireTimestamp(lm parsesyslog.LogMsg) bool {
+	if lm.Wire == nil || lm.Wire.Timestamp == "" {
+		return false
+	}
+	t, err := time.Parse(time.RFC3339, lm.Wire.Timestamp)
+	if err != nil || !t.Equal(lm.Timestamp) {
+		return false
+	}
+	_, wo := t.Zone()
+	_, o := lm.Timestamp.Zone()
+	return wo == o
+}
+
+// isWireStructuredData returns true if the LogMsg carries the original text of its
+// structured data and the text still represents the structured data. Since the parser
+// keeps the values of the params as they were received, the text is compared to the
+// structured data with unescaped values.
+func isWireStructuredData(lm parsesyslog.LogMsg) bool {
+	if lm.Wire == nil || lm.Wire.StructuredData == "" || len(lm.StructuredData) == 0 {
+		return false
+	}
+	w := lm.Wire.StructuredData
+	for _, e := range lm.StructuredData {
+		if !strings.HasPrefix(w, "["+e.ID) {
+			return false
+		}
+		w = w[len(e.ID)+1:]
+		for _, p := range e.Param {
+			if len(w) < len(p.Name)+len(p.Value)+4 || w[0] != ' ' || w[1:len(p.Name)+1] != p.Name {
+				return false
+			}
+			w = w[len(p.Name)+1:]
+			if w[:2] != `="` || w[2:len(p.Value)+2] != p.Value || w[len(p.Value)+2] != '"' {
+				return false
+			}
+			w = w[len(p.Value)+3:]
+		}
+		if w == "" || w[0] != ']' {
+			return false
+		}
+		w = w[1:]
+	}
+	return w == ""
+}
+
+// escapeParamValue writes the given PARAM-VALUE to the buffer with '"', '\' and ']'
+// escaped by a backslash
+// See: https://datatracker.ietf.org/doc/html/rfc5424#section-6.3.3
+func escapeParamValue(buf *bytes.Buffer, v string) {
+	for i := 0; i < len(v); i++ {
+		switch v[i] {
+		case '"', '\\', ']':
+			buf.WriteByte('\\')
+		}
+		buf.WriteByte(v[i])
+	}
+}
+
+// isPrintUSASCII returns true if the given string only consists of printable US-ASCII
+// characters (PRINTUSASCII)
+func isPrintUSASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 33 || s[i] > 126 {
+			return false
+		}
+	}
+	return true
+}
+
+// isSDName returns true if the given string is a valid SD-NAME, as used for SD-IDs and
+// PARAM-NAMEs
+// See: https://datatracker.ietf.org/doc/html/rfc5424#section-6
+func isSDName(s string) bool {
+	if s == "" || len(s) > maxSDNameLen || !isPrintUSASCII(s) {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '=', ']', '"':
+			return false
+		}
+	}
+	return true
+}
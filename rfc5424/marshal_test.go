@@ -0,0 +1,316 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package rfc5424
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wneessen/go-parsesyslog"
+)
+
+// TestMarshal tests the Marshal function
+func TestMarshal(t *testing.T) {
+	ts := time.Date(2003, 10, 11, 22, 14, 15, 3000000, time.FixedZone("", -7*3600))
+	tests := []struct {
+		name string
+		lm   func() parsesyslog.LogMsg
+		opts []MarshalOption
+		want string
+	}{
+		{
+			"full message", func() parsesyslog.LogMsg {
+				lm := parsesyslog.LogMsg{
+					Priority: 165, ProtoVersion: 1, Timestamp: ts, Hostname: "mymachine.example.com",
+					AppName: "evntslog", MsgID: "ID47",
+					StructuredData: []parsesyslog.StructuredDataElement{
+						{ID: "exampleSDID@32473", Param: []parsesyslog.StructuredDataParam{
+							{Name: "iut", Value: "3"}, {Name: "eventSource", Value: "Application"},
+						}},
+					},
+				}
+				lm.Message.WriteString("An application event log entry...")
+				return lm
+			}, nil,
+			`<165>1 2003-10-11T22:14:15.003-07:00 mymachine.example.com evntslog - ID47 ` +
+				`[exampleSDID@32473 iut="3" eventSource="Application"] An application event log entry...`,
+		},
+		{
+			"nil values", func() parsesyslog.LogMsg {
+				return parsesyslog.LogMsg{Priority: 34}
+			}, nil, `<34>1 - - - - - -`,
+		},
+		{
+			"nanoseconds truncated", func() parsesyslog.LogMsg {
+				return parsesyslog.LogMsg{Timestamp: time.Date(2003, 8, 24, 5, 14, 15, 123456789, time.UTC)}
+			}, nil, `<0>1 2003-08-24T05:14:15.123456Z - - - - -`,
+		},
+		{
+			"escaped param value", func() parsesyslog.LogMsg {
+				return parsesyslog.LogMsg{Priority: 13, StructuredData: []parsesyslog.StructuredDataElement{
+					{ID: "a@32473", Param: []parsesyslog.StructuredDataParam{{Name: "v", Value: `q"b\r]`}}},
+					{ID: "b@32473"},
+				}}
+			}, nil, `<13>1 - - - - - [a@32473 v="q\"b\\r\]"][b@32473]`,
+		},
+		{
+			"BOM", func() parsesyslog.LogMsg {
+				lm := parsesyslog.LogMsg{Priority: 13, HasBOM: true}
+				lm.Message.WriteString("üni")
+				return lm
+			}, nil, "<13>1 - - - - - - \xef\xbb\xbfüni",
+		},
+		{
+			"BOM already present", func() parsesyslog.LogMsg {
+				lm := parsesyslog.LogMsg{Priority: 13, HasBOM: true}
+				lm.Message.WriteString("\xef\xbb\xbfüni")
+				return lm
+			}, nil, "<13>1 - - - - - - \xef\xbb\xbfüni",
+		},
+		{
+			"octet counting", func() parsesyslog.LogMsg {
+				lm := parsesyslog.LogMsg{Priority: 13, Hostname: "host"}
+				lm.Message.WriteString("test")
+				return lm
+			}, []MarshalOption{WithOctetCounting()}, `25 <13>1 - host - - - - test`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := Marshal(tt.lm(), tt.opts...)
+			if err != nil {
+				t.Fatalf("Marshal() failed: %s", err)
+			}
+			if string(b) != tt.want {
+				t.Errorf("Marshal() => expected: %q, got: %q", tt.want, string(b))
+			}
+		})
+	}
+}
+
+// TestMarshal_fails tests the Marshal function with LogMsg that can not be represented
+// in RFC5424
+func TestMarshal_fails(t *testing.T) {
+	tests := []struct {
+		name    string
+		lm      parsesyslog.LogMsg
+		wantErr error
+	}{
+		{"priority too large", parsesyslog.LogMsg{Priority: 192}, parsesyslog.ErrInvalidPrio},
+		{"negative priority", parsesyslog.LogMsg{Priority: -1}, parsesyslog.ErrInvalidPrio},
+		{"invalid version", parsesyslog.LogMsg{ProtoVersion: 1000}, parsesyslog.ErrInvalidProtoVersion},
+		{
+			"year out of range", parsesyslog.LogMsg{Timestamp: time.Date(10000, 1, 1, 0, 0, 0, 0, time.UTC)},
+			parsesyslog.ErrInvalidTimestamp,
+		},
+		{"hostname with space", parsesyslog.LogMsg{Hostname: "my host"}, parsesyslog.ErrWrongFormat},
+		{"app name too long", parsesyslog.LogMsg{AppName: strings.Repeat("a", 49)}, parsesyslog.ErrWrongFormat},
+		{"non-ASCII msg ID", parsesyslog.LogMsg{MsgID: "ü"}, parsesyslog.ErrWrongFormat},
+		{
+			"empty SD-ID", parsesyslog.LogMsg{StructuredData: []parsesyslog.StructuredDataElement{{}}},
+			parsesyslog.ErrWrongSDFormat,
+		},
+		{
+			"invalid param name", parsesyslog.LogMsg{StructuredData: []parsesyslog.StructuredDataElement{
+				{ID: "a@32473", Param: []parsesyslog.StructuredDataParam{{Name: "a=b"}}},
+			}}, parsesyslog.ErrWrongSDFormat,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Marshal(tt.lm); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Marshal() => expected: %s, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestMarshal_roundTrip tests that a marshaled LogMsg is parsed into the same LogMsg
+func TestMarshal_roundTrip(t *testing.T) {
+	p, err := parsesyslog.New(Type)
+	if err != nil {
+		t.Fatalf("failed to create new RFC5424 parser: %s", err)
+	}
+	msg := `<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog 1234 ID47 ` +
+		`[exampleSDID@32473 iut="3" eventSource="Application"][examplePriority@32473 class="high"] ` +
+		`An application event log entry...`
+	lm, err := p.ParseString(msg)
+	if err != nil {
+		t.Fatalf("failed to parse message: %s", err)
+	}
+	for _, opts := range [][]MarshalOption{nil, {WithOctetCounting()}} {
+		b, err := Marshal(lm, opts...)
+		if err != nil {
+			t.Fatalf("Marshal() failed: %s", err)
+		}
+		got, err := p.ParseString(string(b))
+		if err != nil {
+			t.Fatalf("failed to parse marshaled message: %s", err)
+		}
+		if !strings.HasSuffix(string(b), msg) {
+			t.Errorf("Marshal() => expected: %q, got: %q", msg, string(b))
+		}
+		for _, d := range parsesyslog.Diff(lm, got) {
+			t.Errorf("round trip failed => %s", d)
+		}
+	}
+}
+
+// TestMarshal_roundTripExact tests that messages that have been parsed in round-trip mode
+// are reproduced byte-exact, including their timestamp text and the escaping of their
+// structured data
+func TestMarshal_roundTripExact(t *testing.T) {
+	p, err := parsesyslog.New(Type, parsesyslog.WithRoundTrip())
+	if err != nil {
+		t.Fatalf("failed to create new RFC5424 parser: %s", err)
+	}
+	for _, msg := range []string{
+		`<165>1 2003-10-11T22:14:15.300Z mymachine.example.com evntslog 1234 ID47 [exampleSDID@32473 iut="3"] event`,
+		`<34>1 2003-10-11T22:14:15+00:00 mymachine su - - [x@32473 path="C:\\tmp" empty=""][y@32473] `,
+		"<165>1 2003-08-24T05:14:15.000003-07:00 192.0.2.1 myproc 8710 - - \xef\xbb\xbf% It's time to make " +
+			"the do-nuts.",
+		`<0>1 - - - - - - `,
+	} {
+		lm, err := p.ParseString(msg)
+		if err != nil {
+			t.Fatalf("failed to parse message %q: %s", msg, err)
+		}
+		b, err := Marshal(lm)
+		if err != nil {
+			t.Fatalf("Marshal() failed: %s", err)
+		}
+		if string(b) != msg {
+			t.Errorf("Marshal() => expected: %q, got: %q", msg, string(b))
+		}
+	}
+}
+
+// TestMarshal_roundTripExact_changed tests that changes to a LogMsg that has been parsed
+// in round-trip mode take precedence over its original text
+func TestMarshal_roundTripExact_changed(t *testing.T) {
+	p, err := parsesyslog.New(Type, parsesyslog.WithRoundTrip())
+	if err != nil {
+		t.Fatalf("failed to create new RFC5424 parser: %s", err)
+	}
+	lm, err := p.ParseString(`<34>1 2003-10-11T22:14:15.300Z mymachine su - - [x@32473 a="1"] message`)
+	if err != nil {
+		t.Fatalf("failed to parse message: %s", err)
+	}
+	lm.Timestamp = lm.Timestamp.Add(time.Second)
+	lm.StructuredData[0].Param[0].Value = `"2"`
+	b, err := Marshal(lm)
+	if err != nil {
+		t.Fatalf("Marshal() failed: %s", err)
+	}
+	if want := `<34>1 2003-10-11T22:14:16.3Z mymachine su - - [x@32473 a="\"2\""] message`; string(b) != want {
+		t.Errorf("Marshal() => expected: %q, got: %q", want, string(b))
+	}
+}
+
+// TestMarshal_roundTripExact_property tests with randomly generated conforming messages
+// that every message that has been parsed in round-trip mode is reproduced byte-exact
+func TestMarshal_roundTripExact_property(t *testing.T) {
+	p, err := parsesyslog.New(Type, parsesyslog.WithRoundTrip())
+	if err != nil {
+		t.Fatalf("failed to create new RFC5424 parser: %s", err)
+	}
+	rnd := rand.New(rand.NewSource(5424))
+	for i := 0; i < 2000; i++ {
+		msg := randomMessage(rnd)
+		lm, err := p.ParseString(msg)
+		if err != nil {
+			t.Fatalf("failed to parse message %q: %s", msg, err)
+		}
+		b, err := Marshal(lm)
+		if err != nil {
+			t.Fatalf("Marshal() of %q failed: %s", msg, err)
+		}
+		if string(b) != msg {
+			t.Fatalf("Marshal() => expected: %q, got: %q", msg, string(b))
+		}
+	}
+}
+
+// randomMessage returns a random RFC5424 message that conforms to the RFC and the
+// restrictions of the parser
+func randomMessage(rnd *rand.Rand) string {
+	// randomString returns a random string of PRINTUSASCII characters, except the
+	// given ones, that does not start with a NILVALUE
+	randomString := func(max int, except string) string {
+		n := 1 + rnd.Intn(max)
+		var sb strings.Builder
+		for sb.Len() < n {
+			c := byte(33 + rnd.Intn(94))
+			if strings.IndexByte(except, c) >= 0 || (sb.Len() == 0 && c == '-') {
+				continue
+			}
+			sb.WriteByte(c)
+		}
+		return sb.String()
+	}
+	// field returns a random header field or the NILVALUE
+	field := func(max int) string {
+		if rnd.Intn(4) == 0 {
+			return "-"
+		}
+		return randomString(max, "")
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "<%d>1 ", rnd.Intn(192))
+	if rnd.Intn(5) == 0 {
+		sb.WriteByte('-')
+	} else {
+		ts := time.Date(1970+rnd.Intn(100), time.Month(1+rnd.Intn(12)), 1+rnd.Intn(28), rnd.Intn(24),
+			rnd.Intn(60), rnd.Intn(60), 0, time.UTC).Format("2006-01-02T15:04:05")
+		if d := rnd.Intn(7); d > 0 {
+			ts += "." + fmt.Sprintf("%06d", rnd.Intn(1000000))[:d]
+		}
+		switch rnd.Intn(3) {
+		case 0:
+			ts += "Z"
+		case 1:
+			ts += fmt.Sprintf("+%02d:%02d", rnd.Intn(15), rnd.Intn(4)*15)
+		default:
+			ts += fmt.Sprintf("-%02d:%02d", rnd.Intn(13), rnd.Intn(4)*15)
+		}
+		sb.WriteString(ts)
+	}
+	fmt.Fprintf(&sb, " %s %s %s %s ", field(maxHostnameLen), field(maxAppNameLen), field(maxProcIDLen),
+		field(maxMsgIDLen))
+
+	if n := rnd.Intn(4); n == 0 {
+		sb.WriteByte('-')
+	} else {
+		for i := 0; i < n; i++ {
+			sb.WriteString("[" + randomString(maxSDNameLen, `=]"[`))
+			for j := rnd.Intn(4); j > 0; j-- {
+				sb.WriteString(" " + randomString(maxSDNameLen, `=]"[`) + `="`)
+				if rnd.Intn(5) > 0 {
+					v := randomString(20, `]"[\`)
+					if rnd.Intn(3) == 0 {
+						v += `\\` + randomString(5, `]"[\`)
+					}
+					sb.WriteString(strings.ReplaceAll(v, "_", " "))
+				}
+				sb.WriteByte('"')
+			}
+			sb.WriteByte(']')
+		}
+	}
+
+	sb.WriteByte(' ')
+	if rnd.Intn(4) == 0 {
+		sb.Write(bom)
+	}
+	if rnd.Intn(5) > 0 {
+		sb.WriteString(randomString(200, "") + " Ünïcödé")
+	}
+	return sb.String()
+}
@@ -9,17 +9,34 @@ package rfc5424
 import (
 	"bufio"
 	"bytes"
-	"errors"
+	"fmt"
 	"io"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/wneessen/go-parsesyslog"
 )
 
 // msg represents a log message in that matches RFC5424
 type msg struct {
-	buf bytes.Buffer
+	bbr  *bufio.Reader
+	br   bytes.Reader
+	buf  bytes.Buffer
+	lbr  *bufio.Reader
+	lr   io.LimitedReader
+	opts parsesyslog.Options
+	hlen int
+	tr   parsesyslog.Tracer
+	src  []byte
+	wire bytes.Buffer
+
+	// The most recently parsed header strings, which are reused if the next
+	// message carries the same values
+	lastAppName  string
+	lastHostname string
+	lastMsgID    string
+	lastProcID   string
 }
 
 // Type represents the ParserType for this Parser
@@ -27,10 +44,14 @@ const Type parsesyslog.ParserType = "rfc5424"
 
 // init registers the Parser
 func init() {
-	fn := func() (parsesyslog.Parser, error) {
-		return &msg{}, nil
+	fn := func(o parsesyslog.Options) (parsesyslog.Parser, error) {
+		// Strict mode takes precedence over lenient mode
+		if o.Strict {
+			o.Lenient = false
+		}
+		return &msg{opts: o}, nil
 	}
-	parsesyslog.Register(Type, fn)
+	parsesyslog.RegisterWithOptions(Type, fn)
 }
 
 // ParseString returns the parsed log message read from a string (as buffered i/o)
@@ -40,90 +61,303 @@ func (m *msg) ParseString(s string) (parsesyslog.LogMsg, error) {
 	return m.ParseReader(br)
 }
 
+// ParseBytes returns the parsed log message read from a byte slice. The readers that
+// are needed to parse it are reused, so that no new ones are allocated for every message.
+// If the parser has been created with parsesyslog.WithZeroCopy, the strings and the
+// Message of the LogMsg reference the byte slice. It satisfies the
+// parsesyslog.BytesParser interface
+func (m *msg) ParseBytes(b []byte) (parsesyslog.LogMsg, error) {
+	m.br.Reset(b)
+	if m.bbr == nil {
+		m.bbr = bufio.NewReader(&m.br)
+	}
+	m.bbr.Reset(&m.br)
+	if m.opts.ZeroCopy {
+		m.src = b
+		defer func() { m.src = nil }()
+	}
+	return m.ParseReader(m.bbr)
+}
+
 // ParseReader is the parser function that is able to interpret RFC5424 and
-// satisfies the Parser interface
+// satisfies the Parser interface. The message can either be prefixed with its
+// length (octet-counting) or start directly with the PRI, in which case the
+// message is read until the end of the io.Reader. If parsing fails, the returned
+// LogMsg holds the fields that precede the one the error occurred in (see Parser).
 func (m *msg) ParseReader(r io.Reader) (parsesyslog.LogMsg, error) {
-	l := parsesyslog.LogMsg{
-		Type: parsesyslog.RFC5424,
+	var l parsesyslog.LogMsg
+	err := m.ParseReaderInto(r, &l)
+	return l, err
+}
+
+// ParseReaderInto works like ParseReader, but parses the log message into the given
+// LogMsg, reusing its message buffer and structured data slices. It satisfies the
+// parsesyslog.ReusingParser interface
+func (m *msg) ParseReaderInto(r io.Reader, l *parsesyslog.LogMsg) error {
+	m.tr.Start(m.opts.Trace)
+	err := m.parseReaderInto(r, l)
+	m.tr.Done(l, err)
+	return err
+}
+
+// parseReaderInto parses the log message read from the given io.Reader into the given
+// LogMsg
+func (m *msg) parseReaderInto(r io.Reader, l *parsesyslog.LogMsg) error {
+	l.Reset()
+	l.Type = parsesyslog.RFC5424
+	if m.src != nil {
+		l.MarkZeroCopy()
+	}
+	if m.opts.RoundTrip {
+		l.Wire = &parsesyslog.WireFormat{}
 	}
 
 	br, ok := r.(*bufio.Reader)
 	if !ok {
 		br = bufio.NewReader(r)
 	}
-	ml, err := parsesyslog.ReadMsgLength(br)
+	// Octet-counted messages start with the message length. Messages that have
+	// already been framed by a transport (i. e. by RFC6587 non-transparent framing)
+	// start with the PRI and end with the reader
+	fb, err := br.Peek(1)
 	if err != nil {
-		return l, err
+		return err
 	}
-
-	lr := io.LimitReader(br, int64(ml))
-	br = bufio.NewReaderSize(lr, ml)
-	if err := m.parseHeader(br, &l); err != nil {
-		switch {
-		case errors.Is(err, io.EOF):
-			return l, parsesyslog.ErrPrematureEOF
-		default:
-			return l, err
+	var outer *bufio.Reader
+	if fb[0] != '<' {
+		ml, err := parsesyslog.ReadMsgLength(br)
+		if err != nil {
+			return err
+		}
+		m.lr = io.LimitedReader{R: br, N: int64(ml)}
+		if m.lbr == nil {
+			m.lbr = bufio.NewReader(&m.lr)
 		}
+		m.lbr.Reset(&m.lr)
+		outer, br = br, m.lbr
 	}
-	if err := m.parseStructuredData(br, &l); err != nil {
-		switch {
-		case errors.Is(err, io.EOF):
-			return l, parsesyslog.ErrPrematureEOF
-		default:
-			return l, err
+	if err := m.parseHeader(br, l); err != nil {
+		return err
+	}
+	parseSD := m.parseStructuredData
+	if !m.opts.Wants(parsesyslog.FieldStructuredData) {
+		parseSD = m.skipStructuredData
+	}
+	if m.opts.Strict {
+		parseSD = m.parseStructuredDataStrict
+	}
+	start := m.hlen
+	m.tr.Phase(parsesyslog.PhaseStructuredData)
+	if err := parseSD(br, l); err != nil {
+		return parsesyslog.NewParseError(err, "STRUCTURED-DATA", start, m.buf.Bytes())
+	}
+	m.tr.Phase(parsesyslog.PhaseMessage)
+
+	if !m.opts.Wants(parsesyslog.FieldMessage) {
+		_, err = io.Copy(io.Discard, br)
+		return err
+	}
+	if m.opts.StreamingBody {
+		// Message bodies that are shorter than a BOM are streamed as well
+		_ = m.parseBOM(br, l)
+		l.Body = br
+		if br == m.lbr {
+			l.MsgLength = m.lbr.Buffered() + int(m.lr.N)
 		}
+		m.opts.PostProcess(l)
+		return nil
+	}
+	if err := m.parseBOM(br, l); err != nil {
+		return nil
 	}
 
-	if err := m.parseBOM(br, &l); err != nil {
-		return l, nil
+	if m.opts.MaxMessageSize > 0 {
+		return m.readMessageLimited(br, l)
+	}
+	if m.src != nil {
+		m.readMessage(br, l)
+	} else if _, err := l.Message.ReadFrom(br); err != nil {
+		return err
 	}
+	l.MsgLength = l.Message.Len()
+	if outer != nil && m.opts.OctetCountPolicy != parsesyslog.OctetCountNextFrame {
+		if err := m.applyOctetCountPolicy(outer, l); err != nil {
+			return err
+		}
+	}
+	if m.opts.Strict && l.HasBOM && !utf8.Valid(l.Message.Bytes()) {
+		err = parsesyslog.NewParseError(violation("MSG with BOM is not valid UTF-8"), "MSG", m.hlen,
+			l.Message.Bytes())
+		m.dropMessage(l)
+		l.MsgLength = 0
+		return err
+	}
+	m.opts.PostProcess(l)
 
-	// rb := make([]byte, ml - l.Message.Len())
-	md, err := io.ReadAll(br)
-	if err != nil {
-		return l, err
+	return nil
+}
+
+// readMessageLimited reads the MSG part of the log message into the given LogMsg, as long
+// as the log message does not exceed the maximum message size. The remainder of larger
+// log messages is discarded without being buffered, the LogMsg is marked as truncated
+// and returned with ErrMessageTooLarge.
+func (m *msg) readMessageLimited(r *bufio.Reader, l *parsesyslog.LogMsg) error {
+	n := m.opts.MaxMessageSize - m.hlen
+	if n < 0 {
+		n = 0
+	}
+	if _, err := l.Message.ReadFrom(io.LimitReader(r, int64(n))); err != nil {
+		return err
 	}
-	l.Message.Write(md)
 	l.MsgLength = l.Message.Len()
+	_, err := r.Peek(1)
+	if err != nil {
+		m.opts.PostProcess(l)
+		return nil
+	}
+	if _, err = io.Copy(io.Discard, r); err != nil {
+		return err
+	}
+	l.Truncated = true
+	m.opts.PostProcess(l)
+	return fmt.Errorf("%w: more than %d bytes", parsesyslog.ErrMessageTooLarge, m.opts.MaxMessageSize)
+}
 
-	return l, nil
+// extraSnippetLen is the maximum number of the bytes that exceed the octet count of a
+// log message that are included in the ParseError
+const extraSnippetLen = 32
+
+// applyOctetCountPolicy applies the OctetCountPolicy to the bytes that follow an
+// octet-counted log message in the given reader
+func (m *msg) applyOctetCountPolicy(r *bufio.Reader, l *parsesyslog.LogMsg) error {
+	if _, err := r.Peek(1); err != nil {
+		return nil
+	}
+	if m.opts.OctetCountPolicy == parsesyslog.OctetCountAccept {
+		n, err := l.Message.ReadFrom(r)
+		if err != nil {
+			return err
+		}
+		l.ExtraBytes = int(n)
+		l.MsgLength = l.Message.Len()
+		return nil
+	}
+	offset := m.hlen + l.MsgLength
+	n := r.Buffered()
+	if n > extraSnippetLen {
+		n = extraSnippetLen
+	}
+	snippet, _ := r.Peek(n)
+	err := fmt.Errorf("%w: MSG exceeds the octet count", parsesyslog.ErrInvalidFrameLength)
+	err = parsesyslog.NewParseError(err, "MSG", offset, snippet)
+	m.dropMessage(l)
+	l.MsgLength = 0
+	if _, cerr := io.Copy(io.Discard, r); cerr != nil {
+		return cerr
+	}
+	return err
 }
 
 // parseHeader will try to parse the header of a RFC5424 syslog message and store
 // it in the provided LogMsg pointer
 // See: https://datatracker.ietf.org/doc/html/rfc5424#section-6.2
 func (m *msg) parseHeader(r *bufio.Reader, lm *parsesyslog.LogMsg) error {
+	m.hlen = 0
 	if err := parsesyslog.ParsePriority(r, &m.buf, lm); err != nil {
-		return err
+		return parsesyslog.NewParseError(err, "PRI", 0, m.buf.Bytes())
 	}
-	if err := m.parseProtoVersion(r, lm); err != nil {
-		return err
-	}
-	if err := m.parseTimestamp(r, lm); err != nil {
-		return err
-	}
-	if err := m.parseHostname(r, lm); err != nil {
-		return err
+	m.hlen += m.buf.Len() + 2
+	if m.opts.Lenient && m.buf.Len() > 1 && m.buf.Bytes()[0] == '0' {
+		lm.Relaxed |= parsesyslog.RelaxedPriority
 	}
-	if err := m.parseAppName(r, lm); err != nil {
-		return err
-	}
-	if err := m.parseProcID(r, lm); err != nil {
-		return err
+	if m.opts.Strict {
+		if err := strictPriority(m.buf.Bytes(), lm.Priority); err != nil {
+			lm.Priority, lm.Facility, lm.Severity = 0, 0, 0
+			return parsesyslog.NewParseError(err, "PRI", 0, m.buf.Bytes())
+		}
 	}
-	if err := m.parseMsgID(r, lm); err != nil {
-		return err
+	for _, f := range headerFields {
+		start := m.hlen
+		err := f.parse(m, r, lm)
+		if err == nil {
+			err = m.checkHeaderSize()
+		}
+		if err == nil && m.opts.Strict && f.max > 0 {
+			err = strictHeaderField(f.name, m.buf.Bytes(), f.max)
+		}
+		if err != nil {
+			f.clear(lm)
+			return parsesyslog.NewParseError(err, f.name, start, m.buf.Bytes())
+		}
 	}
 
 	return nil
 }
 
+// headerField represents a field of the RFC5424 header with the functions that parse
+// it and that reset it after a failed check, its ABNF name and, for the fields that
+// consist of PRINTUSASCII, its maximum length
+type headerField struct {
+	parse func(*msg, *bufio.Reader, *parsesyslog.LogMsg) error
+	clear func(*parsesyslog.LogMsg)
+	name  string
+	max   int
+}
+
+// headerFields are the fields of the RFC5424 header that follow the PRI, in the order of
+// the header
+// See: https://datatracker.ietf.org/doc/html/rfc5424#section-6
+var headerFields = []headerField{
+	{
+		parse: (*msg).parseProtoVersion, name: "VERSION",
+		clear: func(lm *parsesyslog.LogMsg) { lm.ProtoVersion = 0 },
+	},
+	{
+		parse: (*msg).parseTimestamp, name: "TIMESTAMP", max: maxTimestampLen,
+		clear: func(lm *parsesyslog.LogMsg) {
+			lm.Timestamp = time.Time{}
+			lm.Relaxed &^= parsesyslog.RelaxedTimestamp
+			if lm.Wire != nil {
+				lm.Wire.Timestamp = ""
+			}
+		},
+	},
+	{
+		parse: (*msg).parseHostname, name: "HOSTNAME", max: maxHostnameLen,
+		clear: func(lm *parsesyslog.LogMsg) { lm.Hostname, lm.ResolvedHost = "", "" },
+	},
+	{
+		parse: (*msg).parseAppName, name: "APP-NAME", max: maxAppNameLen,
+		clear: func(lm *parsesyslog.LogMsg) { lm.AppName = "" },
+	},
+	{
+		parse: (*msg).parseProcID, name: "PROCID", max: maxProcIDLen,
+		clear: func(lm *parsesyslog.LogMsg) { lm.ProcID = "" },
+	},
+	{
+		parse: (*msg).parseMsgID, name: "MSGID", max: maxMsgIDLen,
+		clear: func(lm *parsesyslog.LogMsg) {
+			lm.MsgID = ""
+			lm.Relaxed &^= parsesyslog.RelaxedMsgID
+		},
+	},
+}
+
+// checkHeaderSize returns ErrHeaderTooLarge if the bytes of the header and structured data
+// that have been read so far exceed the maximum header size
+func (m *msg) checkHeaderSize() error {
+	if m.opts.MaxHeaderSize > 0 && m.hlen > m.opts.MaxHeaderSize {
+		return fmt.Errorf("%w: more than %d bytes", parsesyslog.ErrHeaderTooLarge, m.opts.MaxHeaderSize)
+	}
+	return nil
+}
+
 // parseStructuredData will try to parse the SD of a RFC5424 syslog message and
 // store it in the provided LogMsg pointer
 // See: https://datatracker.ietf.org/doc/html/rfc5424#section-6.2
 // We are using a simple finite state machine here to parse through the different
-// states of the parameters and elements
+// states of the parameters and elements. Escaped characters in PARAM-VALUEs are kept as
+// escaped on the wire, unless the Options require unescaping (see WithUnescapeSD).
 func (m *msg) parseStructuredData(r *bufio.Reader, lm *parsesyslog.LogMsg) error {
 	m.buf.Reset()
 
@@ -131,47 +365,99 @@ func (m *msg) parseStructuredData(r *bufio.Reader, lm *parsesyslog.LogMsg) error
 	if err != nil {
 		return err
 	}
+	m.hlen++
 	if nb == '-' {
 		_, err = r.ReadByte()
 		if err != nil {
 			return err
 		}
+		m.hlen++
 		return nil
 	}
 	if nb != '[' {
 		return parsesyslog.ErrWrongSDFormat
 	}
+	if lm.Wire != nil {
+		m.wire.Reset()
+		m.wire.WriteByte(nb)
+	}
 
-	var sds []parsesyslog.StructuredDataElement
-	var sd parsesyslog.StructuredDataElement
+	// The element and param slices of the LogMsg are reused, as well as the
+	// strings of the previous message, if they are equal
+	sds := lm.StructuredData[:0]
+	sd := nextSDElement(sds)
 	var sdp parsesyslog.StructuredDataParam
 	insideelem := true
 	insideparam := false
+	escaped := false
 	readname := false
 	for {
 		b, err := r.ReadByte()
 		if err != nil {
 			return err
 		}
-		if b == ']' {
+		m.hlen++
+		if err := m.checkHeaderSize(); err != nil {
+			return err
+		}
+		if lm.Wire != nil && (b != ' ' || insideelem) {
+			m.wire.WriteByte(b)
+		}
+		if escaped {
+			escaped = false
+			m.writeEscaped(b)
+			continue
+		}
+		if b == '\\' && insideparam {
+			escaped = true
+			continue
+		}
+		if b == ']' && !insideparam {
 			insideelem = false
-			sds = append(sds, sd)
-			sd = parsesyslog.StructuredDataElement{}
+			if !readname {
+				if err := m.checkSDName(lm, "SD-ID", m.buf.Bytes()); err != nil {
+					return err
+				}
+				sd.ID = m.str(r, sd.ID, m.buf.Bytes())
+			}
 			m.buf.Reset()
+			if sd.ID == "" {
+				if !m.opts.SkipEmptySD {
+					return parsesyslog.ErrWrongSDFormat
+				}
+				continue
+			}
+			if m.opts.Lenient && hasSDID(sds, sd.ID) {
+				lm.Relaxed |= parsesyslog.RelaxedDuplicateSDID
+			}
+			sds = append(sds, sd)
+			sd = nextSDElement(sds)
 			continue
 		}
-		if b == '[' {
+		if b == '[' && !insideparam {
 			insideelem = true
 			readname = false
 			continue
 		}
 		if b == ' ' && !readname {
 			readname = true
-			sd.ID = m.buf.String()
+			if err := m.checkSDName(lm, "SD-ID", m.buf.Bytes()); err != nil {
+				return err
+			}
+			sd.ID = m.str(r, sd.ID, m.buf.Bytes())
 			m.buf.Reset()
 		}
 		if b == '=' && !insideparam {
-			sdp.Name = m.buf.String()
+			if !readname {
+				if err := m.checkSDName(lm, "SD-ID", append(m.buf.Bytes(), b)); err != nil {
+					return err
+				}
+			}
+			if err := m.checkSDName(lm, "PARAM-NAME", m.buf.Bytes()); err != nil {
+				return err
+			}
+			sdp = nextSDParam(sd.Param)
+			sdp.Name = m.str(r, sdp.Name, m.buf.Bytes())
 			m.buf.Reset()
 			continue
 		}
@@ -181,7 +467,7 @@ func (m *msg) parseStructuredData(r *bufio.Reader, lm *parsesyslog.LogMsg) error
 		}
 		if b == '"' && insideparam {
 			insideparam = false
-			sdp.Value = m.buf.String()
+			sdp.Value = m.str(r, sdp.Value, m.buf.Bytes())
 			m.buf.Reset()
 			sd.Param = append(sd.Param, sdp)
 			sdp = parsesyslog.StructuredDataParam{}
@@ -196,10 +482,139 @@ func (m *msg) parseStructuredData(r *bufio.Reader, lm *parsesyslog.LogMsg) error
 		m.buf.WriteByte(b)
 	}
 	lm.StructuredData = sds
+	if lm.Wire != nil {
+		lm.Wire.StructuredData = m.wire.String()
+	}
 
 	return nil
 }
 
+// checkSDName validates the given SD-ID or PARAM-NAME, which must consist of 1 to 32
+// printable US-ASCII characters except '=', SP, ']' and '"'. An empty SD-ID is left to
+// the caller, as it is accepted with the SkipEmptySD option. In lenient mode, an invalid
+// SD-NAME is accepted and recorded in the Relaxed field of the LogMsg.
+// See: https://datatracker.ietf.org/doc/html/rfc5424#section-6.3
+func (m *msg) checkSDName(lm *parsesyslog.LogMsg, name string, b []byte) error {
+	var reason string
+	switch {
+	case len(b) == 0 && name == "SD-ID":
+		return nil
+	case len(b) == 0:
+		reason = "is empty"
+	case len(b) > maxSDNameLen:
+		reason = fmt.Sprintf("exceeds %d characters", maxSDNameLen)
+	default:
+		for _, c := range b {
+			if c < 33 || c > 126 || c == '=' || c == ']' || c == '"' {
+				reason = fmt.Sprintf("contains invalid character 0x%02x", c)
+				break
+			}
+		}
+	}
+	if reason == "" {
+		return nil
+	}
+	if m.opts.Lenient {
+		lm.Relaxed |= parsesyslog.RelaxedSDName
+		return nil
+	}
+	return fmt.Errorf("%w: %s %q %s", parsesyslog.ErrWrongSDFormat, name, b, reason)
+}
+
+// writeEscaped writes the given byte of a PARAM-VALUE, which followed a backslash, to
+// the buffer. Unless the Options require unescaping, the backslash is kept. It is kept
+// in any case if the byte is not one of the characters that need to be escaped.
+// See: https://datatracker.ietf.org/doc/html/rfc5424#section-6.3.3
+func (m *msg) writeEscaped(b byte) {
+	if !m.opts.UnescapeSD || (b != '"' && b != '\\' && b != ']') {
+		m.buf.WriteByte('\\')
+	}
+	m.buf.WriteByte(b)
+}
+
+// hasSDID returns true if one of the given structured data elements has the given SD-ID
+func hasSDID(sds []parsesyslog.StructuredDataElement, id string) bool {
+	for _, e := range sds {
+		if e.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// nextSDElement returns the element that follows the given elements in their backing
+// array (with its params truncated), so that its slices and strings can be reused. If
+// there is no such element, an empty element is returned.
+func nextSDElement(sds []parsesyslog.StructuredDataElement) parsesyslog.StructuredDataElement {
+	if len(sds) == cap(sds) {
+		return parsesyslog.StructuredDataElement{}
+	}
+	e := sds[:len(sds)+1][len(sds)]
+	e.Param = e.Param[:0]
+	return e
+}
+
+// nextSDParam returns the param that follows the given params in their backing array,
+// so that its strings can be reused. If there is no such param, an empty param is
+// returned.
+func nextSDParam(ps []parsesyslog.StructuredDataParam) parsesyslog.StructuredDataParam {
+	if len(ps) == cap(ps) {
+		return parsesyslog.StructuredDataParam{}
+	}
+	return ps[:len(ps)+1][len(ps)]
+}
+
+// skipStructuredData will read past the SD of a RFC5424 syslog message without
+// decomposing it into elements and params
+// See: https://datatracker.ietf.org/doc/html/rfc5424#section-6.3
+func (m *msg) skipStructuredData(r *bufio.Reader, _ *parsesyslog.LogMsg) error {
+	m.buf.Reset()
+	nb, err := r.ReadByte()
+	if err != nil {
+		return err
+	}
+	m.hlen++
+	if nb == '-' {
+		_, err = r.ReadByte()
+		m.hlen++
+		return err
+	}
+	if nb != '[' {
+		return parsesyslog.ErrWrongSDFormat
+	}
+
+	insideparam, escaped := false, false
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+		m.hlen++
+		if err := m.checkHeaderSize(); err != nil {
+			return err
+		}
+		switch {
+		case escaped:
+			escaped = false
+		case b == '\\' && insideparam:
+			escaped = true
+		case b == '"':
+			insideparam = !insideparam
+		case b == ']' && !insideparam:
+			nb, err = r.ReadByte()
+			if err != nil {
+				return err
+			}
+			if nb == ' ' {
+				return nil
+			}
+			if nb != '[' {
+				return parsesyslog.ErrWrongSDFormat
+			}
+		}
+	}
+}
+
 // parseBOM will try to parse the BOM (if any) of the RFC54524 header
 // See: https://datatracker.ietf.org/doc/html/rfc5424#section-6.4
 func (m *msg) parseBOM(r *bufio.Reader, lm *parsesyslog.LogMsg) error {
@@ -216,7 +631,10 @@ func (m *msg) parseBOM(r *bufio.Reader, lm *parsesyslog.LogMsg) error {
 // parseProtoVersion will try to parse the proto version part of the RFC54524 header
 // See: https://datatracker.ietf.org/doc/html/rfc5424#section-6.2.2
 func (m *msg) parseProtoVersion(r *bufio.Reader, lm *parsesyslog.LogMsg) error {
-	b, _, err := parsesyslog.ReadBytesUntilSpace(r)
+	b, n, err := parsesyslog.ReadBytesUntilSpace(r)
+	m.hlen += n
+	m.buf.Reset()
+	m.buf.Write(b)
 	if err != nil {
 		return err
 	}
@@ -224,96 +642,143 @@ func (m *msg) parseProtoVersion(r *bufio.Reader, lm *parsesyslog.LogMsg) error {
 	if err != nil {
 		return parsesyslog.ErrInvalidProtoVersion
 	}
+	if m.opts.Strict {
+		if err = strictProtoVersion(b); err != nil {
+			return err
+		}
+	}
 	lm.ProtoVersion = parsesyslog.ProtoVersion(pv)
 	return nil
 }
 
+// localTimeFormat is the format of timestamps without a time zone offset, that are
+// accepted in lenient mode and treated as UTC
+const localTimeFormat = "2006-01-02T15:04:05.999999999"
+
 // parseTimestamp will try to parse the timestamp (or NILVALUE) part of the
 // RFC54524 header
 // See: https://datatracker.ietf.org/doc/html/rfc5424#section-6.2.3
 func (m *msg) parseTimestamp(r *bufio.Reader, lm *parsesyslog.LogMsg) error {
-	_, err := parsesyslog.ReadBytesUntilSpaceOrNilValue(r, &m.buf)
+	n, err := parsesyslog.ReadBytesUntilSpaceOrNilValue(r, &m.buf)
+	m.hlen += n
 	if err != nil {
 		return err
 	}
-	if m.buf.Len() == 0 {
+	if m.opts.Strict {
+		if err = strictTimestamp(m.buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	if m.buf.Len() == 0 || !m.opts.Wants(parsesyslog.FieldTimestamp) {
 		return nil
 	}
 	if m.buf.Bytes()[0] == '-' {
 		return nil
 	}
 	ts, err := time.Parse(time.RFC3339, m.buf.String())
+	if err != nil && m.opts.Lenient {
+		ts, err = time.Parse(localTimeFormat, m.buf.String())
+		lm.Relaxed |= parsesyslog.RelaxedTimestamp
+	}
 	if err != nil {
 		return parsesyslog.ErrInvalidTimestamp
 	}
 	lm.Timestamp = ts
+	if lm.Wire != nil {
+		lm.Wire.Timestamp = m.buf.String()
+	}
 	return nil
 }
 
 // parseHostname will try to read the hostname part of the RFC54524 header
 // See: https://datatracker.ietf.org/doc/html/rfc5424#section-6.2.4
 func (m *msg) parseHostname(r *bufio.Reader, lm *parsesyslog.LogMsg) error {
-	_, err := parsesyslog.ReadBytesUntilSpaceOrNilValue(r, &m.buf)
+	n, err := parsesyslog.ReadBytesUntilSpaceOrNilValue(r, &m.buf)
+	m.hlen += n
 	if err != nil {
 		return err
 	}
-	if m.buf.Len() == 0 {
+	if m.buf.Len() == 0 || !m.opts.Wants(parsesyslog.FieldHostname) {
 		return nil
 	}
 	if m.buf.Bytes()[0] == '-' {
 		return nil
 	}
-	lm.Hostname = m.buf.String()
+	lm.Hostname = m.name(r, m.lastHostname, m.buf.Bytes())
+	if m.src == nil {
+		m.lastHostname = lm.Hostname
+	}
+	if m.opts.Resolver != nil {
+		lm.ResolvedHost = m.opts.Resolver.Resolve(lm.Hostname)
+	}
 	return nil
 }
 
 // parseAppName will try to read the app name part of the RFC54524 header
 // See: https://datatracker.ietf.org/doc/html/rfc5424#section-6.2.5
 func (m *msg) parseAppName(r *bufio.Reader, lm *parsesyslog.LogMsg) error {
-	_, err := parsesyslog.ReadBytesUntilSpaceOrNilValue(r, &m.buf)
+	n, err := parsesyslog.ReadBytesUntilSpaceOrNilValue(r, &m.buf)
+	m.hlen += n
 	if err != nil {
 		return err
 	}
-	if m.buf.Len() == 0 {
+	if m.buf.Len() == 0 || !m.opts.Wants(parsesyslog.FieldAppName) {
 		return nil
 	}
 	if m.buf.Bytes()[0] == '-' {
 		return nil
 	}
-	lm.AppName = m.buf.String()
+	lm.AppName = m.name(r, m.lastAppName, m.buf.Bytes())
+	if m.src == nil {
+		m.lastAppName = lm.AppName
+	}
 	return nil
 }
 
 // parseProcID will try to read the process ID part of the RFC54524 header
 // See: https://datatracker.ietf.org/doc/html/rfc5424#section-6.2.6
 func (m *msg) parseProcID(r *bufio.Reader, lm *parsesyslog.LogMsg) error {
-	_, err := parsesyslog.ReadBytesUntilSpaceOrNilValue(r, &m.buf)
+	n, err := parsesyslog.ReadBytesUntilSpaceOrNilValue(r, &m.buf)
+	m.hlen += n
 	if err != nil {
 		return err
 	}
-	if m.buf.Len() == 0 {
+	if m.buf.Len() == 0 || !m.opts.Wants(parsesyslog.FieldProcID) {
 		return nil
 	}
 	if m.buf.Bytes()[0] == '-' {
 		return nil
 	}
-	lm.ProcID = m.buf.String()
+	lm.ProcID = m.str(r, m.lastProcID, m.buf.Bytes())
+	if m.src == nil {
+		m.lastProcID = lm.ProcID
+	}
 	return nil
 }
 
 // parseMsgID will try to read the message ID part of the RFC54524 header
 // See: https://datatracker.ietf.org/doc/html/rfc5424#section-6.2.7
 func (m *msg) parseMsgID(r *bufio.Reader, lm *parsesyslog.LogMsg) error {
-	_, err := parsesyslog.ReadBytesUntilSpaceOrNilValue(r, &m.buf)
+	if m.opts.Lenient {
+		if p, err := r.Peek(1); err == nil && p[0] == '[' {
+			lm.Relaxed |= parsesyslog.RelaxedMsgID
+			return nil
+		}
+	}
+	n, err := parsesyslog.ReadBytesUntilSpaceOrNilValue(r, &m.buf)
+	m.hlen += n
 	if err != nil {
 		return err
 	}
-	if m.buf.Len() == 0 {
+	if m.buf.Len() == 0 || !m.opts.Wants(parsesyslog.FieldMsgID) {
 		return nil
 	}
 	if m.buf.Bytes()[0] == '-' {
 		return nil
 	}
-	lm.MsgID = m.buf.String()
+	lm.MsgID = m.str(r, m.lastMsgID, m.buf.Bytes())
+	if m.src == nil {
+		m.lastMsgID = lm.MsgID
+	}
 	return nil
 }
@@ -6,9 +6,12 @@ package rfc5424
 
 import (
 	"bufio"
+	"errors"
+	"fmt"
 	"io"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/wneessen/go-parsesyslog"
 )
@@ -406,3 +409,1106 @@ func BenchmarkParseStringRFC5424(b *testing.B) {
 	}
 	_ = lm
 }
+
+// TestRFC5424Msg_parseStructuredDataOrder tests that the parseStructuredData method of the
+// msg parser keeps the structured data in wire order
+func TestRFC5424Msg_parseStructuredDataOrder(t *testing.T) {
+	sr := strings.NewReader(`[z@1234 c="1" a="2" b="3"][a@1234 z="1"][m@1234 y="1" x="2"] `)
+	br := bufio.NewReader(sr)
+	m := &msg{}
+	lm := &parsesyslog.LogMsg{}
+	if err := m.parseStructuredData(br, lm); err != nil {
+		t.Fatalf("parseStructuredData() failed: %s", err)
+	}
+	wantIDs := []string{"z@1234", "a@1234", "m@1234"}
+	wantParams := [][]string{{"c", "a", "b"}, {"z"}, {"y", "x"}}
+	for i, id := range wantIDs {
+		e, ok := lm.SDAt(i)
+		if !ok || e.ID != id {
+			t.Errorf("parseStructuredData() element %d => expected: %s, got: %s", i, id, e.ID)
+			continue
+		}
+		for pi, pn := range wantParams[i] {
+			if p, ok := e.ParamAt(pi); !ok || p.Name != pn {
+				t.Errorf("parseStructuredData() param %d of %s => expected: %s, got: %s", pi, id, pn,
+					p.Name)
+			}
+		}
+	}
+}
+
+// TestRFC5424Msg_parseStructuredDataEmpty tests the parseStructuredData method of the msg
+// parser with param-less and empty structured data elements
+func TestRFC5424Msg_parseStructuredDataEmpty(t *testing.T) {
+	tests := []struct {
+		name    string
+		msg     string
+		opts    []parsesyslog.Option
+		wantIDs []string
+		wantErr bool
+	}{
+		{"param-less element", `[exampleSDID@32473] `, nil, []string{"exampleSDID@32473"}, false},
+		{
+			"param-less and regular element", `[exampleSDID@32473][foo@1234 a="b"] `, nil,
+			[]string{"exampleSDID@32473", "foo@1234"}, false,
+		},
+		{"empty element", `[] `, nil, nil, true},
+		{"empty element skipped", `[] `, []parsesyslog.Option{parsesyslog.WithSkipEmptySD()}, nil, false},
+		{
+			"empty element between skipped", `[foo@1234 a="b"][][bar@1234] `,
+			[]parsesyslog.Option{parsesyslog.WithSkipEmptySD()}, []string{"foo@1234", "bar@1234"}, false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			br := bufio.NewReader(strings.NewReader(tt.msg))
+			m := &msg{opts: parsesyslog.NewOptions(tt.opts...)}
+			lm := &parsesyslog.LogMsg{}
+			if err := m.parseStructuredData(br, lm); (err != nil) != tt.wantErr {
+				t.Errorf("parseStructuredData() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(lm.StructuredData) != len(tt.wantIDs) {
+				t.Fatalf("parseStructuredData() element count => expected: %d, got: %d", len(tt.wantIDs),
+					len(lm.StructuredData))
+			}
+			for i, id := range tt.wantIDs {
+				if lm.StructuredData[i].ID != id {
+					t.Errorf("parseStructuredData() element ID => expected: %s, got: %s", id,
+						lm.StructuredData[i].ID)
+				}
+			}
+		})
+	}
+}
+
+// TestParseStringRFC5424_withFields tests the ParseString method with the WithFields option
+func TestParseStringRFC5424_withFields(t *testing.T) {
+	tests := []struct {
+		name     string
+		msg      string
+		fields   parsesyslog.Field
+		wantHost string
+		wantSD   int
+		wantMsg  string
+	}{
+		{
+			"priority and message", `107 <7>1 2016-02-28T09:57:10.804642398-05:00 myhostname someapp - - [foo@1234 Revision="1.2.3.4"] Hello, World!`,
+			parsesyslog.FieldPriority | parsesyslog.FieldMessage, "", 0, "Hello, World!",
+		},
+		{
+			"escaped SD skipped", `115 <7>1 2016-02-28T09:57:10.804642398-05:00 myhostname someapp - - [foo@1234 a="x\"] y" b="z"][bar@1234] Hello, World!`,
+			parsesyslog.FieldHostname | parsesyslog.FieldMessage, "myhostname", 0, "Hello, World!",
+		},
+		{
+			"without message", `107 <7>1 2016-02-28T09:57:10.804642398-05:00 myhostname someapp - - [foo@1234 Revision="1.2.3.4"] Hello, World!`,
+			parsesyslog.FieldHostname | parsesyslog.FieldStructuredData, "myhostname", 1, "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := parsesyslog.New(Type, parsesyslog.WithFields(tt.fields))
+			if err != nil {
+				t.Fatalf("failed to create new RFC5424 parser: %s", err)
+			}
+			l, err := p.ParseString(tt.msg)
+			if err != nil {
+				t.Fatalf("failed to parse message: %s", err)
+			}
+			if l.Priority != 7 {
+				t.Errorf("ParseString() wrong priority => expected: %d, got: %d", 7, l.Priority)
+			}
+			if !l.Timestamp.IsZero() {
+				t.Errorf("ParseString() timestamp not expected, got: %s", l.Timestamp)
+			}
+			if l.AppName != "" {
+				t.Errorf("ParseString() app name not expected, got: %s", l.AppName)
+			}
+			if l.Hostname != tt.wantHost {
+				t.Errorf("ParseString() wrong hostname => expected: %s, got: %s", tt.wantHost, l.Hostname)
+			}
+			if len(l.StructuredData) != tt.wantSD {
+				t.Errorf("ParseString() wrong SD count => expected: %d, got: %d", tt.wantSD,
+					len(l.StructuredData))
+			}
+			if l.Message.String() != tt.wantMsg {
+				t.Errorf("ParseString() wrong message => expected: %s, got: %s", tt.wantMsg, l.Message.String())
+			}
+		})
+	}
+}
+
+// TestParseStringRFC5424_withoutLength tests the ParseString method with a message that is
+// not prefixed with its length
+func TestParseStringRFC5424_withoutLength(t *testing.T) {
+	p, err := parsesyslog.New(Type)
+	if err != nil {
+		t.Fatalf("failed to create new RFC5424 parser: %s", err)
+	}
+	l, err := p.ParseString(`<7>1 2016-02-28T09:57:10.804642398-05:00 myhostname someapp - - [foo@1234 Revision="1.2.3.4"] Hello, World!`)
+	if err != nil {
+		t.Fatalf("failed to parse message: %s", err)
+	}
+	if l.Hostname != "myhostname" {
+		t.Errorf("ParseString() wrong hostname => expected: %s, got: %s", "myhostname", l.Hostname)
+	}
+	if l.Message.String() != "Hello, World!" {
+		t.Errorf("ParseString() wrong message => expected: %s, got: %s", "Hello, World!", l.Message.String())
+	}
+}
+
+// TestRFC5424Msg_ParseReaderInto tests the ParseReaderInto method of the msg parser
+func TestRFC5424Msg_ParseReaderInto(t *testing.T) {
+	msgs := []string{
+		`107 <7>1 2016-02-28T09:57:10.804642398-05:00 myhostname someapp - - [foo@1234 Revision="1.2.3.4"] Hello, World!`,
+		`100 <7>1 2016-02-28T09:57:10.804642398-05:00 otherhost - - ID1 [bar@1234 a="b"][baz@1234] Second message`,
+		`72 <7>1 2016-02-28T09:57:10.804642398-05:00 otherhost - - - - Third message`,
+	}
+	m := &msg{}
+	var lm parsesyslog.LogMsg
+	br := bufio.NewReader(strings.NewReader(strings.Join(msgs, "")))
+	if err := m.ParseReaderInto(br, &lm); err != nil {
+		t.Fatalf("ParseReaderInto() failed: %s", err)
+	}
+	first := lm.Clone()
+	if err := m.ParseReaderInto(br, &lm); err != nil {
+		t.Fatalf("ParseReaderInto() failed: %s", err)
+	}
+	if lm.Hostname != "otherhost" || lm.AppName != "" || lm.MsgID != "ID1" {
+		t.Errorf("ParseReaderInto() wrong header fields: %s/%s/%s", lm.Hostname, lm.AppName, lm.MsgID)
+	}
+	if len(lm.StructuredData) != 2 || lm.StructuredData[0].ID != "bar@1234" ||
+		len(lm.StructuredData[0].Param) != 1 || len(lm.StructuredData[1].Param) != 0 {
+		t.Errorf("ParseReaderInto() wrong structured data: %+v", lm.StructuredData)
+	}
+	if lm.Message.String() != "Second message" {
+		t.Errorf("ParseReaderInto() wrong message => expected: %s, got: %s", "Second message",
+			lm.Message.String())
+	}
+	if first.Hostname != "myhostname" || first.StructuredData[0].ID != "foo@1234" ||
+		first.Message.String() != "Hello, World!" {
+		t.Errorf("Clone() of first message was modified: %+v", first)
+	}
+	if err := m.ParseReaderInto(br, &lm); err != nil {
+		t.Fatalf("ParseReaderInto() failed: %s", err)
+	}
+	if len(lm.StructuredData) != 0 || lm.MsgID != "" || lm.Message.String() != "Third message" {
+		t.Errorf("ParseReaderInto() third message not correctly parsed: %+v", lm)
+	}
+}
+
+// BenchmarkRFC5424Msg_ParseReaderInto benchmarks the ParseReaderInto method of the msg type
+func BenchmarkRFC5424Msg_ParseReaderInto(b *testing.B) {
+	b.ReportAllocs()
+	sr := strings.NewReader(`102 <7>1 2016-02-28T09:57:10.804642398Z myhostname someapp - - [foo@1234 Revision="1.2.3.4"] Hello, World!`)
+	br := bufio.NewReader(sr)
+	var lm parsesyslog.LogMsg
+	m := &msg{}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := m.ParseReaderInto(br, &lm); err != nil {
+			b.Errorf("failed to parse message: %s", err)
+			break
+		}
+		_, err := sr.Seek(0, io.SeekStart)
+		if err != nil {
+			b.Errorf("failed to seek back to start: %s", err)
+			break
+		}
+		br.Reset(sr)
+	}
+}
+
+// TestParseStreamRFC5424 tests the RFC5424 parser with a parsesyslog.StreamParser
+func TestParseStreamRFC5424(t *testing.T) {
+	p, err := parsesyslog.New(Type)
+	if err != nil {
+		t.Fatalf("failed to create new RFC5424 parser: %s", err)
+	}
+	in := "72 <7>1 2016-02-28T09:57:10.804642398-05:00 otherhost - - - - First message\n" +
+		"73 <7>1 2016-02-28T09:57:10.804642398-05:00 otherhost - - - - Second message\n"
+	sp := parsesyslog.NewStreamParser(p, strings.NewReader(in))
+	for _, want := range []string{"First message", "Second message"} {
+		lm, err := sp.Next()
+		if err != nil {
+			t.Fatalf("Next() failed: %s", err)
+		}
+		if lm.Message.String() != want {
+			t.Errorf("Next() wrong message => expected: %s, got: %s", want, lm.Message.String())
+		}
+	}
+	if _, err := sp.Next(); !errors.Is(err, io.EOF) {
+		t.Errorf("Next() at end of stream => expected: %s, got: %s", io.EOF, err)
+	}
+}
+
+// TestParseStringRFC5424_withHostResolver tests the RFC5424 parser with a HostResolver
+func TestParseStringRFC5424_withHostResolver(t *testing.T) {
+	r := parsesyslog.NewHostResolver(parsesyslog.WithResolverFailurePolicy(parsesyslog.FailureUseAddress))
+	defer r.Close()
+	p, err := parsesyslog.New(Type, parsesyslog.WithHostResolver(r))
+	if err != nil {
+		t.Fatalf("failed to create new RFC5424 parser: %s", err)
+	}
+	l, err := p.ParseString(`57 <165>1 2003-10-11T22:14:15.003Z mymachine - - - - message`)
+	if err != nil {
+		t.Fatalf("ParseString() failed: %s", err)
+	}
+	if l.ResolvedHost != "mymachine" {
+		t.Errorf("ParseString() wrong resolved host => expected: %s, got: %s", "mymachine", l.ResolvedHost)
+	}
+}
+
+// TestParseBytesRFC5424 tests that the ParseBytes method of the msg type returns the
+// same LogMsg as ParseString and does not reference the byte slice
+func TestParseBytesRFC5424(t *testing.T) {
+	p, err := parsesyslog.New(Type)
+	if err != nil {
+		t.Fatalf("failed to create new RFC5424 parser: %s", err)
+	}
+	bp, ok := p.(parsesyslog.BytesParser)
+	if !ok {
+		t.Fatalf("RFC5424 parser is expected to satisfy the BytesParser interface")
+	}
+	for _, msg := range []string{
+		`107 <7>1 2016-02-28T09:57:10.804642398-05:00 myhostname someapp - - [foo@1234 Revision="1.2.3.4"] Hello, World!`,
+		"<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog - ID47 - \xef\xbb\xbfAn application event",
+		`<34>1 2003-10-11T22:14:15.003Z mymachine.example.com su - ID47 - 'su root' failed for lonvick`,
+		`<34>1 yesterday mymachine.example.com su - ID47 - invalid timestamp`,
+		`<34>`,
+	} {
+		want, wantErr := p.ParseString(msg)
+		b := []byte(msg)
+		got, err := bp.ParseBytes(b)
+		if fmt.Sprint(err) != fmt.Sprint(wantErr) {
+			t.Errorf("ParseBytes(%q) => expected error: %v, got: %v", msg, wantErr, err)
+		}
+		for i := range b {
+			b[i] = 'x'
+		}
+		for _, d := range parsesyslog.Diff(want, got) {
+			t.Errorf("ParseBytes(%q) => %s", msg, d)
+		}
+	}
+}
+
+// BenchmarkParseBytesRFC5424 benchmarks the ParseBytes method of the msg type
+func BenchmarkParseBytesRFC5424(b *testing.B) {
+	b.ReportAllocs()
+	msg := []byte(`107 <7>1 2016-02-28T09:57:10.804642398-05:00 myhostname someapp - - [foo@1234 Revision="1.2.3.4"] Hello, World!`)
+	var lm parsesyslog.LogMsg
+	var err error
+
+	p, err := parsesyslog.New(Type)
+	if err != nil {
+		b.Errorf("failed to create new RFC5424 parser")
+		return
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		lm, err = parsesyslog.ParseBytes(p, msg)
+		if err != nil {
+			b.Errorf("failed to read bytes: %s", err)
+			break
+		}
+	}
+	_ = lm
+}
+
+// TestParseBytesRFC5424_withZeroCopy tests that in zero-copy mode, the ParseBytes method
+// of the msg type returns the same LogMsg as ParseString, but references the byte slice
+func TestParseBytesRFC5424_withZeroCopy(t *testing.T) {
+	p, err := parsesyslog.New(Type, parsesyslog.WithZeroCopy())
+	if err != nil {
+		t.Fatalf("failed to create new RFC5424 parser: %s", err)
+	}
+	bp, ok := p.(parsesyslog.BytesParser)
+	if !ok {
+		t.Fatalf("RFC5424 parser is expected to satisfy the BytesParser interface")
+	}
+	for _, msg := range []string{
+		`107 <7>1 2016-02-28T09:57:10.804642398-05:00 myhostname someapp - - [foo@1234 Revision="1.2.3.4"] Hello, World!`,
+		`<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog 1234 ID47 [exampleSDID@32473 iut="3" ` +
+			`eventSource="Application" eventID="1011"][examplePriority@32473 class="high"] An application event`,
+		"<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog - ID47 [x@32473] \xef\xbb\xbfAn event",
+		`<34>1 2003-10-11T22:14:15.003Z mymachine.example.com su - ID47 -`,
+		`<34>1 yesterday mymachine.example.com su - ID47 - invalid timestamp`,
+	} {
+		want, wantErr := p.ParseString(msg)
+		b := []byte(msg)
+		got, err := bp.ParseBytes(b)
+		if fmt.Sprint(err) != fmt.Sprint(wantErr) {
+			t.Errorf("ParseBytes(%q) => expected error: %v, got: %v", msg, wantErr, err)
+		}
+		for _, d := range parsesyslog.Diff(want, got) {
+			t.Errorf("ParseBytes(%q) => %s", msg, d)
+		}
+		if err != nil {
+			continue
+		}
+		for i := range b {
+			b[i] = 'x'
+		}
+		if strings.Trim(got.Hostname+got.AppName+got.MsgID+got.Message.String(), "x") != "" {
+			t.Errorf("ParseBytes(%q) => expected strings to reference the byte slice, got: %q %q %q %q", msg,
+				got.Hostname, got.AppName, got.MsgID, got.Message.String())
+		}
+		for _, e := range got.StructuredData {
+			for _, sp := range e.Param {
+				if strings.Trim(e.ID+sp.Name+sp.Value, "x") != "" {
+					t.Errorf("ParseBytes(%q) => expected structured data to reference the byte slice, got: %+v",
+						msg, e)
+				}
+			}
+		}
+	}
+}
+
+// TestParseBytesRFC5424_withZeroCopy_message tests that writes to the Message of a
+// zero-copy LogMsg do not overwrite the bytes that follow the message
+func TestParseBytesRFC5424_withZeroCopy_message(t *testing.T) {
+	p, err := parsesyslog.New(Type, parsesyslog.WithZeroCopy())
+	if err != nil {
+		t.Fatalf("failed to create new RFC5424 parser: %s", err)
+	}
+	b := []byte(`34 <34>1 - mymachine su - - - message<34>1`)
+	lm, err := parsesyslog.ParseBytes(p, b)
+	if err != nil {
+		t.Fatalf("ParseBytes() failed: %s", err)
+	}
+	if lm.Message.String() != "message" {
+		t.Errorf("ParseBytes() => expected message: %q, got: %q", "message", lm.Message.String())
+	}
+	lm.Message.WriteString("!!!")
+	if string(b[len(b)-5:]) != "<34>1" {
+		t.Errorf("ParseBytes() => writing to the message overwrote the byte slice: %q", b)
+	}
+}
+
+// TestParseBytesRFC5424_withZeroCopy_reuse tests that reusing a zero-copy LogMsg for
+// another log message does not overwrite the byte slice it has been parsed from
+func TestParseBytesRFC5424_withZeroCopy_reuse(t *testing.T) {
+	zp, err := parsesyslog.New(Type, parsesyslog.WithZeroCopy())
+	if err != nil {
+		t.Fatalf("failed to create new RFC5424 parser: %s", err)
+	}
+	p, err := parsesyslog.New(Type)
+	if err != nil {
+		t.Fatalf("failed to create new RFC5424 parser: %s", err)
+	}
+	msg := `<34>1 - mymachine su - - - a long message`
+	b := []byte(msg)
+	lm, err := parsesyslog.ParseBytes(zp, b)
+	if err != nil {
+		t.Fatalf("ParseBytes() failed: %s", err)
+	}
+	lm.Reset()
+	lm.Message.WriteString("written")
+	if string(b) != msg {
+		t.Errorf("Reset() => writing to the message overwrote the byte slice: %q", b)
+	}
+	lm, err = parsesyslog.ParseBytes(zp, b)
+	if err != nil {
+		t.Fatalf("ParseBytes() failed: %s", err)
+	}
+	for _, rp := range []parsesyslog.Parser{p, zp} {
+		err = rp.(parsesyslog.ReusingParser).ParseReaderInto(strings.NewReader(`<34>1 - host su - - - other`), &lm)
+		if err != nil {
+			t.Fatalf("ParseReaderInto() failed: %s", err)
+		}
+		if lm.Message.String() != "other" {
+			t.Errorf("ParseReaderInto() => expected message: %q, got: %q", "other", lm.Message.String())
+		}
+		if string(b) != msg {
+			t.Errorf("ParseReaderInto() => reusing the LogMsg overwrote the byte slice: %q", b)
+		}
+	}
+}
+
+// TestParseBytesRFC5424_withZeroCopy_clone tests that the clone of a zero-copy LogMsg
+// does not reference the byte slice it has been parsed from, even if parsing failed
+func TestParseBytesRFC5424_withZeroCopy_clone(t *testing.T) {
+	p, err := parsesyslog.New(Type, parsesyslog.WithZeroCopy())
+	if err != nil {
+		t.Fatalf("failed to create new RFC5424 parser: %s", err)
+	}
+	for _, msg := range []string{
+		`<165>1 2003-10-11T22:14:15.003Z host evntslog 1234 ID47 [exampleSDID@32473 iut="3"] event`,
+		`<165>1 2003-10-11T22:14:15.003Z host evntslog 1234 ID47 [exampleSDID@32473 iut="3"`,
+	} {
+		want, wantErr := p.ParseString(msg)
+		b := []byte(msg)
+		lm, err := parsesyslog.ParseBytes(p, b)
+		if fmt.Sprint(err) != fmt.Sprint(wantErr) {
+			t.Errorf("ParseBytes(%q) => expected error: %v, got: %v", msg, wantErr, err)
+		}
+		c := lm.Clone()
+		for i := range b {
+			b[i] = 'X'
+		}
+		for _, d := range parsesyslog.Diff(want, c) {
+			t.Errorf("Clone() of %q => %s", msg, d)
+		}
+	}
+}
+
+// TestParseAllLines_withZeroCopy tests that the results of parsesyslog.ParseAllLines do
+// not reference the buffer of the lines in zero-copy mode, which is overwritten as the
+// input exceeds its size
+func TestParseAllLines_withZeroCopy(t *testing.T) {
+	p, err := parsesyslog.New(Type, parsesyslog.WithZeroCopy())
+	if err != nil {
+		t.Fatalf("failed to create new RFC5424 parser: %s", err)
+	}
+	var sb strings.Builder
+	for i := 0; i < 200; i++ {
+		sb.WriteString(fmt.Sprintf("<34>1 2003-10-11T22:14:15.003Z host%03d su - ID%03d [x@1 n=\"%03d\"] message %03d\n",
+			i, i, i, i))
+	}
+	res, err := parsesyslog.ParseAllLines(p, strings.NewReader(sb.String()))
+	if err != nil {
+		t.Fatalf("ParseAllLines() failed: %s", err)
+	}
+	if len(res) != 200 {
+		t.Fatalf("ParseAllLines() => expected 200 results, got: %d", len(res))
+	}
+	for i, r := range res {
+		n := fmt.Sprintf("%03d", i)
+		if r.Err != nil {
+			t.Errorf("ParseAllLines() line %d failed: %s", r.Line, r.Err)
+			continue
+		}
+		if r.Msg.Hostname != "host"+n || r.Msg.MsgID != "ID"+n || r.Msg.Message.String() != "message "+n ||
+			len(r.Msg.StructuredData) != 1 || r.Msg.StructuredData[0].Param[0].Value != n {
+			t.Errorf("ParseAllLines() line %d => unexpected result: %s", r.Line, r.Msg.String())
+		}
+	}
+}
+
+// BenchmarkParseBytesRFC5424_withZeroCopy benchmarks the ParseBytes method of the msg
+// type in zero-copy mode
+func BenchmarkParseBytesRFC5424_withZeroCopy(b *testing.B) {
+	b.ReportAllocs()
+	msg := []byte(`107 <7>1 2016-02-28T09:57:10.804642398-05:00 myhostname someapp - - [foo@1234 Revision="1.2.3.4"] Hello, World!`)
+	var lm parsesyslog.LogMsg
+	var err error
+
+	p, err := parsesyslog.New(Type, parsesyslog.WithZeroCopy())
+	if err != nil {
+		b.Errorf("failed to create new RFC5424 parser")
+		return
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		lm, err = parsesyslog.ParseBytes(p, msg)
+		if err != nil {
+			b.Errorf("failed to read bytes: %s", err)
+			break
+		}
+	}
+	_ = lm
+}
+
+// TestParseStringRFC5424_withMaxHeaderSize tests that large structured data sections are
+// accepted by default and that the header size can be limited
+func TestParseStringRFC5424_withMaxHeaderSize(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString("<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog 1234 ID47 [large@32473")
+	for i := 0; i < 1000; i++ {
+		fmt.Fprintf(&sb, ` param%d="%s"`, i, strings.Repeat("x", 50))
+	}
+	sb.WriteString("] message")
+	msg := sb.String()
+	hdr := "<165>1 2003-10-11T22:14:15.003Z " + strings.Repeat("h", 200) + " evntslog 1234 ID47 - message"
+
+	tests := []struct {
+		name string
+		msg  string
+		opts []parsesyslog.Option
+		err  error
+	}{
+		{"large structured data", msg, nil, nil},
+		{"within limit", msg, []parsesyslog.Option{parsesyslog.WithMaxHeaderSize(len(msg))}, nil},
+		{"structured data exceeds limit", msg, []parsesyslog.Option{parsesyslog.WithMaxHeaderSize(4096)},
+			parsesyslog.ErrHeaderTooLarge},
+		{
+			"skipped structured data exceeds limit", msg, []parsesyslog.Option{
+				parsesyslog.WithMaxHeaderSize(4096), parsesyslog.WithFields(parsesyslog.FieldMessage),
+			}, parsesyslog.ErrHeaderTooLarge,
+		},
+		{"header exceeds limit", hdr, []parsesyslog.Option{parsesyslog.WithMaxHeaderSize(128)},
+			parsesyslog.ErrHeaderTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := parsesyslog.New(Type, tt.opts...)
+			if err != nil {
+				t.Fatalf("failed to create new RFC5424 parser: %s", err)
+			}
+			l, err := p.ParseString(tt.msg)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ParseString() => expected error: %v, got: %v", tt.err, err)
+			}
+			if err != nil {
+				return
+			}
+			if l.Message.String() != "message" {
+				t.Errorf("ParseString() => expected message: %q, got: %q", "message", l.Message.String())
+			}
+			if len(l.StructuredData) == 1 && len(l.StructuredData[0].Param) != 1000 {
+				t.Errorf("ParseString() => expected 1000 params, got: %d", len(l.StructuredData[0].Param))
+			}
+		})
+	}
+}
+
+// TestParseStringRFC5424_withLenient tests that the deviations of lenient mode are
+// accepted and recorded, while the strict parser rejects them
+func TestParseStringRFC5424_withLenient(t *testing.T) {
+	ts := time.Date(2003, 10, 11, 22, 14, 15, 3000000, time.UTC)
+	tests := []struct {
+		name    string
+		msg     string
+		relaxed parsesyslog.Relaxation
+		msgid   string
+		strict  bool
+	}{
+		{"strict message", "<165>1 2003-10-11T22:14:15.003Z host app 1234 ID47 [a@1 b=\"c\"] test", 0, "ID47", true},
+		{
+			"leading zeros in PRI", "<0165>1 2003-10-11T22:14:15.003Z host app 1234 ID47 [a@1 b=\"c\"] test",
+			parsesyslog.RelaxedPriority, "ID47", true,
+		},
+		{
+			"timestamp without time zone", "<165>1 2003-10-11T22:14:15.003 host app 1234 ID47 [a@1 b=\"c\"] test",
+			parsesyslog.RelaxedTimestamp, "ID47", false,
+		},
+		{
+			"missing MSGID", "<165>1 2003-10-11T22:14:15.003Z host app 1234 [a@1 b=\"c\"] test",
+			parsesyslog.RelaxedMsgID, "", false,
+		},
+	}
+	lp, err := parsesyslog.New(Type, parsesyslog.WithLenient())
+	if err != nil {
+		t.Fatalf("failed to create new RFC5424 parser: %s", err)
+	}
+	sp, err := parsesyslog.New(Type)
+	if err != nil {
+		t.Fatalf("failed to create new RFC5424 parser: %s", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := lp.ParseString(tt.msg)
+			if err != nil {
+				t.Fatalf("ParseString() failed: %s", err)
+			}
+			if l.Relaxed != tt.relaxed {
+				t.Errorf("ParseString() relaxed => expected: %q, got: %q", tt.relaxed, l.Relaxed)
+			}
+			if !l.Timestamp.Equal(ts) {
+				t.Errorf("ParseString() timestamp => expected: %s, got: %s", ts, l.Timestamp)
+			}
+			if l.MsgID != tt.msgid {
+				t.Errorf("ParseString() msgid => expected: %q, got: %q", tt.msgid, l.MsgID)
+			}
+			if len(l.StructuredData) != 1 || l.StructuredData[0].ID != "a@1" {
+				t.Errorf("ParseString() => expected structured data a@1, got: %v", l.StructuredData)
+			}
+			if l.Message.String() != "test" {
+				t.Errorf("ParseString() message => expected: %q, got: %q", "test", l.Message.String())
+			}
+
+			sl, err := sp.ParseString(tt.msg)
+			ok := err == nil && sl.MsgID == tt.msgid && len(sl.StructuredData) == 1
+			if ok != tt.strict {
+				t.Errorf("ParseString() in strict mode => expected strict match: %t, got: %t (%v)", tt.strict, ok, err)
+			}
+		})
+	}
+}
+
+// TestParseReaderRFC5424_withStreamingBody tests that the message body is exposed as
+// io.Reader that ends with the message
+func TestParseReaderRFC5424_withStreamingBody(t *testing.T) {
+	p, err := parsesyslog.New(Type, parsesyslog.WithStreamingBody())
+	if err != nil {
+		t.Fatalf("failed to create new RFC5424 parser: %s", err)
+	}
+	body := strings.Repeat("x", 1<<20)
+	first := "<165>1 2003-10-11T22:14:15.003Z mymachine evntslog - - [x@32473 a=\"1\"] \xef\xbb\xbf" + body
+	second := "<34>1 2003-10-11T22:14:15.003Z mymachine su - - - hi"
+	br := bufio.NewReader(strings.NewReader(fmt.Sprintf("%d %s%d %s", len(first), first, len(second), second)))
+
+	l, err := p.ParseReader(br)
+	if err != nil {
+		t.Fatalf("ParseReader() failed: %s", err)
+	}
+	if l.Message.Len() != 0 || l.Body == nil {
+		t.Fatalf("ParseReader() => expected streamed body, got message of %d bytes", l.Message.Len())
+	}
+	if !l.HasBOM || l.MsgLength != len(body)+3 || len(l.StructuredData) != 1 {
+		t.Errorf("ParseReader() => unexpected LogMsg: BOM: %t, length: %d, SD: %+v", l.HasBOM, l.MsgLength,
+			l.StructuredData)
+	}
+	b, err := io.ReadAll(l.Body)
+	if err != nil {
+		t.Fatalf("failed to read body: %s", err)
+	}
+	if string(b) != "\xef\xbb\xbf"+body {
+		t.Errorf("ParseReader() => expected body of %d bytes, got: %d bytes", len(body)+3, len(b))
+	}
+
+	l, err = p.ParseReader(br)
+	if err != nil {
+		t.Fatalf("ParseReader() failed: %s", err)
+	}
+	if b, err = io.ReadAll(l.Body); err != nil || string(b) != "hi" {
+		t.Errorf("ParseReader() => expected body: %q, got: %q (%v)", "hi", b, err)
+	}
+}
+
+// TestParseStringRFC5424_withInterner tests that the hostnames and app names of log
+// messages are shared between parsers with the same Interner
+func TestParseStringRFC5424_withInterner(t *testing.T) {
+	in := parsesyslog.NewInterner(0)
+	var msgs []parsesyslog.LogMsg
+	for _, msg := range []string{
+		"<165>1 2003-10-11T22:14:15.003Z host1 app 1234 ID47 - test",
+		"<165>1 2003-10-11T22:14:15.003Z host2 app 1234 ID47 - test",
+		"<165>1 2003-10-11T22:14:15.003Z host1 app 1234 ID47 - test",
+	} {
+		p, err := parsesyslog.New(Type, parsesyslog.WithInterner(in))
+		if err != nil {
+			t.Fatalf("failed to create new RFC5424 parser: %s", err)
+		}
+		l, err := p.ParseString(msg)
+		if err != nil {
+			t.Fatalf("ParseString() failed: %s", err)
+		}
+		msgs = append(msgs, l)
+	}
+	if msgs[0].Hostname != "host1" || msgs[1].Hostname != "host2" {
+		t.Errorf("ParseString() => unexpected hostnames: %q, %q", msgs[0].Hostname, msgs[1].Hostname)
+	}
+	if in.Len() != 3 {
+		t.Errorf("Interner.Len() => expected: %d, got: %d", 3, in.Len())
+	}
+	if in.Intern([]byte("host1")) != msgs[2].Hostname {
+		t.Errorf("ParseString() => expected interned hostname")
+	}
+}
+
+// TestSelfTest tests that the RFC5424 parser passes the self-test and that the self-test
+// detects options that reject valid messages
+func TestSelfTest(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []parsesyslog.Option
+		failures int
+	}{
+		{"default", nil, 0},
+		{"lenient", []parsesyslog.Option{parsesyslog.WithLenient()}, 0},
+		{"header size", []parsesyslog.Option{parsesyslog.WithMaxHeaderSize(100)}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := parsesyslog.New(Type, tt.opts...)
+			if err != nil {
+				t.Fatalf("failed to create new RFC5424 parser: %s", err)
+			}
+			if f := parsesyslog.SelfTest(p); len(f) != tt.failures {
+				t.Errorf("SelfTest() => expected %d failures, got: %v", tt.failures, f)
+			}
+		})
+	}
+}
+
+// TestParseReaderRFC5424_withMaxMessageSize tests that log messages that exceed the
+// maximum message size are truncated and returned with ErrMessageTooLarge, and that the
+// next message of the stream can be parsed
+func TestParseReaderRFC5424_withMaxMessageSize(t *testing.T) {
+	hdr := "<165>1 2003-10-11T22:14:15.003Z host app - - - "
+	long := strings.Repeat("x", 100000)
+	short := hdr + "second"
+	tests := []struct {
+		name      string
+		msg       string
+		max       int
+		want      string
+		truncated bool
+		err       error
+	}{
+		{"no limit", fmt.Sprintf("%d %s%s", len(hdr)+len(long), hdr, long), 0, long, false, nil},
+		{"within limit", fmt.Sprintf("%d %s%s", len(hdr)+10, hdr, long[:10]), 1024, long[:10], false, nil},
+		{
+			"exactly at limit", fmt.Sprintf("%d %s%s", len(hdr)+10, hdr, long[:10]), len(hdr) + 10, long[:10],
+			false, nil,
+		},
+		{
+			"exceeds limit", fmt.Sprintf("%d %s%s", len(hdr)+len(long), hdr, long), 1024, long[:1024-len(hdr)],
+			true, parsesyslog.ErrMessageTooLarge,
+		},
+		{
+			"huge octet count", fmt.Sprintf("%d %s%s", 1<<40, hdr, long), 1024, long[:1024-len(hdr)], true,
+			parsesyslog.ErrMessageTooLarge,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := parsesyslog.New(Type, parsesyslog.WithMaxMessageSize(tt.max))
+			if err != nil {
+				t.Fatalf("failed to create new RFC5424 parser: %s", err)
+			}
+			br := bufio.NewReader(strings.NewReader(tt.msg + fmt.Sprintf("%d %s", len(short), short)))
+			l, err := p.ParseReader(br)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ParseReader() => expected error: %v, got: %v", tt.err, err)
+			}
+			if l.Message.String() != tt.want {
+				t.Errorf("ParseReader() => expected message of %d bytes, got: %d", len(tt.want), l.Message.Len())
+			}
+			if l.Truncated != tt.truncated {
+				t.Errorf("ParseReader() truncated => expected: %t, got: %t", tt.truncated, l.Truncated)
+			}
+			if tt.name == "huge octet count" {
+				return
+			}
+			l, err = p.ParseReader(br)
+			if err != nil {
+				t.Fatalf("ParseReader() of next message failed: %s", err)
+			}
+			if l.Message.String() != "second" {
+				t.Errorf("ParseReader() => expected message: %q, got: %q", "second", l.Message.String())
+			}
+		})
+	}
+}
+
+// TestParseStringRFC5424_parseError tests that parse errors carry the field, offset and
+// input they occurred in
+func TestParseStringRFC5424_parseError(t *testing.T) {
+	tests := []struct {
+		name    string
+		msg     string
+		err     error
+		field   string
+		offset  int
+		snippet string
+	}{
+		{"invalid PRI", "<x4>1 - - - - - - test", parsesyslog.ErrInvalidPrio, "PRI", 0, "x4"},
+		{"invalid version", "<34>x - - - - - - test", parsesyslog.ErrInvalidProtoVersion, "VERSION", 4, "x"},
+		{
+			"invalid timestamp", "<34>1 yesterday host app - - - test", parsesyslog.ErrInvalidTimestamp,
+			"TIMESTAMP", 6, "yesterday",
+		},
+		{
+			"premature end", "<34>1 2003-10-11T22:14:15.003Z host app", parsesyslog.ErrPrematureEOF, "APP-NAME",
+			36, "app",
+		},
+		{
+			"invalid structured data", "<34>1 2003-10-11T22:14:15.003Z host app - - x test",
+			parsesyslog.ErrWrongSDFormat, "STRUCTURED-DATA", 44, "",
+		},
+	}
+	p, err := parsesyslog.New(Type)
+	if err != nil {
+		t.Fatalf("failed to create new RFC5424 parser: %s", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := p.ParseString(tt.msg)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ParseString() => expected error: %v, got: %v", tt.err, err)
+			}
+			var pe *parsesyslog.ParseError
+			if !errors.As(err, &pe) {
+				t.Fatalf("ParseString() => expected ParseError, got: %T", err)
+			}
+			if pe.Field != tt.field || pe.Offset != tt.offset || pe.Snippet != tt.snippet {
+				t.Errorf("ParseString() => expected %s at %d near %q, got: %s at %d near %q", tt.field,
+					tt.offset, tt.snippet, pe.Field, pe.Offset, pe.Snippet)
+			}
+		})
+	}
+}
+
+// TestParseStringRFC5424_partial tests that the fields preceding a parse error are returned
+// together with the error
+func TestParseStringRFC5424_partial(t *testing.T) {
+	ts := time.Date(2003, 10, 11, 22, 14, 15, 3000000, time.UTC)
+	tests := []struct {
+		name string
+		msg  string
+		opts []parsesyslog.Option
+		want parsesyslog.LogMsg
+	}{
+		{
+			"invalid timestamp", "<165>1 yesterday host app - - - test", nil,
+			parsesyslog.LogMsg{Priority: 165, Facility: 20, Severity: 5, ProtoVersion: 1},
+		},
+		{
+			"invalid structured data", `<165>1 2003-10-11T22:14:15.003Z host app 42 ID1 [id@1 a="b"][id@2 c="d" test`, nil,
+			parsesyslog.LogMsg{
+				Priority: 165, Facility: 20, Severity: 5, ProtoVersion: 1, Timestamp: ts, Hostname: "host",
+				AppName: "app", ProcID: "42", MsgID: "ID1",
+			},
+		},
+		{
+			"strict hostname", "<165>1 2003-10-11T22:14:15.003Z " + strings.Repeat("h", 256) + " app - - - test",
+			[]parsesyslog.Option{parsesyslog.WithStrict()},
+			parsesyslog.LogMsg{Priority: 165, Facility: 20, Severity: 5, ProtoVersion: 1, Timestamp: ts},
+		},
+		{
+			"strict priority", "<0165>1 2003-10-11T22:14:15.003Z host app - - - test",
+			[]parsesyslog.Option{parsesyslog.WithStrict()}, parsesyslog.LogMsg{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := parsesyslog.New(Type, tt.opts...)
+			if err != nil {
+				t.Fatalf("failed to create new RFC5424 parser: %s", err)
+			}
+			lm, err := p.ParseString(tt.msg)
+			if err == nil {
+				t.Fatal("ParseString() => expected error, got nil")
+			}
+			tt.want.Type = parsesyslog.RFC5424
+			if d := parsesyslog.Diff(lm, tt.want); len(d) > 0 {
+				t.Errorf("ParseString() => unexpected partial LogMsg: %v", d)
+			}
+		})
+	}
+}
+
+// TestParseStringRFC5424_withOctetCountPolicy tests the treatment of bytes that exceed the
+// octet count of a log message
+func TestParseStringRFC5424_withOctetCountPolicy(t *testing.T) {
+	hdr := "<165>1 2003-10-11T22:14:15.003Z host app - - - "
+	exact := fmt.Sprintf("%d %sfoo", len(hdr)+3, hdr)
+	tests := []struct {
+		name   string
+		policy parsesyslog.OctetCountPolicy
+		msg    string
+		want   string
+		extra  int
+		err    error
+	}{
+		{"next frame", parsesyslog.OctetCountNextFrame, exact + "bar", "foo", 0, nil},
+		{"accept", parsesyslog.OctetCountAccept, exact + "bar", "foobar", 3, nil},
+		{"accept exact", parsesyslog.OctetCountAccept, exact, "foo", 0, nil},
+		{"reject", parsesyslog.OctetCountReject, exact + "bar", "", 0, parsesyslog.ErrInvalidFrameLength},
+		{"reject exact", parsesyslog.OctetCountReject, exact, "foo", 0, nil},
+		{"reject without octet count", parsesyslog.OctetCountReject, hdr + "foobar", "foobar", 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := parsesyslog.New(Type, parsesyslog.WithOctetCountPolicy(tt.policy))
+			if err != nil {
+				t.Fatalf("failed to create new RFC5424 parser: %s", err)
+			}
+			l, err := p.ParseString(tt.msg)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ParseString() => expected error: %v, got: %v", tt.err, err)
+			}
+			var pe *parsesyslog.ParseError
+			if tt.err != nil && (!errors.As(err, &pe) || pe.Field != "MSG" || pe.Offset != len(hdr)+3) {
+				t.Errorf("ParseString() => expected ParseError in MSG at offset %d, got: %v", len(hdr)+3, err)
+			}
+			if l.Message.String() != tt.want {
+				t.Errorf("ParseString() => expected message: %q, got: %q", tt.want, l.Message.String())
+			}
+			if l.MsgLength != len(tt.want) {
+				t.Errorf("ParseString() => expected message length: %d, got: %d", len(tt.want), l.MsgLength)
+			}
+			if l.ExtraBytes != tt.extra {
+				t.Errorf("ParseString() => expected extra bytes: %d, got: %d", tt.extra, l.ExtraBytes)
+			}
+		})
+	}
+}
+
+// TestParseStringRFC5424_withTrace tests that the parser hands the ParseTrace of every log
+// message to the TraceFunc
+func TestParseStringRFC5424_withTrace(t *testing.T) {
+	tests := []struct {
+		name    string
+		msg     string
+		wantMsg bool
+		err     bool
+	}{
+		{"valid message", `<165>1 2003-10-11T22:14:15.003Z host app - ID47 [id@1 a="b"] test`, true, false},
+		{"invalid header", `<165>1 2003-10-11T22:14:15.003Z`, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var traces []parsesyslog.ParseTrace
+			var traceErr error
+			fn := func(_ *parsesyslog.LogMsg, pt parsesyslog.ParseTrace, err error) {
+				traces = append(traces, pt)
+				traceErr = err
+			}
+			p, err := parsesyslog.New(Type, parsesyslog.WithTrace(fn))
+			if err != nil {
+				t.Fatalf("failed to create new RFC5424 parser: %s", err)
+			}
+			_, err = p.ParseString(tt.msg)
+			if (err != nil) != tt.err {
+				t.Fatalf("ParseString() => unexpected error: %v", err)
+			}
+			if len(traces) != 1 {
+				t.Fatalf("ParseString() => expected 1 trace, got: %d", len(traces))
+			}
+			if traceErr != err {
+				t.Errorf("ParseString() => expected trace error: %v, got: %v", err, traceErr)
+			}
+			if tt.wantMsg != (traces[0].Message > 0) {
+				t.Errorf("ParseString() => unexpected message phase: %+v", traces[0])
+			}
+			if traces[0].Header <= 0 {
+				t.Errorf("ParseString() => expected header phase, got: %+v", traces[0])
+			}
+		})
+	}
+}
+
+// TestParseBytesRFC5424_withUnescapeSD tests the unescaping of structured data param
+// values
+func TestParseBytesRFC5424_withUnescapeSD(t *testing.T) {
+	msg := `<165>1 2003-10-11T22:14:15.003Z host app - - [id@1 a="say \"hi\"" b="C:\\temp" c="[x\]" d="\n"] test`
+	tests := []struct {
+		name string
+		opts []parsesyslog.Option
+		want []string
+	}{
+		{"escaped", nil, []string{`say \"hi\"`, `C:\\temp`, `[x\]`, `\n`}},
+		{"escaped strict", []parsesyslog.Option{parsesyslog.WithStrict()}, []string{`say \"hi\"`, `C:\\temp`, `[x\]`, `\n`}},
+		{"unescaped", []parsesyslog.Option{parsesyslog.WithUnescapeSD()}, []string{`say "hi"`, `C:\temp`, `[x]`, `\n`}},
+		{
+			"unescaped strict", []parsesyslog.Option{parsesyslog.WithUnescapeSD(), parsesyslog.WithStrict()},
+			[]string{`say "hi"`, `C:\temp`, `[x]`, `\n`},
+		},
+		{
+			"unescaped zero-copy", []parsesyslog.Option{parsesyslog.WithUnescapeSD(), parsesyslog.WithZeroCopy()},
+			[]string{`say "hi"`, `C:\temp`, `[x]`, `\n`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append([]parsesyslog.Option{parsesyslog.WithRoundTrip()}, tt.opts...)
+			p, err := parsesyslog.New(Type, opts...)
+			if err != nil {
+				t.Fatalf("failed to create new RFC5424 parser: %s", err)
+			}
+			l, err := p.(parsesyslog.BytesParser).ParseBytes([]byte(msg))
+			if err != nil {
+				t.Fatalf("ParseBytes() failed: %s", err)
+			}
+			if len(l.StructuredData) != 1 || len(l.StructuredData[0].Param) != len(tt.want) {
+				t.Fatalf("ParseBytes() => unexpected structured data: %+v", l.StructuredData)
+			}
+			for i, w := range tt.want {
+				if v := l.StructuredData[0].Param[i].Value; v != w {
+					t.Errorf("ParseBytes() => expected value %d: %s, got: %s", i, w, v)
+				}
+			}
+			if l.Message.String() != "test" {
+				t.Errorf("ParseBytes() => expected message: test, got: %s", l.Message.String())
+			}
+			wantWire := msg[strings.Index(msg, "[") : strings.LastIndex(msg, "]")+1]
+			if l.Wire.StructuredData != wantWire {
+				t.Errorf("ParseBytes() => expected wire structured data: %s, got: %s", wantWire,
+					l.Wire.StructuredData)
+			}
+		})
+	}
+}
+
+// TestParseStringRFC5424_sdName tests the validation of SD-IDs and PARAM-NAMEs
+func TestParseStringRFC5424_sdName(t *testing.T) {
+	hdr := "<165>1 2003-10-11T22:14:15.003Z host app - - "
+	tests := []struct {
+		name   string
+		sd     string
+		errMsg string
+	}{
+		{"valid", `[id@1 a="1"][id@2]`, ""},
+		{"SD-ID too long", "[" + strings.Repeat("x", 33) + ` a="1"]`, "exceeds 32 characters"},
+		{"SD-ID without params too long", "[" + strings.Repeat("x", 33) + "]", "exceeds 32 characters"},
+		{"SD-ID with non-printable", "[id\x01 a=\"1\"]", "contains invalid character 0x01"},
+		{"SD-ID with '='", `[id=1 a="1"]`, "contains invalid character 0x3d"},
+		{"PARAM-NAME too long", `[id@1 ` + strings.Repeat("a", 33) + `="1"]`, "exceeds 32 characters"},
+		{"empty PARAM-NAME", `[id@1 ="1"]`, `PARAM-NAME "" is empty`},
+		{"PARAM-NAME with non-ASCII", `[id@1 ä="1"]`, "contains invalid character 0xc3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := parsesyslog.New(Type)
+			if err != nil {
+				t.Fatalf("failed to create new RFC5424 parser: %s", err)
+			}
+			_, err = p.ParseString(hdr + tt.sd + " test")
+			if tt.errMsg == "" {
+				if err != nil {
+					t.Fatalf("ParseString() failed: %s", err)
+				}
+				return
+			}
+			if !errors.Is(err, parsesyslog.ErrWrongSDFormat) || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Fatalf("ParseString() => expected error %q, got: %v", tt.errMsg, err)
+			}
+
+			p, err = parsesyslog.New(Type, parsesyslog.WithLenient())
+			if err != nil {
+				t.Fatalf("failed to create new RFC5424 parser: %s", err)
+			}
+			l, err := p.ParseString(hdr + tt.sd + " test")
+			if err != nil {
+				t.Fatalf("ParseString() in lenient mode failed: %s", err)
+			}
+			if !l.Relaxed.Has(parsesyslog.RelaxedSDName) {
+				t.Errorf("ParseString() in lenient mode => expected RelaxedSDName, got: %s", l.Relaxed)
+			}
+		})
+	}
+}
+
+// TestParseStringRFC5424_duplicateSDID tests the detection of duplicate SD-IDs
+func TestParseStringRFC5424_duplicateSDID(t *testing.T) {
+	hdr := "<165>1 2003-10-11T22:14:15.003Z host app - - "
+	tests := []struct {
+		name    string
+		sd      string
+		opts    []parsesyslog.Option
+		want    bool
+		wantErr bool
+	}{
+		{"unique", `[a@1 b="c"][d@1]`, []parsesyslog.Option{parsesyslog.WithLenient()}, false, false},
+		{"duplicate lenient", `[a@1 b="c"][d@1][a@1]`, []parsesyslog.Option{parsesyslog.WithLenient()}, true, false},
+		{"duplicate default", `[a@1 b="c"][a@1]`, nil, false, false},
+		{"duplicate strict", `[a@1 b="c"][a@1]`, []parsesyslog.Option{parsesyslog.WithStrict()}, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := parsesyslog.New(Type, tt.opts...)
+			if err != nil {
+				t.Fatalf("failed to create new RFC5424 parser: %s", err)
+			}
+			l, err := p.ParseString(hdr + tt.sd + " test")
+			if tt.wantErr {
+				if !errors.Is(err, parsesyslog.ErrABNFViolation) {
+					t.Errorf("ParseString() => expected ABNF violation, got: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseString() failed: %s", err)
+			}
+			if got := l.Relaxed.Has(parsesyslog.RelaxedDuplicateSDID); got != tt.want {
+				t.Errorf("ParseString() => expected RelaxedDuplicateSDID: %t, got: %t", tt.want, got)
+			}
+			if l.Relaxed.Has(parsesyslog.RelaxedDuplicateSDID) && l.Relaxed.String() != "duplicate-sdid" {
+				t.Errorf("Relaxed.String() => expected: duplicate-sdid, got: %s", l.Relaxed)
+			}
+		})
+	}
+}
@@ -0,0 +1,262 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package rfc5424
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/wneessen/go-parsesyslog"
+)
+
+const (
+	// maxPrival is the highest valid PRIVAL
+	maxPrival = 191
+	// maxSecFracLen is the maximum amount of digits of TIME-SECFRAC
+	maxSecFracLen = 6
+	// maxTimestampLen is the maximum length of a TIMESTAMP
+	maxTimestampLen = 32
+)
+
+// violation returns a parsesyslog.ErrABNFViolation error with the given details
+func violation(format string, a ...interface{}) error {
+	return fmt.Errorf("%w: %s", parsesyslog.ErrABNFViolation, fmt.Sprintf(format, a...))
+}
+
+// strictPriority validates the given PRIVAL
+// See: https://datatracker.ietf.org/doc/html/rfc5424#section-6.2.1
+func strictPriority(b []byte, p parsesyslog.Priority) error {
+	if len(b) == 0 || len(b) > 3 || p > maxPrival {
+		return violation("PRIVAL %q is out of range", b)
+	}
+	return nil
+}
+
+// strictProtoVersion validates the given VERSION, which must not have leading zeros
+// See: https://datatracker.ietf.org/doc/html/rfc5424#section-6.2.2
+func strictProtoVersion(b []byte) error {
+	if len(b) == 0 || len(b) > 3 || b[0] == '0' {
+		return violation("invalid VERSION %q", b)
+	}
+	return nil
+}
+
+// strictHeaderField validates the given header field, which must consist of 1 to max
+// printable US-ASCII characters
+// See: https://datatracker.ietf.org/doc/html/rfc5424#section-6
+func strictHeaderField(name string, b []byte, max int) error {
+	if len(b) == 0 {
+		return violation("empty %s", name)
+	}
+	if len(b) > max {
+		return violation("%s exceeds %d characters", name, max)
+	}
+	for _, c := range b {
+		if c < 33 || c > 126 {
+			return violation("%s contains non-printable US-ASCII character 0x%02x", name, c)
+		}
+	}
+	return nil
+}
+
+// strictTimestamp validates the given TIMESTAMP, which must be a NILVALUE or an RFC3339
+// timestamp with upper case "T" and "Z" and at most 6 digits of fractional seconds
+// See: https://datatracker.ietf.org/doc/html/rfc5424#section-6.2.3
+func strictTimestamp(b []byte) error {
+	if len(b) == 1 && b[0] == '-' {
+		return nil
+	}
+	if bytes.ContainsAny(b, "tz") {
+		return violation("TIMESTAMP %q contains lower case separator", b)
+	}
+	if _, err := time.Parse(time.RFC3339, string(b)); err != nil {
+		return violation("invalid TIMESTAMP %q", b)
+	}
+	if i := bytes.IndexByte(b, '.'); i >= 0 {
+		n := 0
+		for _, c := range b[i+1:] {
+			if c < '0' || c > '9' {
+				break
+			}
+			n++
+		}
+		if n > maxSecFracLen {
+			return violation("TIME-SECFRAC of TIMESTAMP %q exceeds %d digits", b, maxSecFracLen)
+		}
+	}
+	return nil
+}
+
+// readSDByte reads the next byte of the structured data and accounts for it in the
+// header size and the wire format
+func (m *msg) readSDByte(r *bufio.Reader, lm *parsesyslog.LogMsg) (byte, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	m.hlen++
+	if err = m.checkHeaderSize(); err != nil {
+		return 0, err
+	}
+	if lm.Wire != nil {
+		m.wire.WriteByte(b)
+	}
+	return b, nil
+}
+
+// readSDName reads a SD-NAME (a SD-ID or a PARAM-NAME) into the buffer and returns the
+// byte that terminated it
+// See: https://datatracker.ietf.org/doc/html/rfc5424#section-6.3
+func (m *msg) readSDName(r *bufio.Reader, lm *parsesyslog.LogMsg, name string) (byte, error) {
+	m.buf.Reset()
+	for {
+		b, err := m.readSDByte(r, lm)
+		if err != nil {
+			return 0, err
+		}
+		if b == ' ' || b == '=' || b == ']' {
+			if m.buf.Len() == 0 {
+				return 0, violation("empty %s", name)
+			}
+			return b, nil
+		}
+		if b < 33 || b > 126 || b == '"' {
+			return 0, violation("%s contains invalid character 0x%02x", name, b)
+		}
+		if m.buf.Len() == maxSDNameLen {
+			return 0, violation("%s exceeds %d characters", name, maxSDNameLen)
+		}
+		m.buf.WriteByte(b)
+	}
+}
+
+// readParamValue reads a PARAM-VALUE into the buffer, up to the closing quote. As with
+// parseStructuredData, the value is kept as escaped on the wire, unless the Options
+// require unescaping.
+// See: https://datatracker.ietf.org/doc/html/rfc5424#section-6.3.3
+func (m *msg) readParamValue(r *bufio.Reader, lm *parsesyslog.LogMsg, name string) error {
+	m.buf.Reset()
+	for {
+		b, err := m.readSDByte(r, lm)
+		if err != nil {
+			return err
+		}
+		switch b {
+		case '"':
+			if !utf8.Valid(m.buf.Bytes()) {
+				return violation("PARAM-VALUE of %q is not valid UTF-8", name)
+			}
+			return nil
+		case ']':
+			return violation("PARAM-VALUE of %q contains unescaped ']'", name)
+		case '\\':
+			if b, err = m.readSDByte(r, lm); err != nil {
+				return err
+			}
+			m.writeEscaped(b)
+			continue
+		}
+		m.buf.WriteByte(b)
+	}
+}
+
+// parseStructuredDataStrict works like parseStructuredData, but enforces the ABNF of
+// the structured data: SD-NAMEs of 1 to 32 printable US-ASCII characters except '=',
+// SP, ']' and '"', properly escaped PARAM-VALUEs in UTF-8, no whitespace between the
+// elements and unique SD-IDs.
+// See: https://datatracker.ietf.org/doc/html/rfc5424#section-6.3
+func (m *msg) parseStructuredDataStrict(r *bufio.Reader, lm *parsesyslog.LogMsg) error {
+	m.buf.Reset()
+	if lm.Wire != nil {
+		m.wire.Reset()
+	}
+	b, err := m.readSDByte(r, lm)
+	if err != nil {
+		return err
+	}
+	if b == '-' {
+		if b, err = r.ReadByte(); err != nil {
+			return err
+		}
+		m.hlen++
+		if b != ' ' {
+			return violation("missing SP after STRUCTURED-DATA")
+		}
+		return nil
+	}
+	if b != '[' {
+		return parsesyslog.ErrWrongSDFormat
+	}
+
+	sds := lm.StructuredData[:0]
+	for {
+		sd := nextSDElement(sds)
+		d, err := m.readSDName(r, lm, "SD-ID")
+		if err != nil {
+			return err
+		}
+		if d == '=' {
+			return violation("SD-ID %q contains '='", m.buf.Bytes())
+		}
+		for _, e := range sds {
+			if e.ID == m.buf.String() {
+				return violation("duplicate SD-ID %q", e.ID)
+			}
+		}
+		sd.ID = m.str(r, sd.ID, m.buf.Bytes())
+		for d == ' ' {
+			if d, err = m.readSDName(r, lm, "PARAM-NAME"); err != nil {
+				return err
+			}
+			if d != '=' {
+				return violation("PARAM-NAME %q of %q is not followed by '='", m.buf.Bytes(), sd.ID)
+			}
+			sdp := nextSDParam(sd.Param)
+			sdp.Name = m.str(r, sdp.Name, m.buf.Bytes())
+			if b, err = m.readSDByte(r, lm); err != nil {
+				return err
+			}
+			if b != '"' {
+				return violation("PARAM-VALUE of %q is not quoted", sdp.Name)
+			}
+			if err = m.readParamValue(r, lm, sdp.Name); err != nil {
+				return err
+			}
+			sdp.Value = m.str(r, sdp.Value, m.buf.Bytes())
+			sd.Param = append(sd.Param, sdp)
+			if d, err = m.readSDByte(r, lm); err != nil {
+				return err
+			}
+			if d != ' ' && d != ']' {
+				return violation("PARAM-VALUE of %q is not followed by SP or ']'", sdp.Name)
+			}
+		}
+		sds = append(sds, sd)
+
+		if b, err = r.ReadByte(); err != nil {
+			return err
+		}
+		m.hlen++
+		if b == ' ' {
+			break
+		}
+		if lm.Wire != nil {
+			m.wire.WriteByte(b)
+		}
+		if b != '[' {
+			return violation("SD-ELEMENT is followed by 0x%02x", b)
+		}
+	}
+	if m.opts.Wants(parsesyslog.FieldStructuredData) {
+		lm.StructuredData = sds
+	}
+	if lm.Wire != nil {
+		lm.Wire.StructuredData = m.wire.String()
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package rfc5424
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/wneessen/go-parsesyslog"
+)
+
+// TestParseStringRFC5424_withStrict tests that strict mode rejects messages that violate
+// the ABNF of RFC5424 with a detailed error
+func TestParseStringRFC5424_withStrict(t *testing.T) {
+	sd := `[exampleSDID@32473 iut="3" eventSource="Application" eventID="1011"]`
+	tests := []struct {
+		name   string
+		msg    string
+		detail string
+	}{
+		{"valid", `<165>1 2003-10-11T22:14:15.003Z mymachine evntslog - ID47 ` + sd + ` test`, ""},
+		{"valid with NILVALUEs", `<165>1 - - - - - - test`, ""},
+		{
+			"valid with escapes", `<165>1 2003-10-11T22:14:15.003Z host app - - [a@1 b="x\"y\]z\\"][c@1] test`,
+			"",
+		},
+		{"PRIVAL out of range", `<192>1 2003-10-11T22:14:15.003Z host app - - - test`, "PRIVAL"},
+		{"VERSION with leading zero", `<165>01 2003-10-11T22:14:15.003Z host app - - - test`, "VERSION"},
+		{"secfrac too long", `<165>1 2003-10-11T22:14:15.0000003Z host app - - - test`, "TIME-SECFRAC"},
+		{"lower case separator", `<165>1 2003-10-11t22:14:15.003z host app - - - test`, "lower case"},
+		{
+			"HOSTNAME too long", `<165>1 2003-10-11T22:14:15.003Z ` + strings.Repeat("h", 256) + ` app - - - test`,
+			"HOSTNAME exceeds",
+		},
+		{
+			"APP-NAME too long", `<165>1 2003-10-11T22:14:15.003Z host ` + strings.Repeat("a", 49) + ` - - - test`,
+			"APP-NAME exceeds",
+		},
+		{"non-ASCII MSGID", "<165>1 2003-10-11T22:14:15.003Z host app - ID\xc3\xa4 - test", "MSGID contains"},
+		{"empty PROCID", `<165>1 2003-10-11T22:14:15.003Z host app  ID47 - test`, "empty PROCID"},
+		{
+			"SD-ID too long", `<165>1 2003-10-11T22:14:15.003Z host app - - [` + strings.Repeat("s", 33) +
+				` a="b"] test`, "SD-ID exceeds",
+		},
+		{"quote in PARAM-NAME", `<165>1 2003-10-11T22:14:15.003Z host app - - [a@1 b"="c"] test`, "PARAM-NAME"},
+		{"unquoted PARAM-VALUE", `<165>1 2003-10-11T22:14:15.003Z host app - - [a@1 b=c] test`, "not quoted"},
+		{"unescaped bracket", `<165>1 2003-10-11T22:14:15.003Z host app - - [a@1 b="c]"] test`, "unescaped"},
+		{"element after SP is MSG", `<165>1 2003-10-11T22:14:15.003Z host app - - [a@1 b="c"] [d@1] test`, ""},
+		{"duplicate SD-ID", `<165>1 2003-10-11T22:14:15.003Z host app - - [a@1 b="c"][a@1] test`, "duplicate"},
+		{"empty element", `<165>1 2003-10-11T22:14:15.003Z host app - - [] test`, "empty SD-ID"},
+		{"invalid UTF-8 MSG", "<165>1 2003-10-11T22:14:15.003Z host app - - - \xef\xbb\xbftest\xff", "UTF-8"},
+	}
+	p, err := parsesyslog.New(Type, parsesyslog.WithStrict())
+	if err != nil {
+		t.Fatalf("failed to create new RFC5424 parser: %s", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := p.ParseString(tt.msg)
+			if tt.detail == "" {
+				if err != nil {
+					t.Errorf("ParseString() failed: %s", err)
+				}
+				return
+			}
+			if !errors.Is(err, parsesyslog.ErrABNFViolation) {
+				t.Fatalf("ParseString() => expected: %s, got: %v", parsesyslog.ErrABNFViolation, err)
+			}
+			if !strings.Contains(err.Error(), tt.detail) {
+				t.Errorf("ParseString() => expected error to contain %q, got: %s", tt.detail, err)
+			}
+		})
+	}
+}
+
+// TestParseStringRFC5424_withStrict_structuredData tests that strict mode parses the
+// structured data just like the default mode
+func TestParseStringRFC5424_withStrict_structuredData(t *testing.T) {
+	msg := `<165>1 2003-10-11T22:14:15.003Z mymachine evntslog - ID47 [exampleSDID@32473 iut="3" ` +
+		`eventSource="Application"][examplePriority@32473 class="high"] test`
+	p, err := parsesyslog.New(Type)
+	if err != nil {
+		t.Fatalf("failed to create new RFC5424 parser: %s", err)
+	}
+	sp, err := parsesyslog.New(Type, parsesyslog.WithStrict(), parsesyslog.WithRoundTrip())
+	if err != nil {
+		t.Fatalf("failed to create new RFC5424 parser: %s", err)
+	}
+	want, err := p.ParseString(msg)
+	if err != nil {
+		t.Fatalf("ParseString() failed: %s", err)
+	}
+	got, err := sp.ParseString(msg)
+	if err != nil {
+		t.Fatalf("ParseString() in strict mode failed: %s", err)
+	}
+	if d := parsesyslog.Diff(want, got); len(d) > 0 {
+		t.Errorf("ParseString() in strict mode => unexpected differences: %v", d)
+	}
+	if b, err := Marshal(got); err != nil || string(b) != msg {
+		t.Errorf("Marshal() => expected: %q, got: %q (%v)", msg, b, err)
+	}
+}
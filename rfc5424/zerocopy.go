@@ -0,0 +1,79 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package rfc5424
+
+import (
+	"bufio"
+	"bytes"
+	"unsafe"
+
+	"github.com/wneessen/go-parsesyslog"
+)
+
+// offset returns the position in the source byte slice of the next byte that will be
+// read from the given bufio.Reader, which is either the reader of the source byte slice
+// or the reader of an octet-counted message on top of it
+func (m *msg) offset(r *bufio.Reader) int {
+	o := len(m.src) - m.br.Len() - m.bbr.Buffered()
+	if r == m.lbr {
+		o -= m.lbr.Buffered()
+	}
+	return o
+}
+
+// str returns the given field, which has just been read from the given bufio.Reader,
+// followed by a single delimiter byte, as string. In zero-copy mode, the string
+// references the source byte slice, so it must not be retained by the parser as last
+// value. Otherwise, last is returned if it is equal to the field, to avoid an
+// allocation for repeated values.
+func (m *msg) str(r *bufio.Reader, last string, b []byte) string {
+	if m.src == nil {
+		return parsesyslog.ReuseString(last, b)
+	}
+	e := m.offset(r) - 1
+	s := e - len(b)
+	if s < 0 || !bytes.Equal(m.src[s:e], b) {
+		return string(b)
+	}
+	return bytesToString(m.src[s:e])
+}
+
+// name works like str, but interns the field with the Interner of the Options outside
+// of zero-copy mode
+func (m *msg) name(r *bufio.Reader, last string, b []byte) string {
+	if m.src == nil {
+		return m.opts.Interner.Reuse(last, b)
+	}
+	return m.str(r, last, b)
+}
+
+// readMessage references the remainder of the source byte slice as Message of the given
+// LogMsg (see parsesyslog.LogMsg.SetMessageRef)
+func (m *msg) readMessage(r *bufio.Reader, lm *parsesyslog.LogMsg) {
+	s, e := m.offset(r), len(m.src)
+	if r == m.lbr {
+		e = s + m.lbr.Buffered() + int(m.lr.N)
+	}
+	lm.SetMessageRef(m.src[s:e])
+}
+
+// dropMessage empties the Message of the given LogMsg. In zero-copy mode, the Message
+// references the source byte slice, so it is replaced by a new buffer instead of being
+// reset, as further writes to it would overwrite the source byte slice
+func (m *msg) dropMessage(lm *parsesyslog.LogMsg) {
+	if m.src != nil {
+		lm.Message = bytes.Buffer{}
+		return
+	}
+	lm.Message.Reset()
+}
+
+// bytesToString returns a string that shares the memory of the given byte slice
+func bytesToString(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	return *(*string)(unsafe.Pointer(&b))
+}
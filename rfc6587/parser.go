@@ -0,0 +1,88 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package rfc6587
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/wneessen/go-parsesyslog"
+)
+
+// msg represents a parser that reads RFC6587 framed log messages and delegates the
+// parsing of the payload to another parsesyslog.Parser
+type msg struct {
+	br     bufio.Reader
+	fr     *Framer
+	parser parsesyslog.Parser
+	pr     bytes.Reader
+	src    io.Reader
+}
+
+// NewParser returns a parsesyslog.Parser that reads a single RFC6587 frame from the
+// stream per call and hands its payload to the given Parser (i. e. a rfc3164 or rfc5424
+// parser). The framing of the stream is controlled by the given FramerOption functions.
+//
+// Consecutive calls to ParseReader with the same io.Reader read consecutive frames
+// from that stream. Calling ParseReader with a different io.Reader discards all state
+// of the previous stream. The io.Readers are compared by pointer, so an io.Reader that is
+// no pointer (i. e. a struct value) is considered a new stream on every call. The
+// returned Parser is not safe for concurrent use.
+func NewParser(p parsesyslog.Parser, opts ...FramerOption) parsesyslog.Parser {
+	return &msg{
+		fr:     NewFramer(nil, opts...),
+		parser: p,
+	}
+}
+
+// ParseString returns the parsed log message of the first frame read from a string
+func (m *msg) ParseString(s string) (parsesyslog.LogMsg, error) {
+	return m.ParseReader(strings.NewReader(s))
+}
+
+// ParseReader reads the next frame from the given io.Reader and returns the parsed
+// payload. It satisfies the parsesyslog.Parser interface
+func (m *msg) ParseReader(r io.Reader) (parsesyslog.LogMsg, error) {
+	var l parsesyslog.LogMsg
+	err := m.ParseReaderInto(r, &l)
+	return l, err
+}
+
+// ParseReaderInto works like ParseReader, but parses the payload into the given LogMsg,
+// if the payload parser is a parsesyslog.ReusingParser. It satisfies the
+// parsesyslog.ReusingParser interface
+func (m *msg) ParseReaderInto(r io.Reader, l *parsesyslog.LogMsg) error {
+	if !sameReader(r, m.src) {
+		m.src = r
+		m.fr.Reset(r)
+	}
+	f, err := m.fr.Next()
+	if err != nil {
+		l.Reset()
+		return err
+	}
+	m.pr.Reset(f)
+	m.br.Reset(&m.pr)
+	if rp, ok := m.parser.(parsesyslog.ReusingParser); ok {
+		return rp.ParseReaderInto(&m.br, l)
+	}
+	lm, err := m.parser.ParseReader(&m.br)
+	*l = lm
+	return err
+}
+
+// sameReader returns true if both io.Readers are the same pointer. Other io.Readers are
+// never considered the same, since comparing them as interface values panics if their
+// type is not comparable.
+func sameReader(a, b io.Reader) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	return va.Kind() == reflect.Ptr && va.Type() == vb.Type() && va.Pointer() == vb.Pointer()
+}
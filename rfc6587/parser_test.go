@@ -0,0 +1,131 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package rfc6587
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/wneessen/go-parsesyslog"
+	"github.com/wneessen/go-parsesyslog/rfc3164"
+	"github.com/wneessen/go-parsesyslog/rfc5424"
+)
+
+// TestParser_ParseReader tests the ParseReader method of the RFC6587 parser
+func TestParser_ParseReader(t *testing.T) {
+	tests := []struct {
+		name     string
+		pt       parsesyslog.ParserType
+		input    string
+		wantMsgs []string
+	}{
+		{
+			"RFC5424 octet-counting", rfc5424.Type,
+			`80 <165>1 2003-10-11T22:14:15.003Z mymachine evntslog - ID47 [foo@1234 a="b"] first` +
+				`56 <165>1 2003-10-11T22:14:15.003Z mymachine - - - - second`,
+			[]string{"first", "second"},
+		},
+		{
+			"RFC5424 non-transparent with NUL and LF trailers", rfc5424.Type,
+			"<165>1 2003-10-11T22:14:15.003Z mymachine - - - - first\x00" +
+				"<165>1 2003-10-11T22:14:15.003Z mymachine - - - - second\n",
+			[]string{"first", "second"},
+		},
+		{
+			"RFC3164 non-transparent", rfc3164.Type,
+			"<34>Oct 11 22:14:15 mymachine su: first\n<34>Oct 11 22:14:15 mymachine su: second\n",
+			[]string{"first", "second"},
+		},
+		{
+			"RFC3164 mixed framing", rfc3164.Type,
+			"<34>Oct 11 22:14:15 mymachine su: first\n40 <34>Oct 11 22:14:15 mymachine su: second",
+			[]string{"first", "second"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pp, err := parsesyslog.New(tt.pt)
+			if err != nil {
+				t.Fatalf("failed to create payload parser: %s", err)
+			}
+			p := NewParser(pp)
+			r := strings.NewReader(tt.input)
+			var msgs []string
+			for {
+				lm, err := p.ParseReader(r)
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if err != nil {
+					t.Fatalf("ParseReader() failed: %s", err)
+				}
+				if lm.Hostname != "mymachine" {
+					t.Errorf("ParseReader() wrong hostname => expected: %s, got: %s", "mymachine", lm.Hostname)
+				}
+				msgs = append(msgs, lm.Message.String())
+			}
+			if len(msgs) != len(tt.wantMsgs) {
+				t.Fatalf("ParseReader() message count => expected: %d, got: %d", len(tt.wantMsgs), len(msgs))
+			}
+			for i := range msgs {
+				if msgs[i] != tt.wantMsgs[i] {
+					t.Errorf("ParseReader() message %d => expected: %q, got: %q", i, tt.wantMsgs[i], msgs[i])
+				}
+			}
+		})
+	}
+}
+
+// uncomparableReader is an io.Reader of a type that can not be compared with ==
+type uncomparableReader struct {
+	r    *strings.Reader
+	tags []string
+}
+
+// Read satisfies the io.Reader interface for the uncomparableReader type
+func (u uncomparableReader) Read(p []byte) (int, error) {
+	return u.r.Read(p)
+}
+
+// TestParser_ParseReader_uncomparable tests that the RFC6587 parser accepts io.Readers
+// whose type is not comparable
+func TestParser_ParseReader_uncomparable(t *testing.T) {
+	pp, err := parsesyslog.New(rfc3164.Type)
+	if err != nil {
+		t.Fatalf("failed to create payload parser: %s", err)
+	}
+	p := NewParser(pp)
+	for _, msg := range []string{"first", "second"} {
+		r := uncomparableReader{r: strings.NewReader("<34>Oct 11 22:14:15 mymachine su: " + msg + "\n")}
+		lm, err := p.ParseReader(r)
+		if err != nil {
+			t.Fatalf("ParseReader() failed: %s", err)
+		}
+		if lm.Message.String() != msg {
+			t.Errorf("ParseReader() => expected message: %q, got: %q", msg, lm.Message.String())
+		}
+	}
+}
+
+// TestParser_ParseString tests the ParseString method of the RFC6587 parser
+func TestParser_ParseString(t *testing.T) {
+	pp, err := parsesyslog.New(rfc5424.Type)
+	if err != nil {
+		t.Fatalf("failed to create payload parser: %s", err)
+	}
+	p := NewParser(pp, WithFraming(FramingOctetCounting))
+	lm, err := p.ParseString(`57 <165>1 2003-10-11T22:14:15.003Z mymachine - - - - message`)
+	if err != nil {
+		t.Fatalf("ParseString() failed: %s", err)
+	}
+	if lm.Message.String() != "message" {
+		t.Errorf("ParseString() wrong message => expected: %s, got: %s", "message", lm.Message.String())
+	}
+	if _, err = p.ParseString("<165>1 2003-10-11T22:14:15.003Z mymachine - - - - message\n"); err == nil {
+		t.Errorf("ParseString() with wrong framing expected to fail")
+	}
+}
@@ -0,0 +1,128 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package rfc6587
+
+import (
+	"errors"
+	"io"
+
+	"github.com/wneessen/go-parsesyslog"
+)
+
+// prefetcher reads frames ahead of time on a separate goroutine, so that reading the
+// next frames from the stream overlaps with the processing of the current frame
+type prefetcher struct {
+	bufs   [][]byte
+	done   chan struct{}
+	err    error
+	frames chan prefetched
+}
+
+// prefetched represents a frame that has been read ahead of time
+type prefetched struct {
+	err     error
+	frame   []byte
+	framing Framing
+}
+
+// WithPrefetch makes the Framer read up to n frames ahead of time on a separate
+// goroutine, while the current frame is being processed by the caller. This overlaps
+// the I/O of the stream with the CPU work of parsing, which increases the throughput
+// for network and disk sources. A value of 0 disables prefetching, which is the default.
+//
+// A prefetching Framer must be closed with Close once it is no longer used, so that
+// the prefetching goroutine is stopped.
+func WithPrefetch(n int) FramerOption {
+	return func(fr *Framer) {
+		fr.prefetch = n
+	}
+}
+
+// Close stops the prefetching goroutine of the Framer, if any. The goroutine will
+// terminate once a pending read on the underlying io.Reader returns, so the underlying
+// io.Reader should be closed as well. Close does not close the underlying io.Reader.
+func (f *Framer) Close() error {
+	f.stopPrefetch()
+	return nil
+}
+
+// startPrefetch starts the prefetching goroutine on a separate Framer that reads from
+// the same source, so that the prefetching goroutine never shares state with f
+func (f *Framer) startPrefetch() {
+	inner := &Framer{
+		framing: f.framing,
+		maxLen:  f.maxLen,
+		strict:  f.strict,
+		timeout: f.timeout,
+	}
+	inner.Reset(f.src)
+
+	// The caller holds one frame, the channel holds up to f.prefetch frames and
+	// the goroutine fills one, so f.prefetch+2 buffers can be safely used in turns
+	pf := &prefetcher{
+		bufs:   make([][]byte, f.prefetch+2),
+		done:   make(chan struct{}),
+		frames: make(chan prefetched, f.prefetch),
+	}
+	f.pf = pf
+	go pf.run(inner)
+}
+
+// stopPrefetch stops the prefetching goroutine of the Framer, if any
+func (f *Framer) stopPrefetch() {
+	if f.pf == nil {
+		return
+	}
+	close(f.pf.done)
+	f.pf = nil
+}
+
+// nextPrefetched returns the next frame that has been read ahead of time
+func (f *Framer) nextPrefetched() ([]byte, error) {
+	if f.pf == nil {
+		f.startPrefetch()
+	}
+	if f.pf.err != nil {
+		return nil, f.pf.err
+	}
+	p, ok := <-f.pf.frames
+	if !ok {
+		f.pf.err = io.EOF
+		return nil, io.EOF
+	}
+	if p.err != nil && !recoverable(p.err) {
+		f.pf.err = p.err
+	}
+	f.last = p.framing
+	return p.frame, p.err
+}
+
+// run reads frames from the given Framer until the stream ends, an unrecoverable
+// error occurs or the prefetcher is stopped
+func (p *prefetcher) run(f *Framer) {
+	defer close(p.frames)
+	for i := 0; ; i++ {
+		fr, err := f.next()
+		var b []byte
+		if fr != nil {
+			b = append(p.bufs[i%len(p.bufs)][:0], fr...)
+			p.bufs[i%len(p.bufs)] = b
+		}
+		select {
+		case p.frames <- prefetched{err: err, frame: b, framing: f.last}:
+		case <-p.done:
+			return
+		}
+		if err != nil && !recoverable(err) {
+			return
+		}
+	}
+}
+
+// recoverable returns true if the Framer is able to continue with the next frame
+// after the given error
+func recoverable(err error) bool {
+	return errors.Is(err, parsesyslog.ErrFrameTimeout) || errors.Is(err, parsesyslog.ErrFrameTooLarge)
+}
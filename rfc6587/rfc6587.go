@@ -0,0 +1,388 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+// Package rfc6587 implements the transmission framing of syslog messages over
+// stream transports (like TCP) as described in RFC6587
+package rfc6587
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"time"
+
+	"github.com/wneessen/go-parsesyslog"
+)
+
+// Framing represents a framing method as described in RFC6587
+type Framing int
+
+// Framings
+const (
+	// FramingAuto detects the framing method for each frame individually
+	FramingAuto Framing = iota
+	// FramingOctetCounting represents the octet-counting framing method
+	// See: https://datatracker.ietf.org/doc/html/rfc6587#section-3.4.1
+	FramingOctetCounting
+	// FramingNonTransparent represents the non-transparent framing method, using LF or
+	// NUL as trailer
+	// See: https://datatracker.ietf.org/doc/html/rfc6587#section-3.4.2
+	FramingNonTransparent
+)
+
+// DefaultMaxFrameLength is the maximum length of a frame that a Framer accepts by default
+const DefaultMaxFrameLength = 64 * 1024
+
+// trailers are the characters that terminate a non-transparent frame
+const trailers = "\n\x00"
+
+// maxLenDigits is the maximum amount of digits we accept for the MSG-LEN part of an
+// octet-counted frame
+const maxLenDigits = 9
+
+// Framer splits a stream of syslog messages into individual frames
+type Framer struct {
+	br       *bufio.Reader
+	dl       deadliner
+	frame    []byte
+	framing  Framing
+	last     Framing
+	maxLen   int
+	own      *bufio.Reader
+	pf       *prefetcher
+	prefetch int
+	resync   resync
+	skip     int
+	src      io.Reader
+	strict   bool
+	timeout  time.Duration
+}
+
+// deadliner is implemented by readers that support read deadlines, like net.Conn
+type deadliner interface {
+	SetReadDeadline(time.Time) error
+}
+
+// resync represents the way a Framer re-synchronizes with the stream after a
+// frame timeout
+type resync int
+
+const (
+	resyncNone resync = iota
+	resyncSkipBytes
+	resyncSkipLine
+)
+
+// FramerOption is a function that configures a Framer
+type FramerOption func(*Framer)
+
+// WithFraming sets the framing method of the Framer. By default, the Framer uses
+// FramingAuto and detects the framing method per frame.
+func WithFraming(f Framing) FramerOption {
+	return func(fr *Framer) {
+		fr.framing = f
+	}
+}
+
+// WithStrictFraming enables strict framing. In strict mode, a Framer that uses
+// FramingAuto only detects the framing method of the first frame and will return
+// ErrFramingMismatch for any following frame that uses a different framing method.
+// Without strict mode, senders are allowed to switch between octet-counted and
+// non-transparent framing on a single connection.
+func WithStrictFraming() FramerOption {
+	return func(fr *Framer) {
+		fr.strict = true
+	}
+}
+
+// WithFrameTimeout sets the maximum duration a frame may take to be completed once the
+// first byte of it has been received. If a sender stalls in the middle of a frame for
+// longer than that, Next returns the partial frame data together with ErrFrameTimeout
+// and re-synchronizes to the beginning of the next frame on the following call. If the
+// remainder of the frame does not arrive within the frame timeout either, Next returns
+// ErrFrameTimeout again and continues the re-synchronization on the following call.
+// Waiting for the first byte of a frame is never subject to this timeout, so idle
+// connections are not affected.
+//
+// The frame timeout requires the io.Reader passed to NewFramer or Reset to support
+// read deadlines (i. e. a net.Conn). For other readers, this option has no effect.
+func WithFrameTimeout(d time.Duration) FramerOption {
+	return func(fr *Framer) {
+		fr.timeout = d
+	}
+}
+
+// WithMaxFrameLength sets the maximum length of a frame. Octet-counted frames that
+// declare a larger MSG-LEN and non-transparent frames that grow beyond this length
+// are rejected with ErrFrameTooLarge before their data is buffered. If Next is called
+// again, the remainder of the oversized frame is discarded without buffering it, so that
+// the Framer can continue with the next frame. Since a huge MSG-LEN is often caused by
+// a broken or hostile sender, closing the connection is usually the better choice.
+// If n is 0 or negative, DefaultMaxFrameLength is used.
+func WithMaxFrameLength(n int) FramerOption {
+	return func(fr *Framer) {
+		fr.maxLen = n
+	}
+}
+
+// NewFramer returns a new Framer that reads frames from the given io.Reader
+func NewFramer(r io.Reader, opts ...FramerOption) *Framer {
+	f := &Framer{}
+	for _, o := range opts {
+		o(f)
+	}
+	if f.maxLen <= 0 {
+		f.maxLen = DefaultMaxFrameLength
+	}
+	f.Reset(r)
+	return f
+}
+
+// Reset discards any buffered data and state of the Framer and switches it to read
+// from the given io.Reader
+func (f *Framer) Reset(r io.Reader) {
+	f.stopPrefetch()
+	f.src = r
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		if f.own == nil {
+			f.own = bufio.NewReader(r)
+		}
+		f.own.Reset(r)
+		br = f.own
+	}
+	f.br = br
+	f.dl, _ = r.(deadliner)
+	f.last = FramingAuto
+	f.frame = f.frame[:0]
+	f.resync, f.skip = resyncNone, 0
+}
+
+// Framing returns the framing method of the most recently read frame
+func (f *Framer) Framing() Framing {
+	return f.last
+}
+
+// Next reads the next frame from the stream and returns its payload (without the
+// MSG-LEN prefix or the trailer). The returned byte slice is only valid until the
+// next call to Next. If the stream has ended, io.EOF is returned.
+//
+// If a frame timeout is configured and exceeded, the partial frame data is returned
+// together with ErrFrameTimeout, so that it can be handed to an error path.
+func (f *Framer) Next() ([]byte, error) {
+	if f.prefetch > 0 {
+		return f.nextPrefetched()
+	}
+	return f.next()
+}
+
+// next reads the next frame from the stream
+func (f *Framer) next() ([]byte, error) {
+	f.frame = f.frame[:0]
+	if err := f.resyncStream(); err != nil {
+		if isTimeout(err) {
+			return nil, parsesyslog.ErrFrameTimeout
+		}
+		return nil, err
+	}
+	fm, err := f.detect()
+	if err != nil {
+		return nil, err
+	}
+	f.last = fm
+	if f.timeout > 0 && f.dl != nil {
+		if err := f.dl.SetReadDeadline(time.Now().Add(f.timeout)); err != nil {
+			return nil, err
+		}
+		defer func() { _ = f.dl.SetReadDeadline(time.Time{}) }()
+	}
+
+	var fr []byte
+	if fm == FramingOctetCounting {
+		fr, err = f.readOctetCounted()
+	} else {
+		fr, err = f.readNonTransparent()
+	}
+	if err != nil && isTimeout(err) {
+		return f.frame, parsesyslog.ErrFrameTimeout
+	}
+	return fr, err
+}
+
+// resyncStream discards the remainder of a frame that previously exceeded the frame
+// timeout or the maximum frame length. The remainder is subject to the frame timeout as
+// well, so that a sender that stalls in the middle of it does not block the Framer. In
+// that case, the resync continues on the next call.
+func (f *Framer) resyncStream() error {
+	if f.resync == resyncNone {
+		return nil
+	}
+	if f.timeout > 0 && f.dl != nil {
+		if err := f.dl.SetReadDeadline(time.Now().Add(f.timeout)); err != nil {
+			return err
+		}
+		defer func() { _ = f.dl.SetReadDeadline(time.Time{}) }()
+	}
+	switch f.resync {
+	case resyncSkipBytes:
+		n, err := f.br.Discard(f.skip)
+		f.skip -= n
+		if err != nil {
+			return err
+		}
+	case resyncSkipLine:
+		for {
+			_, err := f.readToTrailer()
+			if errors.Is(err, bufio.ErrBufferFull) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			break
+		}
+	}
+	f.resync = resyncNone
+	return nil
+}
+
+// detect peeks at the beginning of the next frame and returns its framing method.
+// Leading trailer characters (i. e. empty non-transparent frames) are skipped.
+func (f *Framer) detect() (Framing, error) {
+	var b byte
+	for {
+		p, err := f.br.Peek(1)
+		if err != nil {
+			return FramingAuto, err
+		}
+		b = p[0]
+		if b != '\n' && b != 0 {
+			break
+		}
+		_, _ = f.br.Discard(1)
+	}
+
+	fm := FramingNonTransparent
+	if b >= '1' && b <= '9' {
+		fm = FramingOctetCounting
+	}
+	want := f.framing
+	if want == FramingAuto && f.strict {
+		want = f.last
+	}
+	if want != FramingAuto && want != fm {
+		return fm, parsesyslog.ErrFramingMismatch
+	}
+	return fm, nil
+}
+
+// readOctetCounted reads a frame using the octet-counting framing method
+// See: https://datatracker.ietf.org/doc/html/rfc6587#section-3.4.1
+func (f *Framer) readOctetCounted() ([]byte, error) {
+	l := 0
+	for c := 0; ; c++ {
+		b, err := f.br.ReadByte()
+		if err != nil {
+			return nil, eofErr(err)
+		}
+		f.frame = append(f.frame, b)
+		if b == ' ' {
+			break
+		}
+		if b < '0' || b > '9' || c >= maxLenDigits {
+			return nil, parsesyslog.ErrInvalidFrameLength
+		}
+		l = l*10 + int(b-'0')
+	}
+	if l > f.maxLen {
+		f.resync, f.skip = resyncSkipBytes, l
+		return nil, parsesyslog.ErrFrameTooLarge
+	}
+	if cap(f.frame) < l {
+		f.frame = make([]byte, l)
+	}
+	f.frame = f.frame[:l]
+	n, err := io.ReadFull(f.br, f.frame)
+	if err != nil {
+		f.frame = f.frame[:n]
+		if isTimeout(err) {
+			f.resync, f.skip = resyncSkipBytes, l-n
+		}
+		return nil, eofErr(err)
+	}
+	return f.frame, nil
+}
+
+// readNonTransparent reads a frame using the non-transparent framing method
+// See: https://datatracker.ietf.org/doc/html/rfc6587#section-3.4.2
+func (f *Framer) readNonTransparent() ([]byte, error) {
+	for {
+		d, err := f.readToTrailer()
+		if len(f.frame)+len(d) > f.maxLen+1 {
+			f.frame = f.frame[:0]
+			if errors.Is(err, bufio.ErrBufferFull) {
+				f.resync = resyncSkipLine
+			}
+			return nil, parsesyslog.ErrFrameTooLarge
+		}
+		f.frame = append(f.frame, d...)
+		switch {
+		case err == nil:
+			return f.frame[:len(f.frame)-1], nil
+		case errors.Is(err, bufio.ErrBufferFull):
+			continue
+		case errors.Is(err, io.EOF) && len(f.frame) > 0:
+			return f.frame, nil
+		case isTimeout(err):
+			f.resync = resyncSkipLine
+			return nil, err
+		default:
+			return nil, err
+		}
+	}
+}
+
+// readToTrailer reads until the first occurrence of a trailer character (LF or NUL)
+// and returns the read bytes including the trailer. Like bufio.Reader.ReadSlice, the
+// returned bytes are only valid until the next read and bufio.ErrBufferFull is returned
+// if the buffer is full before a trailer was found.
+func (f *Framer) readToTrailer() ([]byte, error) {
+	var err error
+	for {
+		p, _ := f.br.Peek(f.br.Buffered())
+		if i := bytes.IndexAny(p, trailers); i >= 0 {
+			_, _ = f.br.Discard(i + 1)
+			return p[:i+1], nil
+		}
+		if err != nil {
+			_, _ = f.br.Discard(len(p))
+			return p, err
+		}
+		if len(p) == f.br.Size() {
+			_, _ = f.br.Discard(len(p))
+			return p, bufio.ErrBufferFull
+		}
+		_, err = f.br.Peek(len(p) + 1)
+	}
+}
+
+// eofErr converts an unexpected EOF in the middle of a frame into ErrPrematureEOF
+func eofErr(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return parsesyslog.ErrPrematureEOF
+	}
+	return err
+}
+
+// isTimeout returns true if the given error is caused by an exceeded read deadline
+func isTimeout(err error) bool {
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		return true
+	}
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
+}
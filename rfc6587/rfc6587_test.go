@@ -0,0 +1,278 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package rfc6587
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wneessen/go-parsesyslog"
+)
+
+// TestFramer_Next tests the Next method of the Framer
+func TestFramer_Next(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		opts    []FramerOption
+		want    []string
+		wantErr error
+	}{
+		{
+			"octet-counting", "11 <13>1 - - -10 <13>1 test", nil,
+			[]string{"<13>1 - - -", "<13>1 test"}, io.EOF,
+		},
+		{
+			"non-transparent", "<13>Oct 11 22:14:15 host su: test\n<13>foo\n\n", nil,
+			[]string{"<13>Oct 11 22:14:15 host su: test", "<13>foo"}, io.EOF,
+		},
+		{"non-transparent without trailer", "<13>foo\n<13>bar", nil, []string{"<13>foo", "<13>bar"}, io.EOF},
+		{
+			"mixed framing", "<13>foo\n7 <13>bar<13>baz\n", nil,
+			[]string{"<13>foo", "<13>bar", "<13>baz"}, io.EOF,
+		},
+		{
+			"mixed framing strict", "<13>foo\n7 <13>bar", []FramerOption{WithStrictFraming()},
+			[]string{"<13>foo"}, parsesyslog.ErrFramingMismatch,
+		},
+		{
+			"octet-counting enforced", "<13>foo\n", []FramerOption{WithFraming(FramingOctetCounting)},
+			nil, parsesyslog.ErrFramingMismatch,
+		},
+		{"premature EOF", "20 <13>foo", nil, nil, parsesyslog.ErrPrematureEOF},
+		{"invalid length", "1a <13>foo", nil, nil, parsesyslog.ErrInvalidFrameLength},
+		{"length overflow", "9999999999 <13>foo", nil, nil, parsesyslog.ErrInvalidFrameLength},
+		{"empty", "", nil, nil, io.EOF},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFramer(strings.NewReader(tt.input), tt.opts...)
+			var got []string
+			var err error
+			for {
+				var fr []byte
+				fr, err = f.Next()
+				if err != nil {
+					break
+				}
+				got = append(got, string(fr))
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Next() error => expected: %v, got: %v", tt.wantErr, err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Next() frame count => expected: %d, got: %d (%q)", len(tt.want), len(got), got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Next() frame %d => expected: %q, got: %q", i, tt.want[i], got[i])
+				}
+			}
+		})
+	}
+}
+
+// TestFramer_Framing tests the Framing method of the Framer
+func TestFramer_Framing(t *testing.T) {
+	f := NewFramer(strings.NewReader("<13>foo\n7 <13>bar"))
+	if _, err := f.Next(); err != nil {
+		t.Fatalf("Next() failed: %s", err)
+	}
+	if f.Framing() != FramingNonTransparent {
+		t.Errorf("Framing() => expected: %d, got: %d", FramingNonTransparent, f.Framing())
+	}
+	if _, err := f.Next(); err != nil {
+		t.Fatalf("Next() failed: %s", err)
+	}
+	if f.Framing() != FramingOctetCounting {
+		t.Errorf("Framing() => expected: %d, got: %d", FramingOctetCounting, f.Framing())
+	}
+}
+
+// TestFramer_NextTimeout tests the frame timeout of the Framer
+func TestFramer_NextTimeout(t *testing.T) {
+	tests := []struct {
+		name        string
+		start       string
+		rest        string
+		wantPartial string
+	}{
+		{"octet-counting", "20 <13>foo", "0123456789abc<13>bar\n", "<13>foo"},
+		{"non-transparent", "<13>foo", " continued\n<13>bar\n", "<13>foo"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc, cc := net.Pipe()
+			defer func() { _ = sc.Close() }()
+			go func() {
+				_, _ = cc.Write([]byte(tt.start))
+				time.Sleep(time.Millisecond * 100)
+				_, _ = cc.Write([]byte(tt.rest))
+				_ = cc.Close()
+			}()
+
+			f := NewFramer(sc, WithFrameTimeout(time.Millisecond*20))
+			fr, err := f.Next()
+			if !errors.Is(err, parsesyslog.ErrFrameTimeout) {
+				t.Fatalf("Next() error => expected: %v, got: %v", parsesyslog.ErrFrameTimeout, err)
+			}
+			if string(fr) != tt.wantPartial {
+				t.Errorf("Next() partial frame => expected: %q, got: %q", tt.wantPartial, fr)
+			}
+			// The remainder of the frame arrives after the frame timeout as well
+			for fr, err = f.Next(); errors.Is(err, parsesyslog.ErrFrameTimeout); fr, err = f.Next() {
+				if fr != nil {
+					t.Errorf("Next() during resync => expected no frame data, got: %q", fr)
+				}
+			}
+			if err != nil {
+				t.Fatalf("Next() after timeout failed: %s", err)
+			}
+			if string(fr) != "<13>bar" {
+				t.Errorf("Next() after timeout => expected: %q, got: %q", "<13>bar", fr)
+			}
+		})
+	}
+}
+
+// TestFramer_NextTimeout_resync tests that the re-synchronization after a frame timeout
+// is subject to the frame timeout as well
+func TestFramer_NextTimeout_resync(t *testing.T) {
+	sc, cc := net.Pipe()
+	defer func() { _ = sc.Close() }()
+	step := make(chan struct{})
+	go func() {
+		_, _ = cc.Write([]byte("<13>foo"))
+		<-step
+		_, _ = cc.Write([]byte(" stalled"))
+		<-step
+		_, _ = cc.Write([]byte(" end\n<13>bar\n"))
+		_ = cc.Close()
+	}()
+
+	f := NewFramer(sc, WithFrameTimeout(time.Millisecond*20))
+	next := func() ([]byte, error) {
+		type result struct {
+			fr  []byte
+			err error
+		}
+		res := make(chan result, 1)
+		go func() {
+			fr, err := f.Next()
+			res <- result{fr, err}
+		}()
+		select {
+		case r := <-res:
+			return r.fr, r.err
+		case <-time.After(time.Second * 2):
+			t.Fatal("Next() => blocked beyond the frame timeout")
+			return nil, nil
+		}
+	}
+	if _, err := next(); !errors.Is(err, parsesyslog.ErrFrameTimeout) {
+		t.Fatalf("Next() error => expected: %v, got: %v", parsesyslog.ErrFrameTimeout, err)
+	}
+	step <- struct{}{}
+	if _, err := next(); !errors.Is(err, parsesyslog.ErrFrameTimeout) {
+		t.Fatalf("Next() during resync => expected: %v, got: %v", parsesyslog.ErrFrameTimeout, err)
+	}
+	step <- struct{}{}
+	fr, err := next()
+	if err != nil {
+		t.Fatalf("Next() after resync failed: %s", err)
+	}
+	if string(fr) != "<13>bar" {
+		t.Errorf("Next() after resync => expected: %q, got: %q", "<13>bar", fr)
+	}
+}
+
+// TestFramer_NextMaxFrameLength tests the maximum frame length of the Framer
+func TestFramer_NextMaxFrameLength(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"octet-counting", "13 <13>foobarbaz7 <13>bar"},
+		{"octet-counting bogus length", "999999999 <13>foobar"},
+		{"non-transparent", "<13>foobarbaz\n<13>bar\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFramer(strings.NewReader(tt.input), WithMaxFrameLength(10))
+			_, err := f.Next()
+			if !errors.Is(err, parsesyslog.ErrFrameTooLarge) {
+				t.Fatalf("Next() error => expected: %v, got: %v", parsesyslog.ErrFrameTooLarge, err)
+			}
+			fr, err := f.Next()
+			if errors.Is(err, parsesyslog.ErrPrematureEOF) || errors.Is(err, io.EOF) {
+				return
+			}
+			if err != nil {
+				t.Fatalf("Next() after oversized frame failed: %s", err)
+			}
+			if string(fr) != "<13>bar" {
+				t.Errorf("Next() after oversized frame => expected: %q, got: %q", "<13>bar", fr)
+			}
+		})
+	}
+	if f := NewFramer(strings.NewReader("")); f.maxLen != DefaultMaxFrameLength {
+		t.Errorf("NewFramer() default max frame length => expected: %d, got: %d", DefaultMaxFrameLength,
+			f.maxLen)
+	}
+}
+
+// TestFramer_NextPrefetch tests the Next method of the Framer with prefetching enabled
+func TestFramer_NextPrefetch(t *testing.T) {
+	var sb strings.Builder
+	var want []string
+	for i := 0; i < 100; i++ {
+		fr := fmt.Sprintf("<13>message %d", i)
+		want = append(want, fr)
+		if i%2 == 0 {
+			sb.WriteString(fmt.Sprintf("%d %s", len(fr), fr))
+			continue
+		}
+		sb.WriteString(fr + "\n")
+	}
+	sb.WriteString("20 <13>foo")
+
+	f := NewFramer(strings.NewReader(sb.String()), WithPrefetch(4))
+	defer func() { _ = f.Close() }()
+	for i, w := range want {
+		fr, err := f.Next()
+		if err != nil {
+			t.Fatalf("Next() frame %d failed: %s", i, err)
+		}
+		if string(fr) != w {
+			t.Errorf("Next() frame %d => expected: %q, got: %q", i, w, fr)
+		}
+		wf := FramingOctetCounting
+		if i%2 != 0 {
+			wf = FramingNonTransparent
+		}
+		if f.Framing() != wf {
+			t.Errorf("Framing() frame %d => expected: %d, got: %d", i, wf, f.Framing())
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := f.Next(); !errors.Is(err, parsesyslog.ErrPrematureEOF) {
+			t.Errorf("Next() error => expected: %v, got: %v", parsesyslog.ErrPrematureEOF, err)
+		}
+	}
+
+	f.Reset(strings.NewReader("<13>foo\n"))
+	fr, err := f.Next()
+	if err != nil || string(fr) != "<13>foo" {
+		t.Errorf("Next() after Reset() => expected: %q, got: %q (%v)", "<13>foo", fr, err)
+	}
+	if _, err = f.Next(); !errors.Is(err, io.EOF) {
+		t.Errorf("Next() error => expected: %v, got: %v", io.EOF, err)
+	}
+}
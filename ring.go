@@ -0,0 +1,136 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultRingSize is the amount of messages a Ring retains if no size is given
+const DefaultRingSize = 1000
+
+// Ring retains the last parsed log messages in a bounded ring buffer and allows them to
+// be queried by time range, host and severity. This is useful for "show recent logs"
+// features of appliances that are built on this library, without the need for a log
+// storage. Once the Ring is full, the oldest message is overwritten by each new one.
+//
+// A Ring is safe for concurrent use.
+type Ring struct {
+	msgs  []LogMsg
+	mu    sync.RWMutex
+	next  int
+	total uint64
+}
+
+// RingQuery represents the filter criteria of a Ring query. Criteria with a zero value
+// are not applied, so the zero RingQuery matches all retained messages.
+type RingQuery struct {
+	// From is the earliest Timestamp (inclusive) of the returned messages
+	From time.Time
+	// Host is the Hostname of the returned messages (case-insensitive)
+	Host string
+	// Limit is the maximum amount of returned messages. If more messages match, the
+	// most recent ones are returned
+	Limit int
+	// Severities is the list of the Severity values of the returned messages
+	Severities []Severity
+	// To is the latest Timestamp (exclusive) of the returned messages
+	To time.Time
+}
+
+// NewRing returns a new, empty Ring that retains the given amount of messages. If the
+// size is 0 or negative, DefaultRingSize is used.
+func NewRing(size int) *Ring {
+	if size <= 0 {
+		size = DefaultRingSize
+	}
+	return &Ring{msgs: make([]LogMsg, 0, size)}
+}
+
+// Add adds a copy of the given LogMsg to the Ring, overwriting the oldest message if
+// the Ring is full. Since a copy is stored, LogMsgs parsed by a ReusingParser can be
+// added directly.
+func (r *Ring) Add(lm LogMsg) {
+	c := lm.Clone()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.total++
+	if len(r.msgs) < cap(r.msgs) {
+		r.msgs = append(r.msgs, c)
+		return
+	}
+	r.msgs[r.next] = c
+	r.next = (r.next + 1) % len(r.msgs)
+}
+
+// Len returns the amount of messages currently retained in the Ring
+func (r *Ring) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.msgs)
+}
+
+// Total returns the amount of messages that have been added to the Ring since it has
+// been created, including the ones that have been overwritten already
+func (r *Ring) Total() uint64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.total
+}
+
+// Query returns copies of the retained messages that match the given RingQuery, in the
+// order they have been added to the Ring (oldest first)
+func (r *Ring) Query(q RingQuery) []LogMsg {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var res []LogMsg
+	n := len(r.msgs)
+	for i := 0; i < n; i++ {
+		if q.Limit > 0 && len(res) >= q.Limit {
+			break
+		}
+		// Iterate newest first, so that the Limit keeps the most recent messages
+		lm := &r.msgs[(r.next+n-1-i)%n]
+		if q.matches(lm) {
+			res = append(res, lm.Clone())
+		}
+	}
+	for i, j := 0, len(res)-1; i < j; i, j = i+1, j-1 {
+		res[i], res[j] = res[j], res[i]
+	}
+	return res
+}
+
+// Reset removes all messages from the Ring
+func (r *Ring) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.msgs = make([]LogMsg, 0, cap(r.msgs))
+	r.next = 0
+}
+
+// matches returns true if the given LogMsg matches all criteria of the RingQuery
+func (q RingQuery) matches(lm *LogMsg) bool {
+	if !q.From.IsZero() && lm.Timestamp.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && !lm.Timestamp.Before(q.To) {
+		return false
+	}
+	if q.Host != "" && !strings.EqualFold(q.Host, lm.Hostname) {
+		return false
+	}
+	if len(q.Severities) == 0 {
+		return true
+	}
+	for _, s := range q.Severities {
+		if lm.Severity == s {
+			return true
+		}
+	}
+	return false
+}
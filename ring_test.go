@@ -0,0 +1,78 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// TestRing_Query tests the Query method of the Ring
+func TestRing_Query(t *testing.T) {
+	base := time.Date(2023, 1, 10, 12, 0, 0, 0, time.UTC)
+	r := NewRing(5)
+	for i := 0; i < 7; i++ {
+		var lm LogMsg
+		lm.Hostname = "host" + fmt.Sprint(i%2)
+		lm.Severity = Severity(i % 4)
+		lm.Timestamp = base.Add(time.Minute * time.Duration(i))
+		lm.Message.WriteString(fmt.Sprint(i))
+		r.Add(lm)
+	}
+	if r.Len() != 5 || r.Total() != 7 {
+		t.Fatalf("Ring => expected len 5 and total 7, got: %d/%d", r.Len(), r.Total())
+	}
+
+	tests := []struct {
+		name string
+		q    RingQuery
+		want string
+	}{
+		{"all", RingQuery{}, "23456"},
+		{"from", RingQuery{From: base.Add(time.Minute * 4)}, "456"},
+		{"to", RingQuery{To: base.Add(time.Minute * 4)}, "23"},
+		{"from and to", RingQuery{From: base.Add(time.Minute * 3), To: base.Add(time.Minute * 5)}, "34"},
+		{"host", RingQuery{Host: "HOST1"}, "35"},
+		{"severities", RingQuery{Severities: []Severity{0, 2}}, "246"},
+		{"limit", RingQuery{Limit: 2}, "56"},
+		{"combined", RingQuery{Host: "host0", Severities: []Severity{2}, Limit: 1}, "6"},
+		{"no match", RingQuery{Host: "other"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ""
+			for _, lm := range r.Query(tt.q) {
+				got += lm.Message.String()
+			}
+			if got != tt.want {
+				t.Errorf("Query() => expected: %q, got: %q", tt.want, got)
+			}
+		})
+	}
+
+	r.Reset()
+	if r.Len() != 0 || len(r.Query(RingQuery{})) != 0 {
+		t.Errorf("Reset() => expected empty Ring")
+	}
+}
+
+// TestRing_Add_copy tests that the Ring retains copies of the added messages
+func TestRing_Add_copy(t *testing.T) {
+	r := NewRing(0)
+	var lm LogMsg
+	lm.Message.WriteString("original")
+	r.Add(lm)
+	lm.Message.Reset()
+	lm.Message.WriteString("changed")
+	res := r.Query(RingQuery{})
+	if len(res) != 1 || res[0].Message.String() != "original" {
+		t.Fatalf("Add() => expected copy of the message, got: %v", res)
+	}
+	res[0].Message.Reset()
+	if r.Query(RingQuery{})[0].Message.String() != "original" {
+		t.Errorf("Query() => expected copies of the retained messages")
+	}
+}
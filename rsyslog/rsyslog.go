@@ -0,0 +1,374 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+// Package rsyslog implements the rendering of log messages with rsyslog string templates
+// (i. e. `%TIMESTAMP% %HOSTNAME% %syslogtag%%msg%\n`), so that the output templates of an
+// rsyslog configuration can be reused when migrating to a collector based on this
+// library. The rsyslog property names (i. e. %syslogtag%, %fromhost-ip% or
+// %structured-data%) and the most common property replacer options are supported.
+// See: https://www.rsyslog.com/doc/configuration/templates.html
+package rsyslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wneessen/go-parsesyslog"
+)
+
+// Templates that are built into rsyslog
+const (
+	// FileFormat is the template of rsyslog's RSYSLOG_FileFormat, which writes log files
+	// with high-precision timestamps
+	FileFormat = "%TIMESTAMP:::date-rfc3339% %HOSTNAME% %syslogtag%%msg:::sp-if-no-1st-sp%%msg:::drop-last-lf%\n"
+	// SyslogProtocol23Format is the template of rsyslog's RSYSLOG_SyslogProtocol23Format,
+	// which renders the message in RFC5424 format
+	SyslogProtocol23Format = "<%PRI%>1 %TIMESTAMP:::date-rfc3339% %HOSTNAME% %APP-NAME% %PROCID% %MSGID% " +
+		"%STRUCTURED-DATA% %msg%\n"
+	// TraditionalFileFormat is the template of rsyslog's RSYSLOG_TraditionalFileFormat,
+	// which writes log files in the traditional syslogd format
+	TraditionalFileFormat = "%TIMESTAMP% %HOSTNAME% %syslogtag%%msg:::sp-if-no-1st-sp%%msg:::drop-last-lf%\n"
+)
+
+// localIP is the fromhost-ip of messages that have not been received from an IP address
+// (i. e. via a unix socket), like rsyslog does
+const localIP = "127.0.0.1"
+
+// dateFormat represents the format of a timestamp property
+type dateFormat int
+
+// Formats of timestamp properties
+const (
+	dateRFC3164 dateFormat = iota
+	dateRFC3339
+	dateUnix
+)
+
+// rfc3339Micro is the RFC3339 layout with up to microsecond precision, as used by rsyslog
+const rfc3339Micro = "2006-01-02T15:04:05.999999Z07:00"
+
+// facilityNames are the rsyslog names of the facilities
+var facilityNames = []string{
+	"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news", "uucp", "cron", "authpriv", "ftp",
+	"ntp", "audit", "alert", "clock", "local0", "local1", "local2", "local3", "local4", "local5", "local6",
+	"local7",
+}
+
+// severityNames are the rsyslog names of the severities
+var severityNames = []string{"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug"}
+
+// properties are the supported rsyslog properties
+var properties = map[string]bool{
+	"app-name": true, "fromhost": true, "fromhost-ip": true, "hostname": true, "msg": true, "msgid": true,
+	"pri": true, "pri-text": true, "procid": true, "programname": true, "protocol-version": true,
+	"source": true, "structured-data": true, "syslogfacility": true, "syslogfacility-text": true,
+	"syslogpriority": true, "syslogpriority-text": true, "syslogseverity": true, "syslogseverity-text": true,
+	"syslogtag": true, "timegenerated": true, "timereported": true, "timestamp": true,
+}
+
+// Template is a compiled rsyslog string template. It is safe for concurrent use.
+type Template struct {
+	now   func() time.Time
+	parts []part
+}
+
+// part is either a constant text or a property of a Template
+type part struct {
+	date       dateFormat
+	dropLastLF bool
+	json       bool
+	lower      bool
+	prop       string
+	spIfNo1st  bool
+	text       string
+	upper      bool
+}
+
+// Compile compiles the given rsyslog string template. Properties are given as %name% or
+// %name:::options% with a comma separated list of property replacer options, of which
+// date-rfc3164, date-rfc3339, date-unixtimestamp, lowercase, uppercase, json,
+// sp-if-no-1st-sp and drop-last-lf are supported. Property names are case-insensitive.
+// The backslash escapes \n, \r, \t, \\ and \% are supported in the constant text.
+//
+// If the template uses an unknown property or option, or the substring selection of the
+// property replacer (i. e. %msg:1:32%), parsesyslog.ErrInvalidTemplate is returned.
+func Compile(s string) (*Template, error) {
+	t := &Template{now: time.Now}
+	var text strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\':
+			if i+1 >= len(s) {
+				text.WriteByte(c)
+				continue
+			}
+			i++
+			switch s[i] {
+			case 'n':
+				text.WriteByte('\n')
+			case 'r':
+				text.WriteByte('\r')
+			case 't':
+				text.WriteByte('\t')
+			case '\\', '%':
+				text.WriteByte(s[i])
+			default:
+				text.WriteByte(c)
+				text.WriteByte(s[i])
+			}
+		case '%':
+			e := strings.IndexByte(s[i+1:], '%')
+			if e < 0 {
+				return nil, fmt.Errorf("%w: unterminated property at position %d", parsesyslog.ErrInvalidTemplate, i)
+			}
+			p, err := compileProperty(s[i+1 : i+1+e])
+			if err != nil {
+				return nil, err
+			}
+			if text.Len() > 0 {
+				t.parts = append(t.parts, part{text: text.String()})
+				text.Reset()
+			}
+			t.parts = append(t.parts, p)
+			i += e + 1
+		default:
+			text.WriteByte(c)
+		}
+	}
+	if text.Len() > 0 {
+		t.parts = append(t.parts, part{text: text.String()})
+	}
+	return t, nil
+}
+
+// Execute renders the given LogMsg with the Template and writes it to the io.Writer. The
+// address of the sender (i. e. the RemoteAddr of the SourceInfo of the listener, which
+// may be nil) provides the %fromhost-ip% and %fromhost% properties (the latter is the
+// ResolvedHost of the LogMsg, if there is one). %timegenerated% is the time of the call
+// to Execute.
+func (t *Template) Execute(w io.Writer, lm parsesyslog.LogMsg, from net.Addr) error {
+	var buf bytes.Buffer
+	now := t.now()
+	for _, p := range t.parts {
+		if p.prop == "" {
+			buf.WriteString(p.text)
+			continue
+		}
+		buf.WriteString(p.render(property(p, lm, from, now)))
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// compileProperty compiles the given property of a template, without the enclosing
+// percent signs
+func compileProperty(s string) (part, error) {
+	f := strings.SplitN(s, ":", 4)
+	p := part{prop: strings.ToLower(f[0])}
+	if !properties[p.prop] {
+		return p, fmt.Errorf("%w: unknown property %q", parsesyslog.ErrInvalidTemplate, f[0])
+	}
+	if len(f) > 1 && (f[1] != "" || len(f) < 3 || f[2] != "") {
+		return p, fmt.Errorf("%w: substring selection of property %q is not supported",
+			parsesyslog.ErrInvalidTemplate, f[0])
+	}
+	if len(f) < 4 || f[3] == "" {
+		return p, nil
+	}
+	for _, o := range strings.Split(f[3], ",") {
+		switch strings.ToLower(strings.TrimSpace(o)) {
+		case "date-rfc3164":
+			p.date = dateRFC3164
+		case "date-rfc3339":
+			p.date = dateRFC3339
+		case "date-unixtimestamp":
+			p.date = dateUnix
+		case "drop-last-lf":
+			p.dropLastLF = true
+		case "json":
+			p.json = true
+		case "lowercase":
+			p.lower = true
+		case "sp-if-no-1st-sp":
+			p.spIfNo1st = true
+		case "uppercase":
+			p.upper = true
+		default:
+			return p, fmt.Errorf("%w: unknown option %q of property %q", parsesyslog.ErrInvalidTemplate, o,
+				f[0])
+		}
+	}
+	return p, nil
+}
+
+// render applies the options of the property to the given value
+func (p part) render(v string) string {
+	if p.spIfNo1st {
+		if strings.HasPrefix(v, " ") {
+			return ""
+		}
+		return " "
+	}
+	if p.dropLastLF {
+		v = strings.TrimSuffix(v, "\n")
+	}
+	switch {
+	case p.lower:
+		v = strings.ToLower(v)
+	case p.upper:
+		v = strings.ToUpper(v)
+	}
+	if p.json {
+		v = jsonEscape(v)
+	}
+	return v
+}
+
+// property returns the value of the given property for the LogMsg
+func property(p part, lm parsesyslog.LogMsg, from net.Addr, now time.Time) string {
+	switch p.prop {
+	case "app-name":
+		return nilValue(lm.AppName)
+	case "fromhost":
+		if lm.ResolvedHost != "" {
+			return lm.ResolvedHost
+		}
+		return fromHostIP(from)
+	case "fromhost-ip":
+		return fromHostIP(from)
+	case "hostname", "source":
+		if lm.Hostname != "" {
+			return lm.Hostname
+		}
+		return fromHostIP(from)
+	case "msg":
+		return lm.Message.String()
+	case "msgid":
+		return nilValue(lm.MsgID)
+	case "pri":
+		return strconv.Itoa(int(lm.Priority))
+	case "pri-text":
+		return name(facilityNames, int(lm.Facility)) + "." + name(severityNames, int(lm.Severity))
+	case "procid":
+		return nilValue(lm.ProcID)
+	case "programname":
+		return lm.AppName
+	case "protocol-version":
+		return strconv.Itoa(int(lm.ProtoVersion))
+	case "structured-data":
+		return structuredData(lm.StructuredData)
+	case "syslogfacility":
+		return strconv.Itoa(int(lm.Facility))
+	case "syslogfacility-text":
+		return name(facilityNames, int(lm.Facility))
+	case "syslogpriority", "syslogseverity":
+		return strconv.Itoa(int(lm.Severity))
+	case "syslogpriority-text", "syslogseverity-text":
+		return name(severityNames, int(lm.Severity))
+	case "syslogtag":
+		return syslogTag(lm)
+	case "timegenerated":
+		return formatDate(now, p.date)
+	case "timereported", "timestamp":
+		if lm.Timestamp.IsZero() {
+			return formatDate(now, p.date)
+		}
+		return formatDate(lm.Timestamp, p.date)
+	}
+	return ""
+}
+
+// formatDate returns the given time in the given format
+func formatDate(t time.Time, f dateFormat) string {
+	switch f {
+	case dateRFC3339:
+		return t.Format(rfc3339Micro)
+	case dateUnix:
+		return strconv.FormatInt(t.Unix(), 10)
+	default:
+		return t.Format(time.Stamp)
+	}
+}
+
+// fromHostIP returns the IP address of the sender of the message
+func fromHostIP(from net.Addr) string {
+	if from == nil {
+		return localIP
+	}
+	h, _, err := net.SplitHostPort(from.String())
+	if err != nil || net.ParseIP(h) == nil {
+		return localIP
+	}
+	return h
+}
+
+// jsonEscape returns the given string escaped for use in a JSON string
+func jsonEscape(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(s); err != nil {
+		return ""
+	}
+	b := bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+	return string(b[1 : len(b)-1])
+}
+
+// name returns the name with the given index of the given names, or the index itself,
+// if there is no such name
+func name(names []string, i int) string {
+	if i < 0 || i >= len(names) {
+		return strconv.Itoa(i)
+	}
+	return names[i]
+}
+
+// nilValue returns the given string, or the RFC5424 NILVALUE if it is empty
+func nilValue(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// structuredData returns the given structured data elements in RFC5424 notation, or the
+// NILVALUE if there are none
+func structuredData(sds []parsesyslog.StructuredDataElement) string {
+	if len(sds) == 0 {
+		return "-"
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+	var sb strings.Builder
+	for _, e := range sds {
+		sb.WriteByte('[')
+		sb.WriteString(e.ID)
+		for _, p := range e.Param {
+			sb.WriteByte(' ')
+			sb.WriteString(p.Name)
+			sb.WriteString(`="`)
+			_, _ = r.WriteString(&sb, p.Value)
+			sb.WriteByte('"')
+		}
+		sb.WriteByte(']')
+	}
+	return sb.String()
+}
+
+// syslogTag returns the TAG of the message in the traditional "APP-NAME[PROCID]:"
+// notation
+func syslogTag(lm parsesyslog.LogMsg) string {
+	if lm.AppName == "" {
+		return ""
+	}
+	if lm.ProcID == "" {
+		return lm.AppName + ":"
+	}
+	return lm.AppName + "[" + lm.ProcID + "]:"
+}
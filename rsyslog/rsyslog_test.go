@@ -0,0 +1,135 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package rsyslog
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/wneessen/go-parsesyslog"
+	"github.com/wneessen/go-parsesyslog/rfc3164"
+	"github.com/wneessen/go-parsesyslog/rfc5424"
+)
+
+// testNow is the time that is used for %timegenerated%
+var testNow = time.Date(2023, 1, 10, 11, 37, 47, 0, time.UTC)
+
+// testSource is the address of the sender of the test messages
+var testSource net.Addr = &net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 50123}
+
+// parse parses the given message with the parser of the given type
+func parse(t *testing.T, pt parsesyslog.ParserType, s string) parsesyslog.LogMsg {
+	t.Helper()
+	p, err := parsesyslog.New(pt)
+	if err != nil {
+		t.Fatalf("failed to create new parser: %s", err)
+	}
+	lm, err := p.ParseString(s)
+	if err != nil {
+		t.Fatalf("failed to parse message: %s", err)
+	}
+	return lm
+}
+
+// TestTemplate_Execute tests rendering log messages with rsyslog templates
+func TestTemplate_Execute(t *testing.T) {
+	lm5424 := parse(t, rfc5424.Type, `<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog 1234 ID47 `+
+		`[exampleSDID@32473 iut="3" eventSource="Application"] An "application" event`)
+	lm3164 := parse(t, rfc3164.Type, "<34>Oct 11 22:14:15 mymachine su: 'su root' failed for lonvick\n")
+	empty := parsesyslog.LogMsg{Priority: 13, Facility: 1, Severity: 5}
+	empty.Message.WriteString(" starts with a space")
+
+	tests := []struct {
+		name string
+		tmpl string
+		lm   parsesyslog.LogMsg
+		want string
+	}{
+		{
+			"traditional file format", TraditionalFileFormat, lm3164,
+			"Oct 11 22:14:15 mymachine su: 'su root' failed for lonvick\n",
+		},
+		{
+			"file format", FileFormat, lm5424,
+			"2003-10-11T22:14:15.003Z mymachine.example.com evntslog[1234]: An \"application\" event\n",
+		},
+		{
+			"syslog protocol 23 format", SyslogProtocol23Format, lm5424,
+			"<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog 1234 ID47 " +
+				"[exampleSDID@32473 iut=\"3\" eventSource=\"Application\"] An \"application\" event\n",
+		},
+		{
+			"nil values", SyslogProtocol23Format, empty,
+			"<13>1 2023-01-10T11:37:47Z 192.0.2.1 - - - -  starts with a space\n",
+		},
+		{"space if no first space", "%syslogtag%%msg:::sp-if-no-1st-sp%|", empty, "|"},
+		{
+			"source", "%fromhost-ip% %FromHost% %source%", lm3164,
+			"192.0.2.1 192.0.2.1 mymachine",
+		},
+		{
+			"priority", "%pri% %pri-text% %syslogfacility% %syslogfacility-text% %syslogseverity% " +
+				"%syslogseverity-text% %syslogpriority-text:::uppercase%", lm5424,
+			"165 local4.notice 20 local4 5 notice NOTICE",
+		},
+		{
+			"dates", `%timereported:::date-unixtimestamp% %timegenerated:::date-rfc3339% %timestamp%`, lm5424,
+			"1065910455 2023-01-10T11:37:47Z Oct 11 22:14:15",
+		},
+		{"json", `{"msg":"%msg:::json%","app":"%programname:::lowercase,json%"}`, lm5424,
+			`{"msg":"An \"application\" event","app":"evntslog"}`},
+		{"escapes", `\%msg\% %protocol-version%\t\\\n`, lm5424, "%msg% 1\t\\\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tp, err := Compile(tt.tmpl)
+			if err != nil {
+				t.Fatalf("Compile() failed: %s", err)
+			}
+			tp.now = func() time.Time { return testNow }
+			var buf bytes.Buffer
+			if err := tp.Execute(&buf, tt.lm, testSource); err != nil {
+				t.Fatalf("Execute() failed: %s", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("Execute() => expected: %q, got: %q", tt.want, buf.String())
+			}
+		})
+	}
+}
+
+// TestTemplate_Execute_local tests the source properties of messages that have not been
+// received from an IP address
+func TestTemplate_Execute_local(t *testing.T) {
+	tp, err := Compile("%fromhost-ip% %fromhost%")
+	if err != nil {
+		t.Fatalf("Compile() failed: %s", err)
+	}
+	lm := parsesyslog.LogMsg{ResolvedHost: "localhost"}
+	var buf bytes.Buffer
+	for _, from := range []net.Addr{&net.UnixAddr{Name: "@", Net: "unix"}, nil} {
+		buf.Reset()
+		if err := tp.Execute(&buf, lm, from); err != nil {
+			t.Fatalf("Execute() failed: %s", err)
+		}
+		if want := "127.0.0.1 localhost"; buf.String() != want {
+			t.Errorf("Execute() => expected: %q, got: %q", want, buf.String())
+		}
+	}
+}
+
+// TestCompile_invalid tests that invalid templates are rejected
+func TestCompile_invalid(t *testing.T) {
+	for _, s := range []string{
+		"%msg", "%rawmsg%", "%%", "%msg:1:32%", "%msg:::frobnicate%", "%msg:R,ERE,1:x--end%",
+	} {
+		if _, err := Compile(s); !errors.Is(err, parsesyslog.ErrInvalidTemplate) {
+			t.Errorf("Compile(%q) => expected: %s, got: %v", s, parsesyslog.ErrInvalidTemplate, err)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxSDNameLen is the maximum length of a SD-NAME (SD-IDs and PARAM-NAMEs)
+// See: https://datatracker.ietf.org/doc/html/rfc5424#section-6.3
+const maxSDNameLen = 32
+
+// SDBuilder builds a StructuredDataElement step by step, i. e.:
+//
+//	e, err := parsesyslog.NewSDElement("meta").Param("sequenceId", "1").Element()
+//
+// The values of the params are kept unescaped, as in a parsed LogMsg. They are escaped
+// when the element is rendered by String or by the marshalers. The SD-ID and the param
+// names are validated by Element.
+type SDBuilder struct {
+	e StructuredDataElement
+}
+
+// NewSDElement returns a new SDBuilder for a structured data element with the given SD-ID
+func NewSDElement(id string) *SDBuilder {
+	return &SDBuilder{e: StructuredDataElement{ID: id}}
+}
+
+// Param adds a param with the given name and value to the element and returns the
+// SDBuilder, so that calls can be chained
+func (b *SDBuilder) Param(name, value string) *SDBuilder {
+	b.e.Param = append(b.e.Param, StructuredDataParam{Name: name, Value: value})
+	return b
+}
+
+// Element returns the StructuredDataElement. It returns ErrWrongSDFormat if the SD-ID or
+// a param name is not a valid SD-NAME (1 to 32 printable US-ASCII characters except '=',
+// SP, ']' and '"') or if a value is not valid UTF-8.
+// See: https://datatracker.ietf.org/doc/html/rfc5424#section-6.3
+func (b *SDBuilder) Element() (StructuredDataElement, error) {
+	if !isSDName(b.e.ID) {
+		return StructuredDataElement{}, fmt.Errorf("%w: invalid SD-ID %q", ErrWrongSDFormat, b.e.ID)
+	}
+	for _, p := range b.e.Param {
+		if !isSDName(p.Name) {
+			return StructuredDataElement{}, fmt.Errorf("%w: invalid PARAM-NAME %q of %q", ErrWrongSDFormat,
+				p.Name, b.e.ID)
+		}
+		if !utf8.ValidString(p.Value) {
+			return StructuredDataElement{}, fmt.Errorf("%w: PARAM-VALUE of %q is not valid UTF-8",
+				ErrWrongSDFormat, p.Name)
+		}
+	}
+	e := StructuredDataElement{ID: b.e.ID}
+	if b.e.Param != nil {
+		e.Param = append([]StructuredDataParam(nil), b.e.Param...)
+	}
+	return e, nil
+}
+
+// String returns the element as it appears in the STRUCTURED-DATA of a RFC5424 log
+// message, with the values of the params escaped (i. e. `[meta sequenceId="1"]`). It
+// does not validate the element. It satisfies the fmt.Stringer interface.
+func (b *SDBuilder) String() string {
+	var sb strings.Builder
+	writeSDElement(&sb, b.e)
+	return sb.String()
+}
+
+// isSDName returns true if the given string is a valid SD-NAME, as used for SD-IDs and
+// PARAM-NAMEs
+func isSDName(s string) bool {
+	if s == "" || len(s) > maxSDNameLen {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c < 33 || c > 126, c == '=', c == ']', c == '"':
+			return false
+		}
+	}
+	return true
+}
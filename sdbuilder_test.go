@@ -0,0 +1,71 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestSDBuilder tests building structured data elements with the SDBuilder
+func TestSDBuilder(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *SDBuilder
+		want    StructuredDataElement
+		wantStr string
+		err     error
+	}{
+		{
+			"element without params", NewSDElement("meta"), StructuredDataElement{ID: "meta"}, "[meta]",
+			nil,
+		},
+		{
+			"element with params", NewSDElement("meta").Param("sequenceId", "1").Param("sysUpTime", "37"),
+			StructuredDataElement{ID: "meta", Param: []StructuredDataParam{
+				{Name: "sequenceId", Value: "1"}, {Name: "sysUpTime", Value: "37"},
+			}},
+			`[meta sequenceId="1" sysUpTime="37"]`, nil,
+		},
+		{
+			"escaped value", NewSDElement("id@32473").Param("path", `C:\ "x" [y]`),
+			StructuredDataElement{ID: "id@32473", Param: []StructuredDataParam{{Name: "path", Value: `C:\ "x" [y]`}}},
+			`[id@32473 path="C:\\ \"x\" [y\]"]`, nil,
+		},
+		{"empty SD-ID", NewSDElement(""), StructuredDataElement{}, "[]", ErrWrongSDFormat},
+		{
+			"SD-ID with space", NewSDElement("my id"), StructuredDataElement{}, "[my id]",
+			ErrWrongSDFormat,
+		},
+		{
+			"SD-ID too long", NewSDElement(strings.Repeat("x", 33)), StructuredDataElement{},
+			"[" + strings.Repeat("x", 33) + "]", ErrWrongSDFormat,
+		},
+		{
+			"invalid param name", NewSDElement("meta").Param("a=b", "1"), StructuredDataElement{},
+			`[meta a=b="1"]`, ErrWrongSDFormat,
+		},
+		{
+			"invalid UTF-8 value", NewSDElement("meta").Param("a", "\xff"), StructuredDataElement{},
+			"[meta a=\"\xff\"]", ErrWrongSDFormat,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := tt.builder.Element()
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Element() => expected error: %v, got: %v", tt.err, err)
+			}
+			if !reflect.DeepEqual(e, tt.want) {
+				t.Errorf("Element() => expected: %+v, got: %+v", tt.want, e)
+			}
+			if s := tt.builder.String(); s != tt.wantStr {
+				t.Errorf("String() => expected: %s, got: %s", tt.wantStr, s)
+			}
+		})
+	}
+}
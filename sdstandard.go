@@ -0,0 +1,142 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"net"
+	"strconv"
+	"time"
+)
+
+// SD-IDs of the structured data elements that are registered with IANA
+// See: https://datatracker.ietf.org/doc/html/rfc5424#section-7
+const (
+	// SDIDTimeQuality is the SD-ID of the timeQuality element
+	SDIDTimeQuality = "timeQuality"
+	// SDIDOrigin is the SD-ID of the origin element
+	SDIDOrigin = "origin"
+	// SDIDMeta is the SD-ID of the meta element
+	SDIDMeta = "meta"
+)
+
+// TimeQuality represents the timeQuality structured data element, which describes the
+// time quality of the timestamp of the log message
+// See: https://datatracker.ietf.org/doc/html/rfc5424#section-7.1
+type TimeQuality struct {
+	// TZKnown is true if the sender knows its time zone
+	TZKnown bool
+	// IsSynced is true if the clock of the sender is synchronized to a reliable source
+	IsSynced bool
+	// SyncAccuracy is the maximum deviation of the clock of the sender from the source it
+	// is synchronized to. It is zero if it is not given.
+	SyncAccuracy time.Duration
+}
+
+// Origin represents the origin structured data element, which describes the originator
+// of the log message
+// See: https://datatracker.ietf.org/doc/html/rfc5424#section-7.2
+type Origin struct {
+	// IPs are the IP addresses of the originator, in wire order
+	IPs []net.IP
+	// EnterpriseID is the private enterprise number of the vendor of the software, which
+	// may be followed by dot-separated sub-identifiers (i. e. "32473.1")
+	EnterpriseID string
+	// Software is the name of the software that generated the log message
+	Software string
+	// SWVersion is the version of the software that generated the log message
+	SWVersion string
+}
+
+// Meta represents the meta structured data element, which holds meta-information about
+// the log message
+// See: https://datatracker.ietf.org/doc/html/rfc5424#section-7.3
+type Meta struct {
+	// SequenceID is the sequence number of the log message of the sender. It is zero if
+	// it is not given.
+	SequenceID int
+	// SysUpTime is the time since the sender has been started. It is zero if it is not
+	// given.
+	SysUpTime time.Duration
+	// Language is the language of the message, as language tag (i. e. "en-US")
+	Language string
+}
+
+// TimeQuality returns the decoded timeQuality structured data element of the LogMsg. The
+// returned bool is false if the LogMsg has no such element. Params with invalid values
+// are ignored.
+func (l *LogMsg) TimeQuality() (TimeQuality, bool) {
+	var tq TimeQuality
+	e, ok := l.SD(SDIDTimeQuality)
+	if !ok {
+		return tq, false
+	}
+	for _, p := range e.Param {
+		switch p.Name {
+		case "tzKnown":
+			tq.TZKnown = p.Value == "1"
+		case "isSynced":
+			tq.IsSynced = p.Value == "1"
+		case "syncAccuracy":
+			// The accuracy is given in microseconds
+			if n, err := strconv.ParseUint(p.Value, 10, 32); err == nil {
+				tq.SyncAccuracy = time.Duration(n) * time.Microsecond
+			}
+		}
+	}
+	return tq, true
+}
+
+// Origin returns the decoded origin structured data element of the LogMsg. The returned
+// bool is false if the LogMsg has no such element. Params with invalid values are
+// ignored.
+func (l *LogMsg) Origin() (Origin, bool) {
+	var o Origin
+	e, ok := l.SD(SDIDOrigin)
+	if !ok {
+		return o, false
+	}
+	for _, p := range e.Param {
+		switch p.Name {
+		case "ip":
+			if ip := net.ParseIP(p.Value); ip != nil {
+				o.IPs = append(o.IPs, ip)
+			}
+		case "enterpriseId":
+			o.EnterpriseID = p.Value
+		case "software":
+			o.Software = p.Value
+		case "swVersion":
+			o.SWVersion = p.Value
+		}
+	}
+	return o, true
+}
+
+// Meta returns the decoded meta structured data element of the LogMsg. The returned
+// bool is false if the LogMsg has no such element. Params with invalid values are
+// ignored.
+func (l *LogMsg) Meta() (Meta, bool) {
+	var m Meta
+	e, ok := l.SD(SDIDMeta)
+	if !ok {
+		return m, false
+	}
+	for _, p := range e.Param {
+		switch p.Name {
+		case "sequenceId":
+			if n, err := strconv.ParseUint(p.Value, 10, 31); err == nil && n > 0 {
+				m.SequenceID = int(n)
+			}
+		case "sysUpTime":
+			// The uptime is given in hundredths of a second, as the sysUpTime of SNMP
+			if n, err := strconv.ParseUint(p.Value, 10, 32); err == nil {
+				m.SysUpTime = time.Duration(n) * 10 * time.Millisecond
+			}
+		case "language":
+			m.Language = p.Value
+		}
+	}
+	return m, true
+}
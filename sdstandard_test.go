@@ -0,0 +1,63 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestLogMsg_standardSD tests the decoding of the IANA registered structured data
+// elements
+func TestLogMsg_standardSD(t *testing.T) {
+	lm := LogMsg{StructuredData: []StructuredDataElement{
+		{ID: SDIDTimeQuality, Param: []StructuredDataParam{
+			{Name: "tzKnown", Value: "1"}, {Name: "isSynced", Value: "1"}, {Name: "syncAccuracy", Value: "60000000"},
+		}},
+		{ID: SDIDOrigin, Param: []StructuredDataParam{
+			{Name: "ip", Value: "192.0.2.1"}, {Name: "ip", Value: "invalid"}, {Name: "ip", Value: "2001:db8::1"},
+			{Name: "enterpriseId", Value: "32473.1"}, {Name: "software", Value: "test"},
+			{Name: "swVersion", Value: "1.0"},
+		}},
+		{ID: SDIDMeta, Param: []StructuredDataParam{
+			{Name: "sequenceId", Value: "17"}, {Name: "sysUpTime", Value: "12345"}, {Name: "language", Value: "en-US"},
+		}},
+	}}
+
+	tq, ok := lm.TimeQuality()
+	wantTQ := TimeQuality{TZKnown: true, IsSynced: true, SyncAccuracy: time.Minute}
+	if !ok || tq != wantTQ {
+		t.Errorf("TimeQuality() => expected: %+v, got: %+v (%t)", wantTQ, tq, ok)
+	}
+	o, ok := lm.Origin()
+	wantO := Origin{
+		IPs:          []net.IP{net.ParseIP("192.0.2.1"), net.ParseIP("2001:db8::1")},
+		EnterpriseID: "32473.1", Software: "test", SWVersion: "1.0",
+	}
+	if !ok || !reflect.DeepEqual(o, wantO) {
+		t.Errorf("Origin() => expected: %+v, got: %+v (%t)", wantO, o, ok)
+	}
+	m, ok := lm.Meta()
+	wantM := Meta{SequenceID: 17, SysUpTime: 123450 * time.Millisecond, Language: "en-US"}
+	if !ok || m != wantM {
+		t.Errorf("Meta() => expected: %+v, got: %+v (%t)", wantM, m, ok)
+	}
+
+	invalid := LogMsg{StructuredData: []StructuredDataElement{
+		{ID: SDIDTimeQuality, Param: []StructuredDataParam{{Name: "tzKnown", Value: "yes"}, {Name: "syncAccuracy", Value: "-1"}}},
+		{ID: SDIDMeta, Param: []StructuredDataParam{{Name: "sequenceId", Value: "0"}, {Name: "sysUpTime", Value: "x"}}},
+	}}
+	if tq, ok = invalid.TimeQuality(); !ok || tq != (TimeQuality{}) {
+		t.Errorf("TimeQuality() of invalid values => expected zero value, got: %+v (%t)", tq, ok)
+	}
+	if m, ok = invalid.Meta(); !ok || m != (Meta{}) {
+		t.Errorf("Meta() of invalid values => expected zero value, got: %+v (%t)", m, ok)
+	}
+	if _, ok = invalid.Origin(); ok {
+		t.Error("Origin() of LogMsg without origin => expected false")
+	}
+}
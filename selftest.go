@@ -0,0 +1,159 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// SelfTestFailure represents a message of the self-test corpus that a Parser failed to
+// parse as expected
+type SelfTestFailure struct {
+	// Name is the name of the self-test case
+	Name string
+	// Err is the error the Parser returned, if any
+	Err error
+	// Diff holds the fields of the LogMsg that differ from the expected values
+	Diff []FieldDiff
+}
+
+// String returns a human-readable representation of the SelfTestFailure
+func (f SelfTestFailure) String() string {
+	if f.Err != nil {
+		return fmt.Sprintf("%s: %s", f.Name, f.Err)
+	}
+	d := make([]string, len(f.Diff))
+	for i := range f.Diff {
+		d[i] = f.Diff[i].String()
+	}
+	return fmt.Sprintf("%s: %s", f.Name, strings.Join(d, ", "))
+}
+
+// selfTestCase represents a log message of the self-test corpus
+type selfTestCase struct {
+	name string
+	msg  string
+	want LogMsg
+}
+
+// selfTestCorpus is the corpus of log messages SelfTest runs through a Parser. It is
+// mostly made of the examples of the RFCs.
+var selfTestCorpus = []selfTestCase{
+	{
+		"rfc3164 example 1", "<34>Oct 11 22:14:15 mymachine su: 'su root' failed for lonvick on /dev/pts/8",
+		LogMsg{
+			Type: RFC3164, Facility: 4, Hostname: "mymachine", AppName: "su",
+			Message: *bytes.NewBufferString("'su root' failed for lonvick on /dev/pts/8"),
+		},
+	},
+	{
+		"rfc3164 example 2", "<13>Feb  5 17:32:18 10.0.0.99 myapp[1234]: Use the BFG!",
+		LogMsg{
+			Type: RFC3164, Facility: 1, Hostname: "10.0.0.99", AppName: "myapp", ProcID: "1234",
+			Message: *bytes.NewBufferString("Use the BFG!"),
+		},
+	},
+	{
+		"rfc3164 example 3", "<165>Aug 24 05:34:00 mymachine myproc[10]: %% It's time to make the do-nuts. %%",
+		LogMsg{
+			Type: RFC3164, Facility: 20, Hostname: "mymachine", AppName: "myproc", ProcID: "10",
+			Message: *bytes.NewBufferString("%% It's time to make the do-nuts. %%"),
+		},
+	},
+	{
+		"rfc5424 example 1", "<34>1 2003-10-11T22:14:15.003Z mymachine.example.com su - ID47 - " +
+			"\xef\xbb\xbf'su root' failed for lonvick on /dev/pts/8",
+		LogMsg{
+			Type: RFC5424, Facility: 4, Hostname: "mymachine.example.com", AppName: "su", MsgID: "ID47",
+			Message: *bytes.NewBufferString("\xef\xbb\xbf'su root' failed for lonvick on /dev/pts/8"),
+		},
+	},
+	{
+		"rfc5424 example 2", "<165>1 2003-08-24T05:14:15.000003-07:00 192.0.2.1 myproc 8710 - - " +
+			"%% It's time to make the do-nuts.",
+		LogMsg{
+			Type: RFC5424, Facility: 20, Hostname: "192.0.2.1", AppName: "myproc", ProcID: "8710",
+			Message: *bytes.NewBufferString("%% It's time to make the do-nuts."),
+		},
+	},
+	{
+		"rfc5424 example 3", `<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog - ID47 ` +
+			`[exampleSDID@32473 iut="3" eventSource="Application" eventID="1011"]` +
+			`[examplePriority@32473 class="high"] An application event log entry...`,
+		LogMsg{
+			Type: RFC5424, Facility: 20, Hostname: "mymachine.example.com", AppName: "evntslog",
+			MsgID: "ID47", Message: *bytes.NewBufferString("An application event log entry..."),
+		},
+	},
+}
+
+// selfTestFields are the fields of the LogMsg that SelfTest compares. Other fields are
+// subject to the Options of the Parser (i. e. the severity of a SeverityMapper).
+var selfTestFields = map[string]bool{
+	"AppName": true, "Facility": true, "Hostname": true, "Message": true, "MsgID": true, "ProcID": true,
+}
+
+// SelfTest runs a built-in corpus of log messages through the given Parser and returns
+// the messages it failed to parse as expected. Services can run it at startup to
+// validate their parser configuration before they accept traffic, i. e. to detect
+// Options that reject valid messages. As a side effect, it warms up the Parser.
+//
+// The corpus contains messages of every format, of which only those formats are
+// checked that the Parser supports, which is those of which it parses at least one
+// message. Fields that the Parser leaves empty are not compared, as they might be
+// skipped on purpose (see WithFields). A Parser that supports none of the formats
+// results in a single SelfTestFailure with ErrSelfTestUnsupported. An empty result
+// means that the self-test has passed.
+func SelfTest(p Parser) []SelfTestFailure {
+	type result struct {
+		lm  LogMsg
+		err error
+	}
+	res := make([]result, len(selfTestCorpus))
+	supported := make(map[LogMsgType]bool)
+	for i, c := range selfTestCorpus {
+		res[i].lm, res[i].err = selfTestParse(p, c.msg)
+		if res[i].err == nil && res[i].lm.Type == c.want.Type {
+			supported[c.want.Type] = true
+		}
+	}
+	if len(supported) == 0 {
+		return []SelfTestFailure{{Name: "corpus", Err: ErrSelfTestUnsupported}}
+	}
+
+	var f []SelfTestFailure
+	for i, c := range selfTestCorpus {
+		if !supported[c.want.Type] {
+			continue
+		}
+		if res[i].err != nil {
+			f = append(f, SelfTestFailure{Name: c.name, Err: res[i].err})
+			continue
+		}
+		var d []FieldDiff
+		for _, fd := range Diff(c.want, res[i].lm) {
+			if selfTestFields[fd.Field] && fd.B != `""` && fd.B != "0" {
+				d = append(d, fd)
+			}
+		}
+		if len(d) > 0 {
+			f = append(f, SelfTestFailure{Name: c.name, Diff: d})
+		}
+	}
+	return f
+}
+
+// selfTestParse parses the given log message with the given Parser and turns a panic of
+// the Parser into an error
+func selfTestParse(p Parser, msg string) (lm LogMsg, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = NewPanicError(r, []byte(msg))
+		}
+	}()
+	return p.ParseString(msg)
+}
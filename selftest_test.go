@@ -0,0 +1,63 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"errors"
+	"io"
+	"testing"
+)
+
+// selfTestParser is a Parser for testing SelfTest that returns a fixed result
+type selfTestParser struct {
+	lm    LogMsg
+	err   error
+	panic bool
+}
+
+// ParseReader satisfies the Parser interface for the selfTestParser type
+func (p selfTestParser) ParseReader(io.Reader) (LogMsg, error) {
+	return p.ParseString("")
+}
+
+// ParseString satisfies the Parser interface for the selfTestParser type
+func (p selfTestParser) ParseString(string) (LogMsg, error) {
+	if p.panic {
+		panic("broken parser")
+	}
+	return p.lm, p.err
+}
+
+// TestSelfTest tests SelfTest with parsers that do not support the corpus
+func TestSelfTest(t *testing.T) {
+	tests := []struct {
+		name string
+		p    Parser
+	}{
+		{"error", selfTestParser{err: ErrWrongFormat}},
+		{"panic", selfTestParser{panic: true}},
+		{"other format", selfTestParser{lm: LogMsg{Type: "GELF"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := SelfTest(tt.p)
+			if len(f) != 1 || !errors.Is(f[0].Err, ErrSelfTestUnsupported) {
+				t.Errorf("SelfTest() => expected %s, got: %v", ErrSelfTestUnsupported, f)
+			}
+		})
+	}
+}
+
+// TestSelfTest_diff tests that SelfTest reports the differing fields of a parser that
+// supports a format of the corpus
+func TestSelfTest_diff(t *testing.T) {
+	f := SelfTest(selfTestParser{lm: LogMsg{Type: RFC3164, Facility: 4, Hostname: "mymachine"}})
+	if len(f) != 2 {
+		t.Fatalf("SelfTest() => expected 2 failures, got: %v", f)
+	}
+	if want := `rfc3164 example 2: Facility: 1 != 4, Hostname: "10.0.0.99" != "mymachine"`; f[0].String() != want {
+		t.Errorf("SelfTest() => expected: %q, got: %q", want, f[0].String())
+	}
+}
@@ -0,0 +1,215 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"fmt"
+	"time"
+	"unicode/utf8"
+)
+
+// serialField represents a single key/value pair of the generic representation of a
+// LogMsg that is used by the binary encodings. Values are of type string, []byte,
+// int64, bool or []interface{} (of map[string]interface{} for structured data).
+type serialField struct {
+	key string
+	val interface{}
+}
+
+// serialFields returns the generic representation of the LogMsg. It follows the JSON
+// schema, so all encodings share the same field names and SchemaVersion.
+func (l *LogMsg) serialFields() []serialField {
+	f := []serialField{{"schema", int64(SchemaVersion)}}
+	addStr := func(k, v string) {
+		if v != "" {
+			f = append(f, serialField{k, v})
+		}
+	}
+	addStr("app_name", l.AppName)
+	if l.ExtraBytes != 0 {
+		f = append(f, serialField{"extra_bytes", int64(l.ExtraBytes)})
+	}
+	f = append(f, serialField{"facility", int64(l.Facility)})
+	if l.HasBOM {
+		f = append(f, serialField{"has_bom", true})
+	}
+	addStr("hostname", l.Hostname)
+	if mb := l.Message.Bytes(); utf8.Valid(mb) {
+		f = append(f, serialField{"message", string(mb)})
+	} else {
+		f = append(f, serialField{"message_raw", mb})
+	}
+	addStr("msg_id", l.MsgID)
+	if l.OriginalSeverity != nil {
+		f = append(f, serialField{"original_severity", int64(*l.OriginalSeverity)})
+	}
+	f = append(f, serialField{"priority", int64(l.Priority)})
+	addStr("proc_id", l.ProcID)
+	if l.ProtoVersion != 0 {
+		f = append(f, serialField{"proto_version", int64(l.ProtoVersion)})
+	}
+	if l.Relaxed != 0 {
+		f = append(f, serialField{"relaxed", int64(l.Relaxed)})
+	}
+	addStr("resolved_host", l.ResolvedHost)
+	f = append(f, serialField{"severity", int64(l.Severity)})
+	addStr("span_id", l.SpanID)
+	if len(l.StructuredData) > 0 {
+		sd := make([]interface{}, 0, len(l.StructuredData))
+		for _, e := range l.StructuredData {
+			ps := make([]interface{}, 0, len(e.Param))
+			for _, p := range e.Param {
+				ps = append(ps, map[string]interface{}{"name": p.Name, "value": p.Value})
+			}
+			sd = append(sd, map[string]interface{}{"id": e.ID, "params": ps})
+		}
+		f = append(f, serialField{"structured_data", sd})
+	}
+	if !l.Timestamp.IsZero() {
+		f = append(f, serialField{"timestamp", l.Timestamp.Format(time.RFC3339Nano)})
+	}
+	addStr("trace_id", l.TraceID)
+	addStr("trace_state", l.TraceState)
+	if l.Truncated {
+		f = append(f, serialField{"truncated", true})
+	}
+	addStr("type", string(l.Type))
+	return f
+}
+
+// fromSerialMap sets the LogMsg to the given generic representation. Unknown keys are
+// ignored as defined by the compatibility policy of the SchemaVersion.
+func (l *LogMsg) fromSerialMap(m map[string]interface{}) error {
+	schema, err := serialInt(m, "schema")
+	if err != nil {
+		return err
+	}
+	if schema > SchemaVersion {
+		return fmt.Errorf("%w: %d (supported: %d)", ErrUnsupportedSchemaVersion, schema, SchemaVersion)
+	}
+
+	l.Reset()
+	strs := map[string]*string{
+		"app_name": &l.AppName, "hostname": &l.Hostname, "msg_id": &l.MsgID, "proc_id": &l.ProcID,
+		"resolved_host": &l.ResolvedHost, "span_id": &l.SpanID, "trace_id": &l.TraceID,
+		"trace_state": &l.TraceState,
+	}
+	for k, p := range strs {
+		if *p, err = serialString(m, k); err != nil {
+			return err
+		}
+	}
+	t, err := serialString(m, "type")
+	if err != nil {
+		return err
+	}
+	l.Type = LogMsgType(t)
+
+	var n [6]int
+	for i, k := range []string{"facility", "priority", "severity", "proto_version", "relaxed", "extra_bytes"} {
+		if n[i], err = serialInt(m, k); err != nil {
+			return err
+		}
+	}
+	l.Facility, l.Priority, l.Severity, l.ProtoVersion = Facility(n[0]), Priority(n[1]), Severity(n[2]),
+		ProtoVersion(n[3])
+	l.Relaxed, l.ExtraBytes = Relaxation(n[4]), n[5]
+	if b, ok := m["has_bom"].(bool); ok {
+		l.HasBOM = b
+	}
+	if b, ok := m["truncated"].(bool); ok {
+		l.Truncated = b
+	}
+	if _, ok := m["original_severity"]; ok {
+		v, err := serialInt(m, "original_severity")
+		if err != nil {
+			return err
+		}
+		s := Severity(v)
+		l.OriginalSeverity = &s
+	}
+
+	ts, err := serialString(m, "timestamp")
+	if err != nil {
+		return err
+	}
+	if ts != "" {
+		if l.Timestamp, err = time.Parse(time.RFC3339Nano, ts); err != nil {
+			return fmt.Errorf("%w: invalid timestamp: %s", ErrInvalidEncoding, err)
+		}
+	}
+
+	switch v := m["message"].(type) {
+	case string:
+		l.Message.WriteString(v)
+	case nil:
+		if raw, ok := m["message_raw"].([]byte); ok {
+			l.Message.Write(raw)
+		}
+	default:
+		return fmt.Errorf("%w: invalid type %T of field message", ErrInvalidEncoding, v)
+	}
+	l.MsgLength = l.Message.Len()
+
+	sd, ok := m["structured_data"].([]interface{})
+	if !ok {
+		return nil
+	}
+	for _, ev := range sd {
+		em, ok := ev.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%w: invalid type %T of structured data element", ErrInvalidEncoding, ev)
+		}
+		id, err := serialString(em, "id")
+		if err != nil {
+			return err
+		}
+		e := StructuredDataElement{ID: id}
+		ps, _ := em["params"].([]interface{})
+		for _, pv := range ps {
+			pm, ok := pv.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("%w: invalid type %T of structured data param", ErrInvalidEncoding, pv)
+			}
+			var p StructuredDataParam
+			if p.Name, err = serialString(pm, "name"); err != nil {
+				return err
+			}
+			if p.Value, err = serialString(pm, "value"); err != nil {
+				return err
+			}
+			e.Param = append(e.Param, p)
+		}
+		l.StructuredData = append(l.StructuredData, e)
+	}
+	return nil
+}
+
+// serialString returns the string value of the given key. A missing key results in an
+// empty string.
+func serialString(m map[string]interface{}, k string) (string, error) {
+	switch v := m[k].(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	default:
+		return "", fmt.Errorf("%w: invalid type %T of field %s", ErrInvalidEncoding, v, k)
+	}
+}
+
+// serialInt returns the integer value of the given key. A missing key results in 0.
+func serialInt(m map[string]interface{}, k string) (int, error) {
+	switch v := m[k].(type) {
+	case nil:
+		return 0, nil
+	case int64:
+		return int(v), nil
+	case uint64:
+		return int(v), nil
+	default:
+		return 0, fmt.Errorf("%w: invalid type %T of field %s", ErrInvalidEncoding, v, k)
+	}
+}
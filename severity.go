@@ -0,0 +1,155 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultSeverityKeys are the keys a SeverityMapper looks for if no keys are given
+var DefaultSeverityKeys = []string{"level", "severity", "lvl", "loglevel"}
+
+// defaultSeverityNames maps the level names commonly used by applications and logging
+// libraries to a Severity
+var defaultSeverityNames = map[string]Severity{
+	"alert":         1,
+	"crit":          2,
+	"critical":      2,
+	"debug":         7,
+	"emerg":         0,
+	"emergency":     0,
+	"err":           3,
+	"error":         3,
+	"fatal":         2,
+	"info":          6,
+	"informational": 6,
+	"notice":        5,
+	"panic":         0,
+	"trace":         7,
+	"warn":          4,
+	"warning":       4,
+}
+
+// SeverityMapper overrides the Severity of log messages with the level an application
+// encodes in its structured data or message (i. e. "level=error"), as many applications
+// send all of their messages with the same PRI. The Severity of the PRI is preserved in
+// the OriginalSeverity field and the Priority is updated to the new Severity.
+//
+// The level is taken from the first structured data param with one of the keys of the
+// SeverityMapper. If there is none, the message is searched for the first key=value
+// pair with one of the keys. Levels can be given by name (case-insensitive) or as
+// Severity number.
+//
+// A SeverityMapper is safe for concurrent use.
+type SeverityMapper struct {
+	keys  []string
+	mu    sync.RWMutex
+	names map[string]Severity
+}
+
+// NewSeverityMapper returns a new SeverityMapper that looks for the given keys. If no
+// keys are given, DefaultSeverityKeys are used. Keys are case-sensitive.
+func NewSeverityMapper(keys ...string) *SeverityMapper {
+	if len(keys) == 0 {
+		keys = DefaultSeverityKeys
+	}
+	m := &SeverityMapper{
+		keys:  append([]string(nil), keys...),
+		names: make(map[string]Severity, len(defaultSeverityNames)),
+	}
+	for n, s := range defaultSeverityNames {
+		m.names[n] = s
+	}
+	return m
+}
+
+// Map maps the given level name (case-insensitive) to the given Severity, in addition to
+// (or replacing) the default level names
+func (m *SeverityMapper) Map(name string, s Severity) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.names[strings.ToLower(name)] = s
+}
+
+// Apply overrides the Severity of the given LogMsg with the level of its structured
+// data or message. It returns true if the Severity has been overridden. Messages
+// without a known level or with a level that equals their Severity are left unchanged.
+func (m *SeverityMapper) Apply(lm *LogMsg) bool {
+	lv, ok := m.structuredDataLevel(lm)
+	if !ok {
+		lv, ok = m.messageLevel(lm.Message.String())
+	}
+	if !ok {
+		return false
+	}
+	s, ok := m.severity(lv)
+	if !ok || s == lm.Severity {
+		return false
+	}
+	if lm.OriginalSeverity == nil {
+		orig := lm.Severity
+		lm.OriginalSeverity = &orig
+	}
+	lm.Severity = s
+	// The Facility of a log message with a PRI greater than 191 is out of range, so the
+	// Priority is encoded without PriorityFrom to keep it in line with the PRI
+	lm.Priority = Priority(lm.Facility)<<3 | Priority(s)
+	return true
+}
+
+// severity returns the Severity of the given level name or number
+func (m *SeverityMapper) severity(lv string) (Severity, bool) {
+	if n, err := strconv.Atoi(lv); err == nil {
+		return Severity(n), n >= 0 && n <= 7
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	s, ok := m.names[strings.ToLower(lv)]
+	return s, ok
+}
+
+// structuredDataLevel returns the value of the first structured data param of the
+// LogMsg with one of the keys of the SeverityMapper
+func (m *SeverityMapper) structuredDataLevel(lm *LogMsg) (string, bool) {
+	for _, e := range lm.StructuredData {
+		for _, p := range e.Param {
+			for _, k := range m.keys {
+				if p.Name == k {
+					return p.Value, true
+				}
+			}
+		}
+	}
+	return "", false
+}
+
+// messageLevel returns the value of the first key=value pair of the given message with
+// one of the keys of the SeverityMapper. Values may be enclosed in double quotes.
+func (m *SeverityMapper) messageLevel(msg string) (string, bool) {
+	for i := 0; i < len(msg); i++ {
+		if i > 0 && !isKVSpace(msg[i-1]) {
+			continue
+		}
+		for _, k := range m.keys {
+			if !strings.HasPrefix(msg[i:], k+"=") {
+				continue
+			}
+			v := msg[i+len(k)+1:]
+			if strings.HasPrefix(v, `"`) {
+				if e := strings.IndexByte(v[1:], '"'); e >= 0 {
+					return v[1 : e+1], true
+				}
+				return "", false
+			}
+			if e := strings.IndexFunc(v, func(r rune) bool { return r < 128 && isKVSpace(byte(r)) }); e >= 0 {
+				v = v[:e]
+			}
+			return v, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,101 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestSeverityMapper_Apply tests the Apply method of the SeverityMapper
+func TestSeverityMapper_Apply(t *testing.T) {
+	m := NewSeverityMapper()
+	m.Map("SEVERE", 2)
+	tests := []struct {
+		name string
+		sd   []StructuredDataElement
+		msg  string
+		want Severity
+		ok   bool
+	}{
+		{"message name", nil, "user=foo level=error failed", 3, true},
+		{"message quoted", nil, `severity="WARNING" disk almost full`, 4, true},
+		{"message number", nil, "lvl=7 details", 7, true},
+		{"message custom name", nil, "loglevel=severe", 2, true},
+		{"message first key", nil, "level=debug severity=error", 7, true},
+		{"message key inside word", nil, "sublevel=error", 6, false},
+		{"message unknown name", nil, "level=verbose", 6, false},
+		{"message invalid number", nil, "level=8", 6, false},
+		{"message unterminated quote", nil, `level="error`, 6, false},
+		{"message same severity", nil, "level=info", 6, false},
+		{"message without level", nil, "something happened", 6, false},
+		{
+			"structured data before message",
+			[]StructuredDataElement{{ID: "app@32473", Param: []StructuredDataParam{{"severity", "crit"}}}},
+			"level=error", 2, true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lm := LogMsg{Facility: 16, Priority: 134, Severity: 6, StructuredData: tt.sd}
+			lm.Message.WriteString(tt.msg)
+			if ok := m.Apply(&lm); ok != tt.ok {
+				t.Fatalf("Apply() => expected: %t, got: %t", tt.ok, ok)
+			}
+			if lm.Severity != tt.want {
+				t.Errorf("Apply() severity => expected: %d, got: %d", tt.want, lm.Severity)
+			}
+			if !tt.ok {
+				if lm.OriginalSeverity != nil || lm.Priority != 134 {
+					t.Errorf("Apply() => expected unchanged LogMsg, got: %+v", lm)
+				}
+				return
+			}
+			if lm.OriginalSeverity == nil || *lm.OriginalSeverity != 6 {
+				t.Errorf("Apply() => expected original severity 6, got: %s", diffSeverity(lm.OriginalSeverity))
+			}
+			if lm.Priority != Priority(128+int(tt.want)) {
+				t.Errorf("Apply() priority => expected: %d, got: %d", 128+int(tt.want), lm.Priority)
+			}
+		})
+	}
+
+	// Non-strict parsers accept PRIs greater than 191, whose Facility is out of range
+	lm := LogMsg{Facility: 24, Priority: 198, Severity: 6}
+	lm.Message.WriteString("level=error")
+	if !m.Apply(&lm) {
+		t.Fatalf("Apply() with PRI > 191 => expected the severity to be mapped")
+	}
+	if lm.Priority != 195 || lm.Facility != 24 || lm.Severity != 3 {
+		t.Errorf("Apply() with PRI > 191 => expected priority 195, got: %d (facility: %d, severity: %d)",
+			lm.Priority, lm.Facility, lm.Severity)
+	}
+}
+
+// TestSeverityMapper_keys tests a SeverityMapper with custom keys and the JSON round
+// trip of the original severity
+func TestSeverityMapper_keys(t *testing.T) {
+	m := NewSeverityMapper("prio")
+	var lm LogMsg
+	lm.Severity = 5
+	lm.Message.WriteString("level=error prio=alert")
+	if !m.Apply(&lm) || lm.Severity != 1 {
+		t.Fatalf("Apply() with custom key => expected severity 1, got: %d", lm.Severity)
+	}
+	if m.Apply(&lm) {
+		t.Errorf("Apply() on overridden LogMsg => expected no change")
+	}
+	b, err := json.Marshal(lm)
+	if err != nil {
+		t.Fatalf("json.Marshal() failed: %s", err)
+	}
+	var got LogMsg
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("json.Unmarshal() failed: %s", err)
+	}
+	if got.OriginalSeverity == nil || *got.OriginalSeverity != 5 {
+		t.Errorf("json round trip => expected original severity 5, got: %s", diffSeverity(got.OriginalSeverity))
+	}
+}
@@ -0,0 +1,159 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultSkewThreshold is the minimum clock skew of a source that a SkewEstimator
+	// annotates or corrects, if no threshold is given
+	DefaultSkewThreshold = time.Second * 2
+	// SkewSDID is the SD-ID of the structured data element that holds the clock skew
+	// annotation of a message
+	SkewSDID = "skew@32473"
+	// skewMinSamples is the amount of samples of a source needed for an estimate
+	skewMinSamples = 3
+	// skewSamples is the amount of recent samples per source an estimate is based on
+	skewSamples = 16
+)
+
+// SkewEstimator estimates the clock skew of every source by comparing the timestamps of
+// its messages with the time they have been received. Messages of sources with drifting
+// clocks can then be annotated with the skew or corrected, which improves the accuracy
+// of timelines across devices.
+//
+// The estimate of a source is the median of the offsets of its recent messages, so that
+// single messages with an unusual delay (i. e. messages that have been spooled by a
+// relay) do not distort it. The offset includes the transmission delay, which is why
+// skews below the threshold of the SkewEstimator are ignored.
+//
+// A SkewEstimator is safe for concurrent use.
+type SkewEstimator struct {
+	mu        sync.Mutex
+	now       func() time.Time
+	sources   map[string]*skewSource
+	threshold time.Duration
+}
+
+// skewSource represents the recent offsets of a single source
+type skewSource struct {
+	next    int
+	offsets []time.Duration
+}
+
+// NewSkewEstimator returns a new SkewEstimator that annotates and corrects skews of at
+// least the given threshold. If the threshold is 0 or negative, DefaultSkewThreshold is
+// used.
+func NewSkewEstimator(threshold time.Duration) *SkewEstimator {
+	if threshold <= 0 {
+		threshold = DefaultSkewThreshold
+	}
+	return &SkewEstimator{
+		now:       time.Now,
+		sources:   make(map[string]*skewSource),
+		threshold: threshold,
+	}
+}
+
+// Observe accounts the offset between the Timestamp of the given LogMsg and the current
+// time for the given source. It needs to be called when the message is received. The
+// source can be any string that identifies the sender, i. e. the remote address or the
+// hostname. Messages without Timestamp are ignored.
+func (e *SkewEstimator) Observe(source string, lm *LogMsg) {
+	if lm.Timestamp.IsZero() {
+		return
+	}
+	off := lm.Timestamp.Sub(e.now())
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	s, ok := e.sources[source]
+	if !ok {
+		s = &skewSource{offsets: make([]time.Duration, 0, skewSamples)}
+		e.sources[source] = s
+	}
+	if len(s.offsets) < skewSamples {
+		s.offsets = append(s.offsets, off)
+		return
+	}
+	s.offsets[s.next] = off
+	s.next = (s.next + 1) % skewSamples
+}
+
+// Skew returns the estimated clock skew of the given source. A positive skew means
+// that the clock of the source is ahead. The returned bool is false if there are not
+// enough samples of the source for an estimate yet.
+func (e *SkewEstimator) Skew(source string) (time.Duration, bool) {
+	e.mu.Lock()
+	s, ok := e.sources[source]
+	if !ok || len(s.offsets) < skewMinSamples {
+		e.mu.Unlock()
+		return 0, false
+	}
+	offs := append([]time.Duration(nil), s.offsets...)
+	e.mu.Unlock()
+	sort.Slice(offs, func(i, j int) bool { return offs[i] < offs[j] })
+	m := len(offs) / 2
+	if len(offs)%2 == 0 {
+		return offs[m-1] + (offs[m]-offs[m-1])/2, true
+	}
+	return offs[m], true
+}
+
+// Annotate adds a structured data element with the SD-ID SkewSDID and the estimated
+// skew of the given source as "offset" param (i. e. "-3m2s") to the given LogMsg, if
+// the skew reaches the threshold. It returns true if the LogMsg has been annotated.
+func (e *SkewEstimator) Annotate(source string, lm *LogMsg) bool {
+	sk, ok := e.relevantSkew(source)
+	if !ok {
+		return false
+	}
+	lm.StructuredData = append(lm.StructuredData, StructuredDataElement{
+		ID: SkewSDID, Param: []StructuredDataParam{{Name: "offset", Value: sk.String()}},
+	})
+	return true
+}
+
+// Correct subtracts the estimated skew of the given source from the Timestamp of the
+// given LogMsg, if the skew reaches the threshold. Like Annotate, it adds the skew as
+// structured data element, along with the original timestamp as "original" param. It
+// returns true if the LogMsg has been corrected.
+func (e *SkewEstimator) Correct(source string, lm *LogMsg) bool {
+	if lm.Timestamp.IsZero() {
+		return false
+	}
+	sk, ok := e.relevantSkew(source)
+	if !ok {
+		return false
+	}
+	lm.StructuredData = append(lm.StructuredData, StructuredDataElement{
+		ID: SkewSDID, Param: []StructuredDataParam{
+			{Name: "offset", Value: sk.String()},
+			{Name: "original", Value: lm.Timestamp.Format(time.RFC3339Nano)},
+		},
+	})
+	lm.Timestamp = lm.Timestamp.Add(-sk)
+	return true
+}
+
+// Reset removes the samples of all sources
+func (e *SkewEstimator) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.sources = make(map[string]*skewSource)
+}
+
+// relevantSkew returns the estimated skew of the given source, if it reaches the
+// threshold
+func (e *SkewEstimator) relevantSkew(source string) (time.Duration, bool) {
+	sk, ok := e.Skew(source)
+	if !ok || (sk < e.threshold && sk > -e.threshold) {
+		return 0, false
+	}
+	return sk, true
+}
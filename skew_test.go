@@ -0,0 +1,95 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"testing"
+	"time"
+)
+
+// TestSkewEstimator tests the SkewEstimator
+func TestSkewEstimator(t *testing.T) {
+	now := time.Date(2023, 1, 10, 12, 0, 0, 0, time.UTC)
+	e := NewSkewEstimator(0)
+	e.now = func() time.Time { return now }
+	observe := func(src string, offs ...time.Duration) {
+		for _, o := range offs {
+			lm := LogMsg{Timestamp: now.Add(o)}
+			e.Observe(src, &lm)
+		}
+	}
+	observe("ahead", time.Minute, time.Minute+time.Second, -time.Hour, time.Minute-time.Second)
+	observe("behind", -time.Minute*5, -time.Minute*5, -time.Minute*5)
+	observe("synced", time.Millisecond*50, time.Millisecond*80, time.Millisecond*30)
+	observe("new", time.Hour, time.Hour)
+	e.Observe("ahead", &LogMsg{})
+
+	tests := []struct {
+		src       string
+		skew      time.Duration
+		ok        bool
+		corrected bool
+	}{
+		{"ahead", time.Minute - time.Second/2, true, true},
+		{"behind", -time.Minute * 5, true, true},
+		{"synced", time.Millisecond * 50, true, false},
+		{"new", 0, false, false},
+		{"unknown", 0, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			sk, ok := e.Skew(tt.src)
+			if ok != tt.ok || sk != tt.skew {
+				t.Errorf("Skew() => expected: %s/%t, got: %s/%t", tt.skew, tt.ok, sk, ok)
+			}
+
+			var lm LogMsg
+			if e.Annotate(tt.src, &lm) != tt.corrected {
+				t.Errorf("Annotate() => expected: %t", tt.corrected)
+			}
+			ts := now.Add(tt.skew)
+			lm = LogMsg{Timestamp: ts}
+			if e.Correct(tt.src, &lm) != tt.corrected {
+				t.Fatalf("Correct() => expected: %t", tt.corrected)
+			}
+			if !tt.corrected {
+				if !lm.Timestamp.Equal(ts) || len(lm.StructuredData) != 0 {
+					t.Errorf("Correct() => expected unchanged LogMsg, got: %+v", lm)
+				}
+				return
+			}
+			if !lm.Timestamp.Equal(now) {
+				t.Errorf("Correct() timestamp => expected: %s, got: %s", now, lm.Timestamp)
+			}
+			if len(lm.StructuredData) != 1 || lm.StructuredData[0].ID != SkewSDID ||
+				lm.StructuredData[0].Param[0].Value != tt.skew.String() ||
+				lm.StructuredData[0].Param[1].Value != ts.Format(time.RFC3339Nano) {
+				t.Errorf("Correct() => unexpected structured data: %+v", lm.StructuredData)
+			}
+		})
+	}
+
+	e.Reset()
+	if _, ok := e.Skew("ahead"); ok {
+		t.Errorf("Reset() => expected no estimate")
+	}
+}
+
+// TestSkewEstimator_window tests that the estimate follows the recent samples
+func TestSkewEstimator_window(t *testing.T) {
+	now := time.Now()
+	e := NewSkewEstimator(time.Second)
+	e.now = func() time.Time { return now }
+	for i := 0; i < skewSamples*2; i++ {
+		off := time.Minute
+		if i >= skewSamples {
+			off = -time.Minute
+		}
+		e.Observe("drift", &LogMsg{Timestamp: now.Add(off)})
+	}
+	if sk, _ := e.Skew("drift"); sk != -time.Minute {
+		t.Errorf("Skew() => expected: %s, got: %s", -time.Minute, sk)
+	}
+}
@@ -0,0 +1,587 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+// Package spool implements a persistent, disk-backed queue of log messages. It sits
+// between the parser and the sinks of a forwarder, so that parsed messages survive
+// sink outages and process restarts. Messages are appended to segment files and
+// remain on disk until they have been committed by the consumer, so every message is
+// delivered at least once.
+package spool
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wneessen/go-parsesyslog"
+)
+
+// DefaultSegmentSize is the default size at which a new segment file is started
+const DefaultSegmentSize = 64 * 1024 * 1024
+
+// DefaultSyncInterval is the default interval in which appended records are flushed
+// to stable storage with SyncInterval
+const DefaultSyncInterval = time.Second
+
+const (
+	// checkpointFile is the name of the file that holds the committed Position
+	checkpointFile = "checkpoint"
+	// recordHeaderLen is the length of a record header: the length of the payload and
+	// its CRC-32C checksum, both as 32 bit big endian integers
+	recordHeaderLen = 8
+	// segmentExt is the file extension of segment files
+	segmentExt = ".seg"
+)
+
+// crcTable is the CRC-32C (Castagnoli) table used for the record checksums
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// SyncPolicy defines when the appended records are flushed to stable storage (fsync)
+type SyncPolicy int
+
+// Sync policies
+const (
+	// SyncAlways flushes every record before Append returns. No acknowledged record is
+	// lost, even on power failure, at the cost of throughput.
+	SyncAlways SyncPolicy = iota
+	// SyncInterval flushes the appended records in the configured sync interval. On
+	// power failure, the records of the last interval may be lost.
+	SyncInterval
+	// SyncNever leaves flushing to the operating system, except when a segment is
+	// completed or the Spool is closed. Records survive a crash of the process, but not
+	// necessarily a power failure.
+	SyncNever
+)
+
+// Position is the position of a record in the Spool. The Position returned by Read
+// points right after the record that has been read.
+type Position struct {
+	Segment uint64
+	Offset  int64
+}
+
+// before returns true if the Position is before the given Position
+func (p Position) before(o Position) bool {
+	return p.Segment < o.Segment || (p.Segment == o.Segment && p.Offset < o.Offset)
+}
+
+// Spool is a persistent, disk-backed queue of log messages. It is safe for concurrent
+// use.
+type Spool struct {
+	avail    chan struct{}
+	closed   bool
+	commit   Position
+	dir      string
+	dirty    bool
+	done     chan struct{}
+	interval time.Duration
+	mu       sync.Mutex
+	policy   SyncPolicy
+	rf       *os.File
+	rpos     Position
+	rsize    int64
+	segs     []uint64
+	segSize  int64
+	wf       *os.File
+	wg       sync.WaitGroup
+	wpos     Position
+}
+
+// Option is a function that configures a Spool
+type Option func(*Spool)
+
+// WithSegmentSize sets the size at which a new segment file is started. Segments are
+// removed once all of their records have been committed. A single record that is
+// larger than the segment size is stored in a segment of its own.
+func WithSegmentSize(n int64) Option {
+	return func(s *Spool) {
+		if n > 0 {
+			s.segSize = n
+		}
+	}
+}
+
+// WithSyncPolicy sets the SyncPolicy of the Spool. The default is SyncAlways.
+func WithSyncPolicy(p SyncPolicy) Option {
+	return func(s *Spool) {
+		s.policy = p
+	}
+}
+
+// WithSyncInterval sets the interval in which appended records are flushed with
+// SyncInterval
+func WithSyncInterval(d time.Duration) Option {
+	return func(s *Spool) {
+		if d > 0 {
+			s.interval = d
+		}
+	}
+}
+
+// Open opens the Spool in the given directory, which is created if it does not exist.
+// Reading resumes after the last committed Position, so records that have been read
+// but not committed before a restart are read again. A record at the end of the last
+// segment that has not been written completely (i. e. because of a crash) is removed.
+func Open(dir string, opts ...Option) (*Spool, error) {
+	s := &Spool{
+		avail:    make(chan struct{}, 1),
+		dir:      dir,
+		done:     make(chan struct{}),
+		interval: DefaultSyncInterval,
+		segSize:  DefaultSegmentSize,
+	}
+	for _, o := range opts {
+		if o == nil {
+			continue
+		}
+		o(s)
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	if err := s.loadSegments(); err != nil {
+		return nil, err
+	}
+	if err := s.loadCheckpoint(); err != nil {
+		_ = s.wf.Close()
+		return nil, err
+	}
+	if s.policy == SyncInterval {
+		s.wg.Add(1)
+		go s.syncLoop()
+	}
+	return s, nil
+}
+
+// Append appends the given log message to the Spool. With SyncAlways, the record has
+// been flushed to stable storage when Append returns.
+func (s *Spool) Append(lm parsesyslog.LogMsg) error {
+	return s.AppendBatch([]parsesyslog.LogMsg{lm})
+}
+
+// AppendBatch appends the given log messages to the Spool. With SyncAlways, the
+// records are flushed to stable storage at once, before AppendBatch returns, which
+// makes it considerably faster than appending the messages one at a time. Together
+// with a listener.Sink, the messages are only acknowledged to the sender once they
+// are stored durably. If an error is returned, some of the messages may have been
+// appended nonetheless, so a sender that retransmits them causes duplicates.
+func (s *Spool) AppendBatch(batch []parsesyslog.LogMsg) error {
+	recs := make([][]byte, 0, len(batch))
+	for _, lm := range batch {
+		p, err := lm.MarshalCBOR()
+		if err != nil {
+			return err
+		}
+		rec := make([]byte, recordHeaderLen+len(p))
+		binary.BigEndian.PutUint32(rec[0:4], uint32(len(p)))
+		binary.BigEndian.PutUint32(rec[4:8], crc32.Checksum(p, crcTable))
+		copy(rec[recordHeaderLen:], p)
+		recs = append(recs, rec)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return parsesyslog.ErrSpoolClosed
+	}
+	for _, rec := range recs {
+		if s.wpos.Offset > 0 && s.wpos.Offset+int64(len(rec)) > s.segSize {
+			if err := s.rotate(); err != nil {
+				return err
+			}
+		}
+		if _, err := s.wf.Write(rec); err != nil {
+			// Remove a partially written record, so that the segment stays readable
+			_ = s.wf.Truncate(s.wpos.Offset)
+			return err
+		}
+		s.wpos.Offset += int64(len(rec))
+		s.dirty = true
+		select {
+		case s.avail <- struct{}{}:
+		default:
+		}
+	}
+	if s.policy == SyncAlways && s.dirty {
+		if err := s.wf.Sync(); err != nil {
+			return err
+		}
+		s.dirty = false
+	}
+	return nil
+}
+
+// Read returns the next log message of the Spool and the Position right after it,
+// which is to be passed to Commit once the message has been delivered. If the Spool
+// is empty, Read blocks until a message is appended, the context is done or the Spool
+// is closed.
+//
+// A corrupted record results in parsesyslog.ErrCorruptSpool. Since the records that
+// follow it can not be located, the remainder of its segment is skipped.
+func (s *Spool) Read(ctx context.Context) (parsesyslog.LogMsg, Position, error) {
+	for {
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			return parsesyslog.LogMsg{}, Position{}, parsesyslog.ErrSpoolClosed
+		}
+		if s.rpos.before(s.wpos) {
+			lm, pos, err := s.readRecord()
+			s.mu.Unlock()
+			if errors.Is(err, io.EOF) {
+				continue
+			}
+			return lm, pos, err
+		}
+		s.mu.Unlock()
+
+		select {
+		case <-s.avail:
+		case <-s.done:
+		case <-ctx.Done():
+			return parsesyslog.LogMsg{}, Position{}, ctx.Err()
+		}
+	}
+}
+
+// Commit marks all records up to the given Position as delivered. Segments that only
+// hold delivered records are removed. If the Position is after the read position,
+// the records in between are skipped. The committed Position is persisted, so that
+// delivered records are not read again after a restart. Positions before the
+// current committed Position are ignored.
+func (s *Spool) Commit(pos Position) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return parsesyslog.ErrSpoolClosed
+	}
+	if !s.commit.before(pos) {
+		return nil
+	}
+	if s.wpos.before(pos) {
+		return fmt.Errorf("commit position %d:%d is beyond the end of the spool", pos.Segment, pos.Offset)
+	}
+	if err := s.writeCheckpoint(pos); err != nil {
+		return err
+	}
+	s.commit = pos
+	if s.rpos.before(pos) {
+		if s.rf != nil && s.rpos.Segment != pos.Segment {
+			_ = s.rf.Close()
+			s.rf = nil
+		}
+		s.rpos = pos
+	}
+	for len(s.segs) > 0 && s.segs[0] < pos.Segment {
+		if s.rf != nil && s.rpos.Segment == s.segs[0] {
+			_ = s.rf.Close()
+			s.rf = nil
+		}
+		if err := os.Remove(s.segmentPath(s.segs[0])); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		s.segs = s.segs[1:]
+	}
+	return nil
+}
+
+// Close flushes all appended records to stable storage and closes the Spool. Pending
+// calls of Read return parsesyslog.ErrSpoolClosed.
+func (s *Spool) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	close(s.done)
+	err := s.wf.Sync()
+	if cerr := s.wf.Close(); cerr != nil && err == nil {
+		err = cerr
+	}
+	if s.rf != nil {
+		_ = s.rf.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	return err
+}
+
+// readRecord reads the record at the read position and advances it. It returns io.EOF
+// if the read position has been moved to the next segment. The caller needs to hold
+// the lock.
+func (s *Spool) readRecord() (parsesyslog.LogMsg, Position, error) {
+	var lm parsesyslog.LogMsg
+	if s.rf == nil {
+		f, err := os.Open(s.segmentPath(s.rpos.Segment))
+		if err != nil {
+			return lm, Position{}, err
+		}
+		fi, err := f.Stat()
+		if err != nil {
+			_ = f.Close()
+			return lm, Position{}, err
+		}
+		s.rf, s.rsize = f, fi.Size()
+	}
+	end := s.rsize
+	if s.rpos.Segment == s.wpos.Segment {
+		end = s.wpos.Offset
+	}
+	if s.rpos.Offset >= end {
+		return lm, Position{}, s.nextReadSegment()
+	}
+
+	p, err := readRecordAt(s.rf, s.rpos.Offset, end)
+	if errors.Is(err, parsesyslog.ErrCorruptSpool) {
+		_ = s.nextReadSegment()
+	}
+	if err != nil {
+		return lm, Position{}, err
+	}
+	s.rpos.Offset += int64(recordHeaderLen + len(p))
+	if err := lm.UnmarshalCBOR(p); err != nil {
+		return lm, s.rpos, fmt.Errorf("%w: %s", parsesyslog.ErrCorruptSpool, err)
+	}
+	return lm, s.rpos, nil
+}
+
+// nextReadSegment moves the read position to the start of the segment that follows
+// the current one. It returns io.EOF on success. The caller needs to hold the lock.
+func (s *Spool) nextReadSegment() error {
+	if s.rf != nil {
+		_ = s.rf.Close()
+		s.rf = nil
+	}
+	for _, n := range s.segs {
+		if n > s.rpos.Segment {
+			s.rpos = Position{Segment: n}
+			return io.EOF
+		}
+	}
+	// There is no segment after the write segment
+	s.rpos = s.wpos
+	return io.EOF
+}
+
+// rotate completes the current write segment and starts a new one. The caller needs
+// to hold the lock.
+func (s *Spool) rotate() error {
+	if err := s.wf.Sync(); err != nil {
+		return err
+	}
+	if err := s.wf.Close(); err != nil {
+		return err
+	}
+	s.dirty = false
+	if s.rpos.Segment == s.wpos.Segment && s.rf != nil {
+		// The read segment is complete now, so its size is final
+		fi, err := s.rf.Stat()
+		if err != nil {
+			return err
+		}
+		s.rsize = fi.Size()
+	}
+	return s.createSegment(s.wpos.Segment + 1)
+}
+
+// createSegment creates the segment with the given number and makes it the write
+// segment. The caller needs to hold the lock.
+func (s *Spool) createSegment(n uint64) error {
+	f, err := os.OpenFile(s.segmentPath(n), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if s.policy == SyncAlways {
+		if err := syncDir(s.dir); err != nil {
+			_ = f.Close()
+			return err
+		}
+	}
+	s.wf = f
+	s.wpos = Position{Segment: n}
+	s.segs = append(s.segs, n)
+	return nil
+}
+
+// loadSegments loads the existing segments and opens the last one as write segment,
+// after removing an incomplete record at its end
+func (s *Spool) loadSegments() error {
+	ents, err := os.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range ents {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), segmentExt) {
+			continue
+		}
+		n, err := strconv.ParseUint(strings.TrimSuffix(e.Name(), segmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		s.segs = append(s.segs, n)
+	}
+	sort.Slice(s.segs, func(i, j int) bool { return s.segs[i] < s.segs[j] })
+	if len(s.segs) == 0 {
+		return s.createSegment(1)
+	}
+
+	last := s.segs[len(s.segs)-1]
+	f, err := os.OpenFile(s.segmentPath(last), os.O_RDWR, 0o600)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	var off int64
+	for off < fi.Size() {
+		p, err := readRecordAt(f, off, fi.Size())
+		if err != nil {
+			break
+		}
+		off += int64(recordHeaderLen + len(p))
+	}
+	if off < fi.Size() {
+		if err := f.Truncate(off); err != nil {
+			_ = f.Close()
+			return err
+		}
+	}
+	if _, err := f.Seek(off, io.SeekStart); err != nil {
+		_ = f.Close()
+		return err
+	}
+	s.wf = f
+	s.wpos = Position{Segment: last, Offset: off}
+	return nil
+}
+
+// loadCheckpoint loads the committed Position and sets the read position to it
+func (s *Spool) loadCheckpoint() error {
+	s.rpos = Position{Segment: s.segs[0]}
+	b, err := os.ReadFile(filepath.Join(s.dir, checkpointFile))
+	if os.IsNotExist(err) {
+		s.commit = s.rpos
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(b) != 16 {
+		return fmt.Errorf("%w: invalid checkpoint", parsesyslog.ErrCorruptSpool)
+	}
+	cp := Position{
+		Segment: binary.BigEndian.Uint64(b[0:8]),
+		Offset:  int64(binary.BigEndian.Uint64(b[8:16])),
+	}
+	// The segments of the checkpoint may have been removed already, or the checkpoint
+	// may not have been persisted after newer records were lost (i. e. with SyncNever)
+	if s.rpos.before(cp) {
+		s.rpos = cp
+	}
+	if s.wpos.before(s.rpos) {
+		s.rpos = s.wpos
+	}
+	s.commit = s.rpos
+	return nil
+}
+
+// writeCheckpoint atomically replaces the checkpoint file with the given Position
+func (s *Spool) writeCheckpoint(pos Position) error {
+	b := make([]byte, 16)
+	binary.BigEndian.PutUint64(b[0:8], pos.Segment)
+	binary.BigEndian.PutUint64(b[8:16], uint64(pos.Offset))
+	tmp := filepath.Join(s.dir, checkpointFile+".tmp")
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if s.policy == SyncAlways {
+		if err := f.Sync(); err != nil {
+			_ = f.Close()
+			return err
+		}
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(s.dir, checkpointFile))
+}
+
+// syncLoop flushes the appended records in the sync interval until the Spool is closed
+func (s *Spool) syncLoop() {
+	defer s.wg.Done()
+	t := time.NewTicker(s.interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-t.C:
+			s.mu.Lock()
+			if s.dirty && !s.closed {
+				if err := s.wf.Sync(); err == nil {
+					s.dirty = false
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+// segmentPath returns the path of the segment file with the given number
+func (s *Spool) segmentPath(n uint64) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%020d%s", n, segmentExt))
+}
+
+// readRecordAt reads the payload of the record at the given offset of the file. The
+// record needs to end before the given end offset.
+func readRecordAt(f *os.File, off, end int64) ([]byte, error) {
+	h := make([]byte, recordHeaderLen)
+	if end-off < recordHeaderLen {
+		return nil, fmt.Errorf("%w: truncated record header", parsesyslog.ErrCorruptSpool)
+	}
+	if _, err := f.ReadAt(h, off); err != nil {
+		return nil, err
+	}
+	l := int64(binary.BigEndian.Uint32(h[0:4]))
+	if l > end-off-recordHeaderLen {
+		return nil, fmt.Errorf("%w: truncated record", parsesyslog.ErrCorruptSpool)
+	}
+	p := make([]byte, l)
+	if _, err := f.ReadAt(p, off+recordHeaderLen); err != nil {
+		return nil, err
+	}
+	if crc32.Checksum(p, crcTable) != binary.BigEndian.Uint32(h[4:8]) {
+		return nil, fmt.Errorf("%w: checksum mismatch", parsesyslog.ErrCorruptSpool)
+	}
+	return p, nil
+}
+
+// syncDir flushes the directory entries of the given directory to stable storage
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = d.Close() }()
+	return d.Sync()
+}
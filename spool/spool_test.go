@@ -0,0 +1,273 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package spool
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/wneessen/go-parsesyslog"
+)
+
+// testLogMsg returns a LogMsg with the given message
+func testLogMsg(msg string) parsesyslog.LogMsg {
+	lm := parsesyslog.LogMsg{
+		Type: parsesyslog.RFC5424, Priority: 165, Facility: 20, Severity: 5, ProtoVersion: 1,
+		Timestamp: time.Date(2003, 10, 11, 22, 14, 15, 3000000, time.UTC), Hostname: "mymachine.example.com",
+		AppName: "evntslog", MsgID: "ID47",
+	}
+	lm.Message.WriteString(msg)
+	lm.MsgLength = lm.Message.Len()
+	return lm
+}
+
+// openSpool opens a Spool in the given directory and closes it after the test
+func openSpool(t *testing.T, dir string, opts ...Option) *Spool {
+	t.Helper()
+	s, err := Open(dir, opts...)
+	if err != nil {
+		t.Fatalf("Open() failed: %s", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+// appendN appends n messages, numbered from the given start
+func appendN(t *testing.T, s *Spool, start, n int) {
+	t.Helper()
+	for i := start; i < start+n; i++ {
+		if err := s.Append(testLogMsg(fmt.Sprintf("message %d", i))); err != nil {
+			t.Fatalf("Append() failed: %s", err)
+		}
+	}
+}
+
+// readN reads n messages and checks that they are numbered from the given start. It
+// returns the Position of the last message.
+func readN(t *testing.T, s *Spool, start, n int) Position {
+	t.Helper()
+	var pos Position
+	for i := start; i < start+n; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+		lm, p, err := s.Read(ctx)
+		cancel()
+		if err != nil {
+			t.Fatalf("Read() failed: %s", err)
+		}
+		if want := fmt.Sprintf("message %d", i); lm.Message.String() != want {
+			t.Fatalf("Read() => expected: %s, got: %s", want, lm.Message.String())
+		}
+		pos = p
+	}
+	return pos
+}
+
+// segments returns the number of segment files in the given directory
+func segments(t *testing.T, dir string) int {
+	t.Helper()
+	m, err := filepath.Glob(filepath.Join(dir, "*"+segmentExt))
+	if err != nil {
+		t.Fatalf("failed to list segments: %s", err)
+	}
+	return len(m)
+}
+
+// TestSpool tests appending, reading and committing messages
+func TestSpool(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy SyncPolicy
+	}{
+		{"sync always", SyncAlways},
+		{"sync interval", SyncInterval},
+		{"sync never", SyncNever},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := openSpool(t, t.TempDir(), WithSyncPolicy(tt.policy), WithSyncInterval(time.Millisecond))
+			appendN(t, s, 0, 10)
+			readN(t, s, 0, 10)
+			appendN(t, s, 10, 5)
+			pos := readN(t, s, 10, 5)
+			if err := s.Commit(pos); err != nil {
+				t.Errorf("Commit() failed: %s", err)
+			}
+		})
+	}
+}
+
+// TestSpool_resume tests that reading resumes after the committed Position on reopen
+func TestSpool_resume(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open() failed: %s", err)
+	}
+	appendN(t, s, 0, 10)
+	pos := readN(t, s, 0, 4)
+	if err := s.Commit(pos); err != nil {
+		t.Fatalf("Commit() failed: %s", err)
+	}
+	// Read, but not committed
+	readN(t, s, 4, 3)
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close() failed: %s", err)
+	}
+
+	s = openSpool(t, dir)
+	readN(t, s, 4, 6)
+	appendN(t, s, 10, 1)
+	readN(t, s, 10, 1)
+}
+
+// TestSpool_segments tests that segments are rotated and removed once committed
+func TestSpool_segments(t *testing.T) {
+	dir := t.TempDir()
+	s := openSpool(t, dir, WithSegmentSize(256))
+	appendN(t, s, 0, 20)
+	n := segments(t, dir)
+	if n < 5 {
+		t.Fatalf("Append() => expected segments to be rotated, got %d segments", n)
+	}
+	pos := readN(t, s, 0, 10)
+	if err := s.Commit(pos); err != nil {
+		t.Fatalf("Commit() failed: %s", err)
+	}
+	if m := segments(t, dir); m >= n {
+		t.Errorf("Commit() => expected segments to be removed, got %d of %d", m, n)
+	}
+	pos = readN(t, s, 10, 10)
+	if err := s.Commit(pos); err != nil {
+		t.Fatalf("Commit() failed: %s", err)
+	}
+	if m := segments(t, dir); m != 1 {
+		t.Errorf("Commit() => expected only the write segment to remain, got %d", m)
+	}
+	if err := s.Commit(Position{Segment: pos.Segment + 1}); err == nil {
+		t.Errorf("Commit() beyond the end => expected error")
+	}
+}
+
+// TestSpool_truncatedTail tests that an incomplete record at the end of the last
+// segment is removed on Open
+func TestSpool_truncatedTail(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open() failed: %s", err)
+	}
+	appendN(t, s, 0, 3)
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close() failed: %s", err)
+	}
+	f, err := os.OpenFile(s.segmentPath(1), os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		t.Fatalf("failed to open segment: %s", err)
+	}
+	if _, err := f.Write([]byte{0, 0, 1, 0, 1, 2, 3, 4, 'x'}); err != nil {
+		t.Fatalf("failed to write segment: %s", err)
+	}
+	_ = f.Close()
+
+	s = openSpool(t, dir)
+	appendN(t, s, 3, 1)
+	readN(t, s, 0, 4)
+}
+
+// TestSpool_corrupt tests that a corrupted record is reported and the remainder of its
+// segment is skipped
+func TestSpool_corrupt(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir, WithSegmentSize(512))
+	if err != nil {
+		t.Fatalf("Open() failed: %s", err)
+	}
+	appendN(t, s, 0, 10)
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close() failed: %s", err)
+	}
+	// Flip a byte of the payload of the first record
+	b, err := os.ReadFile(s.segmentPath(1))
+	if err != nil {
+		t.Fatalf("failed to read segment: %s", err)
+	}
+	b[recordHeaderLen+5] ^= 0xff
+	recs := len(b) / (recordHeaderLen + int(binary.BigEndian.Uint32(b[0:4])))
+	if recs < 2 || segments(t, dir) < 2 {
+		t.Fatalf("expected multiple records and segments, got %d records in the first segment", recs)
+	}
+	if err := os.WriteFile(s.segmentPath(1), b, 0o600); err != nil {
+		t.Fatalf("failed to write segment: %s", err)
+	}
+
+	s = openSpool(t, dir, WithSegmentSize(512))
+	if _, _, err := s.Read(context.Background()); !errors.Is(err, parsesyslog.ErrCorruptSpool) {
+		t.Fatalf("Read() => expected: %s, got: %v", parsesyslog.ErrCorruptSpool, err)
+	}
+	readN(t, s, recs, 10-recs)
+}
+
+// TestSpool_Read_blocking tests that Read waits for new messages
+func TestSpool_Read_blocking(t *testing.T) {
+	s := openSpool(t, t.TempDir())
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	if _, _, err := s.Read(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Read() on empty spool => expected: %s, got: %v", context.DeadlineExceeded, err)
+	}
+
+	go func() {
+		time.Sleep(time.Millisecond * 50)
+		_ = s.Append(testLogMsg("message 0"))
+	}()
+	readN(t, s, 0, 1)
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, _, err := s.Read(context.Background())
+		errCh <- err
+	}()
+	time.Sleep(time.Millisecond * 50)
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close() failed: %s", err)
+	}
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, parsesyslog.ErrSpoolClosed) {
+			t.Errorf("Read() after Close() => expected: %s, got: %v", parsesyslog.ErrSpoolClosed, err)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("Read() did not return after Close()")
+	}
+	if err := s.Append(testLogMsg("late")); !errors.Is(err, parsesyslog.ErrSpoolClosed) {
+		t.Errorf("Append() after Close() => expected: %s, got: %v", parsesyslog.ErrSpoolClosed, err)
+	}
+	if err := s.Commit(Position{Segment: 2}); !errors.Is(err, parsesyslog.ErrSpoolClosed) {
+		t.Errorf("Commit() after Close() => expected: %s, got: %v", parsesyslog.ErrSpoolClosed, err)
+	}
+}
+
+// TestSpool_AppendBatch tests appending a batch of messages
+func TestSpool_AppendBatch(t *testing.T) {
+	dir := t.TempDir()
+	s := openSpool(t, dir, WithSegmentSize(256))
+	batch := make([]parsesyslog.LogMsg, 5)
+	for i := range batch {
+		batch[i] = testLogMsg(fmt.Sprintf("message %d", i))
+	}
+	if err := s.AppendBatch(batch); err != nil {
+		t.Fatalf("AppendBatch() failed: %s", err)
+	}
+	if segments(t, dir) < 2 {
+		t.Errorf("AppendBatch() => expected segments to be rotated within the batch")
+	}
+	readN(t, s, 0, 5)
+}
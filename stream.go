@@ -0,0 +1,138 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"bufio"
+	"errors"
+	"io"
+)
+
+// StreamParser parses consecutive log messages from a single, long-lived io.Reader,
+// like a network connection or a log file. All messages are read through the same
+// buffered reader, so that data that has been buffered while parsing a message is not
+// lost for the following messages.
+//
+// The Parser is responsible for finding the end of each message. RFC3164 messages end
+// at a LF and RFC5424 messages can be prefixed with their length (octet-counting).
+// RFC5424 messages that are separated by a trailer only (non-transparent framing) need
+// a framing Parser, like the one returned by rfc6587.NewParser. Empty lines and NUL
+// bytes between messages are skipped.
+//
+// A StreamParser is not safe for concurrent use.
+type StreamParser struct {
+	br       *bufio.Reader
+	hist     *SeverityHistogram
+	p        Parser
+	pf       *prefetchReader
+	prefetch int
+}
+
+// StreamOption is a function that configures a StreamParser
+type StreamOption func(*StreamParser)
+
+// WithStreamHistogram makes the StreamParser account every parsed log message in the
+// given SeverityHistogram
+func WithStreamHistogram(h *SeverityHistogram) StreamOption {
+	return func(s *StreamParser) {
+		s.hist = h
+	}
+}
+
+// NewStreamParser returns a new StreamParser that reads log messages from the given
+// io.Reader and parses them with the given Parser
+func NewStreamParser(p Parser, r io.Reader, opts ...StreamOption) *StreamParser {
+	s := &StreamParser{p: p}
+	for _, o := range opts {
+		if o == nil {
+			continue
+		}
+		o(s)
+	}
+	if s.prefetch > 0 {
+		s.pf = newPrefetchReader(r, s.prefetch)
+		r = s.pf
+	}
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	s.br = br
+	return s
+}
+
+// ParseStream returns a function that yields the next log message from the given
+// io.Reader on every call. Once the stream is exhausted, the function returns io.EOF.
+// It is a shortcut for the Next method of a StreamParser with the given StreamOption
+func ParseStream(p Parser, r io.Reader, opts ...StreamOption) func() (LogMsg, error) {
+	return NewStreamParser(p, r, opts...).Next
+}
+
+// Close stops the prefetching goroutine of the StreamParser, if any (see
+// WithStreamPrefetch). Close does not close the underlying io.Reader.
+func (s *StreamParser) Close() error {
+	if s.pf != nil {
+		s.pf.close()
+	}
+	return nil
+}
+
+// Next returns the next log message of the stream. If the stream ends between two
+// messages, io.EOF is returned, while a stream that ends within a message results
+// in the error of the Parser (i. e. ErrPrematureEOF).
+//
+// If parsing a message fails, the stream is not discarded and Next may be called
+// again. Whether the following messages can be parsed then depends on how much of the
+// broken message the Parser has consumed. A panic of the Parser is recovered and
+// returned as *PanicError, so that a Parser bug on exotic input does not crash the
+// program.
+func (s *StreamParser) Next() (LogMsg, error) {
+	var l LogMsg
+	err := s.NextInto(&l)
+	return l, err
+}
+
+// NextInto works like Next, but parses the next log message into the given LogMsg. If
+// the Parser is a ReusingParser, the storage of the LogMsg is reused (see
+// ReusingParser for the implications)
+func (s *StreamParser) NextInto(l *LogMsg) (err error) {
+	if err := s.skipSeparators(); err != nil {
+		return err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = NewPanicError(r, nil)
+		}
+	}()
+	if rp, ok := s.p.(ReusingParser); ok {
+		err = rp.ParseReaderInto(s.br, l)
+	} else {
+		*l, err = s.p.ParseReader(s.br)
+	}
+	if err == nil {
+		s.hist.Add(l.Facility, l.Severity)
+	}
+	return err
+}
+
+// skipSeparators discards any empty lines and NUL bytes in front of the next message.
+// It returns io.EOF if the stream ends before another message starts
+func (s *StreamParser) skipSeparators() error {
+	for {
+		b, err := s.br.Peek(1)
+		if err != nil {
+			if errors.Is(err, io.EOF) && len(b) == 0 {
+				return io.EOF
+			}
+			return err
+		}
+		switch b[0] {
+		case '\n', '\r', 0x00:
+			_, _ = s.br.Discard(1)
+		default:
+			return nil
+		}
+	}
+}
@@ -0,0 +1,157 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// lineParser is a simple Parser that returns each line as message
+type lineParser struct{}
+
+func (lineParser) ParseReader(r io.Reader) (LogMsg, error) {
+	var l LogMsg
+	s, err := r.(*bufio.Reader).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return l, err
+	}
+	if errors.Is(err, io.EOF) {
+		return l, ErrPrematureEOF
+	}
+	l.Message.WriteString(strings.TrimSuffix(s, "\n"))
+	return l, nil
+}
+
+func (p lineParser) ParseString(s string) (LogMsg, error) {
+	return p.ParseReader(bufio.NewReader(strings.NewReader(s)))
+}
+
+// TestStreamParser_Next tests the Next method of the StreamParser
+func TestStreamParser_Next(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		wantErr error
+	}{
+		{"single message", "first\n", []string{"first"}, io.EOF},
+		{"multiple messages", "first\nsecond\nthird\n", []string{"first", "second", "third"}, io.EOF},
+		{"separators", "\n\x00first\r\n\n\nsecond\n\x00", []string{"first\r", "second"}, io.EOF},
+		{"truncated", "first\nsec", []string{"first"}, ErrPrematureEOF},
+		{"empty", "", nil, io.EOF},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := ParseStream(lineParser{}, strings.NewReader(tt.input))
+			var msgs []string
+			var err error
+			for {
+				var lm LogMsg
+				lm, err = next()
+				if err != nil {
+					break
+				}
+				msgs = append(msgs, lm.Message.String())
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Next() final error => expected: %s, got: %s", tt.wantErr, err)
+			}
+			if strings.Join(msgs, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("Next() messages => expected: %q, got: %q", tt.want, msgs)
+			}
+		})
+	}
+}
+
+// TestStreamParser_withHistogram tests that the StreamParser accounts the parsed log
+// messages in the SeverityHistogram
+func TestStreamParser_withHistogram(t *testing.T) {
+	h := NewSeverityHistogram(0)
+	sp := NewStreamParser(lineParser{}, strings.NewReader("first\nsecond\nthi"), WithStreamHistogram(h))
+	for {
+		if _, err := sp.Next(); err != nil {
+			break
+		}
+	}
+	bs := h.Snapshot()
+	if len(bs) != 1 || bs[0].Facility[0] != 2 || bs[0].Severity[0] != 2 {
+		t.Errorf("Snapshot() => expected 2 parsed messages, got: %+v", bs)
+	}
+}
+
+// TestStreamParser_withPrefetch tests that a prefetching StreamParser yields the same
+// log messages as a StreamParser without prefetching
+func TestStreamParser_withPrefetch(t *testing.T) {
+	large := strings.Repeat("x", prefetchChunkSize*2+17)
+	tests := []struct {
+		name     string
+		input    string
+		prefetch int
+		want     []string
+		wantErr  error
+	}{
+		{"multiple messages", "first\nsecond\nthird\n", 1, []string{"first", "second", "third"}, io.EOF},
+		{"truncated", "first\nsec", 2, []string{"first"}, ErrPrematureEOF},
+		{"exceeds chunk", "first\n" + large + "\nlast\n", 1, []string{"first", large, "last"}, io.EOF},
+		{"empty", "", 4, nil, io.EOF},
+		{"disabled", "first\n", 0, []string{"first"}, io.EOF},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := ParseStream(lineParser{}, iotest.HalfReader(strings.NewReader(tt.input)),
+				WithStreamPrefetch(tt.prefetch))
+			var msgs []string
+			var err error
+			for {
+				var lm LogMsg
+				lm, err = next()
+				if err != nil {
+					break
+				}
+				msgs = append(msgs, lm.Message.String())
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Next() final error => expected: %s, got: %s", tt.wantErr, err)
+			}
+			if strings.Join(msgs, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("Next() messages => expected %d messages, got: %d", len(tt.want), len(msgs))
+			}
+		})
+	}
+}
+
+// TestStreamParser_Close tests that closing a prefetching StreamParser stops the
+// prefetching goroutine
+func TestStreamParser_Close(t *testing.T) {
+	pr, pw := io.Pipe()
+	sp := NewStreamParser(lineParser{}, pr, WithStreamPrefetch(1))
+	go func() {
+		_, _ = pw.Write([]byte("first\n"))
+	}()
+	lm, err := sp.Next()
+	if err != nil {
+		t.Fatalf("Next() failed: %s", err)
+	}
+	if lm.Message.String() != "first" {
+		t.Errorf("Next() => expected: %q, got: %q", "first", lm.Message.String())
+	}
+	if err := sp.Close(); err != nil {
+		t.Errorf("Close() failed: %s", err)
+	}
+	if err := pw.Close(); err != nil {
+		t.Errorf("failed to close pipe: %s", err)
+	}
+	if _, err := sp.Next(); !errors.Is(err, io.EOF) {
+		t.Errorf("Next() after Close() => expected: %s, got: %s", io.EOF, err)
+	}
+	if err := sp.Close(); err != nil {
+		t.Errorf("second Close() failed: %s", err)
+	}
+}
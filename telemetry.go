@@ -0,0 +1,177 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultTelemetryInterval is the interval Telemetry.Run emits health messages in by
+	// default
+	DefaultTelemetryInterval = time.Minute
+	// TelemetryAppName is the AppName of the health messages emitted by Telemetry
+	TelemetryAppName = "go-parsesyslog"
+	// TelemetryMsgID is the MsgID of the health messages emitted by Telemetry
+	TelemetryMsgID = "telemetry"
+	// TelemetrySDID is the SD-ID of the structured data element that holds the metrics
+	// of a health message
+	TelemetrySDID = "telemetry@32473"
+)
+
+// Telemetry periodically emits health messages about a collector (i. e. queue depths,
+// drops and error rates) as synthetic LogMsg records. These can be fed into the same
+// pipeline as the received messages, so that a collector which is only monitored via
+// its own log stream still surfaces its health.
+//
+// Every metric is emitted as a param of a structured data element with the SD-ID
+// TelemetrySDID. Counters are additionally emitted as rate per second since the
+// previous health message, using the metric name with a "_rate" suffix.
+//
+// A Telemetry is safe for concurrent use.
+type Telemetry struct {
+	emit     func(LogMsg)
+	hostname string
+	interval time.Duration
+	last     time.Time
+	metrics  []telemetryMetric
+	mu       sync.Mutex
+	now      func() time.Time
+}
+
+// telemetryMetric represents a single metric of a Telemetry
+type telemetryMetric struct {
+	counter func() uint64
+	gauge   func() int64
+	last    uint64
+	name    string
+}
+
+// NewTelemetry returns a new Telemetry that hands its health messages to the given
+// emit function. If the interval is 0 or negative, DefaultTelemetryInterval is used.
+func NewTelemetry(interval time.Duration, emit func(LogMsg)) *Telemetry {
+	if interval <= 0 {
+		interval = DefaultTelemetryInterval
+	}
+	hn, err := os.Hostname()
+	if err != nil {
+		hn = ""
+	}
+	return &Telemetry{
+		emit:     emit,
+		hostname: hn,
+		interval: interval,
+		now:      time.Now,
+	}
+}
+
+// AddGauge adds a metric whose current value is returned by the given function, like
+// the depth of a queue
+func (t *Telemetry) AddGauge(name string, fn func() int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.metrics = append(t.metrics, telemetryMetric{gauge: fn, name: name})
+}
+
+// AddCounter adds a monotonically increasing metric whose current value is returned
+// by the given function, like the amount of dropped messages
+func (t *Telemetry) AddCounter(name string, fn func() uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.metrics = append(t.metrics, telemetryMetric{counter: fn, last: fn(), name: name})
+}
+
+// AddErrorStats adds a counter named "parse_errors" with the total amount of parse
+// failures accounted by the given ErrorStats
+func (t *Telemetry) AddErrorStats(es *ErrorStats) {
+	t.AddCounter("parse_errors", func() uint64 {
+		var n uint64
+		for _, c := range es.Snapshot() {
+			n += c.Count
+		}
+		return n
+	})
+}
+
+// Tick collects all metrics and emits a health message
+func (t *Telemetry) Tick() {
+	lm := t.collect()
+	if t.emit != nil {
+		t.emit(lm)
+	}
+}
+
+// Run emits a health message in every interval until the given context is done
+func (t *Telemetry) Run(ctx context.Context) {
+	tk := time.NewTicker(t.interval)
+	defer tk.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tk.C:
+			t.Tick()
+		}
+	}
+}
+
+// collect returns a health message with the current values of all metrics
+func (t *Telemetry) collect() LogMsg {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	elapsed := t.interval
+	if !t.last.IsZero() {
+		elapsed = now.Sub(t.last)
+	}
+	t.last = now
+
+	sd := StructuredDataElement{ID: TelemetrySDID}
+	for i := range t.metrics {
+		m := &t.metrics[i]
+		if m.gauge != nil {
+			sd.Param = append(sd.Param, StructuredDataParam{Name: m.name,
+				Value: strconv.FormatInt(m.gauge(), 10)})
+			continue
+		}
+		v := m.counter()
+		rate := 0.0
+		if elapsed > 0 && v >= m.last {
+			rate = float64(v-m.last) / elapsed.Seconds()
+		}
+		m.last = v
+		sd.Param = append(sd.Param,
+			StructuredDataParam{Name: m.name, Value: strconv.FormatUint(v, 10)},
+			StructuredDataParam{Name: m.name + "_rate", Value: strconv.FormatFloat(rate, 'f', 2, 64)})
+	}
+
+	p := Syslog | Info
+	lm := LogMsg{
+		AppName:        TelemetryAppName,
+		Facility:       FacilityFromPrio(p),
+		Hostname:       t.hostname,
+		MsgID:          TelemetryMsgID,
+		Priority:       p,
+		ProcID:         strconv.Itoa(os.Getpid()),
+		ProtoVersion:   1,
+		Severity:       SeverityFromPrio(p),
+		StructuredData: []StructuredDataElement{sd},
+		Timestamp:      now,
+		Type:           RFC5424,
+	}
+	for i, sp := range sd.Param {
+		if i > 0 {
+			lm.Message.WriteByte(' ')
+		}
+		_, _ = fmt.Fprintf(&lm.Message, "%s=%s", sp.Name, sp.Value)
+	}
+	lm.MsgLength = lm.Message.Len()
+	return lm
+}
@@ -0,0 +1,85 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestTelemetry_Tick tests the Tick method of the Telemetry
+func TestTelemetry_Tick(t *testing.T) {
+	var msgs []LogMsg
+	tm := NewTelemetry(time.Minute, func(lm LogMsg) { msgs = append(msgs, lm) })
+	ts := time.Date(2023, 10, 11, 22, 14, 0, 0, time.UTC)
+	tm.now = func() time.Time { return ts }
+
+	queue := int64(5)
+	drops := uint64(10)
+	es := NewErrorStats()
+	tm.AddGauge("queue_depth", func() int64 { return queue })
+	tm.AddCounter("drops", func() uint64 { return drops })
+	tm.AddErrorStats(es)
+
+	tm.Tick()
+	ts = ts.Add(time.Second * 10)
+	drops += 50
+	queue = 2
+	es.Add("host1", ErrInvalidPrio)
+	tm.Tick()
+
+	if len(msgs) != 2 {
+		t.Fatalf("Tick() message count => expected: %d, got: %d", 2, len(msgs))
+	}
+	lm := msgs[1]
+	if lm.AppName != TelemetryAppName || lm.MsgID != TelemetryMsgID || lm.Type != RFC5424 {
+		t.Errorf("Tick() wrong header: %s/%s/%s", lm.AppName, lm.MsgID, lm.Type)
+	}
+	if lm.Priority != Syslog|Info || !lm.Timestamp.Equal(ts) {
+		t.Errorf("Tick() wrong priority or timestamp: %d/%s", lm.Priority, lm.Timestamp)
+	}
+	if len(lm.StructuredData) != 1 || lm.StructuredData[0].ID != TelemetrySDID {
+		t.Fatalf("Tick() wrong structured data: %+v", lm.StructuredData)
+	}
+	want := []StructuredDataParam{
+		{"queue_depth", "2"}, {"drops", "60"}, {"drops_rate", "5.00"},
+		{"parse_errors", "1"}, {"parse_errors_rate", "0.10"},
+	}
+	if len(lm.StructuredData[0].Param) != len(want) {
+		t.Fatalf("Tick() param count => expected: %d, got: %d", len(want), len(lm.StructuredData[0].Param))
+	}
+	for i, w := range want {
+		if lm.StructuredData[0].Param[i] != w {
+			t.Errorf("Tick() param %d => expected: %+v, got: %+v", i, w, lm.StructuredData[0].Param[i])
+		}
+	}
+	wantMsg := "queue_depth=2 drops=60 drops_rate=5.00 parse_errors=1 parse_errors_rate=0.10"
+	if lm.Message.String() != wantMsg {
+		t.Errorf("Tick() wrong message => expected: %s, got: %s", wantMsg, lm.Message.String())
+	}
+}
+
+// TestTelemetry_Run tests the Run method of the Telemetry
+func TestTelemetry_Run(t *testing.T) {
+	ch := make(chan LogMsg, 10)
+	tm := NewTelemetry(time.Millisecond*10, func(lm LogMsg) { ch <- lm })
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		tm.Run(ctx)
+		close(done)
+	}()
+	select {
+	case <-ch:
+	case <-time.After(time.Second * 5):
+		t.Errorf("Run() did not emit a health message")
+	}
+	cancel()
+	<-done
+	if NewTelemetry(0, nil).interval != DefaultTelemetryInterval {
+		t.Errorf("NewTelemetry() did not apply the default interval")
+	}
+}
@@ -0,0 +1,61 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import "crypto/tls"
+
+// ALPNProtocol is the ALPN protocol ID that is negotiated by default for syslog over TLS
+const ALPNProtocol = "syslog"
+
+// DefaultTLSMinVersion is the minimum TLS version that is used by default
+const DefaultTLSMinVersion = tls.VersionTLS12
+
+// DefaultTLSCipherSuites are the TLS 1.2 cipher suites that are used by default. Only
+// AEAD cipher suites with forward secrecy are included. The cipher suites of TLS 1.3 are
+// not configurable.
+var DefaultTLSCipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+}
+
+// DefaultTLSConfig returns a copy of the given tls.Config with the syslog TLS defaults
+// applied to all fields that are not set:
+//
+//   - MinVersion is set to DefaultTLSMinVersion
+//   - CipherSuites is set to DefaultTLSCipherSuites
+//   - NextProtos is set to ALPNProtocol
+//   - ClientSessionCache is set to an LRU cache, so that clients resume TLS sessions
+//     when they reconnect (servers support session resumption via session tickets
+//     by default)
+//
+// Fields that are set are left unchanged, so the defaults can be overridden as needed
+// (i. e. by setting NextProtos to a protocol list that does not include ALPNProtocol).
+// A nil tls.Config results in a new tls.Config with only the defaults set.
+//
+// Please note that a TLS server with NextProtos set rejects clients that offer ALPN
+// protocols, but none of NextProtos. Clients that do not use ALPN are not affected.
+func DefaultTLSConfig(cfg *tls.Config) *tls.Config {
+	c := &tls.Config{}
+	if cfg != nil {
+		c = cfg.Clone()
+	}
+	if c.MinVersion == 0 {
+		c.MinVersion = DefaultTLSMinVersion
+	}
+	if c.CipherSuites == nil {
+		c.CipherSuites = append([]uint16(nil), DefaultTLSCipherSuites...)
+	}
+	if c.NextProtos == nil {
+		c.NextProtos = []string{ALPNProtocol}
+	}
+	if c.ClientSessionCache == nil {
+		c.ClientSessionCache = tls.NewLRUClientSessionCache(0)
+	}
+	return c
+}
@@ -0,0 +1,47 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"crypto/tls"
+	"testing"
+)
+
+// TestDefaultTLSConfig tests the DefaultTLSConfig function
+func TestDefaultTLSConfig(t *testing.T) {
+	c := DefaultTLSConfig(nil)
+	if c.MinVersion != DefaultTLSMinVersion {
+		t.Errorf("DefaultTLSConfig() wrong min version => expected: %d, got: %d", DefaultTLSMinVersion, c.MinVersion)
+	}
+	if len(c.CipherSuites) != len(DefaultTLSCipherSuites) {
+		t.Errorf("DefaultTLSConfig() wrong cipher suites => expected: %v, got: %v", DefaultTLSCipherSuites,
+			c.CipherSuites)
+	}
+	if len(c.NextProtos) != 1 || c.NextProtos[0] != ALPNProtocol {
+		t.Errorf("DefaultTLSConfig() wrong ALPN protocols => expected: %s, got: %v", ALPNProtocol, c.NextProtos)
+	}
+	if c.ClientSessionCache == nil {
+		t.Errorf("DefaultTLSConfig() expected a client session cache")
+	}
+
+	// Fields that are set must not be overridden
+	cfg := &tls.Config{
+		MinVersion:   tls.VersionTLS13,
+		CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256},
+		NextProtos:   []string{"other"},
+		ServerName:   "server",
+	}
+	c = DefaultTLSConfig(cfg)
+	if c == cfg {
+		t.Errorf("DefaultTLSConfig() expected a copy of the tls.Config")
+	}
+	if c.MinVersion != tls.VersionTLS13 || len(c.CipherSuites) != 1 || c.NextProtos[0] != "other" ||
+		c.ServerName != "server" {
+		t.Errorf("DefaultTLSConfig() overrode fields that were set: %+v", c)
+	}
+	if cfg.ClientSessionCache != nil {
+		t.Errorf("DefaultTLSConfig() modified the given tls.Config")
+	}
+}
@@ -0,0 +1,93 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"time"
+)
+
+// TracePhase represents a phase of parsing a log message
+type TracePhase int
+
+// TracePhases
+const (
+	// PhaseHeader is the header of the log message, including the PRI and the octet count
+	PhaseHeader TracePhase = iota
+	// PhaseStructuredData is the structured data of the log message
+	PhaseStructuredData
+	// PhaseMessage is the MSG part of the log message, including the post-processing of
+	// the LogMsg (i. e. BodyDecoders)
+	PhaseMessage
+)
+
+// ParseTrace holds the time a parser spent in the phases of parsing a log message. Phases
+// that do not apply to the log format or that have not been reached are zero.
+type ParseTrace struct {
+	Header         time.Duration
+	StructuredData time.Duration
+	Message        time.Duration
+}
+
+// TraceFunc is called by a parser with tracing enabled (see WithTrace) after every log
+// message with the (partially) parsed LogMsg, its ParseTrace and the parse error, if any.
+// The LogMsg is only valid for the duration of the call.
+type TraceFunc func(lm *LogMsg, t ParseTrace, err error)
+
+// Tracer measures the phases of parsing a log message for a TraceFunc. Parsers call
+// Start before parsing a log message, Phase when a new phase begins and Done once the
+// log message has been parsed. All methods are no-ops if no TraceFunc is set.
+type Tracer struct {
+	fn    TraceFunc
+	last  time.Time
+	phase TracePhase
+	trace ParseTrace
+}
+
+// Total returns the total time spent on the log message
+func (t ParseTrace) Total() time.Duration {
+	return t.Header + t.StructuredData + t.Message
+}
+
+// Start resets the Tracer and begins the PhaseHeader of a new log message, which is
+// handed to the given TraceFunc by Done
+func (t *Tracer) Start(fn TraceFunc) {
+	*t = Tracer{fn: fn}
+	if fn != nil {
+		t.last = time.Now()
+	}
+}
+
+// Phase ends the current phase and begins the given one
+func (t *Tracer) Phase(p TracePhase) {
+	if t.fn == nil {
+		return
+	}
+	now := time.Now()
+	t.add(now)
+	t.last, t.phase = now, p
+}
+
+// Done ends the current phase and calls the TraceFunc with the given LogMsg and error
+func (t *Tracer) Done(lm *LogMsg, err error) {
+	if t.fn == nil {
+		return
+	}
+	t.add(time.Now())
+	t.fn(lm, t.trace, err)
+}
+
+// add adds the time from the beginning of the current phase to the given time to the
+// ParseTrace
+func (t *Tracer) add(now time.Time) {
+	d := now.Sub(t.last)
+	switch t.phase {
+	case PhaseHeader:
+		t.trace.Header += d
+	case PhaseStructuredData:
+		t.trace.StructuredData += d
+	case PhaseMessage:
+		t.trace.Message += d
+	}
+}
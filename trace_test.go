@@ -0,0 +1,51 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestTracer tests that the Tracer accounts the time to the phases of a log message
+func TestTracer(t *testing.T) {
+	var got ParseTrace
+	var gotErr error
+	calls := 0
+	fn := func(_ *LogMsg, pt ParseTrace, err error) {
+		calls++
+		got, gotErr = pt, err
+	}
+
+	var tr Tracer
+	tr.Start(fn)
+	time.Sleep(time.Millisecond)
+	tr.Phase(PhaseMessage)
+	time.Sleep(time.Millisecond)
+	tr.Done(&LogMsg{}, ErrPrematureEOF)
+	if calls != 1 {
+		t.Fatalf("Done() => expected 1 call of the TraceFunc, got: %d", calls)
+	}
+	if got.Header < time.Millisecond || got.Message < time.Millisecond {
+		t.Errorf("Done() => expected header and message of at least 1ms, got: %+v", got)
+	}
+	if got.StructuredData != 0 {
+		t.Errorf("Done() => expected no structured data phase, got: %s", got.StructuredData)
+	}
+	if got.Total() != got.Header+got.Message {
+		t.Errorf("Total() => expected: %s, got: %s", got.Header+got.Message, got.Total())
+	}
+	if !errors.Is(gotErr, ErrPrematureEOF) {
+		t.Errorf("Done() => expected error: %s, got: %v", ErrPrematureEOF, gotErr)
+	}
+
+	tr.Start(nil)
+	tr.Phase(PhaseStructuredData)
+	tr.Done(&LogMsg{}, nil)
+	if calls != 1 {
+		t.Errorf("Done() without TraceFunc => expected no call, got: %d", calls-1)
+	}
+}
@@ -0,0 +1,104 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"bytes"
+	"strings"
+)
+
+const (
+	// TraceParentName is the name of the W3C trace context parent header, as it is expected
+	// in a SD param name or in the message
+	TraceParentName = "traceparent"
+	// TraceStateName is the name of the W3C trace context state header, as it is expected
+	// in a SD param name
+	TraceStateName = "tracestate"
+)
+
+// traceParentLength is the length of a version 00 traceparent value
+// See: https://www.w3.org/TR/trace-context/#traceparent-header-field-values
+const traceParentLength = 55
+
+// ExtractTraceContext looks for a W3C trace context in the structured data and the message
+// of the LogMsg and sets the TraceID, SpanID and TraceState fields accordingly. Structured
+// data params named "traceparent" and "tracestate" (in any SD element) take precedence over
+// a "traceparent" that is carried in the message, i. e. as "traceparent=<value>".
+// It returns true if a valid traceparent was found.
+// See: https://www.w3.org/TR/trace-context/
+func (l *LogMsg) ExtractTraceContext() bool {
+	for _, e := range l.StructuredData {
+		for _, p := range e.Param {
+			if strings.EqualFold(p.Name, TraceParentName) {
+				if tid, sid, ok := ParseTraceParent(p.Value); ok {
+					l.TraceID, l.SpanID = tid, sid
+				}
+			}
+			if strings.EqualFold(p.Name, TraceStateName) {
+				l.TraceState = p.Value
+			}
+		}
+	}
+	if l.TraceID != "" {
+		return true
+	}
+
+	mb := l.Message.Bytes()
+	for {
+		i := bytes.Index(mb, []byte(TraceParentName))
+		if i < 0 {
+			return false
+		}
+		mb = mb[i+len(TraceParentName):]
+		v := bytes.TrimLeft(mb, `"=: `)
+		if len(v) < traceParentLength {
+			continue
+		}
+		if tid, sid, ok := ParseTraceParent(string(v[:traceParentLength])); ok {
+			l.TraceID, l.SpanID = tid, sid
+			return true
+		}
+	}
+}
+
+// ParseTraceParent parses a W3C traceparent value and returns the trace ID and the
+// parent (span) ID. The returned bool is false if the value is not a valid traceparent.
+// See: https://www.w3.org/TR/trace-context/#traceparent-header
+func ParseTraceParent(v string) (string, string, bool) {
+	if len(v) < traceParentLength {
+		return "", "", false
+	}
+	if v[2] != '-' || v[35] != '-' || v[52] != '-' {
+		return "", "", false
+	}
+	ver, tid, sid, fl := v[0:2], v[3:35], v[36:52], v[53:55]
+	if !isLowerHex(ver) || ver == "ff" || !isLowerHex(fl) {
+		return "", "", false
+	}
+	// Future versions may append further fields, version 00 must not
+	if len(v) > traceParentLength && (ver == "00" || v[traceParentLength] != '-') {
+		return "", "", false
+	}
+	if !isLowerHex(tid) || !isLowerHex(sid) || isZeroHex(tid) || isZeroHex(sid) {
+		return "", "", false
+	}
+	return tid, sid, true
+}
+
+// isLowerHex returns true if s consists only of lowercase hexadecimal characters
+func isLowerHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// isZeroHex returns true if s consists only of '0' characters
+func isZeroHex(s string) bool {
+	return strings.Trim(s, "0") == ""
+}
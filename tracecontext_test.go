@@ -0,0 +1,86 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import "testing"
+
+// TestParseTraceParent tests the ParseTraceParent method
+func TestParseTraceParent(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  string
+		wantTI string
+		wantSI string
+		wantOK bool
+	}{
+		{
+			"valid", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			"4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7", true,
+		},
+		{
+			"future version with extra field", "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-foo",
+			"4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7", true,
+		},
+		{"version 00 with extra data", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-foo", "", "", false},
+		{"invalid version", "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "", "", false},
+		{"zero trace ID", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", "", "", false},
+		{"zero span ID", "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", "", "", false},
+		{"uppercase", "00-4BF92F3577B34DA6A3CE929D0E0E4736-00F067AA0BA902B7-01", "", "", false},
+		{"too short", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7", "", "", false},
+		{"empty", "", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ti, si, ok := ParseTraceParent(tt.value)
+			if ok != tt.wantOK {
+				t.Errorf("ParseTraceParent() ok => expected: %t, got: %t", tt.wantOK, ok)
+			}
+			if ti != tt.wantTI {
+				t.Errorf("ParseTraceParent() trace ID => expected: %s, got: %s", tt.wantTI, ti)
+			}
+			if si != tt.wantSI {
+				t.Errorf("ParseTraceParent() span ID => expected: %s, got: %s", tt.wantSI, si)
+			}
+		})
+	}
+}
+
+// TestLogMsg_ExtractTraceContext tests the ExtractTraceContext method of the LogMsg
+func TestLogMsg_ExtractTraceContext(t *testing.T) {
+	tp := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	tests := []struct {
+		name      string
+		sd        []StructuredDataElement
+		msg       string
+		wantOK    bool
+		wantState string
+	}{
+		{
+			"SD", []StructuredDataElement{{ID: "trace@32473", Param: []StructuredDataParam{
+				{Name: "traceparent", Value: tp}, {Name: "tracestate", Value: "congo=t61rcWkgMzE"},
+			}}}, "", true, "congo=t61rcWkgMzE",
+		},
+		{"MSG key/value", nil, "request done traceparent=" + tp + " status=200", true, ""},
+		{"MSG JSON", nil, `{"msg":"done","traceparent":"` + tp + `"}`, true, ""},
+		{"MSG invalid", nil, "traceparent=foo traceparent", false, ""},
+		{"none", nil, "Hello, World!", false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lm := LogMsg{StructuredData: tt.sd}
+			lm.Message.WriteString(tt.msg)
+			if ok := lm.ExtractTraceContext(); ok != tt.wantOK {
+				t.Errorf("ExtractTraceContext() => expected: %t, got: %t", tt.wantOK, ok)
+			}
+			if tt.wantOK && (lm.TraceID != tp[3:35] || lm.SpanID != tp[36:52]) {
+				t.Errorf("ExtractTraceContext() wrong IDs => got: %s/%s", lm.TraceID, lm.SpanID)
+			}
+			if lm.TraceState != tt.wantState {
+				t.Errorf("ExtractTraceContext() wrong state => expected: %s, got: %s", tt.wantState,
+					lm.TraceState)
+			}
+		})
+	}
+}
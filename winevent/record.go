@@ -0,0 +1,76 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package winevent
+
+import (
+	"strings"
+
+	"github.com/wneessen/go-parsesyslog"
+)
+
+// levelSeverities maps the standard levels of Windows Event Log records to the syslog
+// severity. Level 0 (LogAlways) is used by audit events (i. e. of the Security channel).
+// See: https://learn.microsoft.com/en-us/windows/win32/wes/eventmanifestschema-leveltype-complextype
+var levelSeverities = map[string]parsesyslog.Priority{
+	"0": parsesyslog.Info,
+	"1": parsesyslog.Crit,
+	"2": parsesyslog.Error,
+	"3": parsesyslog.Warning,
+	"4": parsesyslog.Info,
+	"5": parsesyslog.Debug,
+}
+
+// channelFacilities maps the channels of Windows Event Log records to the syslog facility.
+// Records of all other channels are mapped to the user facility.
+var channelFacilities = map[string]parsesyslog.Priority{
+	"Security": parsesyslog.AuthPriv,
+	"System":   parsesyslog.Daemon,
+}
+
+// FromXML converts the given Windows Event Log record in its XML representation (i. e.
+// as rendered by EvtRender) into a LogMsg of the RFC5424 type. The fields of the record
+// are decoded like Decode does. The provider of the record becomes the AppName, its
+// EventID the MsgID and its Level and Channel are mapped to the Severity and Facility
+// (the Security channel to AuthPriv, the System channel to Daemon and all others to
+// User). The record itself is kept as Message. Records without an EventID are rejected
+// with parsesyslog.ErrWrongFormat.
+func FromXML(rec []byte) (parsesyslog.LogMsg, error) {
+	lm := parsesyslog.LogMsg{Type: parsesyslog.RFC5424, ProtoVersion: 1}
+	lm.Message.Write(rec)
+	if !Decode(&lm) {
+		return lm, parsesyslog.ErrWrongFormat
+	}
+	lm.MsgLength = lm.Message.Len()
+
+	sev, ok := levelSeverities[param(lm, "Level")]
+	if !ok {
+		sev = parsesyslog.Info
+	}
+	fac, ok := channelFacilities[param(lm, "Channel")]
+	if !ok {
+		fac = parsesyslog.User
+	}
+	lm.Facility, lm.Severity = parsesyslog.FacilityFromPrio(fac), parsesyslog.SeverityFromPrio(sev)
+	lm.Priority = parsesyslog.PriorityFrom(lm.Facility, lm.Severity)
+	lm.AppName = strings.ReplaceAll(param(lm, "Provider"), " ", "-")
+	lm.MsgID = param(lm, "EventID")
+	return lm, nil
+}
+
+// param returns the value of the param with the given name of the SDID element of the
+// given LogMsg
+func param(lm parsesyslog.LogMsg, name string) string {
+	for _, e := range lm.StructuredData {
+		if e.ID != SDID {
+			continue
+		}
+		for _, p := range e.Param {
+			if p.Name == name {
+				return p.Value
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,83 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package winevent
+
+import (
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/wneessen/go-parsesyslog"
+)
+
+// TestFromXML tests the FromXML function
+func TestFromXML(t *testing.T) {
+	tests := []struct {
+		name     string
+		rec      string
+		facility parsesyslog.Facility
+		severity parsesyslog.Severity
+		app      string
+		msgid    string
+		err      error
+	}{
+		{
+			"security audit", testEvent, parsesyslog.FacilityFromPrio(parsesyslog.AuthPriv),
+			parsesyslog.SeverityFromPrio(parsesyslog.Info), "Microsoft-Windows-Security-Auditing", "4624", nil,
+		},
+		{
+			"system error", strings.NewReplacer("<Level>0</Level>", "<Level>2</Level>",
+				"<Channel>Security</Channel>", "<Channel>System</Channel>", "Security-Auditing",
+				"Service Control Manager").Replace(testEvent), parsesyslog.FacilityFromPrio(parsesyslog.Daemon),
+			parsesyslog.SeverityFromPrio(parsesyslog.Error), "Microsoft-Windows-Service-Control-Manager", "4624", nil,
+		},
+		{
+			"application warning", strings.NewReplacer("<Level>0</Level>", "<Level>3</Level>",
+				"<Channel>Security</Channel>", "<Channel>Application</Channel>").Replace(testEvent),
+			parsesyslog.FacilityFromPrio(parsesyslog.User), parsesyslog.SeverityFromPrio(parsesyslog.Warning),
+			"Microsoft-Windows-Security-Auditing", "4624", nil,
+		},
+		{"no event", "<Foo/>", 0, 0, "", "", parsesyslog.ErrWrongFormat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lm, err := FromXML([]byte(tt.rec))
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("FromXML() => expected error: %v, got: %v", tt.err, err)
+			}
+			if err != nil {
+				return
+			}
+			if lm.Facility != tt.facility || lm.Severity != tt.severity {
+				t.Errorf("FromXML() => expected %s.%s, got: %s.%s", tt.facility, tt.severity, lm.Facility,
+					lm.Severity)
+			}
+			if lm.Priority != parsesyslog.Priority(tt.facility)<<3|parsesyslog.Priority(tt.severity) {
+				t.Errorf("FromXML() => unexpected priority: %d", lm.Priority)
+			}
+			if lm.AppName != tt.app || lm.MsgID != tt.msgid {
+				t.Errorf("FromXML() => expected app %q and msgid %q, got: %q and %q", tt.app, tt.msgid,
+					lm.AppName, lm.MsgID)
+			}
+			if lm.Type != parsesyslog.RFC5424 || lm.Hostname != "dc01.example.com" || lm.Timestamp.IsZero() {
+				t.Errorf("FromXML() => unexpected header: %+v", lm)
+			}
+			if lm.Message.String() != tt.rec || lm.MsgLength != len(tt.rec) {
+				t.Errorf("FromXML() => expected record to be kept as message")
+			}
+		})
+	}
+}
+
+// TestSubscribe_unsupported tests that Subscribe fails on other platforms than Windows
+func TestSubscribe_unsupported(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the Windows Event Log is supported on Windows")
+	}
+	if _, err := Subscribe("Security", ""); !errors.Is(err, parsesyslog.ErrUnsupportedPlatform) {
+		t.Errorf("Subscribe() => expected: %s, got: %v", parsesyslog.ErrUnsupportedPlatform, err)
+	}
+}
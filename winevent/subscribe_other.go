@@ -0,0 +1,32 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+//go:build !windows
+// +build !windows
+
+package winevent
+
+import (
+	"github.com/wneessen/go-parsesyslog"
+)
+
+// Subscription is a subscription to a channel of the Windows Event Log, that converts
+// the records into LogMsg. It is only available on Windows.
+type Subscription struct{}
+
+// Subscribe returns parsesyslog.ErrUnsupportedPlatform, as the Windows Event Log is
+// only available on Windows
+func Subscribe(string, string) (*Subscription, error) {
+	return nil, parsesyslog.ErrUnsupportedPlatform
+}
+
+// Next returns parsesyslog.ErrUnsupportedPlatform
+func (s *Subscription) Next() (parsesyslog.LogMsg, error) {
+	return parsesyslog.LogMsg{}, parsesyslog.ErrUnsupportedPlatform
+}
+
+// Close does nothing
+func (s *Subscription) Close() error {
+	return nil
+}
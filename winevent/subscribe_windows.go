@@ -0,0 +1,185 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+//go:build windows
+// +build windows
+
+package winevent
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"github.com/wneessen/go-parsesyslog"
+)
+
+var (
+	wevtapi          = syscall.NewLazyDLL("wevtapi.dll")
+	procEvtClose     = wevtapi.NewProc("EvtClose")
+	procEvtNext      = wevtapi.NewProc("EvtNext")
+	procEvtRender    = wevtapi.NewProc("EvtRender")
+	procEvtSubscribe = wevtapi.NewProc("EvtSubscribe")
+
+	kernel32         = syscall.NewLazyDLL("kernel32.dll")
+	procCreateEventW = kernel32.NewProc("CreateEventW")
+	procResetEvent   = kernel32.NewProc("ResetEvent")
+	procSetEvent     = kernel32.NewProc("SetEvent")
+)
+
+const (
+	// evtSubscribeToFutureEvents makes a subscription only deliver events that are
+	// logged after it has been created
+	evtSubscribeToFutureEvents = 1
+	// evtRenderEventXML renders an event as XML
+	evtRenderEventXML = 1
+	// errorNoMoreItems is returned by EvtNext if there are no more events
+	errorNoMoreItems syscall.Errno = 259
+	// batchSize is the maximum amount of events that are fetched with a single EvtNext
+	batchSize = 16
+)
+
+// Subscription is a subscription to a channel of the Windows Event Log, that converts
+// the records into LogMsg. It is only available on Windows.
+type Subscription struct {
+	buf      []uint16
+	closed   bool
+	events   [batchSize]uintptr
+	handle   uintptr
+	mu       sync.Mutex
+	pending  []uintptr
+	released bool
+	signal   uintptr
+	waiting  bool
+}
+
+// Subscribe subscribes to the records of the given channel of the Windows Event Log (i.
+// e. "Security" or "Microsoft-Windows-Sysmon/Operational") that match the given XPath
+// query (i. e. "*[System[(Level<=3)]]"). An empty query matches all records. Only records
+// that are logged after the subscription has been created are delivered.
+// See: https://learn.microsoft.com/en-us/windows/win32/api/winevt/nf-winevt-evtsubscribe
+func Subscribe(channel, query string) (*Subscription, error) {
+	if query == "" {
+		query = "*"
+	}
+	cp, err := syscall.UTF16PtrFromString(channel)
+	if err != nil {
+		return nil, err
+	}
+	qp, err := syscall.UTF16PtrFromString(query)
+	if err != nil {
+		return nil, err
+	}
+	// The manual-reset event is signaled as long as there may be records to fetch
+	sig, _, err := procCreateEventW.Call(0, 1, 1, 0)
+	if sig == 0 {
+		return nil, err
+	}
+	h, _, err := procEvtSubscribe.Call(0, sig, uintptr(unsafe.Pointer(cp)), uintptr(unsafe.Pointer(qp)), 0, 0, 0,
+		evtSubscribeToFutureEvents)
+	if h == 0 {
+		_ = syscall.CloseHandle(syscall.Handle(sig))
+		return nil, err
+	}
+	return &Subscription{buf: make([]uint16, 4096), handle: h, signal: sig}, nil
+}
+
+// Next blocks until the next record of the subscribed channel is logged and returns it
+// as LogMsg, as converted by FromXML. After Close, io.EOF is returned. Next must not be
+// called concurrently, but Close may be called while Next is blocking.
+func (s *Subscription) Next() (parsesyslog.LogMsg, error) {
+	for {
+		s.mu.Lock()
+		if s.closed {
+			s.release()
+			s.mu.Unlock()
+			return parsesyslog.LogMsg{}, io.EOF
+		}
+		if len(s.pending) > 0 {
+			h := s.pending[0]
+			s.pending = s.pending[1:]
+			rec, err := s.render(h)
+			_, _, _ = procEvtClose.Call(h)
+			s.mu.Unlock()
+			if err != nil {
+				return parsesyslog.LogMsg{}, err
+			}
+			return FromXML(rec)
+		}
+		// The event is reset before fetching, so that records which are logged after
+		// the fetch signal it again
+		_, _, _ = procResetEvent.Call(s.signal)
+		var n uint32
+		r, _, err := procEvtNext.Call(s.handle, batchSize, uintptr(unsafe.Pointer(&s.events[0])), 0, 0,
+			uintptr(unsafe.Pointer(&n)))
+		if r != 0 {
+			s.pending = s.events[:n]
+			s.mu.Unlock()
+			continue
+		}
+		if !errors.Is(err, errorNoMoreItems) {
+			s.mu.Unlock()
+			return parsesyslog.LogMsg{}, err
+		}
+		s.waiting = true
+		s.mu.Unlock()
+		_, err = syscall.WaitForSingleObject(syscall.Handle(s.signal), syscall.INFINITE)
+		s.mu.Lock()
+		s.waiting = false
+		s.mu.Unlock()
+		if err != nil {
+			return parsesyslog.LogMsg{}, err
+		}
+	}
+}
+
+// Close cancels the subscription. A blocking Next returns io.EOF.
+func (s *Subscription) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	if s.waiting {
+		// The handles are released by Next, once it stopped waiting for the event
+		_, _, _ = procSetEvent.Call(s.signal)
+		return nil
+	}
+	s.release()
+	return nil
+}
+
+// release closes the handles of the Subscription. It needs to be called with the lock
+// held and while Next is not waiting for the event.
+func (s *Subscription) release() {
+	if s.released {
+		return
+	}
+	s.released = true
+	for _, h := range s.pending {
+		_, _, _ = procEvtClose.Call(h)
+	}
+	s.pending = nil
+	_, _, _ = procEvtClose.Call(s.handle)
+	_ = syscall.CloseHandle(syscall.Handle(s.signal))
+}
+
+// render renders the event of the given handle as XML
+func (s *Subscription) render(h uintptr) ([]byte, error) {
+	for {
+		var used, props uint32
+		r, _, err := procEvtRender.Call(0, h, evtRenderEventXML, uintptr(len(s.buf)*2),
+			uintptr(unsafe.Pointer(&s.buf[0])), uintptr(unsafe.Pointer(&used)), uintptr(unsafe.Pointer(&props)))
+		if r != 0 {
+			return []byte(syscall.UTF16ToString(s.buf[:used/2])), nil
+		}
+		if !errors.Is(err, syscall.ERROR_INSUFFICIENT_BUFFER) {
+			return nil, err
+		}
+		s.buf = make([]uint16, used/2+1)
+	}
+}
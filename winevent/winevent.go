@@ -0,0 +1,108 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+// Package winevent implements the decoding of Windows Event Log records in their XML
+// representation (i. e. `<Event xmlns="..."><System>...</System><EventData>...`), as
+// they are forwarded in the message of a syslog message by agents like NXLog (to_xml())
+// or Snare. Decode is a parsesyslog.BodyDecoder that maps the System fields and the
+// EventData of the record to the params of a structured data element.
+// See: https://learn.microsoft.com/en-us/windows/win32/wes/eventschema-schema
+package winevent
+
+import (
+	"encoding/xml"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wneessen/go-parsesyslog"
+)
+
+// SDID is the SD-ID of the structured data element that holds the fields of a Windows
+// Event Log record, using the private enterprise number of Microsoft
+const SDID = "winevent@311"
+
+// eventDataPrefix is the prefix of the param names of the EventData fields
+const eventDataPrefix = "EventData."
+
+// event represents the parts of a Windows Event Log record that Decode extracts
+type event struct {
+	XMLName xml.Name `xml:"Event"`
+	System  struct {
+		Provider struct {
+			Name string `xml:"Name,attr"`
+		} `xml:"Provider"`
+		EventID     string `xml:"EventID"`
+		Level       string `xml:"Level"`
+		TimeCreated struct {
+			SystemTime string `xml:"SystemTime,attr"`
+		} `xml:"TimeCreated"`
+		EventRecordID string `xml:"EventRecordID"`
+		Channel       string `xml:"Channel"`
+		Computer      string `xml:"Computer"`
+	} `xml:"System"`
+	EventData struct {
+		Data []struct {
+			Name  string `xml:"Name,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"Data"`
+	} `xml:"EventData"`
+}
+
+// Decode decodes the Windows Event Log XML record in the message body of the LogMsg and
+// stores its fields as structured data element with the SD-ID SDID. The params are
+// "EventID", "Channel", "Provider", "Computer", "EventRecordID" and "Level", followed by
+// the EventData fields, prefixed with "EventData." (i. e. "EventData.TargetUserName").
+// Unnamed EventData fields are named by their index (i. e. "EventData.0"). Empty fields
+// are left out. If the LogMsg has no Hostname or Timestamp, they are taken from the
+// "Computer" field and the "SystemTime" of the "TimeCreated" field. The record may be
+// preceded by other text (i. e. a tag of the agent). Message bodies without an Event
+// record that has an EventID are not recognized. Decode satisfies the
+// parsesyslog.BodyDecoder type.
+func Decode(lm *parsesyslog.LogMsg) bool {
+	s := lm.Message.String()
+	i := strings.Index(s, "<Event")
+	if i < 0 {
+		return false
+	}
+	var ev event
+	if err := xml.NewDecoder(strings.NewReader(s[i:])).Decode(&ev); err != nil {
+		return false
+	}
+	sys := ev.System
+	if strings.TrimSpace(sys.EventID) == "" {
+		return false
+	}
+
+	sde := parsesyslog.StructuredDataElement{ID: SDID}
+	add := func(n, v string) {
+		if v = strings.TrimSpace(v); v != "" {
+			sde.Param = append(sde.Param, parsesyslog.StructuredDataParam{Name: n, Value: v})
+		}
+	}
+	add("EventID", sys.EventID)
+	add("Channel", sys.Channel)
+	add("Provider", sys.Provider.Name)
+	add("Computer", sys.Computer)
+	add("EventRecordID", sys.EventRecordID)
+	add("Level", sys.Level)
+	for n, d := range ev.EventData.Data {
+		name := d.Name
+		if name == "" {
+			name = strconv.Itoa(n)
+		}
+		add(eventDataPrefix+name, d.Value)
+	}
+
+	if lm.Hostname == "" {
+		lm.Hostname = strings.TrimSpace(sys.Computer)
+	}
+	if lm.Timestamp.IsZero() && sys.TimeCreated.SystemTime != "" {
+		if ts, err := time.Parse(time.RFC3339Nano, sys.TimeCreated.SystemTime); err == nil {
+			lm.Timestamp = ts
+		}
+	}
+	lm.StructuredData = append(lm.StructuredData, sde)
+	return true
+}
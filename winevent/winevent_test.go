@@ -0,0 +1,113 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package winevent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/wneessen/go-parsesyslog"
+	"github.com/wneessen/go-parsesyslog/rfc3164"
+)
+
+const testEvent = `<Event xmlns="http://schemas.microsoft.com/win/2004/08/events/event"><System>` +
+	`<Provider Name="Microsoft-Windows-Security-Auditing" Guid="{54849625-5478-4994-a5ba-3e3b0328c30d}"/>` +
+	`<EventID>4624</EventID><Version>2</Version><Level>0</Level><Task>12544</Task>` +
+	`<TimeCreated SystemTime="2023-01-10T11:37:47.1234567Z"/><EventRecordID>84231</EventRecordID>` +
+	`<Channel>Security</Channel><Computer>dc01.example.com</Computer><Security/></System>` +
+	`<EventData><Data Name="TargetUserName">jdoe</Data><Data Name="TargetDomainName">EXAMPLE</Data>` +
+	`<Data Name="LogonType">3</Data><Data Name="IpAddress">10.1.1.5</Data><Data Name="WorkstationName"></Data>` +
+	`</EventData></Event>`
+
+// TestDecode tests the Decode function
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		decoded bool
+		params  map[string]string
+	}{
+		{
+			"security event", testEvent, true, map[string]string{
+				"EventID": "4624", "Channel": "Security", "Provider": "Microsoft-Windows-Security-Auditing",
+				"Computer": "dc01.example.com", "EventRecordID": "84231", "Level": "0",
+				"EventData.TargetUserName": "jdoe", "EventData.LogonType": "3", "EventData.IpAddress": "10.1.1.5",
+			},
+		},
+		{
+			"with agent prefix", "MSWinEventLog: " + testEvent + "\n", true, map[string]string{
+				"EventID": "4624", "EventData.TargetDomainName": "EXAMPLE",
+			},
+		},
+		{
+			"unnamed event data", `<Event><System><EventID Qualifiers="16384">7036</EventID>` +
+				`<TimeCreated SystemTime="2023-01-10T11:37:47.1234567Z"/><Channel>System</Channel>` +
+				`<Computer>dc01.example.com</Computer></System><EventData><Data>Windows Update</Data>` +
+				`<Data>running</Data></EventData></Event>`, true, map[string]string{
+				"EventID": "7036", "Channel": "System", "EventData.0": "Windows Update", "EventData.1": "running",
+			},
+		},
+		{"no event id", `<Event><System><Channel>Security</Channel></System></Event>`, false, nil},
+		{"other element", `<EventLog><System><EventID>1</EventID></System></EventLog>`, false, nil},
+		{"invalid xml", `<Event><System><EventID>4624</EventID>`, false, nil},
+		{"no xml", "User jdoe logged on", false, nil},
+		{"empty", "", false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var lm parsesyslog.LogMsg
+			lm.Message.WriteString(tt.body)
+			if got := Decode(&lm); got != tt.decoded {
+				t.Fatalf("Decode() => expected: %t, got: %t", tt.decoded, got)
+			}
+			if !tt.decoded {
+				if len(lm.StructuredData) != 0 || lm.Hostname != "" || !lm.Timestamp.IsZero() {
+					t.Errorf("Decode() => expected unchanged LogMsg, got: %+v", lm)
+				}
+				return
+			}
+			if len(lm.StructuredData) != 1 || lm.StructuredData[0].ID != SDID {
+				t.Fatalf("Decode() => unexpected structured data: %+v", lm.StructuredData)
+			}
+			ps := make(map[string]string)
+			for _, p := range lm.StructuredData[0].Param {
+				if p.Name == "" || p.Value == "" {
+					t.Errorf("Decode() => unexpected empty param: %+v", p)
+				}
+				ps[p.Name] = p.Value
+			}
+			for k, v := range tt.params {
+				if ps[k] != v {
+					t.Errorf("Decode() param %s => expected: %q, got: %q", k, v, ps[k])
+				}
+			}
+			if lm.Hostname != "dc01.example.com" {
+				t.Errorf("Decode() hostname => expected: %s, got: %s", "dc01.example.com", lm.Hostname)
+			}
+			want := time.Date(2023, 1, 10, 11, 37, 47, 123456700, time.UTC)
+			if !lm.Timestamp.Equal(want) {
+				t.Errorf("Decode() timestamp => expected: %s, got: %s", want, lm.Timestamp)
+			}
+		})
+	}
+}
+
+// TestDecode_rfc3164 tests Decode as BodyDecoder of the RFC3164 parser
+func TestDecode_rfc3164(t *testing.T) {
+	p, err := parsesyslog.New(rfc3164.Type, parsesyslog.WithBodyDecoders(Decode))
+	if err != nil {
+		t.Fatalf("failed to create new parser: %s", err)
+	}
+	lm, err := p.ParseString("<14>Jan 10 11:37:47 relay01 nxlog: " + testEvent + "\n")
+	if err != nil {
+		t.Fatalf("ParseString() failed: %s", err)
+	}
+	if len(lm.StructuredData) != 1 || lm.StructuredData[0].ID != SDID {
+		t.Fatalf("ParseString() => unexpected structured data: %+v", lm.StructuredData)
+	}
+	if lm.Hostname != "relay01" {
+		t.Errorf("ParseString() => hostname of the header expected to be kept, got: %s", lm.Hostname)
+	}
+}
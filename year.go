@@ -0,0 +1,61 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"time"
+)
+
+// YearPolicy represents the way a parser infers the year of timestamps that lack it, i. e.
+// the BSD timestamps of RFC3164 ("Oct 11 22:14:15")
+type YearPolicy int
+
+// YearPolicies
+const (
+	// YearCurrent places the timestamps in the year of the reference time
+	YearCurrent YearPolicy = iota
+	// YearNearest places the timestamps in the year before, the year of or the year after
+	// the reference time, whichever is closest to the reference time. This way, messages
+	// of December that are received or replayed in January are placed in the previous
+	// year, and messages of senders whose clocks are slightly ahead at the turn of the year
+	// are placed in the next year.
+	YearNearest
+)
+
+// InferYear returns the given timestamp, which lacks a year, in the year defined by the
+// Options: the year set with WithYear or else the year inferred from the reference time
+// (see WithReferenceTime) according to the YearPolicy. The month, day and time of day of
+// the timestamp are kept.
+func (o Options) InferYear(ts time.Time) time.Time {
+	withYear := func(y int) time.Time {
+		return time.Date(y, ts.Month(), ts.Day(), ts.Hour(), ts.Minute(), ts.Second(), ts.Nanosecond(),
+			ts.Location())
+	}
+	if o.Year != 0 {
+		return withYear(o.Year)
+	}
+	ref := o.ReferenceTime
+	if ref.IsZero() {
+		ref = time.Now()
+	}
+	t := withYear(ref.Year())
+	if o.YearPolicy != YearNearest {
+		return t
+	}
+	for _, y := range []int{ref.Year() - 1, ref.Year() + 1} {
+		if c := withYear(y); absDuration(c.Sub(ref)) < absDuration(t.Sub(ref)) {
+			t = c
+		}
+	}
+	return t
+}
+
+// absDuration returns the absolute value of the given time.Duration
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
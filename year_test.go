@@ -0,0 +1,58 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package parsesyslog
+
+import (
+	"testing"
+	"time"
+)
+
+// TestOptions_InferYear tests the year inference of timestamps that lack a year
+func TestOptions_InferYear(t *testing.T) {
+	jan := time.Date(2023, 1, 2, 10, 0, 0, 0, time.UTC)
+	dec := time.Date(2022, 12, 30, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		opts []Option
+		ts   time.Time
+		want time.Time
+	}{
+		{
+			"current year", []Option{WithReferenceTime(jan)}, time.Date(0, 12, 31, 23, 0, 0, 0, time.UTC),
+			time.Date(2023, 12, 31, 23, 0, 0, 0, time.UTC),
+		},
+		{
+			"nearest previous year", []Option{WithReferenceTime(jan), WithYearPolicy(YearNearest)},
+			time.Date(0, 12, 31, 23, 0, 0, 0, time.UTC), time.Date(2022, 12, 31, 23, 0, 0, 0, time.UTC),
+		},
+		{
+			"nearest same year", []Option{WithReferenceTime(jan), WithYearPolicy(YearNearest)},
+			time.Date(0, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			"nearest next year", []Option{WithReferenceTime(dec), WithYearPolicy(YearNearest)},
+			time.Date(0, 1, 1, 0, 0, 5, 0, time.UTC), time.Date(2023, 1, 1, 0, 0, 5, 0, time.UTC),
+		},
+		{
+			"fixed year", []Option{WithYear(2019), WithReferenceTime(jan), WithYearPolicy(YearNearest)},
+			time.Date(0, 12, 31, 23, 0, 0, 0, time.UTC), time.Date(2019, 12, 31, 23, 0, 0, 0, time.UTC),
+		},
+		{
+			"location", []Option{WithYear(2019)}, time.Date(0, 6, 1, 8, 0, 0, 0, time.FixedZone("CEST", 7200)),
+			time.Date(2019, 6, 1, 8, 0, 0, 0, time.FixedZone("CEST", 7200)),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewOptions(tt.opts...).InferYear(tt.ts)
+			if !got.Equal(tt.want) {
+				t.Errorf("InferYear() => expected: %s, got: %s", tt.want, got)
+			}
+		})
+	}
+	if got := NewOptions().InferYear(time.Date(0, 6, 1, 0, 0, 0, 0, time.UTC)); got.Year() != time.Now().Year() {
+		t.Errorf("InferYear() => expected current year, got: %d", got.Year())
+	}
+}
@@ -0,0 +1,101 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package zstd
+
+import (
+	"math/bits"
+)
+
+// forwardBits reads the bits of a byte slice from the lowest bit of the first byte
+// onwards, as it is needed for the FSE table descriptions
+// See: https://datatracker.ietf.org/doc/html/rfc8878#section-4.1.1
+type forwardBits struct {
+	data []byte
+	pos  int
+}
+
+// peek returns the next n bits (n <= 56) without consuming them. Bits beyond the end
+// of the data are zero.
+func (b *forwardBits) peek(n uint) uint64 {
+	return loadBits(b.data, b.pos, n)
+}
+
+// skip consumes the next n bits
+func (b *forwardBits) skip(n uint) {
+	b.pos += int(n)
+}
+
+// bytesRead returns the number of bytes that have been read, including a partially
+// read byte
+func (b *forwardBits) bytesRead() int {
+	return (b.pos + 7) / 8
+}
+
+// reverseBits reads the bits of a byte slice from the highest bit of the last byte
+// backwards, as it is needed for the Huffman and FSE coded bitstreams. The highest set
+// bit of the last byte marks the start of the bitstream.
+// See: https://datatracker.ietf.org/doc/html/rfc8878#section-4.2
+type reverseBits struct {
+	data []byte
+	pos  int
+}
+
+// init prepares the reverseBits for reading the given bitstream
+func (b *reverseBits) init(data []byte) error {
+	if len(data) == 0 || data[len(data)-1] == 0 {
+		return corrupt("bitstream without start marker")
+	}
+	b.data = data
+	b.pos = len(data)*8 - bits.LeadingZeros8(data[len(data)-1]) - 1
+	return nil
+}
+
+// read consumes and returns the next n bits (n <= 56). Reading beyond the start of the
+// bitstream yields zero bits, which can be detected with overflowed.
+func (b *reverseBits) read(n uint) uint64 {
+	b.pos -= int(n)
+	return b.peekAt(b.pos, n)
+}
+
+// peek returns the next n bits (n <= 56) without consuming them
+func (b *reverseBits) peek(n uint) uint64 {
+	return b.peekAt(b.pos-int(n), n)
+}
+
+// peekAt returns the n bits starting at the given bit position, where positions below
+// zero are read as zero bits
+func (b *reverseBits) peekAt(pos int, n uint) uint64 {
+	if pos >= 0 {
+		return loadBits(b.data, pos, n)
+	}
+	if int(n)+pos <= 0 {
+		return 0
+	}
+	return loadBits(b.data, 0, uint(int(n)+pos)) << uint(-pos)
+}
+
+// overflowed returns true if more bits have been read than the bitstream holds
+func (b *reverseBits) overflowed() bool {
+	return b.pos < 0
+}
+
+// finished returns true if all bits of the bitstream have been read
+func (b *reverseBits) finished() bool {
+	return b.pos == 0
+}
+
+// loadBits returns the n bits (n <= 56) of the given byte slice that start at the given
+// bit position, in little-endian order. Bits beyond the end of the data are zero.
+func loadBits(data []byte, pos int, n uint) uint64 {
+	if n == 0 {
+		return 0
+	}
+	var w uint64
+	i := pos >> 3
+	for j := 0; j < 8 && i+j < len(data); j++ {
+		w |= uint64(data[i+j]) << (8 * uint(j))
+	}
+	return (w >> uint(pos&7)) & (1<<n - 1)
+}
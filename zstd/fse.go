@@ -0,0 +1,173 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package zstd
+
+import (
+	"math/bits"
+)
+
+// fseEntry is a state of a FSE decoding table
+type fseEntry struct {
+	symbol uint8
+	nbBits uint8
+	base   uint16
+}
+
+// fseTable is a FSE decoding table
+// See: https://datatracker.ietf.org/doc/html/rfc8878#section-4.1
+type fseTable struct {
+	accuracyLog uint
+	states      []fseEntry
+}
+
+// fseState is the state of a FSE decoder that reads from a reverseBits
+type fseState struct {
+	table *fseTable
+	state uint64
+}
+
+// init reads the initial state from the bitstream
+func (s *fseState) init(t *fseTable, br *reverseBits) {
+	s.table = t
+	s.state = br.read(t.accuracyLog)
+}
+
+// symbol returns the symbol of the current state
+func (s *fseState) symbol() uint8 {
+	return s.table.states[s.state].symbol
+}
+
+// update reads the next state from the bitstream
+func (s *fseState) update(br *reverseBits) {
+	e := s.table.states[s.state]
+	s.state = uint64(e.base) + br.read(uint(e.nbBits))
+}
+
+// readFSETable reads a FSE table description with the given maximum accuracy log and
+// maximum symbol from the given bytes and builds the decoding table. It returns the
+// number of bytes that the table description occupies.
+// See: https://datatracker.ietf.org/doc/html/rfc8878#section-4.1.1
+func readFSETable(t *fseTable, data []byte, maxLog uint, maxSymbol int) (int, error) {
+	if len(data) == 0 {
+		return 0, corrupt("missing FSE table description")
+	}
+	br := forwardBits{data: data}
+	accuracyLog := uint(br.peek(4)) + 5
+	br.skip(4)
+	if accuracyLog > maxLog {
+		return 0, corrupt("FSE accuracy log too large")
+	}
+
+	probs := make([]int16, 0, maxSymbol+1)
+	remaining := (1 << accuracyLog) + 1
+	threshold := 1 << accuracyLog
+	nbBits := accuracyLog + 1
+	for remaining > 1 && len(probs) <= maxSymbol {
+		if br.bytesRead() > len(data) {
+			return 0, corrupt("truncated FSE table description")
+		}
+		limit := 2*threshold - 1 - remaining
+		var count int
+		if v := int(br.peek(nbBits - 1)); v < limit {
+			count = v
+			br.skip(nbBits - 1)
+		} else {
+			count = int(br.peek(nbBits))
+			if count >= threshold {
+				count -= limit
+			}
+			br.skip(nbBits)
+		}
+		count--
+		if count < 0 {
+			remaining--
+		} else {
+			remaining -= count
+		}
+		probs = append(probs, int16(count))
+		for remaining < threshold {
+			nbBits--
+			threshold >>= 1
+		}
+		if count != 0 || len(probs) > maxSymbol {
+			continue
+		}
+		for {
+			repeat := int(br.peek(2))
+			br.skip(2)
+			for i := 0; i < repeat; i++ {
+				probs = append(probs, 0)
+			}
+			if repeat != 3 {
+				break
+			}
+			if br.bytesRead() > len(data) {
+				return 0, corrupt("truncated FSE table description")
+			}
+		}
+	}
+	if remaining != 1 || len(probs) > maxSymbol+1 || br.bytesRead() > len(data) {
+		return 0, corrupt("invalid FSE table description")
+	}
+	if err := t.build(probs, accuracyLog); err != nil {
+		return 0, err
+	}
+	return br.bytesRead(), nil
+}
+
+// build builds the decoding table from the given normalized probabilities
+// See: https://datatracker.ietf.org/doc/html/rfc8878#section-4.1.1
+func (t *fseTable) build(probs []int16, accuracyLog uint) error {
+	size := 1 << accuracyLog
+	t.accuracyLog = accuracyLog
+	if cap(t.states) < size {
+		t.states = make([]fseEntry, size)
+	}
+	t.states = t.states[:size]
+
+	next := make([]uint16, len(probs))
+	high := size - 1
+	for s, p := range probs {
+		if p == -1 {
+			t.states[high].symbol = uint8(s)
+			high--
+			next[s] = 1
+			continue
+		}
+		next[s] = uint16(p)
+	}
+
+	step := (size >> 1) + (size >> 3) + 3
+	mask := size - 1
+	pos := 0
+	for s, p := range probs {
+		for i := 0; i < int(p); i++ {
+			t.states[pos].symbol = uint8(s)
+			pos = (pos + step) & mask
+			for pos > high {
+				pos = (pos + step) & mask
+			}
+		}
+	}
+	if pos != 0 {
+		return corrupt("invalid FSE probabilities")
+	}
+
+	for i := range t.states {
+		s := t.states[i].symbol
+		n := next[s]
+		next[s]++
+		nb := accuracyLog - uint(bits.Len16(n)-1)
+		t.states[i].nbBits = uint8(nb)
+		t.states[i].base = uint16((int(n) << nb) - size)
+	}
+	return nil
+}
+
+// rle makes the table a table that always decodes the given symbol
+func (t *fseTable) rle(symbol uint8) {
+	t.accuracyLog = 0
+	t.states = append(t.states[:0], fseEntry{symbol: symbol})
+}
@@ -0,0 +1,300 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package zstd
+
+import (
+	"math/bits"
+)
+
+// Literals_Block_Types
+// See: https://datatracker.ietf.org/doc/html/rfc8878#section-3.1.1.3.1.1
+const (
+	literalsRaw        = 0
+	literalsRLE        = 1
+	literalsCompressed = 2
+	literalsTreeless   = 3
+)
+
+// maxHuffmanBits is the maximum length of a Huffman prefix code
+const maxHuffmanBits = 11
+
+// huffEntry is an entry of a Huffman decoding table
+type huffEntry struct {
+	symbol uint8
+	nbBits uint8
+}
+
+// huffTable is a Huffman decoding table, which is indexed by the next maxBits bits
+// of the bitstream
+// See: https://datatracker.ietf.org/doc/html/rfc8878#section-4.2.1
+type huffTable struct {
+	maxBits uint
+	entries []huffEntry
+}
+
+// readLiterals reads the literals section of a compressed block from the given data
+// and returns the literals and the number of bytes that the section occupies
+// See: https://datatracker.ietf.org/doc/html/rfc8878#section-3.1.1.3.1
+func (d *decoder) readLiterals(data []byte) ([]byte, int, error) {
+	if len(data) == 0 {
+		return nil, 0, corrupt("missing literals section")
+	}
+	typ := data[0] & 3
+	format := (data[0] >> 2) & 3
+
+	if typ == literalsRaw || typ == literalsRLE {
+		var size, hlen int
+		switch format {
+		case 0, 2:
+			size, hlen = int(data[0]>>3), 1
+		case 1:
+			if len(data) < 2 {
+				return nil, 0, corrupt("truncated literals section header")
+			}
+			size, hlen = int(data[0]>>4)|int(data[1])<<4, 2
+		default:
+			if len(data) < 3 {
+				return nil, 0, corrupt("truncated literals section header")
+			}
+			size, hlen = int(data[0]>>4)|int(data[1])<<4|int(data[2])<<12, 3
+		}
+		if size > maxBlockSize {
+			return nil, 0, corrupt("literals exceed the maximum block size")
+		}
+		if typ == literalsRLE {
+			if len(data) < hlen+1 {
+				return nil, 0, corrupt("truncated RLE literals")
+			}
+			d.literals = d.literals[:0]
+			for i := 0; i < size; i++ {
+				d.literals = append(d.literals, data[hlen])
+			}
+			return d.literals, hlen + 1, nil
+		}
+		if len(data) < hlen+size {
+			return nil, 0, corrupt("truncated raw literals")
+		}
+		return data[hlen : hlen+size], hlen + size, nil
+	}
+
+	var hlen int
+	streams := 4
+	switch format {
+	case 0, 1:
+		hlen = 3
+		if format == 0 {
+			streams = 1
+		}
+	case 2:
+		hlen = 4
+	default:
+		hlen = 5
+	}
+	if len(data) < hlen {
+		return nil, 0, corrupt("truncated literals section header")
+	}
+	var h uint64
+	for i := hlen - 1; i >= 0; i-- {
+		h = h<<8 | uint64(data[i])
+	}
+	sizeBits := uint(hlen*8-4) / 2
+	size := int((h >> 4) & (1<<sizeBits - 1))
+	csize := int((h >> (4 + sizeBits)) & (1<<sizeBits - 1))
+	if size > maxBlockSize {
+		return nil, 0, corrupt("literals exceed the maximum block size")
+	}
+	if len(data) < hlen+csize {
+		return nil, 0, corrupt("truncated compressed literals")
+	}
+	cdata := data[hlen : hlen+csize]
+
+	if typ == literalsCompressed {
+		n, err := d.huff.read(cdata)
+		if err != nil {
+			return nil, 0, err
+		}
+		d.hasHuff = true
+		cdata = cdata[n:]
+	} else if !d.hasHuff {
+		return nil, 0, corrupt("treeless literals without previous Huffman table")
+	}
+
+	if cap(d.literals) < size {
+		d.literals = make([]byte, size)
+	}
+	d.literals = d.literals[:size]
+	if streams == 1 {
+		if err := d.huff.decode(d.literals, cdata); err != nil {
+			return nil, 0, err
+		}
+		return d.literals, hlen + csize, nil
+	}
+	if len(cdata) < 6 {
+		return nil, 0, corrupt("truncated Huffman jump table")
+	}
+	s1 := int(cdata[0]) | int(cdata[1])<<8
+	s2 := int(cdata[2]) | int(cdata[3])<<8
+	s3 := int(cdata[4]) | int(cdata[5])<<8
+	cdata = cdata[6:]
+	if s1+s2+s3 > len(cdata) {
+		return nil, 0, corrupt("invalid Huffman jump table")
+	}
+	seg := (size + 3) / 4
+	if 3*seg > size {
+		return nil, 0, corrupt("too few literals for four Huffman streams")
+	}
+	offs := []int{0, s1, s1 + s2, s1 + s2 + s3, len(cdata)}
+	for i := 0; i < 4; i++ {
+		out := d.literals[i*seg:]
+		if i < 3 {
+			out = out[:seg]
+		}
+		if err := d.huff.decode(out, cdata[offs[i]:offs[i+1]]); err != nil {
+			return nil, 0, err
+		}
+	}
+	return d.literals, hlen + csize, nil
+}
+
+// read reads the Huffman tree description from the given data and builds the decoding
+// table. It returns the number of bytes that the tree description occupies.
+// See: https://datatracker.ietf.org/doc/html/rfc8878#section-4.2.1
+func (t *huffTable) read(data []byte) (int, error) {
+	if len(data) == 0 {
+		return 0, corrupt("missing Huffman tree description")
+	}
+	var weights [256]uint8
+	var n, size int
+	if hb := int(data[0]); hb < 128 {
+		size = 1 + hb
+		if len(data) < size {
+			return 0, corrupt("truncated Huffman tree description")
+		}
+		var err error
+		n, err = readHuffWeights(weights[:], data[1:size])
+		if err != nil {
+			return 0, err
+		}
+	} else {
+		n = hb - 127
+		size = 1 + (n+1)/2
+		if len(data) < size {
+			return 0, corrupt("truncated Huffman tree description")
+		}
+		for i := 0; i < n; i++ {
+			b := data[1+i/2]
+			if i%2 == 0 {
+				b >>= 4
+			}
+			weights[i] = b & 0xf
+		}
+	}
+	if n >= len(weights) {
+		return 0, corrupt("too many Huffman weights")
+	}
+
+	sum := 0
+	for _, w := range weights[:n] {
+		if w > maxHuffmanBits {
+			return 0, corrupt("invalid Huffman weight")
+		}
+		if w > 0 {
+			sum += 1 << (w - 1)
+		}
+	}
+	if sum == 0 {
+		return 0, corrupt("invalid Huffman weights")
+	}
+	maxBits := uint(bits.Len(uint(sum)))
+	rest := 1<<maxBits - sum
+	if maxBits > maxHuffmanBits || rest&(rest-1) != 0 {
+		return 0, corrupt("invalid Huffman weights")
+	}
+	weights[n] = uint8(bits.Len(uint(rest)))
+	n++
+
+	var rank [maxHuffmanBits + 2]int
+	for _, w := range weights[:n] {
+		rank[w]++
+	}
+	pos := 0
+	for w := 1; w <= int(maxBits); w++ {
+		c := rank[w]
+		rank[w] = pos
+		pos += c << (w - 1)
+	}
+	t.maxBits = maxBits
+	if cap(t.entries) < 1<<maxBits {
+		t.entries = make([]huffEntry, 1<<maxBits)
+	}
+	t.entries = t.entries[:1<<maxBits]
+	for s, w := range weights[:n] {
+		if w == 0 {
+			continue
+		}
+		e := huffEntry{symbol: uint8(s), nbBits: uint8(maxBits + 1 - uint(w))}
+		l := 1 << (w - 1)
+		for i := rank[w]; i < rank[w]+l; i++ {
+			t.entries[i] = e
+		}
+		rank[w] += l
+	}
+	return size, nil
+}
+
+// readHuffWeights decodes the FSE compressed Huffman weights of the given data into the
+// given slice and returns their number
+// See: https://datatracker.ietf.org/doc/html/rfc8878#section-4.2.1.2
+func readHuffWeights(weights []uint8, data []byte) (int, error) {
+	var t fseTable
+	n, err := readFSETable(&t, data, 6, maxHuffmanBits+1)
+	if err != nil {
+		return 0, err
+	}
+	var br reverseBits
+	if err := br.init(data[n:]); err != nil {
+		return 0, err
+	}
+	// The two states are decoded interleaved. Once the bit stream is exhausted, the
+	// symbol of the other state is the last weight.
+	var states [2]fseState
+	states[0].init(&t, &br)
+	states[1].init(&t, &br)
+	for c := 0; ; c++ {
+		if c >= len(weights) {
+			return 0, corrupt("too many Huffman weights")
+		}
+		s := &states[c%2]
+		weights[c] = s.symbol()
+		s.update(&br)
+		if !br.overflowed() {
+			continue
+		}
+		if c+1 >= len(weights) {
+			return 0, corrupt("too many Huffman weights")
+		}
+		weights[c+1] = states[(c+1)%2].symbol()
+		return c + 2, nil
+	}
+}
+
+// decode decodes a single Huffman coded stream into the given slice, which must have
+// the length of the regenerated data
+// See: https://datatracker.ietf.org/doc/html/rfc8878#section-4.2.2
+func (t *huffTable) decode(out []byte, data []byte) error {
+	var br reverseBits
+	if err := br.init(data); err != nil {
+		return err
+	}
+	for i := range out {
+		e := t.entries[br.peek(t.maxBits)]
+		out[i] = e.symbol
+		br.pos -= int(e.nbBits)
+	}
+	if !br.finished() {
+		return corrupt("Huffman stream size mismatch")
+	}
+	return nil
+}
@@ -0,0 +1,248 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package zstd
+
+// Compression modes of the sequences section
+// See: https://datatracker.ietf.org/doc/html/rfc8878#section-3.1.1.3.2.1
+const (
+	modePredefined = 0
+	modeRLE        = 1
+	modeFSE        = 2
+	modeRepeat     = 3
+)
+
+// Symbol types of the sequences section
+const (
+	symbolLiteralsLength = iota
+	symbolOffset
+	symbolMatchLength
+)
+
+// seqKind describes the coding of one of the symbol types of the sequences section
+type seqKind struct {
+	maxLog     uint
+	maxSymbol  int
+	predefined []int16
+	predefLog  uint
+}
+
+// seqKinds are the codings of the symbol types, in the order of symbolLiteralsLength,
+// symbolOffset and symbolMatchLength
+// See: https://datatracker.ietf.org/doc/html/rfc8878#section-3.1.1.3.2.2
+var seqKinds = [3]seqKind{
+	{
+		maxLog: 9, maxSymbol: 35, predefLog: 6,
+		predefined: []int16{
+			4, 3, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 1, 1, 1, 2, 2, 2, 2, 2, 2, 2, 2, 2, 3, 2, 1, 1, 1, 1, 1,
+			-1, -1, -1, -1,
+		},
+	},
+	{
+		maxLog: 8, maxSymbol: 31, predefLog: 5,
+		predefined: []int16{
+			1, 1, 1, 1, 1, 1, 2, 2, 2, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, -1, -1, -1, -1, -1,
+		},
+	},
+	{
+		maxLog: 9, maxSymbol: 52, predefLog: 6,
+		predefined: []int16{
+			1, 4, 3, 2, 2, 2, 2, 2, 2, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
+			1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, -1, -1, -1, -1, -1, -1, -1,
+		},
+	},
+}
+
+// literalsLengthCodes are the baselines and the number of additional bits of the
+// literals length codes
+// See: https://datatracker.ietf.org/doc/html/rfc8878#section-3.1.1.3.2.1.1
+var literalsLengthCodes = [36][2]uint32{
+	{0, 0}, {1, 0}, {2, 0}, {3, 0}, {4, 0}, {5, 0}, {6, 0}, {7, 0},
+	{8, 0}, {9, 0}, {10, 0}, {11, 0}, {12, 0}, {13, 0}, {14, 0}, {15, 0},
+	{16, 1}, {18, 1}, {20, 1}, {22, 1}, {24, 2}, {28, 2}, {32, 3}, {40, 3},
+	{48, 4}, {64, 6}, {128, 7}, {256, 8}, {512, 9}, {1024, 10}, {2048, 11}, {4096, 12},
+	{8192, 13}, {16384, 14}, {32768, 15}, {65536, 16},
+}
+
+// matchLengthCodes are the baselines and the number of additional bits of the match
+// length codes
+// See: https://datatracker.ietf.org/doc/html/rfc8878#section-3.1.1.3.2.1.1
+var matchLengthCodes = [53][2]uint32{
+	{3, 0}, {4, 0}, {5, 0}, {6, 0}, {7, 0}, {8, 0}, {9, 0}, {10, 0},
+	{11, 0}, {12, 0}, {13, 0}, {14, 0}, {15, 0}, {16, 0}, {17, 0}, {18, 0},
+	{19, 0}, {20, 0}, {21, 0}, {22, 0}, {23, 0}, {24, 0}, {25, 0}, {26, 0},
+	{27, 0}, {28, 0}, {29, 0}, {30, 0}, {31, 0}, {32, 0}, {33, 0}, {34, 0},
+	{35, 1}, {37, 1}, {39, 1}, {41, 1}, {43, 2}, {47, 2}, {51, 3}, {59, 3},
+	{67, 4}, {83, 4}, {99, 5}, {131, 7}, {259, 8}, {515, 9}, {1027, 10}, {2051, 11},
+	{4099, 12}, {8195, 13}, {16387, 14}, {32771, 15}, {65539, 16},
+}
+
+// readSequences reads the sequences section of a compressed block from the given data
+// and executes the sequences with the given literals, appending the regenerated data
+// to the history of the decoder
+// See: https://datatracker.ietf.org/doc/html/rfc8878#section-3.1.1.3.2
+func (d *decoder) readSequences(data, literals []byte) error {
+	if len(data) == 0 {
+		return corrupt("missing sequences section")
+	}
+	var n, hlen int
+	switch b := int(data[0]); {
+	case b < 128:
+		n, hlen = b, 1
+	case b < 255:
+		if len(data) < 2 {
+			return corrupt("truncated sequences section header")
+		}
+		n, hlen = (b-128)<<8|int(data[1]), 2
+	default:
+		if len(data) < 3 {
+			return corrupt("truncated sequences section header")
+		}
+		n, hlen = int(data[1])|int(data[2])<<8+0x7f00, 3
+	}
+	if n == 0 {
+		if hlen != len(data) {
+			return corrupt("trailing data after sequences section")
+		}
+		d.hist = append(d.hist, literals...)
+		return nil
+	}
+	if len(data) < hlen+1 {
+		return corrupt("missing symbol compression modes")
+	}
+	modes := data[hlen]
+	if modes&3 != 0 {
+		return corrupt("reserved bits of symbol compression modes set")
+	}
+	data = data[hlen+1:]
+	for i, shift := range [3]uint{6, 4, 2} {
+		c, err := d.readSeqTable(i, int(modes>>shift)&3, data)
+		if err != nil {
+			return err
+		}
+		data = data[c:]
+	}
+
+	var br reverseBits
+	if err := br.init(data); err != nil {
+		return err
+	}
+	var ll, of, ml fseState
+	ll.init(&d.tables[symbolLiteralsLength], &br)
+	of.init(&d.tables[symbolOffset], &br)
+	ml.init(&d.tables[symbolMatchLength], &br)
+	start := len(d.hist)
+	for i := 0; i < n; i++ {
+		ofCode := uint(of.symbol())
+		llCode, mlCode := ll.symbol(), ml.symbol()
+		if ofCode > 31 || int(llCode) >= len(literalsLengthCodes) || int(mlCode) >= len(matchLengthCodes) {
+			return corrupt("invalid sequence code")
+		}
+		offset := int(1<<ofCode) + int(br.read(ofCode))
+		mlc, llc := matchLengthCodes[mlCode], literalsLengthCodes[llCode]
+		matchLen := int(mlc[0]) + int(br.read(uint(mlc[1])))
+		litLen := int(llc[0]) + int(br.read(uint(llc[1])))
+		if i < n-1 {
+			ll.update(&br)
+			ml.update(&br)
+			of.update(&br)
+		}
+		if br.overflowed() {
+			return corrupt("sequences bitstream overflow")
+		}
+
+		offset = d.repeatOffset(offset, litLen)
+		if litLen > len(literals) {
+			return corrupt("literals length exceeds the literals")
+		}
+		if len(d.hist)-start+litLen+matchLen > maxBlockSize {
+			return corrupt("block exceeds the maximum block size")
+		}
+		d.hist = append(d.hist, literals[:litLen]...)
+		literals = literals[litLen:]
+		if offset <= 0 || offset > len(d.hist) {
+			return corrupt("match offset exceeds the history")
+		}
+		m := len(d.hist) - offset
+		if offset >= matchLen {
+			d.hist = append(d.hist, d.hist[m:m+matchLen]...)
+			continue
+		}
+		for j := 0; j < matchLen; j++ {
+			d.hist = append(d.hist, d.hist[m+j])
+		}
+	}
+	if !br.finished() {
+		return corrupt("sequences bitstream size mismatch")
+	}
+	d.hist = append(d.hist, literals...)
+	return nil
+}
+
+// readSeqTable prepares the decoding table of the given symbol type according to the
+// compression mode and returns the number of bytes of the data it occupies
+// See: https://datatracker.ietf.org/doc/html/rfc8878#section-3.1.1.3.2.2
+func (d *decoder) readSeqTable(i, mode int, data []byte) (int, error) {
+	k := seqKinds[i]
+	t := &d.tables[i]
+	switch mode {
+	case modePredefined:
+		if err := t.build(k.predefined, k.predefLog); err != nil {
+			return 0, err
+		}
+	case modeRLE:
+		if len(data) == 0 {
+			return 0, corrupt("missing RLE symbol")
+		}
+		if int(data[0]) > k.maxSymbol {
+			return 0, corrupt("invalid RLE symbol")
+		}
+		t.rle(data[0])
+		d.hasTable[i] = true
+		return 1, nil
+	case modeFSE:
+		n, err := readFSETable(t, data, k.maxLog, k.maxSymbol)
+		if err != nil {
+			return 0, err
+		}
+		d.hasTable[i] = true
+		return n, nil
+	default:
+		if !d.hasTable[i] {
+			return 0, corrupt("repeated sequences table without previous table")
+		}
+		return 0, nil
+	}
+	d.hasTable[i] = true
+	return 0, nil
+}
+
+// repeatOffset resolves the given offset value of a sequence to the actual offset and
+// updates the repeated offsets
+// See: https://datatracker.ietf.org/doc/html/rfc8878#section-3.2.2
+func (d *decoder) repeatOffset(value, litLen int) int {
+	if value > 3 {
+		d.reps[2], d.reps[1], d.reps[0] = d.reps[1], d.reps[0], value-3
+		return d.reps[0]
+	}
+	idx := value - 1
+	if litLen == 0 {
+		idx++
+	}
+	var offset int
+	switch idx {
+	case 0:
+		return d.reps[0]
+	case 1:
+		offset = d.reps[1]
+	case 2:
+		offset = d.reps[2]
+		d.reps[2] = d.reps[1]
+	default:
+		offset = d.reps[0] - 1
+		d.reps[2] = d.reps[1]
+	}
+	d.reps[1], d.reps[0] = d.reps[0], offset
+	return offset
+}
@@ -0,0 +1,110 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package zstd
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// Primes of the XXH64 algorithm, which are variables, so that the initial state can
+// be computed with wrapping arithmetics
+var (
+	xxhPrime1 uint64 = 11400714785074694791
+	xxhPrime2 uint64 = 14029467366897019727
+	xxhPrime3 uint64 = 1609587929392839161
+	xxhPrime4 uint64 = 9650029242287828579
+	xxhPrime5 uint64 = 2870177450012600261
+)
+
+// xxhash64 computes the XXH64 hash with seed 0 of the content of a frame, which is
+// used for the content checksum
+// See: https://datatracker.ietf.org/doc/html/rfc8878#section-3.1.1
+type xxhash64 struct {
+	v     [4]uint64
+	buf   [32]byte
+	nbuf  int
+	total uint64
+}
+
+// Reset resets the hash to its initial state
+func (x *xxhash64) Reset() {
+	x.v = [4]uint64{xxhPrime1 + xxhPrime2, xxhPrime2, 0, -xxhPrime1}
+	x.nbuf = 0
+	x.total = 0
+}
+
+// Write adds the given bytes to the hash
+func (x *xxhash64) Write(b []byte) (int, error) {
+	n := len(b)
+	x.total += uint64(n)
+	if x.nbuf > 0 {
+		c := copy(x.buf[x.nbuf:], b)
+		x.nbuf += c
+		b = b[c:]
+		if x.nbuf < len(x.buf) {
+			return n, nil
+		}
+		x.stripe(x.buf[:])
+		x.nbuf = 0
+	}
+	for len(b) >= len(x.buf) {
+		x.stripe(b[:32])
+		b = b[32:]
+	}
+	x.nbuf = copy(x.buf[:], b)
+	return n, nil
+}
+
+// stripe processes a stripe of 32 bytes
+func (x *xxhash64) stripe(b []byte) {
+	for i := range x.v {
+		x.v[i] = xxhRound(x.v[i], binary.LittleEndian.Uint64(b[i*8:]))
+	}
+}
+
+// Sum64 returns the hash of the bytes written so far
+func (x *xxhash64) Sum64() uint64 {
+	var h uint64
+	if x.total >= 32 {
+		h = bits.RotateLeft64(x.v[0], 1) + bits.RotateLeft64(x.v[1], 7) +
+			bits.RotateLeft64(x.v[2], 12) + bits.RotateLeft64(x.v[3], 18)
+		for _, v := range x.v {
+			h = (h^xxhRound(0, v))*xxhPrime1 + xxhPrime4
+		}
+	} else {
+		h = xxhPrime5
+	}
+	h += x.total
+
+	b := x.buf[:x.nbuf]
+	for ; len(b) >= 8; b = b[8:] {
+		h ^= xxhRound(0, binary.LittleEndian.Uint64(b))
+		h = bits.RotateLeft64(h, 27)*xxhPrime1 + xxhPrime4
+	}
+	if len(b) >= 4 {
+		h ^= uint64(binary.LittleEndian.Uint32(b)) * xxhPrime1
+		h = bits.RotateLeft64(h, 23)*xxhPrime2 + xxhPrime3
+		b = b[4:]
+	}
+	for _, c := range b {
+		h ^= uint64(c) * xxhPrime5
+		h = bits.RotateLeft64(h, 11) * xxhPrime1
+	}
+
+	h ^= h >> 33
+	h *= xxhPrime2
+	h ^= h >> 29
+	h *= xxhPrime3
+	h ^= h >> 32
+	return h
+}
+
+// xxhRound is the round function of XXH64
+func xxhRound(acc, input uint64) uint64 {
+	acc += input * xxhPrime2
+	acc = bits.RotateLeft64(acc, 31)
+	return acc * xxhPrime1
+}
@@ -0,0 +1,359 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+// Package zstd implements a decoder for zstd compressed streams, as they are used for
+// rotated log archives (i. e. "messages.1.zst"). It has no dependencies other than the
+// Go standard library.
+//
+// Importing the package registers the decoder with parsesyslog.Decompress, so that zstd
+// compressed streams are decompressed transparently, just like gzip streams:
+//
+//	import _ "github.com/wneessen/go-parsesyslog/zstd"
+//
+// Dictionaries are not supported.
+// See: https://datatracker.ietf.org/doc/html/rfc8878
+package zstd
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/wneessen/go-parsesyslog"
+)
+
+const (
+	// frameMagic is the magic number that a zstd frame starts with
+	// See: https://datatracker.ietf.org/doc/html/rfc8878#section-3.1.1
+	frameMagic = 0xfd2fb528
+	// skippableMask and skippableMagic identify skippable frames, whose magic number
+	// ranges from 0x184d2a50 to 0x184d2a5f
+	// See: https://datatracker.ietf.org/doc/html/rfc8878#section-3.1.2
+	skippableMask  = 0xfffffff0
+	skippableMagic = 0x184d2a50
+	// maxBlockSize is the maximum size of the content of a block
+	// See: https://datatracker.ietf.org/doc/html/rfc8878#section-3.1.1.2.4
+	maxBlockSize = 128 << 10
+	// MaxWindowSize is the largest window size of a frame that the decoder accepts. It
+	// limits the memory that is used to decode a frame. The zstd command line tool uses
+	// the same limit by default.
+	MaxWindowSize = 128 << 20
+)
+
+// Block_Types
+// See: https://datatracker.ietf.org/doc/html/rfc8878#section-3.1.1.2.2
+const (
+	blockRaw        = 0
+	blockRLE        = 1
+	blockCompressed = 2
+)
+
+// corrupt returns an error for an invalid zstd stream with the given reason, which wraps
+// parsesyslog.ErrWrongFormat
+func corrupt(reason string) error {
+	return fmt.Errorf("zstd: corrupt stream (%s): %w", reason, parsesyslog.ErrWrongFormat)
+}
+
+// unsupported returns an error for a zstd stream that uses a feature that is not
+// supported by the decoder (i. e. a dictionary or a window size that exceeds
+// MaxWindowSize), which wraps parsesyslog.ErrUnsupportedCompression
+func unsupported(reason string) error {
+	return fmt.Errorf("zstd: %s: %w", reason, parsesyslog.ErrUnsupportedCompression)
+}
+
+func init() {
+	magic := make([]byte, 4)
+	binary.LittleEndian.PutUint32(magic, frameMagic)
+	parsesyslog.RegisterDecompressor(magic, func(r io.Reader) (io.Reader, error) {
+		return NewReader(r)
+	})
+}
+
+// Reader is an io.Reader that decompresses a zstd stream, which may consist of
+// multiple concatenated frames
+type Reader struct {
+	br      *bufio.Reader
+	d       decoder
+	err     error
+	pending []byte
+}
+
+// decoder holds the state of the decoding of a frame
+type decoder struct {
+	checksum   bool
+	contentLen int64
+	hasHuff    bool
+	hasTable   [3]bool
+	hist       []byte
+	huff       huffTable
+	last       bool
+	literals   []byte
+	reps       [3]int
+	tables     [3]fseTable
+	total      int64
+	window     int
+	xxh        xxhash64
+}
+
+// NewReader returns a new Reader that decompresses the given zstd stream. The header
+// of the first frame is read right away, so that an error is returned if the stream is
+// no zstd stream.
+func NewReader(r io.Reader) (*Reader, error) {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	zr := &Reader{br: br}
+	if err := zr.readFrameHeader(); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return zr, nil
+}
+
+// Read satisfies the io.Reader interface for the Reader
+func (r *Reader) Read(p []byte) (int, error) {
+	for len(r.pending) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		r.err = r.next()
+	}
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+// next decodes the next block of the stream into the pending data. At the end of a
+// frame, it reads the header of the next frame, if any.
+func (r *Reader) next() error {
+	if r.d.last {
+		if err := r.finishFrame(); err != nil {
+			return err
+		}
+		if _, err := r.br.Peek(1); err != nil {
+			if errors.Is(err, io.EOF) {
+				return io.EOF
+			}
+			return err
+		}
+		if err := r.readFrameHeader(); err != nil {
+			return unexpected(err)
+		}
+	}
+
+	// Only the window size of the history is needed for the following blocks
+	if h := len(r.d.hist); h > 2*r.d.window && h > maxBlockSize {
+		n := copy(r.d.hist, r.d.hist[h-r.d.window:])
+		r.d.hist = r.d.hist[:n]
+	}
+	start := len(r.d.hist)
+	if err := r.readBlock(); err != nil {
+		return unexpected(err)
+	}
+	r.pending = r.d.hist[start:]
+	r.d.total += int64(len(r.pending))
+	if r.d.contentLen >= 0 && r.d.total > r.d.contentLen {
+		return corrupt("frame exceeds its content size")
+	}
+	if r.d.checksum {
+		_, _ = r.d.xxh.Write(r.pending)
+	}
+	return nil
+}
+
+// readFrameHeader reads the magic number and the header of the next frame, skipping
+// any skippable frames in front of it
+// See: https://datatracker.ietf.org/doc/html/rfc8878#section-3.1.1.1
+func (r *Reader) readFrameHeader() error {
+	var b [4]byte
+	for {
+		if _, err := io.ReadFull(r.br, b[:]); err != nil {
+			return err
+		}
+		magic := binary.LittleEndian.Uint32(b[:])
+		if magic == frameMagic {
+			break
+		}
+		if magic&skippableMask != skippableMagic {
+			return corrupt("invalid magic number")
+		}
+		if _, err := io.ReadFull(r.br, b[:]); err != nil {
+			return err
+		}
+		n := int64(binary.LittleEndian.Uint32(b[:]))
+		if m, err := io.CopyN(io.Discard, r.br, n); err != nil || m != n {
+			return io.ErrUnexpectedEOF
+		}
+	}
+
+	desc, err := r.br.ReadByte()
+	if err != nil {
+		return err
+	}
+	if desc&0x08 != 0 {
+		return corrupt("reserved bit of frame header set")
+	}
+	single := desc&0x20 != 0
+	fcsSize := [4]int{0, 2, 4, 8}[desc>>6]
+	if desc>>6 == 0 && single {
+		fcsSize = 1
+	}
+	dictSize := [4]int{0, 1, 2, 4}[desc&3]
+	hlen := fcsSize + dictSize
+	if !single {
+		hlen++
+	}
+	h := make([]byte, hlen)
+	if _, err := io.ReadFull(r.br, h); err != nil {
+		return err
+	}
+
+	var window uint64
+	if !single {
+		wd := h[0]
+		h = h[1:]
+		base := uint64(1) << (10 + uint(wd>>3))
+		window = base + (base/8)*uint64(wd&7)
+	}
+	var dictID uint64
+	for i := dictSize - 1; i >= 0; i-- {
+		dictID = dictID<<8 | uint64(h[i])
+	}
+	if dictID != 0 {
+		return unsupported("dictionaries are not supported")
+	}
+	h = h[dictSize:]
+	contentLen := int64(-1)
+	if fcsSize > 0 {
+		var v uint64
+		for i := fcsSize - 1; i >= 0; i-- {
+			v = v<<8 | uint64(h[i])
+		}
+		if fcsSize == 2 {
+			v += 256
+		}
+		if v > 1<<62 {
+			return corrupt("invalid frame content size")
+		}
+		contentLen = int64(v)
+	}
+	if single {
+		window = uint64(contentLen)
+	}
+	if window > MaxWindowSize {
+		return unsupported(fmt.Sprintf("window size exceeds %d bytes", MaxWindowSize))
+	}
+
+	r.d.reset(int(window), contentLen, desc&0x04 != 0)
+	return nil
+}
+
+// finishFrame verifies the content size and the checksum of the current frame
+// See: https://datatracker.ietf.org/doc/html/rfc8878#section-3.1.1
+func (r *Reader) finishFrame() error {
+	if r.d.contentLen >= 0 && r.d.total != r.d.contentLen {
+		return corrupt("frame content size mismatch")
+	}
+	if !r.d.checksum {
+		return nil
+	}
+	var b [4]byte
+	if _, err := io.ReadFull(r.br, b[:]); err != nil {
+		return unexpected(err)
+	}
+	if binary.LittleEndian.Uint32(b[:]) != uint32(r.d.xxh.Sum64()) {
+		return corrupt("checksum mismatch")
+	}
+	return nil
+}
+
+// readBlock reads the next block of the current frame and appends its content to the
+// history of the decoder
+// See: https://datatracker.ietf.org/doc/html/rfc8878#section-3.1.1.2
+func (r *Reader) readBlock() error {
+	var h [3]byte
+	if _, err := io.ReadFull(r.br, h[:]); err != nil {
+		return err
+	}
+	bh := int(h[0]) | int(h[1])<<8 | int(h[2])<<16
+	r.d.last = bh&1 != 0
+	size := bh >> 3
+	limit := maxBlockSize
+	if r.d.window < limit {
+		limit = r.d.window
+	}
+
+	switch (bh >> 1) & 3 {
+	case blockRaw:
+		if size > limit {
+			return corrupt("block exceeds the maximum block size")
+		}
+		n := len(r.d.hist)
+		r.d.hist = append(r.d.hist, make([]byte, size)...)
+		_, err := io.ReadFull(r.br, r.d.hist[n:])
+		return err
+	case blockRLE:
+		if size > limit {
+			return corrupt("block exceeds the maximum block size")
+		}
+		b, err := r.br.ReadByte()
+		if err != nil {
+			return err
+		}
+		for i := 0; i < size; i++ {
+			r.d.hist = append(r.d.hist, b)
+		}
+		return nil
+	case blockCompressed:
+		if size > limit {
+			return corrupt("block exceeds the maximum block size")
+		}
+		data := make([]byte, size)
+		if _, err := io.ReadFull(r.br, data); err != nil {
+			return err
+		}
+		start := len(r.d.hist)
+		literals, n, err := r.d.readLiterals(data)
+		if err != nil {
+			return err
+		}
+		if err := r.d.readSequences(data[n:], literals); err != nil {
+			return err
+		}
+		if len(r.d.hist)-start > limit {
+			return corrupt("block exceeds the maximum block size")
+		}
+		return nil
+	default:
+		return corrupt("reserved block type")
+	}
+}
+
+// reset prepares the decoder for a new frame
+func (d *decoder) reset(window int, contentLen int64, checksum bool) {
+	d.checksum = checksum
+	d.contentLen = contentLen
+	d.hasHuff = false
+	d.hasTable = [3]bool{}
+	d.hist = d.hist[:0]
+	d.last = false
+	d.reps = [3]int{1, 4, 8}
+	d.total = 0
+	d.window = window
+	d.xxh.Reset()
+}
+
+// unexpected returns io.ErrUnexpectedEOF for io.EOF, since the stream must not end
+// within a frame
+func unexpected(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
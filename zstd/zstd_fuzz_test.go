@@ -0,0 +1,43 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+//go:build go1.18
+// +build go1.18
+
+package zstd
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/wneessen/go-parsesyslog"
+)
+
+// FuzzReader performs a fuzzing test on the Reader. Invalid streams must result in one
+// of the documented errors instead of a panic.
+func FuzzReader(f *testing.F) {
+	for _, tt := range testVectors {
+		b, err := os.ReadFile(filepath.Join("testdata", tt.file))
+		if err != nil {
+			f.Fatalf("failed to read test vector: %s", err)
+		}
+		f.Add(b)
+	}
+	f.Fuzz(func(t *testing.T, b []byte) {
+		r, err := NewReader(bytes.NewReader(b))
+		if err == nil {
+			// Limit the output, as small inputs can expand to large outputs
+			_, err = io.CopyN(io.Discard, r, 1<<20)
+		}
+		if err == nil || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+			errors.Is(err, parsesyslog.ErrWrongFormat) || errors.Is(err, parsesyslog.ErrUnsupportedCompression) {
+			return
+		}
+		t.Errorf("Read() => unexpected error: %s", err)
+	})
+}
@@ -0,0 +1,223 @@
+// SPDX-FileCopyrightText: 2021-2023 Winni Neessen <wn@neessen.dev>
+//
+// SPDX-License-Identifier: MIT
+
+package zstd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/wneessen/go-parsesyslog"
+)
+
+// testVectors are the files in testdata, which have been compressed with the zstd
+// command line tool, with the SHA-256 hashes of their decompressed content
+var testVectors = []struct {
+	file string
+	desc string
+	hash string
+}{
+	{"small.zst", "single segment with raw literals", "30daabc7087e3795a7013adada6df7d1ac9f5610305570d715e2608da3f07fc5"},
+	{"logs.zst", "compressed literals and sequences without checksum", "a4e75348e2562c77aa787530addad7b3a88d3b9abb97e86040214de38d58ac55"},
+	{"stream.zst", "multiple blocks without content size", "e59c3562ab358664bbd0f140860e7e0a4af0909372e850fc6223fcc95880e30e"},
+	{"window.zst", "window size of 1 KiB", "54401a2cad0b8ec019d216b483e57f5dfacb687574d3a079528c093bf65c497f"},
+	{"raw.zst", "raw block", "4b89b187dfc228ef7d72fd8a7af5baa4ac7b2294c7c7307e1d12afad8ad86b1e"},
+	{"rle.zst", "RLE block", "4cbbd9be0cba685835755f827758705db5a413c5494c34262cd25946a73e7582"},
+}
+
+// readVector returns the content of the given file in testdata
+func readVector(t *testing.T, file string) []byte {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join("testdata", file))
+	if err != nil {
+		t.Fatalf("failed to read test vector: %s", err)
+	}
+	return b
+}
+
+// TestReader tests the Reader with the test vectors
+func TestReader(t *testing.T) {
+	for _, tt := range testVectors {
+		t.Run(tt.desc, func(t *testing.T) {
+			r, err := NewReader(bytes.NewReader(readVector(t, tt.file)))
+			if err != nil {
+				t.Fatalf("NewReader() failed: %s", err)
+			}
+			h := sha256.New()
+			if _, err := io.Copy(h, r); err != nil {
+				t.Fatalf("failed to read decompressed data: %s", err)
+			}
+			if got := hex.EncodeToString(h.Sum(nil)); got != tt.hash {
+				t.Errorf("Read() => expected content with hash: %s, got: %s", tt.hash, got)
+			}
+		})
+	}
+}
+
+// TestReader_frames tests the Reader with concatenated and skippable frames
+func TestReader_frames(t *testing.T) {
+	small := readVector(t, "small.zst")
+	r, err := NewReader(bytes.NewReader(small))
+	if err != nil {
+		t.Fatalf("NewReader() failed: %s", err)
+	}
+	want, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("failed to read decompressed data: %s", err)
+	}
+	skippable := []byte{0x5a, 0x2a, 0x4d, 0x18, 0x03, 0x00, 0x00, 0x00, 'a', 'b', 'c'}
+
+	tests := []struct {
+		name  string
+		input []byte
+		want  string
+	}{
+		{"concatenated", append(append([]byte(nil), small...), small...), string(want) + string(want)},
+		{"skippable first", append(append([]byte(nil), skippable...), small...), string(want)},
+		{"skippable between", append(append(append([]byte(nil), small...), skippable...), small...),
+			string(want) + string(want)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := NewReader(bytes.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("NewReader() failed: %s", err)
+			}
+			got, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatalf("failed to read decompressed data: %s", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Read() => expected: %q, got: %q", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestReader_errors tests that the Reader returns an error for invalid streams
+func TestReader_errors(t *testing.T) {
+	logs := readVector(t, "stream.zst")
+	corrupted := append([]byte(nil), logs...)
+	corrupted[len(corrupted)/2] ^= 0xff
+	checksum := append([]byte(nil), logs...)
+	checksum[len(checksum)-1] ^= 0xff
+	dict := []byte{0x28, 0xb5, 0x2f, 0xfd, 0x21, 0x01, 0x00, 0x01, 0x00, 0x00}
+	window := []byte{0x28, 0xb5, 0x2f, 0xfd, 0x00, 0xf8, 0x01, 0x00, 0x00}
+
+	tests := []struct {
+		name    string
+		input   []byte
+		wantErr error
+	}{
+		{"no zstd", []byte("<34>Oct 11 22:14:15 mymachine su: failed"), parsesyslog.ErrWrongFormat},
+		{"empty", nil, io.ErrUnexpectedEOF},
+		{"truncated header", logs[:5], io.ErrUnexpectedEOF},
+		{"truncated", logs[:len(logs)/2], io.ErrUnexpectedEOF},
+		{"corrupted", corrupted, parsesyslog.ErrWrongFormat},
+		{"checksum mismatch", checksum, parsesyslog.ErrWrongFormat},
+		{"dictionary", dict, parsesyslog.ErrUnsupportedCompression},
+		{"window too large", window, parsesyslog.ErrUnsupportedCompression},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := NewReader(bytes.NewReader(tt.input))
+			if err == nil {
+				_, err = io.Copy(io.Discard, r)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Read() => expected error: %v, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestReadHuffWeights tests that readHuffWeights returns an error instead of writing
+// beyond the given slice, if the Huffman weights do not fit into it
+func TestReadHuffWeights(t *testing.T) {
+	// FSE compressed Huffman weights of the first block of logs.zst
+	data, err := hex.DecodeString("504f0ac5060fbfe7b00707a961b12128209eee96ddbd69a72c308d91e9bb5003")
+	if err != nil {
+		t.Fatalf("invalid test data: %s", err)
+	}
+	n, err := readHuffWeights(make([]uint8, 256), data)
+	if err != nil {
+		t.Fatalf("readHuffWeights() failed: %s", err)
+	}
+	if n != 121 {
+		t.Fatalf("readHuffWeights() => expected 121 weights, got: %d", n)
+	}
+	for l := 0; l < n; l++ {
+		if _, err := readHuffWeights(make([]uint8, l), data); !errors.Is(err, parsesyslog.ErrWrongFormat) {
+			t.Errorf("readHuffWeights() with %d weights => expected error: %s, got: %v", l,
+				parsesyslog.ErrWrongFormat, err)
+		}
+	}
+}
+
+// TestDecompress tests that the zstd decoder is registered with parsesyslog.Decompress
+func TestDecompress(t *testing.T) {
+	r, err := parsesyslog.Decompress(bytes.NewReader(readVector(t, "small.zst")))
+	if err != nil {
+		t.Fatalf("Decompress() failed: %s", err)
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("failed to read decompressed data: %s", err)
+	}
+	if !strings.HasPrefix(string(b), "<34>") {
+		t.Errorf("Decompress() => expected a syslog message, got: %q", b)
+	}
+}
+
+// TestXXHash64 tests the XXH64 implementation with known hashes
+func TestXXHash64(t *testing.T) {
+	tests := []struct {
+		input string
+		want  uint64
+	}{
+		{"", 0xef46db3751d8e999},
+		{"a", 0xd24ec4f1a98c6e5b},
+		{"abc", 0x44bc2cf5ad770999},
+		{"Nobody inspects the spammish repetition", 0xfbcea83c8a378bf1},
+	}
+	for _, tt := range tests {
+		var x xxhash64
+		x.Reset()
+		for i := 0; i < len(tt.input); i += 7 {
+			e := i + 7
+			if e > len(tt.input) {
+				e = len(tt.input)
+			}
+			_, _ = x.Write([]byte(tt.input[i:e]))
+		}
+		if got := x.Sum64(); got != tt.want {
+			t.Errorf("Sum64(%q) => expected: %x, got: %x", tt.input, tt.want, got)
+		}
+	}
+}
+
+// BenchmarkReader benchmarks the Reader
+func BenchmarkReader(b *testing.B) {
+	data, err := os.ReadFile(filepath.Join("testdata", "stream.zst"))
+	if err != nil {
+		b.Fatalf("failed to read test vector: %s", err)
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		r, err := NewReader(bytes.NewReader(data))
+		if err != nil {
+			b.Fatalf("NewReader() failed: %s", err)
+		}
+		if _, err := io.Copy(io.Discard, r); err != nil {
+			b.Fatalf("failed to read decompressed data: %s", err)
+		}
+	}
+}